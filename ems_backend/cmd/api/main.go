@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +18,8 @@ import (
 	app_services "ems_backend/internal/application/services"
 	audit_log_services "ems_backend/internal/domain/audit_log/services"
 	auth_services "ems_backend/internal/domain/auth/services"
+	company_services "ems_backend/internal/domain/company/services"
+	member_history_entities "ems_backend/internal/domain/member_history/entities"
 	memberRoleDomainService "ems_backend/internal/domain/member_role/services"
 	menu_services "ems_backend/internal/domain/menu/services"
 	meter_services "ems_backend/internal/domain/meter/services"
@@ -21,10 +27,17 @@ import (
 	role_services "ems_backend/internal/domain/role/services"
 	temperature_services "ems_backend/internal/domain/temperature/services"
 	companyDeviceRepoInterface "ems_backend/internal/domain/company_device/repositories"
+	"ems_backend/internal/infrastructure/archive"
 	"ems_backend/internal/infrastructure/cache"
+	"ems_backend/internal/infrastructure/config"
+	"ems_backend/internal/infrastructure/mail"
 	"ems_backend/internal/infrastructure/messaging"
+	"ems_backend/internal/infrastructure/metrics"
 	msg_handlers "ems_backend/internal/infrastructure/messaging/handlers"
 	"ems_backend/internal/infrastructure/mqtt"
+	"ems_backend/internal/infrastructure/oidc"
+	"ems_backend/internal/infrastructure/ratelimit"
+	"ems_backend/internal/infrastructure/persistence/migrations"
 	repositories "ems_backend/internal/infrastructure/persistence/repositories"
 	api_handlers "ems_backend/internal/interface/api/handlers"
 	"ems_backend/internal/interface/api/middleware"
@@ -44,31 +57,69 @@ func main() {
 		log.Println("[ENV] Loaded .env file")
 	}
 
-	// 設置環境變量 (設定預設值)
-	setupEnvironment()
+	// 載入設定：預設值 → CONFIG_FILE (預設 config.yaml) → 環境變數
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	if cfg.File != "" {
+		log.Printf("[Config] Loaded %s (env: %s)", cfg.File, cfg.Env)
+	} else {
+		log.Printf("[Config] No config file, using defaults and environment variables (env: %s)", cfg.Env)
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Printf("[Config] Warning: %s", warning)
+	}
+	gin.SetMode(cfg.Server.GinMode)
+
+	// 資料庫 migration 子命令：執行後結束，不啟動 API 服務
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:], cfg.Database); err != nil {
+			log.Fatal("[Migrate] ", err)
+		}
+		return
+	}
 
 	// 初始化數據庫
-	db, err := initDatabase()
+	db, err := initDatabase(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// 角色權限與成員角色快取：本實例異動立即失效，其他實例經由 cache_invalidations 同步
+	permissionCache := cache.NewPermissionCache(cfg.PermissionCache.TTL, cache.NewPostgresInvalidationBus(db))
+
 	// 初始化 Repository
 	memberRepo := repositories.NewMemberRepository(db)
 	authRepo := repositories.NewAuthRepository(db)
 	memberHistoryRepo := repositories.NewMemberHistoryRepository(db)
-	memberRoleRepo := repositories.NewMemberRoleRepository(db)
+	memberRoleRepo := cache.NewCachedMemberRoleRepository(repositories.NewMemberRoleRepository(db), permissionCache)
 	menuRepo := repositories.NewMenuRepository(db)
 	temperatureRepo := repositories.NewTemperatureRepository(db)
 	meterRepo := repositories.NewMeterRepository(db)
 	companyRepo := repositories.NewCompanyRepository(db)
 	companyMemberRepo := repositories.NewCompanyMemberRepository(db)
 	companyDeviceRepo := repositories.NewCompanyDeviceRepository(db)
-	powerRepo := repositories.NewPowerRepository(db)
-	roleRepo := repositories.NewRoleRepository(db)
+	powerRepo := cache.NewCachedPowerRepository(repositories.NewPowerRepository(db), permissionCache)
+	roleRepo := cache.NewCachedRoleRepository(repositories.NewRoleRepository(db), permissionCache)
 	auditLogRepo := repositories.NewAuditLogRepository(db)
 	deviceRepo := repositories.NewDeviceRepository(db)
 	scheduleRepo := repositories.NewScheduleRepository(db)
+	scheduleDriftRepo := repositories.NewScheduleDriftRepository(db)
+	scheduleVersionRepo := repositories.NewScheduleVersionRepository(db)
+	deviceCommandRepo := repositories.NewDeviceCommandRepository(db)
+	comfortSettingRepo := repositories.NewComfortSettingRepository(db)
+	demandControlRepo := repositories.NewDemandControlRepository(db)
+	firmwareRepo := repositories.NewFirmwareRepository(db)
+	firmwareCampaignRepo := repositories.NewFirmwareCampaignRepository(db)
+	claimCodeRepo := repositories.NewClaimCodeRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
+	mfaRepo := repositories.NewMFARepository(db)
+	serviceAccountRepo := repositories.NewServiceAccountRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	oidcStateRepo := repositories.NewOIDCStateRepository(db)
+	oidcIdentityRepo := repositories.NewOIDCIdentityRepository(db)
 
 	// 初始化設備快取
 	deviceCache := cache.NewDeviceCache(companyDeviceRepo)
@@ -77,41 +128,122 @@ func main() {
 	}
 
 	// 初始化 Domain Service
-	jwtAccessSecret := os.Getenv("JWT_ACCESS_SECRET")
-	jwtRefreshSecret := os.Getenv("JWT_REFRESH_SECRET")
-	authService := auth_services.NewAuthService(memberRepo, memberHistoryRepo, authRepo, memberRoleRepo, jwtAccessSecret, jwtRefreshSecret)
+	authService := auth_services.NewAuthService(memberRepo, memberHistoryRepo, authRepo, memberRoleRepo, cfg.JWT.AccessSecret, cfg.JWT.RefreshSecret)
+	authService.SetTokenTTL(cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
+	authService.SetLoginThrottle(auth_services.NewLoginThrottleService(loginAttemptRepo, loginThrottleConfig(cfg.Login)))
+	mfaService, err := auth_services.NewMFAService(mfaRepo, memberRepo, roleRepo, auth_services.MFAConfig{
+		Issuer:        cfg.MFA.Issuer,
+		ChallengeTTL:  cfg.MFA.ChallengeTTL,
+		EncryptionKey: cfg.MFA.EncryptionKey,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize MFA service:", err)
+	}
+	authService.SetMFAService(mfaService)
+	passwordPolicyConfig, err := loadPasswordPolicyConfig(cfg.Password)
+	if err != nil {
+		log.Fatal("Invalid password policy configuration:", err)
+	}
+	passwordPolicy, err := auth_services.NewPasswordPolicyService(memberHistoryRepo, passwordPolicyConfig)
+	if err != nil {
+		log.Fatal("Invalid password policy configuration:", err)
+	}
+	authService.SetPasswordPolicy(passwordPolicy) // 變更密碼、有效期限與 argon2 參數升級
+	sessionService := auth_services.NewSessionService(authRepo, auth_services.SessionConfig{AccessTokenTTL: cfg.JWT.AccessExpiry})
+	if _, err := sessionService.Sync(); err != nil {
+		log.Fatal("Failed to load revoked sessions:", err)
+	}
+	authService.SetSessionService(sessionService) // 登出、撤銷或停用後 access token 立即失效
+	apiKeyService := auth_services.NewAPIKeyService(serviceAccountRepo, apiKeyRepo, roleRepo, auth_services.APIKeyConfig{
+		MaxTTL: cfg.APIKey.MaxTTL,
+	})
+	authService.SetAPIKeyService(apiKeyService) // 服務帳號以 X-API-Key 呼叫 API
+	oidcService := auth_services.NewOIDCService(oidcStateRepo, oidcIdentityRepo, memberRepo, roleRepo, companyMemberRepo, auth_services.OIDCConfig{
+		StateTTL: cfg.OIDC.StateTTL,
+	})
+	if err := registerOIDCProviders(oidcService, cfg.OIDC.ProvidersFile); err != nil {
+		log.Fatal("Invalid OIDC provider configuration:", err)
+	}
+	authService.SetOIDCService(oidcService) // 以外部身分提供者單一登入
 	menuService := menu_services.NewMenuService(menuRepo)
 	memberRoleDomainService := memberRoleDomainService.NewMemberRoleService(memberRoleRepo)
 	powerService := power_services.NewPowerService(powerRepo)
 	roleService := role_services.NewRoleService(roleRepo, powerRepo)
+	companyAccessService := company_services.NewCompanyAccessService(roleRepo, companyRepo) // 依角色的公司範圍判斷公司存取權
 	auditLogService := audit_log_services.NewAuditLogService(auditLogRepo)
+	if cfg.Audit.ChainKey != "" {
+		auditLogService.SetChainKey([]byte(cfg.Audit.ChainKey))
+	}
+	auditArchiveStore, err := initAuditArchiveStore(cfg.Audit)
+	if err != nil {
+		log.Fatal("Invalid audit archive configuration:", err)
+	}
+	auditLogService.SetChainHeadStore(auditArchiveStore) // 鏈尾記錄在資料庫以外，偵測尾端記錄被刪除
 	temperatureDomainService := temperature_services.NewTemperatureService(temperatureRepo)
 	meterDomainService := meter_services.NewMeterService(meterRepo)
+	mailSender, err := initMailSender(cfg.Mail)
+	if err != nil {
+		log.Fatal("Failed to initialize mail sender:", err)
+	}
+	passwordResetService := auth_services.NewPasswordResetService(memberRepo, memberHistoryRepo, authRepo, passwordResetRepo, mailSender, auth_services.PasswordResetConfig{
+		TokenTTL: cfg.Password.ResetTTL,
+		ResetURL: cfg.Password.ResetURL,
+	})
+	passwordResetService.SetPasswordPolicy(passwordPolicy)
 
 	// 初始化 Application Service
 	authAppService := app_services.NewAuthApplicationService(authService)
+	authAppService.SetPasswordResetService(passwordResetService)       // 忘記密碼 / 重設密碼
+	authAppService.SetAuditLogService(auditLogService, memberRoleRepo) // 登入、刷新 token、登出、鎖定與解鎖稽核
+	authAppService.SetMFAService(mfaService)                           // 自助設定 TOTP
+	authAppService.SetSessionService(sessionService)                   // 查詢與撤銷登入中的會話
+	authAppService.SetOIDCService(oidcService)                         // 單一登入身分提供者與登入網址
 	menuAppService := app_services.NewMenuApplicationService(menuService)
 	roleAppService := app_services.NewRoleApplicationService(roleService)
 	powerAppService := app_services.NewPowerApplicationService(powerService)
 	auditLogAppService := app_services.NewAuditLogApplicationService(auditLogService)
+	auditRetentionService, err := initAuditRetention(auditLogRepo, auditArchiveStore, cfg.Audit.Retention)
+	if err != nil {
+		log.Fatal("Invalid audit retention configuration:", err)
+	}
+	if auditRetentionService != nil {
+		auditLogAppService.SetRetentionService(auditRetentionService)
+	}
 	memberAppService := app_services.NewMemberApplicationService(memberRepo, memberRoleRepo, memberHistoryRepo, roleService)
+	memberAppService.SetAuthService(authService)       // 管理員解除登入鎖定
+	memberAppService.SetMFAService(mfaService)         // 管理員重設 MFA
+	memberAppService.SetSessionService(sessionService) // 停用成員時撤銷會話
+	memberAppService.SetPasswordPolicy(passwordPolicy) // 建立 / 更新成員密碼
 	deviceAppService := app_services.NewDeviceApplicationService(deviceRepo)
+	deviceAppService.SetClaimCodeRepository(claimCodeRepo) // 批次建檔與一次性認領碼
+	deviceAppService.SetAuditLogService(auditLogService)   // 記錄認領碼自動過期
 	companyAppService := app_services.NewCompanyApplicationService(
 		companyRepo, companyMemberRepo, companyDeviceRepo, deviceRepo,
-		memberRepo, memberHistoryRepo, roleRepo, roleService,
+		memberRepo, memberHistoryRepo, companyAccessService, roleService,
 	)
+	companyAppService.SetClaimCodeRepository(claimCodeRepo) // 公司管理者以認領碼綁定設備
+	companyAppService.SetPasswordPolicy(passwordPolicy)     // 建立公司管理員 / 用戶密碼
+	serviceAccountAppService := app_services.NewServiceAccountApplicationService(apiKeyService, companyRepo)
 	temperatureAppService := app_services.NewTemperatureApplicationService(temperatureDomainService)
 	meterAppService := app_services.NewMeterApplicationService(meterDomainService)
-	dashboardAppService := app_services.NewDashboardApplicationService(companyRepo, companyDeviceRepo, meterRepo)
-	dashboardTempService := app_services.NewDashboardTemperatureService(companyRepo, companyDeviceRepo, temperatureRepo)
-	dashboardAreaService := app_services.NewDashboardAreaService(companyRepo, companyDeviceRepo, meterRepo, temperatureRepo)
+	dashboardAppService := app_services.NewDashboardApplicationService(companyRepo, companyAccessService, companyDeviceRepo, meterRepo)
+	dashboardTempService := app_services.NewDashboardTemperatureService(companyRepo, companyAccessService, companyDeviceRepo, temperatureRepo)
+	dashboardAreaService := app_services.NewDashboardAreaService(companyRepo, companyAccessService, companyDeviceRepo, meterRepo, temperatureRepo)
 	scheduleAppService := app_services.NewScheduleApplicationService(scheduleRepo, companyDeviceRepo)
-	scheduleAppService.SetDeviceRepository(deviceRepo) // 設置設備倉儲以獲取設備 SN
+	scheduleAppService.SetDeviceRepository(deviceRepo)           // 設置設備倉儲以獲取設備 SN
+	scheduleAppService.SetDriftRepository(scheduleDriftRepo)     // 啟用設備排程漂移偵測
+	scheduleAppService.SetVersionRepository(scheduleVersionRepo) // 保存排程歷史版本
+	deviceCommandAppService := app_services.NewDeviceCommandApplicationService(deviceCommandRepo, companyDeviceRepo, deviceRepo)
+	comfortControlAppService := app_services.NewComfortControlApplicationService(comfortSettingRepo, companyDeviceRepo, temperatureRepo, deviceCommandAppService)
+	comfortControlAppService.SetForceDryRun(cfg.Jobs.ComfortControlDryRun)
+	demandControlAppService := app_services.NewDemandControlApplicationService(demandControlRepo, companyDeviceRepo, meterRepo, deviceCommandAppService)
+	comfortControlAppService.SetLoadLock(demandControlAppService) // 需量卸載中的負載不由舒適度控制重新開機
+	firmwareAppService := app_services.NewFirmwareApplicationService(firmwareRepo, firmwareCampaignRepo, deviceRepo)
 
 	// 初始化 MQTT 客戶端 (可選)
 	var mqttClient *mqtt.Client
-	if os.Getenv("ENABLE_MQTT") == "true" {
-		mqttClient, err = initMQTTClient()
+	if cfg.MQTT.Enabled {
+		mqttClient, err = initMQTTClient(cfg.MQTT)
 		if err != nil {
 			log.Printf("[MQTT] Failed to initialize MQTT client: %v", err)
 		} else {
@@ -119,14 +251,20 @@ func main() {
 			schedulePublisher := mqtt.NewSchedulePublisher(mqttClient)
 			scheduleAppService.SetMQTTPublisher(schedulePublisher)
 			log.Println("[MQTT] MQTT publisher configured for schedule service")
+			commandPublisher := mqtt.NewCommandPublisher(mqttClient)
+			deviceCommandAppService.SetPublisher(commandPublisher)
+			firmwareAppService.SetPublisher(commandPublisher)
 
 			// Start device response handler to receive and process device responses
 			deviceResponseHandler := mqtt.NewDeviceResponseHandler(mqttClient, companyDeviceRepo, deviceRepo)
-			deviceResponseHandler.SetScheduleRepository(scheduleRepo) // Enable saving schedule from device
+			deviceResponseHandler.SetScheduleRepository(scheduleRepo)       // Enable saving schedule from device
+			deviceResponseHandler.SetScheduleReconciler(scheduleAppService) // Diff device schedule against cloud
+			deviceResponseHandler.SetCommandAckHandler(deviceCommandAppService)
+			deviceResponseHandler.SetFirmwareReportHandler(firmwareAppService) // 記錄韌體版本與 OTA 進度
 			if err := deviceResponseHandler.Start(); err != nil {
 				log.Printf("[MQTT] Failed to start device response handler: %v", err)
 			} else {
-				log.Println("[MQTT] Device response handler started, listening on ac/return/+")
+				log.Printf("[MQTT] Device response handler started, listening on %s", mqttClient.Topics().ReturnWildcard())
 			}
 		}
 	}
@@ -142,16 +280,49 @@ func main() {
 	deviceHandler := api_handlers.NewDeviceHandler(deviceAppService)
 	companyHandler := api_handlers.NewCompanyHandler(companyAppService, scheduleAppService)
 	scheduleHandler := api_handlers.NewScheduleHandler(scheduleAppService)
+	deviceCommandHandler := api_handlers.NewDeviceCommandHandler(deviceCommandAppService)
+	comfortControlHandler := api_handlers.NewComfortControlHandler(comfortControlAppService)
+	demandControlHandler := api_handlers.NewDemandControlHandler(demandControlAppService)
+	firmwareHandler := api_handlers.NewFirmwareHandler(firmwareAppService)
 	sseHandler := api_handlers.NewSSEHandler()
 	wsHandler := api_handlers.NewWebSocketHandler()
+	serviceAccountHandler := api_handlers.NewServiceAccountHandler(serviceAccountAppService)
 
 	// 初始化 Middleware
 	permissionMw := middleware.NewPermissionMiddleware(powerService)
 	auditMw := middleware.NewAuditMiddleware(auditLogService)
+	// 更新與刪除時記錄資源異動前後的快照
+	auditMw.RegisterSnapshot("ROLE", func(id uint) (interface{}, error) { return roleAppService.GetByID(id) })
+	auditMw.RegisterSnapshot("POWER", func(id uint) (interface{}, error) { return powerAppService.GetByID(id) })
+	auditMw.RegisterSnapshot("DEVICE", func(id uint) (interface{}, error) { return deviceAppService.GetDeviceByID(id) })
+	auditMw.RegisterSnapshot("COMPANY", func(id uint) (interface{}, error) { return companyRepo.FindByID(id) })
+	auditMw.RegisterSnapshot("SCHEDULE", func(id uint) (interface{}, error) { return scheduleAppService.GetByCompanyDeviceID(id) })
+	auditMw.RegisterSnapshot("MEMBER", func(id uint) (interface{}, error) {
+		response, err := memberAppService.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return response.Data, nil
+	})
+	auditMw.RegisterSnapshot("MENU", func(id uint) (interface{}, error) { return menuAppService.GetByID(id) })
+	companyAccessMw := middleware.NewCompanyAccessMiddleware(companyAccessService, companyDeviceRepo)
+	rateLimiter, err := initRateLimiter(db, cfg.RateLimit)
+	if err != nil {
+		log.Fatal("Invalid rate limit configuration:", err)
+	}
+	rateLimitMw := middleware.NewRateLimitMiddleware(rateLimiter)
 
 	// 設置 Gin 路由
 	ginRouter := gin.Default()
 
+	// 限流以 ClientIP 識別未登入的呼叫者，只信任 TRUSTED_PROXIES 帶入的 X-Forwarded-For
+	if err := ginRouter.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	// Prometheus 指標 (設定 METRICS_TOKEN 時需 Bearer token)
+	ginRouter.GET("/metrics", gin.WrapH(metrics.Default.Handler(cfg.Server.MetricsToken)))
+
 	// WebSocket 路由 - 在 CORS 之前設置，避免 CORS 阻擋 WebSocket 升級請求
 	wsGroup := ginRouter.Group("/ws", middleware.SSEAuthMiddleware(authService, memberRoleDomainService))
 	{
@@ -160,9 +331,9 @@ func main() {
 
 	// 設置 CORS 中間件 (僅適用於後續路由)
 	ginRouter.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "http://127.0.0.1:5173", "http://127.0.0.1:3000", "https://kaiems.com"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Role-ID"},
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "If-Match", "Accept", "Authorization", "X-Role-ID", "X-API-Key"},
 		AllowCredentials: true,
 	}))
 
@@ -179,24 +350,67 @@ func main() {
 		deviceHandler,
 		companyHandler,
 		scheduleHandler,
+		deviceCommandHandler,
+		comfortControlHandler,
+		demandControlHandler,
+		firmwareHandler,
 		sseHandler,
 		wsHandler,
+		serviceAccountHandler,
 		authService,
 		memberRoleDomainService,
 		permissionMw,
 		auditMw,
+		companyAccessMw,
+		rateLimitMw,
 	)
 
 	// 初始化 SQS 消息队列监听 (可选功能)
 	ctx := context.Background()
-	queueManager := initQueueListeners(ctx, db, temperatureAppService, meterAppService, companyDeviceRepo, deviceCache)
+	queueManager := initQueueListeners(ctx, cfg.SQS, temperatureAppService, meterAppService, companyDeviceRepo, deviceCache)
 
-	// 啟動服務器
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// 排程漂移輪詢、舒適度與需量控制、韌體發布 (需要 MQTT)
+	pollCtx, stopPolling := context.WithCancel(ctx)
+
+	// 認領碼過期處理
+	go deviceAppService.StartClaimCodeExpiryLoop(pollCtx, cfg.Jobs.ClaimCodeExpiryInterval)
+
+	// 登入鎖定到期自動解鎖
+	go authAppService.StartLockoutExpiryLoop(pollCtx, cfg.Login.LockoutSweepInterval)
+
+	// 同步其他實例的權限快取失效事件
+	go permissionCache.StartSyncLoop(pollCtx, cfg.PermissionCache.SyncInterval)
+
+	// 歸檔並刪除超過保留期限的審計日誌
+	go auditLogAppService.StartRetentionLoop(pollCtx, cfg.Audit.RetentionInterval)
+
+	// 將雜湊鏈的鏈尾記錄到資料庫以外
+	go auditLogAppService.StartChainHeadLoop(pollCtx, cfg.Audit.ChainHeadInterval)
+
+	// 清除閒置的限流 bucket
+	if rateLimiter != nil {
+		go rateLimiter.StartPurgeLoop(pollCtx, cfg.RateLimit.PurgeInterval)
 	}
 
+	// 同步其他實例撤銷的會話並清除過期會話紀錄
+	go authAppService.StartSessionSyncLoop(pollCtx, cfg.Session.RevocationSyncInterval, cfg.Session.PurgeInterval)
+
+	if mqttClient != nil {
+		go scheduleAppService.StartDriftPolling(pollCtx, cfg.Jobs.ScheduleDriftPollInterval)
+
+		// 區域舒適度閉環控制
+		go comfortControlAppService.StartControlLoop(pollCtx, cfg.Jobs.ComfortControlInterval)
+
+		// 契約容量需量控制
+		go demandControlAppService.StartControlLoop(pollCtx, cfg.Jobs.DemandControlInterval)
+
+		// 韌體 OTA 分批發布
+		go firmwareAppService.StartCampaignLoop(pollCtx, cfg.Jobs.FirmwareCampaignInterval)
+	}
+
+	// 啟動服務器
+	port := cfg.Server.Port
+
 	// 创建信号通道用于优雅关闭
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -213,6 +427,9 @@ func main() {
 	<-sigChan
 	log.Println("Shutting down gracefully...")
 
+	// 停止排程漂移輪詢、舒適度與需量控制
+	stopPolling()
+
 	// 停止队列监听
 	if queueManager != nil {
 		queueManager.StopAll()
@@ -226,111 +443,291 @@ func main() {
 	log.Println("Server stopped")
 }
 
-// setupEnvironment 设置环境变量
-func setupEnvironment() {
-	// 数据库配置
-	if os.Getenv("DB_HOST") == "" {
-		os.Setenv("DB_HOST", "220.132.191.5")
+// loginThrottleConfig 將登入設定轉換為登入節流與鎖定設定
+func loginThrottleConfig(cfg config.LoginConfig) auth_services.LoginThrottleConfig {
+	return auth_services.LoginThrottleConfig{
+		FreeAttempts:     cfg.FreeAttempts,
+		IPFreeAttempts:   cfg.IPFreeAttempts,
+		BaseDelay:        cfg.BaseDelay,
+		MaxDelay:         cfg.MaxDelay,
+		Window:           cfg.FailureWindow,
+		LockoutThreshold: cfg.LockoutThreshold,
+		LockoutDuration:  cfg.LockoutDuration,
 	}
-	if os.Getenv("DB_PORT") == "" {
-		os.Setenv("DB_PORT", "9432")
+}
+
+// loadPasswordPolicyConfig 將密碼設定轉換為密碼政策，並讀取額外的常見密碼清單
+func loadPasswordPolicyConfig(cfg config.PasswordConfig) (auth_services.PasswordPolicyConfig, error) {
+	policy := auth_services.PasswordPolicyConfig{
+		MinLength:     cfg.MinLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+		MaxAge:        cfg.MaxAge,
+		HistoryCount:  cfg.HistoryCount,
+		Argon2: member_history_entities.Argon2Params{
+			Time:      cfg.Argon2Time,
+			Memory:    cfg.Argon2MemoryKB,
+			Threads:   cfg.Argon2Threads,
+			KeyLength: member_history_entities.DefaultArgon2Params.KeyLength,
+		},
+	}
+
+	if cfg.CommonListFile != "" {
+		data, err := os.ReadFile(cfg.CommonListFile)
+		if err != nil {
+			return policy, fmt.Errorf("failed to read password.common_list_file: %w", err)
+		}
+		policy.CommonPasswords = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
 	}
-	if os.Getenv("DB_USER") == "" {
-		os.Setenv("DB_USER", "ems_user")
+	return policy, nil
+}
+
+// registerOIDCProviders 讀取身分提供者設定檔並註冊身分提供者，未設定時停用單一登入
+func registerOIDCProviders(oidcService *auth_services.OIDCService, path string) error {
+	if path == "" {
+		return nil
 	}
-	if os.Getenv("DB_CODE") == "" {
-		os.Setenv("DB_CODE", "ji394@ems_user")
+	providers, err := oidc.LoadProviders(path)
+	if err != nil {
+		return err
 	}
-	if os.Getenv("DB_NAME") == "" {
-		os.Setenv("DB_NAME", "ems")
+	toMappings := func(mappings []oidc.ClaimMapping) []auth_services.OIDCClaimMapping {
+		result := make([]auth_services.OIDCClaimMapping, len(mappings))
+		for i, mapping := range mappings {
+			result[i] = auth_services.OIDCClaimMapping(mapping)
+		}
+		return result
 	}
-
-	// 服务器配置
-	if os.Getenv("PORT") == "" {
-		os.Setenv("PORT", "8080")
+	for _, provider := range providers {
+		client, err := oidc.NewClient(provider.ClientConfig())
+		if err != nil {
+			return fmt.Errorf("identity provider %q: %w", provider.ID, err)
+		}
+		if err := oidcService.RegisterProvider(auth_services.OIDCProviderConfig{
+			ID:              provider.ID,
+			Name:            provider.Name,
+			AutoProvision:   provider.AutoProvision,
+			LinkByEmail:     provider.LinkByEmail,
+			AllowedDomains:  provider.AllowedDomains,
+			DefaultRoleIDs:  provider.DefaultRoleIDs,
+			RoleMappings:    toMappings(provider.RoleMappings),
+			CompanyMappings: toMappings(provider.CompanyMappings),
+			SyncRoles:       provider.SyncRoles,
+			SyncCompanies:   provider.SyncCompanies,
+		}, client); err != nil {
+			return err
+		}
+		log.Printf("[SSO] Identity provider %s registered (issuer %s)", provider.ID, provider.Issuer)
 	}
+	return nil
+}
 
-	// JWT 配置
-	if os.Getenv("JWT_ACCESS_SECRET") == "" {
-		os.Setenv("JWT_ACCESS_SECRET", "default-access-secret-change-in-production")
+// initMailSender 依 mail.driver 建立郵件發送器
+func initMailSender(cfg config.MailConfig) (auth_services.MailSender, error) {
+	switch cfg.Driver {
+	case "log":
+		return mail.NewLogSender(), nil
+	case "smtp":
+		return mail.NewSMTPSender(mail.SMTPConfig{
+			Host:        cfg.SMTP.Host,
+			Port:        cfg.SMTP.Port,
+			Username:    cfg.SMTP.Username,
+			Password:    cfg.SMTP.Password,
+			From:        cfg.SMTP.From,
+			ImplicitTLS: cfg.SMTP.ImplicitTLS,
+		})
+	}
+	return nil, fmt.Errorf("unknown mail driver %q (log or smtp)", cfg.Driver)
+}
+
+// initAuditArchiveStore 依 audit.archive_driver 建立審計日誌歸檔儲存
+func initAuditArchiveStore(cfg config.AuditConfig) (*archive.LocalStore, error) {
+	switch cfg.ArchiveDriver {
+	case "local":
+		return archive.NewLocalStore(cfg.ArchiveDir)
+	default:
+		return nil, fmt.Errorf("unknown audit archive driver %q (local)", cfg.ArchiveDriver)
 	}
-	if os.Getenv("JWT_REFRESH_SECRET") == "" {
-		os.Setenv("JWT_REFRESH_SECRET", "default-refresh-secret-change-in-production")
+}
+
+// initAuditRetention 建立審計日誌保留服務，永久保留 (retention 為 0) 時返回 nil
+func initAuditRetention(auditLogRepo *repositories.AuditLogRepository, store audit_log_services.ArchiveStore, retention time.Duration) (*audit_log_services.AuditRetentionService, error) {
+	if retention == 0 {
+		log.Println("[AuditLog] Retention disabled, audit logs are kept forever")
+		return nil, nil
 	}
-	if os.Getenv("JWT_ACCESS_EXPIRY") == "" {
-		os.Setenv("JWT_ACCESS_EXPIRY", "24h") // 24 hours
+
+	return audit_log_services.NewAuditRetentionService(auditLogRepo, store, audit_log_services.AuditRetentionConfig{Retention: retention})
+}
+
+// initRateLimiter 依限流政策與儲存方式建立限流器，停用時返回 nil
+func initRateLimiter(db *gorm.DB, cfg config.RateLimitConfig) (*ratelimit.Limiter, error) {
+	if cfg.Policies == "none" {
+		log.Println("[RateLimit] Disabled")
+		return nil, nil
 	}
-	if os.Getenv("JWT_REFRESH_EXPIRY") == "" {
-		os.Setenv("JWT_REFRESH_EXPIRY", "168h") // 7 days
+	policies, err := ratelimit.ParsePolicies(cfg.Policies)
+	if err != nil {
+		return nil, err
 	}
 
-	// 设置 Gin 模式
-	if os.Getenv("GIN_MODE") == "" {
-		os.Setenv("GIN_MODE", "release")
+	var store ratelimit.Store
+	switch cfg.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = ratelimit.NewPostgresStore(db)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q (memory or postgres)", cfg.Store)
 	}
+	return ratelimit.NewLimiter(store, policies), nil
+}
 
-	// SQS 队列配置
-	if os.Getenv("ENABLE_SQS") == "" {
-		os.Setenv("ENABLE_SQS", "true") // 默认启用 SQS 监听
+// initDatabase 初始化數據庫連接
+func initDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
+	if err != nil {
+		return nil, err
 	}
-	if os.Getenv("AWS_REGION") == "" {
-		os.Setenv("AWS_REGION", "ap-southeast-2")
+
+	// 啟動時套用 migration (DB_AUTO_MIGRATE)：多個實例同時啟動時以 advisory lock 排隊，只有第一個會實際套用
+	if cfg.AutoMigrate {
+		migrator, err := newMigrator(db)
+		if err != nil {
+			return nil, err
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		log.Printf("[Migrate] Schema up to date (%d migration(s) applied)", len(applied))
 	}
 
-	// MQTT 配置 (AWS IoT Core)
-	// MQTT_ENDPOINT: AWS IoT Core endpoint (e.g., "xxxxx.iot.ap-northeast-1.amazonaws.com:8883")
-	// MQTT_CA_CERT: Path to AmazonRootCA1.pem
-	// MQTT_CLIENT_CERT: Path to xxx-certificate.pem.crt
-	// MQTT_PRIVATE_KEY: Path to xxx-private.pem.key
-	if os.Getenv("ENABLE_MQTT") == "" {
-		os.Setenv("ENABLE_MQTT", "false") // 默認不啟用 MQTT
+	return db, nil
+}
+
+// newMigrator 以編譯進執行檔的 migration 建立 Migrator
+func newMigrator(db *gorm.DB) (*migrations.Migrator, error) {
+	embedded, err := migrations.Embedded()
+	if err != nil {
+		return nil, err
 	}
+	return migrations.NewMigrator(db, embedded)
 }
 
-// initDatabase 初始化數據庫連接
-func initDatabase() (*gorm.DB, error) {
-	sslMode := os.Getenv("DB_SSLMODE")
-	if sslMode == "" {
-		sslMode = "require" // 預設使用 SSL (RDS 需要)
+const migrateUsage = "usage: migrate up | down [steps] | status | baseline <version> | create <name>"
+
+// runMigrateCommand 執行 migrate 子命令
+//
+//	up                 套用所有尚未套用的 migration
+//	down [steps]       回復最新的 steps 個 migration (預設 1)，基準版本 (0001–0009) 不可回復
+//	status             列出每個版本的套用狀態，基準版本標記為 irreversible baseline
+//	baseline <version> 將 version (含) 以前的版本標記為已套用但不執行 (既有資料庫改用 migration 時使用)
+//	create <name>      在 MIGRATIONS_DIR (預設 internal/infrastructure/persistence/migrations) 產生下一個版本的檔案
+func runMigrateCommand(args []string, cfg config.DatabaseConfig) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
 	}
 
-	dsn := "host=" + os.Getenv("DB_HOST") +
-		" port=" + os.Getenv("DB_PORT") +
-		" user=" + os.Getenv("DB_USER") +
-		" password=" + os.Getenv("DB_CODE") +
-		" dbname=" + os.Getenv("DB_NAME") +
-		" sslmode=" + sslMode + " TimeZone=UTC"
+	// create 只產生檔案，不需要連線資料庫
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		dir := os.Getenv("MIGRATIONS_DIR")
+		if dir == "" {
+			dir = migrations.DefaultDir
+		}
+		paths, err := migrations.Create(dir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			log.Printf("[Migrate] Created %s", path)
+		}
+		return nil
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
 	if err != nil {
-		return nil, err
+		return err
+	}
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
 	}
+	ctx := context.Background()
 
-	// 自動遷移數據庫表
-	if err := db.AutoMigrate(); err != nil {
-		return nil, err
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		applied, err := migrator.Up(ctx)
+		log.Printf("[Migrate] %d migration(s) applied", len(applied))
+		return err
+
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps: %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		log.Printf("[Migrate] %d migration(s) reverted", len(reverted))
+		return err
+
+	case args[0] == "baseline" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %q", args[1])
+		}
+		marked, err := migrator.Baseline(ctx, version)
+		if err != nil {
+			return err
+		}
+		log.Printf("[Migrate] %d migration(s) marked as applied", len(marked))
+		return nil
+
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tNOTE")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			note := ""
+			if status.Missing {
+				note = "missing from binary"
+			} else if status.Baseline {
+				note = "irreversible baseline"
+			} else if !status.Reversible {
+				note = "irreversible"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, appliedAt, note)
+		}
+		return w.Flush()
 	}
 
-	return db, nil
+	return errors.New(migrateUsage)
 }
 
 // initQueueListeners 初始化队列监听器 (可选)
 // 如果不需要队列监听，可以注释掉这个函数的调用
-func initQueueListeners(ctx context.Context, db *gorm.DB, temperatureAppService *app_services.TemperatureApplicationService, meterAppService *app_services.MeterApplicationService, companyDeviceRepo companyDeviceRepoInterface.CompanyDeviceRepository, deviceCache *cache.DeviceCache) *messaging.QueueManager {
+func initQueueListeners(ctx context.Context, cfg config.SQSConfig, temperatureAppService *app_services.TemperatureApplicationService, meterAppService *app_services.MeterApplicationService, companyDeviceRepo companyDeviceRepoInterface.CompanyDeviceRepository, deviceCache *cache.DeviceCache) *messaging.QueueManager {
 	// 检查是否启用队列监听
-	if os.Getenv("ENABLE_SQS") != "true" {
+	if !cfg.Enabled {
 		log.Println("[SQS] Queue listeners are disabled. Set ENABLE_SQS=true to enable.")
 		return nil
 	}
 
-	// 获取AWS区域
-	awsRegion := os.Getenv("AWS_REGION")
-	if awsRegion == "" {
-		awsRegion = "ap-southeast-2" // 默认区域
-	}
-
 	// 创建SQS客户端
-	sqsClient, err := messaging.NewSQSClient(ctx, awsRegion)
+	sqsClient, err := messaging.NewSQSClient(ctx, cfg.Region)
 	if err != nil {
 		log.Printf("[SQS] Failed to create SQS client: %v", err)
 		return nil
@@ -387,32 +784,29 @@ func initQueueListeners(ctx context.Context, db *gorm.DB, temperatureAppService
 	return queueManager
 }
 
-// initMQTTClient 初始化 MQTT 客戶端 (AWS IoT Core)
-func initMQTTClient() (*mqtt.Client, error) {
-	endpoint := os.Getenv("MQTT_ENDPOINT")
-	if endpoint == "" {
-		return nil, fmt.Errorf("MQTT_ENDPOINT is required for AWS IoT Core")
-	}
-
-	clientID := os.Getenv("MQTT_CLIENT_ID")
+// initMQTTClient 初始化 MQTT 客戶端 (AWS IoT Core 或標準 broker)
+func initMQTTClient(settings config.MQTTConfig) (*mqtt.Client, error) {
+	clientID := settings.ClientID
 	if clientID == "" {
 		clientID = "ems-backend-" + time.Now().Format("20060102150405")
 	}
 
-	caCertPath := os.Getenv("MQTT_CA_CERT")
-	clientCertPath := os.Getenv("MQTT_CLIENT_CERT")
-	privateKeyPath := os.Getenv("MQTT_PRIVATE_KEY")
-
-	if caCertPath == "" || clientCertPath == "" || privateKeyPath == "" {
-		return nil, fmt.Errorf("MQTT certificate paths are required: MQTT_CA_CERT, MQTT_CLIENT_CERT, MQTT_PRIVATE_KEY")
-	}
-
 	cfg := mqtt.Config{
-		Endpoint:       endpoint,
-		ClientID:       clientID,
-		CACertPath:     caCertPath,
-		ClientCertPath: clientCertPath,
-		PrivateKeyPath: privateKeyPath,
+		Endpoint:           settings.Endpoint,
+		BrokerURL:          settings.BrokerURL,
+		ClientID:           clientID,
+		Username:           settings.Username,
+		Password:           settings.Password,
+		CACertPath:         settings.CACert,
+		ClientCertPath:     settings.ClientCert,
+		PrivateKeyPath:     settings.PrivateKey,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+		TopicPrefix:        settings.TopicPrefix,
+		ProtocolVersion:    settings.ProtocolVersion,
+		MaxQoS:             settings.MaxQoS,
+		Session: mqtt.SessionOptions{
+			CleanSession: settings.CleanSession,
+		},
 	}
 
 	client, err := mqtt.NewClient(cfg)
@@ -424,6 +818,5 @@ func initMQTTClient() (*mqtt.Client, error) {
 		return nil, err
 	}
 
-	log.Printf("[MQTT] Connected to AWS IoT Core: %s", endpoint)
 	return client, nil
 }
@@ -0,0 +1,331 @@
+// Command mockidp is a minimal OpenID Connect provider for local development
+// and integration testing of the backend's single sign-on.
+//
+// It serves discovery, JWKS, an authorization endpoint that lets you pick a
+// user from a list (or skips the page with login_hint) and a token endpoint
+// that checks the client secret, redirect_uri and PKCE verifier before
+// returning an RS256-signed ID token. The signing key is generated at start,
+// so restart the backend whenever the provider is restarted.
+//
+// Examples:
+//
+//	# Default users alice (groups: ems-admins) and bob (groups: site-a-operators)
+//	go run ./cmd/mockidp -addr :9000 -client-id ems -client-secret dev-secret
+//
+//	# Custom users (JSON array of {sub, email, email_verified, name, preferred_username, groups})
+//	go run ./cmd/mockidp -users ./testdata/idp-users.json
+//
+// Matching entry for OIDC_PROVIDERS_FILE:
+//
+//	[{"id": "mock", "name": "Mock IdP", "issuer": "http://localhost:9000",
+//	  "client_id": "ems", "client_secret": "dev-secret",
+//	  "redirect_url": "http://localhost:3000/sso/callback",
+//	  "auto_provision": true, "link_by_email": true,
+//	  "role_mappings": [{"claim": "groups", "value": "ems-admins", "id": 1}]}]
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	codeTTL    = time.Minute
+	idTokenTTL = 10 * time.Minute
+	keyID      = "mockidp-1"
+)
+
+// User is an identity the provider can sign in as
+type User struct {
+	Subject           string   `json:"sub"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Groups            []string `json:"groups"`
+}
+
+var defaultUsers = []User{
+	{Subject: "mock-alice", Email: "alice@example.com", EmailVerified: true, Name: "Alice", PreferredUsername: "alice", Groups: []string{"ems-admins"}},
+	{Subject: "mock-bob", Email: "bob@example.com", EmailVerified: true, Name: "Bob", PreferredUsername: "bob", Groups: []string{"site-a-operators"}},
+}
+
+// authorization is an issued, not yet redeemed authorization code
+type authorization struct {
+	user          User
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	users        []User
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "", "issuer URL (default http://localhost<addr>)")
+	clientID := flag.String("client-id", "ems", "accepted client_id")
+	clientSecret := flag.String("client-secret", "dev-secret", "client secret (empty allows public clients)")
+	usersFile := flag.String("users", "", "JSON file with users (default alice and bob)")
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = "http://localhost" + *addr
+	}
+	users := defaultUsers
+	if *usersFile != "" {
+		data, err := os.ReadFile(*usersFile)
+		if err == nil {
+			err = json.Unmarshal(data, &users)
+		}
+		if err != nil {
+			log.Fatalf("users: %v", err)
+		}
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("signing key: %v", err)
+	}
+
+	p := &provider{
+		issuer:       *issuer,
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		users:        users,
+		key:          key,
+		codes:        make(map[string]*authorization),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	log.Printf("mock OpenID provider %s listening on %s (%d users)", p.issuer, *addr, len(users))
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "email", "email_verified", "name", "preferred_username", "groups", "nonce"},
+	})
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+var pickerPage = template.Must(template.New("picker").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Mock IdP</title></head>
+<body style="font-family: sans-serif">
+<h2>Sign in to {{.Issuer}}</h2>
+<ul>{{range .Users}}
+<li><a href="{{.Link}}">{{.Name}}</a> &lt;{{.Email}}&gt; {{.Groups}}</li>{{end}}
+</ul>
+</body></html>`))
+
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != p.clientID || redirectURI == "" {
+		http.Error(w, "unknown client_id or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("response_type") != "code" || q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "response_type=code with an S256 code_challenge is required", http.StatusBadRequest)
+		return
+	}
+
+	// login_hint (email or preferred_username) or the picker link selects the user
+	hint := q.Get("login_hint")
+	var user *User
+	for i := range p.users {
+		if hint != "" && (p.users[i].Email == hint || p.users[i].PreferredUsername == hint || p.users[i].Subject == hint) {
+			user = &p.users[i]
+			break
+		}
+	}
+	if user == nil {
+		p.renderPicker(w, r)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = &authorization{
+		user:          *user,
+		clientID:      p.clientID,
+		redirectURI:   redirectURI,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		expiresAt:     time.Now().Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := target.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	target.RawQuery = values.Encode()
+	log.Printf("authorized %s for %s", user.Email, redirectURI)
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (p *provider) renderPicker(w http.ResponseWriter, r *http.Request) {
+	type entry struct {
+		User
+		Link string
+	}
+	entries := make([]entry, len(p.users))
+	for i, user := range p.users {
+		q := r.URL.Query()
+		q.Set("login_hint", user.Subject)
+		entries[i] = entry{User: user, Link: "/authorize?" + q.Encode()}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = pickerPage.Execute(w, map[string]interface{}{"Issuer": p.issuer, "Users": entries})
+}
+
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1 {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+
+	// codes are single use
+	p.mu.Lock()
+	auth := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	if auth == nil || time.Now().After(auth.expiresAt) || auth.clientID != clientID {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+		return
+	}
+	if r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.issuer,
+		"sub":                auth.user.Subject,
+		"aud":                clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(idTokenTTL).Unix(),
+		"auth_time":          now.Unix(),
+		"email":              auth.user.Email,
+		"email_verified":     auth.user.EmailVerified,
+		"name":               auth.user.Name,
+		"preferred_username": auth.user.PreferredUsername,
+		"groups":             auth.user.Groups,
+	}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
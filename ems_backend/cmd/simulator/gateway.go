@@ -0,0 +1,791 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	deviceCommandEntities "ems_backend/internal/domain/device_command/entities"
+	msgHandlers "ems_backend/internal/infrastructure/messaging/handlers"
+	"ems_backend/internal/infrastructure/mqtt"
+)
+
+// SQS queue names consumed by the backend (see initQueueListeners in cmd/api)
+const (
+	queueACStatus      = "ac_status"
+	queueMeter         = "meter"
+	queueACTemperature = "ac_temperature"
+)
+
+// Layout describes the equipment behind every simulated gateway
+type Layout struct {
+	Packages              int
+	CompressorsPerPackage int
+	VRFs                  int
+	UnitsPerVRF           int
+}
+
+// Faults holds per-tick fault injection probabilities (0-1)
+type Faults struct {
+	OfflineRate   float64       // gateway drops off the network
+	OfflineMin    time.Duration // offline period bounds
+	OfflineMax    time.Duration
+	ErrorRate     float64 // compressor trips into error / command is rejected
+	ErrorDuration time.Duration
+	ResetRate     float64       // meter kWh and compressor runtime counters restart from zero
+	MalformedRate float64       // payload is corrupted before it is sent
+	DelayRate     float64       // payload is held back and sent on a later tick
+	DelayMax      time.Duration // longest hold-back
+}
+
+// Message is one telemetry payload destined for a backend queue
+type Message struct {
+	Queue string
+	Body  []byte
+}
+
+// FaultStats counts injected faults
+type FaultStats struct {
+	Offline   int
+	Errors    int
+	Resets    int
+	Malformed int
+	Delayed   int
+}
+
+type compressor struct {
+	id           string
+	packageID    string
+	packageName  string
+	addr         int
+	ratedKW      float64
+	enabled      bool // remote power state
+	running      bool
+	errorUntil   time.Time
+	runtime      int64
+	startsInHour int
+}
+
+type indoorUnit struct {
+	id         string
+	vrfID      string
+	vrfAddress string
+	number     int
+	ratedKW    float64
+	enabled    bool
+	running    bool
+	mode       string
+	setpoint   float64
+}
+
+type area struct {
+	id          string
+	name        string
+	sensorID    string
+	meterID     string
+	temperature float64
+	humidity    float64
+	setpoint    float64
+	compressors []*compressor
+	units       []*indoorUnit
+	kWh         float64
+}
+
+// delayedPayload is telemetry (queue set) or a reply held back by the delay fault
+type delayedPayload struct {
+	due   time.Time
+	queue string
+	body  []byte
+}
+
+type otaJob struct {
+	commandID string
+	version   string
+	progress  int
+	fail      bool
+}
+
+// Gateway emulates one ems_vrv gateway: its equipment, sensors, meters,
+// schedule and the replies it sends on {prefix}/return/{sn}
+type Gateway struct {
+	SN string
+
+	mu           sync.Mutex
+	rng          *rand.Rand
+	faults       Faults
+	firmware     string
+	hardware     string
+	areas        []*area
+	schedule     *mqtt.ScheduleCommand
+	scheduleID   string
+	scheduleAt   time.Time
+	offlineUntil time.Time
+	hour         int
+	ota          *otaJob
+	delayed      []delayedPayload
+	stats        FaultStats
+}
+
+// NewGateway builds a gateway with deterministic IDs derived from its SN
+func NewGateway(sn string, layout Layout, faults Faults, firmware, hardware string, seed int64) *Gateway {
+	g := &Gateway{
+		SN:       sn,
+		rng:      rand.New(rand.NewSource(seed)),
+		faults:   faults,
+		firmware: firmware,
+		hardware: hardware,
+		hour:     -1,
+	}
+
+	n := 0
+	for p := 1; p <= layout.Packages; p++ {
+		n++
+		packageID := fmt.Sprintf("%s-PKG%d", sn, p)
+		a := g.newArea(n, fmt.Sprintf("Package Zone %d", p))
+		for c := 1; c <= layout.CompressorsPerPackage; c++ {
+			a.compressors = append(a.compressors, &compressor{
+				id:          fmt.Sprintf("%s-C%d", packageID, c),
+				packageID:   packageID,
+				packageName: fmt.Sprintf("Package %d", p),
+				addr:        c,
+				ratedKW:     4 + g.rng.Float64()*3,
+				enabled:     true,
+			})
+		}
+	}
+	for v := 1; v <= layout.VRFs; v++ {
+		n++
+		vrfID := fmt.Sprintf("%s-VRF%d", sn, v)
+		a := g.newArea(n, fmt.Sprintf("VRF Zone %d", v))
+		for u := 1; u <= layout.UnitsPerVRF; u++ {
+			a.units = append(a.units, &indoorUnit{
+				id:         fmt.Sprintf("%s-AC%d", vrfID, u),
+				vrfID:      vrfID,
+				vrfAddress: fmt.Sprintf("%d", v),
+				number:     u,
+				ratedKW:    1 + g.rng.Float64()*1.5,
+				enabled:    true,
+				mode:       deviceCommandEntities.ModeCool,
+				setpoint:   25,
+			})
+		}
+	}
+
+	g.schedule = defaultSchedule()
+	g.scheduleID = fmt.Sprintf("%s-S0", sn)
+	g.scheduleAt = time.Now().UTC()
+	return g
+}
+
+func (g *Gateway) newArea(n int, name string) *area {
+	a := &area{
+		id:          fmt.Sprintf("%s-A%d", g.SN, n),
+		name:        name,
+		sensorID:    fmt.Sprintf("%s-T%d", g.SN, n),
+		meterID:     fmt.Sprintf("%s-M%d", g.SN, n),
+		temperature: 24 + g.rng.Float64()*4,
+		humidity:    55 + g.rng.Float64()*10,
+		setpoint:    25,
+		kWh:         float64(g.rng.Intn(50000)),
+	}
+	g.areas = append(g.areas, a)
+	return a
+}
+
+// defaultSchedule is what a freshly installed gateway runs: weekdays 08:00-18:00
+func defaultSchedule() *mqtt.ScheduleCommand {
+	cmd := &mqtt.ScheduleCommand{Command: "schedule", Data: map[string]*mqtt.DailyRule{}}
+	for _, day := range []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"} {
+		cmd.Data[day] = &mqtt.DailyRule{RunPeriod: &mqtt.TimePeriod{Start: "08:00", End: "18:00"}}
+	}
+	for _, day := range []string{"Saturday", "Sunday"} {
+		cmd.Data[day] = nil
+	}
+	return cmd
+}
+
+// Online reports whether the gateway is currently reachable
+func (g *Gateway) Online(now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !now.Before(g.offlineUntil)
+}
+
+// Stats returns a copy of the injected fault counters
+func (g *Gateway) Stats() FaultStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
+
+// Tick advances the physical model by dt and returns telemetry plus any
+// unsolicited replies (OTA progress). Nothing is returned while offline.
+func (g *Gateway) Tick(now time.Time, dt time.Duration) ([]Message, [][]byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Before(g.offlineUntil) {
+		return nil, nil
+	}
+	if g.chance(g.faults.OfflineRate) {
+		span := g.faults.OfflineMax - g.faults.OfflineMin
+		offline := g.faults.OfflineMin
+		if span > 0 {
+			offline += time.Duration(g.rng.Int63n(int64(span)))
+		}
+		g.offlineUntil = now.Add(offline)
+		g.stats.Offline++
+		return nil, nil
+	}
+
+	if g.chance(g.faults.ResetRate) {
+		g.resetCounters()
+	}
+	if now.Hour() != g.hour {
+		g.hour = now.Hour()
+		for _, a := range g.areas {
+			for _, c := range a.compressors {
+				c.startsInHour = 0
+			}
+		}
+	}
+
+	scheduled := g.scheduleActive(now)
+	var messages []Message
+	for _, a := range g.areas {
+		messages = append(messages, g.stepArea(a, now, dt, scheduled)...)
+	}
+
+	var replies [][]byte
+	if reply := g.stepOTA(); reply != nil {
+		replies = append(replies, reply)
+	}
+
+	// Payloads held back on earlier ticks go out before this tick's, which
+	// are themselves subject to the same faults
+	sentMessages, sentReplies := g.releaseDelayed(now)
+	for _, m := range messages {
+		m.Body = g.maybeMalform(m.Body)
+		if !g.maybeDelay(now, m.Queue, m.Body) {
+			sentMessages = append(sentMessages, m)
+		}
+	}
+	for _, reply := range replies {
+		reply = g.maybeMalform(reply)
+		if !g.maybeDelay(now, "", reply) {
+			sentReplies = append(sentReplies, reply)
+		}
+	}
+	return sentMessages, sentReplies
+}
+
+func (g *Gateway) stepArea(a *area, now time.Time, dt time.Duration, scheduled bool) []Message {
+	tsMs := now.UnixMilli()
+	seconds := dt.Seconds()
+	var messages []Message
+
+	// Thermostat with 0.5 °C hysteresis, only inside the scheduled run period
+	demandOn := a.temperature > a.setpoint+0.5
+	demandOff := a.temperature < a.setpoint-0.5
+	capacity, total, loadKW := 0.0, 0, 0.0
+
+	for _, c := range a.compressors {
+		total++
+		if c.errorUntil.IsZero() && g.chance(g.faults.ErrorRate) {
+			c.errorUntil = now.Add(g.faults.ErrorDuration)
+			g.stats.Errors++
+		}
+		errored := !c.errorUntil.IsZero() && now.Before(c.errorUntil)
+		if !errored {
+			c.errorUntil = time.Time{}
+		}
+
+		wasRunning := c.running
+		switch {
+		case errored || !c.enabled || !scheduled:
+			c.running = false
+		case demandOn:
+			c.running = true
+		case demandOff:
+			c.running = false
+		}
+		if c.running && !wasRunning {
+			c.startsInHour++
+		}
+		if c.running {
+			c.runtime += int64(seconds)
+			capacity++
+			loadKW += c.ratedKW
+		}
+
+		messages = append(messages, g.encode(queueACStatus, msgHandlers.PackageACStatusData{
+			PackageID:      c.packageID,
+			PackageName:    c.packageName,
+			CompressorID:   c.id,
+			CompressorAddr: c.addr,
+			RunStatus:      c.running,
+			ErrorStatus:    errored,
+			RuntimeSeconds: c.runtime,
+			StartsInHour:   c.startsInHour,
+			Type:           "package_ac_status",
+			Timestamp:      now.UTC().Format(time.RFC3339),
+			TsMs:           tsMs,
+			ClientID:       g.SN,
+		}))
+	}
+
+	for _, u := range a.units {
+		total++
+		switch {
+		case !u.enabled || !scheduled:
+			u.running = false
+		case u.mode == deviceCommandEntities.ModeFan:
+			u.running = true
+		case demandOn:
+			u.running = true
+		case demandOff:
+			u.running = false
+		}
+		if u.running {
+			loadKW += u.ratedKW
+			if u.mode != deviceCommandEntities.ModeFan {
+				capacity++
+			}
+		}
+		status := 0
+		if u.running {
+			status = 1
+		}
+		messages = append(messages, g.encode(queueACStatus, msgHandlers.VRFStatusData{
+			VRFID:      u.vrfID,
+			VRFAddress: u.vrfAddress,
+			ACNumber:   u.number,
+			Status:     status,
+			Type:       "vrf_status",
+			TsMs:       tsMs,
+			ClientID:   g.SN,
+		}))
+	}
+
+	// Room temperature drifts toward the outdoor temperature and is pulled
+	// down in proportion to the share of running cooling capacity
+	outdoor := 28 + 5*math.Sin(2*math.Pi*(float64(now.Hour())+float64(now.Minute())/60-9)/24)
+	cooling := 0.0
+	if total > 0 {
+		cooling = capacity / float64(total)
+	}
+	a.temperature += seconds / 600 * ((outdoor-a.temperature)*0.3 - 3*cooling)
+	a.temperature += g.rng.NormFloat64() * 0.05
+	a.humidity = clamp(a.humidity+g.rng.NormFloat64()*0.3-cooling*seconds/600, 35, 85)
+
+	messages = append(messages, g.encode(queueACTemperature, msgHandlers.ACTemperatureData{
+		Temperature:   round(a.temperature, 2),
+		Humidity:      round(a.humidity, 2),
+		TemperatureID: a.sensorID,
+		Timestamp:     tsMs,
+	}))
+
+	kW := 0.3 + loadKW + g.rng.NormFloat64()*0.05
+	if kW < 0 {
+		kW = 0
+	}
+	a.kWh += kW * seconds / 3600
+	messages = append(messages, g.encode(queueMeter, msgHandlers.MeterData{
+		MeterID:   a.meterID,
+		KWh:       round(a.kWh, 3),
+		KW:        round(kW, 3),
+		Timestamp: tsMs,
+	}))
+
+	return messages
+}
+
+// HandleCommand answers one payload received on {prefix}/command/{sn}
+func (g *Gateway) HandleCommand(now time.Time, payload []byte) [][]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Before(g.offlineUntil) {
+		return nil // offline gateways never see the command
+	}
+
+	var envelope struct {
+		Command   string `json:"command"`
+		CommandID string `json:"command_id"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return [][]byte{g.reply("", false, "invalid command payload", nil)}
+	}
+
+	var reply []byte
+	switch envelope.Command {
+	case "deviceInfo":
+		reply = g.reply(envelope.CommandID, true, "ok", g.deviceInfo())
+	case "getSchedule":
+		reply = g.reply(envelope.CommandID, true, "ok", g.scheduleReport())
+	case "schedule":
+		reply = g.applySchedule(now, envelope.CommandID, payload)
+	case "control":
+		reply = g.applyControl(envelope.CommandID, payload)
+	case "ota":
+		reply = g.startOTA(envelope.CommandID, payload)
+	default:
+		reply = g.reply(envelope.CommandID, false, "unknown command: "+envelope.Command, nil)
+	}
+	reply = g.maybeMalform(reply)
+	if g.maybeDelay(now, "", reply) {
+		return nil // sent by a later Tick
+	}
+	return [][]byte{reply}
+}
+
+func (g *Gateway) applySchedule(now time.Time, commandID string, payload []byte) []byte {
+	var cmd mqtt.ScheduleCommand
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return g.reply(commandID, false, "invalid schedule: "+err.Error(), nil)
+	}
+	if g.chance(g.faults.ErrorRate) {
+		g.stats.Errors++
+		return g.reply(commandID, false, "failed to write schedule to flash", nil)
+	}
+
+	g.schedule = &cmd
+	g.scheduleID = fmt.Sprintf("%s-S%d", g.SN, now.Unix())
+	g.scheduleAt = now.UTC()
+	return g.reply(commandID, true, "schedule updated", g.scheduleReport())
+}
+
+func (g *Gateway) applyControl(commandID string, payload []byte) []byte {
+	var cmd mqtt.ControlCommand
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return g.reply(commandID, false, "invalid control command: "+err.Error(), nil)
+	}
+	if g.chance(g.faults.ErrorRate) {
+		g.stats.Errors++
+		return g.reply(commandID, false, "modbus timeout", nil)
+	}
+
+	for _, a := range g.areas {
+		switch cmd.TargetType {
+		case deviceCommandEntities.TargetTypeCompressor:
+			for _, c := range a.compressors {
+				if c.id != cmd.TargetID {
+					continue
+				}
+				switch cmd.Action {
+				case deviceCommandEntities.ActionPowerOn:
+					c.enabled = true
+				case deviceCommandEntities.ActionPowerOff:
+					c.enabled = false
+				default:
+					return g.reply(commandID, false, "unsupported action for compressor: "+cmd.Action, nil)
+				}
+				return g.reply(commandID, true, "ok", nil)
+			}
+		case deviceCommandEntities.TargetTypeACUnit:
+			for _, u := range a.units {
+				if u.id != cmd.TargetID {
+					continue
+				}
+				switch cmd.Action {
+				case deviceCommandEntities.ActionPowerOn:
+					u.enabled = true
+				case deviceCommandEntities.ActionPowerOff:
+					u.enabled = false
+				case deviceCommandEntities.ActionSetMode:
+					u.mode = cmd.Mode
+				case deviceCommandEntities.ActionSetSetpoint:
+					if cmd.Setpoint == nil {
+						return g.reply(commandID, false, "setpoint is required", nil)
+					}
+					u.setpoint = *cmd.Setpoint
+					a.setpoint = *cmd.Setpoint
+				default:
+					return g.reply(commandID, false, "unsupported action for ac unit: "+cmd.Action, nil)
+				}
+				return g.reply(commandID, true, "ok", nil)
+			}
+		}
+	}
+	return g.reply(commandID, false, fmt.Sprintf("unknown %s %q", cmd.TargetType, cmd.TargetID), nil)
+}
+
+func (g *Gateway) startOTA(commandID string, payload []byte) []byte {
+	var cmd mqtt.OTACommand
+	if err := json.Unmarshal(payload, &cmd); err != nil || cmd.Version == "" {
+		return g.reply(commandID, false, "invalid ota command", nil)
+	}
+	if g.ota != nil {
+		return g.reply(commandID, false, "another update is in progress", nil)
+	}
+
+	g.ota = &otaJob{commandID: commandID, version: cmd.Version, fail: g.chance(g.faults.ErrorRate)}
+	if g.ota.fail {
+		g.stats.Errors++
+	}
+	return g.reply(commandID, true, "download started", map[string]any{"status": "downloading", "progress": 0})
+}
+
+// stepOTA reports download / install progress, one step per tick
+func (g *Gateway) stepOTA() []byte {
+	job := g.ota
+	if job == nil {
+		return nil
+	}
+
+	job.progress += 25
+	switch {
+	case job.fail && job.progress >= 75:
+		g.ota = nil
+		return g.reply(job.commandID, false, "checksum mismatch", map[string]any{"status": "failed", "progress": job.progress})
+	case job.progress < 50:
+		return g.reply(job.commandID, true, "", map[string]any{"status": "downloading", "progress": job.progress})
+	case job.progress < 100:
+		return g.reply(job.commandID, true, "", map[string]any{"status": "installing", "progress": job.progress})
+	}
+
+	g.ota = nil
+	g.firmware = job.version
+	return g.reply(job.commandID, true, "update installed", map[string]any{
+		"status":           "succeeded",
+		"progress":         100,
+		"firmware_version": job.version,
+	})
+}
+
+// deviceInfo returns the gateway's configuration in the company_device content shape
+func (g *Gateway) deviceInfo() map[string]any {
+	content := companyDeviceEntities.DeviceContent{
+		Areas:    []companyDeviceEntities.Area{},
+		Packages: []companyDeviceEntities.Package{},
+		VRFs:     []companyDeviceEntities.VRF{},
+	}
+
+	for _, a := range g.areas {
+		area := companyDeviceEntities.Area{
+			ID:   a.id,
+			Name: a.name,
+			MeterMappings: []companyDeviceEntities.MeterMapping{
+				{ID: a.meterID + "-map", AreaID: a.id, DeviceMeterID: a.meterID},
+			},
+			ACMappings: []companyDeviceEntities.ACMapping{},
+		}
+
+		if len(a.compressors) > 0 {
+			pkg := companyDeviceEntities.Package{
+				ID:                  a.compressors[0].packageID,
+				Name:                a.compressors[0].packageName,
+				AreaName:            a.name,
+				TemperatureSensorID: a.sensorID,
+			}
+			for _, c := range a.compressors {
+				pkg.Compressors = append(pkg.Compressors, companyDeviceEntities.Compressor{
+					ID:             c.id,
+					PackageAcID:    c.packageID,
+					Address:        c.addr,
+					RunStatus:      c.running,
+					RuntimeSeconds: c.runtime,
+					StartsInHour:   c.startsInHour,
+				})
+			}
+			content.Packages = append(content.Packages, pkg)
+			area.ACMappings = append(area.ACMappings, companyDeviceEntities.ACMapping{
+				ID: pkg.ID + "-map", Type: "package", ACID: pkg.ID, AreaID: a.id,
+			})
+		}
+
+		if len(a.units) > 0 {
+			vrf := companyDeviceEntities.VRF{ID: a.units[0].vrfID, Address: a.units[0].vrfAddress}
+			for _, u := range a.units {
+				number := u.number
+				status := 0
+				if u.running {
+					status = 1
+				}
+				vrf.ACUnits = append(vrf.ACUnits, companyDeviceEntities.ACUnit{
+					ID:                  u.id,
+					VRFID:               u.vrfID,
+					Number:              &number,
+					Status:              status,
+					TemperatureSensorID: a.sensorID,
+				})
+				area.ACMappings = append(area.ACMappings, companyDeviceEntities.ACMapping{
+					ID: u.id + "-map", Type: "vrf", ACID: u.id, AreaID: a.id,
+				})
+			}
+			content.VRFs = append(content.VRFs, vrf)
+		}
+
+		content.Areas = append(content.Areas, area)
+	}
+
+	data := map[string]any{}
+	raw, _ := json.Marshal(content)
+	_ = json.Unmarshal(raw, &data)
+	delete(data, "version") // the backend owns the content version
+	data["firmware_version"] = g.firmware
+	data["hardware_version"] = g.hardware
+	data["sn"] = g.SN
+	return data
+}
+
+// scheduleReport returns the current schedule in the getSchedule reply shape
+func (g *Gateway) scheduleReport() mqtt.DeviceScheduleResponse {
+	report := mqtt.DeviceScheduleResponse{
+		ScheduleID: g.scheduleID,
+		Command:    "schedule",
+		DailyRules: map[string]*mqtt.DeviceDailyRule{},
+		Exceptions: g.schedule.Exceptions,
+		CreatedAt:  g.scheduleAt.Format(time.RFC3339),
+		UpdatedAt:  g.scheduleAt.Format(time.RFC3339),
+	}
+	if report.Exceptions == nil {
+		report.Exceptions = []string{}
+	}
+
+	days := make([]string, 0, len(g.schedule.Data))
+	for day := range g.schedule.Data {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		rule := g.schedule.Data[day]
+		if rule == nil {
+			report.DailyRules[day] = nil
+			continue
+		}
+		deviceRule := &mqtt.DeviceDailyRule{DayOfWeek: day, Actions: []mqtt.DeviceAction{}}
+		if rule.RunPeriod != nil {
+			deviceRule.RunPeriod = &mqtt.DeviceTimePeriod{Start: rule.RunPeriod.Start, End: rule.RunPeriod.End}
+		}
+		for _, action := range rule.Actions {
+			deviceRule.Actions = append(deviceRule.Actions, mqtt.DeviceAction{Type: action.Type, Time: action.Time})
+		}
+		report.DailyRules[day] = deviceRule
+	}
+	return report
+}
+
+// scheduleActive reports whether now falls inside today's run period
+func (g *Gateway) scheduleActive(now time.Time) bool {
+	day := now.Weekday().String()
+	if g.schedule == nil {
+		return true
+	}
+	for _, exception := range g.schedule.Exceptions {
+		if exception == now.Format("2006-01-02") {
+			return false
+		}
+	}
+	rule, ok := g.schedule.Data[day]
+	if !ok || rule == nil || rule.RunPeriod == nil {
+		return false
+	}
+	clock := now.Format("15:04")
+	return clock >= rule.RunPeriod.Start && clock < rule.RunPeriod.End
+}
+
+func (g *Gateway) resetCounters() {
+	for _, a := range g.areas {
+		a.kWh = 0
+		for _, c := range a.compressors {
+			c.runtime = 0
+		}
+	}
+	g.stats.Resets++
+}
+
+func (g *Gateway) reply(commandID string, success bool, message string, data any) []byte {
+	response := map[string]any{"success": success, "message": message}
+	if commandID != "" {
+		response["command_id"] = commandID
+	}
+	if data != nil {
+		response["data"] = data
+	}
+	payload, _ := json.Marshal(response)
+	return payload
+}
+
+func (g *Gateway) encode(queue string, v any) Message {
+	body, _ := json.Marshal(v)
+	return Message{Queue: queue, Body: body}
+}
+
+// maybeMalform corrupts a payload the way flaky gateway firmware does:
+// truncated JSON, wrong value types, an empty body or binary garbage
+func (g *Gateway) maybeMalform(payload []byte) []byte {
+	if !g.chance(g.faults.MalformedRate) {
+		return payload
+	}
+	g.stats.Malformed++
+
+	switch g.rng.Intn(4) {
+	case 0:
+		if len(payload) > 2 {
+			return payload[:g.rng.Intn(len(payload)-1)+1]
+		}
+		return []byte("{")
+	case 1:
+		return []byte(strings.NewReplacer(`":`, `":"`, `,"`, `","`).Replace(string(payload)))
+	case 2:
+		return []byte{}
+	default:
+		garbage := make([]byte, 16+g.rng.Intn(48))
+		g.rng.Read(garbage)
+		return garbage
+	}
+}
+
+// maybeDelay holds a payload back for up to DelayMax; it is released by the
+// first Tick after it is due, after payloads generated later
+func (g *Gateway) maybeDelay(now time.Time, queue string, body []byte) bool {
+	if !g.chance(g.faults.DelayRate) {
+		return false
+	}
+	wait := time.Nanosecond
+	if g.faults.DelayMax > 0 {
+		wait += time.Duration(g.rng.Int63n(int64(g.faults.DelayMax)))
+	}
+	g.delayed = append(g.delayed, delayedPayload{due: now.Add(wait), queue: queue, body: body})
+	g.stats.Delayed++
+	return true
+}
+
+// releaseDelayed returns held-back payloads that are due at now
+func (g *Gateway) releaseDelayed(now time.Time) ([]Message, [][]byte) {
+	var messages []Message
+	var replies [][]byte
+	pending := g.delayed[:0]
+	for _, p := range g.delayed {
+		switch {
+		case now.Before(p.due):
+			pending = append(pending, p)
+		case p.queue == "":
+			replies = append(replies, p.body)
+		default:
+			messages = append(messages, Message{Queue: p.queue, Body: p.body})
+		}
+	}
+	g.delayed = pending
+	return messages, replies
+}
+
+func (g *Gateway) chance(p float64) bool {
+	return p > 0 && g.rng.Float64() < p
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
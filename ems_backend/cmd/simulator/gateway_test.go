@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	deviceCommandEntities "ems_backend/internal/domain/device_command/entities"
+	firmwareServices "ems_backend/internal/domain/firmware/services"
+	msgHandlers "ems_backend/internal/infrastructure/messaging/handlers"
+	"ems_backend/internal/infrastructure/mqtt"
+)
+
+var testLayout = Layout{Packages: 1, CompressorsPerPackage: 2, VRFs: 1, UnitsPerVRF: 2}
+
+// 週一 10:00，預設排程運轉中
+var testNow = time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+// decodeStrict 以後端的型別解碼，多出後端不認得的欄位也視為錯誤
+func decodeStrict(body []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// decodeTelemetry 依佇列與 type 欄位，以後端 handler 使用的結構解碼
+func decodeTelemetry(m Message) error {
+	switch m.Queue {
+	case queueACStatus:
+		var messageType msgHandlers.MessageType
+		if err := json.Unmarshal(m.Body, &messageType); err != nil {
+			return err
+		}
+		if messageType.Type == "vrf_status" {
+			return decodeStrict(m.Body, &msgHandlers.VRFStatusData{})
+		}
+		return decodeStrict(m.Body, &msgHandlers.PackageACStatusData{})
+	case queueACTemperature:
+		return decodeStrict(m.Body, &msgHandlers.ACTemperatureData{})
+	case queueMeter:
+		return decodeStrict(m.Body, &msgHandlers.MeterData{})
+	}
+	return nil
+}
+
+func marshalCommand(t *testing.T, v any) []byte {
+	t.Helper()
+	payload, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("編碼指令失敗: %v", err)
+	}
+	return payload
+}
+
+func TestGateway_TelemetryDecodesWithBackendTypes(t *testing.T) {
+	g := NewGateway("SIM-0001", testLayout, Faults{}, "1.0.0", "rev-a", 1)
+
+	messages, _ := g.Tick(testNow, 10*time.Second)
+
+	// 每個區域：壓縮機 / 室內機狀態各一筆、溫度一筆、電表一筆
+	counts := map[string]int{}
+	for _, m := range messages {
+		if err := decodeTelemetry(m); err != nil {
+			t.Errorf("%s 訊息無法以後端結構解碼: %v\n%s", m.Queue, err, m.Body)
+		}
+		if m.Queue == queueACStatus {
+			var messageType msgHandlers.MessageType
+			_ = json.Unmarshal(m.Body, &messageType)
+			counts[messageType.Type]++
+			continue
+		}
+		counts[m.Queue]++
+	}
+
+	want := map[string]int{"package_ac_status": 2, "vrf_status": 2, queueACTemperature: 2, queueMeter: 2}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%s 期望 %d 筆，得到 %d", key, n, counts[key])
+		}
+	}
+}
+
+func TestGateway_CommandRepliesDecodeWithBackendTypes(t *testing.T) {
+	setpoint := 23.5
+	tests := []struct {
+		name        string
+		command     any
+		wantSuccess bool
+		check       func(t *testing.T, data json.RawMessage)
+	}{
+		{
+			name:        "deviceInfo",
+			command:     map[string]string{"command": "deviceInfo", "command_id": "c1"},
+			wantSuccess: true,
+			check: func(t *testing.T, data json.RawMessage) {
+				var content companyDeviceEntities.DeviceContent
+				if err := json.Unmarshal(data, &content); err != nil {
+					t.Fatalf("無法解碼為 DeviceContent: %v", err)
+				}
+				if len(content.Areas) != 2 || len(content.Packages) != 1 || len(content.VRFs) != 1 {
+					t.Errorf("設備內容錯誤: areas=%d packages=%d vrfs=%d", len(content.Areas), len(content.Packages), len(content.VRFs))
+				}
+				firmware, hardware, ok := firmwareServices.NewFirmwareService().ExtractVersions(data)
+				if !ok || firmware != "1.0.0" || hardware != "rev-a" {
+					t.Errorf("版本擷取錯誤: %q %q %v", firmware, hardware, ok)
+				}
+			},
+		},
+		{
+			name:        "getSchedule",
+			command:     map[string]string{"command": "getSchedule", "command_id": "c2"},
+			wantSuccess: true,
+			check: func(t *testing.T, data json.RawMessage) {
+				var schedule mqtt.DeviceScheduleResponse
+				if err := decodeStrict(data, &schedule); err != nil {
+					t.Fatalf("無法解碼為 DeviceScheduleResponse: %v", err)
+				}
+				if schedule.ScheduleID == "" || schedule.DailyRules["Monday"] == nil || schedule.DailyRules["Monday"].RunPeriod.Start != "08:00" {
+					t.Errorf("排程內容錯誤: %+v", schedule)
+				}
+			},
+		},
+		{
+			name: "schedule",
+			command: mqtt.ScheduleCommand{
+				Command: "schedule",
+				Data: map[string]*mqtt.DailyRule{
+					"Monday": {RunPeriod: &mqtt.TimePeriod{Start: "07:00", End: "19:00"}, Actions: []*mqtt.Action{{Type: "closeOnce", Time: "12:00"}}},
+					"Sunday": nil,
+				},
+				Exceptions: []string{"2026-12-25"},
+			},
+			wantSuccess: true,
+			check: func(t *testing.T, data json.RawMessage) {
+				var schedule mqtt.DeviceScheduleResponse
+				if err := decodeStrict(data, &schedule); err != nil {
+					t.Fatalf("無法解碼為 DeviceScheduleResponse: %v", err)
+				}
+				monday := schedule.DailyRules["Monday"]
+				if monday == nil || monday.RunPeriod.Start != "07:00" || len(monday.Actions) != 1 || len(schedule.Exceptions) != 1 {
+					t.Errorf("排程未套用: %+v", schedule)
+				}
+			},
+		},
+		{
+			name: "control 設定溫度",
+			command: mqtt.ControlCommand{
+				Command: "control", CommandID: "c4",
+				TargetType: deviceCommandEntities.TargetTypeACUnit, TargetID: "SIM-0001-VRF1-AC1",
+				Action: deviceCommandEntities.ActionSetSetpoint, Setpoint: &setpoint,
+			},
+			wantSuccess: true,
+		},
+		{
+			name: "control 未知目標",
+			command: mqtt.ControlCommand{
+				Command: "control", CommandID: "c5",
+				TargetType: deviceCommandEntities.TargetTypeCompressor, TargetID: "missing",
+				Action: deviceCommandEntities.ActionPowerOff,
+			},
+			wantSuccess: false,
+		},
+		{
+			name:        "ota",
+			command:     mqtt.OTACommand{Command: "ota", CommandID: "c6", Version: "1.1.0", URL: "https://example.com/fw.bin", SHA256: "abc", Size: 1024},
+			wantSuccess: true,
+		},
+		{
+			name:        "未知指令",
+			command:     map[string]string{"command": "reboot", "command_id": "c7"},
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGateway("SIM-0001", testLayout, Faults{}, "1.0.0", "rev-a", 1)
+
+			replies := g.HandleCommand(testNow, marshalCommand(t, tt.command))
+			if len(replies) != 1 {
+				t.Fatalf("期望 1 筆回覆，得到 %d", len(replies))
+			}
+
+			var response mqtt.DeviceResponse
+			if err := decodeStrict(replies[0], &response); err != nil {
+				t.Fatalf("回覆無法以 DeviceResponse 解碼: %v\n%s", err, replies[0])
+			}
+			if response.Success != tt.wantSuccess {
+				t.Errorf("期望 success=%v，得到 %v (%s)", tt.wantSuccess, response.Success, response.Message)
+			}
+			var envelope struct {
+				CommandID string `json:"command_id"`
+			}
+			_ = json.Unmarshal(marshalCommand(t, tt.command), &envelope)
+			if response.CommandID != envelope.CommandID {
+				t.Errorf("command_id 期望 %q，得到 %q", envelope.CommandID, response.CommandID)
+			}
+			if tt.check != nil {
+				tt.check(t, response.Data)
+			}
+		})
+	}
+}
+
+func TestGateway_FaultInjection(t *testing.T) {
+	getSchedule := []byte(`{"command":"getSchedule","command_id":"c1"}`)
+
+	t.Run("斷線期間不送資料也不回應指令", func(t *testing.T) {
+		g := NewGateway("SIM-0001", testLayout, Faults{OfflineRate: 1, OfflineMin: time.Minute, OfflineMax: 2 * time.Minute}, "1.0.0", "rev-a", 1)
+
+		messages, replies := g.Tick(testNow, 10*time.Second)
+		if len(messages) != 0 || len(replies) != 0 {
+			t.Fatalf("斷線時不應送出資料，得到 %d 筆遙測 %d 筆回覆", len(messages), len(replies))
+		}
+		if g.Stats().Offline != 1 {
+			t.Errorf("期望 Offline=1，得到 %d", g.Stats().Offline)
+		}
+
+		if g.Online(testNow.Add(59 * time.Second)) {
+			t.Error("未達 OfflineMin 前應維持斷線")
+		}
+		if replies := g.HandleCommand(testNow.Add(30*time.Second), getSchedule); replies != nil {
+			t.Errorf("斷線時不應回應指令，得到 %d 筆", len(replies))
+		}
+		if !g.Online(testNow.Add(2 * time.Minute)) {
+			t.Error("超過 OfflineMax 後應恢復連線")
+		}
+		if replies := g.HandleCommand(testNow.Add(2*time.Minute), getSchedule); len(replies) != 1 {
+			t.Errorf("恢復連線後應回應指令，得到 %d 筆", len(replies))
+		}
+	})
+
+	t.Run("損毀的封包無法被後端解碼", func(t *testing.T) {
+		g := NewGateway("SIM-0001", testLayout, Faults{MalformedRate: 1}, "1.0.0", "rev-a", 1)
+
+		var sent int
+		for i := 0; i < 5; i++ {
+			messages, _ := g.Tick(testNow.Add(time.Duration(i)*10*time.Second), 10*time.Second)
+			for _, m := range messages {
+				sent++
+				if err := decodeTelemetry(m); err == nil {
+					t.Errorf("損毀的 %s 訊息不應能解碼: %q", m.Queue, m.Body)
+				}
+			}
+		}
+		replies := g.HandleCommand(testNow, getSchedule)
+		for _, reply := range replies {
+			sent++
+			if err := json.Unmarshal(reply, &mqtt.DeviceResponse{}); err == nil {
+				t.Errorf("損毀的回覆不應能解碼: %q", reply)
+			}
+		}
+
+		if g.Stats().Malformed != sent {
+			t.Errorf("期望 Malformed=%d，得到 %d", sent, g.Stats().Malformed)
+		}
+	})
+
+	t.Run("延遲的封包在之後的 Tick 送出", func(t *testing.T) {
+		g := NewGateway("SIM-0001", testLayout, Faults{DelayRate: 1, DelayMax: 20 * time.Second}, "1.0.0", "rev-a", 1)
+
+		messages, _ := g.Tick(testNow, 10*time.Second)
+		if replies := g.HandleCommand(testNow, getSchedule); replies != nil {
+			t.Fatalf("延遲的回覆不應立即送出，得到 %d 筆", len(replies))
+		}
+		if len(messages) != 0 {
+			t.Fatalf("延遲的遙測不應立即送出，得到 %d 筆", len(messages))
+		}
+		held := g.Stats().Delayed
+		if held != 9 {
+			t.Fatalf("期望延遲 9 筆 (8 筆遙測 + 1 筆回覆)，得到 %d", held)
+		}
+
+		// DelayMax 之後全部送出，且內容未被改動；同一個 Tick 新產生的資料再次被延遲
+		g.faults.DelayRate = 0
+		messages, replies := g.Tick(testNow.Add(20*time.Second), 10*time.Second)
+		if len(messages) != 16 || len(replies) != 1 {
+			t.Fatalf("期望 16 筆遙測 (8 筆延遲 + 8 筆新的) 與 1 筆回覆，得到 %d 與 %d", len(messages), len(replies))
+		}
+		for _, m := range messages {
+			if err := decodeTelemetry(m); err != nil {
+				t.Errorf("延遲的 %s 訊息無法解碼: %v", m.Queue, err)
+			}
+		}
+		var response mqtt.DeviceResponse
+		if err := decodeStrict(replies[0], &response); err != nil || response.CommandID != "c1" {
+			t.Errorf("延遲的回覆錯誤: %v %+v", err, response)
+		}
+	})
+
+	t.Run("指令失敗", func(t *testing.T) {
+		g := NewGateway("SIM-0001", testLayout, Faults{ErrorRate: 1}, "1.0.0", "rev-a", 1)
+
+		command := mqtt.ControlCommand{
+			Command: "control", CommandID: "c1",
+			TargetType: deviceCommandEntities.TargetTypeCompressor, TargetID: "SIM-0001-PKG1-C1",
+			Action: deviceCommandEntities.ActionPowerOff,
+		}
+		replies := g.HandleCommand(testNow, marshalCommand(t, command))
+		var response mqtt.DeviceResponse
+		if err := decodeStrict(replies[0], &response); err != nil {
+			t.Fatalf("回覆無法解碼: %v", err)
+		}
+		if response.Success || response.CommandID != "c1" {
+			t.Errorf("期望失敗回覆，得到 %+v", response)
+		}
+		if g.Stats().Errors == 0 {
+			t.Error("期望記錄錯誤次數")
+		}
+	})
+}
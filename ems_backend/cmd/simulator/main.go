@@ -0,0 +1,419 @@
+// Command simulator emulates a fleet of ems_vrv gateways for load and
+// integration testing.
+//
+// Each gateway publishes package / VRF compressor status, meter readings and
+// temperatures in the formats consumed by the backend queue handlers, and
+// answers commands received on {prefix}/command/{sn} (schedule, getSchedule,
+// deviceInfo, control, ota) on {prefix}/return/{sn}. Faults — offline periods,
+// compressor errors, counter resets, malformed and delayed payloads — can be injected
+// with per-tick probabilities.
+//
+// Examples:
+//
+//	# 50 gateways on a local Mosquitto, telemetry straight into ElasticMQ
+//	AWS_ENDPOINT_URL=http://localhost:9324 go run ./cmd/simulator \
+//	    -broker tcp://localhost:1883 -gateways 50 -sink sqs
+//
+//	# Telemetry only, printed as JSON lines
+//	go run ./cmd/simulator -broker "" -sink stdout -gateways 3 -duration 1m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/joho/godotenv"
+
+	"ems_backend/internal/infrastructure/mqtt"
+)
+
+type options struct {
+	gateways   int
+	snPrefix   string
+	startIndex int
+	layout     Layout
+	faults     Faults
+	interval   time.Duration
+	duration   time.Duration
+	seed       int64
+	firmware   string
+	hardware   string
+
+	sink           string
+	telemetryTopic string
+	region         string
+
+	broker      string
+	endpoint    string
+	username    string
+	password    string
+	caCert      string
+	clientCert  string
+	privateKey  string
+	insecure    bool
+	topicPrefix string
+	shared      bool
+	connectPool int
+
+	quiet bool
+}
+
+// counters are reported periodically and on exit
+type counters struct {
+	telemetry    atomic.Int64
+	sendFailures atomic.Int64
+	commands     atomic.Int64
+	replies      atomic.Int64
+}
+
+func main() {
+	_ = godotenv.Load()
+	opts := parseFlags()
+
+	if opts.quiet {
+		// The MQTT wrapper logs every publish; keep only the simulator's own reports
+		log.SetOutput(io.Discard)
+	}
+	report := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, time.Now().Format("2006/01/02 15:04:05 ")+format+"\n", args...)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if opts.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	gateways := make([]*Gateway, opts.gateways)
+	bySN := make(map[string]*Gateway, opts.gateways)
+	for i := range gateways {
+		sn := fmt.Sprintf("%s%04d", opts.snPrefix, opts.startIndex+i)
+		gateways[i] = NewGateway(sn, opts.layout, opts.faults, opts.firmware, opts.hardware, opts.seed+int64(i))
+		bySN[sn] = gateways[i]
+	}
+
+	stats := &counters{}
+	conns, err := connectMQTT(ctx, opts, gateways, bySN, stats)
+	if err != nil {
+		report("MQTT: %v", err)
+		os.Exit(1)
+	}
+	defer conns.disconnect()
+
+	sink, err := newSink(ctx, opts, conns)
+	if err != nil {
+		report("sink: %v", err)
+		os.Exit(1)
+	}
+
+	report("simulating %d gateways (%s..%s), sink=%s, interval=%s",
+		len(gateways), gateways[0].SN, gateways[len(gateways)-1].SN, opts.sink, opts.interval)
+
+	var wg sync.WaitGroup
+	for _, g := range gateways {
+		wg.Add(1)
+		go func(g *Gateway) {
+			defer wg.Done()
+			runGateway(ctx, g, opts, sink, conns, stats)
+		}(g)
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			report("%s", summarize(gateways, stats))
+		}
+	}
+	wg.Wait()
+	report("stopped: %s", summarize(gateways, stats))
+}
+
+func parseFlags() options {
+	var opts options
+
+	flag.IntVar(&opts.gateways, "gateways", 10, "number of gateways to simulate")
+	flag.StringVar(&opts.snPrefix, "sn-prefix", "SIM-", "gateway serial number prefix")
+	flag.IntVar(&opts.startIndex, "start-index", 1, "first serial number index (SNs are <prefix><index:04d>)")
+	flag.IntVar(&opts.layout.Packages, "packages", 1, "package AC units per gateway")
+	flag.IntVar(&opts.layout.CompressorsPerPackage, "compressors", 2, "compressors per package AC")
+	flag.IntVar(&opts.layout.VRFs, "vrfs", 1, "VRF systems per gateway")
+	flag.IntVar(&opts.layout.UnitsPerVRF, "units", 4, "indoor units per VRF")
+	flag.DurationVar(&opts.interval, "interval", 10*time.Second, "telemetry interval per gateway")
+	flag.DurationVar(&opts.duration, "duration", 0, "stop after this long (0 runs until interrupted)")
+	flag.Int64Var(&opts.seed, "seed", time.Now().UnixNano(), "random seed (gateway i uses seed+i)")
+	flag.StringVar(&opts.firmware, "firmware", "1.0.0", "initial firmware version")
+	flag.StringVar(&opts.hardware, "hardware", "rev-a", "hardware version")
+
+	flag.Float64Var(&opts.faults.OfflineRate, "fault-offline", 0, "per-tick probability a gateway goes offline")
+	flag.DurationVar(&opts.faults.OfflineMin, "offline-min", 30*time.Second, "shortest offline period")
+	flag.DurationVar(&opts.faults.OfflineMax, "offline-max", 5*time.Minute, "longest offline period")
+	flag.Float64Var(&opts.faults.ErrorRate, "fault-error", 0, "per-tick probability a compressor trips; also the command failure rate")
+	flag.DurationVar(&opts.faults.ErrorDuration, "error-duration", 2*time.Minute, "how long a tripped compressor stays in error")
+	flag.Float64Var(&opts.faults.ResetRate, "fault-reset", 0, "per-tick probability meter and runtime counters reset to zero")
+	flag.Float64Var(&opts.faults.MalformedRate, "fault-malformed", 0, "probability each outgoing payload is corrupted")
+	flag.Float64Var(&opts.faults.DelayRate, "fault-delay", 0, "probability each outgoing payload is held back and sent late")
+	flag.DurationVar(&opts.faults.DelayMax, "delay-max", 30*time.Second, "longest delay for held-back payloads")
+
+	flag.StringVar(&opts.sink, "sink", "mqtt", "telemetry sink: mqtt, sqs or stdout")
+	flag.StringVar(&opts.telemetryTopic, "telemetry-topic", "{prefix}/{queue}/{sn}", "MQTT telemetry topic template ({prefix}, {queue}, {sn})")
+	flag.StringVar(&opts.region, "region", envOr("AWS_REGION", "ap-southeast-2"), "AWS region for the sqs sink")
+
+	flag.StringVar(&opts.broker, "broker", envOr("MQTT_BROKER_URL", "tcp://localhost:1883"), "MQTT broker URL (empty with no -endpoint disables MQTT)")
+	flag.StringVar(&opts.endpoint, "endpoint", "", "AWS IoT Core endpoint (used when -broker is empty)")
+	flag.StringVar(&opts.username, "username", os.Getenv("MQTT_USERNAME"), "MQTT username")
+	flag.StringVar(&opts.password, "password", os.Getenv("MQTT_PASSWORD"), "MQTT password")
+	flag.StringVar(&opts.caCert, "ca-cert", os.Getenv("MQTT_CA_CERT"), "CA certificate path")
+	flag.StringVar(&opts.clientCert, "client-cert", os.Getenv("MQTT_CLIENT_CERT"), "client certificate path")
+	flag.StringVar(&opts.privateKey, "private-key", os.Getenv("MQTT_PRIVATE_KEY"), "client private key path")
+	flag.BoolVar(&opts.insecure, "insecure", os.Getenv("MQTT_TLS_INSECURE_SKIP_VERIFY") == "true", "skip broker certificate verification")
+	flag.StringVar(&opts.topicPrefix, "topic-prefix", envOr("MQTT_TOPIC_PREFIX", mqtt.DefaultTopicPrefix), "device topic prefix")
+	flag.BoolVar(&opts.shared, "shared", false, "use one MQTT connection for all gateways instead of one per gateway")
+	flag.IntVar(&opts.connectPool, "connect-concurrency", 16, "parallel MQTT connection attempts")
+
+	flag.BoolVar(&opts.quiet, "quiet", false, "suppress per-message MQTT logging")
+	flag.Parse()
+
+	if opts.gateways < 1 {
+		log.Fatal("-gateways must be at least 1")
+	}
+	if opts.interval <= 0 {
+		log.Fatal("-interval must be positive")
+	}
+	if opts.faults.OfflineMax < opts.faults.OfflineMin {
+		opts.faults.OfflineMax = opts.faults.OfflineMin
+	}
+	return opts
+}
+
+// mqttConns holds either one shared connection or one connection per gateway
+type mqttConns struct {
+	shared  *mqtt.Client
+	perSN   map[string]*mqtt.Client
+	topics  mqtt.Topics
+	enabled bool
+}
+
+func (c *mqttConns) clientFor(sn string) *mqtt.Client {
+	if c.shared != nil {
+		return c.shared
+	}
+	return c.perSN[sn]
+}
+
+func (c *mqttConns) disconnect() {
+	if c.shared != nil {
+		c.shared.Disconnect()
+	}
+	for _, client := range c.perSN {
+		client.Disconnect()
+	}
+}
+
+func connectMQTT(ctx context.Context, opts options, gateways []*Gateway, bySN map[string]*Gateway, stats *counters) (*mqttConns, error) {
+	conns := &mqttConns{perSN: map[string]*mqtt.Client{}, topics: mqtt.NewTopics(opts.topicPrefix)}
+	if opts.broker == "" && opts.endpoint == "" {
+		return conns, nil // telemetry only
+	}
+	conns.enabled = true
+
+	newClient := func(clientID string) (*mqtt.Client, error) {
+		client, err := mqtt.NewClient(mqtt.Config{
+			Endpoint:           opts.endpoint,
+			BrokerURL:          opts.broker,
+			ClientID:           clientID,
+			Username:           opts.username,
+			Password:           opts.password,
+			CACertPath:         opts.caCert,
+			ClientCertPath:     opts.clientCert,
+			PrivateKeyPath:     opts.privateKey,
+			InsecureSkipVerify: opts.insecure,
+			TopicPrefix:        opts.topicPrefix,
+			Session:            mqtt.SessionOptions{CleanSession: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Connect(); err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	onCommand := func(sn string, payload []byte) {
+		g, ok := bySN[sn]
+		if !ok {
+			return
+		}
+		stats.commands.Add(1)
+		for _, reply := range g.HandleCommand(time.Now(), payload) {
+			publishReply(conns, sn, reply, stats)
+		}
+	}
+
+	if opts.shared {
+		client, err := newClient(fmt.Sprintf("ems-sim-%d", time.Now().UnixNano()))
+		if err != nil {
+			return nil, err
+		}
+		conns.shared = client
+		return conns, client.Subscribe(conns.topics.CommandWildcard(), 1, func(_ pahomqtt.Client, msg pahomqtt.Message) {
+			if sn, ok := conns.topics.DeviceSNFromCommand(msg.Topic()); ok {
+				onCommand(sn, msg.Payload())
+			}
+		})
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, max(opts.connectPool, 1))
+	)
+	for _, g := range gateways {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sn string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			client, err := newClient("ems-sim-" + sn)
+			if err == nil {
+				err = client.Subscribe(conns.topics.Command(sn), 1, func(_ pahomqtt.Client, msg pahomqtt.Message) {
+					onCommand(sn, msg.Payload())
+				})
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("gateway %s: %w", sn, err)
+				}
+				if client != nil {
+					client.Disconnect()
+				}
+				return
+			}
+			conns.perSN[sn] = client
+		}(g.SN)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		conns.disconnect()
+		return nil, firstErr
+	}
+	return conns, ctx.Err()
+}
+
+func newSink(ctx context.Context, opts options, conns *mqttConns) (Sink, error) {
+	switch opts.sink {
+	case "mqtt":
+		if !conns.enabled {
+			return nil, fmt.Errorf("the mqtt sink needs -broker or -endpoint")
+		}
+		return &mqttSink{clientFor: conns.clientFor, template: opts.telemetryTopic, prefix: opts.topicPrefix}, nil
+	case "sqs":
+		return newSQSSink(ctx, opts.region)
+	case "stdout":
+		return &stdoutSink{w: os.Stdout}, nil
+	}
+	return nil, fmt.Errorf("unknown sink %q (mqtt, sqs or stdout)", opts.sink)
+}
+
+// runGateway ticks one gateway until ctx is done; the first tick is spread
+// randomly over one interval so the fleet does not publish in lockstep
+func runGateway(ctx context.Context, g *Gateway, opts options, sink Sink, conns *mqttConns, stats *counters) {
+	g.mu.Lock()
+	offset := time.Duration(g.rng.Int63n(int64(opts.interval)))
+	g.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(offset):
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		now := time.Now()
+		messages, replies := g.Tick(now, now.Sub(last))
+		last = now
+
+		for _, msg := range messages {
+			if err := sink.Send(ctx, g.SN, msg); err != nil {
+				stats.sendFailures.Add(1)
+				log.Printf("[SIM] %s: failed to send %s telemetry: %v", g.SN, msg.Queue, err)
+				continue
+			}
+			stats.telemetry.Add(1)
+		}
+		for _, reply := range replies {
+			publishReply(conns, g.SN, reply, stats)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func publishReply(conns *mqttConns, sn string, payload []byte, stats *counters) {
+	client := conns.clientFor(sn)
+	if client == nil {
+		return
+	}
+	if err := client.Publish(conns.topics.Return(sn), 1, false, payload); err != nil {
+		log.Printf("[SIM] %s: failed to publish reply: %v", sn, err)
+		return
+	}
+	stats.replies.Add(1)
+}
+
+func summarize(gateways []*Gateway, stats *counters) string {
+	var faults FaultStats
+	offline := 0
+	now := time.Now()
+	for _, g := range gateways {
+		s := g.Stats()
+		faults.Offline += s.Offline
+		faults.Errors += s.Errors
+		faults.Resets += s.Resets
+		faults.Malformed += s.Malformed
+		faults.Delayed += s.Delayed
+		if !g.Online(now) {
+			offline++
+		}
+	}
+	return fmt.Sprintf("telemetry=%d send_failures=%d commands=%d replies=%d offline_now=%d faults{offline=%d errors=%d resets=%d malformed=%d delayed=%d}",
+		stats.telemetry.Load(), stats.sendFailures.Load(), stats.commands.Load(), stats.replies.Load(), offline,
+		faults.Offline, faults.Errors, faults.Resets, faults.Malformed, faults.Delayed)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
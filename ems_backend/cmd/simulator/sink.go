@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"ems_backend/internal/infrastructure/messaging"
+	"ems_backend/internal/infrastructure/mqtt"
+)
+
+// Sink delivers telemetry to wherever the backend consumes it
+type Sink interface {
+	Send(ctx context.Context, sn string, msg Message) error
+}
+
+// sqsSink writes straight into the backend's SQS queues (or a local
+// ElasticMQ / LocalStack when AWS_ENDPOINT_URL is set)
+type sqsSink struct {
+	client    *messaging.SQSClient
+	queueURLs map[string]string
+}
+
+func newSQSSink(ctx context.Context, region string) (*sqsSink, error) {
+	client, err := messaging.NewSQSClient(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQS client: %w", err)
+	}
+
+	sink := &sqsSink{client: client, queueURLs: map[string]string{}}
+	for _, queue := range []string{queueACStatus, queueMeter, queueACTemperature} {
+		url, err := client.GetQueueURL(ctx, queue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve queue %q: %w", queue, err)
+		}
+		sink.queueURLs[queue] = url
+	}
+	return sink, nil
+}
+
+func (s *sqsSink) Send(ctx context.Context, sn string, msg Message) error {
+	return s.client.SendMessage(ctx, s.queueURLs[msg.Queue], string(msg.Body))
+}
+
+// mqttSink publishes telemetry the way a real gateway does; a broker rule
+// (e.g. an AWS IoT topic rule) forwards it to the matching SQS queue
+type mqttSink struct {
+	clientFor func(sn string) *mqtt.Client
+	template  string
+	prefix    string
+}
+
+// topic renders the telemetry topic template ({prefix}, {queue}, {sn})
+func (s *mqttSink) topic(sn, queue string) string {
+	return strings.NewReplacer("{prefix}", s.prefix, "{queue}", queue, "{sn}", sn).Replace(s.template)
+}
+
+func (s *mqttSink) Send(ctx context.Context, sn string, msg Message) error {
+	client := s.clientFor(sn)
+	if client == nil {
+		return fmt.Errorf("no MQTT connection for %s", sn)
+	}
+	return client.Publish(s.topic(sn, msg.Queue), 1, false, msg.Body)
+}
+
+// stdoutSink prints one JSON line per message, for piping into other tools
+type stdoutSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *stdoutSink) Send(ctx context.Context, sn string, msg Message) error {
+	line, err := json.Marshal(struct {
+		Queue string `json:"queue"`
+		SN    string `json:"sn"`
+		Body  string `json:"body"`
+	}{msg.Queue, sn, string(msg.Body)})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintln(s.w, string(line))
+	return err
+}
@@ -16,6 +16,9 @@ type AuditLogResponse struct {
 	Status       string                 `json:"status"`
 	ErrorMessage string                 `json:"error_message"`
 	CreateTime   time.Time              `json:"create_time"`
+	Sequence     uint64                 `json:"sequence"`  // 雜湊鏈序號，0 表示不在鏈上
+	PrevHash     string                 `json:"prev_hash"`
+	Hash         string                 `json:"hash"`
 }
 
 // AuditLogQueryRequest 審計日誌查詢請求
@@ -36,3 +39,21 @@ type AuditLogListResponse struct {
 	Total int64               `json:"total"`
 	Logs  []AuditLogResponse  `json:"logs"`
 }
+
+// AuditChainIssue 雜湊鏈上的問題
+type AuditChainIssue struct {
+	Kind     string `json:"kind"` // gap, broken_link, hash_invalid, truncated, head_changed
+	Sequence uint64 `json:"sequence"`
+	LogID    uint   `json:"log_id,omitempty"`
+	Missing  uint64 `json:"missing,omitempty"` // gap、truncated 時缺少的筆數
+}
+
+// AuditChainVerificationResponse 雜湊鏈驗證結果
+type AuditChainVerificationResponse struct {
+	Valid         bool              `json:"valid"`
+	Checked       int               `json:"checked"`
+	FirstSequence uint64            `json:"first_sequence"`
+	LastSequence  uint64            `json:"last_sequence"`
+	Issues        []AuditChainIssue `json:"issues"`
+	Truncated     bool              `json:"truncated"`
+}
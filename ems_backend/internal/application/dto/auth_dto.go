@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // LoginRequest - 登入請求
 type LoginRequest struct {
 	Account  string `json:"account" binding:"required"`
@@ -24,6 +26,21 @@ type AuthResponse struct {
 	MemberRoles  []MemberRole `json:"member_roles"`
 	ExpiresIn    int64        `json:"expires_in"`
 	TokenType    string       `json:"token_type"`
+
+	// 登入時完成 MFA 設定才會返回，僅顯示這一次
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+
+	// 密碼超過有效期限，應引導至 PUT /auth/password 變更密碼
+	PasswordExpired bool `json:"password_expired,omitempty"`
+}
+
+// MFAChallengeResponse - 密碼正確但需要 MFA 時的登入回應
+type MFAChallengeResponse struct {
+	MFARequired        bool       `json:"mfa_required"`
+	ChallengeToken     string     `json:"mfa_challenge_token"`
+	EnrollmentRequired bool       `json:"mfa_enrollment_required"` // 需先呼叫 /auth/mfa/enroll 設定驗證器
+	ExpiresIn          int64      `json:"expires_in"`
+	Member             MemberInfo `json:"member"`
 }
 
 // MemberInfo - 會員信息
@@ -37,3 +54,94 @@ type MemberRole struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
+
+// ForgotPasswordRequest - 忘記密碼請求
+type ForgotPasswordRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	RedirectPath string `json:"redirect_path"`
+}
+
+// ResetPasswordRequest - 重設密碼請求
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangePasswordRequest - 變更自己的密碼
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// PasswordPolicyResponse - 密碼政策 (供前端顯示規則)
+type PasswordPolicyResponse struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	MaxAgeDays    int  `json:"max_age_days"`  // 0 表示不限制
+	HistoryCount  int  `json:"history_count"` // 不可與最近 N 組密碼相同
+}
+
+// ResetPasswordResponse - 重設密碼回應
+type ResetPasswordResponse struct {
+	Message      string `json:"message"`
+	RedirectPath string `json:"redirect_path,omitempty"`
+}
+
+// MFAVerifyRequest - 兩階段登入第二步 (code 為 TOTP 或復原碼)
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"mfa_challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// MFAChallengeEnrollRequest - 角色要求 MFA 時於登入途中設定驗證器
+type MFAChallengeEnrollRequest struct {
+	ChallengeToken string `json:"mfa_challenge_token" binding:"required"`
+}
+
+// MFACodeRequest - 以 TOTP 或復原碼確認操作
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCodesResponse - 新產生的復原碼 (僅顯示這一次)
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+// SessionResponse - 登入中的會話
+type SessionResponse struct {
+	ID            string    `json:"id"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastRefreshAt time.Time `json:"last_refresh_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Current       bool      `json:"current"` // 目前請求使用的會話
+}
+
+// OIDCProviderResponse - 可用的單一登入身分提供者
+type OIDCProviderResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// OIDCAuthorizeResponse - 身分提供者登入網址，前端以整頁導向前往
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest - 身分提供者導回前端後，以 code 與 state 完成登入
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// OIDCLoginResponse - 單一登入回應
+type OIDCLoginResponse struct {
+	AuthResponse
+	RedirectPath string `json:"redirect_path,omitempty"` // 開始登入時指定的前端路徑
+}
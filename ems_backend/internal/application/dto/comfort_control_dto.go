@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/comfort_control/entities"
+)
+
+// ComfortSettingRequest 區域舒適度控制設定請求 (未提供的欄位維持原值)
+type ComfortSettingRequest struct {
+	Enabled       *bool    `json:"enabled"`
+	DryRun        *bool    `json:"dry_run"`
+	ComfortLow    *float64 `json:"comfort_low"`  // 體感 °C
+	ComfortHigh   *float64 `json:"comfort_high"` // 體感 °C
+	Hysteresis    *float64 `json:"hysteresis"`   // °C
+	MinOnSeconds  *int     `json:"min_on_seconds"`
+	MinOffSeconds *int     `json:"min_off_seconds"`
+}
+
+// ComfortSettingResponse 區域舒適度控制設定與最近一次判斷結果
+type ComfortSettingResponse struct {
+	AreaID        string                    `json:"area_id"`
+	AreaName      string                    `json:"area_name"`
+	Configured    bool                      `json:"configured"` // false 表示尚未設定，返回預設值
+	Enabled       bool                      `json:"enabled"`
+	DryRun        bool                      `json:"dry_run"`
+	ComfortLow    float64                   `json:"comfort_low"`
+	ComfortHigh   float64                   `json:"comfort_high"`
+	Hysteresis    float64                   `json:"hysteresis"`
+	MinOnSeconds  int                       `json:"min_on_seconds"`
+	MinOffSeconds int                       `json:"min_off_seconds"`
+	UpdatedBy     uint                      `json:"updated_by,omitempty"`
+	UpdatedAt     *time.Time                `json:"updated_at,omitempty"`
+	Decisions     []ComfortDecisionResponse `json:"decisions"` // 最近一次控制迴圈的決策
+}
+
+// ComfortDecisionResponse 控制目標啟停決策
+type ComfortDecisionResponse struct {
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	HeatIndex  float64   `json:"heat_index"`
+	Running    bool      `json:"running"`
+	Action     string    `json:"action"` // start, stop, hold
+	Reason     string    `json:"reason"`
+	DryRun     bool      `json:"dry_run"`
+	DecidedAt  time.Time `json:"decided_at"`
+}
+
+// NewComfortSettingResponse 從實體創建響應 DTO
+func NewComfortSettingResponse(setting *entities.ComfortSetting, areaName string, decisions []*entities.ComfortDecision) *ComfortSettingResponse {
+	response := &ComfortSettingResponse{
+		AreaID:        setting.AreaID,
+		AreaName:      areaName,
+		Configured:    setting.ID != 0,
+		Enabled:       setting.Enabled,
+		DryRun:        setting.DryRun,
+		ComfortLow:    setting.ComfortLow,
+		ComfortHigh:   setting.ComfortHigh,
+		Hysteresis:    setting.Hysteresis,
+		MinOnSeconds:  setting.MinOnSeconds,
+		MinOffSeconds: setting.MinOffSeconds,
+		UpdatedBy:     setting.UpdatedBy,
+		Decisions:     make([]ComfortDecisionResponse, 0, len(decisions)),
+	}
+	if !setting.UpdatedAt.IsZero() {
+		updatedAt := setting.UpdatedAt
+		response.UpdatedAt = &updatedAt
+	}
+	for _, decision := range decisions {
+		response.Decisions = append(response.Decisions, ComfortDecisionResponse{
+			TargetType: decision.TargetType,
+			TargetID:   decision.TargetID,
+			HeatIndex:  decision.HeatIndex,
+			Running:    decision.Running,
+			Action:     decision.Action,
+			Reason:     decision.Reason,
+			DryRun:     decision.DryRun,
+			DecidedAt:  decision.DecidedAt,
+		})
+	}
+	return response
+}
@@ -33,7 +33,7 @@ type UpdateCompanyRequest struct {
 type CreateCompanyManagerRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 // AddCompanyMemberRequest 添加公司成員請求
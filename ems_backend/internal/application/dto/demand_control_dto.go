@@ -0,0 +1,166 @@
+package dto
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/demand_control/entities"
+)
+
+// DemandSettingRequest 需量控制設定請求 (未提供的欄位維持原值)
+type DemandSettingRequest struct {
+	Enabled        *bool                  `json:"enabled"`
+	DryRun         *bool                  `json:"dry_run"`
+	ContractKW     *float64               `json:"contract_kw"`
+	ShedRatio      *float64               `json:"shed_ratio"`    // 例如 0.95
+	RestoreRatio   *float64               `json:"restore_ratio"` // 例如 0.85
+	MinShedSeconds *int                   `json:"min_shed_seconds"`
+	Loads          []SheddableLoadRequest `json:"loads"` // 卸載優先順序，排在前面的先卸載；nil 表示不變
+}
+
+// SheddableLoadRequest 可卸載負載
+type SheddableLoadRequest struct {
+	CompanyDeviceID uint   `json:"company_device_id" binding:"required"`
+	TargetType      string `json:"target_type" binding:"required"` // ac_unit, compressor
+	TargetID        string `json:"target_id" binding:"required"`
+}
+
+// DemandSettingResponse 需量控制設定響應
+type DemandSettingResponse struct {
+	CompanyID          uint                   `json:"company_id"`
+	Configured         bool                   `json:"configured"`
+	Enabled            bool                   `json:"enabled"`
+	DryRun             bool                   `json:"dry_run"`
+	ContractKW         float64                `json:"contract_kw"`
+	ShedRatio          float64                `json:"shed_ratio"`
+	RestoreRatio       float64                `json:"restore_ratio"`
+	ShedThresholdKW    float64                `json:"shed_threshold_kw"`
+	RestoreThresholdKW float64                `json:"restore_threshold_kw"`
+	MinShedSeconds     int                    `json:"min_shed_seconds"`
+	Loads              []SheddableLoadRequest `json:"loads"`
+	ModifyID           uint                   `json:"modify_id,omitempty"`
+	ModifyTime         *time.Time             `json:"modify_time,omitempty"`
+}
+
+// DemandStatusResponse 目前需量狀態
+type DemandStatusResponse struct {
+	RollingKW       float64   `json:"rolling_kw"`
+	WindowStart     time.Time `json:"window_start"`
+	WindowEnd       time.Time `json:"window_end"`
+	WindowAverageKW float64   `json:"window_average_kw"`
+	CurrentKW       float64   `json:"current_kw"`
+	PredictedKW     float64   `json:"predicted_kw"`
+	UsageRatio      float64   `json:"usage_ratio"` // 預測需量 / 契約容量
+	MeterCount      int       `json:"meter_count"`
+	CalculatedAt    time.Time `json:"calculated_at"`
+}
+
+// ActiveShedResponse 卸載中的負載
+type ActiveShedResponse struct {
+	CompanyDeviceID uint      `json:"company_device_id"`
+	TargetType      string    `json:"target_type"`
+	TargetID        string    `json:"target_id"`
+	DryRun          bool      `json:"dry_run"`
+	ShedAt          time.Time `json:"shed_at"`
+}
+
+// DemandEventResponse 卸載 / 復歸事件
+type DemandEventResponse struct {
+	ID              uint      `json:"id"`
+	EventType       string    `json:"event_type"` // shed, restore
+	CompanyDeviceID uint      `json:"company_device_id"`
+	TargetType      string    `json:"target_type"`
+	TargetID        string    `json:"target_id"`
+	RollingKW       float64   `json:"rolling_kw"`
+	PredictedKW     float64   `json:"predicted_kw"`
+	ContractKW      float64   `json:"contract_kw"`
+	DryRun          bool      `json:"dry_run"`
+	CommandID       string    `json:"command_id,omitempty"`
+	Message         string    `json:"message,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// DemandDashboardResponse Dashboard 需量總覽
+type DemandDashboardResponse struct {
+	CompanyID   uint                   `json:"company_id"`
+	Setting     *DemandSettingResponse `json:"setting"`
+	Status      *DemandStatusResponse  `json:"status"`
+	ActiveSheds []ActiveShedResponse   `json:"active_sheds"`
+	Events      []DemandEventResponse  `json:"events"`
+}
+
+// NewDemandSettingResponse 從實體創建響應 DTO
+func NewDemandSettingResponse(setting *entities.DemandSetting, configured bool) *DemandSettingResponse {
+	response := &DemandSettingResponse{
+		CompanyID:          setting.CompanyID,
+		Configured:         configured,
+		Enabled:            setting.Enabled,
+		DryRun:             setting.DryRun,
+		ContractKW:         setting.ContractKW,
+		ShedRatio:          setting.ShedRatio,
+		RestoreRatio:       setting.RestoreRatio,
+		ShedThresholdKW:    setting.ShedThresholdKW(),
+		RestoreThresholdKW: setting.RestoreThresholdKW(),
+		MinShedSeconds:     setting.MinShedSeconds,
+		Loads:              make([]SheddableLoadRequest, len(setting.Loads)),
+		ModifyID:           setting.ModifyID,
+	}
+	for i, load := range setting.Loads {
+		response.Loads[i] = SheddableLoadRequest{
+			CompanyDeviceID: load.CompanyDeviceID,
+			TargetType:      load.TargetType,
+			TargetID:        load.TargetID,
+		}
+	}
+	if !setting.ModifyTime.IsZero() {
+		modifyTime := setting.ModifyTime
+		response.ModifyTime = &modifyTime
+	}
+	return response
+}
+
+// NewDemandStatusResponse 從需量狀態創建響應 DTO
+func NewDemandStatusResponse(status *entities.DemandStatus, contractKW float64) *DemandStatusResponse {
+	response := &DemandStatusResponse{
+		RollingKW:       status.RollingKW,
+		WindowStart:     status.WindowStart,
+		WindowEnd:       status.WindowEnd,
+		WindowAverageKW: status.WindowAverageKW,
+		CurrentKW:       status.CurrentKW,
+		PredictedKW:     status.PredictedKW,
+		MeterCount:      status.MeterCount,
+		CalculatedAt:    status.CalculatedAt,
+	}
+	if contractKW > 0 {
+		response.UsageRatio = status.PredictedKW / contractKW
+	}
+	return response
+}
+
+// NewActiveShedResponse 從實體創建響應 DTO
+func NewActiveShedResponse(shed *entities.ActiveShed) ActiveShedResponse {
+	return ActiveShedResponse{
+		CompanyDeviceID: shed.Load.CompanyDeviceID,
+		TargetType:      shed.Load.TargetType,
+		TargetID:        shed.Load.TargetID,
+		DryRun:          shed.DryRun,
+		ShedAt:          shed.ShedAt,
+	}
+}
+
+// NewDemandEventResponse 從實體創建響應 DTO
+func NewDemandEventResponse(event *entities.DemandEvent) DemandEventResponse {
+	return DemandEventResponse{
+		ID:              event.ID,
+		EventType:       event.EventType,
+		CompanyDeviceID: event.CompanyDeviceID,
+		TargetType:      event.TargetType,
+		TargetID:        event.TargetID,
+		RollingKW:       event.RollingKW,
+		PredictedKW:     event.PredictedKW,
+		ContractKW:      event.ContractKW,
+		DryRun:          event.DryRun,
+		CommandID:       event.CommandID,
+		Message:         event.Message,
+		CreatedAt:       event.CreatedAt,
+	}
+}
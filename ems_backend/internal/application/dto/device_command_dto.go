@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/device_command/entities"
+)
+
+// DevicePowerCommandRequest 開關機命令請求
+type DevicePowerCommandRequest struct {
+	TargetType string `json:"target_type" binding:"required"` // ac_unit, compressor
+	TargetID   string `json:"target_id" binding:"required"`
+	On         *bool  `json:"on" binding:"required"`
+}
+
+// DeviceModeCommandRequest 運轉模式命令請求
+type DeviceModeCommandRequest struct {
+	TargetID string `json:"target_id" binding:"required"` // ac_unit ID
+	Mode     string `json:"mode" binding:"required"`      // cool, heat, fan, dry, auto
+}
+
+// DeviceSetpointCommandRequest 設定溫度命令請求
+type DeviceSetpointCommandRequest struct {
+	TargetID string   `json:"target_id" binding:"required"` // ac_unit ID
+	Setpoint *float64 `json:"setpoint" binding:"required"`  // °C
+}
+
+// DeviceCommandResponse 遠端控制命令響應
+type DeviceCommandResponse struct {
+	CommandID   string     `json:"command_id"`
+	TargetType  string     `json:"target_type"`
+	TargetID    string     `json:"target_id"`
+	Action      string     `json:"action"`
+	Mode        string     `json:"mode,omitempty"`
+	Setpoint    *float64   `json:"setpoint,omitempty"`
+	Status      string     `json:"status"` // pending, sent, acknowledged, failed, timeout
+	Message     string     `json:"message,omitempty"`
+	RequestedBy uint       `json:"requested_by"`
+	RequestedAt time.Time  `json:"requested_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	AckAt       *time.Time `json:"ack_at,omitempty"`
+}
+
+// NewDeviceCommandResponse 從實體創建響應 DTO
+func NewDeviceCommandResponse(e *entities.DeviceCommand) *DeviceCommandResponse {
+	return &DeviceCommandResponse{
+		CommandID:   e.CommandID,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Action:      e.Action,
+		Mode:        e.Mode,
+		Setpoint:    e.Setpoint,
+		Status:      e.Status,
+		Message:     e.Message,
+		RequestedBy: e.RequestedBy,
+		RequestedAt: e.RequestedAt,
+		SentAt:      e.SentAt,
+		AckAt:       e.AckAt,
+	}
+}
@@ -10,6 +10,8 @@ import (
 type DeviceResponse struct {
 	ID         uint      `json:"id"`
 	SN         string    `json:"sn"`
+	Model      string    `json:"model"`
+	Notes      string    `json:"notes"`
 	CreateID   uint      `json:"create_id"`
 	CreateTime time.Time `json:"create_time"`
 	ModifyID   uint      `json:"modify_id"`
@@ -18,12 +20,16 @@ type DeviceResponse struct {
 
 // DeviceCreateRequest 創建設備請求 DTO
 type DeviceCreateRequest struct {
-	SN string `json:"sn" binding:"required"`
+	SN    string `json:"sn" binding:"required"`
+	Model string `json:"model"`
+	Notes string `json:"notes"`
 }
 
 // DeviceUpdateRequest 更新設備請求 DTO
 type DeviceUpdateRequest struct {
-	SN string `json:"sn" binding:"required"`
+	SN    string  `json:"sn" binding:"required"`
+	Model *string `json:"model"` // 未提供時保留原值
+	Notes *string `json:"notes"` // 未提供時保留原值
 }
 
 // NewDeviceResponse 從實體創建響應 DTO
@@ -31,6 +37,8 @@ func NewDeviceResponse(e *entities.Device) *DeviceResponse {
 	return &DeviceResponse{
 		ID:         e.ID,
 		SN:         e.SN,
+		Model:      e.Model,
+		Notes:      e.Notes,
 		CreateID:   e.CreateID,
 		CreateTime: e.CreateTime,
 		ModifyID:   e.ModifyID,
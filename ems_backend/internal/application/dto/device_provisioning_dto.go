@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/device/entities"
+)
+
+// DeviceImportOptions CSV 匯入選項
+type DeviceImportOptions struct {
+	DryRun          bool          // 僅驗證不寫入
+	IssueClaimCodes bool          // 為新建設備簽發認領碼
+	ClaimCodeTTL    time.Duration // 認領碼有效期限
+}
+
+// DeviceImportRowResult 單列匯入結果
+type DeviceImportRowResult struct {
+	Line      int                `json:"line"`
+	SN        string             `json:"sn"`
+	Model     string             `json:"model"`
+	Notes     string             `json:"notes"`
+	Status    string             `json:"status"` // valid (dry run) / created / error
+	Errors    []string           `json:"errors,omitempty"`
+	DeviceID  uint               `json:"device_id,omitempty"`
+	ClaimCode *ClaimCodeResponse `json:"claim_code,omitempty"`
+}
+
+// DeviceImportReport CSV 匯入報告
+type DeviceImportReport struct {
+	DryRun  bool                     `json:"dry_run"`
+	Total   int                      `json:"total"`
+	Valid   int                      `json:"valid"`
+	Created int                      `json:"created"`
+	Failed  int                      `json:"failed"`
+	Rows    []*DeviceImportRowResult `json:"rows"`
+}
+
+// IssueClaimCodeRequest 簽發認領碼請求
+type IssueClaimCodeRequest struct {
+	TTLHours int `json:"ttl_hours"` // 預設 168 (7 天)，最長 720
+}
+
+// RedeemClaimCodeRequest 兌換認領碼請求
+type RedeemClaimCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ClaimCodeResponse 認領碼響應 (Code 僅於簽發時返回一次)
+type ClaimCodeResponse struct {
+	ID         uint       `json:"id"`
+	DeviceID   uint       `json:"device_id"`
+	Code       string     `json:"code,omitempty"`
+	CodeHint   string     `json:"code_hint"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	IssuedBy   uint       `json:"issued_by"`
+	IssuedAt   time.Time  `json:"issued_at"`
+	RedeemedBy *uint      `json:"redeemed_by,omitempty"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty"`
+	CompanyID  *uint      `json:"company_id,omitempty"`
+}
+
+// NewClaimCodeResponse 從實體創建認領碼響應
+func NewClaimCodeResponse(code *entities.ClaimCode, plaintext string) *ClaimCodeResponse {
+	return &ClaimCodeResponse{
+		ID:         code.ID,
+		DeviceID:   code.DeviceID,
+		Code:       plaintext,
+		CodeHint:   code.CodeHint,
+		Status:     code.Status,
+		ExpiresAt:  code.ExpiresAt,
+		IssuedBy:   code.IssuedBy,
+		IssuedAt:   code.IssuedAt,
+		RedeemedBy: code.RedeemedBy,
+		RedeemedAt: code.RedeemedAt,
+		CompanyID:  code.CompanyID,
+	}
+}
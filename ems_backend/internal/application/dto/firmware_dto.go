@@ -0,0 +1,157 @@
+package dto
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/firmware/entities"
+	"ems_backend/internal/domain/firmware/services"
+)
+
+// CreateFirmwareRequest 新增韌體檔案請求
+type CreateFirmwareRequest struct {
+	Version         string `json:"version" binding:"required"`
+	HardwareVersion string `json:"hardware_version"` // 空字串表示不限硬體
+	URL             string `json:"url" binding:"required"`
+	SHA256          string `json:"sha256" binding:"required"`
+	SizeBytes       int64  `json:"size_bytes" binding:"required"`
+	ReleaseNotes    string `json:"release_notes"`
+}
+
+// FirmwareResponse 韌體檔案響應
+type FirmwareResponse struct {
+	ID              uint      `json:"id"`
+	Version         string    `json:"version"`
+	HardwareVersion string    `json:"hardware_version"`
+	URL             string    `json:"url"`
+	SHA256          string    `json:"sha256"`
+	SizeBytes       int64     `json:"size_bytes"`
+	ReleaseNotes    string    `json:"release_notes"`
+	CreateID        uint      `json:"create_id"`
+	CreateTime      time.Time `json:"create_time"`
+}
+
+// DeviceFirmwareResponse 單一設備的版本盤點
+type DeviceFirmwareResponse struct {
+	DeviceID        uint       `json:"device_id"`
+	DeviceSN        string     `json:"device_sn"`
+	FirmwareVersion string     `json:"firmware_version"` // 尚未回報時為空
+	HardwareVersion string     `json:"hardware_version"`
+	ReportedAt      *time.Time `json:"reported_at"`
+}
+
+// FirmwareInventoryResponse 設備版本盤點
+type FirmwareInventoryResponse struct {
+	Devices  []*DeviceFirmwareResponse `json:"devices"`
+	Versions map[string]int            `json:"versions"` // 韌體版本 -> 設備數
+	Unknown  int                       `json:"unknown"`  // 尚未回報版本的設備數
+}
+
+// CreateCampaignRequest 建立韌體發布活動請求 (percentage 與 device_ids 擇一)
+type CreateCampaignRequest struct {
+	Name                 string   `json:"name" binding:"required"`
+	FirmwareID           uint     `json:"firmware_id" binding:"required"`
+	Percentage           int      `json:"percentage"` // 1-100，占符合條件設備的比例
+	DeviceIDs            []uint   `json:"device_ids"`
+	BatchSize            int      `json:"batch_size"`       // 預設 10
+	MaxFailureRate       *float64 `json:"max_failure_rate"` // 預設 0.2
+	MinSamples           int      `json:"min_samples"`      // 預設 5
+	DeviceTimeoutSeconds int      `json:"device_timeout_seconds"`
+}
+
+// CampaignResponse 韌體發布活動響應
+type CampaignResponse struct {
+	ID                   uint                      `json:"id"`
+	Name                 string                    `json:"name"`
+	FirmwareID           uint                      `json:"firmware_id"`
+	FirmwareVersion      string                    `json:"firmware_version"`
+	TargetMode           string                    `json:"target_mode"`
+	Percentage           int                       `json:"percentage,omitempty"`
+	DeviceIDs            []uint                    `json:"device_ids,omitempty"`
+	BatchSize            int                       `json:"batch_size"`
+	MaxFailureRate       float64                   `json:"max_failure_rate"`
+	MinSamples           int                       `json:"min_samples"`
+	DeviceTimeoutSeconds int                       `json:"device_timeout_seconds"`
+	Status               string                    `json:"status"`
+	HaltReason           string                    `json:"halt_reason,omitempty"`
+	Progress             entities.CampaignProgress `json:"progress"`
+	CreateID             uint                      `json:"create_id"`
+	CreateTime           time.Time                 `json:"create_time"`
+	ModifyTime           time.Time                 `json:"modify_time"`
+	FinishedAt           *time.Time                `json:"finished_at,omitempty"`
+}
+
+// CampaignDeviceResponse 活動中單一設備的進度
+type CampaignDeviceResponse struct {
+	DeviceID    uint       `json:"device_id"`
+	DeviceSN    string     `json:"device_sn"`
+	FromVersion string     `json:"from_version"`
+	CommandID   string     `json:"command_id,omitempty"`
+	Status      string     `json:"status"`
+	Progress    int        `json:"progress"`
+	Message     string     `json:"message,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// CampaignDetailResponse 活動詳情 (含各設備進度)
+type CampaignDetailResponse struct {
+	*CampaignResponse
+	Devices []*CampaignDeviceResponse `json:"devices"`
+	Skipped []services.SkippedTarget  `json:"skipped,omitempty"` // 僅建立時返回
+}
+
+// NewFirmwareResponse 從實體創建韌體檔案響應
+func NewFirmwareResponse(firmware *entities.Firmware) *FirmwareResponse {
+	return &FirmwareResponse{
+		ID:              firmware.ID,
+		Version:         firmware.Version,
+		HardwareVersion: firmware.HardwareVersion,
+		URL:             firmware.URL,
+		SHA256:          firmware.SHA256,
+		SizeBytes:       firmware.SizeBytes,
+		ReleaseNotes:    firmware.ReleaseNotes,
+		CreateID:        firmware.CreateID,
+		CreateTime:      firmware.CreateTime,
+	}
+}
+
+// NewCampaignResponse 從實體創建活動響應
+func NewCampaignResponse(campaign *entities.Campaign, firmwareVersion string, progress entities.CampaignProgress) *CampaignResponse {
+	return &CampaignResponse{
+		ID:                   campaign.ID,
+		Name:                 campaign.Name,
+		FirmwareID:           campaign.FirmwareID,
+		FirmwareVersion:      firmwareVersion,
+		TargetMode:           campaign.TargetMode,
+		Percentage:           campaign.Percentage,
+		DeviceIDs:            campaign.DeviceIDs,
+		BatchSize:            campaign.BatchSize,
+		MaxFailureRate:       campaign.MaxFailureRate,
+		MinSamples:           campaign.MinSamples,
+		DeviceTimeoutSeconds: campaign.DeviceTimeoutSeconds,
+		Status:               campaign.Status,
+		HaltReason:           campaign.HaltReason,
+		Progress:             progress,
+		CreateID:             campaign.CreateID,
+		CreateTime:           campaign.CreateTime,
+		ModifyTime:           campaign.ModifyTime,
+		FinishedAt:           campaign.FinishedAt,
+	}
+}
+
+// NewCampaignDeviceResponse 從實體創建設備進度響應
+func NewCampaignDeviceResponse(device *entities.CampaignDevice) *CampaignDeviceResponse {
+	return &CampaignDeviceResponse{
+		DeviceID:    device.DeviceID,
+		DeviceSN:    device.DeviceSN,
+		FromVersion: device.FromVersion,
+		CommandID:   device.CommandID,
+		Status:      device.Status,
+		Progress:    device.Progress,
+		Message:     device.Message,
+		SentAt:      device.SentAt,
+		UpdatedAt:   device.UpdatedAt,
+		FinishedAt:  device.FinishedAt,
+	}
+}
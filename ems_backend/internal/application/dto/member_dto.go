@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // MemberDTO 成員數據傳輸對象
 type MemberDTO struct {
 	ID       uint       `json:"id"`
@@ -7,6 +9,10 @@ type MemberDTO struct {
 	Email    string     `json:"email"`
 	IsEnable bool       `json:"is_enable"`
 	Roles    []RoleInfo `json:"roles"`
+
+	// 連續登入失敗鎖定狀態；locked 為 true 且無 locked_until 時需管理員解鎖
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
 }
 
 // MemberUpdateStatusRequest 更新成員狀態請求
@@ -24,7 +30,7 @@ type RoleInfo struct {
 type MemberCreateRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	IsEnable bool   `json:"is_enable"`
 	RoleIDs  []uint `json:"role_ids" binding:"required,min=1"`
 }
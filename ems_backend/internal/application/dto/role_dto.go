@@ -2,20 +2,24 @@ package dto
 
 // RoleRequest 角色創建/更新請求
 type RoleRequest struct {
-	ID          uint   `json:"id"`
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
-	Sort        int    `json:"sort"`
-	IsEnable    bool   `json:"is_enable"`
+	ID           uint   `json:"id"`
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description"`
+	Sort         int    `json:"sort"`
+	IsEnable     bool   `json:"is_enable"`
+	MFARequired  bool   `json:"mfa_required"`
+	CompanyScope string `json:"company_scope"` // none / own / subtree / all；建立時空白視為 none，更新時空白保留原值
 }
 
 // RoleResponse 角色響應
 type RoleResponse struct {
-	ID          uint   `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Sort        int    `json:"sort"`
-	IsEnable    bool   `json:"is_enable"`
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Sort         int    `json:"sort"`
+	IsEnable     bool   `json:"is_enable"`
+	MFARequired  bool   `json:"mfa_required"`
+	CompanyScope string `json:"company_scope"`
 }
 
 // AssignPowersRequest 分配權限請求
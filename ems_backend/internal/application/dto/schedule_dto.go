@@ -52,6 +52,7 @@ type ScheduleResponse struct {
 	SyncedAt        *string                       `json:"synced_at,omitempty"`
 	CreatedAt       string                        `json:"created_at"`
 	UpdatedAt       string                        `json:"updated_at"`
+	Drift           *ScheduleDriftResponse        `json:"drift,omitempty"` // 尚未解決的設備排程差異
 }
 
 // DailyRuleResponse - 每日規則響應
@@ -83,10 +84,167 @@ type ActionResponse struct {
 	UpdatedAt   string `json:"updated_at"`
 }
 
+// ============================================
+// Drift DTOs
+// ============================================
+
+// ResolveScheduleDriftRequest - 解決排程漂移請求
+type ResolveScheduleDriftRequest struct {
+	Resolution string `json:"resolution" binding:"required"` // cloud_wins, device_wins
+}
+
+// ScheduleDriftPolicyRequest - 設定排程漂移策略請求
+type ScheduleDriftPolicyRequest struct {
+	Policy string `json:"policy" binding:"required"` // cloud_wins, device_wins, manual
+}
+
+// ScheduleDriftResponse - 排程漂移響應
+type ScheduleDriftResponse struct {
+	ID              uint                       `json:"id"`
+	CompanyDeviceID uint                       `json:"company_device_id"`
+	CloudVersion    int                        `json:"cloud_version"`
+	Differences     []entities.RuleDifference  `json:"differences"`
+	DeviceSchedule  *entities.ScheduleSnapshot `json:"device_schedule,omitempty"`
+	Status          string                     `json:"status"`
+	Resolution      string                     `json:"resolution,omitempty"` // cloud_wins, device_wins, converged
+	ResolvedBy      uint                       `json:"resolved_by,omitempty"`
+	ResolvedAt      *string                    `json:"resolved_at,omitempty"`
+	DetectedAt      string                     `json:"detected_at"`
+}
+
+// ScheduleDriftPolicyResponse - 排程漂移策略響應
+type ScheduleDriftPolicyResponse struct {
+	CompanyID  uint    `json:"company_id"`
+	Policy     string  `json:"policy"`
+	ModifyTime *string `json:"modify_time,omitempty"`
+}
+
+// ============================================
+// Version DTOs
+// ============================================
+
+// RestoreScheduleVersionRequest - 還原排程版本請求
+type RestoreScheduleVersionRequest struct {
+	Sync bool `json:"sync"` // 還原後是否立即同步到設備
+}
+
+// ScheduleVersionResponse - 排程歷史版本響應
+type ScheduleVersionResponse struct {
+	ID           uint                       `json:"id"`
+	Version      int                        `json:"version"`
+	Source       string                     `json:"source"`
+	RestoredFrom int                        `json:"restored_from,omitempty"`
+	CreatedBy    uint                       `json:"created_by"`
+	CreatedAt    string                     `json:"created_at"`
+	Rules        *entities.ScheduleSnapshot `json:"rules,omitempty"`
+}
+
+// ScheduleVersionDiffResponse - 排程版本差異響應
+type ScheduleVersionDiffResponse struct {
+	FromVersion int                      `json:"from_version"`
+	ToVersion   int                      `json:"to_version"`
+	Differences []*VersionDifferenceItem `json:"differences"`
+}
+
+// VersionDifferenceItem - 單一規則差異
+type VersionDifferenceItem struct {
+	Day   string `json:"day,omitempty"`
+	Field string `json:"field"` // rule, run_period, actions, exceptions
+	Kind  string `json:"kind"`  // added, removed, changed
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ============================================
+// Device command DTOs
+// ============================================
+
+// DeviceCommandSummary - 發送到設備的 MQTT 命令摘要 (記錄於審計日誌)
+type DeviceCommandSummary struct {
+	CompanyDeviceID uint                   `json:"company_device_id"`
+	DeviceID        uint                   `json:"device_id"`
+	DeviceSN        string                 `json:"device_sn"`
+	Command         string                 `json:"command"`           // schedule, deviceInfo, getSchedule
+	Payload         map[string]interface{} `json:"payload,omitempty"` // 命令內容摘要，不含完整排程
+}
+
 // ============================================
 // Conversion functions
 // ============================================
 
+// ToScheduleVersionResponse - 轉換排程歷史版本為響應
+func ToScheduleVersionResponse(version *entities.ScheduleVersion) *ScheduleVersionResponse {
+	return &ScheduleVersionResponse{
+		ID:           version.ID,
+		Version:      version.Version,
+		Source:       version.Source,
+		RestoredFrom: version.RestoredFrom,
+		CreatedBy:    version.CreatedBy,
+		CreatedAt:    version.CreatedAt.Format(time.RFC3339),
+		Rules:        version.Rules,
+	}
+}
+
+// ToScheduleVersionDiffResponse - 轉換版本差異為響應
+func ToScheduleVersionDiffResponse(from, to *entities.ScheduleVersion, differences []entities.RuleDifference) *ScheduleVersionDiffResponse {
+	resp := &ScheduleVersionDiffResponse{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Differences: make([]*VersionDifferenceItem, 0, len(differences)),
+	}
+	// Compare 以 (cloud, device) 命名，此處 cloud = from, device = to
+	for _, diff := range differences {
+		resp.Differences = append(resp.Differences, &VersionDifferenceItem{
+			Day:   diff.Day,
+			Field: diff.Field,
+			Kind:  diff.Kind,
+			From:  diff.Cloud,
+			To:    diff.Device,
+		})
+	}
+	return resp
+}
+
+// ToScheduleDriftResponse - 轉換排程漂移為響應
+func ToScheduleDriftResponse(drift *entities.ScheduleDrift) *ScheduleDriftResponse {
+	if drift == nil {
+		return nil
+	}
+
+	resp := &ScheduleDriftResponse{
+		ID:              drift.ID,
+		CompanyDeviceID: drift.CompanyDeviceID,
+		CloudVersion:    drift.CloudVersion,
+		Differences:     drift.Differences,
+		DeviceSchedule:  drift.DeviceSnapshot,
+		Status:          drift.Status,
+		Resolution:      drift.Resolution,
+		ResolvedBy:      drift.ResolvedBy,
+		DetectedAt:      drift.DetectedAt.Format(time.RFC3339),
+	}
+	if resp.Differences == nil {
+		resp.Differences = []entities.RuleDifference{}
+	}
+	if drift.ResolvedAt != nil {
+		formatted := drift.ResolvedAt.Format(time.RFC3339)
+		resp.ResolvedAt = &formatted
+	}
+	return resp
+}
+
+// ToScheduleDriftPolicyResponse - 轉換排程漂移策略為響應
+func ToScheduleDriftPolicyResponse(policy *entities.ScheduleDriftPolicy) *ScheduleDriftPolicyResponse {
+	resp := &ScheduleDriftPolicyResponse{
+		CompanyID: policy.CompanyID,
+		Policy:    policy.Policy,
+	}
+	if !policy.ModifyTime.IsZero() {
+		formatted := policy.ModifyTime.Format(time.RFC3339)
+		resp.ModifyTime = &formatted
+	}
+	return resp
+}
+
 // ToScheduleResponse - 轉換完整排程為響應
 func ToScheduleResponse(fullSchedule *entities.ScheduleWithRules) *ScheduleResponse {
 	if fullSchedule == nil || fullSchedule.Schedule == nil {
@@ -0,0 +1,51 @@
+package dto
+
+import "time"
+
+// ServiceAccountCreateRequest 建立服務帳號
+type ServiceAccountCreateRequest struct {
+	Name        string `json:"name" binding:"required"` // 3-20 個英數字或底線
+	Description string `json:"description"`
+	CompanyID   *uint  `json:"company_id"` // 綁定公司；存取範圍 (含子公司與否) 依 Key 的角色
+}
+
+// ServiceAccountDTO 服務帳號
+type ServiceAccountDTO struct {
+	ID          uint      `json:"id"` // 即 member_id
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Description string    `json:"description"`
+	CompanyID   *uint     `json:"company_id,omitempty"`
+	IsEnable    bool      `json:"is_enable"`
+	CreatedBy   uint      `json:"created_by"`
+	CreateTime  time.Time `json:"create_time"`
+}
+
+// APIKeyCreateRequest 建立 API Key
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	RoleID    uint       `json:"role_id" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // 未指定時依 API_KEY_MAX_TTL
+}
+
+// APIKeyDTO API Key (不含明文與雜湊)
+type APIKeyDTO struct {
+	ID               uint       `json:"id"`
+	ServiceAccountID uint       `json:"service_account_id"`
+	RoleID           uint       `json:"role_id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP       string     `json:"last_used_ip,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	Active           bool       `json:"active"`
+	CreatedBy        uint       `json:"created_by"`
+	CreateTime       time.Time  `json:"create_time"`
+}
+
+// APIKeyCreatedResponse 建立 API Key 的回應，Key 明文只會出現這一次
+type APIKeyCreatedResponse struct {
+	APIKeyDTO
+	Key string `json:"key"`
+}
@@ -1,19 +1,46 @@
 package services
 
 import (
+	"context"
 	"ems_backend/internal/application/dto"
 	"ems_backend/internal/domain/audit_log/entities"
 	"ems_backend/internal/domain/audit_log/services"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// 審計日誌匯出格式
+const (
+	AuditExportCSV   = "csv"
+	AuditExportJSONL = "jsonl"
+)
+
+// auditExportColumns CSV 欄位順序
+var auditExportColumns = []string{
+	"id", "sequence", "create_time", "member_id", "role_id", "action", "resource_type", "resource_id",
+	"status", "error_message", "ip_address", "user_agent", "details", "prev_hash", "hash",
+}
+
 type AuditLogApplicationService struct {
-	auditLogService *services.AuditLogService
+	auditLogService  *services.AuditLogService
+	retentionService *services.AuditRetentionService
 }
 
 func NewAuditLogApplicationService(auditLogService *services.AuditLogService) *AuditLogApplicationService {
 	return &AuditLogApplicationService{auditLogService: auditLogService}
 }
 
+// SetRetentionService 設置保留期限服務 (未設置時不清理審計日誌)
+func (s *AuditLogApplicationService) SetRetentionService(retentionService *services.AuditRetentionService) {
+	s.retentionService = retentionService
+}
+
 // Query 根據過濾條件查詢審計日誌
 func (s *AuditLogApplicationService) Query(req *dto.AuditLogQueryRequest) (*dto.AuditLogListResponse, error) {
 	filter := &entities.AuditLogFilter{
@@ -56,6 +83,9 @@ func (s *AuditLogApplicationService) Query(req *dto.AuditLogQueryRequest) (*dto.
 			Status:       log.Status,
 			ErrorMessage: log.ErrorMessage,
 			CreateTime:   log.CreateTime,
+			Sequence:     log.Sequence,
+			PrevHash:     log.PrevHash,
+			Hash:         log.Hash,
 		}
 	}
 
@@ -85,6 +115,9 @@ func (s *AuditLogApplicationService) GetByID(id uint) (*dto.AuditLogResponse, er
 		Status:       log.Status,
 		ErrorMessage: log.ErrorMessage,
 		CreateTime:   log.CreateTime,
+		Sequence:     log.Sequence,
+		PrevHash:     log.PrevHash,
+		Hash:         log.Hash,
 	}, nil
 }
 
@@ -123,6 +156,9 @@ func (s *AuditLogApplicationService) GetByMemberID(memberID uint, limit int, off
 			Status:       log.Status,
 			ErrorMessage: log.ErrorMessage,
 			CreateTime:   log.CreateTime,
+			Sequence:     log.Sequence,
+			PrevHash:     log.PrevHash,
+			Hash:         log.Hash,
 		}
 	}
 
@@ -167,6 +203,9 @@ func (s *AuditLogApplicationService) GetByResourceType(resourceType string, limi
 			Status:       log.Status,
 			ErrorMessage: log.ErrorMessage,
 			CreateTime:   log.CreateTime,
+			Sequence:     log.Sequence,
+			PrevHash:     log.PrevHash,
+			Hash:         log.Hash,
 		}
 	}
 
@@ -175,3 +214,156 @@ func (s *AuditLogApplicationService) GetByResourceType(resourceType string, limi
 		Logs:  logResponses,
 	}, nil
 }
+
+// VerifyChain 驗證審計日誌的雜湊鏈
+func (s *AuditLogApplicationService) VerifyChain(fromSequence uint64) (*dto.AuditChainVerificationResponse, error) {
+	result, err := s.auditLogService.VerifyChain(fromSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]dto.AuditChainIssue, len(result.Issues))
+	for i, issue := range result.Issues {
+		issues[i] = dto.AuditChainIssue{
+			Kind:     issue.Kind,
+			Sequence: issue.Sequence,
+			LogID:    issue.LogID,
+			Missing:  issue.Missing,
+		}
+	}
+
+	return &dto.AuditChainVerificationResponse{
+		Valid:         result.Valid,
+		Checked:       result.Checked,
+		FirstSequence: result.FirstSequence,
+		LastSequence:  result.LastSequence,
+		Issues:        issues,
+		Truncated:     result.Truncated,
+	}, nil
+}
+
+// Export 將符合條件的審計日誌以 CSV 或 JSON Lines 寫入 w (忽略分頁參數)
+func (s *AuditLogApplicationService) Export(req *dto.AuditLogQueryRequest, format string, w io.Writer) error {
+	filter := &entities.AuditLogFilter{
+		MemberID:     req.MemberID,
+		RoleID:       req.RoleID,
+		Action:       req.Action,
+		ResourceType: req.ResourceType,
+		Status:       req.Status,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+	}
+
+	switch format {
+	case AuditExportJSONL:
+		encoder := json.NewEncoder(w)
+		return s.auditLogService.Export(filter, func(entry *entities.AuditLog) error {
+			return encoder.Encode(entry)
+		})
+	case AuditExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(auditExportColumns); err != nil {
+			return err
+		}
+		err := s.auditLogService.Export(filter, func(entry *entities.AuditLog) error {
+			return writer.Write(auditExportRecord(entry))
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// auditExportRecord 將審計日誌轉為 CSV 欄位
+func auditExportRecord(entry *entities.AuditLog) []string {
+	resourceID := ""
+	if entry.ResourceID != nil {
+		resourceID = strconv.FormatUint(uint64(*entry.ResourceID), 10)
+	}
+	details := ""
+	if entry.Details != nil {
+		data, _ := json.Marshal(entry.Details)
+		details = string(data)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		strconv.FormatUint(entry.Sequence, 10),
+		entry.CreateTime.Format(time.RFC3339Nano),
+		strconv.FormatUint(uint64(entry.MemberID), 10),
+		strconv.FormatUint(uint64(entry.RoleID), 10),
+		csvSafe(entry.Action),
+		csvSafe(entry.ResourceType),
+		resourceID,
+		csvSafe(entry.Status),
+		csvSafe(entry.ErrorMessage),
+		csvSafe(entry.IPAddress),
+		csvSafe(entry.UserAgent),
+		csvSafe(details),
+		entry.PrevHash,
+		entry.Hash,
+	}
+}
+
+// csvSafe 避免試算表把使用者輸入的內容當成公式執行
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// StartRetentionLoop 啟動時及之後每隔 interval 歸檔並刪除超過保留期限的審計日誌
+func (s *AuditLogApplicationService) StartRetentionLoop(ctx context.Context, interval time.Duration) {
+	if s.retentionService == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[AuditLog] Retention loop started (retention: %s, interval: %s)", s.retentionService.Retention(), interval)
+	for {
+		deleted, err := s.retentionService.Run()
+		if err != nil {
+			log.Printf("[AuditLog] Failed to apply retention: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("[AuditLog] Archived and deleted %d audit log(s)", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[AuditLog] Retention loop stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartChainHeadLoop 啟動時及之後每隔 interval 將雜湊鏈的鏈尾記錄到資料庫以外
+func (s *AuditLogApplicationService) StartChainHeadLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[AuditLog] Chain head loop started (interval: %s)", interval)
+	for {
+		if _, err := s.auditLogService.RecordHead(); err != nil {
+			log.Printf("[AuditLog] Failed to record chain head: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("[AuditLog] Chain head loop stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,13 +1,26 @@
 package services
 
 import (
+	"context"
 	"ems_backend/internal/application/dto"
+	audit_log_services "ems_backend/internal/domain/audit_log/services"
+	auth_entities "ems_backend/internal/domain/auth/entities"
 	"ems_backend/internal/domain/auth/services"
+	member_role_repositories "ems_backend/internal/domain/member_role/repositories"
+	"errors"
+	"log"
 	"strconv"
+	"time"
 )
 
 type AuthApplicationService struct {
-	authService *services.AuthService
+	authService          *services.AuthService
+	passwordResetService *services.PasswordResetService
+	auditLogService      *audit_log_services.AuditLogService
+	memberRoleRepo       member_role_repositories.MemberRoleRepository
+	mfaService           *services.MFAService
+	sessionService       *services.SessionService
+	oidcService          *services.OIDCService
 }
 
 func NewAuthApplicationService(authService *services.AuthService) *AuthApplicationService {
@@ -16,15 +29,222 @@ func NewAuthApplicationService(authService *services.AuthService) *AuthApplicati
 	}
 }
 
-func (s *AuthApplicationService) Login(request *dto.LoginRequest) (*dto.APIResponse, error) {
-	authResult, err := s.authService.Login(request.Account, request.Password)
+// SetPasswordResetService 設定忘記密碼服務
+func (s *AuthApplicationService) SetPasswordResetService(passwordResetService *services.PasswordResetService) {
+	s.passwordResetService = passwordResetService
+}
+
+// SetMFAService 設定多因素驗證服務 (自助設定 / 停用)
+func (s *AuthApplicationService) SetMFAService(mfaService *services.MFAService) {
+	s.mfaService = mfaService
+}
+
+// SetSessionService 設定會話服務 (查詢與撤銷登入中的會話)
+func (s *AuthApplicationService) SetSessionService(sessionService *services.SessionService) {
+	s.sessionService = sessionService
+}
+
+// SetOIDCService 設定單一登入服務 (列出身分提供者與產生登入網址)
+func (s *AuthApplicationService) SetOIDCService(oidcService *services.OIDCService) {
+	s.oidcService = oidcService
+}
+
+// SetAuditLogService 設定審計日誌服務 (登入、刷新 token、登出、鎖定與解鎖)
+// 審計日誌需要角色，以會員的第一個角色記錄
+func (s *AuthApplicationService) SetAuditLogService(auditLogService *audit_log_services.AuditLogService, memberRoleRepo member_role_repositories.MemberRoleRepository) {
+	s.auditLogService = auditLogService
+	s.memberRoleRepo = memberRoleRepo
+}
+
+// Login 登入
+// 節流或帳號鎖定時返回 *services.LoginError，由 handler 回應 429 / 423 與 Retry-After
+func (s *AuthApplicationService) Login(request *dto.LoginRequest, clientIP, userAgent string) (*dto.APIResponse, error) {
+	authResult, err := s.authService.Login(request.Account, request.Password, clientIP, userAgent)
 	if err != nil {
+		var loginErr *services.LoginError
+		if errors.As(err, &loginErr) {
+			s.auditLoginFailure(loginErr, clientIP, userAgent)
+			if !errors.Is(loginErr, services.ErrInvalidCredentials) {
+				return nil, loginErr
+			}
+		}
 		return &dto.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, nil
 	}
 
+	// 需要 MFA：返回挑戰憑證，以 /auth/mfa/verify 完成登入
+	if authResult.MFARequired {
+		return &dto.APIResponse{
+			Success: true,
+			Data: &dto.MFAChallengeResponse{
+				MFARequired:        true,
+				ChallengeToken:     authResult.MFAChallengeToken,
+				EnrollmentRequired: authResult.MFAEnrollmentRequired,
+				ExpiresIn:          authResult.ExpiresIn,
+				Member: dto.MemberInfo{
+					ID:   authResult.Member.ID,
+					Name: authResult.Member.Name,
+				},
+			},
+		}, nil
+	}
+
+	s.auditAuthEvent(authResultMemberID(authResult), "LOGIN", map[string]interface{}{"method": "password"}, clientIP, userAgent, "")
+
+	return &dto.APIResponse{
+		Success: true,
+		Data:    toAuthResponse(authResult),
+	}, nil
+}
+
+// VerifyMFA 兩階段登入第二步：驗證 TOTP 或復原碼後簽發 token
+func (s *AuthApplicationService) VerifyMFA(request *dto.MFAVerifyRequest, clientIP, userAgent string) (*dto.APIResponse, error) {
+	authResult, err := s.authService.CompleteMFALogin(request.ChallengeToken, request.Code, clientIP, userAgent)
+	if err != nil {
+		var loginErr *services.LoginError
+		if errors.As(err, &loginErr) {
+			s.auditLoginFailure(loginErr, clientIP, userAgent)
+			if !errors.Is(loginErr, auth_entities.ErrMFACodeInvalid) {
+				return nil, loginErr
+			}
+		} else if !isMFAClientError(err) {
+			return nil, err
+		}
+		return &dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.auditAuthEvent(authResultMemberID(authResult), "LOGIN", map[string]interface{}{"method": "password", "mfa": true}, clientIP, userAgent, "")
+
+	return &dto.APIResponse{
+		Success: true,
+		Data:    toAuthResponse(authResult),
+	}, nil
+}
+
+// BeginChallengeEnrollment 角色要求 MFA 但尚未設定時，於登入途中產生驗證器密鑰
+func (s *AuthApplicationService) BeginChallengeEnrollment(request *dto.MFAChallengeEnrollRequest) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	challenge, err := s.mfaService.ResolveChallenge(request.ChallengeToken)
+	if err != nil {
+		return mfaErrorResponse(err)
+	}
+	enrollment, err := s.mfaService.BeginEnrollment(challenge.MemberID)
+	if err != nil {
+		return mfaErrorResponse(err)
+	}
+	return &dto.APIResponse{Success: true, Data: enrollment}, nil
+}
+
+// MFAStatus 查詢自己的 MFA 狀態
+func (s *AuthApplicationService) MFAStatus(memberID uint) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	status, err := s.mfaService.Status(memberID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.APIResponse{Success: true, Data: status}, nil
+}
+
+// BeginMFAEnrollment 產生驗證器密鑰與 otpauth URI (尚未啟用)
+func (s *AuthApplicationService) BeginMFAEnrollment(memberID uint) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	enrollment, err := s.mfaService.BeginEnrollment(memberID)
+	if err != nil {
+		return mfaErrorResponse(err)
+	}
+	return &dto.APIResponse{Success: true, Data: enrollment}, nil
+}
+
+// ActivateMFA 以驗證碼確認並啟用 MFA，返回復原碼
+func (s *AuthApplicationService) ActivateMFA(memberID uint, request *dto.MFACodeRequest) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	codes, err := s.mfaService.Activate(memberID, request.Code)
+	if err != nil {
+		return mfaErrorResponse(err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data: dto.MFARecoveryCodesResponse{
+			RecoveryCodes: codes,
+			Message:       "MFA enabled, store these recovery codes in a safe place",
+		},
+	}, nil
+}
+
+// RegenerateRecoveryCodes 重新產生復原碼
+func (s *AuthApplicationService) RegenerateRecoveryCodes(memberID uint, request *dto.MFACodeRequest) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	codes, err := s.mfaService.RegenerateRecoveryCodes(memberID, request.Code)
+	if err != nil {
+		return mfaErrorResponse(err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data: dto.MFARecoveryCodesResponse{
+			RecoveryCodes: codes,
+			Message:       "previous recovery codes are no longer valid",
+		},
+	}, nil
+}
+
+// DisableMFA 停用自己的 MFA
+func (s *AuthApplicationService) DisableMFA(memberID uint, request *dto.MFACodeRequest) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, errors.New("MFA is not configured")
+	}
+	if err := s.mfaService.Disable(memberID, request.Code); err != nil {
+		return mfaErrorResponse(err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "MFA disabled"},
+	}, nil
+}
+
+// isMFAClientError 屬於使用者輸入造成的 MFA 錯誤
+func isMFAClientError(err error) bool {
+	for _, target := range []error{
+		auth_entities.ErrMFAChallengeInvalid,
+		auth_entities.ErrMFAChallengeExpired,
+		auth_entities.ErrMFACodeInvalid,
+		auth_entities.ErrMFANotEnrolled,
+		auth_entities.ErrMFAAlreadyEnabled,
+		auth_entities.ErrMFARequiredByRole,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func mfaErrorResponse(err error) (*dto.APIResponse, error) {
+	if isMFAClientError(err) {
+		return &dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	return nil, err
+}
+
+// toAuthResponse 轉換為 DTO
+func toAuthResponse(authResult *auth_entities.AuthResult) *dto.AuthResponse {
 	memberRoles := make([]dto.MemberRole, len(authResult.MemberRoles))
 	for i, role := range authResult.MemberRoles {
 		memberRoles[i] = dto.MemberRole{
@@ -32,28 +252,151 @@ func (s *AuthApplicationService) Login(request *dto.LoginRequest) (*dto.APIRespo
 			Name: role.RoleName,
 		}
 	}
-	// 轉換為 DTO
-	authResponse := &dto.AuthResponse{
+	return &dto.AuthResponse{
 		AccessToken:  authResult.AccessToken,
 		RefreshToken: authResult.RefreshToken,
 		Member: dto.MemberInfo{
 			ID:   authResult.Member.ID,
 			Name: authResult.Member.Name,
 		},
-		MemberRoles: memberRoles,
-		ExpiresIn:   authResult.ExpiresIn,
-		TokenType:   authResult.TokenType,
+		MemberRoles:     memberRoles,
+		ExpiresIn:       authResult.ExpiresIn,
+		TokenType:       authResult.TokenType,
+		RecoveryCodes:   authResult.RecoveryCodes,
+		PasswordExpired: authResult.PasswordExpired,
 	}
+}
 
-	return &dto.APIResponse{
-		Success: true,
-		Data:    authResponse,
-	}, nil
+// auditLoginFailure 記錄帳號存在時的登入失敗，觸發鎖定時另記 LOCK_ACCOUNT
+func (s *AuthApplicationService) auditLoginFailure(loginErr *services.LoginError, clientIP, userAgent string) {
+	if loginErr.MemberID == 0 {
+		return // 帳號不存在或被節流，沒有可記錄的操作者
+	}
+	roleID, ok := s.auditRoleID(loginErr.MemberID)
+	if !ok {
+		return
+	}
+
+	memberID := loginErr.MemberID
+	details := map[string]interface{}{"failures": loginErr.Failures}
+	if err := s.auditLogService.LogFailure(memberID, roleID, "LOGIN", "MEMBER", &memberID, details, clientIP, userAgent, loginErr.Error()); err != nil {
+		log.Printf("[Auth] Failed to write login audit log: %v", err)
+	}
+
+	if loginErr.Locked {
+		details := map[string]interface{}{
+			"reason":       "too many failed login attempts",
+			"locked_until": loginErr.LockedUntil,
+			"automatic":    true,
+		}
+		if err := s.auditLogService.LogSuccess(memberID, roleID, "LOCK_ACCOUNT", "MEMBER", &memberID, details, clientIP, userAgent); err != nil {
+			log.Printf("[Auth] Failed to write lockout audit log: %v", err)
+		}
+	}
 }
 
-func (s *AuthApplicationService) RefreshToken(request *dto.RefreshTokenRequest) (*dto.APIResponse, error) {
+// auditAuthEvent 記錄會員的認證事件 (登入、刷新 token、登出)，errMsg 為空時記錄為成功
+// 無法辨識會員 (memberID 為 0) 或會員沒有角色時不記錄
+func (s *AuthApplicationService) auditAuthEvent(memberID uint, action string, details map[string]interface{}, clientIP, userAgent, errMsg string) {
+	if memberID == 0 {
+		return
+	}
+	roleID, ok := s.auditRoleID(memberID)
+	if !ok {
+		return
+	}
+
+	var err error
+	if errMsg == "" {
+		err = s.auditLogService.LogSuccess(memberID, roleID, action, "MEMBER", &memberID, details, clientIP, userAgent)
+	} else {
+		err = s.auditLogService.LogFailure(memberID, roleID, action, "MEMBER", &memberID, details, clientIP, userAgent, errMsg)
+	}
+	if err != nil {
+		log.Printf("[Auth] Failed to write %s audit log: %v", action, err)
+	}
+}
+
+// authResultMemberID 取得認證結果中的會員 ID，無法解析時返回 0
+func authResultMemberID(authResult *auth_entities.AuthResult) uint {
+	if authResult == nil || authResult.Member == nil {
+		return 0
+	}
+	id, err := strconv.ParseUint(authResult.Member.ID, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// refreshTokenOwner 審計用：在刷新或登出前找出 refresh token 所屬的會員 (會話撤銷後就查不到)
+func (s *AuthApplicationService) refreshTokenOwner(refreshToken string) uint {
+	if s.auditLogService == nil {
+		return 0
+	}
+	return s.authService.RefreshTokenOwner(refreshToken)
+}
+
+func (s *AuthApplicationService) auditRoleID(memberID uint) (uint, bool) {
+	if s.auditLogService == nil || s.memberRoleRepo == nil {
+		return 0, false
+	}
+	roles, err := s.memberRoleRepo.GetByMemberID(memberID)
+	if err != nil || len(roles) == 0 {
+		return 0, false
+	}
+	return roles[0].RoleID, true
+}
+
+// StartLockoutExpiryLoop 定期解除到期的登入鎖定並清理過期的失敗統計
+func (s *AuthApplicationService) StartLockoutExpiryLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Auth] Lockout expiry loop started (interval: %s)", interval)
+	s.ExpireLockouts(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Auth] Lockout expiry loop stopped")
+			return
+		case <-ticker.C:
+			s.ExpireLockouts(time.Now())
+		}
+	}
+}
+
+// ExpireLockouts 解除到期的登入鎖定，返回解鎖數量
+func (s *AuthApplicationService) ExpireLockouts(now time.Time) int {
+	members, err := s.authService.ExpireLockouts(now)
+	if err != nil {
+		log.Printf("[Auth] Failed to expire lockouts: %v", err)
+	}
+
+	for _, member := range members {
+		memberID := member.ID.Value()
+		if roleID, ok := s.auditRoleID(memberID); ok {
+			_ = s.auditLogService.LogSuccess(memberID, roleID, "UNLOCK_ACCOUNT", "MEMBER", &memberID, map[string]interface{}{
+				"reason":    "lockout expired",
+				"automatic": true,
+			}, "", "system")
+		}
+	}
+	if len(members) > 0 {
+		log.Printf("[Auth] Unlocked %d member(s) after lockout expiry", len(members))
+	}
+	return len(members)
+}
+
+func (s *AuthApplicationService) RefreshToken(request *dto.RefreshTokenRequest, clientIP, userAgent string) (*dto.APIResponse, error) {
+	ownerID := s.refreshTokenOwner(request.RefreshToken)
 	authResult, err := s.authService.RefreshToken(request.RefreshToken)
 	if err != nil {
+		s.auditAuthEvent(ownerID, "REFRESH_TOKEN", nil, clientIP, userAgent, err.Error())
 		return &dto.APIResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -79,6 +422,7 @@ func (s *AuthApplicationService) RefreshToken(request *dto.RefreshTokenRequest)
 		ExpiresIn:   authResult.ExpiresIn,
 		TokenType:   authResult.TokenType,
 	}
+	s.auditAuthEvent(authResultMemberID(authResult), "REFRESH_TOKEN", nil, clientIP, userAgent, "")
 
 	return &dto.APIResponse{
 		Success: true,
@@ -86,17 +430,281 @@ func (s *AuthApplicationService) RefreshToken(request *dto.RefreshTokenRequest)
 	}, nil
 }
 
-func (s *AuthApplicationService) Logout(request *dto.LogoutRequest) (*dto.APIResponse, error) {
+// ChangePassword 變更自己的密碼，成功後撤銷目前會話以外的所有會話
+// 帳號因目前密碼連續錯誤被鎖定時返回 *services.LoginError
+func (s *AuthApplicationService) ChangePassword(memberID uint, currentSessionID string, request *dto.ChangePasswordRequest) (*dto.APIResponse, error) {
+	err := s.authService.ChangePassword(memberID, request.CurrentPassword, request.NewPassword, currentSessionID)
+	if err != nil {
+		var loginErr *services.LoginError
+		if errors.As(err, &loginErr) && !errors.Is(loginErr, services.ErrInvalidCredentials) {
+			return nil, loginErr
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) || errors.Is(err, services.ErrPasswordPolicy) {
+			return &dto.APIResponse{Success: false, Error: err.Error()}, nil
+		}
+		return nil, err
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"message": "Password changed successfully"},
+	}, nil
+}
+
+// GetPasswordPolicy 目前的密碼政策
+func (s *AuthApplicationService) GetPasswordPolicy() *dto.APIResponse {
+	policy := s.authService.PasswordPolicy()
+	return &dto.APIResponse{
+		Success: true,
+		Data: dto.PasswordPolicyResponse{
+			MinLength:     policy.MinLength,
+			RequireUpper:  policy.RequireUpper,
+			RequireLower:  policy.RequireLower,
+			RequireDigit:  policy.RequireDigit,
+			RequireSymbol: policy.RequireSymbol,
+			MaxAgeDays:    int(policy.MaxAge.Hours() / 24),
+			HistoryCount:  policy.HistoryCount,
+		},
+	}
+}
+
+// ListOIDCProviders 可用的單一登入身分提供者
+func (s *AuthApplicationService) ListOIDCProviders() *dto.APIResponse {
+	providers := []dto.OIDCProviderResponse{}
+	if s.oidcService != nil {
+		for _, provider := range s.oidcService.Providers() {
+			providers = append(providers, dto.OIDCProviderResponse{ID: provider.ID, Name: provider.Name})
+		}
+	}
+	return &dto.APIResponse{Success: true, Data: providers}
+}
+
+// BeginOIDCLogin 產生身分提供者的登入網址 (state、nonce 與 PKCE 保存在伺服器)
+func (s *AuthApplicationService) BeginOIDCLogin(providerID, redirectPath string) (*dto.APIResponse, error) {
+	if s.oidcService == nil {
+		return &dto.APIResponse{Success: false, Error: auth_entities.ErrOIDCProviderNotFound.Error()}, nil
+	}
+	authorizationURL, err := s.oidcService.BeginLogin(providerID, redirectPath)
+	if err != nil {
+		if errors.Is(err, auth_entities.ErrOIDCProviderNotFound) || errors.Is(err, services.ErrInvalidRedirectPath) {
+			return &dto.APIResponse{Success: false, Error: err.Error()}, nil
+		}
+		return nil, err
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data:    dto.OIDCAuthorizeResponse{AuthorizationURL: authorizationURL},
+	}, nil
+}
+
+// CompleteOIDCLogin 以身分提供者的授權碼完成單一登入
+// 帳號停用或鎖定時返回 *services.LoginError
+func (s *AuthApplicationService) CompleteOIDCLogin(ctx context.Context, providerID string, request *dto.OIDCCallbackRequest, clientIP, userAgent string) (*dto.APIResponse, error) {
+	authResult, redirectPath, err := s.authService.CompleteOIDCLogin(ctx, providerID, request.Code, request.State, clientIP, userAgent)
+	if err != nil {
+		var loginErr *services.LoginError
+		if errors.As(err, &loginErr) {
+			s.auditLoginFailure(loginErr, clientIP, userAgent)
+			return nil, loginErr
+		}
+		if !isOIDCClientError(err) {
+			return nil, err
+		}
+		if errors.Is(err, auth_entities.ErrOIDCTokenInvalid) {
+			log.Printf("[Auth] SSO login via %s failed: %v", providerID, err)
+			err = auth_entities.ErrOIDCTokenInvalid // 不回應身分提供者的錯誤細節
+		}
+		return &dto.APIResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	s.auditAuthEvent(authResultMemberID(authResult), "LOGIN", map[string]interface{}{"method": "oidc", "provider": providerID}, clientIP, userAgent, "")
+
+	return &dto.APIResponse{
+		Success: true,
+		Data: &dto.OIDCLoginResponse{
+			AuthResponse: *toAuthResponse(authResult),
+			RedirectPath: redirectPath,
+		},
+	}, nil
+}
+
+// isOIDCClientError 單一登入流程中由使用者或身分提供者造成的錯誤
+func isOIDCClientError(err error) bool {
+	for _, target := range []error{
+		auth_entities.ErrOIDCProviderNotFound,
+		auth_entities.ErrOIDCStateInvalid,
+		auth_entities.ErrOIDCTokenInvalid,
+		auth_entities.ErrOIDCEmailRequired,
+		auth_entities.ErrOIDCDomainNotAllowed,
+		auth_entities.ErrOIDCAccountNotLinked,
+		auth_entities.ErrOIDCNoRoleMapped,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListSessions 列出自己登入中的會話
+func (s *AuthApplicationService) ListSessions(memberID uint, currentSessionID string) (*dto.APIResponse, error) {
+	if s.sessionService == nil {
+		return nil, errors.New("session management is not configured")
+	}
+	sessions, err := s.sessionService.List(memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionDTOs := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		sessionDTOs[i] = dto.SessionResponse{
+			ID:            session.SessionID,
+			IPAddress:     session.IPAddress,
+			UserAgent:     session.UserAgent,
+			CreatedAt:     session.CreateTime,
+			LastRefreshAt: session.ModifyTime,
+			ExpiresAt:     session.ExpiresAt,
+			Current:       session.SessionID == currentSessionID,
+		}
+	}
+	return &dto.APIResponse{Success: true, Data: sessionDTOs}, nil
+}
+
+// RevokeSession 撤銷自己的單一會話，該會話的 token 立即失效
+func (s *AuthApplicationService) RevokeSession(memberID uint, sessionID string) (*dto.APIResponse, error) {
+	if s.sessionService == nil {
+		return nil, errors.New("session management is not configured")
+	}
+	if err := s.sessionService.Revoke(memberID, sessionID); err != nil {
+		if errors.Is(err, auth_entities.ErrSessionNotFound) {
+			return &dto.APIResponse{Success: false, Error: err.Error()}, nil
+		}
+		return nil, err
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"message": "Session revoked successfully"},
+	}, nil
+}
+
+// RevokeAllSessions 撤銷自己所有會話；keepCurrent 時保留目前的會話
+func (s *AuthApplicationService) RevokeAllSessions(memberID uint, currentSessionID string, keepCurrent bool) (*dto.APIResponse, error) {
+	if s.sessionService == nil {
+		return nil, errors.New("session management is not configured")
+	}
+	except := ""
+	if keepCurrent {
+		except = currentSessionID
+	}
+	revoked, err := s.sessionService.RevokeAll(memberID, except)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message": "Sessions revoked successfully",
+			"revoked": revoked,
+		},
+	}, nil
+}
+
+// StartSessionSyncLoop 定期同步其他實例的撤銷紀錄，並清除過期的會話紀錄
+func (s *AuthApplicationService) StartSessionSyncLoop(ctx context.Context, syncInterval, purgeInterval time.Duration) {
+	if s.sessionService == nil || syncInterval <= 0 || purgeInterval <= 0 {
+		return
+	}
+
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+
+	log.Printf("[Auth] Session sync loop started (sync: %s, purge: %s)", syncInterval, purgeInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Auth] Session sync loop stopped")
+			return
+		case <-syncTicker.C:
+			if _, err := s.sessionService.Sync(); err != nil {
+				log.Printf("[Auth] Failed to sync revoked sessions: %v", err)
+			}
+		case <-purgeTicker.C:
+			purged, err := s.sessionService.Purge()
+			if err != nil {
+				log.Printf("[Auth] Failed to purge sessions: %v", err)
+			} else if purged > 0 {
+				log.Printf("[Auth] Purged %d expired session(s)", purged)
+			}
+		}
+	}
+}
+
+func (s *AuthApplicationService) Logout(request *dto.LogoutRequest, clientIP, userAgent string) (*dto.APIResponse, error) {
+	ownerID := s.refreshTokenOwner(request.RefreshToken)
 	err := s.authService.Logout(request.RefreshToken)
 	if err != nil {
+		s.auditAuthEvent(ownerID, "LOGOUT", nil, clientIP, userAgent, err.Error())
 		return &dto.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, nil
 	}
+	s.auditAuthEvent(ownerID, "LOGOUT", nil, clientIP, userAgent, "")
 
 	return &dto.APIResponse{
 		Success: true,
 		Data:    map[string]string{"message": "logged out successfully"},
 	}, nil
 }
+
+// ForgotPassword 寄送重設密碼連結
+// 不論帳號是否存在都回應相同訊息，避免被用來探測帳號
+func (s *AuthApplicationService) ForgotPassword(request *dto.ForgotPasswordRequest) (*dto.APIResponse, error) {
+	if s.passwordResetService == nil {
+		return nil, errors.New("password reset is not configured")
+	}
+
+	if err := s.passwordResetService.RequestReset(request.Email, request.RedirectPath); err != nil {
+		if errors.Is(err, services.ErrInvalidRedirectPath) {
+			return &dto.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		log.Printf("[Auth] Password reset request failed: %v", err)
+	}
+
+	return &dto.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "if the account exists, a password reset link has been sent"},
+	}, nil
+}
+
+// ResetPassword 以重設連結設定新密碼
+func (s *AuthApplicationService) ResetPassword(request *dto.ResetPasswordRequest) (*dto.APIResponse, error) {
+	if s.passwordResetService == nil {
+		return nil, errors.New("password reset is not configured")
+	}
+
+	redirectPath, err := s.passwordResetService.ResetPassword(request.Token, request.Password)
+	if err != nil {
+		if errors.Is(err, auth_entities.ErrResetTokenInvalid) || errors.Is(err, auth_entities.ErrResetTokenExpired) ||
+			errors.Is(err, services.ErrPasswordPolicy) {
+			return &dto.APIResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		return nil, err
+	}
+
+	return &dto.APIResponse{
+		Success: true,
+		Data: dto.ResetPasswordResponse{
+			Message:      "password has been reset, please log in again",
+			RedirectPath: redirectPath,
+		},
+	}, nil
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/domain/comfort_control/entities"
+	"ems_backend/internal/domain/comfort_control/repositories"
+	"ems_backend/internal/domain/comfort_control/services"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	companyDeviceRepos "ems_backend/internal/domain/company_device/repositories"
+	temperatureRepos "ems_backend/internal/domain/temperature/repositories"
+)
+
+// SensorStaleAfter 超過此時間的感測器讀值不參與控制
+const SensorStaleAfter = 15 * time.Minute
+
+// comfortStatusSettleTime 發送命令後等待設備回報新運轉狀態的時間
+const comfortStatusSettleTime = 2 * time.Minute
+
+// ComfortControlApplicationService 區域舒適度閉環控制應用服務
+// 定期計算各區域體感溫度，依舒適區間自動啟停該區域的壓縮機 / VRF 室內機
+type ComfortControlApplicationService struct {
+	settingRepo       repositories.ComfortSettingRepository
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
+	temperatureRepo   temperatureRepos.TemperatureRepository
+	commandAppService *DeviceCommandApplicationService
+	controlService    *services.ComfortControlService
+	forceDryRun       bool            // 全域 dry-run：所有區域僅記錄決策
+	loadLock          ComfortLoadLock // Optional: 需量控制卸載中的負載不重新開機
+
+	mu        sync.Mutex
+	targets   map[string]*comfortTargetState         // "companyDeviceID/targetType/targetID" -> 狀態
+	decisions map[string][]*entities.ComfortDecision // "companyDeviceID/areaID" -> 最近一次決策
+}
+
+// ComfortLoadLock 提供被其他控制 (例如需量卸載) 鎖定、不得開機的負載
+type ComfortLoadLock interface {
+	IsShed(companyDeviceID uint, targetType, targetID string) bool
+}
+
+// comfortTargetState 控制迴圈追蹤的目標運轉狀態
+type comfortTargetState struct {
+	running      bool
+	lastSwitchAt *time.Time
+	commandedAt  *time.Time // 控制迴圈最近一次發送命令的時間
+}
+
+// NewComfortControlApplicationService 創建舒適度控制應用服務
+func NewComfortControlApplicationService(
+	settingRepo repositories.ComfortSettingRepository,
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository,
+	temperatureRepo temperatureRepos.TemperatureRepository,
+	commandAppService *DeviceCommandApplicationService,
+) *ComfortControlApplicationService {
+	return &ComfortControlApplicationService{
+		settingRepo:       settingRepo,
+		companyDeviceRepo: companyDeviceRepo,
+		temperatureRepo:   temperatureRepo,
+		commandAppService: commandAppService,
+		controlService:    services.NewComfortControlService(),
+		targets:           make(map[string]*comfortTargetState),
+		decisions:         make(map[string][]*entities.ComfortDecision),
+	}
+}
+
+// SetForceDryRun 設置全域 dry-run (開啟時忽略各區域設定，一律不發送命令)
+func (s *ComfortControlApplicationService) SetForceDryRun(forceDryRun bool) {
+	s.forceDryRun = forceDryRun
+}
+
+// SetLoadLock 設置負載鎖定來源 (可選)
+func (s *ComfortControlApplicationService) SetLoadLock(loadLock ComfortLoadLock) {
+	s.loadLock = loadLock
+}
+
+// GetSettings 取得設備所有區域的舒適度設定 (未設定的區域返回預設值)
+func (s *ComfortControlApplicationService) GetSettings(companyID, deviceID uint) ([]*dto.ComfortSettingResponse, error) {
+	companyDevice, content, err := s.loadCompanyDevice(companyID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingRepo.FindByCompanyDeviceID(companyDevice.ID)
+	if err != nil {
+		return nil, err
+	}
+	settingMap := make(map[string]*entities.ComfortSetting, len(settings))
+	for _, setting := range settings {
+		settingMap[setting.AreaID] = setting
+	}
+
+	responses := make([]*dto.ComfortSettingResponse, 0, len(content.Areas))
+	for _, area := range content.Areas {
+		setting, ok := settingMap[area.ID]
+		if !ok {
+			setting = entities.NewComfortSetting(companyDevice.ID, area.ID)
+		}
+		responses = append(responses, dto.NewComfortSettingResponse(setting, area.Name, s.lastDecisions(companyDevice.ID, area.ID)))
+	}
+	return responses, nil
+}
+
+// GetSetting 取得單一區域已保存的舒適度設定 (未設定時返回預設值)
+func (s *ComfortControlApplicationService) GetSetting(companyID, deviceID uint, areaID string) (*entities.ComfortSetting, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil || companyDevice == nil {
+		return nil, errors.New("company device not found")
+	}
+
+	setting, err := s.settingRepo.FindByArea(companyDevice.ID, areaID)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		setting = entities.NewComfortSetting(companyDevice.ID, areaID)
+	}
+	return setting, nil
+}
+
+// UpdateSetting 更新區域舒適度設定
+func (s *ComfortControlApplicationService) UpdateSetting(companyID, deviceID uint, areaID string, req *dto.ComfortSettingRequest, memberID uint) (*dto.ComfortSettingResponse, error) {
+	companyDevice, content, err := s.loadCompanyDevice(companyID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	area := findArea(content, areaID)
+	if area == nil {
+		return nil, errors.New("area not found on device")
+	}
+
+	setting, err := s.settingRepo.FindByArea(companyDevice.ID, areaID)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		setting = entities.NewComfortSetting(companyDevice.ID, areaID)
+		setting.CreatedAt = time.Now()
+	}
+
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+	if req.DryRun != nil {
+		setting.DryRun = *req.DryRun
+	}
+	if req.ComfortLow != nil {
+		setting.ComfortLow = *req.ComfortLow
+	}
+	if req.ComfortHigh != nil {
+		setting.ComfortHigh = *req.ComfortHigh
+	}
+	if req.Hysteresis != nil {
+		setting.Hysteresis = *req.Hysteresis
+	}
+	if req.MinOnSeconds != nil {
+		setting.MinOnSeconds = *req.MinOnSeconds
+	}
+	if req.MinOffSeconds != nil {
+		setting.MinOffSeconds = *req.MinOffSeconds
+	}
+	if err := setting.Validate(); err != nil {
+		return nil, err
+	}
+
+	if setting.Enabled && len(s.controlService.AreaTargets(content, area)) == 0 {
+		return nil, errors.New("area has no mapped compressors or VRF units")
+	}
+	if setting.Enabled && len(s.controlService.AreaSensorIDs(content, area)) == 0 {
+		return nil, errors.New("area has no temperature sensors")
+	}
+
+	setting.UpdatedBy = memberID
+	setting.UpdatedAt = time.Now()
+	if err := s.settingRepo.Save(setting); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Comfort] Area %s on company device %d updated by member %d (enabled=%t, dry_run=%t, band=%.1f-%.1f)",
+		areaID, companyDevice.ID, memberID, setting.Enabled, setting.DryRun, setting.ComfortLow, setting.ComfortHigh)
+	return dto.NewComfortSettingResponse(setting, area.Name, s.lastDecisions(companyDevice.ID, areaID)), nil
+}
+
+// StartControlLoop 定期執行舒適度閉環控制
+func (s *ComfortControlApplicationService) StartControlLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Comfort] Control loop started (interval: %s, force dry-run: %t)", interval, s.forceDryRun)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Comfort] Control loop stopped")
+			return
+		case <-ticker.C:
+			s.RunOnce(time.Now())
+		}
+	}
+}
+
+// RunOnce 對所有啟用的區域執行一次控制判斷
+func (s *ComfortControlApplicationService) RunOnce(now time.Time) {
+	settings, err := s.settingRepo.FindEnabled()
+	if err != nil {
+		log.Printf("[Comfort] Failed to load comfort settings: %v", err)
+		return
+	}
+
+	contents := make(map[uint]*companyDeviceEntities.DeviceContent)
+	for _, setting := range settings {
+		content, ok := contents[setting.CompanyDeviceID]
+		if !ok {
+			companyDevice, err := s.companyDeviceRepo.FindByID(setting.CompanyDeviceID)
+			if err != nil {
+				log.Printf("[Comfort] Company device %d not found: %v", setting.CompanyDeviceID, err)
+				continue
+			}
+			content, err = companyDevice.ParseContent()
+			if err != nil {
+				log.Printf("[Comfort] Failed to parse content of company device %d: %v", setting.CompanyDeviceID, err)
+				continue
+			}
+			contents[setting.CompanyDeviceID] = content
+		}
+
+		area := findArea(content, setting.AreaID)
+		if area == nil {
+			log.Printf("[Comfort] Area %s no longer exists on company device %d", setting.AreaID, setting.CompanyDeviceID)
+			continue
+		}
+		s.evaluateArea(setting, content, area, now)
+	}
+}
+
+// evaluateArea 計算區域體感溫度並對每個控制目標做出決策
+func (s *ComfortControlApplicationService) evaluateArea(
+	setting *entities.ComfortSetting,
+	content *companyDeviceEntities.DeviceContent,
+	area *companyDeviceEntities.Area,
+	now time.Time,
+) {
+	targets := s.controlService.AreaTargets(content, area)
+	if len(targets) == 0 {
+		return
+	}
+
+	dryRun := setting.DryRun || s.forceDryRun
+	heatIndex, ok := s.areaHeatIndex(content, area, now)
+
+	decisions := make([]*entities.ComfortDecision, 0, len(targets))
+	for _, target := range targets {
+		key := fmt.Sprintf("%d/%s/%s", setting.CompanyDeviceID, target.TargetType, target.TargetID)
+		state := s.observeTarget(key, target.State.Running, now)
+
+		decision := &entities.ComfortDecision{
+			CompanyDeviceID: setting.CompanyDeviceID,
+			AreaID:          setting.AreaID,
+			TargetType:      target.TargetType,
+			TargetID:        target.TargetID,
+			HeatIndex:       heatIndex,
+			Running:         state.Running,
+			Action:          entities.DecisionHold,
+			Reason:          entities.ReasonNoReading,
+			DryRun:          dryRun,
+			DecidedAt:       now,
+		}
+		if ok {
+			decision.Action, decision.Reason = s.controlService.Decide(setting, heatIndex, state, now)
+		}
+		if decision.Action == entities.DecisionStart && s.loadLock != nil && s.loadLock.IsShed(setting.CompanyDeviceID, target.TargetType, target.TargetID) {
+			decision.Action, decision.Reason = entities.DecisionHold, entities.ReasonDemandShed
+		}
+		decisions = append(decisions, decision)
+
+		if !decision.IsSwitch() {
+			continue
+		}
+		if dryRun {
+			log.Printf("[Comfort] [dry-run] Area %s on company device %d: would %s %s %s (heat index %.1f, %s)",
+				setting.AreaID, setting.CompanyDeviceID, decision.Action, target.TargetType, target.TargetID, heatIndex, decision.Reason)
+			continue
+		}
+		s.execute(key, decision, now)
+	}
+
+	s.mu.Lock()
+	s.decisions[areaKey(setting.CompanyDeviceID, setting.AreaID)] = decisions
+	s.mu.Unlock()
+}
+
+// execute 發送啟停命令並記錄切換時間
+func (s *ComfortControlApplicationService) execute(key string, decision *entities.ComfortDecision, now time.Time) {
+	on := decision.Action == entities.DecisionStart
+	result, err := s.commandAppService.SendAutomaticPower(decision.CompanyDeviceID, decision.TargetType, decision.TargetID, on)
+	if err != nil {
+		log.Printf("[Comfort] Failed to %s %s %s on company device %d: %v",
+			decision.Action, decision.TargetType, decision.TargetID, decision.CompanyDeviceID, err)
+		return
+	}
+	if result.Status == "failed" {
+		log.Printf("[Comfort] Command %s to %s %s failed: %s", result.CommandID, decision.TargetType, decision.TargetID, result.Message)
+		return
+	}
+
+	log.Printf("[Comfort] Area %s on company device %d: %s %s %s (heat index %.1f, %s, command %s)",
+		decision.AreaID, decision.CompanyDeviceID, decision.Action, decision.TargetType, decision.TargetID,
+		decision.HeatIndex, decision.Reason, result.CommandID)
+
+	s.mu.Lock()
+	switchedAt := now
+	s.targets[key] = &comfortTargetState{running: on, lastSwitchAt: &switchedAt, commandedAt: &switchedAt}
+	s.mu.Unlock()
+}
+
+// observeTarget 以設備回報的運轉狀態更新追蹤狀態
+// 控制迴圈剛發送命令且設備尚未回報時，以命令後的狀態為準
+func (s *ComfortControlApplicationService) observeTarget(key string, running bool, now time.Time) entities.TargetState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tracked, ok := s.targets[key]
+	if !ok {
+		// 首次觀察，無法得知上次啟停時間
+		s.targets[key] = &comfortTargetState{running: running}
+		return entities.TargetState{Running: running}
+	}
+
+	if tracked.running != running {
+		if tracked.commandedAt != nil && now.Sub(*tracked.commandedAt) < comfortStatusSettleTime {
+			return entities.TargetState{Running: tracked.running, LastSwitchAt: tracked.lastSwitchAt}
+		}
+		switchedAt := now
+		tracked.running = running
+		tracked.lastSwitchAt = &switchedAt
+		tracked.commandedAt = nil
+	}
+	return entities.TargetState{Running: tracked.running, LastSwitchAt: tracked.lastSwitchAt}
+}
+
+// areaHeatIndex 計算區域內有效感測器的平均體感溫度
+func (s *ComfortControlApplicationService) areaHeatIndex(content *companyDeviceEntities.DeviceContent, area *companyDeviceEntities.Area, now time.Time) (float64, bool) {
+	sensorIDs := s.controlService.AreaSensorIDs(content, area)
+	if len(sensorIDs) == 0 {
+		return 0, false
+	}
+
+	latestDataMap, err := s.temperatureRepo.GetLatestByTemperatureIDs(sensorIDs)
+	if err != nil {
+		log.Printf("[Comfort] Failed to query sensors of area %s: %v", area.ID, err)
+		return 0, false
+	}
+
+	var sum float64
+	count := 0
+	for _, latest := range latestDataMap {
+		if latest == nil || now.Sub(latest.Timestamp) > SensorStaleAfter {
+			continue
+		}
+		sum += calculateHeatIndexForArea(latest.Temperature, latest.Humidity)
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// loadCompanyDevice 取得公司設備與解析後的內容
+func (s *ComfortControlApplicationService) loadCompanyDevice(companyID, deviceID uint) (*companyDeviceEntities.CompanyDevice, *companyDeviceEntities.DeviceContent, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil {
+		return nil, nil, errors.New("company device not found")
+	}
+	content, err := companyDevice.ParseContent()
+	if err != nil || content == nil {
+		return nil, nil, errors.New("device content is empty, query device info first")
+	}
+	return companyDevice, content, nil
+}
+
+func (s *ComfortControlApplicationService) lastDecisions(companyDeviceID uint, areaID string) []*entities.ComfortDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.decisions[areaKey(companyDeviceID, areaID)]
+}
+
+func areaKey(companyDeviceID uint, areaID string) string {
+	return fmt.Sprintf("%d/%s", companyDeviceID, areaID)
+}
+
+func findArea(content *companyDeviceEntities.DeviceContent, areaID string) *companyDeviceEntities.Area {
+	for i := range content.Areas {
+		if content.Areas[i].ID == areaID {
+			return &content.Areas[i]
+		}
+	}
+	return nil
+}
@@ -1,25 +1,26 @@
 package services
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"ems_backend/internal/application/dto"
+	authServices "ems_backend/internal/domain/auth/services"
 	companyEntities "ems_backend/internal/domain/company/entities"
 	companyRepos "ems_backend/internal/domain/company/repositories"
+	companyServices "ems_backend/internal/domain/company/services"
 	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
 	companyDeviceRepos "ems_backend/internal/domain/company_device/repositories"
+	companyDeviceServices "ems_backend/internal/domain/company_device/services"
+	deviceEntities "ems_backend/internal/domain/device/entities"
 	deviceRepos "ems_backend/internal/domain/device/repositories"
+	deviceServices "ems_backend/internal/domain/device/services"
 	memberEntities "ems_backend/internal/domain/member/entities"
 	memberRepos "ems_backend/internal/domain/member/repositories"
 	memberValueObjects "ems_backend/internal/domain/member/value_objects"
-	memberHistoryEntities "ems_backend/internal/domain/member_history/entities"
 	memberHistoryRepos "ems_backend/internal/domain/member_history/repositories"
-	roleRepos "ems_backend/internal/domain/role/repositories"
 	roleService "ems_backend/internal/domain/role/services"
 )
 
@@ -38,8 +39,11 @@ type CompanyApplicationService struct {
 	deviceRepo        deviceRepos.DeviceRepository
 	memberRepo        memberRepos.MemberRepository
 	memberHistoryRepo memberHistoryRepos.MemberHistoryRepository
-	roleRepo          roleRepos.RoleRepository
+	accessService     *companyServices.CompanyAccessService
 	roleService       *roleService.RoleService
+	contentValidator  *companyDeviceServices.DeviceContentValidator
+	claimCodeRepo     deviceRepos.ClaimCodeRepository
+	passwordPolicy    *authServices.PasswordPolicyService
 }
 
 // NewCompanyApplicationService 創建公司管理應用服務
@@ -50,9 +54,11 @@ func NewCompanyApplicationService(
 	deviceRepo deviceRepos.DeviceRepository,
 	memberRepo memberRepos.MemberRepository,
 	memberHistoryRepo memberHistoryRepos.MemberHistoryRepository,
-	roleRepo roleRepos.RoleRepository,
+	accessService *companyServices.CompanyAccessService,
 	roleService *roleService.RoleService,
 ) *CompanyApplicationService {
+	// 預設政策只檢查長度與常見密碼，SetPasswordPolicy 可換成完整政策
+	passwordPolicy, _ := authServices.NewPasswordPolicyService(memberHistoryRepo, authServices.PasswordPolicyConfig{})
 	return &CompanyApplicationService{
 		companyRepo:       companyRepo,
 		companyMemberRepo: companyMemberRepo,
@@ -60,11 +66,23 @@ func NewCompanyApplicationService(
 		deviceRepo:        deviceRepo,
 		memberRepo:        memberRepo,
 		memberHistoryRepo: memberHistoryRepo,
-		roleRepo:          roleRepo,
+		accessService:     accessService,
 		roleService:       roleService,
+		contentValidator:  companyDeviceServices.NewDeviceContentValidator(),
+		passwordPolicy:    passwordPolicy,
 	}
 }
 
+// SetPasswordPolicy 設置密碼政策 (建立公司管理員 / 用戶)
+func (s *CompanyApplicationService) SetPasswordPolicy(passwordPolicy *authServices.PasswordPolicyService) {
+	s.passwordPolicy = passwordPolicy
+}
+
+// SetClaimCodeRepository 設置認領碼倉儲 (可選，未設置時無法兌換認領碼)
+func (s *CompanyApplicationService) SetClaimCodeRepository(claimCodeRepo deviceRepos.ClaimCodeRepository) {
+	s.claimCodeRepo = claimCodeRepo
+}
+
 // GetAccessibleCompanies 獲取當前用戶可訪問的公司列表
 func (s *CompanyApplicationService) GetAccessibleCompanies(memberID, roleID uint) ([]*dto.CompanyResponse, error) {
 	companies, err := s.getAccessibleCompanyEntities(memberID, roleID)
@@ -217,6 +235,11 @@ func (s *CompanyApplicationService) CreateCompanyManager(companyID uint, req *dt
 		return errors.New("email already exists")
 	}
 
+	// 檢查密碼政策
+	if err := s.passwordPolicy.Validate(0, req.Password, req.Name, req.Email); err != nil {
+		return err
+	}
+
 	// 創建成員
 	memberID, err := memberValueObjects.NewMemberID(0)
 	if err != nil {
@@ -246,24 +269,12 @@ func (s *CompanyApplicationService) CreateCompanyManager(companyID uint, req *dt
 		return fmt.Errorf("failed to save member: %w", err)
 	}
 
-	// 生成密碼
-	salt, err := s.generateSalt()
+	// 以密碼政策雜湊密碼
+	memberHistory, err := s.passwordPolicy.NewHistory(member.ID, req.Password, createID)
 	if err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+		return err
 	}
 
-	memberHistory := &memberHistoryEntities.MemberHistory{}
-	hash := memberHistory.HashPassword(req.Password, salt)
-
-	memberHistory.MemberID = member.ID
-	memberHistory.Salt = salt
-	memberHistory.Hash = hash
-	memberHistory.ErrorCount = 0
-	memberHistory.CreateID = createID
-	memberHistory.CreateTime = time.Now()
-	memberHistory.ModifyID = createID
-	memberHistory.ModifyTime = time.Now()
-
 	if err := s.memberHistoryRepo.Save(memberHistory); err != nil {
 		return fmt.Errorf("failed to save member history: %w", err)
 	}
@@ -304,6 +315,11 @@ func (s *CompanyApplicationService) CreateCompanyUser(companyID uint, req *dto.C
 		return errors.New("email already exists")
 	}
 
+	// 檢查密碼政策
+	if err := s.passwordPolicy.Validate(0, req.Password, req.Name, req.Email); err != nil {
+		return err
+	}
+
 	// 創建成員
 	memberID, err := memberValueObjects.NewMemberID(0)
 	if err != nil {
@@ -333,24 +349,12 @@ func (s *CompanyApplicationService) CreateCompanyUser(companyID uint, req *dto.C
 		return fmt.Errorf("failed to save member: %w", err)
 	}
 
-	// 生成密碼
-	salt, err := s.generateSalt()
+	// 以密碼政策雜湊密碼
+	memberHistory, err := s.passwordPolicy.NewHistory(member.ID, req.Password, createID)
 	if err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+		return err
 	}
 
-	memberHistory := &memberHistoryEntities.MemberHistory{}
-	hash := memberHistory.HashPassword(req.Password, salt)
-
-	memberHistory.MemberID = member.ID
-	memberHistory.Salt = salt
-	memberHistory.Hash = hash
-	memberHistory.ErrorCount = 0
-	memberHistory.CreateID = createID
-	memberHistory.CreateTime = time.Now()
-	memberHistory.ModifyID = createID
-	memberHistory.ModifyTime = time.Now()
-
 	if err := s.memberHistoryRepo.Save(memberHistory); err != nil {
 		return fmt.Errorf("failed to save member history: %w", err)
 	}
@@ -489,6 +493,9 @@ func (s *CompanyApplicationService) AssignDeviceToCompany(companyID uint, req *d
 	if content == nil {
 		content = json.RawMessage(`{}`)
 	}
+	if err := s.contentValidator.Validate(content); err != nil {
+		return err
+	}
 
 	now := time.Now()
 	companyDevice := &companyDeviceEntities.CompanyDevice{
@@ -504,90 +511,170 @@ func (s *CompanyApplicationService) AssignDeviceToCompany(companyID uint, req *d
 	return s.companyDeviceRepo.Save(companyDevice)
 }
 
-// RemoveDeviceFromCompany 從公司移除設備 (僅 SystemAdmin)
-func (s *CompanyApplicationService) RemoveDeviceFromCompany(companyID, deviceID, memberID uint) error {
-	return s.companyDeviceRepo.DeleteByCompanyAndDevice(companyID, deviceID)
-}
+// ClaimDevice 以一次性認領碼將未綁定設備綁定到公司 (公司管理者自助綁定)
+// 返回的認領碼資訊在認領碼已找到時一併返回 (即使兌換失敗)，供審計記錄
+func (s *CompanyApplicationService) ClaimDevice(companyID uint, code string, memberID, roleID uint) (*dto.ClaimCodeResponse, error) {
+	if s.claimCodeRepo == nil {
+		return nil, errors.New("claim codes are not available")
+	}
+	if !s.canAccessCompany(memberID, roleID, companyID) {
+		return nil, errors.New("access denied")
+	}
 
-// GetCompanyDeviceByCompanyAndDevice 根據公司 ID 和設備 ID 獲取公司設備關聯
-func (s *CompanyApplicationService) GetCompanyDeviceByCompanyAndDevice(companyID, deviceID uint) (*companyDeviceEntities.CompanyDevice, error) {
-	return s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
-}
+	provisioning := deviceServices.NewProvisioningService()
+	if provisioning.NormalizeClaimCode(code) == "" {
+		return nil, deviceEntities.ErrClaimCodeInvalid
+	}
+	claimCode, err := s.claimCodeRepo.FindByHash(provisioning.HashClaimCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if claimCode == nil {
+		return nil, deviceEntities.ErrClaimCodeInvalid
+	}
+	if err := claimCode.CheckRedeemable(time.Now()); err != nil {
+		return dto.NewClaimCodeResponse(claimCode, ""), err
+	}
 
-// ==================== 私有輔助方法 ====================
+	existing, _ := s.companyDeviceRepo.FindByDeviceID(claimCode.DeviceID)
+	if existing != nil {
+		return dto.NewClaimCodeResponse(claimCode, ""), errors.New("device already assigned to a company")
+	}
 
-// getAccessibleCompanyEntities 獲取當前用戶可訪問的公司實體列表
-func (s *CompanyApplicationService) getAccessibleCompanyEntities(memberID, roleID uint) ([]*companyEntities.Company, error) {
-	role, err := s.roleRepo.GetByID(roleID)
+	// 先以條件更新佔用認領碼，避免並行兌換
+	claimCode.Redeem(companyID, memberID)
+	ok, err := s.claimCodeRepo.CloseIfActive(claimCode)
 	if err != nil {
 		return nil, err
 	}
+	if !ok {
+		return dto.NewClaimCodeResponse(claimCode, ""), deviceEntities.ErrClaimCodeRedeemed
+	}
 
-	switch role.Title {
-	case "SystemAdmin":
-		// SystemAdmin 可以訪問所有公司
-		return s.companyRepo.FindAll()
+	now := time.Now()
+	companyDevice := &companyDeviceEntities.CompanyDevice{
+		CompanyID:  companyID,
+		DeviceID:   claimCode.DeviceID,
+		Content:    json.RawMessage(`{}`),
+		CreateID:   memberID,
+		CreateTime: now,
+		ModifyID:   memberID,
+		ModifyTime: now,
+	}
+	if err := s.companyDeviceRepo.Save(companyDevice); err != nil {
+		// 綁定失敗時釋放認領碼，讓使用者可以重試
+		_ = s.claimCodeRepo.Reopen(claimCode.ID)
+		return nil, err
+	}
 
-	case "company_manager":
-		// company_manager 可以訪問自己的公司及其所有子公司
-		memberCompanies, err := s.companyRepo.FindByMemberID(memberID)
-		if err != nil {
-			return nil, err
-		}
-		if len(memberCompanies) == 0 {
-			return []*companyEntities.Company{}, nil
-		}
+	return dto.NewClaimCodeResponse(claimCode, ""), nil
+}
 
-		// 收集所有可訪問的公司（自己的公司 + 所有子公司）
-		accessibleMap := make(map[uint]*companyEntities.Company)
-		for _, c := range memberCompanies {
-			accessibleMap[c.ID] = c
-			descendants, err := s.companyRepo.FindDescendants(c.ID)
-			if err != nil {
-				continue
-			}
-			for _, d := range descendants {
-				accessibleMap[d.ID] = d
-			}
-		}
+// PatchDeviceContent 以 JSON Patch (RFC 6902) 更新設備內容
+// expectedVersion 必須等於目前內容版本 (樂觀鎖)，套用後的內容需通過 schema 與關聯驗證
+func (s *CompanyApplicationService) PatchDeviceContent(companyID, deviceID uint, patch []byte, expectedVersion int64, memberID uint) (*dto.CompanyDeviceResponse, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil || companyDevice == nil {
+		return nil, errors.New("device not found in company")
+	}
 
-		result := make([]*companyEntities.Company, 0, len(accessibleMap))
-		for _, c := range accessibleMap {
-			result = append(result, c)
-		}
-		return result, nil
+	currentVersion := companyDevice.ContentVersion()
+	if currentVersion != expectedVersion {
+		return nil, companyDeviceEntities.ErrContentVersionConflict
+	}
 
-	case "company_user":
-		// company_user 只能訪問自己被分配的公司
-		return s.companyRepo.FindByMemberID(memberID)
+	operations, err := companyDeviceServices.DecodeJSONPatch(patch)
+	if err != nil {
+		return nil, err
+	}
 
-	default:
-		return []*companyEntities.Company{}, nil
+	original := companyDevice.Content
+	if len(original) == 0 {
+		original = json.RawMessage(`{}`)
+	}
+	patched, err := companyDeviceServices.ApplyJSONPatch(original, operations)
+	if err != nil {
+		return nil, err
 	}
-}
 
-// canAccessCompany 檢查用戶是否可以訪問指定公司
-func (s *CompanyApplicationService) canAccessCompany(memberID, roleID, companyID uint) bool {
-	accessibleCompanies, err := s.getAccessibleCompanyEntities(memberID, roleID)
+	if err := s.contentValidator.Validate(patched); err != nil {
+		return nil, err
+	}
+
+	// 版本由伺服器遞增，忽略 patch 對 version 的修改
+	var document map[string]any
+	if err := json.Unmarshal(patched, &document); err != nil {
+		return nil, err
+	}
+	document["version"] = currentVersion + 1
+	content, err := json.Marshal(document)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	for _, c := range accessibleCompanies {
-		if c.ID == companyID {
-			return true
-		}
+	companyDevice.Content = content
+	companyDevice.ModifyID = memberID
+	companyDevice.ModifyTime = time.Now()
+	if err := s.companyDeviceRepo.UpdateContentIfVersion(companyDevice, currentVersion); err != nil {
+		return nil, err
+	}
+
+	deviceSN := ""
+	if device, err := s.deviceRepo.FindByID(deviceID); err == nil && device != nil {
+		deviceSN = device.SN
 	}
-	return false
+	return dto.NewCompanyDeviceResponse(companyDevice, deviceSN), nil
 }
 
-// generateSalt 生成隨機鹽值
-func (s *CompanyApplicationService) generateSalt() (string, error) {
-	salt := make([]byte, 16)
-	if _, err := rand.Read(salt); err != nil {
-		return "", err
+// GetDeviceContentVersion 取得設備內容目前版本
+func (s *CompanyApplicationService) GetDeviceContentVersion(companyID, deviceID uint) (int64, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil || companyDevice == nil {
+		return 0, errors.New("device not found in company")
 	}
-	return base64.StdEncoding.EncodeToString(salt), nil
+	return companyDevice.ContentVersion(), nil
+}
+
+// RemoveDeviceFromCompany 從公司移除設備 (僅 SystemAdmin)
+func (s *CompanyApplicationService) RemoveDeviceFromCompany(companyID, deviceID, memberID uint) error {
+	return s.companyDeviceRepo.DeleteByCompanyAndDevice(companyID, deviceID)
+}
+
+// GetCompanyDevice 根據公司 ID 和設備 ID 獲取公司設備 (含設備序號)
+func (s *CompanyApplicationService) GetCompanyDevice(companyID, deviceID uint) (*dto.CompanyDeviceResponse, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil || companyDevice == nil {
+		return nil, errors.New("device not found in company")
+	}
+
+	deviceSN := ""
+	if device, err := s.deviceRepo.FindByID(deviceID); err == nil && device != nil {
+		deviceSN = device.SN
+	}
+	return dto.NewCompanyDeviceResponse(companyDevice, deviceSN), nil
+}
+
+// GetCompanyMember 獲取公司與成員的關聯，不存在時返回 nil
+func (s *CompanyApplicationService) GetCompanyMember(companyID, memberID uint) (*companyEntities.CompanyMember, error) {
+	return s.companyMemberRepo.FindByCompanyAndMember(companyID, memberID)
+}
+
+// GetCompanyDeviceByCompanyAndDevice 根據公司 ID 和設備 ID 獲取公司設備關聯
+func (s *CompanyApplicationService) GetCompanyDeviceByCompanyAndDevice(companyID, deviceID uint) (*companyDeviceEntities.CompanyDevice, error) {
+	return s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+}
+
+// ==================== 私有輔助方法 ====================
+
+// getAccessibleCompanyEntities 獲取當前用戶可訪問的公司實體列表 (依角色的公司範圍)
+func (s *CompanyApplicationService) getAccessibleCompanyEntities(memberID, roleID uint) ([]*companyEntities.Company, error) {
+	return s.accessService.AccessibleCompanies(memberID, roleID)
+}
+
+// canAccessCompany 檢查用戶是否可以訪問指定公司
+func (s *CompanyApplicationService) canAccessCompany(memberID, roleID, companyID uint) bool {
+	_, err := s.accessService.Authorize(memberID, roleID, companyID)
+	return err == nil
 }
 
 // GetAllCompanies 獲取所有公司 (僅 SystemAdmin 使用)
@@ -4,46 +4,36 @@ import (
 	"ems_backend/internal/application/dto"
 	companyEntities "ems_backend/internal/domain/company/entities"
 	companyRepo "ems_backend/internal/domain/company/repositories"
+	companyServices "ems_backend/internal/domain/company/services"
 	deviceRepo "ems_backend/internal/domain/company_device/repositories"
 	meterRepo "ems_backend/internal/domain/meter/repositories"
 	"errors"
 )
 
-// Role constants for dashboard access control
-const (
-	DashboardRoleSystemAdmin    = 1
-	DashboardRoleCompanyManager = 2
-	DashboardRoleCompanyUser    = 3
-)
-
 type DashboardApplicationService struct {
 	companyRepo       companyRepo.CompanyRepository
+	accessService     *companyServices.CompanyAccessService
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository
 	meterRepo         meterRepo.MeterRepository
 }
 
 func NewDashboardApplicationService(
 	companyRepo companyRepo.CompanyRepository,
+	accessService *companyServices.CompanyAccessService,
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository,
 	meterRepo meterRepo.MeterRepository,
 ) *DashboardApplicationService {
 	return &DashboardApplicationService{
 		companyRepo:       companyRepo,
+		accessService:     accessService,
 		companyDeviceRepo: companyDeviceRepo,
 		meterRepo:         meterRepo,
 	}
 }
 
-// getAccessibleCompanies - 根據角色獲取可訪問的公司列表
-// SystemAdmin: 可以看所有公司
-// CompanyManager/CompanyUser: 只能看自己關聯的公司
+// getAccessibleCompanies - 根據角色的公司範圍獲取可訪問的公司列表
 func (s *DashboardApplicationService) getAccessibleCompanies(memberID uint, roleID uint) ([]*companyEntities.Company, error) {
-	if roleID == DashboardRoleSystemAdmin {
-		// SystemAdmin 可以看所有公司
-		return s.companyRepo.FindAll()
-	}
-	// CompanyManager 和 CompanyUser 只能看自己關聯的公司
-	return s.companyRepo.FindByMemberID(memberID)
+	return s.accessService.AccessibleCompanies(memberID, roleID)
 }
 
 // GetCompanyList - 獲取用戶有權限的公司列表
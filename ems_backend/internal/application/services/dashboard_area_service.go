@@ -4,6 +4,7 @@ import (
 	"ems_backend/internal/application/dto"
 	companyEntities "ems_backend/internal/domain/company/entities"
 	companyRepo "ems_backend/internal/domain/company/repositories"
+	companyServices "ems_backend/internal/domain/company/services"
 	deviceRepo "ems_backend/internal/domain/company_device/repositories"
 	meterRepo "ems_backend/internal/domain/meter/repositories"
 	temperatureRepo "ems_backend/internal/domain/temperature/repositories"
@@ -14,6 +15,7 @@ import (
 
 type DashboardAreaService struct {
 	companyRepo       companyRepo.CompanyRepository
+	accessService     *companyServices.CompanyAccessService
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository
 	meterRepo         meterRepo.MeterRepository
 	temperatureRepo   temperatureRepo.TemperatureRepository
@@ -21,26 +23,23 @@ type DashboardAreaService struct {
 
 func NewDashboardAreaService(
 	companyRepo companyRepo.CompanyRepository,
+	accessService *companyServices.CompanyAccessService,
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository,
 	meterRepo meterRepo.MeterRepository,
 	temperatureRepo temperatureRepo.TemperatureRepository,
 ) *DashboardAreaService {
 	return &DashboardAreaService{
 		companyRepo:       companyRepo,
+		accessService:     accessService,
 		companyDeviceRepo: companyDeviceRepo,
 		meterRepo:         meterRepo,
 		temperatureRepo:   temperatureRepo,
 	}
 }
 
-// getAccessibleCompanies - 根據角色獲取可訪問的公司列表
+// getAccessibleCompanies - 根據角色的公司範圍獲取可訪問的公司列表
 func (s *DashboardAreaService) getAccessibleCompanies(memberID uint, roleID uint) ([]*companyEntities.Company, error) {
-	if roleID == DashboardRoleSystemAdmin {
-		// SystemAdmin 可以看所有公司
-		return s.companyRepo.FindAll()
-	}
-	// CompanyManager 和 CompanyUser 只能看自己關聯的公司
-	return s.companyRepo.FindByMemberID(memberID)
+	return s.accessService.AccessibleCompanies(memberID, roleID)
 }
 
 // GetAreaOverview - 獲取區域總覽（包含完整的區域解構和統計）
@@ -4,6 +4,7 @@ import (
 	"ems_backend/internal/application/dto"
 	companyEntities "ems_backend/internal/domain/company/entities"
 	companyRepo "ems_backend/internal/domain/company/repositories"
+	companyServices "ems_backend/internal/domain/company/services"
 	deviceRepo "ems_backend/internal/domain/company_device/repositories"
 	temperatureEntities "ems_backend/internal/domain/temperature/entities"
 	temperatureRepo "ems_backend/internal/domain/temperature/repositories"
@@ -13,28 +14,28 @@ import (
 
 type DashboardTemperatureService struct {
 	companyRepo       companyRepo.CompanyRepository
+	accessService     *companyServices.CompanyAccessService
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository
 	temperatureRepo   temperatureRepo.TemperatureRepository
 }
 
 func NewDashboardTemperatureService(
 	companyRepo companyRepo.CompanyRepository,
+	accessService *companyServices.CompanyAccessService,
 	companyDeviceRepo deviceRepo.CompanyDeviceRepository,
 	temperatureRepo temperatureRepo.TemperatureRepository,
 ) *DashboardTemperatureService {
 	return &DashboardTemperatureService{
 		companyRepo:       companyRepo,
+		accessService:     accessService,
 		companyDeviceRepo: companyDeviceRepo,
 		temperatureRepo:   temperatureRepo,
 	}
 }
 
-// getAccessibleCompanies - 根據角色獲取可訪問的公司列表
+// getAccessibleCompanies - 根據角色的公司範圍獲取可訪問的公司列表
 func (s *DashboardTemperatureService) getAccessibleCompanies(memberID uint, roleID uint) ([]*companyEntities.Company, error) {
-	if roleID == DashboardRoleSystemAdmin {
-		return s.companyRepo.FindAll()
-	}
-	return s.companyRepo.FindByMemberID(memberID)
+	return s.accessService.AccessibleCompanies(memberID, roleID)
 }
 
 // GetTemperatureData - 獲取溫度數據（優化版：批量查詢）
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	companyDeviceRepos "ems_backend/internal/domain/company_device/repositories"
+	"ems_backend/internal/domain/demand_control/entities"
+	"ems_backend/internal/domain/demand_control/repositories"
+	"ems_backend/internal/domain/demand_control/services"
+	commandEntities "ems_backend/internal/domain/device_command/entities"
+	commandServices "ems_backend/internal/domain/device_command/services"
+	meterRepos "ems_backend/internal/domain/meter/repositories"
+)
+
+// DemandEventLimit Dashboard 顯示的最近事件筆數
+const DemandEventLimit = 50
+
+// DemandControlApplicationService 契約容量需量控制應用服務
+// 定期計算各公司 15 分鐘需量，接近契約容量時依優先順序卸載壓縮機 / VRF 室內機，需量回落後復歸
+type DemandControlApplicationService struct {
+	demandRepo        repositories.DemandControlRepository
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
+	meterRepo         meterRepos.MeterRepository
+	commandAppService *DeviceCommandApplicationService
+	demandService     *services.DemandControlService
+	commandService    *commandServices.DeviceCommandService
+
+	// 目前卸載中的負載 (供舒適度控制避免重新開機)
+	shedMu   sync.RWMutex
+	shedKeys map[string]bool
+}
+
+// NewDemandControlApplicationService 創建需量控制應用服務
+func NewDemandControlApplicationService(
+	demandRepo repositories.DemandControlRepository,
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository,
+	meterRepo meterRepos.MeterRepository,
+	commandAppService *DeviceCommandApplicationService,
+) *DemandControlApplicationService {
+	return &DemandControlApplicationService{
+		demandRepo:        demandRepo,
+		companyDeviceRepo: companyDeviceRepo,
+		meterRepo:         meterRepo,
+		commandAppService: commandAppService,
+		demandService:     services.NewDemandControlService(),
+		commandService:    commandServices.NewDeviceCommandService(),
+		shedKeys:          make(map[string]bool),
+	}
+}
+
+// IsShed 檢查負載是否因需量控制而卸載中 (implements ComfortLoadLock)
+func (s *DemandControlApplicationService) IsShed(companyDeviceID uint, targetType, targetID string) bool {
+	key := entities.SheddableLoad{CompanyDeviceID: companyDeviceID, TargetType: targetType, TargetID: targetID}.Key()
+	s.shedMu.RLock()
+	defer s.shedMu.RUnlock()
+	return s.shedKeys[key]
+}
+
+// GetSetting 取得公司需量控制設定 (未設定時返回預設值)
+func (s *DemandControlApplicationService) GetSetting(companyID uint) (*dto.DemandSettingResponse, error) {
+	setting, err := s.demandRepo.FindSettingByCompanyID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		return dto.NewDemandSettingResponse(entities.NewDemandSetting(companyID), false), nil
+	}
+	return dto.NewDemandSettingResponse(setting, true), nil
+}
+
+// UpdateSetting 更新公司需量控制設定；停用時復歸所有卸載中的負載
+func (s *DemandControlApplicationService) UpdateSetting(companyID uint, req *dto.DemandSettingRequest, memberID uint) (*dto.DemandSettingResponse, error) {
+	setting, err := s.demandRepo.FindSettingByCompanyID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		setting = entities.NewDemandSetting(companyID)
+	}
+
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+	if req.DryRun != nil {
+		setting.DryRun = *req.DryRun
+	}
+	if req.ContractKW != nil {
+		setting.ContractKW = *req.ContractKW
+	}
+	if req.ShedRatio != nil {
+		setting.ShedRatio = *req.ShedRatio
+	}
+	if req.RestoreRatio != nil {
+		setting.RestoreRatio = *req.RestoreRatio
+	}
+	if req.MinShedSeconds != nil {
+		setting.MinShedSeconds = *req.MinShedSeconds
+	}
+	if req.Loads != nil {
+		loads := make([]entities.SheddableLoad, len(req.Loads))
+		for i, load := range req.Loads {
+			loads[i] = entities.SheddableLoad{
+				CompanyDeviceID: load.CompanyDeviceID,
+				TargetType:      load.TargetType,
+				TargetID:        load.TargetID,
+			}
+		}
+		setting.Loads = loads
+	}
+
+	if err := setting.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.validateLoads(companyID, setting.Loads); err != nil {
+		return nil, err
+	}
+
+	setting.ModifyID = memberID
+	setting.ModifyTime = time.Now()
+	if err := s.demandRepo.SaveSetting(setting); err != nil {
+		return nil, err
+	}
+	log.Printf("[Demand] Company %d demand setting updated by member %d (enabled=%t, dry_run=%t, contract=%.1fkW, loads=%d)",
+		companyID, memberID, setting.Enabled, setting.DryRun, setting.ContractKW, len(setting.Loads))
+
+	if !setting.Enabled {
+		s.restoreAll(setting, "demand control disabled")
+	}
+
+	return dto.NewDemandSettingResponse(setting, true), nil
+}
+
+// GetDashboard 取得公司需量總覽：目前需量、預測、卸載中負載與最近事件
+func (s *DemandControlApplicationService) GetDashboard(companyID uint) (*dto.DemandDashboardResponse, error) {
+	settingResponse, err := s.GetSetting(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status, _, err := s.calculateStatus(companyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.demandRepo.FindActiveSheds(companyID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.demandRepo.FindEventsByCompanyID(companyID, DemandEventLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.DemandDashboardResponse{
+		CompanyID:   companyID,
+		Setting:     settingResponse,
+		Status:      dto.NewDemandStatusResponse(status, settingResponse.ContractKW),
+		ActiveSheds: make([]dto.ActiveShedResponse, len(active)),
+		Events:      make([]dto.DemandEventResponse, len(events)),
+	}
+	for i, shed := range active {
+		response.ActiveSheds[i] = dto.NewActiveShedResponse(shed)
+	}
+	for i, event := range events {
+		response.Events[i] = dto.NewDemandEventResponse(event)
+	}
+	return response, nil
+}
+
+// GetEvents 取得公司卸載 / 復歸事件紀錄
+func (s *DemandControlApplicationService) GetEvents(companyID uint, limit int) ([]dto.DemandEventResponse, error) {
+	events, err := s.demandRepo.FindEventsByCompanyID(companyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.DemandEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = dto.NewDemandEventResponse(event)
+	}
+	return responses, nil
+}
+
+// StartControlLoop 定期執行需量控制
+func (s *DemandControlApplicationService) StartControlLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Demand] Control loop started (interval: %s)", interval)
+	s.RunOnce(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Demand] Control loop stopped")
+			return
+		case <-ticker.C:
+			s.RunOnce(time.Now())
+		}
+	}
+}
+
+// RunOnce 對所有啟用需量控制的公司執行一次判斷
+func (s *DemandControlApplicationService) RunOnce(now time.Time) {
+	settings, err := s.demandRepo.FindEnabledSettings()
+	if err != nil {
+		log.Printf("[Demand] Failed to load demand settings: %v", err)
+		return
+	}
+
+	shedKeys := make(map[string]bool)
+	for _, setting := range settings {
+		active, err := s.evaluateCompany(setting, now)
+		if err != nil {
+			log.Printf("[Demand] Company %d evaluation failed: %v", setting.CompanyID, err)
+		}
+		for _, shed := range active {
+			if !shed.DryRun {
+				shedKeys[shed.Load.Key()] = true
+			}
+		}
+	}
+
+	s.shedMu.Lock()
+	s.shedKeys = shedKeys
+	s.shedMu.Unlock()
+}
+
+// evaluateCompany 計算公司需量並執行卸載 / 復歸，返回執行後仍卸載中的負載
+func (s *DemandControlApplicationService) evaluateCompany(setting *entities.DemandSetting, now time.Time) ([]*entities.ActiveShed, error) {
+	active, err := s.demandRepo.FindActiveSheds(setting.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, running, err := s.calculateStatus(setting.CompanyID, now)
+	if err != nil {
+		return active, err
+	}
+
+	action := s.demandService.Decide(setting, status, active, running, now)
+	switch action.Type {
+	case entities.EventTypeShed:
+		if shed := s.shed(setting, *action.Load, status, now); shed != nil {
+			active = append(active, shed)
+		}
+	case entities.EventTypeRestore:
+		if s.restore(setting, action.Shed, status, "") {
+			remaining := make([]*entities.ActiveShed, 0, len(active))
+			for _, a := range active {
+				if a.ID != action.Shed.ID {
+					remaining = append(remaining, a)
+				}
+			}
+			active = remaining
+		}
+	default:
+		if status.PredictedKW >= setting.ShedThresholdKW() {
+			log.Printf("[Demand] Company %d predicted %.1fkW exceeds %.1fkW but no more loads can be shed",
+				setting.CompanyID, status.PredictedKW, setting.ShedThresholdKW())
+		}
+	}
+
+	return active, nil
+}
+
+// shed 卸載負載並記錄事件
+func (s *DemandControlApplicationService) shed(setting *entities.DemandSetting, load entities.SheddableLoad, status *entities.DemandStatus, now time.Time) *entities.ActiveShed {
+	event := entities.NewDemandEvent(setting.CompanyID, entities.EventTypeShed, load, status, setting.ContractKW, setting.DryRun)
+
+	if !setting.DryRun {
+		result, err := s.commandAppService.SendAutomaticPower(load.CompanyDeviceID, load.TargetType, load.TargetID, false)
+		if err == nil && result.Status == commandEntities.StatusFailed {
+			err = errors.New(result.Message)
+		}
+		if err != nil {
+			event.Message = "command failed: " + err.Error()
+			s.saveEvent(event)
+			log.Printf("[Demand] Company %d failed to shed %s: %v", setting.CompanyID, load.Key(), err)
+			return nil
+		}
+		event.CommandID = result.CommandID
+	}
+
+	shed := &entities.ActiveShed{CompanyID: setting.CompanyID, Load: load, DryRun: setting.DryRun, ShedAt: now}
+	if err := s.demandRepo.SaveActiveShed(shed); err != nil {
+		log.Printf("[Demand] Failed to save active shed %s: %v", load.Key(), err)
+	}
+	s.saveEvent(event)
+
+	log.Printf("[Demand] Company %d shed %s (dry_run=%t, predicted %.1fkW / contract %.1fkW)",
+		setting.CompanyID, load.Key(), setting.DryRun, status.PredictedKW, setting.ContractKW)
+	return shed
+}
+
+// restore 復歸卸載中的負載並記錄事件
+func (s *DemandControlApplicationService) restore(setting *entities.DemandSetting, shed *entities.ActiveShed, status *entities.DemandStatus, message string) bool {
+	event := entities.NewDemandEvent(setting.CompanyID, entities.EventTypeRestore, shed.Load, status, setting.ContractKW, shed.DryRun)
+	event.Message = message
+
+	// dry-run 卸載並未實際停機，不需發送命令
+	if !shed.DryRun {
+		result, err := s.commandAppService.SendAutomaticPower(shed.Load.CompanyDeviceID, shed.Load.TargetType, shed.Load.TargetID, true)
+		if err == nil && result.Status == commandEntities.StatusFailed {
+			err = errors.New(result.Message)
+		}
+		if err != nil {
+			event.Message = "command failed: " + err.Error()
+			s.saveEvent(event)
+			log.Printf("[Demand] Company %d failed to restore %s: %v", setting.CompanyID, shed.Load.Key(), err)
+			return false
+		}
+		event.CommandID = result.CommandID
+	}
+
+	if err := s.demandRepo.DeleteActiveShed(shed.ID); err != nil {
+		log.Printf("[Demand] Failed to delete active shed %d: %v", shed.ID, err)
+	}
+	s.saveEvent(event)
+
+	log.Printf("[Demand] Company %d restored %s (dry_run=%t, predicted %.1fkW / contract %.1fkW)",
+		setting.CompanyID, shed.Load.Key(), shed.DryRun, status.PredictedKW, setting.ContractKW)
+	return true
+}
+
+// restoreAll 復歸公司所有卸載中的負載
+func (s *DemandControlApplicationService) restoreAll(setting *entities.DemandSetting, message string) {
+	active, err := s.demandRepo.FindActiveSheds(setting.CompanyID)
+	if err != nil {
+		log.Printf("[Demand] Failed to load active sheds of company %d: %v", setting.CompanyID, err)
+		return
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	status, _, err := s.calculateStatus(setting.CompanyID, time.Now())
+	if err != nil {
+		status = &entities.DemandStatus{CompanyID: setting.CompanyID}
+	}
+	for _, shed := range active {
+		if s.restore(setting, shed, status, message) {
+			s.shedMu.Lock()
+			delete(s.shedKeys, shed.Load.Key())
+			s.shedMu.Unlock()
+		}
+	}
+}
+
+// calculateStatus 查詢公司電表讀值並計算需量，同時返回各負載的運轉狀態
+func (s *DemandControlApplicationService) calculateStatus(companyID uint, now time.Time) (*entities.DemandStatus, map[string]bool, error) {
+	devices, err := s.companyDeviceRepo.FindByCompanyID(companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meterIDs []string
+	seen := make(map[string]bool)
+	running := make(map[string]bool)
+	for _, device := range devices {
+		content, err := device.ParseContent()
+		if err != nil {
+			continue
+		}
+		for _, area := range content.Areas {
+			for _, meterMapping := range area.MeterMappings {
+				if meterMapping.DeviceMeterID != "" && !seen[meterMapping.DeviceMeterID] {
+					seen[meterMapping.DeviceMeterID] = true
+					meterIDs = append(meterIDs, meterMapping.DeviceMeterID)
+				}
+			}
+		}
+		collectRunning(device.ID, content, running)
+	}
+
+	if len(meterIDs) == 0 {
+		return s.demandService.Calculate(companyID, nil, now), running, nil
+	}
+	readings, err := s.meterRepo.GetByMeterIDsAndTimeRange(meterIDs, s.demandService.QueryStart(now), now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.demandService.Calculate(companyID, readings, now), running, nil
+}
+
+// validateLoads 確認負載屬於該公司且存在於設備內容
+func (s *DemandControlApplicationService) validateLoads(companyID uint, loads []entities.SheddableLoad) error {
+	contents := make(map[uint]*companyDeviceEntities.DeviceContent)
+	for _, load := range loads {
+		content, ok := contents[load.CompanyDeviceID]
+		if !ok {
+			companyDevice, err := s.companyDeviceRepo.FindByID(load.CompanyDeviceID)
+			if err != nil || companyDevice.CompanyID != companyID {
+				return fmt.Errorf("company device %d not found in company", load.CompanyDeviceID)
+			}
+			content, _ = companyDevice.ParseContent()
+			contents[load.CompanyDeviceID] = content
+		}
+
+		// 卸載以關機命令執行，沿用命令驗證確認目標存在
+		command := commandEntities.NewDeviceCommand("", load.CompanyDeviceID, "", load.TargetType, load.TargetID, commandEntities.ActionPowerOff, 0)
+		if err := s.commandService.Validate(content, command); err != nil {
+			return fmt.Errorf("invalid load %s: %w", load.Key(), err)
+		}
+	}
+	return nil
+}
+
+func (s *DemandControlApplicationService) saveEvent(event *entities.DemandEvent) {
+	if err := s.demandRepo.SaveEvent(event); err != nil {
+		log.Printf("[Demand] Failed to save %s event for %s/%s: %v", event.EventType, event.TargetType, event.TargetID, err)
+	}
+}
+
+// collectRunning 記錄設備內各壓縮機 / VRF 室內機的運轉狀態
+func collectRunning(companyDeviceID uint, content *companyDeviceEntities.DeviceContent, running map[string]bool) {
+	for _, pkg := range content.Packages {
+		for _, compressor := range pkg.Compressors {
+			key := entities.SheddableLoad{CompanyDeviceID: companyDeviceID, TargetType: commandEntities.TargetTypeCompressor, TargetID: compressor.ID}.Key()
+			running[key] = compressor.RunStatus
+		}
+	}
+	for i := range content.VRFs {
+		for _, unit := range content.VRFs[i].GetUnits() {
+			key := entities.SheddableLoad{CompanyDeviceID: companyDeviceID, TargetType: commandEntities.TargetTypeACUnit, TargetID: unit.ID}.Key()
+			running[key] = unit.IsRunning()
+		}
+	}
+}
@@ -1,25 +1,51 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"io"
+	"log"
+	"time"
 
 	"ems_backend/internal/application/dto"
+	auditLogServices "ems_backend/internal/domain/audit_log/services"
 	"ems_backend/internal/domain/device/entities"
 	"ems_backend/internal/domain/device/repositories"
+	deviceServices "ems_backend/internal/domain/device/services"
+)
+
+// 認領碼有效期限
+const (
+	DefaultClaimCodeTTL = 7 * 24 * time.Hour
+	MaxClaimCodeTTL     = 30 * 24 * time.Hour
 )
 
 // DeviceApplicationService 設備應用服務
 type DeviceApplicationService struct {
-	deviceRepo repositories.DeviceRepository
+	deviceRepo          repositories.DeviceRepository
+	claimCodeRepo       repositories.ClaimCodeRepository
+	provisioningService *deviceServices.ProvisioningService
+	auditLogService     *auditLogServices.AuditLogService
 }
 
 // NewDeviceApplicationService 創建設備應用服務
 func NewDeviceApplicationService(deviceRepo repositories.DeviceRepository) *DeviceApplicationService {
 	return &DeviceApplicationService{
-		deviceRepo: deviceRepo,
+		deviceRepo:          deviceRepo,
+		provisioningService: deviceServices.NewProvisioningService(),
 	}
 }
 
+// SetClaimCodeRepository 設置認領碼倉儲 (可選，未設置時無法簽發認領碼)
+func (s *DeviceApplicationService) SetClaimCodeRepository(claimCodeRepo repositories.ClaimCodeRepository) {
+	s.claimCodeRepo = claimCodeRepo
+}
+
+// SetAuditLogService 設置審計日誌服務 (用於記錄認領碼自動過期)
+func (s *DeviceApplicationService) SetAuditLogService(auditLogService *auditLogServices.AuditLogService) {
+	s.auditLogService = auditLogService
+}
+
 // GetAllDevices 獲取所有設備
 func (s *DeviceApplicationService) GetAllDevices() ([]*dto.DeviceResponse, error) {
 	devices, err := s.deviceRepo.FindAll()
@@ -51,6 +77,7 @@ func (s *DeviceApplicationService) CreateDevice(req *dto.DeviceCreateRequest, cr
 
 	// 創建設備
 	device := entities.NewDevice(req.SN, createID)
+	device.SetDetails(req.Model, req.Notes)
 	if err := s.deviceRepo.Create(device); err != nil {
 		return nil, err
 	}
@@ -77,6 +104,14 @@ func (s *DeviceApplicationService) UpdateDevice(id uint, req *dto.DeviceUpdateRe
 
 	// 更新設備
 	device.Update(req.SN, modifyID)
+	model, notes := device.Model, device.Notes
+	if req.Model != nil {
+		model = *req.Model
+	}
+	if req.Notes != nil {
+		notes = *req.Notes
+	}
+	device.SetDetails(model, notes)
 	if err := s.deviceRepo.Update(device); err != nil {
 		return nil, err
 	}
@@ -103,3 +138,216 @@ func (s *DeviceApplicationService) GetUnassignedDevices() ([]*dto.DeviceResponse
 	}
 	return dto.NewDeviceResponseList(devices), nil
 }
+
+// ImportDevices 從 CSV 批次建檔設備並返回逐列驗證報告
+// 驗證失敗的列會被略過，其餘列在同一交易內建立；可選擇同時簽發認領碼
+func (s *DeviceApplicationService) ImportDevices(r io.Reader, opts dto.DeviceImportOptions, createID, roleID uint) (*dto.DeviceImportReport, error) {
+	if opts.IssueClaimCodes && s.claimCodeRepo == nil {
+		return nil, errors.New("claim codes are not available")
+	}
+	ttl, err := normalizeClaimCodeTTL(opts.ClaimCodeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.provisioningService.ParseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sns := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.SN != "" {
+			sns = append(sns, row.SN)
+		}
+	}
+	existing, err := s.deviceRepo.FindExistingSNs(sns)
+	if err != nil {
+		return nil, err
+	}
+	existingSNs := make(map[string]bool, len(existing))
+	for _, sn := range existing {
+		existingSNs[sn] = true
+	}
+	s.provisioningService.ValidateRows(rows, existingSNs)
+
+	report := &dto.DeviceImportReport{DryRun: opts.DryRun, Total: len(rows)}
+	var devices []*entities.Device
+	var created []*dto.DeviceImportRowResult
+	for _, row := range rows {
+		result := &dto.DeviceImportRowResult{
+			Line:   row.Line,
+			SN:     row.SN,
+			Model:  row.Model,
+			Notes:  row.Notes,
+			Status: "valid",
+			Errors: row.Errors,
+		}
+		report.Rows = append(report.Rows, result)
+		if !row.Valid() {
+			result.Status = "error"
+			report.Failed++
+			continue
+		}
+		report.Valid++
+
+		device := entities.NewDevice(row.SN, createID)
+		device.SetDetails(row.Model, row.Notes)
+		devices = append(devices, device)
+		created = append(created, result)
+	}
+
+	if opts.DryRun || len(devices) == 0 {
+		return report, nil
+	}
+
+	if err := s.deviceRepo.CreateBatch(devices); err != nil {
+		return nil, err
+	}
+	for i, device := range devices {
+		result := created[i]
+		result.Status = "created"
+		result.DeviceID = device.ID
+		report.Created++
+
+		if !opts.IssueClaimCodes {
+			continue
+		}
+		claimCode, err := s.issueClaimCode(device.ID, ttl, createID, roleID)
+		if err != nil {
+			// 設備已建立，認領碼可稍後重新簽發
+			result.Errors = append(result.Errors, "failed to issue claim code: "+err.Error())
+			continue
+		}
+		result.ClaimCode = claimCode
+	}
+
+	return report, nil
+}
+
+// IssueClaimCode 為未綁定的設備簽發一次性認領碼，先前仍有效的認領碼會被撤銷
+// 明文只在此處返回一次，資料庫僅保存雜湊
+func (s *DeviceApplicationService) IssueClaimCode(deviceID uint, ttl time.Duration, memberID, roleID uint) (*dto.ClaimCodeResponse, error) {
+	if s.claimCodeRepo == nil {
+		return nil, errors.New("claim codes are not available")
+	}
+	ttl, err := normalizeClaimCodeTTL(ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deviceRepo.FindByID(deviceID); err != nil {
+		return nil, errors.New("device not found")
+	}
+	assigned, err := s.deviceRepo.IsAssigned(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if assigned {
+		return nil, errors.New("device already assigned to a company")
+	}
+
+	return s.issueClaimCode(deviceID, ttl, memberID, roleID)
+}
+
+// GetClaimCodes 取得設備的認領碼紀錄 (不含明文)
+func (s *DeviceApplicationService) GetClaimCodes(deviceID uint) ([]*dto.ClaimCodeResponse, error) {
+	if s.claimCodeRepo == nil {
+		return []*dto.ClaimCodeResponse{}, nil
+	}
+	codes, err := s.claimCodeRepo.FindByDeviceID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*dto.ClaimCodeResponse, len(codes))
+	for i, code := range codes {
+		result[i] = dto.NewClaimCodeResponse(code, "")
+	}
+	return result, nil
+}
+
+// StartClaimCodeExpiryLoop 定期將逾期的認領碼標記為過期並寫入審計日誌，直到 ctx 取消
+func (s *DeviceApplicationService) StartClaimCodeExpiryLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.claimCodeRepo == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[ClaimCode] Expiry loop started (interval: %s)", interval)
+	s.ExpireClaimCodes(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[ClaimCode] Expiry loop stopped")
+			return
+		case <-ticker.C:
+			s.ExpireClaimCodes(time.Now())
+		}
+	}
+}
+
+// ExpireClaimCodes 標記逾期認領碼，返回處理數量
+// 審計日誌需要操作者，以簽發者與其簽發時的角色記錄
+func (s *DeviceApplicationService) ExpireClaimCodes(now time.Time) int {
+	codes, err := s.claimCodeRepo.FindActiveExpired(now)
+	if err != nil {
+		log.Printf("[ClaimCode] Failed to load expired claim codes: %v", err)
+		return 0
+	}
+
+	expired := 0
+	for _, code := range codes {
+		code.Expire()
+		ok, err := s.claimCodeRepo.CloseIfActive(code)
+		if err != nil {
+			log.Printf("[ClaimCode] Failed to expire claim code %d: %v", code.ID, err)
+			continue
+		}
+		if !ok {
+			continue // 同時被兌換或撤銷
+		}
+		expired++
+
+		if s.auditLogService != nil {
+			deviceID := code.DeviceID
+			_ = s.auditLogService.LogSuccess(code.IssuedBy, code.IssuedRoleID, "EXPIRE_CLAIM_CODE", "DEVICE", &deviceID, map[string]interface{}{
+				"claim_code_id": code.ID,
+				"code_hint":     code.CodeHint,
+				"expires_at":    code.ExpiresAt,
+				"automatic":     true,
+			}, "", "system")
+		}
+	}
+	if expired > 0 {
+		log.Printf("[ClaimCode] Expired %d claim code(s)", expired)
+	}
+	return expired
+}
+
+func (s *DeviceApplicationService) issueClaimCode(deviceID uint, ttl time.Duration, memberID, roleID uint) (*dto.ClaimCodeResponse, error) {
+	plaintext, hash, hint, err := s.provisioningService.GenerateClaimCode()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.claimCodeRepo.RevokeActiveByDeviceID(deviceID); err != nil {
+		return nil, err
+	}
+
+	code := entities.NewClaimCode(deviceID, hash, hint, ttl, memberID, roleID)
+	if err := s.claimCodeRepo.Create(code); err != nil {
+		return nil, err
+	}
+	return dto.NewClaimCodeResponse(code, plaintext), nil
+}
+
+func normalizeClaimCodeTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		return DefaultClaimCodeTTL, nil
+	}
+	if ttl < time.Hour || ttl > MaxClaimCodeTTL {
+		return 0, errors.New("claim code TTL must be between 1 hour and 30 days")
+	}
+	return ttl, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	companyDeviceRepos "ems_backend/internal/domain/company_device/repositories"
+	deviceRepos "ems_backend/internal/domain/device/repositories"
+	"ems_backend/internal/domain/device_command/entities"
+	"ems_backend/internal/domain/device_command/repositories"
+	"ems_backend/internal/domain/device_command/services"
+	"ems_backend/internal/infrastructure/mqtt"
+
+	"github.com/google/uuid"
+)
+
+// MaxCommandWait 等待設備確認的最長時間
+const MaxCommandWait = entities.AckTimeout
+
+// SystemMemberID 系統自動發送命令時的 RequestedBy
+const SystemMemberID uint = 0
+
+// DeviceCommandApplicationService 遠端控制命令應用服務
+type DeviceCommandApplicationService struct {
+	commandRepo       repositories.DeviceCommandRepository
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
+	deviceRepo        deviceRepos.DeviceRepository
+	commandService    *services.DeviceCommandService
+	publisher         *mqtt.CommandPublisher // Optional: nil when MQTT is disabled
+
+	// 等待設備確認的呼叫者 (command_id -> channel)
+	waiters   map[string]chan struct{}
+	waitersMu sync.Mutex
+}
+
+// NewDeviceCommandApplicationService 創建遠端控制命令應用服務
+func NewDeviceCommandApplicationService(
+	commandRepo repositories.DeviceCommandRepository,
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository,
+	deviceRepo deviceRepos.DeviceRepository,
+) *DeviceCommandApplicationService {
+	return &DeviceCommandApplicationService{
+		commandRepo:       commandRepo,
+		companyDeviceRepo: companyDeviceRepo,
+		deviceRepo:        deviceRepo,
+		commandService:    services.NewDeviceCommandService(),
+		waiters:           make(map[string]chan struct{}),
+	}
+}
+
+// SetPublisher 設置 MQTT 命令發布者 (可選)
+func (s *DeviceCommandApplicationService) SetPublisher(publisher *mqtt.CommandPublisher) {
+	s.publisher = publisher
+}
+
+// SendPower 發送開關機命令
+func (s *DeviceCommandApplicationService) SendPower(companyID, deviceID uint, req *dto.DevicePowerCommandRequest, memberID uint, wait time.Duration) (*dto.DeviceCommandResponse, error) {
+	action := entities.ActionPowerOff
+	if *req.On {
+		action = entities.ActionPowerOn
+	}
+	return s.send(companyID, deviceID, req.TargetType, req.TargetID, action, "", nil, memberID, wait)
+}
+
+// SendMode 發送運轉模式命令 (僅 VRF 室內機)
+func (s *DeviceCommandApplicationService) SendMode(companyID, deviceID uint, req *dto.DeviceModeCommandRequest, memberID uint, wait time.Duration) (*dto.DeviceCommandResponse, error) {
+	return s.send(companyID, deviceID, entities.TargetTypeACUnit, req.TargetID, entities.ActionSetMode, req.Mode, nil, memberID, wait)
+}
+
+// SendSetpoint 發送設定溫度命令 (僅 VRF 室內機)
+func (s *DeviceCommandApplicationService) SendSetpoint(companyID, deviceID uint, req *dto.DeviceSetpointCommandRequest, memberID uint, wait time.Duration) (*dto.DeviceCommandResponse, error) {
+	return s.send(companyID, deviceID, entities.TargetTypeACUnit, req.TargetID, entities.ActionSetSetpoint, "", req.Setpoint, memberID, wait)
+}
+
+// SendAutomaticPower 由系統 (例如舒適度閉環控制) 發送開關機命令，RequestedBy 為 0
+func (s *DeviceCommandApplicationService) SendAutomaticPower(companyDeviceID uint, targetType, targetID string, on bool) (*dto.DeviceCommandResponse, error) {
+	if s.publisher == nil {
+		return nil, errors.New("MQTT publisher not configured")
+	}
+
+	companyDevice, err := s.companyDeviceRepo.FindByID(companyDeviceID)
+	if err != nil {
+		return nil, errors.New("company device not found")
+	}
+
+	action := entities.ActionPowerOff
+	if on {
+		action = entities.ActionPowerOn
+	}
+	return s.dispatch(companyDevice, targetType, targetID, action, "", nil, SystemMemberID, 0)
+}
+
+// GetCommand 查詢命令狀態
+func (s *DeviceCommandApplicationService) GetCommand(companyID, deviceID uint, commandID string) (*dto.DeviceCommandResponse, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil {
+		return nil, errors.New("company device not found")
+	}
+
+	command, err := s.commandRepo.FindByCommandID(commandID)
+	if err != nil || command.CompanyDeviceID != companyDevice.ID {
+		return nil, errors.New("command not found")
+	}
+	s.checkTimeout(command)
+	return dto.NewDeviceCommandResponse(command), nil
+}
+
+// ListCommands 查詢設備命令紀錄
+func (s *DeviceCommandApplicationService) ListCommands(companyID, deviceID uint, limit int) ([]*dto.DeviceCommandResponse, error) {
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil {
+		return nil, errors.New("company device not found")
+	}
+
+	commands, err := s.commandRepo.FindByCompanyDeviceID(companyDevice.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.DeviceCommandResponse, len(commands))
+	for i, command := range commands {
+		s.checkTimeout(command)
+		responses[i] = dto.NewDeviceCommandResponse(command)
+	}
+	return responses, nil
+}
+
+// HandleCommandAck 處理設備回覆 (implements mqtt.CommandAckHandler)
+func (s *DeviceCommandApplicationService) HandleCommandAck(deviceSN, commandID string, success bool, message string) {
+	command, err := s.commandRepo.FindByCommandID(commandID)
+	if err != nil {
+		log.Printf("[Command] Ack for unknown command %s from %s", commandID, deviceSN)
+		return
+	}
+	if command.DeviceSN != deviceSN {
+		log.Printf("[Command] Ack for command %s came from %s, expected %s", commandID, deviceSN, command.DeviceSN)
+		return
+	}
+
+	command.Acknowledge(success, message)
+	if err := s.commandRepo.Update(command); err != nil {
+		log.Printf("[Command] Failed to update command %s: %v", commandID, err)
+	}
+	log.Printf("[Command] Command %s acknowledged by %s: %s", commandID, deviceSN, command.Status)
+
+	s.waitersMu.Lock()
+	if ch, ok := s.waiters[commandID]; ok {
+		close(ch)
+		delete(s.waiters, commandID)
+	}
+	s.waitersMu.Unlock()
+}
+
+// send 驗證、保存並發送命令，wait > 0 時等待設備確認
+func (s *DeviceCommandApplicationService) send(
+	companyID, deviceID uint,
+	targetType, targetID, action, mode string,
+	setpoint *float64,
+	memberID uint,
+	wait time.Duration,
+) (*dto.DeviceCommandResponse, error) {
+	if s.publisher == nil {
+		return nil, errors.New("MQTT publisher not configured")
+	}
+
+	companyDevice, err := s.companyDeviceRepo.FindByCompanyAndDevice(companyID, deviceID)
+	if err != nil {
+		return nil, errors.New("company device not found")
+	}
+	return s.dispatch(companyDevice, targetType, targetID, action, mode, setpoint, memberID, wait)
+}
+
+// dispatch 對指定公司設備驗證、保存並發送命令
+func (s *DeviceCommandApplicationService) dispatch(
+	companyDevice *companyDeviceEntities.CompanyDevice,
+	targetType, targetID, action, mode string,
+	setpoint *float64,
+	memberID uint,
+	wait time.Duration,
+) (*dto.DeviceCommandResponse, error) {
+	device, err := s.deviceRepo.FindByID(companyDevice.DeviceID)
+	if err != nil {
+		return nil, errors.New("device not found")
+	}
+	if device.SN == "" {
+		return nil, errors.New("device serial number is not set")
+	}
+
+	command := entities.NewDeviceCommand(uuid.New().String(), companyDevice.ID, device.SN, targetType, targetID, action, memberID)
+	command.Mode = mode
+	command.Setpoint = setpoint
+
+	content, _ := companyDevice.ParseContent()
+	if err := s.commandService.Validate(content, command); err != nil {
+		return nil, err
+	}
+
+	if err := s.commandRepo.Save(command); err != nil {
+		return nil, err
+	}
+
+	// 先註冊等待者，避免設備回覆早於等待開始
+	var ackCh chan struct{}
+	if wait > 0 {
+		if wait > MaxCommandWait {
+			wait = MaxCommandWait
+		}
+		ackCh = make(chan struct{})
+		s.waitersMu.Lock()
+		s.waiters[command.CommandID] = ackCh
+		s.waitersMu.Unlock()
+	}
+
+	err = s.publisher.PublishControl(device.SN, &mqtt.ControlCommand{
+		CommandID:  command.CommandID,
+		TargetType: command.TargetType,
+		TargetID:   command.TargetID,
+		Action:     command.Action,
+		Mode:       command.Mode,
+		Setpoint:   command.Setpoint,
+	})
+	if err != nil {
+		s.removeWaiter(command.CommandID)
+		command.MarkFailed(err.Error())
+		s.commandRepo.Update(command)
+		return dto.NewDeviceCommandResponse(command), nil
+	}
+
+	command.MarkSent()
+	if err := s.commandRepo.Update(command); err != nil {
+		log.Printf("[Command] Failed to update command %s: %v", command.CommandID, err)
+	}
+	log.Printf("[Command] Sent %s to %s %s on device %s (command %s)", action, targetType, targetID, device.SN, command.CommandID)
+
+	if ackCh == nil {
+		return dto.NewDeviceCommandResponse(command), nil
+	}
+
+	select {
+	case <-ackCh:
+	case <-time.After(wait):
+		s.removeWaiter(command.CommandID)
+	}
+
+	latest, err := s.commandRepo.FindByCommandID(command.CommandID)
+	if err != nil {
+		return dto.NewDeviceCommandResponse(command), nil
+	}
+	return dto.NewDeviceCommandResponse(latest), nil
+}
+
+// checkTimeout 將逾時未確認的命令標記為 timeout
+func (s *DeviceCommandApplicationService) checkTimeout(command *entities.DeviceCommand) {
+	if command.CheckTimeout(time.Now()) {
+		if err := s.commandRepo.Update(command); err != nil {
+			log.Printf("[Command] Failed to mark command %s as timeout: %v", command.CommandID, err)
+		}
+	}
+}
+
+func (s *DeviceCommandApplicationService) removeWaiter(commandID string) {
+	s.waitersMu.Lock()
+	delete(s.waiters, commandID)
+	s.waitersMu.Unlock()
+}
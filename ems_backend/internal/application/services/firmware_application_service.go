@@ -0,0 +1,607 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	deviceRepos "ems_backend/internal/domain/device/repositories"
+	"ems_backend/internal/domain/firmware/entities"
+	"ems_backend/internal/domain/firmware/repositories"
+	"ems_backend/internal/domain/firmware/services"
+	"ems_backend/internal/infrastructure/mqtt"
+
+	"github.com/google/uuid"
+)
+
+// FirmwareApplicationService 閘道器韌體盤點與 OTA 分批發布應用服務
+// 由 deviceInfo 回覆記錄各設備版本；發布活動依 BatchSize 分批派送 OTA 命令，
+// 追蹤設備回報的進度，失敗率超過門檻時自動停止
+type FirmwareApplicationService struct {
+	firmwareRepo    repositories.FirmwareRepository
+	campaignRepo    repositories.CampaignRepository
+	deviceRepo      deviceRepos.DeviceRepository
+	firmwareService *services.FirmwareService
+	publisher       *mqtt.CommandPublisher // Optional: nil when MQTT is disabled
+
+	// 序列化活動狀態變更 (控制迴圈、設備回報與 API 操作)
+	mu sync.Mutex
+}
+
+// NewFirmwareApplicationService 創建韌體應用服務
+func NewFirmwareApplicationService(
+	firmwareRepo repositories.FirmwareRepository,
+	campaignRepo repositories.CampaignRepository,
+	deviceRepo deviceRepos.DeviceRepository,
+) *FirmwareApplicationService {
+	return &FirmwareApplicationService{
+		firmwareRepo:    firmwareRepo,
+		campaignRepo:    campaignRepo,
+		deviceRepo:      deviceRepo,
+		firmwareService: services.NewFirmwareService(),
+	}
+}
+
+// SetPublisher 設置 MQTT 命令發布者 (可選)
+func (s *FirmwareApplicationService) SetPublisher(publisher *mqtt.CommandPublisher) {
+	s.publisher = publisher
+}
+
+// ==================== 韌體目錄 ====================
+
+// ListFirmware 取得韌體目錄 (最新在前)
+func (s *FirmwareApplicationService) ListFirmware() ([]*dto.FirmwareResponse, error) {
+	firmwares, err := s.firmwareRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.FirmwareResponse, len(firmwares))
+	for i, firmware := range firmwares {
+		result[i] = dto.NewFirmwareResponse(firmware)
+	}
+	return result, nil
+}
+
+// CreateFirmware 新增韌體檔案
+func (s *FirmwareApplicationService) CreateFirmware(req *dto.CreateFirmwareRequest, memberID uint) (*dto.FirmwareResponse, error) {
+	firmware := entities.NewFirmware(req.Version, req.HardwareVersion, req.URL, req.SHA256, req.SizeBytes, req.ReleaseNotes, memberID)
+	if err := firmware.Validate(); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.firmwareRepo.ExistsByVersion(firmware.Version, firmware.HardwareVersion)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("firmware version already exists for this hardware version")
+	}
+
+	if err := s.firmwareRepo.Save(firmware); err != nil {
+		return nil, err
+	}
+	return dto.NewFirmwareResponse(firmware), nil
+}
+
+// ==================== 版本盤點 ====================
+
+// GetInventory 取得所有設備的韌體 / 硬體版本
+func (s *FirmwareApplicationService) GetInventory() (*dto.FirmwareInventoryResponse, error) {
+	devices, err := s.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.inventoryByDeviceID()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.FirmwareInventoryResponse{
+		Devices:  make([]*dto.DeviceFirmwareResponse, 0, len(devices)),
+		Versions: make(map[string]int),
+	}
+	for _, device := range devices {
+		item := &dto.DeviceFirmwareResponse{DeviceID: device.ID, DeviceSN: device.SN}
+		if reported, ok := inventory[device.ID]; ok {
+			item.FirmwareVersion = reported.FirmwareVersion
+			item.HardwareVersion = reported.HardwareVersion
+			reportedAt := reported.ReportedAt
+			item.ReportedAt = &reportedAt
+			result.Versions[reported.FirmwareVersion]++
+		} else {
+			result.Unknown++
+		}
+		result.Devices = append(result.Devices, item)
+	}
+	return result, nil
+}
+
+// HandleDeviceInfo 記錄 deviceInfo 回覆中的版本 (implements mqtt.FirmwareReportHandler)
+// 進行中的更新若回報版本已是目標版本，視為更新成功
+func (s *FirmwareApplicationService) HandleDeviceInfo(deviceSN string, data json.RawMessage) {
+	firmwareVersion, hardwareVersion, ok := s.firmwareService.ExtractVersions(data)
+	if !ok {
+		return
+	}
+
+	device, err := s.deviceRepo.FindBySN(deviceSN)
+	if err != nil || device == nil {
+		return
+	}
+
+	if err := s.firmwareRepo.SaveInventory(&entities.DeviceFirmware{
+		DeviceID:        device.ID,
+		FirmwareVersion: firmwareVersion,
+		HardwareVersion: hardwareVersion,
+		ReportedAt:      time.Now(),
+	}); err != nil {
+		log.Printf("[Firmware] Failed to record version for %s: %v", deviceSN, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaignDevice, err := s.campaignRepo.FindInFlightDevice(device.ID)
+	if err != nil || campaignDevice == nil {
+		return
+	}
+	campaign, firmware, err := s.loadCampaign(campaignDevice.CampaignID)
+	if err != nil || firmware.Version != firmwareVersion {
+		return
+	}
+
+	campaignDevice.Succeed(fmt.Sprintf("device reports firmware %s", firmwareVersion), time.Now())
+	if err := s.campaignRepo.UpdateDevice(campaignDevice); err != nil {
+		log.Printf("[Firmware] Failed to update campaign device %d: %v", campaignDevice.ID, err)
+		return
+	}
+	log.Printf("[Firmware] %s confirmed firmware %s (campaign %d)", deviceSN, firmwareVersion, campaign.ID)
+}
+
+// HandleOTAProgress 記錄 OTA 進度回報 (implements mqtt.FirmwareReportHandler)
+func (s *FirmwareApplicationService) HandleOTAProgress(deviceSN, commandID string, success bool, message string, data json.RawMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaignDevice, err := s.campaignRepo.FindDeviceByCommandID(commandID)
+	if err != nil || campaignDevice == nil {
+		return false
+	}
+	if campaignDevice.DeviceSN != deviceSN {
+		log.Printf("[Firmware] OTA report for command %s came from %s, expected %s", commandID, deviceSN, campaignDevice.DeviceSN)
+		return true
+	}
+	if campaignDevice.IsFinal() {
+		return true
+	}
+
+	var report struct {
+		Status   string `json:"status"`
+		Progress int    `json:"progress"`
+		Message  string `json:"message"`
+	}
+	if len(data) > 0 {
+		json.Unmarshal(data, &report)
+	}
+	if report.Message != "" {
+		message = report.Message
+	}
+
+	now := time.Now()
+	status := s.firmwareService.ParseReportStatus(report.Status)
+	switch {
+	case !success || status == entities.DeviceStatusFailed:
+		if message == "" {
+			message = "device reported OTA failure"
+		}
+		campaignDevice.Fail(entities.DeviceStatusFailed, message, now)
+	case status == entities.DeviceStatusSucceeded:
+		campaignDevice.Succeed(message, now)
+	case status != "":
+		campaignDevice.ReportProgress(status, report.Progress, message, now)
+	default:
+		// 僅確認收到命令
+		campaignDevice.ReportProgress(campaignDevice.Status, report.Progress, message, now)
+	}
+
+	if err := s.campaignRepo.UpdateDevice(campaignDevice); err != nil {
+		log.Printf("[Firmware] Failed to update campaign device %d: %v", campaignDevice.ID, err)
+		return true
+	}
+	log.Printf("[Firmware] OTA %s on %s: %s %d%%", commandID, deviceSN, campaignDevice.Status, campaignDevice.Progress)
+
+	if campaignDevice.Status == entities.DeviceStatusFailed {
+		// 失敗時立即評估是否停止，不必等到下一次控制迴圈
+		if campaign, _, err := s.loadCampaign(campaignDevice.CampaignID); err == nil && campaign.Status == entities.CampaignStatusRunning {
+			s.checkHalt(campaign)
+		}
+	}
+	return true
+}
+
+// ==================== 發布活動 ====================
+
+// CreateCampaign 建立發布活動並挑選目標設備，建立後由控制迴圈開始派送
+func (s *FirmwareApplicationService) CreateCampaign(req *dto.CreateCampaignRequest, memberID uint) (*dto.CampaignDetailResponse, error) {
+	firmware, err := s.firmwareRepo.FindByID(req.FirmwareID)
+	if err != nil {
+		return nil, err
+	}
+	if firmware == nil {
+		return nil, errors.New("firmware not found")
+	}
+
+	campaign := entities.NewCampaign(req.Name, firmware.ID, memberID)
+	switch {
+	case len(req.DeviceIDs) > 0 && req.Percentage > 0:
+		return nil, errors.New("specify either percentage or device_ids, not both")
+	case len(req.DeviceIDs) > 0:
+		campaign.TargetMode = entities.TargetModeDevices
+		campaign.DeviceIDs = req.DeviceIDs
+	case req.Percentage > 0:
+		campaign.TargetMode = entities.TargetModePercentage
+		campaign.Percentage = req.Percentage
+	}
+	if req.BatchSize > 0 {
+		campaign.BatchSize = req.BatchSize
+	}
+	if req.MaxFailureRate != nil {
+		campaign.MaxFailureRate = *req.MaxFailureRate
+	}
+	if req.MinSamples > 0 {
+		campaign.MinSamples = req.MinSamples
+	}
+	if req.DeviceTimeoutSeconds > 0 {
+		campaign.DeviceTimeoutSeconds = req.DeviceTimeoutSeconds
+	}
+	if err := campaign.Validate(); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.candidates(campaign)
+	if err != nil {
+		return nil, err
+	}
+	selected, skipped := s.firmwareService.SelectTargets(campaign, firmware, candidates)
+	if len(selected) == 0 {
+		return nil, errors.New("no eligible devices for this firmware")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.campaignRepo.Save(campaign); err != nil {
+		return nil, err
+	}
+	devices := make([]*entities.CampaignDevice, len(selected))
+	for i, target := range selected {
+		devices[i] = entities.NewCampaignDevice(target.DeviceID, target.DeviceSN, target.FirmwareVersion)
+	}
+	if err := s.campaignRepo.SaveDevices(campaign.ID, devices); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Firmware] Campaign %d (%s) created: firmware %s to %d devices (%d skipped)",
+		campaign.ID, campaign.Name, firmware.Version, len(devices), len(skipped))
+
+	detail := s.newDetailResponse(campaign, firmware, devices)
+	detail.Skipped = skipped
+	return detail, nil
+}
+
+// ListCampaigns 取得所有發布活動 (最新在前)
+func (s *FirmwareApplicationService) ListCampaigns() ([]*dto.CampaignResponse, error) {
+	campaigns, err := s.campaignRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.CampaignResponse, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		devices, err := s.campaignRepo.FindDevices(campaign.ID)
+		if err != nil {
+			return nil, err
+		}
+		version := ""
+		if firmware, err := s.firmwareRepo.FindByID(campaign.FirmwareID); err == nil && firmware != nil {
+			version = firmware.Version
+		}
+		result = append(result, dto.NewCampaignResponse(campaign, version, s.firmwareService.Summarize(campaign, devices)))
+	}
+	return result, nil
+}
+
+// GetCampaign 取得發布活動與各設備進度
+func (s *FirmwareApplicationService) GetCampaign(id uint) (*dto.CampaignDetailResponse, error) {
+	campaign, firmware, err := s.loadCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.campaignRepo.FindDevices(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newDetailResponse(campaign, firmware, devices), nil
+}
+
+// PauseCampaign 暫停派送新的更新
+func (s *FirmwareApplicationService) PauseCampaign(id, memberID uint) (*dto.CampaignDetailResponse, error) {
+	return s.transition(id, func(campaign *entities.Campaign, _ []*entities.CampaignDevice) error {
+		return campaign.Pause(memberID)
+	})
+}
+
+// ResumeCampaign 恢復暫停或自動停止的活動；自動停止後恢復時失敗率重新計算
+func (s *FirmwareApplicationService) ResumeCampaign(id, memberID uint) (*dto.CampaignDetailResponse, error) {
+	return s.transition(id, func(campaign *entities.Campaign, devices []*entities.CampaignDevice) error {
+		return campaign.Resume(s.firmwareService.Summarize(campaign, devices), memberID)
+	})
+}
+
+// CancelCampaign 取消活動，尚未派送的設備標記為 cancelled
+func (s *FirmwareApplicationService) CancelCampaign(id, memberID uint) (*dto.CampaignDetailResponse, error) {
+	return s.transition(id, func(campaign *entities.Campaign, devices []*entities.CampaignDevice) error {
+		if err := campaign.Cancel(memberID); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, device := range devices {
+			if device.Status != entities.DeviceStatusPending {
+				continue
+			}
+			device.Cancel(now)
+			if err := s.campaignRepo.UpdateDevice(device); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ==================== 控制迴圈 ====================
+
+// StartCampaignLoop 定期推進執行中的發布活動，直到 ctx 取消
+func (s *FirmwareApplicationService) StartCampaignLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Firmware] Campaign loop started (interval: %s)", interval)
+	s.RunOnce(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Firmware] Campaign loop stopped")
+			return
+		case <-ticker.C:
+			s.RunOnce(time.Now())
+		}
+	}
+}
+
+// RunOnce 對每個執行中的活動：標記逾時、評估失敗率、派送下一批或標記完成
+func (s *FirmwareApplicationService) RunOnce(now time.Time) {
+	if s.publisher == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaigns, err := s.campaignRepo.FindByStatus(entities.CampaignStatusRunning)
+	if err != nil {
+		log.Printf("[Firmware] Failed to load running campaigns: %v", err)
+		return
+	}
+	for _, campaign := range campaigns {
+		if err := s.advance(campaign, now); err != nil {
+			log.Printf("[Firmware] Campaign %d failed to advance: %v", campaign.ID, err)
+		}
+	}
+}
+
+// advance 推進單一活動
+func (s *FirmwareApplicationService) advance(campaign *entities.Campaign, now time.Time) error {
+	devices, err := s.campaignRepo.FindDevices(campaign.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		if device.CheckTimeout(now, campaign.DeviceTimeout()) {
+			if err := s.campaignRepo.UpdateDevice(device); err != nil {
+				return err
+			}
+			log.Printf("[Firmware] Campaign %d: %s timed out", campaign.ID, device.DeviceSN)
+		}
+	}
+
+	progress := s.firmwareService.Summarize(campaign, devices)
+	if halt, reason := s.firmwareService.ShouldHalt(campaign, progress); halt {
+		campaign.Halt(reason)
+		log.Printf("[Firmware] Campaign %d halted: %s", campaign.ID, reason)
+		return s.campaignRepo.Save(campaign)
+	}
+	if progress.Pending == 0 && progress.InFlight == 0 {
+		campaign.Complete()
+		log.Printf("[Firmware] Campaign %d completed: %d succeeded, %d failed", campaign.ID, progress.Succeeded, progress.Failed)
+		return s.campaignRepo.Save(campaign)
+	}
+
+	batch := s.firmwareService.NextBatch(campaign, devices)
+	if len(batch) == 0 {
+		return nil
+	}
+	firmware, err := s.firmwareRepo.FindByID(campaign.FirmwareID)
+	if err != nil {
+		return err
+	}
+	if firmware == nil {
+		return errors.New("firmware not found")
+	}
+	for _, device := range batch {
+		s.dispatch(firmware, device, now)
+	}
+	return nil
+}
+
+// dispatch 發送 OTA 命令給單一設備，發送失敗視為更新失敗
+func (s *FirmwareApplicationService) dispatch(firmware *entities.Firmware, device *entities.CampaignDevice, now time.Time) {
+	commandID := uuid.New().String()
+	device.MarkSent(commandID, now)
+
+	err := s.publisher.PublishOTA(device.DeviceSN, &mqtt.OTACommand{
+		CommandID: commandID,
+		Version:   firmware.Version,
+		URL:       firmware.URL,
+		SHA256:    firmware.SHA256,
+		Size:      firmware.SizeBytes,
+	})
+	if err != nil {
+		device.Fail(entities.DeviceStatusFailed, fmt.Sprintf("publish failed: %v", err), now)
+	} else {
+		log.Printf("[Firmware] Sent OTA %s (firmware %s) to %s", commandID, firmware.Version, device.DeviceSN)
+	}
+
+	if err := s.campaignRepo.UpdateDevice(device); err != nil {
+		log.Printf("[Firmware] Failed to update campaign device %d: %v", device.ID, err)
+	}
+}
+
+// checkHalt 依目前進度判斷是否自動停止活動
+func (s *FirmwareApplicationService) checkHalt(campaign *entities.Campaign) {
+	devices, err := s.campaignRepo.FindDevices(campaign.ID)
+	if err != nil {
+		return
+	}
+	progress := s.firmwareService.Summarize(campaign, devices)
+	if halt, reason := s.firmwareService.ShouldHalt(campaign, progress); halt {
+		campaign.Halt(reason)
+		if err := s.campaignRepo.Save(campaign); err != nil {
+			log.Printf("[Firmware] Failed to halt campaign %d: %v", campaign.ID, err)
+			return
+		}
+		log.Printf("[Firmware] Campaign %d halted: %s", campaign.ID, reason)
+	}
+}
+
+// ==================== 私有輔助方法 ====================
+
+// transition 載入活動與設備後套用狀態變更並保存
+func (s *FirmwareApplicationService) transition(id uint, apply func(*entities.Campaign, []*entities.CampaignDevice) error) (*dto.CampaignDetailResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign, firmware, err := s.loadCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.campaignRepo.FindDevices(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(campaign, devices); err != nil {
+		return nil, err
+	}
+	if err := s.campaignRepo.Save(campaign); err != nil {
+		return nil, err
+	}
+	return s.newDetailResponse(campaign, firmware, devices), nil
+}
+
+func (s *FirmwareApplicationService) loadCampaign(id uint) (*entities.Campaign, *entities.Firmware, error) {
+	campaign, err := s.campaignRepo.FindByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if campaign == nil {
+		return nil, nil, errors.New("campaign not found")
+	}
+	firmware, err := s.firmwareRepo.FindByID(campaign.FirmwareID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if firmware == nil {
+		return nil, nil, errors.New("firmware not found")
+	}
+	return campaign, firmware, nil
+}
+
+// candidates 依目標模式列出候選設備與其目前版本
+func (s *FirmwareApplicationService) candidates(campaign *entities.Campaign) ([]*services.TargetCandidate, error) {
+	devices, err := s.deviceRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := s.inventoryByDeviceID()
+	if err != nil {
+		return nil, err
+	}
+	busyIDs, err := s.campaignRepo.FindBusyDeviceIDs()
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[uint]bool, len(busyIDs))
+	for _, id := range busyIDs {
+		busy[id] = true
+	}
+
+	var wanted map[uint]bool
+	if campaign.TargetMode == entities.TargetModeDevices {
+		wanted = make(map[uint]bool, len(campaign.DeviceIDs))
+		for _, id := range campaign.DeviceIDs {
+			wanted[id] = true
+		}
+	}
+
+	candidates := make([]*services.TargetCandidate, 0, len(devices))
+	for _, device := range devices {
+		if wanted != nil && !wanted[device.ID] {
+			continue
+		}
+		delete(wanted, device.ID)
+		candidate := &services.TargetCandidate{DeviceID: device.ID, DeviceSN: device.SN, Busy: busy[device.ID]}
+		if reported, ok := inventory[device.ID]; ok {
+			candidate.FirmwareVersion = reported.FirmwareVersion
+			candidate.HardwareVersion = reported.HardwareVersion
+		}
+		candidates = append(candidates, candidate)
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("device %d not found", id)
+	}
+	return candidates, nil
+}
+
+func (s *FirmwareApplicationService) inventoryByDeviceID() (map[uint]*entities.DeviceFirmware, error) {
+	inventory, err := s.firmwareRepo.FindInventory()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]*entities.DeviceFirmware, len(inventory))
+	for _, item := range inventory {
+		result[item.DeviceID] = item
+	}
+	return result, nil
+}
+
+func (s *FirmwareApplicationService) newDetailResponse(campaign *entities.Campaign, firmware *entities.Firmware, devices []*entities.CampaignDevice) *dto.CampaignDetailResponse {
+	detail := &dto.CampaignDetailResponse{
+		CampaignResponse: dto.NewCampaignResponse(campaign, firmware.Version, s.firmwareService.Summarize(campaign, devices)),
+		Devices:          make([]*dto.CampaignDeviceResponse, len(devices)),
+	}
+	for i, device := range devices {
+		detail.Devices[i] = dto.NewCampaignDeviceResponse(device)
+	}
+	return detail
+}
@@ -1,16 +1,15 @@
 package services
 
 import (
-	"crypto/rand"
 	"ems_backend/internal/application/dto"
+	authServices "ems_backend/internal/domain/auth/services"
 	memberEntities "ems_backend/internal/domain/member/entities"
 	memberRepo "ems_backend/internal/domain/member/repositories"
 	memberValueObjects "ems_backend/internal/domain/member/value_objects"
-	memberHistoryEntities "ems_backend/internal/domain/member_history/entities"
 	memberHistoryRepo "ems_backend/internal/domain/member_history/repositories"
 	memberRoleRepo "ems_backend/internal/domain/member_role/repositories"
 	roleService "ems_backend/internal/domain/role/services"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -20,6 +19,10 @@ type MemberApplicationService struct {
 	memberRoleRepo    memberRoleRepo.MemberRoleRepository
 	memberHistoryRepo memberHistoryRepo.MemberHistoryRepository
 	roleService       *roleService.RoleService
+	authService       *authServices.AuthService
+	mfaService        *authServices.MFAService
+	sessionService    *authServices.SessionService
+	passwordPolicy    *authServices.PasswordPolicyService
 }
 
 func NewMemberApplicationService(
@@ -28,14 +31,42 @@ func NewMemberApplicationService(
 	memberHistoryRepo memberHistoryRepo.MemberHistoryRepository,
 	roleService *roleService.RoleService,
 ) *MemberApplicationService {
+	// 預設政策只檢查長度與常見密碼，SetPasswordPolicy 可換成完整政策
+	passwordPolicy, _ := authServices.NewPasswordPolicyService(memberHistoryRepo, authServices.PasswordPolicyConfig{})
 	return &MemberApplicationService{
 		memberRepo:        memberRepo,
 		memberRoleRepo:    memberRoleRepo,
 		memberHistoryRepo: memberHistoryRepo,
 		roleService:       roleService,
+		passwordPolicy:    passwordPolicy,
 	}
 }
 
+// SetAuthService 設定認證服務 (管理員解除登入鎖定)
+func (s *MemberApplicationService) SetAuthService(authService *authServices.AuthService) {
+	s.authService = authService
+}
+
+// SetMFAService 設定多因素驗證服務 (管理員重設 MFA)
+func (s *MemberApplicationService) SetMFAService(mfaService *authServices.MFAService) {
+	s.mfaService = mfaService
+}
+
+// SetSessionService 設定會話服務 (停用成員時撤銷其所有會話)
+func (s *MemberApplicationService) SetSessionService(sessionService *authServices.SessionService) {
+	s.sessionService = sessionService
+}
+
+// SetPasswordPolicy 設定密碼政策 (建立成員與管理員變更密碼)
+func (s *MemberApplicationService) SetPasswordPolicy(passwordPolicy *authServices.PasswordPolicyService) {
+	s.passwordPolicy = passwordPolicy
+}
+
+// IsPasswordPolicyError 密碼不符合政策 (應回應 400)
+func IsPasswordPolicyError(err error) bool {
+	return errors.Is(err, authServices.ErrPasswordPolicy)
+}
+
 // GetAll 獲取所有成員
 func (s *MemberApplicationService) GetAll() (*dto.APIResponse, error) {
 	members, err := s.memberRepo.FindAll()
@@ -67,6 +98,7 @@ func (s *MemberApplicationService) GetAll() (*dto.APIResponse, error) {
 			IsEnable: member.IsEnable,
 			Roles:    roles,
 		}
+		memberDTO.Locked, memberDTO.LockedUntil = lockStatus(member)
 		memberDTOs = append(memberDTOs, memberDTO)
 	}
 
@@ -104,6 +136,7 @@ func (s *MemberApplicationService) GetByID(id uint) (*dto.APIResponse, error) {
 		IsEnable: member.IsEnable,
 		Roles:    roles,
 	}
+	memberDTO.Locked, memberDTO.LockedUntil = lockStatus(member)
 
 	return &dto.APIResponse{
 		Success: true,
@@ -123,12 +156,70 @@ func (s *MemberApplicationService) UpdateStatus(id uint, isEnable bool, modifyID
 		return nil, fmt.Errorf("failed to update member status: %w", err)
 	}
 
+	// 停用後已登入的會話立即失效
+	if !isEnable && s.sessionService != nil {
+		if _, err := s.sessionService.RevokeAll(id, ""); err != nil {
+			return nil, fmt.Errorf("failed to revoke member sessions: %w", err)
+		}
+	}
+
 	return &dto.APIResponse{
 		Success: true,
 		Data:    "Member status updated successfully",
 	}, nil
 }
 
+// Unlock 解除成員因連續登入失敗造成的鎖定
+func (s *MemberApplicationService) Unlock(id uint) (*dto.APIResponse, error) {
+	if s.authService == nil {
+		return nil, fmt.Errorf("account unlock is not configured")
+	}
+
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", id))
+	if err != nil {
+		return nil, fmt.Errorf("member not found: %w", err)
+	}
+	wasLocked, lockedUntil := lockStatus(member)
+
+	if _, err := s.authService.UnlockAccount(id); err != nil {
+		return nil, fmt.Errorf("failed to unlock member: %w", err)
+	}
+
+	return &dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message":      "Member unlocked successfully",
+			"was_locked":   wasLocked,
+			"locked_until": lockedUntil,
+		},
+	}, nil
+}
+
+// ResetMFA 清除成員的 MFA 設定 (遺失驗證器時)，角色要求 MFA 時下次登入需重新設定
+func (s *MemberApplicationService) ResetMFA(id uint) (*dto.APIResponse, error) {
+	if s.mfaService == nil {
+		return nil, fmt.Errorf("MFA is not configured")
+	}
+	if _, err := s.memberRepo.FindByID(fmt.Sprintf("%d", id)); err != nil {
+		return nil, fmt.Errorf("member not found: %w", err)
+	}
+	if err := s.mfaService.Reset(id); err != nil {
+		return nil, fmt.Errorf("failed to reset MFA: %w", err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"message": "MFA reset successfully"},
+	}, nil
+}
+
+// lockStatus 返回目前是否鎖定與鎖定期限
+func lockStatus(member *memberEntities.Member) (bool, *time.Time) {
+	if !member.IsLocked(time.Now()) {
+		return false, nil
+	}
+	return true, member.LockedUntil
+}
+
 // Create 創建成員
 func (s *MemberApplicationService) Create(req *dto.MemberCreateRequest, createID uint) (*dto.APIResponse, error) {
 	// 1. 檢查郵箱是否已存在
@@ -137,7 +228,12 @@ func (s *MemberApplicationService) Create(req *dto.MemberCreateRequest, createID
 		return nil, fmt.Errorf("email already exists")
 	}
 
-	// 2. 創建成員實體
+	// 2. 檢查密碼政策 (新成員沒有歷史密碼)
+	if err := s.passwordPolicy.Validate(0, req.Password, req.Name, req.Email); err != nil {
+		return nil, err
+	}
+
+	// 3. 創建成員實體
 	memberID, err := memberValueObjects.NewMemberID(0) // 新成員 ID 為 0，由資料庫自動生成
 	if err != nil {
 		return nil, fmt.Errorf("failed to create member ID: %w", err)
@@ -162,30 +258,16 @@ func (s *MemberApplicationService) Create(req *dto.MemberCreateRequest, createID
 		UpdatedAt: time.Now(),
 	}
 
-	// 3. 保存成員
+	// 4. 保存成員
 	if err := s.memberRepo.Save(member); err != nil {
 		return nil, fmt.Errorf("failed to save member: %w", err)
 	}
 
-	// 4. 生成鹽值和密碼哈希
-	salt, err := s.generateSalt()
+	// 5. 以密碼政策雜湊並保存密碼歷史
+	memberHistory, err := s.passwordPolicy.NewHistory(member.ID, req.Password, createID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate salt: %w", err)
+		return nil, err
 	}
-
-	memberHistory := &memberHistoryEntities.MemberHistory{}
-	hash := memberHistory.HashPassword(req.Password, salt)
-
-	// 5. 保存密碼歷史
-	memberHistory.MemberID = member.ID
-	memberHistory.Salt = salt
-	memberHistory.Hash = hash
-	memberHistory.ErrorCount = 0
-	memberHistory.CreateID = createID
-	memberHistory.CreateTime = time.Now()
-	memberHistory.ModifyID = createID
-	memberHistory.ModifyTime = time.Now()
-
 	if err := s.memberHistoryRepo.Save(memberHistory); err != nil {
 		return nil, fmt.Errorf("failed to save member history: %w", err)
 	}
@@ -237,27 +319,10 @@ func (s *MemberApplicationService) Update(id uint, req *dto.MemberUpdateRequest,
 		return nil, fmt.Errorf("failed to update member: %w", err)
 	}
 
-	// 4. 如果提供了新密碼，則更新密碼
+	// 4. 如果提供了新密碼，則更新密碼 (不可與最近使用過的密碼相同)
 	if req.Password != nil && *req.Password != "" {
-		salt, err := s.generateSalt()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate salt: %w", err)
-		}
-
-		memberHistory := &memberHistoryEntities.MemberHistory{}
-		hash := memberHistory.HashPassword(*req.Password, salt)
-
-		memberHistory.MemberID = member.ID
-		memberHistory.Salt = salt
-		memberHistory.Hash = hash
-		memberHistory.ErrorCount = 0
-		memberHistory.CreateID = modifyID
-		memberHistory.CreateTime = time.Now()
-		memberHistory.ModifyID = modifyID
-		memberHistory.ModifyTime = time.Now()
-
-		if err := s.memberHistoryRepo.Save(memberHistory); err != nil {
-			return nil, fmt.Errorf("failed to save member history: %w", err)
+		if err := s.passwordPolicy.SetPassword(member.ID, *req.Password, modifyID, req.Name, req.Email); err != nil {
+			return nil, err
 		}
 	}
 
@@ -299,12 +364,3 @@ func (s *MemberApplicationService) Update(id uint, req *dto.MemberUpdateRequest,
 	// 6. 返回更新後的成員
 	return s.GetByID(id)
 }
-
-// generateSalt 生成隨機鹽值
-func (s *MemberApplicationService) generateSalt() (string, error) {
-	salt := make([]byte, 16)
-	if _, err := rand.Read(salt); err != nil {
-		return "", err
-	}
-	return base64.StdEncoding.EncodeToString(salt), nil
-}
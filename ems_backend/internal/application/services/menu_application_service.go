@@ -35,6 +35,23 @@ func (s *MenuApplicationService) GetAll() ([]*dto.MenuResponse, error) {
 	return menuResponses, nil
 }
 
+func (s *MenuApplicationService) GetByID(id uint) (*dto.MenuResponse, error) {
+	menu, err := s.menuService.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MenuResponse{
+		ID:       menu.ID,
+		Title:    menu.Title,
+		Icon:     menu.Icon,
+		Url:      menu.Url,
+		Parent:   menu.Parent,
+		Sort:     menu.Sort,
+		IsEnable: menu.IsEnable,
+		IsShow:   menu.IsShow,
+	}, nil
+}
+
 func (s *MenuApplicationService) Create(menu *dto.MenuRequest, memberID uint) (*dto.APIResponse, error) {
 	var parent uint
 	if menu.Parent != 0 {
@@ -24,11 +24,13 @@ func (s *RoleApplicationService) GetAll() ([]*dto.RoleResponse, error) {
 	roleResponses := make([]*dto.RoleResponse, len(roles))
 	for i, role := range roles {
 		roleResponses[i] = &dto.RoleResponse{
-			ID:          role.ID,
-			Title:       role.Title,
-			Description: role.Description,
-			Sort:        role.Sort,
-			IsEnable:    role.IsEnable,
+			ID:           role.ID,
+			Title:        role.Title,
+			Description:  role.Description,
+			Sort:         role.Sort,
+			IsEnable:     role.IsEnable,
+			MFARequired:  role.MFARequired,
+			CompanyScope: string(role.CompanyScope),
 		}
 	}
 	return roleResponses, nil
@@ -42,21 +44,25 @@ func (s *RoleApplicationService) GetByID(id uint) (*dto.RoleResponse, error) {
 	}
 
 	return &dto.RoleResponse{
-		ID:          role.ID,
-		Title:       role.Title,
-		Description: role.Description,
-		Sort:        role.Sort,
-		IsEnable:    role.IsEnable,
+		ID:           role.ID,
+		Title:        role.Title,
+		Description:  role.Description,
+		Sort:         role.Sort,
+		IsEnable:     role.IsEnable,
+		MFARequired:  role.MFARequired,
+		CompanyScope: string(role.CompanyScope),
 	}, nil
 }
 
 // Create 創建角色
 func (s *RoleApplicationService) Create(req *dto.RoleRequest, memberID uint) (*dto.APIResponse, error) {
 	err := s.roleService.Create(&entities.Role{
-		Title:       req.Title,
-		Description: req.Description,
-		Sort:        req.Sort,
-		IsEnable:    req.IsEnable,
+		Title:        req.Title,
+		Description:  req.Description,
+		Sort:         req.Sort,
+		IsEnable:     req.IsEnable,
+		MFARequired:  req.MFARequired,
+		CompanyScope: entities.CompanyScope(req.CompanyScope),
 	}, memberID)
 
 	if err != nil {
@@ -68,11 +74,13 @@ func (s *RoleApplicationService) Create(req *dto.RoleRequest, memberID uint) (*d
 // Update 更新角色
 func (s *RoleApplicationService) Update(req *dto.RoleRequest, memberID uint) (*dto.APIResponse, error) {
 	err := s.roleService.Update(&entities.Role{
-		ID:          req.ID,
-		Title:       req.Title,
-		Description: req.Description,
-		Sort:        req.Sort,
-		IsEnable:    req.IsEnable,
+		ID:           req.ID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Sort:         req.Sort,
+		IsEnable:     req.IsEnable,
+		MFARequired:  req.MFARequired,
+		CompanyScope: entities.CompanyScope(req.CompanyScope),
 	}, memberID)
 
 	if err != nil {
@@ -139,6 +147,19 @@ func (s *RoleApplicationService) GetRoleMembers(roleID uint) (*dto.RoleMembersRe
 	}, nil
 }
 
+// GetRolePowerIDs 獲取角色擁有的權限ID
+func (s *RoleApplicationService) GetRolePowerIDs(roleID uint) (*dto.RolePowersResponse, error) {
+	powerIDs, err := s.roleService.GetRolePowers(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RolePowersResponse{
+		RoleID:   roleID,
+		PowerIDs: powerIDs,
+	}, nil
+}
+
 // GetRolePowers 獲取角色擁有的所有權限（完整對象）
 func (s *RoleApplicationService) GetRolePowers(roleID uint) ([]*dto.PowerResponse, error) {
 	// 獲取角色的權限ID列表
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
+	"sort"
 	"time"
 
 	"ems_backend/internal/application/dto"
@@ -12,6 +14,7 @@ import (
 	deviceRepos "ems_backend/internal/domain/device/repositories"
 	"ems_backend/internal/domain/schedule/entities"
 	"ems_backend/internal/domain/schedule/repositories"
+	scheduleServices "ems_backend/internal/domain/schedule/services"
 	"ems_backend/internal/infrastructure/mqtt"
 
 	"github.com/google/uuid"
@@ -21,8 +24,11 @@ import (
 type ScheduleApplicationService struct {
 	scheduleRepo      repositories.ScheduleRepository
 	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
-	deviceRepo        deviceRepos.DeviceRepository // For getting device SN
-	mqttPublisher     *mqtt.SchedulePublisher      // Optional: for syncing to devices
+	deviceRepo        deviceRepos.DeviceRepository         // For getting device SN
+	mqttPublisher     *mqtt.SchedulePublisher              // Optional: for syncing to devices
+	driftRepo         repositories.ScheduleDriftRepository // Optional: for drift detection
+	driftService      *scheduleServices.ScheduleDriftService
+	versionRepo       repositories.ScheduleVersionRepository // Optional: for version history
 }
 
 // NewScheduleApplicationService - 創建排程應用服務
@@ -33,6 +39,7 @@ func NewScheduleApplicationService(
 	return &ScheduleApplicationService{
 		scheduleRepo:      scheduleRepo,
 		companyDeviceRepo: companyDeviceRepo,
+		driftService:      scheduleServices.NewScheduleDriftService(),
 	}
 }
 
@@ -46,13 +53,31 @@ func (s *ScheduleApplicationService) SetMQTTPublisher(publisher *mqtt.SchedulePu
 	s.mqttPublisher = publisher
 }
 
+// SetDriftRepository - 設置排程漂移倉儲 (可選，啟用設備排程比對)
+func (s *ScheduleApplicationService) SetDriftRepository(driftRepo repositories.ScheduleDriftRepository) {
+	s.driftRepo = driftRepo
+}
+
+// SetVersionRepository - 設置排程版本倉儲 (可選，保存每次修改的歷史版本)
+func (s *ScheduleApplicationService) SetVersionRepository(versionRepo repositories.ScheduleVersionRepository) {
+	s.versionRepo = versionRepo
+}
+
 // GetByCompanyDeviceID - 獲取設備排程
 func (s *ScheduleApplicationService) GetByCompanyDeviceID(companyDeviceID uint) (*dto.ScheduleResponse, error) {
 	fullSchedule, err := s.scheduleRepo.FindFullSchedule(companyDeviceID)
 	if err != nil {
 		return nil, err
 	}
-	return dto.ToScheduleResponse(fullSchedule), nil
+	resp := dto.ToScheduleResponse(fullSchedule)
+
+	// 附上尚未解決的漂移
+	if s.driftRepo != nil && resp != nil {
+		if drift, err := s.driftRepo.FindOpenByCompanyDeviceID(companyDeviceID); err == nil && drift != nil {
+			resp.Drift = dto.ToScheduleDriftResponse(drift)
+		}
+	}
+	return resp, nil
 }
 
 // Create - 創建排程
@@ -77,12 +102,12 @@ func (s *ScheduleApplicationService) Create(req *dto.ScheduleRequest, memberID u
 	fullSchedule := dto.RequestToFullSchedule(req, scheduleID, memberID)
 
 	// Save full schedule
-	if err := s.scheduleRepo.SaveFullSchedule(fullSchedule); err != nil {
+	if err := s.saveFullSchedule(fullSchedule, entities.VersionSourceCreate, 0); err != nil {
 		return nil, err
 	}
 
 	// 發送 schedule 命令到設備
-	if err := s.SyncToDevice(req.CompanyDeviceID); err != nil {
+	if _, err := s.SyncToDevice(req.CompanyDeviceID); err != nil {
 		log.Printf("[Schedule] Warning: failed to sync to device after create: %v", err)
 		// 不返回錯誤，因為已經保存到 DB
 	}
@@ -105,6 +130,11 @@ func (s *ScheduleApplicationService) Update(companyDeviceID uint, req *dto.Sched
 		return nil, errors.New("schedule not found")
 	}
 
+	// 手動審核中的漂移需先解決，避免覆蓋設備端的變更
+	if existing.SyncStatus == entities.SyncStatusDrift {
+		return nil, errors.New("schedule has unresolved drift, resolve it before updating")
+	}
+
 	// Convert request to full schedule
 	fullSchedule := dto.RequestToFullSchedule(req, existing.ScheduleID, memberID)
 	fullSchedule.Schedule.ID = existing.ID
@@ -114,12 +144,12 @@ func (s *ScheduleApplicationService) Update(companyDeviceID uint, req *dto.Sched
 	fullSchedule.Schedule.ModifiedAt = time.Now()
 
 	// Save full schedule
-	if err := s.scheduleRepo.SaveFullSchedule(fullSchedule); err != nil {
+	if err := s.saveFullSchedule(fullSchedule, entities.VersionSourceUpdate, 0); err != nil {
 		return nil, err
 	}
 
 	// 發送 schedule 命令到設備
-	if err := s.SyncToDevice(companyDeviceID); err != nil {
+	if _, err := s.SyncToDevice(companyDeviceID); err != nil {
 		log.Printf("[Schedule] Warning: failed to sync to device after update: %v", err)
 		// 不返回錯誤，因為已經保存到 DB
 	}
@@ -174,30 +204,13 @@ func (s *ScheduleApplicationService) MarkFailed(companyDeviceID uint) error {
 }
 
 // SyncToDevice - 同步排程到設備 (via MQTT)
-func (s *ScheduleApplicationService) SyncToDevice(companyDeviceID uint) error {
-	// Get the company device
-	companyDevice, err := s.companyDeviceRepo.FindByID(companyDeviceID)
-	if err != nil {
-		return errors.New("company device not found")
-	}
-
-	// Get device serial number from Device table
-	if s.deviceRepo == nil {
-		return errors.New("device repository not configured")
-	}
-	device, err := s.deviceRepo.FindByID(companyDevice.DeviceID)
+// 返回已發送 (或發送失敗) 的命令摘要，找不到設備時為 nil
+func (s *ScheduleApplicationService) SyncToDevice(companyDeviceID uint) (*dto.DeviceCommandSummary, error) {
+	companyDevice, summary, err := s.commandTarget(companyDeviceID, "schedule")
 	if err != nil {
-		return errors.New("device not found")
-	}
-	deviceSN := device.SN
-	if deviceSN == "" {
-		return errors.New("device serial number is not set")
-	}
-
-	// Check if MQTT publisher is available
-	if s.mqttPublisher == nil {
-		return errors.New("MQTT publisher not configured")
+		return summary, err
 	}
+	deviceSN := summary.DeviceSN
 
 	// Get the full schedule (optional - may not exist yet)
 	fullSchedule, err := s.scheduleRepo.FindFullSchedule(companyDeviceID)
@@ -209,21 +222,23 @@ func (s *ScheduleApplicationService) SyncToDevice(companyDeviceID uint) error {
 			Data:       make(map[string]*mqtt.DailyRule),
 			Exceptions: []string{},
 		}
+		summary.Payload = scheduleCommandSummary(emptyCmd, 0)
 		if err := s.mqttPublisher.PublishSchedule(deviceSN, emptyCmd); err != nil {
-			return err
+			return summary, err
 		}
 		log.Printf("[Schedule] Successfully synced empty schedule to device %s", deviceSN)
-		return nil
+		return summary, nil
 	}
 
 	// Convert to MQTT command format
 	mqttCmd := s.buildMQTTCommand(fullSchedule)
+	summary.Payload = scheduleCommandSummary(mqttCmd, fullSchedule.Schedule.Version)
 
 	// Publish to device
 	if err := s.mqttPublisher.PublishSchedule(deviceSN, mqttCmd); err != nil {
 		// Mark as failed
 		s.scheduleRepo.UpdateSyncStatus(fullSchedule.Schedule.ID, entities.SyncStatusFailed)
-		return err
+		return summary, err
 	}
 
 	// Mark as synced
@@ -233,77 +248,481 @@ func (s *ScheduleApplicationService) SyncToDevice(companyDeviceID uint) error {
 	s.syncScheduleToDeviceContent(companyDevice, fullSchedule)
 
 	log.Printf("[Schedule] Successfully synced schedule to device %s", deviceSN)
-	return nil
+	return summary, nil
 }
 
 // QueryDeviceInfo - 查詢設備資訊 (via MQTT)
-func (s *ScheduleApplicationService) QueryDeviceInfo(companyDeviceID uint) error {
+func (s *ScheduleApplicationService) QueryDeviceInfo(companyDeviceID uint) (*dto.DeviceCommandSummary, error) {
+	_, summary, err := s.commandTarget(companyDeviceID, "deviceInfo")
+	if err != nil {
+		return summary, err
+	}
+
+	// Send deviceInfo command
+	if err := s.mqttPublisher.PublishDeviceInfoRequest(summary.DeviceSN); err != nil {
+		return summary, err
+	}
+
+	log.Printf("[Schedule] Sent deviceInfo request to device %s", summary.DeviceSN)
+	return summary, nil
+}
+
+// QueryScheduleFromDevice - 從設備獲取排程 (via MQTT getSchedule command)
+func (s *ScheduleApplicationService) QueryScheduleFromDevice(companyDeviceID uint) (*dto.DeviceCommandSummary, error) {
+	_, summary, err := s.commandTarget(companyDeviceID, "getSchedule")
+	if err != nil {
+		return summary, err
+	}
+
+	// Send getSchedule command
+	if err := s.mqttPublisher.PublishGetScheduleRequest(summary.DeviceSN); err != nil {
+		return summary, err
+	}
+
+	log.Printf("[Schedule] Sent getSchedule request to device %s", summary.DeviceSN)
+	return summary, nil
+}
+
+// commandTarget - 找出命令的目標設備與 SN
+// 找到設備後即返回摘要 (即使之後的檢查失敗)，讓審計日誌能記錄目標設備
+func (s *ScheduleApplicationService) commandTarget(companyDeviceID uint, command string) (*companyDeviceEntities.CompanyDevice, *dto.DeviceCommandSummary, error) {
 	// Get the company device
 	companyDevice, err := s.companyDeviceRepo.FindByID(companyDeviceID)
 	if err != nil {
-		return errors.New("company device not found")
+		return nil, nil, errors.New("company device not found")
 	}
 
 	// Get device serial number from Device table
 	if s.deviceRepo == nil {
-		return errors.New("device repository not configured")
+		return nil, nil, errors.New("device repository not configured")
 	}
 	device, err := s.deviceRepo.FindByID(companyDevice.DeviceID)
 	if err != nil {
-		return errors.New("device not found")
+		return nil, nil, errors.New("device not found")
+	}
+	summary := &dto.DeviceCommandSummary{
+		CompanyDeviceID: companyDeviceID,
+		DeviceID:        device.ID,
+		DeviceSN:        device.SN,
+		Command:         command,
 	}
-	deviceSN := device.SN
-	if deviceSN == "" {
-		return errors.New("device serial number is not set")
+	if device.SN == "" {
+		return nil, summary, errors.New("device serial number is not set")
 	}
 
 	// Check if MQTT publisher is available
 	if s.mqttPublisher == nil {
-		return errors.New("MQTT publisher not configured")
+		return nil, summary, errors.New("MQTT publisher not configured")
 	}
 
-	// Send deviceInfo command
-	if err := s.mqttPublisher.PublishDeviceInfoRequest(deviceSN); err != nil {
+	return companyDevice, summary, nil
+}
+
+// scheduleCommandSummary - 排程命令摘要：版本、設定的日數、動作數與例外日
+func scheduleCommandSummary(cmd *mqtt.ScheduleCommand, version int) map[string]interface{} {
+	days := make([]string, 0, len(cmd.Data))
+	actions := 0
+	for day, rule := range cmd.Data {
+		days = append(days, day)
+		actions += len(rule.Actions)
+	}
+	sort.Strings(days)
+
+	return map[string]interface{}{
+		"version":    version,
+		"days":       days,
+		"actions":    actions,
+		"exceptions": len(cmd.Exceptions),
+	}
+}
+
+// ============================================
+// Schedule drift
+// ============================================
+
+// ReconcileDeviceSchedule - 比對設備回報的排程與雲端排程，並依公司策略處理
+// (implements mqtt.ScheduleReconciler)
+func (s *ScheduleApplicationService) ReconcileDeviceSchedule(companyDeviceID uint, deviceSchedule *entities.ScheduleWithRules) error {
+	cloudSchedule, err := s.scheduleRepo.FindFullSchedule(companyDeviceID)
+	if err != nil || cloudSchedule == nil || cloudSchedule.Schedule == nil {
+		// 雲端尚無排程，直接採用設備排程
+		deviceSchedule.Schedule.CompanyDeviceID = companyDeviceID
+		deviceSchedule.Schedule.Version = 1
+		deviceSchedule.Schedule.MarkSynced()
+		log.Printf("[Schedule] No cloud schedule for company device %d, adopting device schedule", companyDeviceID)
+		return s.saveFullSchedule(deviceSchedule, entities.VersionSourceDevice, 0)
+	}
+
+	deviceSnapshot := deviceSchedule.Snapshot()
+	differences := s.driftService.Compare(cloudSchedule.Snapshot(), deviceSnapshot)
+
+	if len(differences) == 0 {
+		// 一致：標記已同步，未解決的漂移以 converged 關閉 (不歸屬任何成員)
+		if err := s.scheduleRepo.UpdateSyncStatus(cloudSchedule.Schedule.ID, entities.SyncStatusSynced); err != nil {
+			return err
+		}
+		if s.driftRepo != nil {
+			if drift, _ := s.driftRepo.FindOpenByCompanyDeviceID(companyDeviceID); drift != nil {
+				drift.Resolve(entities.DriftResolutionConverged, 0)
+				return s.driftRepo.Update(drift)
+			}
+		}
+		return nil
+	}
+
+	if s.driftRepo == nil {
+		// 未啟用漂移偵測，維持原本行為：設備排程覆蓋雲端
+		return s.adoptDeviceSnapshot(cloudSchedule.Schedule, deviceSnapshot, 0)
+	}
+
+	log.Printf("[Schedule] Drift detected for company device %d: %d difference(s)", companyDeviceID, len(differences))
+
+	// 更新或建立漂移紀錄
+	drift, err := s.driftRepo.FindOpenByCompanyDeviceID(companyDeviceID)
+	if err != nil {
 		return err
 	}
+	if drift != nil {
+		drift.ScheduleID = cloudSchedule.Schedule.ID
+		drift.CloudVersion = cloudSchedule.Schedule.Version
+		drift.Differences = differences
+		drift.DeviceSnapshot = deviceSnapshot
+		drift.DetectedAt = time.Now()
+		if err := s.driftRepo.Update(drift); err != nil {
+			return err
+		}
+	} else {
+		drift = entities.NewScheduleDrift(companyDeviceID, cloudSchedule.Schedule, differences, deviceSnapshot)
+		if err := s.driftRepo.Save(drift); err != nil {
+			return err
+		}
+	}
 
-	log.Printf("[Schedule] Sent deviceInfo request to device %s", deviceSN)
-	return nil
+	policy, err := s.getDriftPolicyForCompanyDevice(companyDeviceID)
+	if err != nil {
+		return err
+	}
+	if policy == entities.DriftPolicyManual {
+		return s.scheduleRepo.UpdateSyncStatus(cloudSchedule.Schedule.ID, entities.SyncStatusDrift)
+	}
+	return s.applyDriftResolution(drift, policy, 0)
 }
 
-// QueryScheduleFromDevice - 從設備獲取排程 (via MQTT getSchedule command)
-func (s *ScheduleApplicationService) QueryScheduleFromDevice(companyDeviceID uint) error {
-	// Get the company device
+// GetDrifts - 獲取設備排程漂移紀錄 (最新在前)
+func (s *ScheduleApplicationService) GetDrifts(companyDeviceID uint, limit int) ([]*dto.ScheduleDriftResponse, error) {
+	if s.driftRepo == nil {
+		return nil, errors.New("schedule drift detection not configured")
+	}
+	drifts, err := s.driftRepo.FindByCompanyDeviceID(companyDeviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.ScheduleDriftResponse, 0, len(drifts))
+	for _, drift := range drifts {
+		result = append(result, dto.ToScheduleDriftResponse(drift))
+	}
+	return result, nil
+}
+
+// ResolveDrift - 手動解決設備排程漂移
+func (s *ScheduleApplicationService) ResolveDrift(companyDeviceID uint, resolution string, memberID uint) (*dto.ScheduleResponse, error) {
+	if s.driftRepo == nil {
+		return nil, errors.New("schedule drift detection not configured")
+	}
+	if !entities.IsValidDriftResolution(resolution) {
+		return nil, errors.New("invalid resolution, must be cloud_wins or device_wins")
+	}
+
+	drift, err := s.driftRepo.FindOpenByCompanyDeviceID(companyDeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if drift == nil {
+		return nil, errors.New("no unresolved drift for this device")
+	}
+
+	if err := s.applyDriftResolution(drift, resolution, memberID); err != nil {
+		return nil, err
+	}
+	return s.GetByCompanyDeviceID(companyDeviceID)
+}
+
+// GetDriftPolicy - 獲取公司排程漂移策略
+func (s *ScheduleApplicationService) GetDriftPolicy(companyID uint) (*dto.ScheduleDriftPolicyResponse, error) {
+	if s.driftRepo == nil {
+		return nil, errors.New("schedule drift detection not configured")
+	}
+	policy, err := s.driftRepo.FindPolicyByCompanyID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return &dto.ScheduleDriftPolicyResponse{CompanyID: companyID, Policy: entities.DriftPolicyManual}, nil
+	}
+	return dto.ToScheduleDriftPolicyResponse(policy), nil
+}
+
+// SetDriftPolicy - 設定公司排程漂移策略
+func (s *ScheduleApplicationService) SetDriftPolicy(companyID uint, policy string, memberID uint) (*dto.ScheduleDriftPolicyResponse, error) {
+	if s.driftRepo == nil {
+		return nil, errors.New("schedule drift detection not configured")
+	}
+	if !entities.IsValidDriftPolicy(policy) {
+		return nil, errors.New("invalid policy, must be cloud_wins, device_wins or manual")
+	}
+
+	entity := &entities.ScheduleDriftPolicy{
+		CompanyID:  companyID,
+		Policy:     policy,
+		ModifyID:   memberID,
+		ModifyTime: time.Now(),
+	}
+	if err := s.driftRepo.SavePolicy(entity); err != nil {
+		return nil, err
+	}
+	return dto.ToScheduleDriftPolicyResponse(entity), nil
+}
+
+// StartDriftPolling - 定期向所有已排程設備發送 getSchedule 以偵測漂移
+func (s *ScheduleApplicationService) StartDriftPolling(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Schedule] Drift polling started (interval: %s)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Schedule] Drift polling stopped")
+			return
+		case <-ticker.C:
+			schedules, err := s.scheduleRepo.FindAll()
+			if err != nil {
+				log.Printf("[Schedule] Drift polling failed to load schedules: %v", err)
+				continue
+			}
+			for _, schedule := range schedules {
+				if _, err := s.QueryScheduleFromDevice(schedule.CompanyDeviceID); err != nil {
+					log.Printf("[Schedule] Drift polling failed for company device %d: %v", schedule.CompanyDeviceID, err)
+				}
+			}
+		}
+	}
+}
+
+// applyDriftResolution - 依解決方式處理漂移並關閉紀錄
+func (s *ScheduleApplicationService) applyDriftResolution(drift *entities.ScheduleDrift, resolution string, memberID uint) error {
+	switch resolution {
+	case entities.DriftPolicyCloudWins:
+		// 重新下發雲端排程覆蓋設備
+		if _, err := s.SyncToDevice(drift.CompanyDeviceID); err != nil {
+			return err
+		}
+	case entities.DriftPolicyDeviceWins:
+		// 以設備排程作為新的雲端版本
+		if drift.DeviceSnapshot == nil {
+			return errors.New("drift has no device snapshot")
+		}
+		existing, err := s.scheduleRepo.FindByCompanyDeviceID(drift.CompanyDeviceID)
+		if err != nil {
+			return errors.New("schedule not found")
+		}
+		if err := s.adoptDeviceSnapshot(existing, drift.DeviceSnapshot, memberID); err != nil {
+			return err
+		}
+		if companyDevice, err := s.companyDeviceRepo.FindByID(drift.CompanyDeviceID); err == nil && companyDevice != nil {
+			if fullSchedule, err := s.scheduleRepo.FindFullSchedule(drift.CompanyDeviceID); err == nil {
+				s.syncScheduleToDeviceContent(companyDevice, fullSchedule)
+			}
+		}
+	default:
+		return errors.New("invalid resolution")
+	}
+
+	drift.Resolve(resolution, memberID)
+	log.Printf("[Schedule] Drift %d for company device %d resolved: %s", drift.ID, drift.CompanyDeviceID, resolution)
+	return s.driftRepo.Update(drift)
+}
+
+// adoptDeviceSnapshot - 以設備排程快照覆蓋雲端排程 (版本遞增)
+func (s *ScheduleApplicationService) adoptDeviceSnapshot(existing *entities.Schedule, snapshot *entities.ScheduleSnapshot, memberID uint) error {
+	base := *existing
+	base.Version = existing.Version + 1
+	base.ModifiedBy = memberID
+	base.ModifiedAt = time.Now()
+	base.MarkSynced()
+
+	return s.saveFullSchedule(snapshot.ToScheduleWithRules(&base), entities.VersionSourceDevice, 0)
+}
+
+// getDriftPolicyForCompanyDevice - 取得設備所屬公司的漂移策略 (未設定時為 manual)
+func (s *ScheduleApplicationService) getDriftPolicyForCompanyDevice(companyDeviceID uint) (string, error) {
 	companyDevice, err := s.companyDeviceRepo.FindByID(companyDeviceID)
+	if err != nil || companyDevice == nil {
+		return "", errors.New("company device not found")
+	}
+	policy, err := s.driftRepo.FindPolicyByCompanyID(companyDevice.CompanyID)
 	if err != nil {
-		return errors.New("company device not found")
+		return "", err
+	}
+	if policy == nil {
+		return entities.DriftPolicyManual, nil
 	}
+	return policy.Policy, nil
+}
 
-	// Get device serial number from Device table
-	if s.deviceRepo == nil {
-		return errors.New("device repository not configured")
+// ============================================
+// Version history
+// ============================================
+
+// GetVersions - 獲取排程歷史版本 (最新在前)
+func (s *ScheduleApplicationService) GetVersions(companyDeviceID uint) ([]*dto.ScheduleVersionResponse, error) {
+	if s.versionRepo == nil {
+		return nil, errors.New("schedule version history not configured")
 	}
-	device, err := s.deviceRepo.FindByID(companyDevice.DeviceID)
+	schedule, err := s.scheduleRepo.FindByCompanyDeviceID(companyDeviceID)
+	if err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	if err := s.ensureBaselineVersion(companyDeviceID); err != nil {
+		log.Printf("[Schedule] Warning: failed to record baseline version: %v", err)
+	}
+
+	versions, err := s.versionRepo.FindByScheduleID(schedule.ID)
 	if err != nil {
-		return errors.New("device not found")
+		return nil, err
 	}
-	deviceSN := device.SN
-	if deviceSN == "" {
-		return errors.New("device serial number is not set")
+
+	result := make([]*dto.ScheduleVersionResponse, 0, len(versions))
+	for _, version := range versions {
+		resp := dto.ToScheduleVersionResponse(version)
+		resp.Rules = nil // 列表不返回完整規則
+		result = append(result, resp)
 	}
+	return result, nil
+}
 
-	// Check if MQTT publisher is available
-	if s.mqttPublisher == nil {
-		return errors.New("MQTT publisher not configured")
+// GetVersion - 獲取單一歷史版本 (含完整規則)
+func (s *ScheduleApplicationService) GetVersion(companyDeviceID uint, versionNumber int) (*dto.ScheduleVersionResponse, error) {
+	version, err := s.findVersion(companyDeviceID, versionNumber)
+	if err != nil {
+		return nil, err
 	}
+	return dto.ToScheduleVersionResponse(version), nil
+}
 
-	// Send getSchedule command
-	if err := s.mqttPublisher.PublishGetScheduleRequest(deviceSN); err != nil {
+// DiffVersions - 比對兩個歷史版本
+func (s *ScheduleApplicationService) DiffVersions(companyDeviceID uint, fromVersion, toVersion int) (*dto.ScheduleVersionDiffResponse, error) {
+	from, err := s.findVersion(companyDeviceID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.findVersion(companyDeviceID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	differences := s.driftService.Compare(from.Rules, to.Rules)
+	return dto.ToScheduleVersionDiffResponse(from, to, differences), nil
+}
+
+// RestoreVersion - 還原至指定版本 (建立新版本，可選擇同步到設備)
+func (s *ScheduleApplicationService) RestoreVersion(companyDeviceID uint, versionNumber int, sync bool, memberID uint) (*dto.ScheduleResponse, error) {
+	version, err := s.findVersion(companyDeviceID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.scheduleRepo.FindByCompanyDeviceID(companyDeviceID)
+	if err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	if existing.SyncStatus == entities.SyncStatusDrift {
+		return nil, errors.New("schedule has unresolved drift, resolve it before restoring")
+	}
+
+	base := *existing
+	base.Version = existing.Version + 1
+	base.SyncStatus = entities.SyncStatusPending
+	base.ModifiedBy = memberID
+	base.ModifiedAt = time.Now()
+
+	fullSchedule := version.Rules.ToScheduleWithRules(&base)
+	if err := s.saveFullSchedule(fullSchedule, entities.VersionSourceRollback, version.Version); err != nil {
+		return nil, err
+	}
+	log.Printf("[Schedule] Company device %d restored to version %d as version %d", companyDeviceID, version.Version, base.Version)
+
+	if sync {
+		if _, err := s.SyncToDevice(companyDeviceID); err != nil {
+			log.Printf("[Schedule] Warning: failed to sync to device after restore: %v", err)
+		}
+	}
+
+	return s.GetByCompanyDeviceID(companyDeviceID)
+}
+
+// findVersion - 取得目前排程的指定版本
+func (s *ScheduleApplicationService) findVersion(companyDeviceID uint, versionNumber int) (*entities.ScheduleVersion, error) {
+	if s.versionRepo == nil {
+		return nil, errors.New("schedule version history not configured")
+	}
+	schedule, err := s.scheduleRepo.FindByCompanyDeviceID(companyDeviceID)
+	if err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	if err := s.ensureBaselineVersion(companyDeviceID); err != nil {
+		log.Printf("[Schedule] Warning: failed to record baseline version: %v", err)
+	}
+	version, err := s.versionRepo.FindByVersion(schedule.ID, versionNumber)
+	if err != nil {
+		return nil, errors.New("schedule version not found")
+	}
+	return version, nil
+}
+
+// saveFullSchedule - 保存完整排程並記錄歷史版本
+func (s *ScheduleApplicationService) saveFullSchedule(fullSchedule *entities.ScheduleWithRules, source string, restoredFrom int) error {
+	// 覆蓋前先保留尚未記錄的目前版本
+	if fullSchedule.Schedule.ID != 0 {
+		if err := s.ensureBaselineVersion(fullSchedule.Schedule.CompanyDeviceID); err != nil {
+			log.Printf("[Schedule] Warning: failed to record baseline version: %v", err)
+		}
+	}
+
+	if s.versionRepo == nil {
+		return s.scheduleRepo.SaveFullSchedule(fullSchedule)
+	}
+
+	// 歷史版本與排程在同一交易中寫入，版本寫入失敗時排程也不會更新
+	return s.scheduleRepo.SaveFullScheduleWithVersion(fullSchedule, func(saved *entities.ScheduleWithRules) *entities.ScheduleVersion {
+		version := entities.NewScheduleVersion(saved, source, saved.Schedule.ModifiedBy)
+		version.RestoredFrom = restoredFrom
+		return version
+	})
+}
+
+// ensureBaselineVersion - 為尚無歷史紀錄的目前排程建立基準版本
+func (s *ScheduleApplicationService) ensureBaselineVersion(companyDeviceID uint) error {
+	if s.versionRepo == nil {
+		return nil
+	}
+	current, err := s.scheduleRepo.FindFullSchedule(companyDeviceID)
+	if err != nil || current == nil || current.Schedule == nil {
+		return nil
+	}
+	exists, err := s.versionRepo.ExistsVersion(current.Schedule.ID, current.Schedule.Version)
+	if err != nil || exists {
 		return err
 	}
 
-	log.Printf("[Schedule] Sent getSchedule request to device %s", deviceSN)
-	return nil
+	version := entities.NewScheduleVersion(current, entities.VersionSourceBaseline, current.Schedule.ModifiedBy)
+	version.CreatedAt = current.Schedule.ModifiedAt
+	return s.versionRepo.Save(version)
 }
 
 // buildMQTTCommand - 構建 MQTT 命令
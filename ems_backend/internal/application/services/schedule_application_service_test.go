@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/schedule/entities"
+	"ems_backend/internal/domain/schedule/repositories"
+)
+
+// MockScheduleRepository - 只實作版本歷史用到的方法，其餘方法呼叫時 panic
+type MockScheduleRepository struct {
+	repositories.ScheduleRepository
+	current  *entities.ScheduleWithRules
+	saved    []*entities.ScheduleWithRules
+	versions *MockScheduleVersionRepository
+}
+
+func (m *MockScheduleRepository) FindByCompanyDeviceID(companyDeviceID uint) (*entities.Schedule, error) {
+	if m.current == nil || m.current.Schedule.CompanyDeviceID != companyDeviceID {
+		return nil, errors.New("record not found")
+	}
+	schedule := *m.current.Schedule
+	return &schedule, nil
+}
+
+func (m *MockScheduleRepository) FindFullSchedule(companyDeviceID uint) (*entities.ScheduleWithRules, error) {
+	if m.current == nil || m.current.Schedule.CompanyDeviceID != companyDeviceID {
+		return nil, errors.New("record not found")
+	}
+	return m.current, nil
+}
+
+func (m *MockScheduleRepository) SaveFullSchedule(schedule *entities.ScheduleWithRules) error {
+	m.saved = append(m.saved, schedule)
+	m.current = schedule
+	return nil
+}
+
+// SaveFullScheduleWithVersion - 版本寫入失敗時不保存排程，模擬交易回滾
+func (m *MockScheduleRepository) SaveFullScheduleWithVersion(schedule *entities.ScheduleWithRules, newVersion func(*entities.ScheduleWithRules) *entities.ScheduleVersion) error {
+	if err := m.versions.Save(newVersion(schedule)); err != nil {
+		return err
+	}
+	return m.SaveFullSchedule(schedule)
+}
+
+func (m *MockScheduleRepository) UpdateSyncStatus(id uint, status string) error {
+	if m.current == nil || m.current.Schedule.ID != id {
+		return errors.New("record not found")
+	}
+	m.current.Schedule.SyncStatus = status
+	return nil
+}
+
+// MockScheduleDriftRepository - 只實作漂移比對用到的方法
+type MockScheduleDriftRepository struct {
+	repositories.ScheduleDriftRepository
+	open    *entities.ScheduleDrift
+	updated []*entities.ScheduleDrift
+}
+
+func (m *MockScheduleDriftRepository) FindOpenByCompanyDeviceID(companyDeviceID uint) (*entities.ScheduleDrift, error) {
+	if m.open == nil || m.open.CompanyDeviceID != companyDeviceID || !m.open.IsOpen() {
+		return nil, nil
+	}
+	return m.open, nil
+}
+
+func (m *MockScheduleDriftRepository) Update(drift *entities.ScheduleDrift) error {
+	m.updated = append(m.updated, drift)
+	return nil
+}
+
+// MockScheduleVersionRepository - 記憶體中的版本歷史
+type MockScheduleVersionRepository struct {
+	versions []*entities.ScheduleVersion
+	saveErr  error
+}
+
+func (m *MockScheduleVersionRepository) Save(version *entities.ScheduleVersion) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.versions = append(m.versions, version)
+	return nil
+}
+
+func (m *MockScheduleVersionRepository) FindByScheduleID(scheduleID uint) ([]*entities.ScheduleVersion, error) {
+	var result []*entities.ScheduleVersion
+	for i := len(m.versions) - 1; i >= 0; i-- {
+		if m.versions[i].ScheduleID == scheduleID {
+			result = append(result, m.versions[i])
+		}
+	}
+	return result, nil
+}
+
+func (m *MockScheduleVersionRepository) FindByVersion(scheduleID uint, version int) (*entities.ScheduleVersion, error) {
+	for _, v := range m.versions {
+		if v.ScheduleID == scheduleID && v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *MockScheduleVersionRepository) ExistsVersion(scheduleID uint, version int) (bool, error) {
+	_, err := m.FindByVersion(scheduleID, version)
+	return err == nil, nil
+}
+
+const testCompanyDeviceID = 5
+
+func mondaySnapshot(start, end string) *entities.ScheduleSnapshot {
+	return &entities.ScheduleSnapshot{
+		Command: "schedule",
+		DailyRules: map[string]*entities.RuleSnapshot{
+			"Monday": {
+				RunPeriod: &entities.PeriodSnapshot{Start: start, End: end},
+				Actions:   []entities.ActionSnapshot{{Type: entities.ActionTypeCloseOnce, Time: "12:00"}},
+			},
+		},
+		Exceptions: []string{},
+	}
+}
+
+// newVersionTestService 建立目前版本為 currentVersion 的排程，並記錄 history 中的歷史版本
+func newVersionTestService(currentVersion int, syncStatus string, history map[int]*entities.ScheduleSnapshot) (*ScheduleApplicationService, *MockScheduleRepository, *MockScheduleVersionRepository) {
+	schedule := &entities.Schedule{
+		ID:              10,
+		CompanyDeviceID: testCompanyDeviceID,
+		ScheduleID:      "uuid",
+		Command:         "schedule",
+		Version:         currentVersion,
+		SyncStatus:      syncStatus,
+		ModifiedBy:      1,
+		ModifiedAt:      time.Now(),
+	}
+	versionRepo := &MockScheduleVersionRepository{}
+	scheduleRepo := &MockScheduleRepository{current: mondaySnapshot("08:00", "18:00").ToScheduleWithRules(schedule), versions: versionRepo}
+	for version, snapshot := range history {
+		versionRepo.versions = append(versionRepo.versions, &entities.ScheduleVersion{
+			ScheduleID:      schedule.ID,
+			CompanyDeviceID: testCompanyDeviceID,
+			Version:         version,
+			Source:          entities.VersionSourceUpdate,
+			Rules:           snapshot,
+		})
+	}
+
+	service := NewScheduleApplicationService(scheduleRepo, nil)
+	service.SetVersionRepository(versionRepo)
+	return service, scheduleRepo, versionRepo
+}
+
+func TestScheduleApplicationService_DiffVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  int
+		wantErr   bool
+		wantDiffs int
+	}{
+		{name: "時段改變", from: 1, to: 2, wantDiffs: 1},
+		{name: "相同版本沒有差異", from: 2, to: 2, wantDiffs: 0},
+		{name: "來源版本不存在", from: 9, to: 2, wantErr: true},
+		{name: "目標版本不存在", from: 1, to: 9, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newVersionTestService(2, entities.SyncStatusSynced, map[int]*entities.ScheduleSnapshot{
+				1: mondaySnapshot("08:00", "18:00"),
+				2: mondaySnapshot("09:00", "18:00"),
+			})
+
+			diff, err := service.DiffVersions(testCompanyDeviceID, tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望錯誤，但沒有返回錯誤")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if diff.FromVersion != tt.from || diff.ToVersion != tt.to {
+				t.Errorf("版本號錯誤: 期望 %d→%d，得到 %d→%d", tt.from, tt.to, diff.FromVersion, diff.ToVersion)
+			}
+			if len(diff.Differences) != tt.wantDiffs {
+				t.Fatalf("期望 %d 個差異，得到 %d: %+v", tt.wantDiffs, len(diff.Differences), diff.Differences)
+			}
+			if tt.wantDiffs == 1 {
+				d := diff.Differences[0]
+				if d.Day != "Monday" || d.Field != entities.DiffFieldRunPeriod || d.From != "08:00-18:00" || d.To != "09:00-18:00" {
+					t.Errorf("差異內容錯誤: %+v", d)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleApplicationService_RestoreVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		syncStatus string
+		restore    int
+		versionErr error
+		wantErr    bool
+	}{
+		{name: "還原為新版本", syncStatus: entities.SyncStatusSynced, restore: 1},
+		{name: "漂移未解決時拒絕還原", syncStatus: entities.SyncStatusDrift, restore: 1, wantErr: true},
+		{name: "版本不存在", syncStatus: entities.SyncStatusSynced, restore: 9, wantErr: true},
+		{name: "版本寫入失敗時不更新排程", syncStatus: entities.SyncStatusSynced, restore: 1, versionErr: errors.New("db down"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, scheduleRepo, versionRepo := newVersionTestService(3, tt.syncStatus, map[int]*entities.ScheduleSnapshot{
+				1: mondaySnapshot("07:00", "17:00"),
+				3: mondaySnapshot("08:00", "18:00"),
+			})
+			versionsBefore := len(versionRepo.versions)
+			versionRepo.saveErr = tt.versionErr
+
+			resp, err := service.RestoreVersion(testCompanyDeviceID, tt.restore, false, 2)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望錯誤，但沒有返回錯誤")
+				}
+				if len(scheduleRepo.saved) != 0 || len(versionRepo.versions) != versionsBefore {
+					t.Error("失敗時不應保存排程或新增版本")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+
+			if resp.Version != 4 {
+				t.Errorf("期望新版本號 4，得到 %d", resp.Version)
+			}
+			if resp.SyncStatus != entities.SyncStatusPending {
+				t.Errorf("還原後應為 pending，得到 %s", resp.SyncStatus)
+			}
+
+			latest := versionRepo.versions[len(versionRepo.versions)-1]
+			if latest.Version != 4 || latest.Source != entities.VersionSourceRollback || latest.RestoredFrom != 1 || latest.CreatedBy != 2 {
+				t.Errorf("版本紀錄錯誤: version=%d source=%s restored_from=%d created_by=%d",
+					latest.Version, latest.Source, latest.RestoredFrom, latest.CreatedBy)
+			}
+			if got := scheduleRepo.current.DailyRules["Monday"].RunPeriod; got.Start != "07:00" || got.End != "17:00" {
+				t.Errorf("排程應還原為版本 1 的時段，得到 %s-%s", got.Start, got.End)
+			}
+		})
+	}
+}
+
+func TestScheduleApplicationService_EnsureBaselineVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		history   map[int]*entities.ScheduleSnapshot
+		deviceID  uint
+		wantSaved int
+	}{
+		{name: "尚無歷史時建立基準版本", history: nil, deviceID: testCompanyDeviceID, wantSaved: 1},
+		{name: "目前版本已有紀錄", history: map[int]*entities.ScheduleSnapshot{2: mondaySnapshot("08:00", "18:00")}, deviceID: testCompanyDeviceID, wantSaved: 0},
+		{name: "沒有排程時略過", history: nil, deviceID: 99, wantSaved: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, versionRepo := newVersionTestService(2, entities.SyncStatusSynced, tt.history)
+			before := len(versionRepo.versions)
+
+			// 重複呼叫只會建立一次
+			for i := 0; i < 2; i++ {
+				if err := service.ensureBaselineVersion(tt.deviceID); err != nil {
+					t.Fatalf("不期望錯誤: %v", err)
+				}
+			}
+
+			if saved := len(versionRepo.versions) - before; saved != tt.wantSaved {
+				t.Fatalf("期望新增 %d 個版本，得到 %d", tt.wantSaved, saved)
+			}
+			if tt.wantSaved == 1 {
+				baseline := versionRepo.versions[len(versionRepo.versions)-1]
+				if baseline.Version != 2 || baseline.Source != entities.VersionSourceBaseline {
+					t.Errorf("基準版本錯誤: version=%d source=%s", baseline.Version, baseline.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleApplicationService_ReconcileConverged(t *testing.T) {
+	service, scheduleRepo, _ := newVersionTestService(2, entities.SyncStatusDrift, nil)
+	driftRepo := &MockScheduleDriftRepository{
+		open: entities.NewScheduleDrift(testCompanyDeviceID, scheduleRepo.current.Schedule, nil, nil),
+	}
+	service.SetDriftRepository(driftRepo)
+
+	// 設備回報的排程與雲端一致
+	device := mondaySnapshot("08:00", "18:00").ToScheduleWithRules(&entities.Schedule{})
+	if err := service.ReconcileDeviceSchedule(testCompanyDeviceID, device); err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+
+	if got := scheduleRepo.current.Schedule.SyncStatus; got != entities.SyncStatusSynced {
+		t.Errorf("期望同步狀態 synced，得到 %s", got)
+	}
+	if len(driftRepo.updated) != 1 {
+		t.Fatalf("期望更新 1 筆漂移紀錄，得到 %d", len(driftRepo.updated))
+	}
+	drift := driftRepo.updated[0]
+	if drift.IsOpen() || drift.Resolution != entities.DriftResolutionConverged || drift.ResolvedBy != 0 {
+		t.Errorf("漂移應以 converged 關閉且不歸屬成員: status=%s resolution=%s resolved_by=%d",
+			drift.Status, drift.Resolution, drift.ResolvedBy)
+	}
+	if len(scheduleRepo.saved) != 0 {
+		t.Error("一致時不應覆蓋雲端排程")
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	authEntities "ems_backend/internal/domain/auth/entities"
+	authServices "ems_backend/internal/domain/auth/services"
+	companyRepositories "ems_backend/internal/domain/company/repositories"
+)
+
+// ServiceAccountApplicationService 服務帳號與 API Key 管理
+type ServiceAccountApplicationService struct {
+	apiKeyService *authServices.APIKeyService
+	companyRepo   companyRepositories.CompanyRepository
+}
+
+func NewServiceAccountApplicationService(
+	apiKeyService *authServices.APIKeyService,
+	companyRepo companyRepositories.CompanyRepository,
+) *ServiceAccountApplicationService {
+	return &ServiceAccountApplicationService{
+		apiKeyService: apiKeyService,
+		companyRepo:   companyRepo,
+	}
+}
+
+// GetAll 列出所有服務帳號
+func (s *ServiceAccountApplicationService) GetAll() (*dto.APIResponse, error) {
+	accounts, err := s.apiKeyService.ListServiceAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service accounts: %w", err)
+	}
+
+	accountDTOs := make([]dto.ServiceAccountDTO, len(accounts))
+	for i, account := range accounts {
+		accountDTOs[i] = toServiceAccountDTO(account)
+	}
+	return &dto.APIResponse{Success: true, Data: accountDTOs}, nil
+}
+
+// Create 建立服務帳號
+func (s *ServiceAccountApplicationService) Create(req *dto.ServiceAccountCreateRequest, createID uint) (*dto.APIResponse, error) {
+	if req.CompanyID != nil {
+		if company, err := s.companyRepo.FindByID(*req.CompanyID); err != nil || company == nil {
+			return serviceAccountErrorResponse(fmt.Errorf("%w: company not found", authServices.ErrInvalidAPIKeyRequest))
+		}
+	}
+
+	account, err := s.apiKeyService.CreateServiceAccount(req.Name, req.Description, req.CompanyID, createID)
+	if err != nil {
+		return serviceAccountErrorResponse(err)
+	}
+	return &dto.APIResponse{Success: true, Data: toServiceAccountDTO(account)}, nil
+}
+
+// Disable 停用服務帳號並撤銷其所有 API Key
+func (s *ServiceAccountApplicationService) Disable(id, modifyID uint) (*dto.APIResponse, error) {
+	revoked, err := s.apiKeyService.DisableServiceAccount(id, modifyID)
+	if err != nil {
+		return serviceAccountErrorResponse(err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message":      "Service account disabled successfully",
+			"revoked_keys": revoked,
+		},
+	}, nil
+}
+
+// GetKeys 列出服務帳號的 API Key
+func (s *ServiceAccountApplicationService) GetKeys(id uint) (*dto.APIResponse, error) {
+	keys, err := s.apiKeyService.ListKeys(id)
+	if err != nil {
+		return serviceAccountErrorResponse(err)
+	}
+
+	now := time.Now()
+	keyDTOs := make([]dto.APIKeyDTO, len(keys))
+	for i, key := range keys {
+		keyDTOs[i] = toAPIKeyDTO(key, now)
+	}
+	return &dto.APIResponse{Success: true, Data: keyDTOs}, nil
+}
+
+// CreateKey 建立 API Key，回應中的明文只會返回這一次；createRoleID 為建立者目前使用的角色
+func (s *ServiceAccountApplicationService) CreateKey(id uint, req *dto.APIKeyCreateRequest, createID, createRoleID uint) (*dto.APIResponse, error) {
+	key, plaintext, err := s.apiKeyService.CreateKey(id, req.RoleID, req.Name, req.ExpiresAt, createID, createRoleID)
+	if err != nil {
+		return serviceAccountErrorResponse(err)
+	}
+	return &dto.APIResponse{
+		Success: true,
+		Data: dto.APIKeyCreatedResponse{
+			APIKeyDTO: toAPIKeyDTO(key, time.Now()),
+			Key:       plaintext,
+		},
+	}, nil
+}
+
+// RevokeKey 撤銷 API Key
+func (s *ServiceAccountApplicationService) RevokeKey(id, keyID uint) (*dto.APIResponse, error) {
+	key, err := s.apiKeyService.RevokeKey(id, keyID)
+	if err != nil {
+		return serviceAccountErrorResponse(err)
+	}
+	return &dto.APIResponse{Success: true, Data: toAPIKeyDTO(key, time.Now())}, nil
+}
+
+// IsServiceAccountNotFound 服務帳號或 API Key 不存在
+func IsServiceAccountNotFound(response *dto.APIResponse) bool {
+	return response.Error == authEntities.ErrServiceAccountNotFound.Error() ||
+		response.Error == authEntities.ErrAPIKeyNotFound.Error()
+}
+
+// serviceAccountErrorResponse 將參數錯誤轉為失敗回應，其餘錯誤交由 handler 返回 500
+func serviceAccountErrorResponse(err error) (*dto.APIResponse, error) {
+	for _, target := range []error{
+		authServices.ErrInvalidAPIKeyRequest,
+		authEntities.ErrServiceAccountNotFound,
+		authEntities.ErrServiceAccountDisabled,
+		authEntities.ErrAPIKeyNotFound,
+		authEntities.ErrAPIKeyRoleNotAllowed,
+		authEntities.ErrAPIKeyRoleExceedsCaller,
+	} {
+		if errors.Is(err, target) {
+			return &dto.APIResponse{Success: false, Error: err.Error()}, nil
+		}
+	}
+	return nil, err
+}
+
+func toServiceAccountDTO(account *authEntities.ServiceAccount) dto.ServiceAccountDTO {
+	return dto.ServiceAccountDTO{
+		ID:          account.MemberID,
+		Name:        account.Name,
+		Email:       account.Email,
+		Description: account.Description,
+		CompanyID:   account.CompanyID,
+		IsEnable:    account.IsEnable,
+		CreatedBy:   account.CreatedBy,
+		CreateTime:  account.CreateTime,
+	}
+}
+
+func toAPIKeyDTO(key *authEntities.APIKey, now time.Time) dto.APIKeyDTO {
+	return dto.APIKeyDTO{
+		ID:               key.ID,
+		ServiceAccountID: key.ServiceAccountID,
+		RoleID:           key.RoleID,
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		ExpiresAt:        key.ExpiresAt,
+		LastUsedAt:       key.LastUsedAt,
+		LastUsedIP:       key.LastUsedIP,
+		RevokedAt:        key.RevokedAt,
+		Active:           key.IsActive(now),
+		CreatedBy:        key.CreatedBy,
+		CreateTime:       key.CreateTime,
+	}
+}
@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"time"
+)
+
+// chainTimeLayout - 雜湊使用的時間格式 (資料庫 timestamp 不含時區，精度為微秒)
+const chainTimeLayout = "2006-01-02T15:04:05.000000"
+
+// 雜湊鏈驗證發現的問題種類
+const (
+	ChainIssueGap         = "gap"          // 序號不連續，有記錄被刪除
+	ChainIssueBrokenLink  = "broken_link"  // prev_hash 與前一筆的雜湊不符
+	ChainIssueHashInvalid = "hash_invalid" // 內容與雜湊不符，記錄被修改
+	ChainIssueTruncated   = "truncated"    // 資料表的鏈尾早於庫外記錄的鏈尾，尾端記錄被刪除
+	ChainIssueHeadChanged = "head_changed" // 庫外記錄的鏈尾與資料表中同序號記錄的雜湊不符
+)
+
+// ComputeHash - 以序號、前一筆雜湊與記錄內容計算 HMAC-SHA256
+// key 保存在資料庫以外，只能寫入資料庫的人無法為修改後的記錄算出有效的雜湊；key 為空時退回 SHA-256 (僅供開發環境)
+// Details 需為 JSON 正規化後的值 (與從資料庫讀回的相同)，否則驗證時會不一致
+func (l *AuditLog) ComputeHash(key []byte) string {
+	payload, _ := json.Marshal(struct {
+		Sequence     uint64                 `json:"sequence"`
+		PrevHash     string                 `json:"prev_hash"`
+		MemberID     uint                   `json:"member_id"`
+		RoleID       uint                   `json:"role_id"`
+		Action       string                 `json:"action"`
+		ResourceType string                 `json:"resource_type"`
+		ResourceID   *uint                  `json:"resource_id"`
+		Details      map[string]interface{} `json:"details"`
+		IPAddress    string                 `json:"ip_address"`
+		UserAgent    string                 `json:"user_agent"`
+		Status       string                 `json:"status"`
+		ErrorMessage string                 `json:"error_message"`
+		CreateTime   string                 `json:"create_time"`
+	}{
+		Sequence:     l.Sequence,
+		PrevHash:     l.PrevHash,
+		MemberID:     l.MemberID,
+		RoleID:       l.RoleID,
+		Action:       l.Action,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		Details:      l.Details,
+		IPAddress:    l.IPAddress,
+		UserAgent:    l.UserAgent,
+		Status:       l.Status,
+		ErrorMessage: l.ErrorMessage,
+		CreateTime:   l.CreateTime.Format(chainTimeLayout),
+	})
+	var mac hash.Hash
+	if len(key) == 0 {
+		mac = sha256.New()
+	} else {
+		mac = hmac.New(sha256.New, key)
+	}
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ChainHead - 記錄在資料庫以外的鏈尾，用於偵測尾端記錄被刪除 (雜湊鏈本身無法偵測)
+type ChainHead struct {
+	Sequence   uint64    `json:"sequence"`
+	Hash       string    `json:"hash"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// FieldChange - 欄位異動前後的值
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ChainIssue - 雜湊鏈上的一個問題
+type ChainIssue struct {
+	Kind     string `json:"kind"`
+	Sequence uint64 `json:"sequence"`         // 發生問題的記錄；gap、truncated 時為缺少的第一個序號
+	LogID    uint   `json:"log_id,omitempty"` // gap 時為缺口之後的記錄
+	Missing  uint64 `json:"missing,omitempty"`
+}
+
+// ChainVerification - 雜湊鏈驗證結果
+type ChainVerification struct {
+	Valid         bool         `json:"valid"`
+	Checked       int          `json:"checked"`
+	FirstSequence uint64       `json:"first_sequence"` // 大於 1 時表示更早的記錄已不在表中，從此筆開始驗證
+	LastSequence  uint64       `json:"last_sequence"`
+	Issues        []ChainIssue `json:"issues"`
+	Truncated     bool         `json:"truncated"` // 問題過多，只返回前面的部分
+}
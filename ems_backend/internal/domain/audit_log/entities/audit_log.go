@@ -16,6 +16,11 @@ type AuditLog struct {
 	Status       string                 `json:"status"`        // SUCCESS 或 FAILURE
 	ErrorMessage string                 `json:"error_message"` // 如果失敗，記錄錯誤信息
 	CreateTime   time.Time              `json:"create_time"`
+
+	// 雜湊鏈：每筆記錄包含前一筆的雜湊，修改或刪除任何一筆都會在驗證時被發現
+	Sequence uint64 `json:"sequence"`  // 鏈上的序號，連續遞增 (舊資料為 0，不在鏈上)
+	PrevHash string `json:"prev_hash"` // 前一筆的雜湊，第一筆為空字串
+	Hash     string `json:"hash"`      // 本筆內容與 PrevHash 的 SHA-256 (hex)
 }
 
 // AuditLogFilter - 審計日誌查詢過濾器
@@ -3,8 +3,15 @@ package repositories
 import "ems_backend/internal/domain/audit_log/entities"
 
 type AuditLogRepository interface {
-	// Create 創建審計日誌
-	Create(log *entities.AuditLog) error
+	// Append 鎖定雜湊鏈尾後寫入審計日誌
+	// 寫入前以鏈上最後一筆 (沒有時為 nil) 呼叫 seal 設定序號與雜湊，同一時間只有一筆能寫入
+	Append(log *entities.AuditLog, seal func(last *entities.AuditLog)) error
+
+	// ListChain 依序號遞增返回序號大於 afterSequence 的記錄 (只含鏈上的記錄)
+	ListChain(afterSequence uint64, limit int) ([]*entities.AuditLog, error)
+
+	// LastInChain 返回鏈上序號最大的記錄，鏈為空時返回 nil
+	LastInChain() (*entities.AuditLog, error)
 
 	// GetByID 根據ID獲取審計日誌
 	GetByID(id uint) (*entities.AuditLog, error)
@@ -24,6 +31,12 @@ type AuditLogRepository interface {
 	// Count 計算符合條件的審計日誌總數
 	Count(filter *entities.AuditLogFilter) (int64, error)
 
+	// ListAfterID 依 ID 遞增返回符合條件且 ID 大於 afterID 的記錄 (忽略 filter 的分頁參數)
+	ListAfterID(filter *entities.AuditLogFilter, afterID uint, limit int) ([]*entities.AuditLog, error)
+
+	// DeleteByIDs 刪除指定的審計日誌，返回刪除的筆數
+	DeleteByIDs(ids []uint) (int64, error)
+
 	// DeleteOlderThan 刪除早於指定時間的審計日誌（用於日誌清理）
 	DeleteOlderThan(days int) error
 }
@@ -1,26 +1,64 @@
 package services
 
 import (
+	"bytes"
 	"ems_backend/internal/domain/audit_log/entities"
 	"ems_backend/internal/domain/audit_log/repositories"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"time"
 )
 
+// 雜湊鏈驗證與匯出每次讀取的筆數、驗證最多返回的問題數
+const (
+	chainVerifyBatchSize = 500
+	chainVerifyMaxIssues = 100
+	exportBatchSize      = 500
+)
+
+// chainHeadName 庫外鏈尾紀錄的檔名
+const chainHeadName = "audit-chain-head.json"
+
+// ChainHeadStore 保存鏈尾紀錄的位置，需與資料庫分開 (例如審計日誌歸檔儲存)
+type ChainHeadStore interface {
+	// Put 寫入檔案，同名檔案已存在時覆蓋
+	Put(name string, content io.Reader) error
+
+	// Get 讀取檔案，不存在時返回 fs.ErrNotExist
+	Get(name string) (io.ReadCloser, error)
+}
+
 type AuditLogService struct {
 	auditLogRepo repositories.AuditLogRepository
+	chainKey     []byte
+	headStore    ChainHeadStore
 }
 
 func NewAuditLogService(auditLogRepo repositories.AuditLogRepository) *AuditLogService {
 	return &AuditLogService{auditLogRepo: auditLogRepo}
 }
 
+// SetChainKey 設置雜湊鏈的 HMAC key (未設置時使用 SHA-256)
+// key 不可存放在資料庫中，設置後不可更換；啟用前的記錄需以 from 參數從第一筆使用 key 的序號開始驗證
+func (s *AuditLogService) SetChainKey(key []byte) {
+	s.chainKey = key
+}
+
+// SetChainHeadStore 設置庫外鏈尾紀錄的儲存 (未設置時無法偵測尾端記錄被刪除)
+func (s *AuditLogService) SetChainHeadStore(store ChainHeadStore) {
+	s.headStore = store
+}
+
 // Create 創建審計日誌
 func (s *AuditLogService) Create(log *entities.AuditLog) error {
-	// 設置創建時間
+	// 設置創建時間 (資料庫精度為微秒，先截斷才能在驗證時重現雜湊)
 	if log.CreateTime.IsZero() {
 		log.CreateTime = time.Now()
 	}
+	log.CreateTime = log.CreateTime.Truncate(time.Microsecond)
 
 	// 默認狀態為成功
 	if log.Status == "" {
@@ -32,7 +70,180 @@ func (s *AuditLogService) Create(log *entities.AuditLog) error {
 		return err
 	}
 
-	return s.auditLogRepo.Create(log)
+	details, err := normalizeDetails(log.Details)
+	if err != nil {
+		return fmt.Errorf("invalid details: %w", err)
+	}
+	log.Details = details
+
+	return s.auditLogRepo.Append(log, func(last *entities.AuditLog) {
+		log.Sequence = 1
+		log.PrevHash = ""
+		if last != nil {
+			log.Sequence = last.Sequence + 1
+			log.PrevHash = last.Hash
+		}
+		log.Hash = log.ComputeHash(s.chainKey)
+	})
+}
+
+// RecordHead 將資料表目前的鏈尾寫入庫外儲存，未設置儲存或鏈為空時返回 nil
+// 資料表的鏈尾早於上次記錄的鏈尾、或上次記錄的鏈尾已被修改時不覆蓋 (保留證據) 並返回錯誤
+func (s *AuditLogService) RecordHead() (*entities.ChainHead, error) {
+	if s.headStore == nil {
+		return nil, nil
+	}
+	previous, err := s.loadHead()
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.auditLogRepo.LastInChain()
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		if previous != nil {
+			return nil, fmt.Errorf("audit chain is empty but head was recorded at sequence %d", previous.Sequence)
+		}
+		return nil, nil
+	}
+
+	if previous != nil {
+		if last.Sequence < previous.Sequence {
+			return nil, fmt.Errorf("audit chain head moved back from sequence %d to %d", previous.Sequence, last.Sequence)
+		}
+		logs, err := s.auditLogRepo.ListChain(previous.Sequence-1, 1)
+		if err != nil {
+			return nil, err
+		}
+		// 該筆已依保留期限歸檔刪除時無從比對，交由 VerifyChain 檢查
+		if len(logs) > 0 && logs[0].Sequence == previous.Sequence && logs[0].Hash != previous.Hash {
+			return nil, fmt.Errorf("audit chain entry %d no longer matches the recorded head", previous.Sequence)
+		}
+		if last.Sequence == previous.Sequence {
+			return previous, nil
+		}
+	}
+
+	head := &entities.ChainHead{Sequence: last.Sequence, Hash: last.Hash, RecordedAt: time.Now()}
+	payload, err := json.Marshal(head)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.headStore.Put(chainHeadName, bytes.NewReader(payload)); err != nil {
+		return nil, fmt.Errorf("failed to record audit chain head: %w", err)
+	}
+	return head, nil
+}
+
+// loadHead 讀取上次記錄的鏈尾，尚未記錄時返回 nil
+func (s *AuditLogService) loadHead() (*entities.ChainHead, error) {
+	content, err := s.headStore.Get(chainHeadName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	var head entities.ChainHead
+	if err := json.NewDecoder(content).Decode(&head); err != nil {
+		return nil, fmt.Errorf("invalid audit chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// VerifyChain 驗證序號 fromSequence 起的雜湊鏈 (0 表示從頭)
+// 起點之前的記錄不在驗證範圍內，起點記錄的 prev_hash 視為可信
+// 設置庫外鏈尾紀錄時，另檢查資料表的鏈尾是否早於記錄的鏈尾 (尾端記錄被刪除)
+func (s *AuditLogService) VerifyChain(fromSequence uint64) (*entities.ChainVerification, error) {
+	var head *entities.ChainHead
+	if s.headStore != nil {
+		var err error
+		if head, err = s.loadHead(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &entities.ChainVerification{Issues: []entities.ChainIssue{}}
+	addIssue := func(issue entities.ChainIssue) {
+		if len(result.Issues) >= chainVerifyMaxIssues {
+			result.Truncated = true
+			return
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+
+	after := uint64(0)
+	if fromSequence > 0 {
+		after = fromSequence - 1
+	}
+	var prev *entities.AuditLog
+	for {
+		logs, err := s.auditLogRepo.ListChain(after, chainVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, log := range logs {
+			switch {
+			case prev == nil:
+				result.FirstSequence = log.Sequence
+				if fromSequence > 0 && log.Sequence != fromSequence {
+					addIssue(entities.ChainIssue{
+						Kind:     entities.ChainIssueGap,
+						Sequence: fromSequence,
+						LogID:    log.ID,
+						Missing:  log.Sequence - fromSequence,
+					})
+				}
+				if log.Sequence == 1 && log.PrevHash != "" {
+					addIssue(entities.ChainIssue{Kind: entities.ChainIssueBrokenLink, Sequence: log.Sequence, LogID: log.ID})
+				}
+			case log.Sequence != prev.Sequence+1:
+				addIssue(entities.ChainIssue{
+					Kind:     entities.ChainIssueGap,
+					Sequence: prev.Sequence + 1,
+					LogID:    log.ID,
+					Missing:  log.Sequence - prev.Sequence - 1,
+				})
+			case log.PrevHash != prev.Hash:
+				addIssue(entities.ChainIssue{Kind: entities.ChainIssueBrokenLink, Sequence: log.Sequence, LogID: log.ID})
+			}
+			if log.Hash != log.ComputeHash(s.chainKey) {
+				addIssue(entities.ChainIssue{Kind: entities.ChainIssueHashInvalid, Sequence: log.Sequence, LogID: log.ID})
+			}
+			if head != nil && log.Sequence == head.Sequence && log.Hash != head.Hash {
+				addIssue(entities.ChainIssue{Kind: entities.ChainIssueHeadChanged, Sequence: log.Sequence, LogID: log.ID})
+			}
+
+			result.Checked++
+			result.LastSequence = log.Sequence
+			prev = log
+		}
+
+		if len(logs) < chainVerifyBatchSize {
+			break
+		}
+		after = prev.Sequence
+	}
+
+	// 記錄的鏈尾在驗證範圍內但資料表中沒有該筆
+	if head != nil && head.Sequence >= fromSequence && head.Sequence > result.LastSequence {
+		missingFrom := result.LastSequence + 1
+		if missingFrom < fromSequence {
+			missingFrom = fromSequence
+		}
+		addIssue(entities.ChainIssue{
+			Kind:     entities.ChainIssueTruncated,
+			Sequence: missingFrom,
+			Missing:  head.Sequence - missingFrom + 1,
+		})
+	}
+
+	result.Valid = len(result.Issues) == 0
+	return result, nil
 }
 
 // GetByID 根據ID獲取審計日誌
@@ -94,6 +305,26 @@ func (s *AuditLogService) Count(filter *entities.AuditLogFilter) (int64, error)
 	return s.auditLogRepo.Count(filter)
 }
 
+// Export 依 ID 順序逐筆處理所有符合條件的審計日誌 (忽略分頁參數)，fn 返回錯誤時停止
+func (s *AuditLogService) Export(filter *entities.AuditLogFilter, fn func(log *entities.AuditLog) error) error {
+	var afterID uint
+	for {
+		logs, err := s.auditLogRepo.ListAfterID(filter, afterID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+		if len(logs) < exportBatchSize {
+			return nil
+		}
+		afterID = logs[len(logs)-1].ID
+	}
+}
+
 // DeleteOlderThan 刪除早於指定天數的審計日誌
 func (s *AuditLogService) DeleteOlderThan(days int) error {
 	if days < 0 {
@@ -2,7 +2,9 @@ package services
 
 import (
 	"ems_backend/internal/domain/audit_log/entities"
+	"encoding/json"
 	"errors"
+	"sort"
 	"testing"
 	"time"
 )
@@ -21,18 +23,57 @@ func NewMockAuditLogRepository() *MockAuditLogRepository {
 	}
 }
 
-func (m *MockAuditLogRepository) Create(log *entities.AuditLog) error {
+func (m *MockAuditLogRepository) Append(log *entities.AuditLog, seal func(last *entities.AuditLog)) error {
 	if m.createError != nil {
 		return m.createError
 	}
+	var last *entities.AuditLog
+	for _, existing := range m.logs {
+		if existing.Sequence > 0 && (last == nil || existing.Sequence > last.Sequence) {
+			last = existing
+		}
+	}
+	seal(last)
 	if log.ID == 0 {
 		log.ID = m.nextID
 		m.nextID++
 	}
-	m.logs[log.ID] = log
+
+	// 與資料庫相同，Details 以 JSON 儲存後讀回
+	stored := *log
+	if log.Details != nil {
+		data, _ := json.Marshal(log.Details)
+		stored.Details = nil
+		json.Unmarshal(data, &stored.Details)
+	}
+	m.logs[log.ID] = &stored
 	return nil
 }
 
+func (m *MockAuditLogRepository) ListChain(afterSequence uint64, limit int) ([]*entities.AuditLog, error) {
+	logs := make([]*entities.AuditLog, 0)
+	for _, log := range m.logs {
+		if log.Sequence > afterSequence {
+			logs = append(logs, log)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Sequence < logs[j].Sequence })
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (m *MockAuditLogRepository) LastInChain() (*entities.AuditLog, error) {
+	var last *entities.AuditLog
+	for _, log := range m.logs {
+		if log.Sequence > 0 && (last == nil || log.Sequence > last.Sequence) {
+			last = log
+		}
+	}
+	return last, nil
+}
+
 func (m *MockAuditLogRepository) GetByID(id uint) (*entities.AuditLog, error) {
 	if log, ok := m.logs[id]; ok {
 		return log, nil
@@ -148,6 +189,31 @@ func (m *MockAuditLogRepository) Count(filter *entities.AuditLogFilter) (int64,
 	return count, nil
 }
 
+func (m *MockAuditLogRepository) ListAfterID(filter *entities.AuditLogFilter, afterID uint, limit int) ([]*entities.AuditLog, error) {
+	logs := make([]*entities.AuditLog, 0)
+	for _, log := range m.logs {
+		if log.ID > afterID && m.matchFilter(log, filter) {
+			logs = append(logs, log)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID < logs[j].ID })
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (m *MockAuditLogRepository) DeleteByIDs(ids []uint) (int64, error) {
+	var count int64
+	for _, id := range ids {
+		if _, ok := m.logs[id]; ok {
+			delete(m.logs, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (m *MockAuditLogRepository) DeleteOlderThan(days int) error {
 	cutoff := time.Now().AddDate(0, 0, -days)
 	for id, log := range m.logs {
@@ -565,3 +631,390 @@ func TestAuditLogService_LogFailure(t *testing.T) {
 		t.Errorf("期望錯誤消息為 %q，得到 %q", errorMessage, log.ErrorMessage)
 	}
 }
+
+// testChainKey 測試用的雜湊鏈 HMAC key
+var testChainKey = []byte("test-audit-chain-key")
+
+// newChainTestService 建立使用 testChainKey 的服務
+func newChainTestService(repo *MockAuditLogRepository) *AuditLogService {
+	service := NewAuditLogService(repo)
+	service.SetChainKey(testChainKey)
+	return service
+}
+
+// appendTestLogs 依序寫入 count 筆審計日誌
+func appendTestLogs(t *testing.T, service *AuditLogService, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		resourceID := uint(i + 1)
+		details := map[string]interface{}{
+			"count":  uint(i),
+			"before": struct{ Name string }{Name: "舊名稱"},
+		}
+		if err := service.LogSuccess(1, 1, "UPDATE", "ROLE", &resourceID, details, "192.168.1.1", "Mozilla/5.0"); err != nil {
+			t.Fatalf("寫入審計日誌失敗: %v", err)
+		}
+	}
+}
+
+func TestAuditLogService_HashChain(t *testing.T) {
+	repo := NewMockAuditLogRepository()
+	service := newChainTestService(repo)
+	appendTestLogs(t, service, 3)
+
+	for id := uint(1); id <= 3; id++ {
+		log := repo.logs[id]
+		if log.Sequence != uint64(id) {
+			t.Errorf("期望序號為 %d，得到 %d", id, log.Sequence)
+		}
+		if log.Hash == "" || log.Hash != log.ComputeHash(testChainKey) {
+			t.Errorf("序號 %d 的雜湊與讀回的內容不符", log.Sequence)
+		}
+		if log.Hash == log.ComputeHash(nil) {
+			t.Errorf("設置 key 後序號 %d 的雜湊不應為 SHA-256", log.Sequence)
+		}
+	}
+	if repo.logs[1].PrevHash != "" {
+		t.Errorf("期望第一筆的 prev_hash 為空，得到 %q", repo.logs[1].PrevHash)
+	}
+	if repo.logs[2].PrevHash != repo.logs[1].Hash || repo.logs[3].PrevHash != repo.logs[2].Hash {
+		t.Error("期望每筆的 prev_hash 為前一筆的雜湊")
+	}
+}
+
+func TestAuditLogService_VerifyChain(t *testing.T) {
+	tests := []struct {
+		name         string
+		tamper       func(repo *MockAuditLogRepository)
+		fromSequence uint64
+		wantValid    bool
+		wantKind     string
+		wantSequence uint64
+		wantFirst    uint64
+		wantChecked  int
+	}{
+		{
+			name:        "完整的鏈",
+			tamper:      func(repo *MockAuditLogRepository) {},
+			wantValid:   true,
+			wantFirst:   1,
+			wantChecked: 4,
+		},
+		{
+			name: "修改記錄內容",
+			tamper: func(repo *MockAuditLogRepository) {
+				repo.logs[2].Action = "DELETE"
+			},
+			wantKind:     entities.ChainIssueHashInvalid,
+			wantSequence: 2,
+			wantFirst:    1,
+			wantChecked:  4,
+		},
+		{
+			name: "修改記錄並重新計算雜湊",
+			tamper: func(repo *MockAuditLogRepository) {
+				repo.logs[2].Action = "DELETE"
+				repo.logs[2].Hash = repo.logs[2].ComputeHash(testChainKey)
+			},
+			wantKind:     entities.ChainIssueBrokenLink,
+			wantSequence: 3,
+			wantFirst:    1,
+			wantChecked:  4,
+		},
+		{
+			name: "刪除中間的記錄",
+			tamper: func(repo *MockAuditLogRepository) {
+				delete(repo.logs, 3)
+			},
+			wantKind:     entities.ChainIssueGap,
+			wantSequence: 3,
+			wantFirst:    1,
+			wantChecked:  3,
+		},
+		{
+			name: "從指定序號開始驗證",
+			tamper: func(repo *MockAuditLogRepository) {
+				delete(repo.logs, 1)
+			},
+			fromSequence: 2,
+			wantValid:    true,
+			wantFirst:    2,
+			wantChecked:  3,
+		},
+		{
+			name: "指定序號的記錄已被刪除",
+			tamper: func(repo *MockAuditLogRepository) {
+				delete(repo.logs, 2)
+			},
+			fromSequence: 2,
+			wantKind:     entities.ChainIssueGap,
+			wantSequence: 2,
+			wantFirst:    3,
+			wantChecked:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockAuditLogRepository()
+			service := newChainTestService(repo)
+			appendTestLogs(t, service, 4)
+			tt.tamper(repo)
+
+			result, err := service.VerifyChain(tt.fromSequence)
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			if result.Valid != tt.wantValid {
+				t.Errorf("期望 valid 為 %v，得到 %v (%+v)", tt.wantValid, result.Valid, result.Issues)
+			}
+			if result.FirstSequence != tt.wantFirst {
+				t.Errorf("期望起始序號為 %d，得到 %d", tt.wantFirst, result.FirstSequence)
+			}
+			if result.Checked != tt.wantChecked {
+				t.Errorf("期望檢查 %d 筆，得到 %d", tt.wantChecked, result.Checked)
+			}
+			if tt.wantKind == "" {
+				return
+			}
+			if len(result.Issues) != 1 {
+				t.Fatalf("期望 1 個問題，得到 %+v", result.Issues)
+			}
+			if result.Issues[0].Kind != tt.wantKind || result.Issues[0].Sequence != tt.wantSequence {
+				t.Errorf("期望序號 %d 的 %s，得到 %+v", tt.wantSequence, tt.wantKind, result.Issues[0])
+			}
+		})
+	}
+}
+
+func TestAuditLogService_VerifyChain_WrongKey(t *testing.T) {
+	repo := NewMockAuditLogRepository()
+	service := newChainTestService(repo)
+	appendTestLogs(t, service, 4)
+
+	// 只能寫入資料庫的人修改記錄後，以自己的 key 重新串起整條鏈
+	repo.logs[2].Action = "DELETE"
+	prevHash := ""
+	for id := uint(1); id <= 4; id++ {
+		log := repo.logs[id]
+		log.PrevHash = prevHash
+		log.Hash = log.ComputeHash([]byte("attacker-key"))
+		prevHash = log.Hash
+	}
+
+	result, err := service.VerifyChain(0)
+	if err != nil {
+		t.Fatalf("不期望錯誤，但得到: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("以錯誤 key 重新計算的鏈不應通過驗證")
+	}
+	if len(result.Issues) != 4 {
+		t.Fatalf("期望每筆都有問題，得到 %+v", result.Issues)
+	}
+	for i, issue := range result.Issues {
+		if issue.Kind != entities.ChainIssueHashInvalid || issue.Sequence != uint64(i+1) {
+			t.Errorf("期望序號 %d 的 %s，得到 %+v", i+1, entities.ChainIssueHashInvalid, issue)
+		}
+	}
+
+	// 未使用 key 的 SHA-256 同樣無法通過
+	for id := uint(1); id <= 4; id++ {
+		repo.logs[id].Hash = repo.logs[id].ComputeHash(nil)
+	}
+	if result, _ := service.VerifyChain(0); result.Valid {
+		t.Error("以 SHA-256 重新計算的鏈不應通過驗證")
+	}
+}
+
+func TestAuditLogService_ChainHead(t *testing.T) {
+	tests := []struct {
+		name         string
+		tamper       func(repo *MockAuditLogRepository, service *AuditLogService)
+		fromSequence uint64
+		wantKind     string
+		wantSequence uint64
+		wantMissing  uint64
+	}{
+		{
+			name:   "鏈尾未變更",
+			tamper: func(repo *MockAuditLogRepository, service *AuditLogService) {},
+		},
+		{
+			name: "記錄鏈尾後繼續寫入",
+			tamper: func(repo *MockAuditLogRepository, service *AuditLogService) {
+				appendTestLogs(t, service, 2)
+			},
+		},
+		{
+			name: "刪除尾端記錄",
+			tamper: func(repo *MockAuditLogRepository, service *AuditLogService) {
+				delete(repo.logs, 3)
+				delete(repo.logs, 4)
+			},
+			wantKind:     entities.ChainIssueTruncated,
+			wantSequence: 3,
+			wantMissing:  2,
+		},
+		{
+			name: "刪除全部記錄",
+			tamper: func(repo *MockAuditLogRepository, service *AuditLogService) {
+				repo.logs = make(map[uint]*entities.AuditLog)
+			},
+			fromSequence: 2,
+			wantKind:     entities.ChainIssueTruncated,
+			wantSequence: 2,
+			wantMissing:  3,
+		},
+		{
+			name: "刪除尾端記錄後由程式寫入新記錄",
+			tamper: func(repo *MockAuditLogRepository, service *AuditLogService) {
+				delete(repo.logs, 4)
+				appendTestLogs(t, service, 1)
+			},
+			wantKind:     entities.ChainIssueHeadChanged,
+			wantSequence: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockAuditLogRepository()
+			service := newChainTestService(repo)
+			store := NewMockArchiveStore()
+			service.SetChainHeadStore(store)
+			appendTestLogs(t, service, 4)
+
+			head, err := service.RecordHead()
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			if head.Sequence != 4 || head.Hash != repo.logs[4].Hash {
+				t.Fatalf("期望記錄序號 4 的鏈尾，得到 %+v", head)
+			}
+			tt.tamper(repo, service)
+
+			result, err := service.VerifyChain(tt.fromSequence)
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			if tt.wantKind == "" {
+				if !result.Valid {
+					t.Errorf("期望通過驗證，得到 %+v", result.Issues)
+				}
+				return
+			}
+			if len(result.Issues) != 1 {
+				t.Fatalf("期望 1 個問題，得到 %+v", result.Issues)
+			}
+			issue := result.Issues[0]
+			if issue.Kind != tt.wantKind || issue.Sequence != tt.wantSequence || issue.Missing != tt.wantMissing {
+				t.Errorf("期望序號 %d 的 %s (缺少 %d 筆)，得到 %+v", tt.wantSequence, tt.wantKind, tt.wantMissing, issue)
+			}
+
+			// 資料表的鏈尾有問題時不覆蓋記錄的鏈尾
+			if _, err := service.RecordHead(); err == nil {
+				t.Error("期望拒絕更新鏈尾")
+			}
+			if result, _ := service.VerifyChain(tt.fromSequence); result.Valid {
+				t.Error("拒絕更新後仍應偵測到問題")
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	type role struct {
+		Title        string            `json:"title"`
+		IsEnable     bool              `json:"is_enable"`
+		PasswordHash string            `json:"password_hash"`
+		Meta         map[string]string `json:"meta"`
+	}
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   map[string]entities.FieldChange
+	}{
+		{
+			name:   "欄位變更",
+			before: role{Title: "管理員", IsEnable: true},
+			after:  role{Title: "系統管理員", IsEnable: true},
+			want: map[string]entities.FieldChange{
+				"title": {Before: "管理員", After: "系統管理員"},
+			},
+		},
+		{
+			name:   "巢狀欄位變更",
+			before: role{Meta: map[string]string{"color": "red", "icon": "star"}},
+			after:  role{Meta: map[string]string{"color": "blue", "icon": "star"}},
+			want: map[string]entities.FieldChange{
+				"meta.color": {Before: "red", After: "blue"},
+			},
+		},
+		{
+			name:   "敏感欄位不記錄原值",
+			before: role{Title: "管理員", PasswordHash: "old"},
+			after:  role{Title: "管理員", PasswordHash: "new"},
+			want:   map[string]entities.FieldChange{},
+		},
+		{
+			name:   "刪除",
+			before: role{Title: "管理員"},
+			after:  (*role)(nil),
+			want: map[string]entities.FieldChange{
+				"title":         {Before: "管理員", After: nil},
+				"is_enable":     {Before: false, After: nil},
+				"password_hash": {Before: redactedValue, After: nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSnapshots(Snapshot(tt.before), Snapshot(tt.after))
+			if len(got) != len(tt.want) {
+				t.Fatalf("期望 %d 個異動欄位，得到 %+v", len(tt.want), got)
+			}
+			for field, change := range tt.want {
+				if got[field] != change {
+					t.Errorf("期望 %s 異動為 %+v，得到 %+v", field, change, got[field])
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLogService_Export(t *testing.T) {
+	repo := NewMockAuditLogRepository()
+	service := NewAuditLogService(repo)
+
+	now := time.Now()
+	for id := uint(1); id <= exportBatchSize+10; id++ {
+		action := "UPDATE"
+		if id%2 == 0 {
+			action = "DELETE"
+		}
+		repo.logs[id] = &entities.AuditLog{ID: id, MemberID: 1, RoleID: 1, Action: action, ResourceType: "ROLE", Status: "SUCCESS", CreateTime: now}
+	}
+
+	var ids []uint
+	err := service.Export(&entities.AuditLogFilter{Action: "DELETE", Limit: 10}, func(log *entities.AuditLog) error {
+		ids = append(ids, log.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("不期望錯誤，但得到: %v", err)
+	}
+
+	// 跨越多批且忽略分頁參數
+	if len(ids) != (exportBatchSize+10)/2 {
+		t.Fatalf("期望匯出 %d 筆，得到 %d", (exportBatchSize+10)/2, len(ids))
+	}
+	for i, id := range ids {
+		if id != uint(i+1)*2 {
+			t.Fatalf("期望依 ID 順序匯出，第 %d 筆為 %d", i, id)
+		}
+	}
+}
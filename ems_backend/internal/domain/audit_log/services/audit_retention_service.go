@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"ems_backend/internal/domain/audit_log/entities"
+	"ems_backend/internal/domain/audit_log/repositories"
+)
+
+// DefaultAuditArchiveBatchSize 每個歸檔檔案預設的筆數
+const DefaultAuditArchiveBatchSize = 5000
+
+// ArchiveStore 審計日誌歸檔的儲存位置 (本機目錄或其他儲存)
+type ArchiveStore interface {
+	// Put 寫入歸檔檔案，同名檔案已存在時覆蓋
+	Put(name string, content io.Reader) error
+}
+
+// AuditRetentionConfig 審計日誌保留設定
+type AuditRetentionConfig struct {
+	Retention time.Duration // 超過此期限的記錄歸檔後刪除
+	BatchSize int           // 每個歸檔檔案的筆數
+}
+
+// AuditRetentionService 審計日誌保留期限處理
+// 超過期限的記錄依 ID 順序分批寫成 gzip 壓縮的 JSON Lines 歸檔，寫入成功後才從資料表刪除；
+// 歸檔包含雜湊鏈欄位，資料表中第一筆的 prev_hash 即為最後一個歸檔中最後一筆的 hash
+type AuditRetentionService struct {
+	auditLogRepo repositories.AuditLogRepository
+	store        ArchiveStore
+	config       AuditRetentionConfig
+	now          func() time.Time
+}
+
+// NewAuditRetentionService 創建審計日誌保留服務
+func NewAuditRetentionService(auditLogRepo repositories.AuditLogRepository, store ArchiveStore, config AuditRetentionConfig) (*AuditRetentionService, error) {
+	if store == nil {
+		return nil, errors.New("archive store is required")
+	}
+	if config.Retention <= 0 {
+		return nil, errors.New("retention must be positive")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultAuditArchiveBatchSize
+	}
+	return &AuditRetentionService{
+		auditLogRepo: auditLogRepo,
+		store:        store,
+		config:       config,
+		now:          time.Now,
+	}, nil
+}
+
+// Retention 保留期限
+func (s *AuditRetentionService) Retention() time.Duration {
+	return s.config.Retention
+}
+
+// Run 歸檔並刪除超過保留期限的記錄，返回刪除的筆數
+// 歸檔失敗時停止，該批記錄保留在資料表中，下次執行時重試
+func (s *AuditRetentionService) Run() (int64, error) {
+	filter := &entities.AuditLogFilter{EndTime: s.now().Add(-s.config.Retention)}
+
+	var deleted int64
+	for {
+		logs, err := s.auditLogRepo.ListAfterID(filter, 0, s.config.BatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		if len(logs) == 0 {
+			return deleted, nil
+		}
+
+		name, content, err := encodeArchive(logs)
+		if err != nil {
+			return deleted, err
+		}
+		if err := s.store.Put(name, content); err != nil {
+			return deleted, fmt.Errorf("failed to archive %s: %w", name, err)
+		}
+
+		ids := make([]uint, len(logs))
+		for i, log := range logs {
+			ids[i] = log.ID
+		}
+		count, err := s.auditLogRepo.DeleteByIDs(ids)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+		if count == 0 {
+			// 已由其他實例刪除，避免重複處理同一批
+			return deleted, nil
+		}
+	}
+}
+
+// encodeArchive 將一批記錄編碼為 gzip 壓縮的 JSON Lines，檔名包含首筆日期與 ID 範圍
+func encodeArchive(logs []*entities.AuditLog) (string, io.Reader, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, log := range logs {
+		if err := encoder.Encode(log); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", nil, err
+	}
+
+	first, last := logs[0], logs[len(logs)-1]
+	name := fmt.Sprintf("audit-log-%s-%d-%d.jsonl.gz", first.CreateTime.Format("20060102"), first.ID, last.ID)
+	return name, &buf, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/audit_log/entities"
+)
+
+// MockArchiveStore 模擬歸檔儲存
+type MockArchiveStore struct {
+	files    map[string][]byte
+	putError error
+}
+
+func NewMockArchiveStore() *MockArchiveStore {
+	return &MockArchiveStore{files: make(map[string][]byte)}
+}
+
+func (m *MockArchiveStore) Put(name string, content io.Reader) error {
+	if m.putError != nil {
+		return m.putError
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.files[name] = data
+	return nil
+}
+
+func (m *MockArchiveStore) Get(name string) (io.ReadCloser, error) {
+	data, ok := m.files[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// readArchive 解壓縮歸檔並返回其中的記錄
+func readArchive(t *testing.T, data []byte) []*entities.AuditLog {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("歸檔不是 gzip 格式: %v", err)
+	}
+	var logs []*entities.AuditLog
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var log entities.AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			t.Fatalf("歸檔內容不是 JSON Lines: %v", err)
+		}
+		logs = append(logs, &log)
+	}
+	return logs
+}
+
+func TestNewAuditRetentionService(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    ArchiveStore
+		config   AuditRetentionConfig
+		errorMsg string
+	}{
+		{
+			name:   "有效設定",
+			store:  NewMockArchiveStore(),
+			config: AuditRetentionConfig{Retention: 24 * time.Hour},
+		},
+		{
+			name:     "沒有歸檔儲存時不刪除記錄",
+			config:   AuditRetentionConfig{Retention: 24 * time.Hour},
+			errorMsg: "archive store is required",
+		},
+		{
+			name:     "保留期限必須大於 0",
+			store:    NewMockArchiveStore(),
+			errorMsg: "retention must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := NewAuditRetentionService(NewMockAuditLogRepository(), tt.store, tt.config)
+			if tt.errorMsg != "" {
+				if err == nil || err.Error() != tt.errorMsg {
+					t.Fatalf("期望錯誤 %q，得到 %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			if service.config.BatchSize != DefaultAuditArchiveBatchSize {
+				t.Errorf("期望預設批次為 %d，得到 %d", DefaultAuditArchiveBatchSize, service.config.BatchSize)
+			}
+		})
+	}
+}
+
+func TestAuditRetentionService_Run(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		putError    error
+		wantDeleted int64
+		wantFiles   int
+		wantRemain  int
+	}{
+		{
+			name:        "歸檔後刪除過期記錄",
+			wantDeleted: 5,
+			wantFiles:   3, // 每批 2 筆
+			wantRemain:  2,
+		},
+		{
+			name:        "歸檔失敗時不刪除",
+			putError:    errors.New("disk full"),
+			wantDeleted: 0,
+			wantFiles:   0,
+			wantRemain:  7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMockAuditLogRepository()
+			for id := uint(1); id <= 7; id++ {
+				createTime := now.AddDate(0, 0, -100+int(id)) // 第 1 ~ 5 筆超過 90 天
+				if id > 5 {
+					createTime = now.AddDate(0, 0, -1)
+				}
+				repo.logs[id] = &entities.AuditLog{ID: id, Sequence: uint64(id), MemberID: 1, RoleID: 1, Action: "UPDATE", ResourceType: "ROLE", Status: "SUCCESS", CreateTime: createTime}
+			}
+			store := NewMockArchiveStore()
+			store.putError = tt.putError
+
+			service, err := NewAuditRetentionService(repo, store, AuditRetentionConfig{Retention: 90 * 24 * time.Hour, BatchSize: 2})
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			service.now = func() time.Time { return now }
+
+			deleted, err := service.Run()
+			if (err != nil) != (tt.putError != nil) {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.putError, err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("期望刪除 %d 筆，得到 %d", tt.wantDeleted, deleted)
+			}
+			if len(store.files) != tt.wantFiles {
+				t.Errorf("期望 %d 個歸檔，得到 %d", tt.wantFiles, len(store.files))
+			}
+			if len(repo.logs) != tt.wantRemain {
+				t.Errorf("期望保留 %d 筆，得到 %d", tt.wantRemain, len(repo.logs))
+			}
+
+			archived := 0
+			for _, data := range store.files {
+				for _, log := range readArchive(t, data) {
+					if log.ID > 5 {
+						t.Errorf("未過期的記錄 %d 不應被歸檔", log.ID)
+					}
+					if log.Sequence != uint64(log.ID) {
+						t.Errorf("歸檔應保留雜湊鏈序號，記錄 %d 的序號為 %d", log.ID, log.Sequence)
+					}
+					archived++
+				}
+			}
+			if int64(archived) != tt.wantDeleted {
+				t.Errorf("期望歸檔 %d 筆，得到 %d", tt.wantDeleted, archived)
+			}
+		})
+	}
+}
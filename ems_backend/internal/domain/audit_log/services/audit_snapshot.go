@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"ems_backend/internal/domain/audit_log/entities"
+)
+
+// redactedValue - 敏感欄位在快照中的替代值
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys - 欄位名稱 (不分大小寫) 包含這些字時不記錄原值
+var sensitiveKeys = []string{"password", "secret", "token", "hash"}
+
+// Snapshot 將資源轉為 JSON 物件形式的快照，敏感欄位以 [REDACTED] 取代
+// 資源為 nil 或不是 JSON 物件時返回 nil
+func Snapshot(resource interface{}) map[string]interface{} {
+	if resource == nil {
+		return nil
+	}
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	redact(snapshot)
+	return snapshot
+}
+
+func redact(values map[string]interface{}) {
+	for key, value := range values {
+		if isSensitiveKey(key) {
+			values[key] = redactedValue
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			redact(nested)
+		}
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSnapshots 比較異動前後的快照，返回有變化的欄位 (巢狀物件以 a.b 表示)
+// before 為 nil 表示新建，after 為 nil 表示刪除
+func DiffSnapshots(before, after map[string]interface{}) map[string]entities.FieldChange {
+	changes := make(map[string]entities.FieldChange)
+	diffInto(changes, "", before, after)
+	return changes
+}
+
+func diffInto(changes map[string]entities.FieldChange, prefix string, before, after map[string]interface{}) {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		oldValue, newValue := before[key], after[key]
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diffInto(changes, path, oldMap, newMap)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[path] = entities.FieldChange{Before: oldValue, After: newValue}
+		}
+	}
+}
+
+// normalizeDetails 將 Details 轉為與從資料庫 (jsonb) 讀回時相同的形式，雜湊才能在驗證時重現
+func normalizeDetails(details map[string]interface{}) (map[string]interface{}, error) {
+	if details == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrAPIKeyInvalid API Key 不存在、格式錯誤、已撤銷或已過期 (對外不區分原因)
+	ErrAPIKeyInvalid = errors.New("invalid API key")
+	// ErrAPIKeyNotFound 指定的 API Key 不存在
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrServiceAccountNotFound 服務帳號不存在
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	// ErrServiceAccountDisabled 服務帳號已停用
+	ErrServiceAccountDisabled = errors.New("service account is disabled")
+	// ErrAPIKeyRoleNotAllowed 綁定公司的服務帳號不可使用可存取所有公司的角色
+	ErrAPIKeyRoleNotAllowed = errors.New("a company-scoped service account cannot use a system-wide role")
+	// ErrAPIKeyRoleExceedsCaller Key 的角色超出建立者本身的權限
+	ErrAPIKeyRoleExceedsCaller = errors.New("the role grants permissions the caller does not have")
+)
+
+// ServiceAccount - 服務帳號 (BI、外部整合使用，不可以密碼登入)
+// 以 member 資料列表示，使 member_id / current_role_id 沿用既有的權限與公司存取檢查
+type ServiceAccount struct {
+	MemberID    uint
+	Name        string
+	Email       string // 系統產生的識別用 email，不會收信
+	Description string
+	CompanyID   *uint // 綁定的公司；可存取範圍 (含子公司與否) 取決於 Key 的角色
+	IsEnable    bool
+	CreatedBy   uint
+	CreateTime  time.Time
+}
+
+// APIKey - 服務帳號的 API Key (只保存雜湊，明文僅在建立時返回一次)
+type APIKey struct {
+	ID               uint
+	ServiceAccountID uint // 服務帳號的 member_id
+	RoleID           uint // 使用此 Key 時的 current_role_id
+	Name             string
+	Prefix           string // 明文中的查找前綴，可公開顯示以辨識 Key
+	KeyHash          string // 完整明文的 SHA-256 (hex)
+	ExpiresAt        *time.Time
+	LastUsedAt       *time.Time
+	LastUsedIP       string
+	RevokedAt        *time.Time
+	CreatedBy        uint
+	CreateTime       time.Time
+}
+
+// IsExpired 是否已過期 (ExpiresAt 為 nil 表示永不過期)
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IsActive 未撤銷且未過期
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && !k.IsExpired(now)
+}
@@ -13,6 +13,15 @@ type AuthResult struct {
 	MemberRoles  []*member_role_entities.MemberRole `json:"member_roles"`
 	ExpiresIn    int64                              `json:"expires_in"`
 	TokenType    string                             `json:"token_type"`
+
+	// 需要 MFA 時不簽發 token，改以 MFAChallengeToken 呼叫 /auth/mfa/verify
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAChallengeToken     string   `json:"mfa_challenge_token,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"` // 角色要求 MFA 但尚未設定
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`          // 登入時完成設定才會返回
+
+	// 密碼超過有效期限，前端應要求變更密碼
+	PasswordExpired bool `json:"password_expired,omitempty"`
 }
 
 func NewAuthResult(accessToken, refreshToken string, member *entities.Member, memberRoles []*member_role_entities.MemberRole, expiresIn int64) *AuthResult {
@@ -25,3 +34,14 @@ func NewAuthResult(accessToken, refreshToken string, member *entities.Member, me
 		TokenType:    "Bearer",
 	}
 }
+
+// NewMFAChallengeResult 密碼驗證通過但需要 MFA 時的結果
+func NewMFAChallengeResult(member *entities.Member, challengeToken string, expiresIn int64, enrollmentRequired bool) *AuthResult {
+	return &AuthResult{
+		Member:                member.GetPublicInfo(),
+		ExpiresIn:             expiresIn,
+		MFARequired:           true,
+		MFAChallengeToken:     challengeToken,
+		MFAEnrollmentRequired: enrollmentRequired,
+	}
+}
@@ -3,15 +3,29 @@ package entities
 import (
 	auth_value_objects "ems_backend/internal/domain/auth/value_objects"
 	member_value_objects "ems_backend/internal/domain/member/value_objects"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrSessionNotFound 會話不存在或不屬於該會員
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionRevoked 會話已登出、被撤銷或已過期
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
 // AuthSession - 認證會話
+// access / refresh token 皆帶有 SessionID (sid)，撤銷會話即讓兩者立即失效
 type AuthSession struct {
 	ID           uint
+	SessionID    string
 	MemberID     member_value_objects.MemberID
 	AccessToken  auth_value_objects.JWTToken
 	RefreshToken auth_value_objects.JWTToken
+	IPAddress    string
+	UserAgent    string
+	ExpiresAt    time.Time // refresh token 到期時間
+	RevokedAt    *time.Time
 	CreateID     uint
 	CreateTime   time.Time
 	ModifyID     uint
@@ -29,3 +43,8 @@ func NewAuthSession(memberID member_value_objects.MemberID, accessToken auth_val
 		ModifyID:     memberID.Value(),
 	}
 }
+
+// IsActive 未撤銷且未過期
+func (s *AuthSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt))
+}
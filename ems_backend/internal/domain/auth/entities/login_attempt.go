@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// 登入失敗統計維度
+const (
+	LoginAttemptScopeEmail = "email"
+	LoginAttemptScopeIP    = "ip"
+)
+
+// LoginAttempt 某個 email 或來源 IP 在統計窗口內的連續登入失敗
+type LoginAttempt struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+}
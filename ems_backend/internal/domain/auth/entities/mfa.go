@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMFAChallengeInvalid 挑戰憑證不存在或已使用
+	ErrMFAChallengeInvalid = errors.New("invalid or used MFA challenge")
+	// ErrMFAChallengeExpired 挑戰憑證已過期或嘗試次數過多
+	ErrMFAChallengeExpired = errors.New("MFA challenge has expired, please log in again")
+	// ErrMFACodeInvalid 驗證碼或復原碼錯誤
+	ErrMFACodeInvalid = errors.New("invalid MFA code")
+	// ErrMFANotEnrolled 尚未啟用 MFA
+	ErrMFANotEnrolled = errors.New("MFA is not enabled")
+	// ErrMFAAlreadyEnabled 已啟用 MFA
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+	// ErrMFARequiredByRole 角色政策要求 MFA，不可停用
+	ErrMFARequiredByRole = errors.New("MFA is required by your role and cannot be disabled")
+)
+
+// MemberMFA - 會員 TOTP 設定
+// Enabled 為 false 表示已產生密鑰但尚未以驗證碼確認
+type MemberMFA struct {
+	MemberID     uint
+	Secret       string // base32 密鑰 (持久層保存的是加密後的值)
+	Enabled      bool
+	EnabledAt    *time.Time
+	LastUsedStep int64 // 最後一次通過驗證的時間步，防止同一驗證碼重複使用
+	CreateTime   time.Time
+}
+
+// MFARecoveryCode - 復原碼 (只保存雜湊)
+type MFARecoveryCode struct {
+	ID       uint
+	MemberID uint
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+// MFAChallenge - 密碼驗證通過後、簽發 token 前的 MFA 挑戰
+type MFAChallenge struct {
+	ID         uint
+	MemberID   uint
+	TokenHash  string
+	Attempts   int
+	ExpiresAt  time.Time
+	CreateTime time.Time
+}
+
+// IsExpired 是否已過期
+func (c *MFAChallenge) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
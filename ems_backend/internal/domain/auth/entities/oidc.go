@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrOIDCProviderNotFound 未設定的身分提供者
+	ErrOIDCProviderNotFound = errors.New("identity provider not found")
+	// ErrOIDCStateInvalid state 不存在、已使用、已過期或不屬於此提供者
+	ErrOIDCStateInvalid = errors.New("invalid or expired sso login state")
+	// ErrOIDCTokenInvalid 授權碼交換失敗或 ID token 驗證失敗
+	ErrOIDCTokenInvalid = errors.New("identity provider returned an invalid token")
+	// ErrOIDCEmailRequired ID token 沒有 email 或 email 未經驗證
+	ErrOIDCEmailRequired = errors.New("identity provider did not return a verified email")
+	// ErrOIDCDomainNotAllowed email 網域不在允許清單
+	ErrOIDCDomainNotAllowed = errors.New("email domain is not allowed for this identity provider")
+	// ErrOIDCAccountNotLinked 找不到連結的會員且未開啟自動建立 / 以 email 連結
+	ErrOIDCAccountNotLinked = errors.New("no member is linked to this identity")
+	// ErrOIDCNoRoleMapped 宣告沒有對應到任何角色
+	ErrOIDCNoRoleMapped = errors.New("identity is not mapped to any role")
+)
+
+// OIDCLoginState 授權碼流程進行中的狀態 (state、nonce、PKCE code_verifier)
+// state 只保存雜湊值；回呼時刪除，只能使用一次
+type OIDCLoginState struct {
+	ID           uint
+	StateHash    string // SHA-256 (hex)
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	RedirectPath string
+	ExpiresAt    time.Time
+	CreateTime   time.Time
+}
+
+// IsExpired 是否已超過有效期限
+func (s *OIDCLoginState) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// OIDCIdentity 外部身分 (provider + sub) 與會員的連結
+type OIDCIdentity struct {
+	ID          uint
+	Provider    string
+	Subject     string
+	MemberID    uint
+	Email       string
+	CreateTime  time.Time
+	LastLoginAt time.Time
+}
+
+// OIDCClaims 已驗證的 ID token 宣告
+type OIDCClaims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	Raw               map[string]interface{} // 完整宣告，供角色與公司對應使用
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrResetTokenInvalid 重設連結不存在或已使用
+	ErrResetTokenInvalid = errors.New("invalid or already used reset token")
+	// ErrResetTokenExpired 重設連結已過期
+	ErrResetTokenExpired = errors.New("reset token expired")
+)
+
+// PasswordResetToken 忘記密碼重設憑證 (forgot_temp)
+// 只保存雜湊值，明文僅出現在寄出的連結中；使用後即刪除
+type PasswordResetToken struct {
+	ID           uint
+	MemberID     uint
+	CodeHash     string // SHA-256 (hex)
+	ExpiresAt    time.Time
+	RedirectPath string // 重設完成後前端導向的路徑
+}
+
+// NewPasswordResetToken 創建重設憑證
+func NewPasswordResetToken(memberID uint, codeHash string, ttl time.Duration, redirectPath string) *PasswordResetToken {
+	return &PasswordResetToken{
+		MemberID:     memberID,
+		CodeHash:     codeHash,
+		ExpiresAt:    time.Now().Add(ttl),
+		RedirectPath: redirectPath,
+	}
+}
+
+// IsExpired 是否已超過有效期限
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
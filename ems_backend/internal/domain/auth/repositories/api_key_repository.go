@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+)
+
+// ServiceAccountRepository 服務帳號倉儲接口
+type ServiceAccountRepository interface {
+	// Create 在同一交易中建立 member、service_accounts 與公司綁定，並回填 MemberID
+	Create(account *entities.ServiceAccount) error
+
+	// FindByMemberID 查找服務帳號，不存在時返回 nil
+	FindByMemberID(memberID uint) (*entities.ServiceAccount, error)
+
+	// FindAll 列出所有服務帳號
+	FindAll() ([]*entities.ServiceAccount, error)
+
+	// Disable 停用服務帳號 (member.is_enable = false)
+	Disable(memberID uint, modifyID uint, now time.Time) error
+}
+
+// APIKeyRepository API Key 倉儲接口
+type APIKeyRepository interface {
+	// Create 建立 API Key 並回填 ID
+	Create(key *entities.APIKey) error
+
+	// FindByID 查找 API Key，不存在時返回 nil
+	FindByID(id uint) (*entities.APIKey, error)
+
+	// FindByPrefix 依查找前綴取得 API Key，不存在時返回 nil
+	FindByPrefix(prefix string) (*entities.APIKey, error)
+
+	// FindByServiceAccount 列出服務帳號的所有 API Key (含已撤銷)
+	FindByServiceAccount(memberID uint) ([]*entities.APIKey, error)
+
+	// Revoke 撤銷 API Key，返回是否撤銷 (已撤銷時為 false)
+	Revoke(id uint, now time.Time) (bool, error)
+
+	// RevokeByServiceAccount 撤銷服務帳號所有未撤銷的 API Key
+	RevokeByServiceAccount(memberID uint, now time.Time) (int64, error)
+
+	// TouchLastUsed 記錄最後使用時間與來源 IP；上次記錄晚於 since 時不更新，避免每個請求都寫入
+	TouchLastUsed(id uint, ip string, now time.Time, since time.Time) error
+}
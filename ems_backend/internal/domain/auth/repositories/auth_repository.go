@@ -1,12 +1,29 @@
 package repositories
 
-import "ems_backend/internal/domain/auth/entities"
+import (
+	"ems_backend/internal/domain/auth/entities"
+	"time"
+)
 
 type AuthRepository interface {
 	SaveSession(session *entities.AuthSession) error
 	UpdateAccessToken(id uint, accessToken string) error
+	// FindSessionByRefreshToken 只返回未撤銷的會話
 	FindSessionByRefreshToken(refreshToken string) (*entities.AuthSession, error)
 	FindSessionByMemberID(memberID uint) (*entities.AuthSession, error)
+	// InvalidateSession 撤銷會話 (標記 revoked_at)
 	InvalidateSession(sessionID uint) error
+	// InvalidateAllSessionsByMemberID 撤銷會員所有會話
 	InvalidateAllSessionsByMemberID(memberID uint) error
+
+	// FindActiveSessionsByMemberID 列出會員未撤銷且未過期的會話
+	FindActiveSessionsByMemberID(memberID uint, now time.Time) ([]*entities.AuthSession, error)
+	// FindSessionBySessionID 依 sid 查找會話，不存在時返回 nil
+	FindSessionBySessionID(sessionID string) (*entities.AuthSession, error)
+	// RevokeSessionsByMemberID 撤銷會員的會話 (exceptSessionID 非空時保留該會話)，返回被撤銷的 sid
+	RevokeSessionsByMemberID(memberID uint, exceptSessionID string, now time.Time) ([]string, error)
+	// FindRevokedSince 返回 revoked_at 晚於 since 的 sid 與撤銷時間
+	FindRevokedSince(since time.Time) (map[string]time.Time, error)
+	// DeleteSessionsBefore 刪除在 cutoff 之前已撤銷或已過期的會話
+	DeleteSessionsBefore(cutoff time.Time) (int64, error)
 }
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+)
+
+// LoginAttemptRepository 登入失敗統計倉儲接口
+type LoginAttemptRepository interface {
+	// Find 查找統計，不存在時返回 nil
+	Find(scope, key string) (*entities.LoginAttempt, error)
+
+	// RecordFailure 失敗次數加一 (上次失敗早於 windowStart 時從 1 重新計算)，返回更新後的統計
+	RecordFailure(scope, key string, now, windowStart time.Time) (*entities.LoginAttempt, error)
+
+	// Reset 清除統計
+	Reset(scope, key string) error
+
+	// DeleteBefore 刪除最後失敗時間早於 cutoff 的統計，返回刪除數量
+	DeleteBefore(cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+)
+
+// MFARepository 多因素驗證倉儲接口
+type MFARepository interface {
+	// FindByMemberID 查找會員的 TOTP 設定，不存在時返回 nil
+	FindByMemberID(memberID uint) (*entities.MemberMFA, error)
+
+	// Save 新增或覆寫會員的 TOTP 設定
+	Save(mfa *entities.MemberMFA) error
+
+	// AdvanceLastUsedStep 僅在 step 大於已使用的時間步時更新，返回是否更新 (並發時同一驗證碼只有一方成功)
+	AdvanceLastUsedStep(memberID uint, step int64) (bool, error)
+
+	// Delete 刪除會員的 TOTP 設定、復原碼與挑戰
+	Delete(memberID uint) error
+
+	// ReplaceRecoveryCodes 以新的復原碼雜湊取代舊的
+	ReplaceRecoveryCodes(memberID uint, codeHashes []string) error
+
+	// UseRecoveryCode 標記復原碼已使用，返回是否成功 (不存在或已使用時為 false)
+	UseRecoveryCode(memberID uint, codeHash string, now time.Time) (bool, error)
+
+	// CountRecoveryCodes 剩餘可用的復原碼數量
+	CountRecoveryCodes(memberID uint) (int, error)
+
+	// CreateChallenge 建立登入挑戰
+	CreateChallenge(challenge *entities.MFAChallenge) error
+
+	// FindChallenge 依 token 雜湊查找挑戰，不存在時返回 nil
+	FindChallenge(tokenHash string) (*entities.MFAChallenge, error)
+
+	// IncrementChallengeAttempts 嘗試次數加一，返回更新後的次數
+	IncrementChallengeAttempts(id uint) (int, error)
+
+	// DeleteChallenge 刪除挑戰，返回是否刪除 (並發時只有一方會成功)
+	DeleteChallenge(id uint) (bool, error)
+
+	// DeleteExpiredChallenges 刪除已過期的挑戰
+	DeleteExpiredChallenges(now time.Time) (int64, error)
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+)
+
+// OIDCStateRepository 授權碼流程狀態倉儲接口
+type OIDCStateRepository interface {
+	// Create 保存登入狀態
+	Create(state *entities.OIDCLoginState) error
+
+	// Consume 刪除並返回 state 雜湊對應的狀態，不存在時返回 nil (並發回呼只有一方取得)
+	Consume(stateHash string) (*entities.OIDCLoginState, error)
+
+	// DeleteExpired 刪除已過期的狀態
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// OIDCIdentityRepository 外部身分連結倉儲接口
+type OIDCIdentityRepository interface {
+	// FindByProviderSubject 查找外部身分，不存在時返回 nil
+	FindByProviderSubject(provider, subject string) (*entities.OIDCIdentity, error)
+
+	// Create 連結既有會員並回填 ID
+	Create(identity *entities.OIDCIdentity) error
+
+	// CreateWithMember 在同一交易中建立會員 (無本地密碼) 與外部身分，並回填 MemberID
+	CreateWithMember(name, email string, identity *entities.OIDCIdentity) error
+
+	// TouchLogin 更新最後登入時間與 email
+	TouchLogin(id uint, email string, now time.Time) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+)
+
+// PasswordResetRepository 忘記密碼重設憑證倉儲接口
+type PasswordResetRepository interface {
+	// Create 創建重設憑證
+	Create(token *entities.PasswordResetToken) error
+
+	// FindByHash 根據雜湊查找憑證，不存在時返回 nil
+	FindByHash(codeHash string) (*entities.PasswordResetToken, error)
+
+	// Consume 刪除憑證，返回是否由本次呼叫刪除 (確保只能使用一次)
+	Consume(id uint) (bool, error)
+
+	// DeleteByMemberID 刪除會員所有重設憑證
+	DeleteByMemberID(memberID uint) error
+
+	// DeleteExpired 刪除已過期的憑證，返回刪除數量
+	DeleteExpired(now time.Time) (int64, error)
+}
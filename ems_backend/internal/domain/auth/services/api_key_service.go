@@ -0,0 +1,375 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+	member_value_objects "ems_backend/internal/domain/member/value_objects"
+	role_entities "ems_backend/internal/domain/role/entities"
+	role_repositories "ems_backend/internal/domain/role/repositories"
+)
+
+// API Key 格式: ems_<8 位 hex 前綴>_<base64url 密文>
+const (
+	apiKeyScheme             = "ems"
+	apiKeyPrefixSize         = 4  // bytes，hex 後為 8 字元
+	apiKeySecretSize         = 32 // bytes
+	serviceAccountEmailHost  = "service-accounts.invalid"
+	DefaultAPIKeyTouchPeriod = time.Minute
+)
+
+// ErrInvalidAPIKeyRequest 建立服務帳號或 API Key 的參數錯誤
+var ErrInvalidAPIKeyRequest = errors.New("invalid request")
+
+// APIKeyConfig API Key 設定
+type APIKeyConfig struct {
+	MaxTTL      time.Duration // 大於 0 時 Key 必須有到期時間且不可超過此期限；未指定時以此為預設
+	TouchPeriod time.Duration // 最後使用時間的更新間隔
+}
+
+// APIKeyService 服務帳號與 API Key 領域服務
+type APIKeyService struct {
+	accountRepo auth_repositories.ServiceAccountRepository
+	keyRepo     auth_repositories.APIKeyRepository
+	roleRepo    role_repositories.RoleRepository
+	config      APIKeyConfig
+	now         func() time.Time
+}
+
+// NewAPIKeyService 創建 API Key 領域服務
+func NewAPIKeyService(
+	accountRepo auth_repositories.ServiceAccountRepository,
+	keyRepo auth_repositories.APIKeyRepository,
+	roleRepo role_repositories.RoleRepository,
+	config APIKeyConfig,
+) *APIKeyService {
+	if config.TouchPeriod <= 0 {
+		config.TouchPeriod = DefaultAPIKeyTouchPeriod
+	}
+	return &APIKeyService{
+		accountRepo: accountRepo,
+		keyRepo:     keyRepo,
+		roleRepo:    roleRepo,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// CreateServiceAccount 建立服務帳號；name 沿用會員名稱規則 (3-20 個英數字或底線)
+func (s *APIKeyService) CreateServiceAccount(name, description string, companyID *uint, createdBy uint) (*entities.ServiceAccount, error) {
+	if _, err := member_value_objects.NewName(name); err != nil {
+		return nil, invalidAPIKeyRequest("%v", err)
+	}
+	suffix, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &entities.ServiceAccount{
+		Name:        name,
+		Email:       fmt.Sprintf("svc-%s-%s@%s", strings.ToLower(strings.ReplaceAll(name, "_", "-")), suffix, serviceAccountEmailHost),
+		Description: strings.TrimSpace(description),
+		CompanyID:   companyID,
+		IsEnable:    true,
+		CreatedBy:   createdBy,
+		CreateTime:  s.now(),
+	}
+	if err := s.accountRepo.Create(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetServiceAccount 查找服務帳號
+func (s *APIKeyService) GetServiceAccount(memberID uint) (*entities.ServiceAccount, error) {
+	account, err := s.accountRepo.FindByMemberID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, entities.ErrServiceAccountNotFound
+	}
+	return account, nil
+}
+
+// ListServiceAccounts 列出所有服務帳號
+func (s *APIKeyService) ListServiceAccounts() ([]*entities.ServiceAccount, error) {
+	return s.accountRepo.FindAll()
+}
+
+// DisableServiceAccount 停用服務帳號、撤銷其所有 API Key 並移除 Key 指派的角色
+func (s *APIKeyService) DisableServiceAccount(memberID, modifyID uint) (int64, error) {
+	if _, err := s.GetServiceAccount(memberID); err != nil {
+		return 0, err
+	}
+	keys, err := s.keyRepo.FindByServiceAccount(memberID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	if err := s.accountRepo.Disable(memberID, modifyID, now); err != nil {
+		return 0, err
+	}
+	revoked, err := s.keyRepo.RevokeByServiceAccount(memberID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	roleIDs := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		roleIDs = append(roleIDs, key.RoleID)
+	}
+	if err := s.releaseUnusedRoles(memberID, roleIDs, now); err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+// CreateKey 為服務帳號建立綁定角色的 API Key，返回明文 (只會返回這一次)
+// createdByRoleID 為建立者目前使用的角色，Key 的角色不可超出建立者的權限
+func (s *APIKeyService) CreateKey(memberID, roleID uint, name string, expiresAt *time.Time, createdBy, createdByRoleID uint) (*entities.APIKey, string, error) {
+	account, err := s.GetServiceAccount(memberID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !account.IsEnable {
+		return nil, "", entities.ErrServiceAccountDisabled
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", invalidAPIKeyRequest("key name cannot be empty")
+	}
+
+	role, err := s.roleRepo.GetByID(roleID)
+	if err != nil || role == nil {
+		return nil, "", invalidAPIKeyRequest("role not found")
+	}
+	if !role.IsEnable {
+		return nil, "", invalidAPIKeyRequest("role is disabled")
+	}
+	if account.CompanyID != nil && role.CompanyScope == role_entities.CompanyScopeAll {
+		return nil, "", entities.ErrAPIKeyRoleNotAllowed
+	}
+	if err := s.authorizeKeyRole(role, createdBy, createdByRoleID); err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	expiresAt, err = s.resolveExpiry(expiresAt, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 服務帳號需擁有該角色，權限檢查才會與一般會員一致
+	if err := s.roleRepo.AssignMembers(roleID, []uint{memberID}, createdBy); err != nil {
+		return nil, "", fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	prefix, err := randomHex(apiKeyPrefixSize)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomToken(apiKeySecretSize)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := apiKeyScheme + "_" + prefix + "_" + secret
+
+	key := &entities.APIKey{
+		ServiceAccountID: memberID,
+		RoleID:           roleID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          sha256Hex(plaintext),
+		ExpiresAt:        expiresAt,
+		CreatedBy:        createdBy,
+		CreateTime:       now,
+	}
+	if err := s.keyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// ListKeys 列出服務帳號的 API Key
+func (s *APIKeyService) ListKeys(memberID uint) ([]*entities.APIKey, error) {
+	if _, err := s.GetServiceAccount(memberID); err != nil {
+		return nil, err
+	}
+	return s.keyRepo.FindByServiceAccount(memberID)
+}
+
+// RevokeKey 撤銷服務帳號的 API Key；沒有其他有效 Key 使用同一角色時一併移除該角色
+func (s *APIKeyService) RevokeKey(memberID, keyID uint) (*entities.APIKey, error) {
+	key, err := s.keyRepo.FindByID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.ServiceAccountID != memberID {
+		return nil, entities.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := s.now()
+	if _, err := s.keyRepo.Revoke(keyID, now); err != nil {
+		return nil, err
+	}
+	key.RevokedAt = &now
+	if err := s.releaseUnusedRoles(memberID, []uint{key.RoleID}, now); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Authenticate 驗證 API Key 明文並記錄使用；任何失敗對外都是 ErrAPIKeyInvalid
+func (s *APIKeyService) Authenticate(plaintext, clientIP string) (*entities.APIKey, error) {
+	prefix, ok := parseAPIKeyPrefix(plaintext)
+	if !ok {
+		return nil, entities.ErrAPIKeyInvalid
+	}
+
+	key, err := s.keyRepo.FindByPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(sha256Hex(plaintext)), []byte(key.KeyHash)) != 1 {
+		return nil, entities.ErrAPIKeyInvalid
+	}
+
+	now := s.now()
+	if !key.IsActive(now) {
+		return nil, entities.ErrAPIKeyInvalid
+	}
+	account, err := s.accountRepo.FindByMemberID(key.ServiceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || !account.IsEnable {
+		return nil, entities.ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= s.config.TouchPeriod {
+		if err := s.keyRepo.TouchLastUsed(key.ID, clientIP, now, now.Add(-s.config.TouchPeriod)); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+		key.LastUsedIP = clientIP
+	}
+	return key, nil
+}
+
+// authorizeKeyRole 避免以 API Key 提權：角色須為建立者擁有的角色，
+// 或權限為建立者目前角色權限的子集且公司範圍不大於目前角色
+func (s *APIKeyService) authorizeKeyRole(role *role_entities.Role, callerID, callerRoleID uint) error {
+	held, err := s.roleRepo.GetByMemberID(callerID)
+	if err != nil {
+		return err
+	}
+	for _, r := range held {
+		if r.ID == role.ID {
+			return nil
+		}
+	}
+
+	callerRole, err := s.roleRepo.GetByID(callerRoleID)
+	if err != nil || callerRole == nil || !callerRole.CompanyScope.Includes(role.CompanyScope) {
+		return entities.ErrAPIKeyRoleExceedsCaller
+	}
+	rolePowers, err := s.roleRepo.GetRolePowers(role.ID)
+	if err != nil {
+		return err
+	}
+	callerPowers, err := s.roleRepo.GetRolePowers(callerRoleID)
+	if err != nil {
+		return err
+	}
+	granted := make(map[uint]bool, len(callerPowers))
+	for _, powerID := range callerPowers {
+		granted[powerID] = true
+	}
+	for _, powerID := range rolePowers {
+		if !granted[powerID] {
+			return entities.ErrAPIKeyRoleExceedsCaller
+		}
+	}
+	return nil
+}
+
+// releaseUnusedRoles 將服務帳號從不再有有效 Key 使用的角色中移除
+// 角色是建立 Key 時指派的，Key 全部失效後不應保留
+func (s *APIKeyService) releaseUnusedRoles(memberID uint, roleIDs []uint, now time.Time) error {
+	keys, err := s.keyRepo.FindByServiceAccount(memberID)
+	if err != nil {
+		return err
+	}
+	inUse := make(map[uint]bool, len(keys))
+	for _, key := range keys {
+		if key.IsActive(now) {
+			inUse[key.RoleID] = true
+		}
+	}
+
+	released := make(map[uint]bool, len(roleIDs))
+	for _, roleID := range roleIDs {
+		if inUse[roleID] || released[roleID] {
+			continue
+		}
+		if err := s.roleRepo.RemoveMembers(roleID, []uint{memberID}); err != nil {
+			return fmt.Errorf("failed to remove role: %w", err)
+		}
+		released[roleID] = true
+	}
+	return nil
+}
+
+// resolveExpiry 套用預設與最長有效期限
+func (s *APIKeyService) resolveExpiry(expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, invalidAPIKeyRequest("expires_at must be in the future")
+	}
+	if s.config.MaxTTL <= 0 {
+		return expiresAt, nil
+	}
+	limit := now.Add(s.config.MaxTTL)
+	if expiresAt == nil {
+		return &limit, nil
+	}
+	if expiresAt.After(limit) {
+		return nil, invalidAPIKeyRequest("expires_at cannot be more than %s from now", s.config.MaxTTL)
+	}
+	return expiresAt, nil
+}
+
+// parseAPIKeyPrefix 解析 ems_<prefix>_<secret> 中的前綴
+func parseAPIKeyPrefix(plaintext string) (string, bool) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyScheme || len(parts[1]) != apiKeyPrefixSize*2 || parts[2] == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func invalidAPIKeyRequest(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidAPIKeyRequest, fmt.Sprintf(format, args...))
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
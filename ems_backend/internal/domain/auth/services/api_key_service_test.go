@@ -0,0 +1,410 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	role_entities "ems_backend/internal/domain/role/entities"
+)
+
+// MockServiceAccountRepository 模擬服務帳號 Repository
+type MockServiceAccountRepository struct {
+	accounts map[uint]*entities.ServiceAccount
+	nextID   uint
+}
+
+func NewMockServiceAccountRepository() *MockServiceAccountRepository {
+	return &MockServiceAccountRepository{accounts: map[uint]*entities.ServiceAccount{}, nextID: 100}
+}
+
+func (m *MockServiceAccountRepository) Create(account *entities.ServiceAccount) error {
+	m.nextID++
+	account.MemberID = m.nextID
+	stored := *account
+	m.accounts[account.MemberID] = &stored
+	return nil
+}
+
+func (m *MockServiceAccountRepository) FindByMemberID(memberID uint) (*entities.ServiceAccount, error) {
+	account, ok := m.accounts[memberID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (m *MockServiceAccountRepository) FindAll() ([]*entities.ServiceAccount, error) {
+	accounts := make([]*entities.ServiceAccount, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (m *MockServiceAccountRepository) Disable(memberID uint, modifyID uint, now time.Time) error {
+	if account, ok := m.accounts[memberID]; ok {
+		account.IsEnable = false
+	}
+	return nil
+}
+
+// MockAPIKeyRepository 模擬 API Key Repository
+type MockAPIKeyRepository struct {
+	keys    map[uint]*entities.APIKey
+	nextID  uint
+	touches int
+}
+
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{keys: map[uint]*entities.APIKey{}}
+}
+
+func (m *MockAPIKeyRepository) Create(key *entities.APIKey) error {
+	m.nextID++
+	key.ID = m.nextID
+	stored := *key
+	m.keys[key.ID] = &stored
+	return nil
+}
+
+func (m *MockAPIKeyRepository) FindByID(id uint) (*entities.APIKey, error) {
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (m *MockAPIKeyRepository) FindByPrefix(prefix string) (*entities.APIKey, error) {
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockAPIKeyRepository) FindByServiceAccount(memberID uint) ([]*entities.APIKey, error) {
+	var keys []*entities.APIKey
+	for _, key := range m.keys {
+		if key.ServiceAccountID == memberID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uint, now time.Time) (bool, error) {
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	key.RevokedAt = &now
+	return true, nil
+}
+
+func (m *MockAPIKeyRepository) RevokeByServiceAccount(memberID uint, now time.Time) (int64, error) {
+	var count int64
+	for _, key := range m.keys {
+		if key.ServiceAccountID == memberID && key.RevokedAt == nil {
+			key.RevokedAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(id uint, ip string, now time.Time, since time.Time) error {
+	key, ok := m.keys[id]
+	if !ok || (key.LastUsedAt != nil && key.LastUsedAt.After(since)) {
+		return nil
+	}
+	m.touches++
+	key.LastUsedAt = &now
+	key.LastUsedIP = ip
+	return nil
+}
+
+type apiKeyFixture struct {
+	service  *APIKeyService
+	accounts *MockServiceAccountRepository
+	keys     *MockAPIKeyRepository
+	roles    *MockRoleRepository
+	now      time.Time
+}
+
+func newAPIKeyFixture(config APIKeyConfig) *apiKeyFixture {
+	f := &apiKeyFixture{
+		accounts: NewMockServiceAccountRepository(),
+		keys:     NewMockAPIKeyRepository(),
+		roles: &MockRoleRepository{roles: map[uint]*role_entities.Role{
+			1: {ID: 1, Title: "SystemAdmin", IsEnable: true, CompanyScope: role_entities.CompanyScopeAll},
+			2: {ID: 2, Title: "company_manager", IsEnable: true, CompanyScope: role_entities.CompanyScopeSubtree},
+			4: {ID: 4, Title: "disabled_role", IsEnable: false},
+			5: {ID: 5, Title: "report_reader", IsEnable: true, CompanyScope: role_entities.CompanyScopeOwn},
+			6: {ID: 6, Title: "operator", IsEnable: true, CompanyScope: role_entities.CompanyScopeOwn},
+			7: {ID: 7, Title: "global_reader", IsEnable: true, CompanyScope: role_entities.CompanyScopeAll},
+		},
+			powers: map[uint][]uint{
+				1: {1, 2, 3, 4},
+				2: {1, 2},
+				5: {1},
+				6: {1, 3},
+				7: {1},
+			},
+			// 會員 1 為系統管理員，會員 2 為公司管理者
+			memberRoles: map[uint][]uint{1: {1}, 2: {2}},
+		},
+		now: time.Unix(1700000000, 0),
+	}
+	f.service = NewAPIKeyService(f.accounts, f.keys, f.roles, config)
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestAPIKeyService_CreateServiceAccount(t *testing.T) {
+	f := newAPIKeyFixture(APIKeyConfig{})
+
+	account, err := f.service.CreateServiceAccount("bi_export", " 報表匯出 ", nil, 1)
+	if err != nil {
+		t.Fatalf("建立服務帳號失敗: %v", err)
+	}
+	if account.MemberID == 0 || !account.IsEnable || account.Description != "報表匯出" {
+		t.Errorf("服務帳號欄位不正確: %+v", account)
+	}
+	if !strings.HasPrefix(account.Email, "svc-bi-export-") || !strings.HasSuffix(account.Email, "@service-accounts.invalid") {
+		t.Errorf("期望系統產生的 email，得到 %s", account.Email)
+	}
+
+	if _, err := f.service.CreateServiceAccount("bad name!", "", nil, 1); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+		t.Errorf("名稱不符規則應返回 ErrInvalidAPIKeyRequest，得到 %v", err)
+	}
+}
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	companyID := uint(7)
+	past := time.Unix(1600000000, 0)
+	tooLate := time.Unix(1700000000, 0).Add(48 * time.Hour)
+
+	tests := []struct {
+		name      string
+		companyID *uint
+		roleID    uint
+		keyName   string
+		expiresAt *time.Time
+		wantErr   error
+	}{
+		{"未綁定公司可使用系統管理角色", nil, 1, "bi", nil, nil},
+		{"綁定公司使用公司角色", &companyID, 2, "partner", nil, nil},
+		{"綁定公司不可使用系統管理角色", &companyID, 1, "partner", nil, entities.ErrAPIKeyRoleNotAllowed},
+		{"角色不存在", nil, 99, "bi", nil, ErrInvalidAPIKeyRequest},
+		{"角色已停用", nil, 4, "bi", nil, ErrInvalidAPIKeyRequest},
+		{"名稱空白", nil, 2, "  ", nil, ErrInvalidAPIKeyRequest},
+		{"到期時間已過", nil, 2, "bi", &past, ErrInvalidAPIKeyRequest},
+		{"超過最長有效期限", nil, 2, "bi", &tooLate, ErrInvalidAPIKeyRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIKeyFixture(APIKeyConfig{MaxTTL: 24 * time.Hour})
+			account, _ := f.service.CreateServiceAccount("partner", "", tt.companyID, 1)
+
+			key, plaintext, err := f.service.CreateKey(account.MemberID, tt.roleID, tt.keyName, tt.expiresAt, 1, 1)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("期望 %v，得到 %v", tt.wantErr, err)
+				}
+				if len(f.keys.keys) != 0 {
+					t.Errorf("失敗時不應建立 Key")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("建立 Key 失敗: %v", err)
+			}
+
+			if !strings.HasPrefix(plaintext, "ems_"+key.Prefix+"_") {
+				t.Errorf("明文格式不正確: %s", plaintext)
+			}
+			stored := f.keys.keys[key.ID]
+			if stored.KeyHash != sha256Hex(plaintext) || strings.Contains(stored.KeyHash, plaintext) {
+				t.Errorf("只應保存明文的雜湊")
+			}
+			if key.ExpiresAt == nil || !key.ExpiresAt.Equal(f.now.Add(24*time.Hour)) {
+				t.Errorf("未指定到期時間應套用最長有效期限，得到 %v", key.ExpiresAt)
+			}
+			if members := f.roles.assigned[tt.roleID]; len(members) != 1 || members[0] != account.MemberID {
+				t.Errorf("服務帳號應被指派 Key 的角色，得到 %v", members)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	f := newAPIKeyFixture(APIKeyConfig{})
+	account, _ := f.service.CreateServiceAccount("bi_export", "", nil, 1)
+	key, plaintext, err := f.service.CreateKey(account.MemberID, 2, "bi", nil, 1, 1)
+	if err != nil {
+		t.Fatalf("建立 Key 失敗: %v", err)
+	}
+
+	got, err := f.service.Authenticate(plaintext, "10.0.0.1")
+	if err != nil || got.ID != key.ID || got.RoleID != 2 || got.ServiceAccountID != account.MemberID {
+		t.Fatalf("正確的 Key 應驗證成功，得到 %+v %v", got, err)
+	}
+	if f.keys.touches != 1 || f.keys.keys[key.ID].LastUsedIP != "10.0.0.1" {
+		t.Errorf("應記錄最後使用時間與 IP")
+	}
+
+	// 一分鐘內重複使用不再寫入
+	f.now = f.now.Add(30 * time.Second)
+	_, _ = f.service.Authenticate(plaintext, "10.0.0.1")
+	if f.keys.touches != 1 {
+		t.Errorf("更新間隔內不應重複記錄，得到 %d 次", f.keys.touches)
+	}
+	f.now = f.now.Add(time.Minute)
+	_, _ = f.service.Authenticate(plaintext, "10.0.0.2")
+	if f.keys.touches != 2 || f.keys.keys[key.ID].LastUsedIP != "10.0.0.2" {
+		t.Errorf("超過更新間隔應再次記錄")
+	}
+
+	invalid := []struct {
+		name  string
+		key   string
+		setup func()
+	}{
+		{"格式錯誤", "Bearer abc", nil},
+		{"前綴不存在", "ems_00000000_secret", nil},
+		{"密文錯誤", "ems_" + key.Prefix + "_wrong", nil},
+		{"已過期", plaintext, func() {
+			expired := f.now.Add(-time.Second)
+			f.keys.keys[key.ID].ExpiresAt = &expired
+		}},
+		{"已撤銷", plaintext, func() {
+			f.keys.keys[key.ID].ExpiresAt = nil
+			_, _ = f.service.RevokeKey(account.MemberID, key.ID)
+		}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if _, err := f.service.Authenticate(tt.key, "10.0.0.1"); !errors.Is(err, entities.ErrAPIKeyInvalid) {
+				t.Errorf("期望 ErrAPIKeyInvalid，得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeAndDisable(t *testing.T) {
+	f := newAPIKeyFixture(APIKeyConfig{})
+	account, _ := f.service.CreateServiceAccount("bi_export", "", nil, 1)
+	other, _ := f.service.CreateServiceAccount("partner", "", nil, 1)
+	first, firstPlain, _ := f.service.CreateKey(account.MemberID, 2, "first", nil, 1, 1)
+	_, secondPlain, _ := f.service.CreateKey(account.MemberID, 2, "second", nil, 1, 1)
+
+	if _, err := f.service.RevokeKey(other.MemberID, first.ID); !errors.Is(err, entities.ErrAPIKeyNotFound) {
+		t.Errorf("不可撤銷其他服務帳號的 Key，得到 %v", err)
+	}
+	revoked, err := f.service.RevokeKey(account.MemberID, first.ID)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("撤銷失敗: %+v %v", revoked, err)
+	}
+	if _, err := f.service.Authenticate(firstPlain, ""); !errors.Is(err, entities.ErrAPIKeyInvalid) {
+		t.Errorf("撤銷後的 Key 不可使用")
+	}
+	if _, err := f.service.Authenticate(secondPlain, ""); err != nil {
+		t.Errorf("其他 Key 不受影響，得到 %v", err)
+	}
+	if members := f.roles.assigned[2]; len(members) == 0 {
+		t.Errorf("仍有有效 Key 使用角色時不應移除")
+	}
+
+	count, err := f.service.DisableServiceAccount(account.MemberID, 1)
+	if err != nil || count != 1 {
+		t.Fatalf("停用應撤銷剩餘的 1 把 Key，得到 %d %v", count, err)
+	}
+	if _, err := f.service.Authenticate(secondPlain, ""); !errors.Is(err, entities.ErrAPIKeyInvalid) {
+		t.Errorf("停用後的服務帳號不可使用 Key")
+	}
+	if members := f.roles.assigned[2]; len(members) != 0 {
+		t.Errorf("停用後應移除 Key 指派的角色，得到 %v", members)
+	}
+	if _, _, err := f.service.CreateKey(account.MemberID, 2, "third", nil, 1, 1); !errors.Is(err, entities.ErrServiceAccountDisabled) {
+		t.Errorf("停用的服務帳號不可建立 Key，得到 %v", err)
+	}
+	if _, err := f.service.DisableServiceAccount(999, 1); !errors.Is(err, entities.ErrServiceAccountNotFound) {
+		t.Errorf("期望 ErrServiceAccountNotFound，得到 %v", err)
+	}
+}
+
+func TestAPIKeyService_CreateKey_CallerPermissions(t *testing.T) {
+	tests := []struct {
+		name         string
+		callerID     uint
+		callerRoleID uint
+		roleID       uint
+		wantErr      error
+	}{
+		{"系統管理員可發出任何角色", 1, 1, 6, nil},
+		{"使用自己擁有的角色", 2, 2, 2, nil},
+		{"權限為目前角色的子集", 2, 2, 5, nil},
+		{"權限超出目前角色", 2, 2, 6, entities.ErrAPIKeyRoleExceedsCaller},
+		{"未擁有的系統管理角色", 2, 2, 1, entities.ErrAPIKeyRoleExceedsCaller},
+		{"公司範圍大於目前角色", 2, 2, 7, entities.ErrAPIKeyRoleExceedsCaller},
+		{"目前角色不存在", 2, 99, 5, entities.ErrAPIKeyRoleExceedsCaller},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIKeyFixture(APIKeyConfig{})
+			account, _ := f.service.CreateServiceAccount("partner", "", nil, tt.callerID)
+
+			_, _, err := f.service.CreateKey(account.MemberID, tt.roleID, "partner", nil, tt.callerID, tt.callerRoleID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望 %v，得到 %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil && (len(f.keys.keys) != 0 || len(f.roles.assigned[tt.roleID]) != 0) {
+				t.Errorf("拒絕時不應建立 Key 或指派角色")
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeKey_ReleasesRole(t *testing.T) {
+	f := newAPIKeyFixture(APIKeyConfig{})
+	account, _ := f.service.CreateServiceAccount("bi_export", "", nil, 1)
+	reader, _, _ := f.service.CreateKey(account.MemberID, 5, "reader", nil, 1, 1)
+	manager, _, _ := f.service.CreateKey(account.MemberID, 2, "manager", nil, 1, 1)
+
+	if _, err := f.service.RevokeKey(account.MemberID, reader.ID); err != nil {
+		t.Fatalf("撤銷失敗: %v", err)
+	}
+	if members := f.roles.assigned[5]; len(members) != 0 {
+		t.Errorf("最後一把使用角色的 Key 撤銷後應移除角色，得到 %v", members)
+	}
+	if members := f.roles.assigned[2]; len(members) != 1 {
+		t.Errorf("其他 Key 的角色不受影響，得到 %v", members)
+	}
+
+	// 已過期的 Key 不算仍在使用
+	expired := f.now.Add(-time.Second)
+	f.keys.keys[manager.ID].ExpiresAt = &expired
+	other, _, _ := f.service.CreateKey(account.MemberID, 5, "other", nil, 1, 1)
+	if _, err := f.service.RevokeKey(account.MemberID, other.ID); err != nil {
+		t.Fatalf("撤銷失敗: %v", err)
+	}
+	if _, err := f.service.RevokeKey(account.MemberID, manager.ID); err != nil {
+		t.Fatalf("撤銷失敗: %v", err)
+	}
+	if members := f.roles.assigned[2]; len(members) != 0 {
+		t.Errorf("撤銷過期 Key 後應移除角色，得到 %v", members)
+	}
+}
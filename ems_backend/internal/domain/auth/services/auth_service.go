@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"ems_backend/internal/domain/auth/entities"
 	auth_repositories "ems_backend/internal/domain/auth/repositories"
 	auth_value_objects "ems_backend/internal/domain/auth/value_objects"
+	member_entities "ems_backend/internal/domain/member/entities"
 	"ems_backend/internal/domain/member/repositories"
 	member_history_repositories "ems_backend/internal/domain/member_history/repositories"
 	member_role_repositories "ems_backend/internal/domain/member_role/repositories"
@@ -14,6 +16,36 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	// ErrInvalidCredentials 帳號或密碼錯誤
+	ErrInvalidCredentials = errors.New("invalid password")
+	// ErrAccountLocked 帳號因連續登入失敗被鎖定
+	ErrAccountLocked = errors.New("account is locked due to too many failed login attempts")
+	// ErrTooManyAttempts 失敗次數過多，需等待後再試
+	ErrTooManyAttempts = errors.New("too many failed login attempts, please retry later")
+	// ErrAccountDisabled 帳號已停用
+	ErrAccountDisabled = errors.New("account is disabled")
+)
+
+// token 預設有效期限 (SetTokenTTL 可調整)
+const (
+	defaultAccessTokenTTL  = 5 * time.Minute
+	defaultRefreshTokenTTL = 24 * time.Hour
+)
+
+// LoginError 登入失敗資訊，供應用層寫入審計日誌與回應 Retry-After
+type LoginError struct {
+	Err         error
+	MemberID    uint          // 0 表示帳號不存在或尚未查詢
+	RetryAfter  time.Duration // 節流或鎖定的剩餘時間
+	Failures    int           // 帳號累計連續失敗次數
+	Locked      bool          // 本次失敗觸發了鎖定
+	LockedUntil *time.Time    // nil 且 Locked 時表示需管理員解鎖
+}
+
+func (e *LoginError) Error() string { return e.Err.Error() }
+func (e *LoginError) Unwrap() error { return e.Err }
+
 type AuthService struct {
 	memberRepo         repositories.MemberRepository
 	memberHistoryRepo  member_history_repositories.MemberHistoryRepository
@@ -21,9 +53,19 @@ type AuthService struct {
 	memberRoleRepo     member_role_repositories.MemberRoleRepository
 	accessTokenSecret  string
 	refreshTokenSecret string
+	accessTokenTTL     time.Duration
+	refreshTokenTTL    time.Duration
+	throttle           *LoginThrottleService
+	mfa                *MFAService
+	apiKeys            *APIKeyService
+	sessions           *SessionService
+	passwordPolicy     *PasswordPolicyService
+	oidc               *OIDCService
 }
 
 func NewAuthService(memberRepo repositories.MemberRepository, memberHistoryRepo member_history_repositories.MemberHistoryRepository, authRepo auth_repositories.AuthRepository, memberRoleRepo member_role_repositories.MemberRoleRepository, accessTokenSecret string, refreshTokenSecret string) *AuthService {
+	// 預設政策只檢查長度與常見密碼，SetPasswordPolicy 可換成完整政策
+	passwordPolicy, _ := NewPasswordPolicyService(memberHistoryRepo, PasswordPolicyConfig{})
 	return &AuthService{
 		memberRepo:         memberRepo,
 		memberHistoryRepo:  memberHistoryRepo,
@@ -31,44 +73,301 @@ func NewAuthService(memberRepo repositories.MemberRepository, memberHistoryRepo
 		memberRoleRepo:     memberRoleRepo,
 		accessTokenSecret:  accessTokenSecret,
 		refreshTokenSecret: refreshTokenSecret,
+		accessTokenTTL:     defaultAccessTokenTTL,
+		refreshTokenTTL:    defaultRefreshTokenTTL,
+		passwordPolicy:     passwordPolicy,
+	}
+}
+
+// SetTokenTTL 設置 access token 與 refresh token (會話) 的有效期限，0 表示沿用預設值
+func (s *AuthService) SetTokenTTL(accessTTL, refreshTTL time.Duration) {
+	if accessTTL > 0 {
+		s.accessTokenTTL = accessTTL
 	}
+	if refreshTTL > 0 {
+		s.refreshTokenTTL = refreshTTL
+	}
+}
+
+// SetPasswordPolicy 設置密碼政策 (變更密碼、有效期限與雜湊參數升級)
+func (s *AuthService) SetPasswordPolicy(policy *PasswordPolicyService) {
+	s.passwordPolicy = policy
+}
+
+// PasswordPolicy 目前的密碼政策設定
+func (s *AuthService) PasswordPolicy() PasswordPolicyConfig {
+	return s.passwordPolicy.Config()
+}
+
+// SetLoginThrottle 啟用登入節流與連續失敗自動鎖定
+func (s *AuthService) SetLoginThrottle(throttle *LoginThrottleService) {
+	s.throttle = throttle
+}
+
+// SetMFAService 啟用 TOTP 多因素驗證 (兩階段登入)
+func (s *AuthService) SetMFAService(mfa *MFAService) {
+	s.mfa = mfa
 }
 
-func (s *AuthService) Login(email, password string) (*entities.AuthResult, error) {
+// SetSessionService 啟用會話撤銷檢查 (登出、停用帳號後 access token 立即失效)
+func (s *AuthService) SetSessionService(sessions *SessionService) {
+	s.sessions = sessions
+}
+
+// SetOIDCService 啟用 OpenID Connect 單一登入
+func (s *AuthService) SetOIDCService(oidc *OIDCService) {
+	s.oidc = oidc
+}
+
+// SetAPIKeyService 啟用服務帳號 API Key 驗證
+func (s *AuthService) SetAPIKeyService(apiKeys *APIKeyService) {
+	s.apiKeys = apiKeys
+}
+
+// AuthenticateAPIKey 驗證服務帳號的 API Key，返回 Key 綁定的服務帳號與角色
+func (s *AuthService) AuthenticateAPIKey(plaintext, clientIP string) (*entities.APIKey, error) {
+	if s.apiKeys == nil {
+		return nil, entities.ErrAPIKeyInvalid
+	}
+	return s.apiKeys.Authenticate(plaintext, clientIP)
+}
+
+// Login 登入；失敗時返回 *LoginError
+func (s *AuthService) Login(email, password, clientIP, userAgent string) (*entities.AuthResult, error) {
+	now := time.Now()
+
+	// 0. 節流檢查 (email 與來源 IP)
+	if s.throttle != nil {
+		wait, err := s.throttle.Check(email, clientIP, now)
+		if err != nil {
+			return nil, err
+		}
+		if wait > 0 {
+			return nil, &LoginError{Err: ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+
 	// 1. 查找會員
 	member, err := s.memberRepo.FindByEmail(email)
 	if err != nil {
 		fmt.Print(err)
-		return nil, errors.New("invalid password")
+		return nil, s.loginFailed(email, clientIP, now, &LoginError{Err: ErrInvalidCredentials})
+	}
+	if member.IsLocked(now) {
+		loginErr := &LoginError{Err: ErrAccountLocked, MemberID: member.ID.Value(), LockedUntil: member.LockedUntil}
+		if member.LockedUntil != nil {
+			loginErr.RetryAfter = member.LockedUntil.Sub(now)
+		}
+		return nil, loginErr
 	}
 
 	// 2. 尋找歷時密碼
 	memberHistory, err := s.memberHistoryRepo.LastMemberHistory(member.ID.Value())
 	if err != nil {
 		fmt.Print(err)
-		return nil, errors.New("invalid password")
+		return nil, s.loginFailed(email, clientIP, now, &LoginError{Err: ErrInvalidCredentials, MemberID: member.ID.Value()})
 	}
 
 	// fmt.Println(memberHistory)
 	// 2. 驗證憑證
 	verify := memberHistory.ValidatePassword(password, memberHistory.Salt, memberHistory.Hash)
 	if !verify {
-		return nil, errors.New("invalid password")
+		loginErr := &LoginError{Err: ErrInvalidCredentials, MemberID: member.ID.Value()}
+		if err := s.countAccountFailure(member, now, loginErr); err != nil {
+			return nil, err
+		}
+		return nil, s.loginFailed(email, clientIP, now, loginErr)
+	}
+	if !member.IsEnable {
+		return nil, &LoginError{Err: ErrAccountDisabled, MemberID: member.ID.Value()}
+	}
+	// 密碼正確時才能以目前的 argon2 參數重新雜湊
+	if _, err := s.passwordPolicy.RehashIfNeeded(memberHistory, password); err != nil {
+		return nil, fmt.Errorf("failed to rehash password: %w", err)
+	}
+
+	// 3. 已啟用 MFA 或角色要求 MFA 時，先返回挑戰憑證
+	// 失敗統計保留到 MFA 完成，避免以正確密碼重新登入來重置驗證碼的錯誤次數
+	if s.mfa != nil {
+		challenge, enrollmentRequired, err := s.mfa.LoginRequirement(member.ID.Value())
+		if err != nil {
+			return nil, err
+		}
+		if challenge {
+			token, err := s.mfa.IssueChallenge(member.ID.Value())
+			if err != nil {
+				return nil, err
+			}
+			return entities.NewMFAChallengeResult(member, token, int64(s.mfa.ChallengeTTL().Seconds()), enrollmentRequired), nil
+		}
+	}
+
+	// 登入成功，清除失敗統計
+	if err := s.clearFailures(member, memberHistory.ErrorCount > 0); err != nil {
+		return nil, err
 	}
+	result, err := s.issueSession(member, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	result.PasswordExpired = s.passwordPolicy.IsExpired(memberHistory)
+	return result, nil
+}
 
-	// 3. 生成 JWT Access Token
-	accessToken, err := s.generateAccessToken(member.ID.String(), member.Name.String())
+// CompleteOIDCLogin 以身分提供者的授權碼完成單一登入，返回結果與登入前指定的導向路徑
+// 多因素驗證由身分提供者負責，不再要求本地 TOTP；帳號停用或鎖定時仍拒絕登入
+func (s *AuthService) CompleteOIDCLogin(ctx context.Context, providerID, code, state, clientIP, userAgent string) (*entities.AuthResult, string, error) {
+	if s.oidc == nil {
+		return nil, "", entities.ErrOIDCProviderNotFound
+	}
+	member, redirectPath, err := s.oidc.CompleteLogin(ctx, providerID, code, state)
+	if err != nil {
+		return nil, "", err
+	}
+	if !member.IsEnable {
+		return nil, "", &LoginError{Err: ErrAccountDisabled, MemberID: member.ID.Value()}
+	}
+	if now := time.Now(); member.IsLocked(now) {
+		loginErr := &LoginError{Err: ErrAccountLocked, MemberID: member.ID.Value(), LockedUntil: member.LockedUntil}
+		if member.LockedUntil != nil {
+			loginErr.RetryAfter = member.LockedUntil.Sub(now)
+		}
+		return nil, "", loginErr
+	}
+
+	result, err := s.issueSession(member, clientIP, userAgent)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, redirectPath, nil
+}
+
+// CompleteMFALogin 以挑戰憑證與 TOTP / 復原碼完成登入
+// 角色要求但尚未設定 MFA 時，驗證碼會同時確認設定並在結果中返回復原碼
+func (s *AuthService) CompleteMFALogin(challengeToken, code, clientIP, userAgent string) (*entities.AuthResult, error) {
+	if s.mfa == nil {
+		return nil, entities.ErrMFANotEnrolled
+	}
+	challenge, err := s.mfa.ResolveChallenge(challengeToken)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. 生成 Refresh Token
-	refreshToken, err := s.generateRefreshToken(member.ID.Value())
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", challenge.MemberID))
+	if err != nil || member == nil || !member.IsEnable {
+		return nil, entities.ErrMFAChallengeInvalid
+	}
+	now := time.Now()
+	if member.IsLocked(now) {
+		return nil, &LoginError{Err: ErrAccountLocked, MemberID: member.ID.Value(), LockedUntil: member.LockedUntil}
+	}
+
+	enabled, err := s.mfa.IsEnabled(member.ID.Value())
 	if err != nil {
 		return nil, err
 	}
+	var recoveryCodes []string
+	if enabled {
+		err = s.mfa.Verify(member.ID.Value(), code)
+	} else {
+		recoveryCodes, err = s.mfa.Activate(member.ID.Value(), code)
+	}
+	if errors.Is(err, entities.ErrMFACodeInvalid) {
+		loginErr := &LoginError{Err: entities.ErrMFACodeInvalid, MemberID: member.ID.Value()}
+		if err := s.mfa.FailChallenge(challenge); err != nil {
+			return nil, err
+		}
+		if err := s.countAccountFailure(member, now, loginErr); err != nil {
+			return nil, err
+		}
+		return nil, loginErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfa.ConsumeChallenge(challenge); err != nil {
+		return nil, err
+	}
+	if err := s.clearFailures(member, true); err != nil {
+		return nil, err
+	}
 
-	// 5. 創建會話
+	result, err := s.issueSession(member, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	if history, err := s.memberHistoryRepo.LastMemberHistory(member.ID.Value()); err == nil {
+		result.PasswordExpired = s.passwordPolicy.IsExpired(history)
+	}
+	return result, nil
+}
+
+// ChangePassword 以目前密碼驗證後變更密碼，並撤銷目前會話以外的所有會話
+func (s *AuthService) ChangePassword(memberID uint, currentPassword, newPassword, currentSessionID string) error {
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", memberID))
+	if err != nil || member == nil {
+		return fmt.Errorf("member not found: %w", err)
+	}
+	history, err := s.memberHistoryRepo.LastMemberHistory(memberID)
+	if err != nil {
+		return fmt.Errorf("failed to find password: %w", err)
+	}
+	if !history.ValidatePassword(currentPassword, history.Salt, history.Hash) {
+		// 計入連續失敗，避免以被盜用的會話猜測密碼
+		loginErr := &LoginError{Err: ErrInvalidCredentials, MemberID: memberID}
+		if err := s.countAccountFailure(member, time.Now(), loginErr); err != nil {
+			return err
+		}
+		return loginErr
+	}
+
+	if err := s.passwordPolicy.SetPassword(member.ID, newPassword, memberID, member.Name.String(), member.Email.String()); err != nil {
+		return err
+	}
+	if s.sessions != nil && currentSessionID != "" {
+		_, err = s.sessions.RevokeAll(memberID, currentSessionID)
+		return err
+	}
+	return s.authRepo.InvalidateAllSessionsByMemberID(memberID)
+}
+
+// clearFailures 登入成功後清除 email 節流統計與帳號連續失敗次數
+func (s *AuthService) clearFailures(member *member_entities.Member, resetErrorCount bool) error {
+	if s.throttle == nil {
+		return nil
+	}
+	if err := s.throttle.RecordSuccess(member.Email.String()); err != nil {
+		return err
+	}
+	if resetErrorCount {
+		return s.memberHistoryRepo.ResetErrorCount(member.ID.Value())
+	}
+	return nil
+}
+
+// issueSession 簽發 access / refresh token 並保存會話
+func (s *AuthService) issueSession(member *member_entities.Member, clientIP, userAgent string) (*entities.AuthResult, error) {
+	// 1. 產生會話識別碼，兩種 token 都帶有 sid
+	sessionID, err := randomToken(sessionIDSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 生成 JWT Access Token
+	accessToken, err := s.generateAccessToken(member.ID.String(), member.Name.String(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 生成 Refresh Token
+	refreshToken, err := s.generateRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 創建會話
 	accessTokenVO, err := s.createJWTToken(accessToken)
 	if err != nil {
 		return nil, err
@@ -80,28 +379,120 @@ func (s *AuthService) Login(email, password string) (*entities.AuthResult, error
 	}
 
 	session := entities.NewAuthSession(member.ID, accessTokenVO, refreshTokenVO)
+	session.SessionID = sessionID
+	session.IPAddress = clientIP
+	session.UserAgent = userAgent
+	session.ExpiresAt = session.CreateTime.Add(s.refreshTokenTTL)
 
-	// 6. 保存會話
+	// 4. 保存會話
 	if err := s.authRepo.SaveSession(session); err != nil {
 		return nil, err
 	}
 
-	// 7. 獲取會員角色
+	// 5. 獲取會員角色
 	memberRoles, err := s.memberRoleRepo.GetByMemberID(member.ID.Value())
 	if err != nil {
 		return nil, err
 	}
 
-	// 7. 返回認證結果
+	// 6. 返回認證結果
 	return entities.NewAuthResult(
 		accessToken,
 		refreshToken,
 		member,
 		memberRoles,
-		int64(24*time.Hour.Seconds()), // 24小時
+		int64(s.accessTokenTTL.Seconds()),
 	), nil
 }
 
+// loginFailed 記錄 email / IP 失敗統計後返回原錯誤
+func (s *AuthService) loginFailed(email, clientIP string, now time.Time, loginErr *LoginError) error {
+	if s.throttle != nil {
+		if err := s.throttle.RecordFailure(email, clientIP, now); err != nil {
+			return err
+		}
+	}
+	return loginErr
+}
+
+// countAccountFailure 累計帳號連續失敗次數，達到門檻時鎖定帳號
+func (s *AuthService) countAccountFailure(member *member_entities.Member, now time.Time, loginErr *LoginError) error {
+	if s.throttle == nil || s.throttle.Config().LockoutThreshold <= 0 {
+		return nil
+	}
+
+	failures, err := s.memberHistoryRepo.IncrementErrorCount(member.ID.Value())
+	if err != nil {
+		return err
+	}
+	loginErr.Failures = failures
+	if failures < s.throttle.Config().LockoutThreshold {
+		return nil
+	}
+
+	member.Lock(now, s.throttle.Config().LockoutDuration)
+	if err := s.memberRepo.UpdateLock(member); err != nil {
+		return err
+	}
+	if err := s.memberHistoryRepo.ResetErrorCount(member.ID.Value()); err != nil {
+		return err
+	}
+
+	loginErr.Err = ErrAccountLocked
+	loginErr.Locked = true
+	loginErr.LockedUntil = member.LockedUntil
+	if member.LockedUntil != nil {
+		loginErr.RetryAfter = member.LockedUntil.Sub(now)
+	}
+	return nil
+}
+
+// UnlockAccount 管理員解除登入鎖定並清除失敗統計
+func (s *AuthService) UnlockAccount(memberID uint) (*member_entities.Member, error) {
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", memberID))
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+
+	member.Unlock()
+	if err := s.memberRepo.UpdateLock(member); err != nil {
+		return nil, err
+	}
+	if err := s.memberHistoryRepo.ResetErrorCount(memberID); err != nil {
+		return nil, err
+	}
+	if s.throttle != nil {
+		if err := s.throttle.RecordSuccess(member.Email.String()); err != nil {
+			return nil, err
+		}
+	}
+	return member, nil
+}
+
+// ExpireLockouts 清除已到期的鎖定與過期的失敗統計，返回被解鎖的會員
+func (s *AuthService) ExpireLockouts(now time.Time) ([]*member_entities.Member, error) {
+	members, err := s.memberRepo.FindLockExpired(now)
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked := make([]*member_entities.Member, 0, len(members))
+	for _, member := range members {
+		member.Unlock()
+		if err := s.memberRepo.UpdateLock(member); err != nil {
+			return unlocked, err
+		}
+		unlocked = append(unlocked, member)
+	}
+
+	if s.throttle != nil {
+		if _, err := s.throttle.Purge(now); err != nil {
+			return unlocked, err
+		}
+	}
+	return unlocked, nil
+}
+
 func (s *AuthService) RefreshToken(refreshToken string) (*entities.AuthResult, error) {
 	// 1. 查找會話
 	session, err := s.authRepo.FindSessionByRefreshToken(refreshToken)
@@ -111,9 +502,12 @@ func (s *AuthService) RefreshToken(refreshToken string) (*entities.AuthResult, e
 
 	_, err = s.ValidateRefreshToken(refreshToken)
 	if err != nil {
-		s.authRepo.InvalidateSession(session.ID)
+		s.revokeSession(session)
 		return nil, errors.New("invalid refresh token")
 	}
+	if session.SessionID == "" || !session.IsActive(time.Now()) {
+		return nil, entities.ErrSessionRevoked
+	}
 
 	// 2. 查找會員
 	member, err := s.memberRepo.FindByID(session.MemberID.String())
@@ -122,7 +516,7 @@ func (s *AuthService) RefreshToken(refreshToken string) (*entities.AuthResult, e
 	}
 
 	// 3. 生成新的 Access Token
-	newAccessToken, err := s.generateAccessToken(member.ID.String(), member.Name.String())
+	newAccessToken, err := s.generateAccessToken(member.ID.String(), member.Name.String(), session.SessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +544,7 @@ func (s *AuthService) RefreshToken(refreshToken string) (*entities.AuthResult, e
 		session.RefreshToken.String(),
 		member,
 		memberRoles,
-		int64(24*time.Hour.Seconds()),
+		int64(s.accessTokenTTL.Seconds()),
 	), nil
 }
 
@@ -160,6 +554,23 @@ func (s *AuthService) Logout(refreshToken string) error {
 		return errors.New("invalid refresh token")
 	}
 
+	return s.revokeSession(session)
+}
+
+// RefreshTokenOwner 返回 refresh token 所屬會話的會員 ID，會話不存在或已撤銷時返回 0
+func (s *AuthService) RefreshTokenOwner(refreshToken string) uint {
+	session, err := s.authRepo.FindSessionByRefreshToken(refreshToken)
+	if err != nil {
+		return 0
+	}
+	return session.MemberID.Value()
+}
+
+// revokeSession 撤銷會話；啟用會話服務時同時更新記憶體中的撤銷紀錄
+func (s *AuthService) revokeSession(session *entities.AuthSession) error {
+	if s.sessions != nil {
+		return s.sessions.RevokeSession(session)
+	}
 	return s.authRepo.InvalidateSession(session.ID)
 }
 
@@ -172,11 +583,16 @@ func (s *AuthService) ValidateToken(tokenString string) (*auth_value_objects.JWT
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*auth_value_objects.JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*auth_value_objects.JWTClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	return nil, errors.New("invalid token")
+	// 會話已登出或被撤銷時立即失效 (不查詢資料庫)
+	if s.sessions != nil && (claims.SessionID == "" || s.sessions.IsRevoked(claims.SessionID)) {
+		return nil, entities.ErrSessionRevoked
+	}
+	return claims, nil
 }
 
 func (s *AuthService) ValidateRefreshToken(refreshToken string) (*auth_value_objects.JWTClaims, error) {
@@ -195,17 +611,19 @@ func (s *AuthService) ValidateRefreshToken(refreshToken string) (*auth_value_obj
 	return nil, errors.New("invalid token")
 }
 
-func (s *AuthService) generateAccessToken(memberID, username string) (string, error) {
-	expirationTime := time.Now().Add(5 * time.Minute)
+func (s *AuthService) generateAccessToken(memberID, username, sessionID string) (string, error) {
+	expirationTime := time.Now().Add(s.accessTokenTTL)
 	claims := s.createJWTClaims(memberID, username, expirationTime)
+	claims.SessionID = sessionID
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.accessTokenSecret))
 }
 
-func (s *AuthService) generateRefreshToken(memberID uint) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+func (s *AuthService) generateRefreshToken(sessionID string) (string, error) {
+	expirationTime := time.Now().Add(s.refreshTokenTTL)
 	claims := s.createJWTClaims("", "", expirationTime)
+	claims.SessionID = sessionID
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	refreshToken, err := token.SignedString([]byte(s.refreshTokenSecret))
 	return refreshToken, err
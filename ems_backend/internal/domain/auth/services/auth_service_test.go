@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+	member_entities "ems_backend/internal/domain/member/entities"
+	member_history_entities "ems_backend/internal/domain/member_history/entities"
+	member_role_entities "ems_backend/internal/domain/member_role/entities"
+)
+
+// MockMemberRoleRepository 模擬會員角色 Repository
+type MockMemberRoleRepository struct{}
+
+func (m *MockMemberRoleRepository) GetByMemberID(memberID uint) ([]*member_role_entities.MemberRole, error) {
+	return nil, nil
+}
+
+type lockoutFixture struct {
+	service *AuthService
+	members *MockMemberRepository
+	history *MockMemberHistoryRepository
+}
+
+func newLockoutFixture(t *testing.T, config LoginThrottleConfig) *lockoutFixture {
+	t.Helper()
+	reset := newResetFixture(t)
+	members := reset.service.memberRepo.(*MockMemberRepository)
+
+	history := &member_history_entities.MemberHistory{MemberID: members.members[1].ID, Salt: "c2FsdA=="}
+	history.Hash = history.HashPassword("correct password", history.Salt)
+	_ = reset.history.Save(history)
+
+	service := NewAuthService(members, reset.history, reset.auth, &MockMemberRoleRepository{}, "access-secret", "refresh-secret")
+	service.SetLoginThrottle(NewLoginThrottleService(NewMockLoginAttemptRepository(), config))
+	return &lockoutFixture{service: service, members: members, history: reset.history}
+}
+
+func (f *lockoutFixture) alice() *member_entities.Member {
+	return f.members.members[1]
+}
+
+func TestAuthService_Login_LocksAfterThreshold(t *testing.T) {
+	tests := []struct {
+		name            string
+		duration        time.Duration
+		wantLockedUntil bool
+	}{
+		{"定時鎖定", 30 * time.Minute, true},
+		{"需管理員解鎖", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLockoutFixture(t, LoginThrottleConfig{
+				FreeAttempts:     100,
+				IPFreeAttempts:   100,
+				LockoutThreshold: 3,
+				LockoutDuration:  tt.duration,
+			})
+
+			for i := 1; i <= 2; i++ {
+				_, err := f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+				var loginErr *LoginError
+				if !errors.As(err, &loginErr) || !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("第 %d 次期望密碼錯誤，得到 %v", i, err)
+				}
+				if loginErr.Failures != i || loginErr.MemberID != 1 {
+					t.Errorf("期望累計 %d 次失敗，得到 %+v", i, loginErr)
+				}
+			}
+
+			_, err := f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+			var loginErr *LoginError
+			if !errors.As(err, &loginErr) || !errors.Is(err, ErrAccountLocked) || !loginErr.Locked {
+				t.Fatalf("達門檻時期望鎖定，得到 %v", err)
+			}
+			if (loginErr.LockedUntil != nil) != tt.wantLockedUntil {
+				t.Errorf("期望 LockedUntil 存在 %v，得到 %v", tt.wantLockedUntil, loginErr.LockedUntil)
+			}
+			if f.alice().LockedAt == nil {
+				t.Errorf("會員應被標記為鎖定")
+			}
+
+			// 鎖定期間正確密碼也無法登入
+			_, err = f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+			if !errors.Is(err, ErrAccountLocked) {
+				t.Errorf("鎖定期間期望 ErrAccountLocked，得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthService_Login_SuccessResetsFailures(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100, LockoutThreshold: 3})
+
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	if _, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("正確密碼應可登入，得到 %v", err)
+	}
+
+	_, err := f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	var loginErr *LoginError
+	if !errors.As(err, &loginErr) || loginErr.Failures != 1 {
+		t.Errorf("登入成功後應重新計算失敗次數，得到 %v", err)
+	}
+}
+
+func TestAuthService_Login_Throttled(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 2, IPFreeAttempts: 100, BaseDelay: time.Minute})
+
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+
+	_, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+	var loginErr *LoginError
+	if !errors.As(err, &loginErr) || !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("期望 ErrTooManyAttempts，得到 %v", err)
+	}
+	if loginErr.RetryAfter <= 0 || loginErr.RetryAfter > time.Minute {
+		t.Errorf("期望 Retry-After 介於 0 與 1 分鐘，得到 %v", loginErr.RetryAfter)
+	}
+}
+
+func TestAuthService_UnlockAndExpire(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100, LockoutThreshold: 1, LockoutDuration: time.Minute})
+
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	if f.alice().LockedAt == nil {
+		t.Fatalf("會員應被鎖定")
+	}
+
+	unlocked, err := f.service.ExpireLockouts(time.Now())
+	if err != nil || len(unlocked) != 0 {
+		t.Fatalf("未到期不應解鎖，得到 %v %v", unlocked, err)
+	}
+	unlocked, err = f.service.ExpireLockouts(time.Now().Add(2 * time.Minute))
+	if err != nil || len(unlocked) != 1 || f.alice().LockedAt != nil {
+		t.Fatalf("到期應自動解鎖，得到 %v %v", unlocked, err)
+	}
+
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	if _, err := f.service.UnlockAccount(1); err != nil {
+		t.Fatalf("管理員解鎖失敗: %v", err)
+	}
+	if f.alice().LockedAt != nil || f.history.histories[0].ErrorCount != 0 {
+		t.Errorf("解鎖後應清除鎖定與失敗次數")
+	}
+	if _, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent"); err != nil {
+		t.Errorf("解鎖後應可登入，得到 %v", err)
+	}
+}
+
+func TestAuthService_Login_MFA(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100, LockoutThreshold: 5})
+	mfaRepo := NewMockMFARepository()
+	roles := &MockRoleRepository{mfaRequired: map[uint]bool{}}
+	mfa, _ := NewMFAService(mfaRepo, f.members, roles, MFAConfig{})
+	f.service.SetMFAService(mfa)
+
+	// 未啟用且角色不要求時直接簽發 token
+	result, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+	if err != nil || result.MFARequired || result.AccessToken == "" {
+		t.Fatalf("未啟用 MFA 應直接登入，得到 %+v %v", result, err)
+	}
+
+	// 角色要求但尚未設定：登入途中完成設定
+	roles.mfaRequired[1] = true
+	result, err = f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+	if err != nil || !result.MFARequired || !result.MFAEnrollmentRequired || result.AccessToken != "" {
+		t.Fatalf("期望要求設定 MFA，得到 %+v %v", result, err)
+	}
+	enrollment, err := mfa.BeginEnrollment(1)
+	if err != nil {
+		t.Fatalf("開始設定失敗: %v", err)
+	}
+	code, _ := TOTPCode(enrollment.Secret, TOTPStep(time.Now().Add(-30*time.Second)))
+	result, err = f.service.CompleteMFALogin(result.MFAChallengeToken, code, "10.0.0.1", "test-agent")
+	if err != nil || result.AccessToken == "" || len(result.RecoveryCodes) != DefaultRecoveryCodeCount {
+		t.Fatalf("完成設定應簽發 token 並返回復原碼，得到 %+v %v", result, err)
+	}
+
+	// 已啟用：密碼正確後需要驗證碼
+	result, err = f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+	if err != nil || !result.MFARequired || result.MFAEnrollmentRequired {
+		t.Fatalf("期望 MFA 挑戰，得到 %+v %v", result, err)
+	}
+	challengeToken := result.MFAChallengeToken
+
+	_, err = f.service.CompleteMFALogin(challengeToken, "000000", "10.0.0.1", "test-agent")
+	var loginErr *LoginError
+	if !errors.As(err, &loginErr) || !errors.Is(err, auth_entities.ErrMFACodeInvalid) || loginErr.Failures != 1 {
+		t.Fatalf("錯誤驗證碼應累計帳號失敗次數，得到 %v", err)
+	}
+
+	code, _ = TOTPCode(enrollment.Secret, TOTPStep(time.Now()))
+	result, err = f.service.CompleteMFALogin(challengeToken, code, "10.0.0.1", "test-agent")
+	if err != nil || result.AccessToken == "" || result.RecoveryCodes != nil {
+		t.Fatalf("正確驗證碼應簽發 token，得到 %+v %v", result, err)
+	}
+	if f.history.histories[0].ErrorCount != 0 {
+		t.Errorf("完成 MFA 後應清除失敗次數")
+	}
+	if _, err := f.service.CompleteMFALogin(challengeToken, code, "10.0.0.1", "test-agent"); !errors.Is(err, auth_entities.ErrMFAChallengeInvalid) {
+		t.Errorf("挑戰只能使用一次，得到 %v", err)
+	}
+}
+
+func TestAuthService_RefreshTokenOwner(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100, LockoutThreshold: 3})
+
+	result, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("正確密碼應可登入，得到 %v", err)
+	}
+
+	if got := f.service.RefreshTokenOwner(result.RefreshToken); got != f.alice().ID.Value() {
+		t.Errorf("期望會員 %d，得到 %d", f.alice().ID.Value(), got)
+	}
+	if got := f.service.RefreshTokenOwner("unknown"); got != 0 {
+		t.Errorf("未知的 refresh token 應返回 0，得到 %d", got)
+	}
+
+	if err := f.service.Logout(result.RefreshToken); err != nil {
+		t.Fatalf("登出失敗: %v", err)
+	}
+	if got := f.service.RefreshTokenOwner(result.RefreshToken); got != 0 {
+		t.Errorf("登出後應返回 0，得到 %d", got)
+	}
+}
+
+func TestAuthService_SetTokenTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		accessTTL   time.Duration
+		refreshTTL  time.Duration
+		wantAccess  time.Duration
+		wantSession time.Duration
+	}{
+		{name: "預設有效期限", wantAccess: defaultAccessTokenTTL, wantSession: defaultRefreshTokenTTL},
+		{name: "自訂有效期限", accessTTL: 15 * time.Minute, refreshTTL: 7 * 24 * time.Hour, wantAccess: 15 * time.Minute, wantSession: 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100, LockoutThreshold: 3})
+			f.service.SetTokenTTL(tt.accessTTL, tt.refreshTTL)
+
+			result, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+			if err != nil {
+				t.Fatalf("正確密碼應可登入，得到 %v", err)
+			}
+			if result.ExpiresIn != int64(tt.wantAccess.Seconds()) {
+				t.Errorf("期望 expires_in 為 %d，得到 %d", int64(tt.wantAccess.Seconds()), result.ExpiresIn)
+			}
+
+			claims, err := f.service.ValidateToken(result.AccessToken)
+			if err != nil {
+				t.Fatalf("access token 應有效，得到 %v", err)
+			}
+			// JWT 時間精度為秒
+			if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got < tt.wantAccess-time.Second || got > tt.wantAccess {
+				t.Errorf("期望 access token 有效 %v，得到 %v", tt.wantAccess, got)
+			}
+
+			session, err := f.service.authRepo.FindSessionByRefreshToken(result.RefreshToken)
+			if err != nil {
+				t.Fatalf("找不到會話: %v", err)
+			}
+			if got := session.ExpiresAt.Sub(session.CreateTime); got != tt.wantSession {
+				t.Errorf("期望會話有效 %v，得到 %v", tt.wantSession, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+)
+
+// LoginThrottleConfig 登入節流與自動鎖定設定
+type LoginThrottleConfig struct {
+	// 每個 email / IP 在統計窗口內可連續失敗的次數，超過後開始要求等待
+	FreeAttempts   int
+	IPFreeAttempts int // 同一 IP 可能有多位使用者，門檻較高
+
+	// 等待時間自 BaseDelay 起每次失敗加倍，最多 MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// 最後一次失敗超過 Window 後統計歸零
+	Window time.Duration
+
+	// 帳號連續失敗 LockoutThreshold 次後鎖定 LockoutDuration (0 表示需管理員解鎖)
+	// LockoutThreshold 為 0 時不鎖定
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// DefaultLoginThrottleConfig 預設登入節流設定
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		FreeAttempts:     3,
+		IPFreeAttempts:   20,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		Window:           15 * time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  30 * time.Minute,
+	}
+}
+
+// LoginThrottleService 依 email 與來源 IP 統計登入失敗並計算漸進等待時間
+type LoginThrottleService struct {
+	attemptRepo auth_repositories.LoginAttemptRepository
+	config      LoginThrottleConfig
+}
+
+// NewLoginThrottleService 創建登入節流服務
+func NewLoginThrottleService(attemptRepo auth_repositories.LoginAttemptRepository, config LoginThrottleConfig) *LoginThrottleService {
+	defaults := DefaultLoginThrottleConfig()
+	if config.FreeAttempts <= 0 {
+		config.FreeAttempts = defaults.FreeAttempts
+	}
+	if config.IPFreeAttempts <= 0 {
+		config.IPFreeAttempts = defaults.IPFreeAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = defaults.BaseDelay
+	}
+	if config.MaxDelay < config.BaseDelay {
+		config.MaxDelay = config.BaseDelay
+	}
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	return &LoginThrottleService{attemptRepo: attemptRepo, config: config}
+}
+
+// Config 返回生效中的設定
+func (s *LoginThrottleService) Config() LoginThrottleConfig {
+	return s.config
+}
+
+// Delay 連續失敗 failures 次後下一次嘗試前需等待的時間
+func (s *LoginThrottleService) Delay(failures, freeAttempts int) time.Duration {
+	if failures < freeAttempts {
+		return 0
+	}
+	delay := s.config.BaseDelay
+	for i := freeAttempts; i < failures; i++ {
+		delay *= 2
+		if delay >= s.config.MaxDelay {
+			return s.config.MaxDelay
+		}
+	}
+	return delay
+}
+
+// Check 返回還需等待多久才能再嘗試登入，0 表示可立即嘗試
+func (s *LoginThrottleService) Check(email, ip string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	for _, k := range s.keys(email, ip) {
+		attempt, err := s.attemptRepo.Find(k.scope, k.key)
+		if err != nil {
+			return 0, err
+		}
+		if attempt == nil || now.Sub(attempt.LastFailureAt) >= s.config.Window {
+			continue
+		}
+		if remaining := attempt.LastFailureAt.Add(s.Delay(attempt.Failures, k.free)).Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure 記錄一次失敗
+func (s *LoginThrottleService) RecordFailure(email, ip string, now time.Time) error {
+	for _, k := range s.keys(email, ip) {
+		if _, err := s.attemptRepo.RecordFailure(k.scope, k.key, now, now.Add(-s.config.Window)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordSuccess 登入成功後清除該 email 的統計 (IP 統計保留，避免以自己的帳號洗掉)
+func (s *LoginThrottleService) RecordSuccess(email string) error {
+	return s.attemptRepo.Reset(entities.LoginAttemptScopeEmail, normalizeEmailKey(email))
+}
+
+// Purge 刪除已超過統計窗口的紀錄
+func (s *LoginThrottleService) Purge(now time.Time) (int64, error) {
+	return s.attemptRepo.DeleteBefore(now.Add(-s.config.Window))
+}
+
+type throttleKey struct {
+	scope string
+	key   string
+	free  int
+}
+
+func (s *LoginThrottleService) keys(email, ip string) []throttleKey {
+	keys := make([]throttleKey, 0, 2)
+	if email = normalizeEmailKey(email); email != "" {
+		keys = append(keys, throttleKey{entities.LoginAttemptScopeEmail, email, s.config.FreeAttempts})
+	}
+	if ip != "" {
+		keys = append(keys, throttleKey{entities.LoginAttemptScopeIP, ip, s.config.IPFreeAttempts})
+	}
+	return keys
+}
+
+func normalizeEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
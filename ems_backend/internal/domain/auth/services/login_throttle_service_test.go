@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+)
+
+// MockLoginAttemptRepository 模擬登入失敗統計 Repository
+type MockLoginAttemptRepository struct {
+	attempts map[string]*auth_entities.LoginAttempt
+}
+
+func NewMockLoginAttemptRepository() *MockLoginAttemptRepository {
+	return &MockLoginAttemptRepository{attempts: make(map[string]*auth_entities.LoginAttempt)}
+}
+
+func (m *MockLoginAttemptRepository) Find(scope, key string) (*auth_entities.LoginAttempt, error) {
+	return m.attempts[scope+"|"+key], nil
+}
+
+func (m *MockLoginAttemptRepository) RecordFailure(scope, key string, now, windowStart time.Time) (*auth_entities.LoginAttempt, error) {
+	attempt, ok := m.attempts[scope+"|"+key]
+	if !ok || attempt.LastFailureAt.Before(windowStart) {
+		attempt = &auth_entities.LoginAttempt{Scope: scope, Key: key}
+		m.attempts[scope+"|"+key] = attempt
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	return attempt, nil
+}
+
+func (m *MockLoginAttemptRepository) Reset(scope, key string) error {
+	delete(m.attempts, scope+"|"+key)
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	var n int64
+	for k, attempt := range m.attempts {
+		if attempt.LastFailureAt.Before(cutoff) {
+			delete(m.attempts, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestLoginThrottleService_Delay(t *testing.T) {
+	service := NewLoginThrottleService(NewMockLoginAttemptRepository(), LoginThrottleConfig{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+	})
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"未達免等待次數", 2, 0},
+		{"剛達免等待次數", 3, time.Second},
+		{"每次失敗加倍", 5, 4 * time.Second},
+		{"不超過上限", 10, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.Delay(tt.failures, 3); got != tt.want {
+				t.Errorf("期望等待 %v，得到 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleService_Check(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	config := LoginThrottleConfig{
+		FreeAttempts:   2,
+		IPFreeAttempts: 5,
+		BaseDelay:      time.Second,
+		MaxDelay:       time.Minute,
+		Window:         15 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		failures []string // 依序失敗的 email (同一 IP)
+		email    string
+		at       time.Duration // 最後一次失敗後經過的時間
+		want     time.Duration
+	}{
+		{"沒有失敗紀錄", nil, "alice@example.com", 0, 0},
+		{"email 超過免等待次數", []string{"alice@example.com", "alice@example.com", "alice@example.com"}, "alice@example.com", 0, 2 * time.Second},
+		{"email 大小寫視為相同", []string{"Alice@Example.com", "alice@example.com", "ALICE@example.com"}, "alice@example.com", 0, 2 * time.Second},
+		{"等待時間隨時間遞減", []string{"alice@example.com", "alice@example.com", "alice@example.com"}, "alice@example.com", 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"超過統計窗口不再等待", []string{"alice@example.com", "alice@example.com", "alice@example.com"}, "alice@example.com", 20 * time.Minute, 0},
+		{"其他帳號只受 IP 門檻限制", []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}, "f@example.com", 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewLoginThrottleService(NewMockLoginAttemptRepository(), config)
+			for _, email := range tt.failures {
+				if err := service.RecordFailure(email, "10.0.0.1", now); err != nil {
+					t.Fatalf("記錄失敗錯誤: %v", err)
+				}
+			}
+
+			wait, err := service.Check(tt.email, "10.0.0.1", now.Add(tt.at))
+			if err != nil {
+				t.Fatalf("檢查錯誤: %v", err)
+			}
+			if wait != tt.want {
+				t.Errorf("期望等待 %v，得到 %v", tt.want, wait)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleService_RecordSuccess(t *testing.T) {
+	now := time.Now()
+	repo := NewMockLoginAttemptRepository()
+	service := NewLoginThrottleService(repo, LoginThrottleConfig{FreeAttempts: 1, IPFreeAttempts: 1})
+
+	_ = service.RecordFailure("alice@example.com", "10.0.0.1", now)
+	if err := service.RecordSuccess("alice@example.com"); err != nil {
+		t.Fatalf("清除失敗錯誤: %v", err)
+	}
+
+	if a, _ := repo.Find(auth_entities.LoginAttemptScopeEmail, "alice@example.com"); a != nil {
+		t.Errorf("登入成功後應清除 email 統計")
+	}
+	if a, _ := repo.Find(auth_entities.LoginAttemptScopeIP, "10.0.0.1"); a == nil {
+		t.Errorf("登入成功不應清除 IP 統計")
+	}
+}
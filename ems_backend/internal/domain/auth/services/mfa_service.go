@@ -0,0 +1,451 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+	"ems_backend/internal/domain/member/repositories"
+	role_repositories "ems_backend/internal/domain/role/repositories"
+)
+
+// MFA 預設值
+const (
+	DefaultMFAIssuer            = "EMS"
+	DefaultMFAChallengeTTL      = 5 * time.Minute
+	DefaultMFAChallengeAttempts = 5
+	DefaultRecoveryCodeCount    = 10
+	encryptedSecretPrefix       = "enc:"
+)
+
+// MFAConfig 多因素驗證設定
+type MFAConfig struct {
+	Issuer               string        // 顯示在驗證器 App 中的發行者名稱
+	ChallengeTTL         time.Duration // 密碼驗證後完成 MFA 的期限
+	MaxChallengeAttempts int           // 同一挑戰可輸入錯誤的次數
+	RecoveryCodeCount    int
+	EncryptionKey        string // 非空時以 AES-GCM 加密保存 TOTP 密鑰
+}
+
+// MFAEnrollment 開始設定時返回的密鑰與 otpauth URI
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus 會員的 MFA 狀態
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Pending                bool       `json:"pending"`  // 已產生密鑰但尚未確認
+	Required               bool       `json:"required"` // 角色政策要求
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAService TOTP (RFC 6238) 多因素驗證領域服務
+type MFAService struct {
+	mfaRepo    auth_repositories.MFARepository
+	memberRepo repositories.MemberRepository
+	roleRepo   role_repositories.RoleRepository
+	config     MFAConfig
+	gcm        cipher.AEAD
+	now        func() time.Time
+}
+
+// NewMFAService 創建多因素驗證領域服務
+func NewMFAService(
+	mfaRepo auth_repositories.MFARepository,
+	memberRepo repositories.MemberRepository,
+	roleRepo role_repositories.RoleRepository,
+	config MFAConfig,
+) (*MFAService, error) {
+	if config.Issuer == "" {
+		config.Issuer = DefaultMFAIssuer
+	}
+	if config.ChallengeTTL <= 0 {
+		config.ChallengeTTL = DefaultMFAChallengeTTL
+	}
+	if config.MaxChallengeAttempts <= 0 {
+		config.MaxChallengeAttempts = DefaultMFAChallengeAttempts
+	}
+	if config.RecoveryCodeCount <= 0 {
+		config.RecoveryCodeCount = DefaultRecoveryCodeCount
+	}
+
+	s := &MFAService{
+		mfaRepo:    mfaRepo,
+		memberRepo: memberRepo,
+		roleRepo:   roleRepo,
+		config:     config,
+		now:        time.Now,
+	}
+	if config.EncryptionKey != "" {
+		key := sha256.Sum256([]byte(config.EncryptionKey))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+		if s.gcm, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ChallengeTTL 挑戰有效期限
+func (s *MFAService) ChallengeTTL() time.Duration {
+	return s.config.ChallengeTTL
+}
+
+// RequiredByRole 會員的任一啟用中角色要求 MFA
+func (s *MFAService) RequiredByRole(memberID uint) (bool, error) {
+	roles, err := s.roleRepo.GetByMemberID(memberID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load member roles: %w", err)
+	}
+	for _, role := range roles {
+		if role.MFARequired {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LoginRequirement 登入時是否需要 MFA 挑戰，以及是否尚未完成設定
+func (s *MFAService) LoginRequirement(memberID uint) (challenge bool, enrollmentRequired bool, err error) {
+	mfa, err := s.find(memberID)
+	if err != nil {
+		return false, false, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return true, false, nil
+	}
+	required, err := s.RequiredByRole(memberID)
+	if err != nil {
+		return false, false, err
+	}
+	return required, required, nil
+}
+
+// Status 返回會員的 MFA 狀態
+func (s *MFAService) Status(memberID uint) (*MFAStatus, error) {
+	mfa, err := s.find(memberID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.RequiredByRole(memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Required: required}
+	if mfa == nil {
+		return status, nil
+	}
+	status.Enabled = mfa.Enabled
+	status.EnabledAt = mfa.EnabledAt
+	status.Pending = !mfa.Enabled
+	if mfa.Enabled {
+		if status.RecoveryCodesRemaining, err = s.mfaRepo.CountRecoveryCodes(memberID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// BeginEnrollment 產生新的密鑰 (尚未啟用)，重複呼叫會取代未確認的密鑰
+func (s *MFAService) BeginEnrollment(memberID uint) (*MFAEnrollment, error) {
+	existing, err := s.find(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, entities.ErrMFAAlreadyEnabled
+	}
+
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", memberID))
+	if err != nil || member == nil {
+		return nil, errors.New("member not found")
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	if err := s.save(&entities.MemberMFA{MemberID: memberID, Secret: secret, CreateTime: s.now()}); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(s.config.Issuer, member.Email.String(), secret),
+	}, nil
+}
+
+// Activate 以驗證碼確認設定並啟用 MFA，返回一次性顯示的復原碼
+func (s *MFAService) Activate(memberID uint, code string) ([]string, error) {
+	mfa, err := s.find(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, entities.ErrMFANotEnrolled
+	}
+	if mfa.Enabled {
+		return nil, entities.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := VerifyTOTP(mfa.Secret, code, s.now())
+	if !ok {
+		return nil, entities.ErrMFACodeInvalid
+	}
+
+	now := s.now()
+	mfa.Enabled = true
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = step
+	if err := s.save(mfa); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(memberID)
+}
+
+// Verify 驗證已啟用會員的 TOTP 或復原碼 (復原碼使用後即失效)
+func (s *MFAService) Verify(memberID uint, code string) error {
+	mfa, err := s.find(memberID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return entities.ErrMFANotEnrolled
+	}
+
+	normalized := normalizeMFACode(code)
+	if len(normalized) == totpDigits {
+		step, ok := VerifyTOTP(mfa.Secret, normalized, s.now())
+		if !ok || step <= mfa.LastUsedStep {
+			return entities.ErrMFACodeInvalid
+		}
+		advanced, err := s.mfaRepo.AdvanceLastUsedStep(memberID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return entities.ErrMFACodeInvalid
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(memberID, sha256Hex(normalized), s.now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return entities.ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 驗證後重新產生復原碼 (舊的全部失效)
+func (s *MFAService) RegenerateRecoveryCodes(memberID uint, code string) ([]string, error) {
+	if err := s.Verify(memberID, code); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(memberID)
+}
+
+// Disable 會員自行停用 MFA，角色政策要求時不允許
+func (s *MFAService) Disable(memberID uint, code string) error {
+	required, err := s.RequiredByRole(memberID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return entities.ErrMFARequiredByRole
+	}
+	if err := s.Verify(memberID, code); err != nil {
+		return err
+	}
+	return s.mfaRepo.Delete(memberID)
+}
+
+// Reset 管理員清除會員的 MFA (遺失裝置時)，角色要求時下次登入需重新設定
+func (s *MFAService) Reset(memberID uint) error {
+	return s.mfaRepo.Delete(memberID)
+}
+
+// IssueChallenge 密碼驗證通過後簽發挑戰憑證
+func (s *MFAService) IssueChallenge(memberID uint) (string, error) {
+	now := s.now()
+	if _, err := s.mfaRepo.DeleteExpiredChallenges(now); err != nil {
+		return "", fmt.Errorf("failed to purge expired MFA challenges: %w", err)
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate MFA challenge: %w", err)
+	}
+	challenge := &entities.MFAChallenge{
+		MemberID:   memberID,
+		TokenHash:  sha256Hex(token),
+		ExpiresAt:  now.Add(s.config.ChallengeTTL),
+		CreateTime: now,
+	}
+	if err := s.mfaRepo.CreateChallenge(challenge); err != nil {
+		return "", fmt.Errorf("failed to save MFA challenge: %w", err)
+	}
+	return token, nil
+}
+
+// ResolveChallenge 查找仍有效的挑戰
+func (s *MFAService) ResolveChallenge(token string) (*entities.MFAChallenge, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, entities.ErrMFAChallengeInvalid
+	}
+	challenge, err := s.mfaRepo.FindChallenge(sha256Hex(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find MFA challenge: %w", err)
+	}
+	if challenge == nil {
+		return nil, entities.ErrMFAChallengeInvalid
+	}
+	if challenge.IsExpired(s.now()) || challenge.Attempts >= s.config.MaxChallengeAttempts {
+		_, _ = s.mfaRepo.DeleteChallenge(challenge.ID)
+		return nil, entities.ErrMFAChallengeExpired
+	}
+	return challenge, nil
+}
+
+// FailChallenge 記錄一次錯誤，達上限後挑戰作廢需重新登入
+func (s *MFAService) FailChallenge(challenge *entities.MFAChallenge) error {
+	attempts, err := s.mfaRepo.IncrementChallengeAttempts(challenge.ID)
+	if err != nil {
+		return err
+	}
+	if attempts >= s.config.MaxChallengeAttempts {
+		_, err = s.mfaRepo.DeleteChallenge(challenge.ID)
+	}
+	return err
+}
+
+// ConsumeChallenge 完成驗證後作廢挑戰，同一挑戰並發使用時只有一方成功
+func (s *MFAService) ConsumeChallenge(challenge *entities.MFAChallenge) error {
+	deleted, err := s.mfaRepo.DeleteChallenge(challenge.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return entities.ErrMFAChallengeInvalid
+	}
+	return nil
+}
+
+// IsEnabled 會員是否已啟用 MFA
+func (s *MFAService) IsEnabled(memberID uint) (bool, error) {
+	mfa, err := s.find(memberID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.Enabled, nil
+}
+
+func (s *MFAService) issueRecoveryCodes(memberID uint) ([]string, error) {
+	codes := make([]string, s.config.RecoveryCodeCount)
+	hashes := make([]string, s.config.RecoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = sha256Hex(raw)
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(memberID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func (s *MFAService) find(memberID uint) (*entities.MemberMFA, error) {
+	mfa, err := s.mfaRepo.FindByMemberID(memberID)
+	if err != nil || mfa == nil {
+		return nil, err
+	}
+	if mfa.Secret, err = s.decryptSecret(mfa.Secret); err != nil {
+		return nil, err
+	}
+	return mfa, nil
+}
+
+func (s *MFAService) save(mfa *entities.MemberMFA) error {
+	stored := *mfa
+	var err error
+	if stored.Secret, err = s.encryptSecret(mfa.Secret); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Save(&stored); err != nil {
+		return fmt.Errorf("failed to save MFA settings: %w", err)
+	}
+	return nil
+}
+
+func (s *MFAService) encryptSecret(secret string) (string, error) {
+	if s.gcm == nil {
+		return secret, nil
+	}
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *MFAService) decryptSecret(stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedSecretPrefix) {
+		return stored, nil
+	}
+	if s.gcm == nil {
+		return "", errors.New("MFA secret is encrypted but no encryption key is configured")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSecretPrefix))
+	if err != nil || len(sealed) < s.gcm.NonceSize() {
+		return "", errors.New("malformed encrypted MFA secret")
+	}
+	nonce, ciphertext := sealed[:s.gcm.NonceSize()], sealed[s.gcm.NonceSize():]
+	plain, err := s.gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt MFA secret (wrong MFA_ENCRYPTION_KEY?)")
+	}
+	return string(plain), nil
+}
+
+// normalizeMFACode 去除空白與連字號，復原碼不分大小寫
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+	role_entities "ems_backend/internal/domain/role/entities"
+)
+
+// MockMFARepository 模擬 MFA Repository
+type MockMFARepository struct {
+	settings   map[uint]*auth_entities.MemberMFA
+	codes      map[uint]map[string]bool // hash -> 已使用
+	challenges map[uint]*auth_entities.MFAChallenge
+	nextID     uint
+}
+
+func NewMockMFARepository() *MockMFARepository {
+	return &MockMFARepository{
+		settings:   make(map[uint]*auth_entities.MemberMFA),
+		codes:      make(map[uint]map[string]bool),
+		challenges: make(map[uint]*auth_entities.MFAChallenge),
+		nextID:     1,
+	}
+}
+
+func (m *MockMFARepository) FindByMemberID(memberID uint) (*auth_entities.MemberMFA, error) {
+	if mfa, ok := m.settings[memberID]; ok {
+		copied := *mfa
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *MockMFARepository) Save(mfa *auth_entities.MemberMFA) error {
+	copied := *mfa
+	m.settings[mfa.MemberID] = &copied
+	return nil
+}
+
+func (m *MockMFARepository) AdvanceLastUsedStep(memberID uint, step int64) (bool, error) {
+	mfa, ok := m.settings[memberID]
+	if !ok || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	return true, nil
+}
+
+func (m *MockMFARepository) Delete(memberID uint) error {
+	delete(m.settings, memberID)
+	delete(m.codes, memberID)
+	return nil
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(memberID uint, codeHashes []string) error {
+	m.codes[memberID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		m.codes[memberID][hash] = false
+	}
+	return nil
+}
+
+func (m *MockMFARepository) UseRecoveryCode(memberID uint, codeHash string, now time.Time) (bool, error) {
+	used, ok := m.codes[memberID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.codes[memberID][codeHash] = true
+	return true, nil
+}
+
+func (m *MockMFARepository) CountRecoveryCodes(memberID uint) (int, error) {
+	n := 0
+	for _, used := range m.codes[memberID] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockMFARepository) CreateChallenge(challenge *auth_entities.MFAChallenge) error {
+	challenge.ID = m.nextID
+	m.nextID++
+	m.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (m *MockMFARepository) FindChallenge(tokenHash string) (*auth_entities.MFAChallenge, error) {
+	for _, challenge := range m.challenges {
+		if challenge.TokenHash == tokenHash {
+			return challenge, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockMFARepository) IncrementChallengeAttempts(id uint) (int, error) {
+	m.challenges[id].Attempts++
+	return m.challenges[id].Attempts, nil
+}
+
+func (m *MockMFARepository) DeleteChallenge(id uint) (bool, error) {
+	if _, ok := m.challenges[id]; !ok {
+		return false, nil
+	}
+	delete(m.challenges, id)
+	return true, nil
+}
+
+func (m *MockMFARepository) DeleteExpiredChallenges(now time.Time) (int64, error) {
+	var n int64
+	for id, challenge := range m.challenges {
+		if challenge.IsExpired(now) {
+			delete(m.challenges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MockRoleRepository 模擬角色 Repository (只實作 MFA 政策與 API Key 會用到的操作)
+type MockRoleRepository struct {
+	mfaRequired map[uint]bool                // memberID -> 角色是否要求 MFA
+	roles       map[uint]*role_entities.Role // roleID -> 角色
+	assigned    map[uint][]uint              // roleID -> 被指派的 memberID
+	powers      map[uint][]uint              // roleID -> 權限 ID
+	memberRoles map[uint][]uint              // memberID -> 擁有的角色 ID；nil 時返回要求 MFA 與否的預設角色
+}
+
+func (m *MockRoleRepository) GetByMemberID(memberID uint) ([]*role_entities.Role, error) {
+	if m.memberRoles == nil {
+		return []*role_entities.Role{{ID: 1, Title: "role", IsEnable: true, MFARequired: m.mfaRequired[memberID]}}, nil
+	}
+	var roles []*role_entities.Role
+	for _, roleID := range m.memberRoles[memberID] {
+		roles = append(roles, m.roles[roleID])
+	}
+	return roles, nil
+}
+func (m *MockRoleRepository) GetAll() ([]*role_entities.Role, error) { return nil, nil }
+func (m *MockRoleRepository) GetByID(id uint) (*role_entities.Role, error) {
+	if role, ok := m.roles[id]; ok {
+		return role, nil
+	}
+	return nil, errors.New("record not found")
+}
+func (m *MockRoleRepository) Create(role *role_entities.Role, memberID uint) error { return nil }
+func (m *MockRoleRepository) Update(role *role_entities.Role, memberID uint) error { return nil }
+func (m *MockRoleRepository) Delete(id uint) error                                 { return nil }
+func (m *MockRoleRepository) AssignPowers(roleID uint, powerIDs []uint, memberID uint) error {
+	return nil
+}
+func (m *MockRoleRepository) RemovePowers(roleID uint, powerIDs []uint) error { return nil }
+func (m *MockRoleRepository) AssignMembers(roleID uint, memberIDs []uint, memberID uint) error {
+	if m.assigned == nil {
+		m.assigned = map[uint][]uint{}
+	}
+	m.assigned[roleID] = append(m.assigned[roleID], memberIDs...)
+	return nil
+}
+func (m *MockRoleRepository) RemoveMembers(roleID uint, memberIDs []uint) error {
+	if m.assigned == nil {
+		return nil
+	}
+	remaining := m.assigned[roleID][:0]
+	for _, assigned := range m.assigned[roleID] {
+		removed := false
+		for _, id := range memberIDs {
+			removed = removed || id == assigned
+		}
+		if !removed {
+			remaining = append(remaining, assigned)
+		}
+	}
+	m.assigned[roleID] = remaining
+	return nil
+}
+func (m *MockRoleRepository) GetRoleMembers(roleID uint) ([]uint, error) { return nil, nil }
+func (m *MockRoleRepository) GetRolePowers(roleID uint) ([]uint, error) {
+	return m.powers[roleID], nil
+}
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// RFC 6238 附錄 B 的 SHA1 測試向量 (取後 6 位)
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("計算失敗: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("T=%d 期望 %s，得到 %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestVerifyTOTP_Skew(t *testing.T) {
+	secret, _ := GenerateTOTPSecret()
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   bool
+	}{
+		{"當前時間步", 0, true},
+		{"前一個時間步", -30 * time.Second, true},
+		{"後一個時間步", 30 * time.Second, true},
+		{"超出容許誤差", -90 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := TOTPCode(secret, TOTPStep(now.Add(tt.offset)))
+			if _, ok := VerifyTOTP(secret, code, now); ok != tt.want {
+				t.Errorf("期望 %v，得到 %v", tt.want, ok)
+			}
+		})
+	}
+}
+
+type mfaFixture struct {
+	service *MFAService
+	repo    *MockMFARepository
+	roles   *MockRoleRepository
+	now     time.Time
+}
+
+func newMFAFixture(t *testing.T, encryptionKey string) *mfaFixture {
+	t.Helper()
+	reset := newResetFixture(t)
+	f := &mfaFixture{
+		repo:  NewMockMFARepository(),
+		roles: &MockRoleRepository{mfaRequired: map[uint]bool{}},
+		now:   time.Unix(1700000000, 0),
+	}
+	service, err := NewMFAService(f.repo, reset.service.memberRepo, f.roles, MFAConfig{EncryptionKey: encryptionKey})
+	if err != nil {
+		t.Fatalf("建立 MFA 服務失敗: %v", err)
+	}
+	service.now = func() time.Time { return f.now }
+	f.service = service
+	return f
+}
+
+// enable 為會員 1 完成設定，返回密鑰與復原碼
+func (f *mfaFixture) enable(t *testing.T) (string, []string) {
+	t.Helper()
+	enrollment, err := f.service.BeginEnrollment(1)
+	if err != nil {
+		t.Fatalf("開始設定失敗: %v", err)
+	}
+	code, _ := TOTPCode(enrollment.Secret, TOTPStep(f.now))
+	codes, err := f.service.Activate(1, code)
+	if err != nil {
+		t.Fatalf("啟用失敗: %v", err)
+	}
+	f.now = f.now.Add(30 * time.Second)
+	return enrollment.Secret, codes
+}
+
+func TestMFAService_Enrollment(t *testing.T) {
+	f := newMFAFixture(t, "test-key")
+
+	enrollment, err := f.service.BeginEnrollment(1)
+	if err != nil {
+		t.Fatalf("開始設定失敗: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/EMS:alice@example.com?") ||
+		!strings.Contains(enrollment.ProvisioningURI, "secret="+enrollment.Secret) {
+		t.Errorf("otpauth URI 格式錯誤: %s", enrollment.ProvisioningURI)
+	}
+	if stored := f.repo.settings[1].Secret; !strings.HasPrefix(stored, encryptedSecretPrefix) || strings.Contains(stored, enrollment.Secret) {
+		t.Errorf("設定加密金鑰時應加密保存密鑰，得到 %q", stored)
+	}
+
+	if _, err := f.service.Activate(1, "000000"); !errors.Is(err, auth_entities.ErrMFACodeInvalid) {
+		t.Fatalf("錯誤驗證碼應被拒絕，得到 %v", err)
+	}
+	code, _ := TOTPCode(enrollment.Secret, TOTPStep(f.now))
+	codes, err := f.service.Activate(1, code)
+	if err != nil {
+		t.Fatalf("啟用失敗: %v", err)
+	}
+	if len(codes) != DefaultRecoveryCodeCount {
+		t.Errorf("期望 %d 組復原碼，得到 %d", DefaultRecoveryCodeCount, len(codes))
+	}
+
+	if _, err := f.service.BeginEnrollment(1); !errors.Is(err, auth_entities.ErrMFAAlreadyEnabled) {
+		t.Errorf("已啟用時不應重新產生密鑰，得到 %v", err)
+	}
+	status, _ := f.service.Status(1)
+	if !status.Enabled || status.RecoveryCodesRemaining != DefaultRecoveryCodeCount {
+		t.Errorf("狀態錯誤: %+v", status)
+	}
+}
+
+func TestMFAService_Verify(t *testing.T) {
+	f := newMFAFixture(t, "")
+	secret, recoveryCodes := f.enable(t)
+	current, _ := TOTPCode(secret, TOTPStep(f.now))
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"正確的 TOTP", current, nil},
+		{"同一驗證碼不可重放", current, auth_entities.ErrMFACodeInvalid},
+		{"錯誤的 TOTP", "123456", auth_entities.ErrMFACodeInvalid},
+		{"復原碼 (不分大小寫)", strings.ToUpper(recoveryCodes[0]), nil},
+		{"復原碼只能使用一次", recoveryCodes[0], auth_entities.ErrMFACodeInvalid},
+		{"不含連字號的復原碼", strings.ReplaceAll(recoveryCodes[1], "-", ""), nil},
+		{"未知的復原碼", "aaaaa-bbbbb", auth_entities.ErrMFACodeInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.service.Verify(1, tt.code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if n, _ := f.repo.CountRecoveryCodes(1); n != DefaultRecoveryCodeCount-2 {
+		t.Errorf("期望剩餘 %d 組復原碼，得到 %d", DefaultRecoveryCodeCount-2, n)
+	}
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	tests := []struct {
+		name        string
+		mfaRequired bool
+		wantErr     error
+	}{
+		{"可自行停用", false, nil},
+		{"角色要求時不可停用", true, auth_entities.ErrMFARequiredByRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMFAFixture(t, "")
+			secret, _ := f.enable(t)
+			f.roles.mfaRequired[1] = tt.mfaRequired
+
+			code, _ := TOTPCode(secret, TOTPStep(f.now))
+			if err := f.service.Disable(1, code); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+			enabled, _ := f.service.IsEnabled(1)
+			if enabled != (tt.wantErr != nil) {
+				t.Errorf("停用後狀態錯誤，enabled = %v", enabled)
+			}
+		})
+	}
+}
+
+func TestMFAService_Challenge(t *testing.T) {
+	f := newMFAFixture(t, "")
+
+	token, err := f.service.IssueChallenge(1)
+	if err != nil {
+		t.Fatalf("簽發挑戰失敗: %v", err)
+	}
+	challenge, err := f.service.ResolveChallenge(token)
+	if err != nil || challenge.MemberID != 1 {
+		t.Fatalf("查找挑戰失敗: %v", err)
+	}
+
+	for i := 0; i < DefaultMFAChallengeAttempts; i++ {
+		_ = f.service.FailChallenge(challenge)
+	}
+	if _, err := f.service.ResolveChallenge(token); !errors.Is(err, auth_entities.ErrMFAChallengeInvalid) {
+		t.Errorf("錯誤次數達上限後挑戰應作廢，得到 %v", err)
+	}
+
+	token, _ = f.service.IssueChallenge(1)
+	f.now = f.now.Add(DefaultMFAChallengeTTL)
+	if _, err := f.service.ResolveChallenge(token); !errors.Is(err, auth_entities.ErrMFAChallengeExpired) {
+		t.Errorf("過期挑戰應被拒絕，得到 %v", err)
+	}
+}
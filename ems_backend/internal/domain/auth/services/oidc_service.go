@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+	company_entities "ems_backend/internal/domain/company/entities"
+	company_repositories "ems_backend/internal/domain/company/repositories"
+	member_entities "ems_backend/internal/domain/member/entities"
+	"ems_backend/internal/domain/member/repositories"
+	role_repositories "ems_backend/internal/domain/role/repositories"
+)
+
+// OIDC 預設值
+const (
+	DefaultOIDCStateTTL  = 10 * time.Minute
+	oidcStateSize        = 32 // bytes
+	oidcNonceSize        = 16
+	oidcCodeVerifierSize = 32 // base64url 後 43 字元 (RFC 7636 下限)
+	oidcNameMaxLength    = 20 // 與會員名稱規則一致
+)
+
+var invalidMemberNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// OIDCClient 與身分提供者通訊 (discovery、JWKS 與 ID token 驗證由實作負責)
+type OIDCClient interface {
+	// AuthorizationURL 產生授權碼流程的登入網址 (S256 code_challenge)
+	AuthorizationURL(state, nonce, codeChallenge string) (string, error)
+
+	// Exchange 以授權碼與 code_verifier 換取並驗證 ID token (含 nonce)，返回宣告
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*entities.OIDCClaims, error)
+}
+
+// OIDCClaimMapping 宣告值對應到角色或公司；宣告可為字串或字串陣列 (例如 groups)
+type OIDCClaimMapping struct {
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+	ID    uint   `json:"id"` // role_id 或 company_id
+}
+
+// OIDCProviderConfig 身分提供者的帳號建立與對應規則
+type OIDCProviderConfig struct {
+	ID   string
+	Name string
+
+	AutoProvision  bool     // 沒有連結的會員時自動建立 (無本地密碼)
+	LinkByEmail    bool     // 以已驗證的 email 連結既有會員
+	AllowedDomains []string // email 網域白名單，空白表示不限制
+
+	DefaultRoleIDs  []uint // 一律給予的角色
+	RoleMappings    []OIDCClaimMapping
+	CompanyMappings []OIDCClaimMapping
+	SyncRoles       bool // 每次登入移除不再對應的角色
+	SyncCompanies   bool // 每次登入移除不再對應的公司
+}
+
+// OIDCConfig 單一登入設定
+type OIDCConfig struct {
+	StateTTL time.Duration
+}
+
+type oidcProvider struct {
+	config OIDCProviderConfig
+	client OIDCClient
+}
+
+// OIDCService OpenID Connect 單一登入 (授權碼 + PKCE)、即時建立會員與宣告對應
+type OIDCService struct {
+	stateRepo         auth_repositories.OIDCStateRepository
+	identityRepo      auth_repositories.OIDCIdentityRepository
+	memberRepo        repositories.MemberRepository
+	roleRepo          role_repositories.RoleRepository
+	companyMemberRepo company_repositories.CompanyMemberRepository
+	providers         map[string]*oidcProvider
+	order             []string
+	config            OIDCConfig
+	now               func() time.Time
+}
+
+// NewOIDCService 創建單一登入服務，再以 RegisterProvider 加入身分提供者
+func NewOIDCService(
+	stateRepo auth_repositories.OIDCStateRepository,
+	identityRepo auth_repositories.OIDCIdentityRepository,
+	memberRepo repositories.MemberRepository,
+	roleRepo role_repositories.RoleRepository,
+	companyMemberRepo company_repositories.CompanyMemberRepository,
+	config OIDCConfig,
+) *OIDCService {
+	if config.StateTTL <= 0 {
+		config.StateTTL = DefaultOIDCStateTTL
+	}
+	return &OIDCService{
+		stateRepo:         stateRepo,
+		identityRepo:      identityRepo,
+		memberRepo:        memberRepo,
+		roleRepo:          roleRepo,
+		companyMemberRepo: companyMemberRepo,
+		providers:         make(map[string]*oidcProvider),
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+// RegisterProvider 加入身分提供者
+func (s *OIDCService) RegisterProvider(config OIDCProviderConfig, client OIDCClient) error {
+	if config.ID == "" || client == nil {
+		return fmt.Errorf("identity provider id and client are required")
+	}
+	if _, exists := s.providers[config.ID]; exists {
+		return fmt.Errorf("duplicate identity provider %q", config.ID)
+	}
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+	s.providers[config.ID] = &oidcProvider{config: config, client: client}
+	s.order = append(s.order, config.ID)
+	return nil
+}
+
+// Providers 已設定的身分提供者 (依註冊順序)
+func (s *OIDCService) Providers() []OIDCProviderConfig {
+	providers := make([]OIDCProviderConfig, len(s.order))
+	for i, id := range s.order {
+		providers[i] = s.providers[id].config
+	}
+	return providers
+}
+
+// BeginLogin 建立 state / nonce / PKCE 並返回身分提供者的登入網址
+func (s *OIDCService) BeginLogin(providerID, redirectPath string) (string, error) {
+	provider, ok := s.providers[providerID]
+	if !ok {
+		return "", entities.ErrOIDCProviderNotFound
+	}
+	redirectPath = strings.TrimSpace(redirectPath)
+	if !validRedirectPath(redirectPath) {
+		return "", ErrInvalidRedirectPath
+	}
+
+	now := s.now()
+	if _, err := s.stateRepo.DeleteExpired(now); err != nil {
+		return "", fmt.Errorf("failed to purge expired sso states: %w", err)
+	}
+
+	state, err := randomToken(oidcStateSize)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(oidcNonceSize)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken(oidcCodeVerifierSize)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.stateRepo.Create(&entities.OIDCLoginState{
+		StateHash:    sha256Hex(state),
+		Provider:     providerID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectPath: redirectPath,
+		ExpiresAt:    now.Add(s.config.StateTTL),
+		CreateTime:   now,
+	}); err != nil {
+		return "", fmt.Errorf("failed to save sso state: %w", err)
+	}
+	return provider.client.AuthorizationURL(state, nonce, pkceChallenge(verifier))
+}
+
+// CompleteLogin 驗證回呼的 state 與授權碼，找出或建立會員並套用角色 / 公司對應
+// 返回會員與 BeginLogin 時指定的導向路徑
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerID, code, state string) (*member_entities.Member, string, error) {
+	provider, ok := s.providers[providerID]
+	if !ok {
+		return nil, "", entities.ErrOIDCProviderNotFound
+	}
+	if strings.TrimSpace(code) == "" || strings.TrimSpace(state) == "" {
+		return nil, "", entities.ErrOIDCStateInvalid
+	}
+
+	loginState, err := s.stateRepo.Consume(sha256Hex(state))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load sso state: %w", err)
+	}
+	if loginState == nil || loginState.Provider != providerID || loginState.IsExpired(s.now()) {
+		return nil, "", entities.ErrOIDCStateInvalid
+	}
+
+	claims, err := provider.client.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", entities.ErrOIDCTokenInvalid, err)
+	}
+	if claims.Subject == "" {
+		return nil, "", fmt.Errorf("%w: missing sub claim", entities.ErrOIDCTokenInvalid)
+	}
+
+	roleIDs := provider.config.mapClaims(provider.config.RoleMappings, claims, provider.config.DefaultRoleIDs)
+	companyIDs := provider.config.mapClaims(provider.config.CompanyMappings, claims, nil)
+	if len(roleIDs) == 0 && provider.config.SyncRoles {
+		return nil, "", entities.ErrOIDCNoRoleMapped
+	}
+
+	member, err := s.resolveMember(provider.config, claims, len(roleIDs) > 0)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.syncRoles(member.ID.Value(), roleIDs, provider.config.SyncRoles); err != nil {
+		return nil, "", err
+	}
+	if err := s.syncCompanies(member.ID.Value(), companyIDs, provider.config.SyncCompanies); err != nil {
+		return nil, "", err
+	}
+	return member, loginState.RedirectPath, nil
+}
+
+// resolveMember 依外部身分、email 連結或自動建立找出會員
+func (s *OIDCService) resolveMember(provider OIDCProviderConfig, claims *entities.OIDCClaims, hasRoles bool) (*member_entities.Member, error) {
+	now := s.now()
+	identity, err := s.identityRepo.FindByProviderSubject(provider.ID, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if err := s.identityRepo.TouchLogin(identity.ID, claims.Email, now); err != nil {
+			return nil, err
+		}
+		return s.findMember(identity.MemberID)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.EmailVerified {
+		return nil, entities.ErrOIDCEmailRequired
+	}
+	if !provider.allowsDomain(email) {
+		return nil, entities.ErrOIDCDomainNotAllowed
+	}
+
+	identity = &entities.OIDCIdentity{
+		Provider:    provider.ID,
+		Subject:     claims.Subject,
+		Email:       email,
+		CreateTime:  now,
+		LastLoginAt: now,
+	}
+
+	// email 已被本地會員使用時只能連結，不可另建同 email 的會員
+	if existing, _ := s.memberRepo.FindByEmail(email); existing != nil {
+		if !provider.LinkByEmail {
+			return nil, entities.ErrOIDCAccountNotLinked
+		}
+		identity.MemberID = existing.ID.Value()
+		if err := s.identityRepo.Create(identity); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %w", err)
+		}
+		return existing, nil
+	}
+
+	if !provider.AutoProvision {
+		return nil, entities.ErrOIDCAccountNotLinked
+	}
+	if !hasRoles {
+		return nil, entities.ErrOIDCNoRoleMapped
+	}
+	if err := s.identityRepo.CreateWithMember(provisionedMemberName(claims), email, identity); err != nil {
+		return nil, fmt.Errorf("failed to provision member: %w", err)
+	}
+	return s.findMember(identity.MemberID)
+}
+
+func (s *OIDCService) findMember(memberID uint) (*member_entities.Member, error) {
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", memberID))
+	if err != nil || member == nil {
+		return nil, fmt.Errorf("linked member %d not found: %w", memberID, err)
+	}
+	return member, nil
+}
+
+// syncRoles 加入對應的角色；sync 時移除不再對應的角色
+func (s *OIDCService) syncRoles(memberID uint, roleIDs []uint, sync bool) error {
+	current, err := s.roleRepo.GetByMemberID(memberID)
+	if err != nil {
+		return fmt.Errorf("failed to load member roles: %w", err)
+	}
+	assigned := make(map[uint]bool, len(current))
+	for _, role := range current {
+		assigned[role.ID] = true
+	}
+	wanted := make(map[uint]bool, len(roleIDs))
+	for _, roleID := range roleIDs {
+		wanted[roleID] = true
+		if !assigned[roleID] {
+			if err := s.roleRepo.AssignMembers(roleID, []uint{memberID}, memberID); err != nil {
+				return fmt.Errorf("failed to assign role %d: %w", roleID, err)
+			}
+		}
+	}
+	if !sync {
+		return nil
+	}
+	for _, role := range current {
+		if !wanted[role.ID] {
+			if err := s.roleRepo.RemoveMembers(role.ID, []uint{memberID}); err != nil {
+				return fmt.Errorf("failed to remove role %d: %w", role.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// syncCompanies 加入對應的公司；sync 時移除不再對應的公司
+func (s *OIDCService) syncCompanies(memberID uint, companyIDs []uint, sync bool) error {
+	now := s.now()
+	wanted := make(map[uint]bool, len(companyIDs))
+	for _, companyID := range companyIDs {
+		wanted[companyID] = true
+		exists, err := s.companyMemberRepo.ExistsByCompanyAndMember(companyID, memberID)
+		if err != nil {
+			return fmt.Errorf("failed to check company membership: %w", err)
+		}
+		if exists {
+			continue
+		}
+		if err := s.companyMemberRepo.Save(&company_entities.CompanyMember{
+			CompanyID:  companyID,
+			MemberID:   memberID,
+			CreateID:   memberID,
+			CreateTime: now,
+			ModifyID:   memberID,
+			ModifyTime: now,
+		}); err != nil {
+			return fmt.Errorf("failed to add company %d membership: %w", companyID, err)
+		}
+	}
+	if !sync {
+		return nil
+	}
+	current, err := s.companyMemberRepo.FindByMemberID(memberID)
+	if err != nil {
+		return fmt.Errorf("failed to load company memberships: %w", err)
+	}
+	for _, membership := range current {
+		if !wanted[membership.CompanyID] {
+			if err := s.companyMemberRepo.DeleteByCompanyAndMember(membership.CompanyID, memberID); err != nil {
+				return fmt.Errorf("failed to remove company %d membership: %w", membership.CompanyID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mapClaims 返回符合的 ID (含 defaults)，已去除重複並排序
+func (c OIDCProviderConfig) mapClaims(mappings []OIDCClaimMapping, claims *entities.OIDCClaims, defaults []uint) []uint {
+	matched := make(map[uint]bool)
+	for _, id := range defaults {
+		matched[id] = true
+	}
+	for _, mapping := range mappings {
+		for _, value := range claimValues(claims.Raw[mapping.Claim]) {
+			if value == mapping.Value {
+				matched[mapping.ID] = true
+				break
+			}
+		}
+	}
+	ids := make([]uint, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (c OIDCProviderConfig) allowsDomain(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	domain := email[strings.LastIndexByte(email, '@')+1:]
+	for _, allowed := range c.AllowedDomains {
+		if strings.EqualFold(domain, strings.TrimSpace(allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimValues 將字串、陣列或其他純量宣告轉為字串清單
+func claimValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// provisionedMemberName 由 preferred_username 或 email 產生符合會員名稱規則的名稱
+func provisionedMemberName(claims *entities.OIDCClaims) string {
+	source := claims.PreferredUsername
+	if at := strings.IndexByte(source, '@'); at >= 0 {
+		source = source[:at]
+	}
+	if source == "" {
+		source = claims.Email[:strings.IndexByte(claims.Email+"@", '@')]
+	}
+	name := strings.Trim(invalidMemberNameChars.ReplaceAllString(source, "_"), "_")
+	if len(name) > oidcNameMaxLength {
+		name = name[:oidcNameMaxLength]
+	}
+	for len(name) < 3 {
+		name += "_"
+	}
+	return name
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+	company_entities "ems_backend/internal/domain/company/entities"
+	member_entities "ems_backend/internal/domain/member/entities"
+	member_value_objects "ems_backend/internal/domain/member/value_objects"
+	role_entities "ems_backend/internal/domain/role/entities"
+)
+
+// MockOIDCClient 模擬身分提供者，Exchange 返回預先設定的宣告
+type MockOIDCClient struct {
+	claims       *auth_entities.OIDCClaims
+	err          error
+	codeVerifier string
+	nonce        string
+}
+
+func (m *MockOIDCClient) AuthorizationURL(state, nonce, codeChallenge string) (string, error) {
+	query := url.Values{"state": {state}, "nonce": {nonce}, "code_challenge": {codeChallenge}}
+	return "https://idp.example.com/authorize?" + query.Encode(), nil
+}
+
+func (m *MockOIDCClient) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*auth_entities.OIDCClaims, error) {
+	m.codeVerifier, m.nonce = codeVerifier, nonce
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.claims, nil
+}
+
+// MockOIDCStateRepository 模擬授權碼流程狀態 Repository
+type MockOIDCStateRepository struct {
+	states map[string]*auth_entities.OIDCLoginState
+}
+
+func (m *MockOIDCStateRepository) Create(state *auth_entities.OIDCLoginState) error {
+	if m.states == nil {
+		m.states = map[string]*auth_entities.OIDCLoginState{}
+	}
+	m.states[state.StateHash] = state
+	return nil
+}
+
+func (m *MockOIDCStateRepository) Consume(stateHash string) (*auth_entities.OIDCLoginState, error) {
+	state := m.states[stateHash]
+	delete(m.states, stateHash)
+	return state, nil
+}
+
+func (m *MockOIDCStateRepository) DeleteExpired(now time.Time) (int64, error) {
+	var n int64
+	for hash, state := range m.states {
+		if state.IsExpired(now) {
+			delete(m.states, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MockOIDCIdentityRepository 模擬外部身分 Repository，建立的會員加入 MockMemberRepository
+type MockOIDCIdentityRepository struct {
+	identities []*auth_entities.OIDCIdentity
+	members    *MockMemberRepository
+}
+
+func (m *MockOIDCIdentityRepository) FindByProviderSubject(provider, subject string) (*auth_entities.OIDCIdentity, error) {
+	for _, identity := range m.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockOIDCIdentityRepository) Create(identity *auth_entities.OIDCIdentity) error {
+	identity.ID = uint(len(m.identities) + 1)
+	m.identities = append(m.identities, identity)
+	return nil
+}
+
+func (m *MockOIDCIdentityRepository) CreateWithMember(name, email string, identity *auth_entities.OIDCIdentity) error {
+	id := uint(len(m.members.members) + 1)
+	memberID, _ := member_value_objects.NewMemberID(id)
+	memberName, err := member_value_objects.NewName(name)
+	if err != nil {
+		return err
+	}
+	mail, _ := member_value_objects.NewEmail(email)
+	m.members.members[id] = &member_entities.Member{ID: memberID, Name: memberName, Email: mail, IsEnable: true}
+	identity.MemberID = id
+	return m.Create(identity)
+}
+
+func (m *MockOIDCIdentityRepository) TouchLogin(id uint, email string, now time.Time) error {
+	for _, identity := range m.identities {
+		if identity.ID == id {
+			identity.LastLoginAt = now
+		}
+	}
+	return nil
+}
+
+// MockRoleAssignmentRepository 記錄每位會員實際擁有的角色
+type MockRoleAssignmentRepository struct {
+	MockRoleRepository
+	memberRoles map[uint]map[uint]bool // memberID -> roleID
+}
+
+func (m *MockRoleAssignmentRepository) GetByMemberID(memberID uint) ([]*role_entities.Role, error) {
+	var roles []*role_entities.Role
+	for roleID := range m.memberRoles[memberID] {
+		roles = append(roles, &role_entities.Role{ID: roleID, IsEnable: true})
+	}
+	return roles, nil
+}
+
+func (m *MockRoleAssignmentRepository) AssignMembers(roleID uint, memberIDs []uint, memberID uint) error {
+	for _, id := range memberIDs {
+		if m.memberRoles[id] == nil {
+			m.memberRoles[id] = map[uint]bool{}
+		}
+		m.memberRoles[id][roleID] = true
+	}
+	return nil
+}
+
+func (m *MockRoleAssignmentRepository) RemoveMembers(roleID uint, memberIDs []uint) error {
+	for _, id := range memberIDs {
+		delete(m.memberRoles[id], roleID)
+	}
+	return nil
+}
+
+func (m *MockRoleAssignmentRepository) has(memberID uint, roleIDs ...uint) bool {
+	if len(m.memberRoles[memberID]) != len(roleIDs) {
+		return false
+	}
+	for _, roleID := range roleIDs {
+		if !m.memberRoles[memberID][roleID] {
+			return false
+		}
+	}
+	return true
+}
+
+// MockCompanyMemberRepository 模擬公司成員 Repository
+type MockCompanyMemberRepository struct {
+	memberships []*company_entities.CompanyMember
+}
+
+func (m *MockCompanyMemberRepository) FindByCompanyID(companyID uint) ([]*company_entities.CompanyMember, error) {
+	return nil, nil
+}
+
+func (m *MockCompanyMemberRepository) FindByMemberID(memberID uint) ([]*company_entities.CompanyMember, error) {
+	var result []*company_entities.CompanyMember
+	for _, membership := range m.memberships {
+		if membership.MemberID == memberID {
+			result = append(result, membership)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockCompanyMemberRepository) FindByCompanyIDWithDetails(companyID uint) ([]*company_entities.CompanyMemberWithDetails, error) {
+	return nil, nil
+}
+
+func (m *MockCompanyMemberRepository) FindByCompanyAndMember(companyID, memberID uint) (*company_entities.CompanyMember, error) {
+	for _, membership := range m.memberships {
+		if membership.CompanyID == companyID && membership.MemberID == memberID {
+			return membership, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockCompanyMemberRepository) Save(companyMember *company_entities.CompanyMember) error {
+	m.memberships = append(m.memberships, companyMember)
+	return nil
+}
+
+func (m *MockCompanyMemberRepository) Delete(id uint) error { return nil }
+
+func (m *MockCompanyMemberRepository) DeleteByCompanyAndMember(companyID, memberID uint) error {
+	for i, membership := range m.memberships {
+		if membership.CompanyID == companyID && membership.MemberID == memberID {
+			m.memberships = append(m.memberships[:i], m.memberships[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockCompanyMemberRepository) ExistsByCompanyAndMember(companyID, memberID uint) (bool, error) {
+	membership, _ := m.FindByCompanyAndMember(companyID, memberID)
+	return membership != nil, nil
+}
+
+func (m *MockCompanyMemberRepository) companyIDs(memberID uint) []uint {
+	memberships, _ := m.FindByMemberID(memberID)
+	ids := make([]uint, len(memberships))
+	for i, membership := range memberships {
+		ids[i] = membership.CompanyID
+	}
+	return ids
+}
+
+type oidcFixture struct {
+	*sessionFixture
+	oidc       *OIDCService
+	client     *MockOIDCClient
+	states     *MockOIDCStateRepository
+	identities *MockOIDCIdentityRepository
+	roles      *MockRoleAssignmentRepository
+	companies  *MockCompanyMemberRepository
+}
+
+// newOIDCFixture 身分提供者 corp：groups 對應角色 10 / 11，company 對應公司 100
+func newOIDCFixture(t *testing.T, configure func(*OIDCProviderConfig)) *oidcFixture {
+	t.Helper()
+	f := &oidcFixture{
+		sessionFixture: newSessionFixture(t),
+		client:         &MockOIDCClient{},
+		states:         &MockOIDCStateRepository{},
+		roles:          &MockRoleAssignmentRepository{memberRoles: map[uint]map[uint]bool{}},
+		companies:      &MockCompanyMemberRepository{},
+	}
+	f.identities = &MockOIDCIdentityRepository{members: f.members}
+	f.oidc = NewOIDCService(f.states, f.identities, f.members, f.roles, f.companies, OIDCConfig{})
+
+	config := OIDCProviderConfig{
+		ID:             "corp",
+		AutoProvision:  true,
+		LinkByEmail:    true,
+		AllowedDomains: []string{"example.com"},
+		RoleMappings: []OIDCClaimMapping{
+			{Claim: "groups", Value: "ems-admins", ID: 10},
+			{Claim: "groups", Value: "operators", ID: 11},
+		},
+		CompanyMappings: []OIDCClaimMapping{{Claim: "company", Value: "site-a", ID: 100}},
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	if err := f.oidc.RegisterProvider(config, f.client); err != nil {
+		t.Fatalf("註冊身分提供者失敗: %v", err)
+	}
+	f.service.SetOIDCService(f.oidc)
+	return f
+}
+
+// begin 開始登入並從登入網址取出 state
+func (f *oidcFixture) begin(t *testing.T, redirectPath string) string {
+	t.Helper()
+	authorizationURL, err := f.oidc.BeginLogin("corp", redirectPath)
+	if err != nil {
+		t.Fatalf("開始單一登入失敗: %v", err)
+	}
+	parsed, _ := url.Parse(authorizationURL)
+	return parsed.Query().Get("state")
+}
+
+func (f *oidcFixture) complete(t *testing.T, claims *auth_entities.OIDCClaims) (*auth_entities.AuthResult, string, error) {
+	t.Helper()
+	f.client.claims = claims
+	return f.service.CompleteOIDCLogin(context.Background(), "corp", "code", f.begin(t, "/dashboard"), "10.0.0.1", "browser")
+}
+
+func newOIDCClaims(subject, email string, raw map[string]interface{}) *auth_entities.OIDCClaims {
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return &auth_entities.OIDCClaims{Subject: subject, Email: email, EmailVerified: true, Raw: raw}
+}
+
+func TestOIDCService_BeginLogin(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	authorizationURL, err := f.oidc.BeginLogin("corp", "/dashboard")
+	if err != nil {
+		t.Fatalf("開始單一登入失敗: %v", err)
+	}
+	query, _ := url.Parse(authorizationURL)
+	state := query.Query().Get("state")
+
+	stored := f.states.states[sha256Hex(state)]
+	if stored == nil || f.states.states[state] != nil {
+		t.Fatalf("state 應只以雜湊保存")
+	}
+	if query.Query().Get("code_challenge") != pkceChallenge(stored.CodeVerifier) || len(stored.CodeVerifier) < 43 {
+		t.Errorf("code_challenge 應為 code_verifier 的 S256")
+	}
+	if query.Query().Get("nonce") != stored.Nonce || stored.RedirectPath != "/dashboard" {
+		t.Errorf("nonce 與導向路徑應保存在伺服器，得到 %+v", stored)
+	}
+
+	if _, err := f.oidc.BeginLogin("unknown", ""); !errors.Is(err, auth_entities.ErrOIDCProviderNotFound) {
+		t.Errorf("期望 ErrOIDCProviderNotFound，得到 %v", err)
+	}
+	if _, err := f.oidc.BeginLogin("corp", "https://evil.example.com"); !errors.Is(err, ErrInvalidRedirectPath) {
+		t.Errorf("期望 ErrInvalidRedirectPath，得到 %v", err)
+	}
+}
+
+func TestAuthService_CompleteOIDCLogin_Provisions(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	claims := newOIDCClaims("sub-carol", "carol@example.com", map[string]interface{}{
+		"groups":  []interface{}{"ems-admins", "other"},
+		"company": "site-a",
+	})
+	claims.PreferredUsername = "carol.chen@example.com"
+
+	result, redirectPath, err := f.complete(t, claims)
+	if err != nil {
+		t.Fatalf("單一登入失敗: %v", err)
+	}
+	if redirectPath != "/dashboard" {
+		t.Errorf("期望導向 /dashboard，得到 %q", redirectPath)
+	}
+	if _, err := f.service.ValidateToken(result.AccessToken); err != nil {
+		t.Errorf("應簽發有效的 access token，得到 %v", err)
+	}
+	if len(f.states.states) != 0 {
+		t.Errorf("state 使用後應刪除")
+	}
+	if f.client.nonce == "" || f.client.codeVerifier == "" {
+		t.Errorf("交換授權碼時應帶入保存的 nonce 與 code_verifier")
+	}
+
+	carol, _ := f.members.FindByEmail("carol@example.com")
+	if carol == nil || carol.Name.String() != "carol_chen" {
+		t.Fatalf("應自動建立會員並以 preferred_username 命名，得到 %+v", carol)
+	}
+	if !f.roles.has(carol.ID.Value(), 10) {
+		t.Errorf("應依 groups 指派角色 10，得到 %v", f.roles.memberRoles[carol.ID.Value()])
+	}
+	if ids := f.companies.companyIDs(carol.ID.Value()); len(ids) != 1 || ids[0] != 100 {
+		t.Errorf("應依 company 加入公司 100，得到 %v", ids)
+	}
+
+	// 第二次以同一外部身分登入，不再建立會員
+	members := len(f.members.members)
+	if _, _, err := f.complete(t, claims); err != nil {
+		t.Fatalf("第二次單一登入失敗: %v", err)
+	}
+	if len(f.members.members) != members || len(f.identities.identities) != 1 {
+		t.Errorf("已連結的身分不應重複建立會員")
+	}
+}
+
+func TestAuthService_CompleteOIDCLogin_LinksByEmail(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	if _, _, err := f.complete(t, newOIDCClaims("sub-alice", "Alice@Example.com", map[string]interface{}{"groups": "operators"})); err != nil {
+		t.Fatalf("單一登入失敗: %v", err)
+	}
+	if len(f.identities.identities) != 1 || f.identities.identities[0].MemberID != 1 {
+		t.Fatalf("應以已驗證的 email 連結既有會員 1，得到 %+v", f.identities.identities)
+	}
+	if !f.roles.has(1, 11) {
+		t.Errorf("應指派角色 11，得到 %v", f.roles.memberRoles[1])
+	}
+}
+
+func TestAuthService_CompleteOIDCLogin_Rejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*OIDCProviderConfig)
+		claims    *auth_entities.OIDCClaims
+		exchange  error
+		wantErr   error
+	}{
+		{"身分提供者拒絕", nil, nil, errors.New("invalid_grant"), auth_entities.ErrOIDCTokenInvalid},
+		{"email 未驗證", nil, &auth_entities.OIDCClaims{Subject: "s", Email: "dave@example.com", Raw: map[string]interface{}{"groups": "operators"}}, nil, auth_entities.ErrOIDCEmailRequired},
+		{"網域不在允許清單", nil, newOIDCClaims("s", "dave@evil.example.org", map[string]interface{}{"groups": "operators"}), nil, auth_entities.ErrOIDCDomainNotAllowed},
+		{"新會員沒有對應角色", nil, newOIDCClaims("s", "dave@example.com", nil), nil, auth_entities.ErrOIDCNoRoleMapped},
+		{"未開啟 email 連結", func(c *OIDCProviderConfig) { c.LinkByEmail = false }, newOIDCClaims("s", "alice@example.com", map[string]interface{}{"groups": "operators"}), nil, auth_entities.ErrOIDCAccountNotLinked},
+		{"未開啟自動建立", func(c *OIDCProviderConfig) { c.AutoProvision = false }, newOIDCClaims("s", "dave@example.com", map[string]interface{}{"groups": "operators"}), nil, auth_entities.ErrOIDCAccountNotLinked},
+		{"停用的會員", nil, newOIDCClaims("s", "locked@example.com", map[string]interface{}{"groups": "operators"}), nil, ErrAccountDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t, tt.configure)
+			f.client.err = tt.exchange
+			members := len(f.members.members)
+
+			_, _, err := f.complete(t, tt.claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望 %v，得到 %v", tt.wantErr, err)
+			}
+			if len(f.members.members) != members {
+				t.Errorf("失敗時不應建立會員")
+			}
+		})
+	}
+}
+
+func TestOIDCService_CompleteLogin_InvalidState(t *testing.T) {
+	f := newOIDCFixture(t, nil)
+	f.client.claims = newOIDCClaims("sub-alice", "alice@example.com", map[string]interface{}{"groups": "operators"})
+	ctx := context.Background()
+
+	state := f.begin(t, "")
+	if _, _, err := f.oidc.CompleteLogin(ctx, "corp", "code", "forged"); !errors.Is(err, auth_entities.ErrOIDCStateInvalid) {
+		t.Errorf("偽造的 state 期望 ErrOIDCStateInvalid，得到 %v", err)
+	}
+	if _, _, err := f.oidc.CompleteLogin(ctx, "corp", "code", state); err != nil {
+		t.Fatalf("單一登入失敗: %v", err)
+	}
+	if _, _, err := f.oidc.CompleteLogin(ctx, "corp", "code", state); !errors.Is(err, auth_entities.ErrOIDCStateInvalid) {
+		t.Errorf("state 只能使用一次，得到 %v", err)
+	}
+
+	expired := f.begin(t, "")
+	f.oidc.now = func() time.Time { return time.Now().Add(DefaultOIDCStateTTL + time.Second) }
+	if _, _, err := f.oidc.CompleteLogin(ctx, "corp", "code", expired); !errors.Is(err, auth_entities.ErrOIDCStateInvalid) {
+		t.Errorf("過期的 state 期望 ErrOIDCStateInvalid，得到 %v", err)
+	}
+}
+
+func TestOIDCService_SyncRolesAndCompanies(t *testing.T) {
+	f := newOIDCFixture(t, func(c *OIDCProviderConfig) {
+		c.SyncRoles, c.SyncCompanies = true, true
+	})
+	// 既有的角色 99 與公司 200 不在對應中
+	f.roles.memberRoles[1] = map[uint]bool{99: true, 10: true}
+	f.companies.memberships = []*company_entities.CompanyMember{{CompanyID: 200, MemberID: 1}}
+
+	if _, _, err := f.complete(t, newOIDCClaims("sub-alice", "alice@example.com", map[string]interface{}{
+		"groups":  []interface{}{"operators"},
+		"company": "site-a",
+	})); err != nil {
+		t.Fatalf("單一登入失敗: %v", err)
+	}
+	if !f.roles.has(1, 11) {
+		t.Errorf("同步後應只剩角色 11，得到 %v", f.roles.memberRoles[1])
+	}
+	if ids := f.companies.companyIDs(1); len(ids) != 1 || ids[0] != 100 {
+		t.Errorf("同步後應只剩公司 100，得到 %v", ids)
+	}
+
+	// 同步時宣告不再對應任何角色，拒絕登入而不是移除所有角色
+	_, _, err := f.complete(t, newOIDCClaims("sub-alice", "alice@example.com", nil))
+	if !errors.Is(err, auth_entities.ErrOIDCNoRoleMapped) {
+		t.Fatalf("期望 ErrOIDCNoRoleMapped，得到 %v", err)
+	}
+	if !f.roles.has(1, 11) {
+		t.Errorf("拒絕登入時不應變更角色，得到 %v", f.roles.memberRoles[1])
+	}
+}
+
+func TestProvisionedMemberName(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims auth_entities.OIDCClaims
+		want   string
+	}{
+		{"preferred_username", auth_entities.OIDCClaims{PreferredUsername: "bob", Email: "x@example.com"}, "bob"},
+		{"以 email 帳號命名", auth_entities.OIDCClaims{Email: "mary-jane.watson@example.com"}, "mary_jane_watson"},
+		{"截斷為 20 字元", auth_entities.OIDCClaims{Email: "a-very-long-account-name-indeed@example.com"}, "a_very_long_account_"},
+		{"補足 3 字元", auth_entities.OIDCClaims{Email: "li@example.com"}, "li_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := provisionedMemberName(&tt.claims)
+			if got != tt.want {
+				t.Errorf("期望 %q，得到 %q", tt.want, got)
+			}
+			if _, err := member_value_objects.NewName(got); err != nil {
+				t.Errorf("%q 不符合會員名稱規則: %v", got, err)
+			}
+		})
+	}
+}
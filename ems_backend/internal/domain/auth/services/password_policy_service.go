@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	member_value_objects "ems_backend/internal/domain/member/value_objects"
+	member_history_entities "ems_backend/internal/domain/member_history/entities"
+	member_history_repositories "ems_backend/internal/domain/member_history/repositories"
+)
+
+// minIdentifierLength 名稱或 email 少於此長度時不檢查密碼是否包含
+const minIdentifierLength = 3
+
+var (
+	// ErrPasswordPolicy 密碼不符合政策，errors.Is 可再判斷個別原因
+	ErrPasswordPolicy = errors.New("password does not meet the password policy")
+	// ErrPasswordMissingUpper 缺少大寫字母
+	ErrPasswordMissingUpper = errors.New("password must contain an uppercase letter")
+	// ErrPasswordMissingLower 缺少小寫字母
+	ErrPasswordMissingLower = errors.New("password must contain a lowercase letter")
+	// ErrPasswordMissingDigit 缺少數字
+	ErrPasswordMissingDigit = errors.New("password must contain a digit")
+	// ErrPasswordMissingSymbol 缺少符號
+	ErrPasswordMissingSymbol = errors.New("password must contain a symbol")
+	// ErrPasswordCommon 常見或已外洩的密碼
+	ErrPasswordCommon = errors.New("password is too common or has appeared in a data breach")
+	// ErrPasswordContainsIdentity 密碼包含帳號名稱或 email
+	ErrPasswordContainsIdentity = errors.New("password must not contain the account name or email")
+	// ErrPasswordReused 與最近使用過的密碼相同
+	ErrPasswordReused = errors.New("password was used recently")
+)
+
+// commonPasswords 內建的常見密碼清單，可再以 PasswordPolicyConfig.CommonPasswords 擴充
+var commonPasswords = []string{
+	"123456", "12345678", "123456789", "1234567890", "111111", "000000", "123123", "654321",
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "p@ssword", "admin", "admin123",
+	"administrator", "root", "qwerty", "qwerty123", "qwertyuiop", "1q2w3e4r", "1qaz2wsx", "zaq12wsx",
+	"abc123", "abcd1234", "iloveyou", "welcome", "welcome1", "letmein", "monkey", "dragon",
+	"sunshine", "princess", "football", "baseball", "superman", "trustno1", "master", "changeme",
+	"default", "secret", "test1234", "guest", "login", "aa123456", "a123456", "asdf1234",
+}
+
+// PasswordPolicyConfig 密碼政策設定
+type PasswordPolicyConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// MaxAge 密碼有效期限，0 不限制；過期後登入仍可成功但結果標示需變更密碼
+	MaxAge time.Duration
+	// HistoryCount 不可與最近 N 組密碼 (含目前密碼) 相同，0 不檢查
+	HistoryCount int
+	// CommonPasswords 額外禁止的密碼 (不分大小寫)
+	CommonPasswords []string
+
+	// Argon2 新密碼使用的雜湊參數；登入時舊參數的雜湊會自動升級
+	Argon2 member_history_entities.Argon2Params
+}
+
+// PasswordPolicyError 密碼不符合政策的所有原因
+type PasswordPolicyError struct {
+	Violations []error
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrPasswordPolicy, strings.Join(messages, "; "))
+}
+
+// Is 讓 errors.Is(err, ErrPasswordPolicy) 成立
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// Unwrap 讓 errors.Is 可判斷個別原因
+func (e *PasswordPolicyError) Unwrap() []error {
+	return e.Violations
+}
+
+// PasswordPolicyService 密碼政策：強度檢查、重複使用檢查、有效期限與雜湊參數升級
+type PasswordPolicyService struct {
+	memberHistoryRepo member_history_repositories.MemberHistoryRepository
+	config            PasswordPolicyConfig
+	common            map[string]struct{}
+	now               func() time.Time
+}
+
+// NewPasswordPolicyService 創建密碼政策服務
+func NewPasswordPolicyService(memberHistoryRepo member_history_repositories.MemberHistoryRepository, config PasswordPolicyConfig) (*PasswordPolicyService, error) {
+	if config.MinLength <= 0 {
+		config.MinLength = DefaultMinPasswordLength
+	}
+	if config.HistoryCount < 0 {
+		config.HistoryCount = 0
+	}
+	if config.Argon2 == (member_history_entities.Argon2Params{}) {
+		config.Argon2 = member_history_entities.DefaultArgon2Params
+	}
+	if err := config.Argon2.Validate(); err != nil {
+		return nil, err
+	}
+
+	common := make(map[string]struct{}, len(commonPasswords)+len(config.CommonPasswords))
+	for _, list := range [][]string{commonPasswords, config.CommonPasswords} {
+		for _, password := range list {
+			if password = strings.ToLower(strings.TrimSpace(password)); password != "" {
+				common[password] = struct{}{}
+			}
+		}
+	}
+	return &PasswordPolicyService{
+		memberHistoryRepo: memberHistoryRepo,
+		config:            config,
+		common:            common,
+		now:               time.Now,
+	}, nil
+}
+
+// Config 目前的政策設定
+func (s *PasswordPolicyService) Config() PasswordPolicyConfig {
+	return s.config
+}
+
+// Validate 檢查密碼；memberID 為 0 (新會員) 時不檢查重複使用
+// identifiers 為帳號名稱、email 等不可出現在密碼中的字串
+func (s *PasswordPolicyService) Validate(memberID uint, password string, identifiers ...string) error {
+	var violations []error
+	if len([]rune(password)) < s.config.MinLength {
+		violations = append(violations, fmt.Errorf("%w: at least %d characters required", ErrPasswordTooShort, s.config.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	for _, rule := range []struct {
+		required, present bool
+		err               error
+	}{
+		{s.config.RequireUpper, upper, ErrPasswordMissingUpper},
+		{s.config.RequireLower, lower, ErrPasswordMissingLower},
+		{s.config.RequireDigit, digit, ErrPasswordMissingDigit},
+		{s.config.RequireSymbol, symbol, ErrPasswordMissingSymbol},
+	} {
+		if rule.required && !rule.present {
+			violations = append(violations, rule.err)
+		}
+	}
+
+	lowered := strings.ToLower(password)
+	if _, ok := s.common[lowered]; ok {
+		violations = append(violations, ErrPasswordCommon)
+	}
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+		if at := strings.IndexByte(identifier, '@'); at >= 0 {
+			identifier = identifier[:at]
+		}
+		if len([]rune(identifier)) >= minIdentifierLength && strings.Contains(lowered, identifier) {
+			violations = append(violations, ErrPasswordContainsIdentity)
+			break
+		}
+	}
+
+	// 其他規則不通過時不再計算歷史雜湊
+	if len(violations) == 0 && memberID != 0 {
+		reused, err := s.reused(memberID, password)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, fmt.Errorf("%w: must differ from the last %d passwords", ErrPasswordReused, s.config.HistoryCount))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// NewHistory 以目前參數雜湊密碼並建立新的密碼歷史 (不檢查政策、尚未保存)
+func (s *PasswordPolicyService) NewHistory(memberID member_value_objects.MemberID, password string, actorID uint) (*member_history_entities.MemberHistory, error) {
+	now := s.now()
+	history := &member_history_entities.MemberHistory{
+		MemberID:   memberID,
+		CreateID:   actorID,
+		CreateTime: now,
+		ModifyID:   actorID,
+		ModifyTime: now,
+	}
+	if err := history.SetPassword(password, s.config.Argon2); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return history, nil
+}
+
+// SetPassword 檢查並保存新密碼
+func (s *PasswordPolicyService) SetPassword(memberID member_value_objects.MemberID, password string, actorID uint, identifiers ...string) error {
+	if err := s.Validate(memberID.Value(), password, identifiers...); err != nil {
+		return err
+	}
+	history, err := s.NewHistory(memberID, password, actorID)
+	if err != nil {
+		return err
+	}
+	if err := s.memberHistoryRepo.Save(history); err != nil {
+		return fmt.Errorf("failed to save member history: %w", err)
+	}
+	return nil
+}
+
+// IsExpired 密碼是否超過有效期限
+func (s *PasswordPolicyService) IsExpired(history *member_history_entities.MemberHistory) bool {
+	return s.config.MaxAge > 0 && s.now().Sub(history.CreateTime) > s.config.MaxAge
+}
+
+// RehashIfNeeded 密碼驗證成功後，以目前參數重新雜湊舊參數的密碼
+func (s *PasswordPolicyService) RehashIfNeeded(history *member_history_entities.MemberHistory, password string) (bool, error) {
+	if history.ID == 0 || !history.NeedsRehash(s.config.Argon2) {
+		return false, nil
+	}
+	upgraded := *history
+	if err := upgraded.SetPassword(password, s.config.Argon2); err != nil {
+		return false, err
+	}
+	if err := s.memberHistoryRepo.UpdateHash(history.ID, upgraded.Salt, upgraded.Hash, upgraded.HashParams); err != nil {
+		return false, err
+	}
+	history.Salt, history.Hash, history.HashParams = upgraded.Salt, upgraded.Hash, upgraded.HashParams
+	return true, nil
+}
+
+// reused 是否與最近 HistoryCount 組密碼相同；每筆以其保存的參數計算
+func (s *PasswordPolicyService) reused(memberID uint, password string) (bool, error) {
+	if s.config.HistoryCount == 0 {
+		return false, nil
+	}
+	histories, err := s.memberHistoryRepo.RecentMemberHistories(memberID, s.config.HistoryCount)
+	if err != nil {
+		return false, fmt.Errorf("failed to load password history: %w", err)
+	}
+	for _, history := range histories {
+		if history.ValidatePassword(password, history.Salt, history.Hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	member_history_entities "ems_backend/internal/domain/member_history/entities"
+)
+
+// testArgon2Params 測試用的低成本參數
+var testArgon2Params = member_history_entities.Argon2Params{Time: 1, Memory: 8 * 1024, Threads: 1, KeyLength: 32}
+
+func newTestPasswordPolicy(t *testing.T, history *MockMemberHistoryRepository, config PasswordPolicyConfig) *PasswordPolicyService {
+	t.Helper()
+	if config.Argon2 == (member_history_entities.Argon2Params{}) {
+		config.Argon2 = testArgon2Params
+	}
+	policy, err := NewPasswordPolicyService(history, config)
+	if err != nil {
+		t.Fatalf("建立密碼政策失敗: %v", err)
+	}
+	return policy
+}
+
+func TestPasswordPolicyService_Validate(t *testing.T) {
+	config := PasswordPolicyConfig{
+		MinLength:       10,
+		RequireUpper:    true,
+		RequireLower:    true,
+		RequireDigit:    true,
+		RequireSymbol:   true,
+		CommonPasswords: []string{"Winter2024!Pass"},
+	}
+	tests := []struct {
+		name     string
+		password string
+		wantErrs []error
+	}{
+		{"符合政策", "Correct-Horse-9", nil},
+		{"太短", "Ab1!", []error{ErrPasswordTooShort}},
+		{"缺少大寫與符號", "lowercase123", []error{ErrPasswordMissingUpper, ErrPasswordMissingSymbol}},
+		{"缺少小寫與數字", "UPPERCASE!!!", []error{ErrPasswordMissingLower, ErrPasswordMissingDigit}},
+		{"內建常見密碼", "password123", []error{ErrPasswordCommon}},
+		{"自訂外洩清單不分大小寫", "winter2024!pass", []error{ErrPasswordCommon}},
+		{"包含 email 帳號", "Alice-Secret-9", []error{ErrPasswordContainsIdentity}},
+	}
+
+	policy := newTestPasswordPolicy(t, &MockMemberHistoryRepository{}, config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(0, tt.password, "ab", "alice@example.com")
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Fatalf("期望通過，得到 %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrPasswordPolicy) {
+				t.Fatalf("期望 ErrPasswordPolicy，得到 %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("期望包含 %v，得到 %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyService_RejectsReuse(t *testing.T) {
+	f := newResetFixture(t)
+	policy := newTestPasswordPolicy(t, f.history, PasswordPolicyConfig{HistoryCount: 3})
+	alice, _ := f.service.memberRepo.FindByID("1")
+
+	// 舊雜湊沒有保存參數，以 LegacyArgon2Params 計算
+	legacy := &member_history_entities.MemberHistory{MemberID: alice.ID, Salt: "c2FsdA=="}
+	legacy.Hash = legacy.HashPassword("first password", legacy.Salt)
+	_ = f.history.Save(legacy)
+	for _, password := range []string{"second password", "third password"} {
+		if err := policy.SetPassword(alice.ID, password, 1); err != nil {
+			t.Fatalf("設定密碼失敗: %v", err)
+		}
+	}
+
+	for _, password := range []string{"first password", "third password"} {
+		if err := policy.SetPassword(alice.ID, password, 1); !errors.Is(err, ErrPasswordReused) {
+			t.Errorf("%q 為最近 3 組密碼之一，期望 ErrPasswordReused，得到 %v", password, err)
+		}
+	}
+
+	// 第 4 組後最早的密碼已超出檢查範圍
+	if err := policy.SetPassword(alice.ID, "fourth password", 1); err != nil {
+		t.Fatalf("設定密碼失敗: %v", err)
+	}
+	if err := policy.SetPassword(alice.ID, "first password", 1); err != nil {
+		t.Errorf("超出最近 3 組的密碼應可再使用，得到 %v", err)
+	}
+	if len(f.history.histories) != 5 {
+		t.Errorf("期望 5 筆密碼歷史，得到 %d", len(f.history.histories))
+	}
+}
+
+func TestAuthService_Login_RehashesLegacyPassword(t *testing.T) {
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100})
+	f.service.SetPasswordPolicy(newTestPasswordPolicy(t, f.history, PasswordPolicyConfig{}))
+	stored := f.history.histories[0]
+	stored.ID = 1
+	oldHash := stored.Hash
+
+	if _, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("登入失敗: %v", err)
+	}
+	if stored.HashParams != testArgon2Params.String() || stored.Hash == oldHash {
+		t.Fatalf("登入成功後應以目前參數重新雜湊，得到 %q", stored.HashParams)
+	}
+	if stored.NeedsRehash(testArgon2Params) {
+		t.Errorf("升級後不應再需要重新雜湊")
+	}
+
+	// 升級後的雜湊仍可登入，且不再重新計算
+	upgradedHash := stored.Hash
+	if _, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("升級後登入失敗: %v", err)
+	}
+	if stored.Hash != upgradedHash {
+		t.Errorf("參數相同時不應重新雜湊")
+	}
+
+	// 密碼錯誤時不升級
+	stored.HashParams, stored.Hash = "", oldHash
+	_, _ = f.service.Login("alice@example.com", "wrong", "10.0.0.1", "test-agent")
+	if stored.HashParams != "" {
+		t.Errorf("密碼錯誤時不應重新雜湊")
+	}
+}
+
+func TestAuthService_Login_PasswordExpired(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		age         time.Duration
+		wantExpired bool
+	}{
+		{"未設定有效期限", 0, 365 * 24 * time.Hour, false},
+		{"尚未過期", 90 * 24 * time.Hour, 30 * 24 * time.Hour, false},
+		{"已過期", 90 * 24 * time.Hour, 91 * 24 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100})
+			f.service.SetPasswordPolicy(newTestPasswordPolicy(t, f.history, PasswordPolicyConfig{MaxAge: tt.maxAge}))
+			f.history.histories[0].CreateTime = time.Now().Add(-tt.age)
+
+			result, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "test-agent")
+			if err != nil {
+				t.Fatalf("過期的密碼仍應可登入，得到 %v", err)
+			}
+			if result.PasswordExpired != tt.wantExpired {
+				t.Errorf("期望 PasswordExpired=%v，得到 %v", tt.wantExpired, result.PasswordExpired)
+			}
+		})
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	f := newSessionFixture(t)
+	f.service.SetPasswordPolicy(newTestPasswordPolicy(t, f.history, PasswordPolicyConfig{HistoryCount: 5}))
+	current := f.login(t, "browser")
+	other := f.login(t, "mobile")
+	claims, _ := f.service.ValidateToken(current.AccessToken)
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		wantErr         error
+	}{
+		{"目前密碼錯誤", "wrong", "brand new password", ErrInvalidCredentials},
+		{"不符合政策", "correct password", "short", ErrPasswordTooShort},
+		{"與目前密碼相同", "correct password", "correct password", ErrPasswordReused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.service.ChangePassword(1, tt.currentPassword, tt.newPassword, claims.SessionID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望 %v，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+	if len(f.history.histories) != 1 {
+		t.Fatalf("失敗時不應新增密碼歷史")
+	}
+
+	if err := f.service.ChangePassword(1, "correct password", "brand new password", claims.SessionID); err != nil {
+		t.Fatalf("變更密碼失敗: %v", err)
+	}
+	if _, err := f.service.ValidateToken(current.AccessToken); err != nil {
+		t.Errorf("目前的會話應保留，得到 %v", err)
+	}
+	if _, err := f.service.ValidateToken(other.AccessToken); err == nil {
+		t.Errorf("其他會話應被撤銷")
+	}
+	if _, err := f.service.Login("alice@example.com", "brand new password", "10.0.0.1", "browser"); err != nil {
+		t.Errorf("應可使用新密碼登入，得到 %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+	"ems_backend/internal/domain/member/repositories"
+	member_history_repositories "ems_backend/internal/domain/member_history/repositories"
+)
+
+// 重設密碼預設值
+const (
+	DefaultResetTokenTTL     = 30 * time.Minute
+	DefaultMinPasswordLength = 8
+	maxRedirectPathLength    = 512
+)
+
+var (
+	// ErrPasswordTooShort 新密碼長度不足
+	ErrPasswordTooShort = errors.New("password is too short")
+	// ErrInvalidRedirectPath 導向路徑必須是站內相對路徑
+	ErrInvalidRedirectPath = errors.New("redirect_path must be a relative path starting with '/'")
+)
+
+// MailSender 郵件發送接口 (SMTP、log 等實作位於 infrastructure/mail)
+type MailSender interface {
+	Send(to, subject, body string) error
+}
+
+// PasswordResetConfig 重設密碼設定
+type PasswordResetConfig struct {
+	TokenTTL          time.Duration
+	ResetURL          string // 前端重設頁面，token 以 ?token= 附加
+	MinPasswordLength int
+}
+
+// PasswordResetService 忘記密碼 / 重設密碼領域服務
+type PasswordResetService struct {
+	memberRepo        repositories.MemberRepository
+	memberHistoryRepo member_history_repositories.MemberHistoryRepository
+	authRepo          auth_repositories.AuthRepository
+	resetRepo         auth_repositories.PasswordResetRepository
+	mailer            MailSender
+	policy            *PasswordPolicyService
+	config            PasswordResetConfig
+	now               func() time.Time
+}
+
+// NewPasswordResetService 創建重設密碼領域服務
+func NewPasswordResetService(
+	memberRepo repositories.MemberRepository,
+	memberHistoryRepo member_history_repositories.MemberHistoryRepository,
+	authRepo auth_repositories.AuthRepository,
+	resetRepo auth_repositories.PasswordResetRepository,
+	mailer MailSender,
+	config PasswordResetConfig,
+) *PasswordResetService {
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = DefaultResetTokenTTL
+	}
+	if config.MinPasswordLength <= 0 {
+		config.MinPasswordLength = DefaultMinPasswordLength
+	}
+	// 預設只檢查長度與常見密碼，SetPasswordPolicy 可換成完整政策
+	policy, _ := NewPasswordPolicyService(memberHistoryRepo, PasswordPolicyConfig{MinLength: config.MinPasswordLength})
+	return &PasswordResetService{
+		memberRepo:        memberRepo,
+		memberHistoryRepo: memberHistoryRepo,
+		authRepo:          authRepo,
+		resetRepo:         resetRepo,
+		mailer:            mailer,
+		policy:            policy,
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+// SetPasswordPolicy 設置新密碼的政策
+func (s *PasswordResetService) SetPasswordPolicy(policy *PasswordPolicyService) {
+	s.policy = policy
+}
+
+// RequestReset 簽發重設憑證並寄出連結
+// 帳號不存在或已停用時不做任何事也不回報錯誤，避免被用來探測帳號
+func (s *PasswordResetService) RequestReset(email, redirectPath string) error {
+	redirectPath = strings.TrimSpace(redirectPath)
+	if !validRedirectPath(redirectPath) {
+		return ErrInvalidRedirectPath
+	}
+
+	if _, err := s.resetRepo.DeleteExpired(s.now()); err != nil {
+		return fmt.Errorf("failed to purge expired reset tokens: %w", err)
+	}
+
+	member, err := s.memberRepo.FindByEmail(strings.TrimSpace(email))
+	if err != nil || member == nil || !member.IsEnable {
+		return nil
+	}
+
+	// 同一會員只保留最新一組連結
+	if err := s.resetRepo.DeleteByMemberID(member.ID.Value()); err != nil {
+		return fmt.Errorf("failed to revoke previous reset tokens: %w", err)
+	}
+
+	token, err := generateResetToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	resetToken := entities.NewPasswordResetToken(member.ID.Value(), HashResetToken(token), s.config.TokenTTL, redirectPath)
+	if err := s.resetRepo.Create(resetToken); err != nil {
+		return fmt.Errorf("failed to save reset token: %w", err)
+	}
+
+	link, err := s.resetLink(token)
+	if err != nil {
+		return err
+	}
+	subject := "EMS 密碼重設"
+	body := fmt.Sprintf(
+		"%s 您好：\n\n我們收到了重設您 EMS 帳號密碼的請求，請於 %d 分鐘內開啟以下連結設定新密碼：\n\n%s\n\n此連結僅能使用一次。若您沒有提出此請求，請忽略本郵件，您的密碼不會變更。\n",
+		member.Name.String(), int(s.config.TokenTTL.Minutes()), link,
+	)
+	if err := s.mailer.Send(member.Email.String(), subject, body); err != nil {
+		return fmt.Errorf("failed to send reset mail: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword 以重設憑證設定新密碼，成功後使該會員所有登入會話失效
+// 返回簽發時指定的導向路徑；新密碼不符合政策時不消耗憑證，可修改後重試
+func (s *PasswordResetService) ResetPassword(token, newPassword string) (string, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", entities.ErrResetTokenInvalid
+	}
+
+	resetToken, err := s.resetRepo.FindByHash(HashResetToken(token))
+	if err != nil {
+		return "", fmt.Errorf("failed to find reset token: %w", err)
+	}
+	if resetToken == nil {
+		return "", entities.ErrResetTokenInvalid
+	}
+	if resetToken.IsExpired(s.now()) {
+		_, _ = s.resetRepo.Consume(resetToken.ID)
+		return "", entities.ErrResetTokenExpired
+	}
+
+	member, err := s.memberRepo.FindByID(fmt.Sprintf("%d", resetToken.MemberID))
+	if err != nil || member == nil || !member.IsEnable {
+		_, _ = s.resetRepo.Consume(resetToken.ID)
+		return "", entities.ErrResetTokenInvalid
+	}
+	if err := s.policy.Validate(member.ID.Value(), newPassword, member.Name.String(), member.Email.String()); err != nil {
+		return "", err
+	}
+
+	// 先刪除憑證再改密碼，同一連結並發使用時只有一方會成功
+	consumed, err := s.resetRepo.Consume(resetToken.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	if !consumed {
+		return "", entities.ErrResetTokenInvalid
+	}
+
+	history, err := s.policy.NewHistory(member.ID, newPassword, member.ID.Value())
+	if err != nil {
+		return "", err
+	}
+	if err := s.memberHistoryRepo.Save(history); err != nil {
+		return "", fmt.Errorf("failed to save member history: %w", err)
+	}
+
+	if err := s.authRepo.InvalidateAllSessionsByMemberID(member.ID.Value()); err != nil {
+		return "", fmt.Errorf("failed to invalidate sessions: %w", err)
+	}
+	if err := s.resetRepo.DeleteByMemberID(member.ID.Value()); err != nil {
+		return "", fmt.Errorf("failed to revoke remaining reset tokens: %w", err)
+	}
+	return resetToken.RedirectPath, nil
+}
+
+// HashResetToken 計算重設憑證的 SHA-256
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *PasswordResetService) resetLink(token string) (string, error) {
+	base, err := url.Parse(s.config.ResetURL)
+	if err != nil || s.config.ResetURL == "" {
+		return "", fmt.Errorf("invalid password reset URL %q", s.config.ResetURL)
+	}
+	query := base.Query()
+	query.Set("token", token)
+	base.RawQuery = query.Encode()
+	return base.String(), nil
+}
+
+// validRedirectPath 只接受站內路徑，避免 //evil.com 之類的開放重導
+func validRedirectPath(path string) bool {
+	if path == "" {
+		return true
+	}
+	if len(path) > maxRedirectPathLength || !strings.HasPrefix(path, "/") {
+		return false
+	}
+	return !strings.HasPrefix(path, "//") && !strings.ContainsAny(path, "\\\r\n")
+}
+
+func generateResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
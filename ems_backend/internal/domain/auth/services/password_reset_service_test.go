@@ -0,0 +1,459 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+	member_entities "ems_backend/internal/domain/member/entities"
+	member_value_objects "ems_backend/internal/domain/member/value_objects"
+	member_history_entities "ems_backend/internal/domain/member_history/entities"
+)
+
+// MockMemberRepository 模擬會員 Repository
+type MockMemberRepository struct {
+	members map[uint]*member_entities.Member
+}
+
+func (m *MockMemberRepository) FindByID(id string) (*member_entities.Member, error) {
+	for _, member := range m.members {
+		if member.ID.String() == id {
+			return member, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *MockMemberRepository) FindByEmail(email string) (*member_entities.Member, error) {
+	for _, member := range m.members {
+		if member.Email.String() == email {
+			return member, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *MockMemberRepository) FindAll() ([]*member_entities.Member, error) { return nil, nil }
+func (m *MockMemberRepository) Save(member *member_entities.Member) error   { return nil }
+func (m *MockMemberRepository) Update(member *member_entities.Member) error { return nil }
+func (m *MockMemberRepository) Delete(id string) error                      { return nil }
+func (m *MockMemberRepository) UpdateLock(member *member_entities.Member) error {
+	m.members[member.ID.Value()] = member
+	return nil
+}
+
+func (m *MockMemberRepository) FindLockExpired(now time.Time) ([]*member_entities.Member, error) {
+	var expired []*member_entities.Member
+	for _, member := range m.members {
+		if member.LockExpired(now) {
+			expired = append(expired, member)
+		}
+	}
+	return expired, nil
+}
+
+// MockMemberHistoryRepository 模擬密碼歷史 Repository
+type MockMemberHistoryRepository struct {
+	histories []*member_history_entities.MemberHistory
+}
+
+func (m *MockMemberHistoryRepository) Save(history *member_history_entities.MemberHistory) error {
+	m.histories = append(m.histories, history)
+	return nil
+}
+
+func (m *MockMemberHistoryRepository) Update(history *member_history_entities.MemberHistory) error {
+	return nil
+}
+
+func (m *MockMemberHistoryRepository) LastMemberHistory(memberID uint) (*member_history_entities.MemberHistory, error) {
+	for i := len(m.histories) - 1; i >= 0; i-- {
+		if m.histories[i].MemberID.Value() == memberID {
+			return m.histories[i], nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (m *MockMemberHistoryRepository) RecentMemberHistories(memberID uint, limit int) ([]*member_history_entities.MemberHistory, error) {
+	var histories []*member_history_entities.MemberHistory
+	for i := len(m.histories) - 1; i >= 0 && len(histories) < limit; i-- {
+		if m.histories[i].MemberID.Value() == memberID {
+			histories = append(histories, m.histories[i])
+		}
+	}
+	return histories, nil
+}
+
+func (m *MockMemberHistoryRepository) UpdateHash(id uint, salt, hash, params string) error {
+	for _, history := range m.histories {
+		if history.ID == id {
+			history.Salt, history.Hash, history.HashParams = salt, hash, params
+			return nil
+		}
+	}
+	return errors.New("record not found")
+}
+
+func (m *MockMemberHistoryRepository) IncrementErrorCount(memberID uint) (int, error) {
+	history, err := m.LastMemberHistory(memberID)
+	if err != nil {
+		return 0, err
+	}
+	history.ErrorCount++
+	return int(history.ErrorCount), nil
+}
+
+func (m *MockMemberHistoryRepository) ResetErrorCount(memberID uint) error {
+	history, err := m.LastMemberHistory(memberID)
+	if err != nil {
+		return err
+	}
+	history.ErrorCount = 0
+	return nil
+}
+
+// MockAuthRepository 模擬會話 Repository
+type MockAuthRepository struct {
+	sessions    []*auth_entities.AuthSession
+	invalidated []uint
+}
+
+func (m *MockAuthRepository) SaveSession(session *auth_entities.AuthSession) error {
+	session.ID = uint(len(m.sessions) + 1)
+	m.sessions = append(m.sessions, session)
+	return nil
+}
+func (m *MockAuthRepository) UpdateAccessToken(id uint, accessToken string) error { return nil }
+func (m *MockAuthRepository) FindSessionByRefreshToken(refreshToken string) (*auth_entities.AuthSession, error) {
+	for _, session := range m.sessions {
+		if session.RefreshToken.String() == refreshToken && session.RevokedAt == nil {
+			return session, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+func (m *MockAuthRepository) FindSessionByMemberID(memberID uint) (*auth_entities.AuthSession, error) {
+	return nil, errors.New("record not found")
+}
+func (m *MockAuthRepository) InvalidateSession(sessionID uint) error {
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.ID == sessionID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
+func (m *MockAuthRepository) InvalidateAllSessionsByMemberID(memberID uint) error {
+	m.invalidated = append(m.invalidated, memberID)
+	_, err := m.RevokeSessionsByMemberID(memberID, "", time.Now())
+	return err
+}
+func (m *MockAuthRepository) FindActiveSessionsByMemberID(memberID uint, now time.Time) ([]*auth_entities.AuthSession, error) {
+	var sessions []*auth_entities.AuthSession
+	for _, session := range m.sessions {
+		if session.MemberID.Value() == memberID && session.IsActive(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+func (m *MockAuthRepository) FindSessionBySessionID(sessionID string) (*auth_entities.AuthSession, error) {
+	for _, session := range m.sessions {
+		if session.SessionID == sessionID {
+			return session, nil
+		}
+	}
+	return nil, nil
+}
+func (m *MockAuthRepository) RevokeSessionsByMemberID(memberID uint, exceptSessionID string, now time.Time) ([]string, error) {
+	var revoked []string
+	for _, session := range m.sessions {
+		if session.MemberID.Value() == memberID && session.RevokedAt == nil && session.SessionID != exceptSessionID {
+			revokedAt := now
+			session.RevokedAt = &revokedAt
+			revoked = append(revoked, session.SessionID)
+		}
+	}
+	return revoked, nil
+}
+func (m *MockAuthRepository) FindRevokedSince(since time.Time) (map[string]time.Time, error) {
+	revoked := map[string]time.Time{}
+	for _, session := range m.sessions {
+		if session.RevokedAt != nil && session.RevokedAt.After(since) {
+			revoked[session.SessionID] = *session.RevokedAt
+		}
+	}
+	return revoked, nil
+}
+func (m *MockAuthRepository) DeleteSessionsBefore(cutoff time.Time) (int64, error) {
+	kept := m.sessions[:0]
+	var deleted int64
+	for _, session := range m.sessions {
+		if (session.RevokedAt != nil && session.RevokedAt.Before(cutoff)) || session.ExpiresAt.Before(cutoff) {
+			deleted++
+			continue
+		}
+		kept = append(kept, session)
+	}
+	m.sessions = kept
+	return deleted, nil
+}
+
+// MockPasswordResetRepository 模擬重設憑證 Repository
+type MockPasswordResetRepository struct {
+	tokens map[uint]*auth_entities.PasswordResetToken
+	nextID uint
+}
+
+func NewMockPasswordResetRepository() *MockPasswordResetRepository {
+	return &MockPasswordResetRepository{tokens: make(map[uint]*auth_entities.PasswordResetToken), nextID: 1}
+}
+
+func (m *MockPasswordResetRepository) Create(token *auth_entities.PasswordResetToken) error {
+	token.ID = m.nextID
+	m.nextID++
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockPasswordResetRepository) FindByHash(codeHash string) (*auth_entities.PasswordResetToken, error) {
+	for _, token := range m.tokens {
+		if token.CodeHash == codeHash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockPasswordResetRepository) Consume(id uint) (bool, error) {
+	if _, ok := m.tokens[id]; !ok {
+		return false, nil
+	}
+	delete(m.tokens, id)
+	return true, nil
+}
+
+func (m *MockPasswordResetRepository) DeleteByMemberID(memberID uint) error {
+	for id, token := range m.tokens {
+		if token.MemberID == memberID {
+			delete(m.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (m *MockPasswordResetRepository) DeleteExpired(now time.Time) (int64, error) {
+	var n int64
+	for id, token := range m.tokens {
+		if token.IsExpired(now) {
+			delete(m.tokens, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MockMailSender 記錄寄出的郵件
+type MockMailSender struct {
+	sent []string // 收件者
+	body string   // 最後一封內容
+}
+
+func (m *MockMailSender) Send(to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	m.body = body
+	return nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+type resetFixture struct {
+	service   *PasswordResetService
+	resetRepo *MockPasswordResetRepository
+	history   *MockMemberHistoryRepository
+	auth      *MockAuthRepository
+	mailer    *MockMailSender
+}
+
+func newResetFixture(t *testing.T) *resetFixture {
+	t.Helper()
+	newMember := func(id uint, email string, enabled bool) *member_entities.Member {
+		memberID, _ := member_value_objects.NewMemberID(id)
+		name, _ := member_value_objects.NewName(fmt.Sprintf("member-%d", id))
+		mail, _ := member_value_objects.NewEmail(email)
+		return &member_entities.Member{ID: memberID, Name: name, Email: mail, IsEnable: enabled}
+	}
+
+	f := &resetFixture{
+		resetRepo: NewMockPasswordResetRepository(),
+		history:   &MockMemberHistoryRepository{},
+		auth:      &MockAuthRepository{},
+		mailer:    &MockMailSender{},
+	}
+	members := &MockMemberRepository{members: map[uint]*member_entities.Member{
+		1: newMember(1, "alice@example.com", true),
+		2: newMember(2, "locked@example.com", false),
+	}}
+	f.service = NewPasswordResetService(members, f.history, f.auth, f.resetRepo, f.mailer, PasswordResetConfig{
+		ResetURL: "https://ems.example.com/reset-password",
+	})
+	return f
+}
+
+// requestToken 申請重設並從郵件連結取出 token
+func (f *resetFixture) requestToken(t *testing.T, redirectPath string) string {
+	t.Helper()
+	if err := f.service.RequestReset("alice@example.com", redirectPath); err != nil {
+		t.Fatalf("申請重設失敗: %v", err)
+	}
+	match := resetTokenPattern.FindStringSubmatch(f.mailer.body)
+	if match == nil {
+		t.Fatalf("郵件中找不到重設連結: %q", f.mailer.body)
+	}
+	return match[1]
+}
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		redirectPath string
+		wantErr      error
+		wantMail     bool
+	}{
+		{"既有帳號寄出連結", "alice@example.com", "/dashboard", nil, true},
+		{"帳號不存在不回報錯誤", "nobody@example.com", "", nil, false},
+		{"停用帳號不寄信", "locked@example.com", "", nil, false},
+		{"外部網址導向", "alice@example.com", "https://evil.example.com", ErrInvalidRedirectPath, false},
+		{"協定相對網址導向", "alice@example.com", "//evil.example.com", ErrInvalidRedirectPath, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newResetFixture(t)
+			err := f.service.RequestReset(tt.email, tt.redirectPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+			if (len(f.mailer.sent) == 1) != tt.wantMail {
+				t.Fatalf("期望寄信 %v，實際寄出 %v", tt.wantMail, f.mailer.sent)
+			}
+			if !tt.wantMail {
+				if len(f.resetRepo.tokens) != 0 {
+					t.Errorf("未寄信時不應建立憑證，得到 %d 筆", len(f.resetRepo.tokens))
+				}
+				return
+			}
+
+			token := resetTokenPattern.FindStringSubmatch(f.mailer.body)[1]
+			stored, _ := f.resetRepo.FindByHash(HashResetToken(token))
+			if stored == nil || stored.CodeHash == token {
+				t.Fatalf("應只保存 token 雜湊")
+			}
+			if stored.RedirectPath != tt.redirectPath {
+				t.Errorf("期望導向 %q，得到 %q", tt.redirectPath, stored.RedirectPath)
+			}
+		})
+	}
+}
+
+func TestPasswordResetService_RequestReset_ReplacesPreviousToken(t *testing.T) {
+	f := newResetFixture(t)
+	first := f.requestToken(t, "")
+	second := f.requestToken(t, "")
+
+	if len(f.resetRepo.tokens) != 1 {
+		t.Fatalf("期望只保留 1 筆憑證，得到 %d", len(f.resetRepo.tokens))
+	}
+	if _, err := f.service.ResetPassword(first, "new-password-1"); !errors.Is(err, auth_entities.ErrResetTokenInvalid) {
+		t.Errorf("舊連結應失效，得到 %v", err)
+	}
+	if _, err := f.service.ResetPassword(second, "new-password-1"); err != nil {
+		t.Errorf("新連結應可使用，得到 %v", err)
+	}
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	f := newResetFixture(t)
+	token := f.requestToken(t, "/settings")
+
+	redirect, err := f.service.ResetPassword(token, "correct horse battery")
+	if err != nil {
+		t.Fatalf("重設失敗: %v", err)
+	}
+	if redirect != "/settings" {
+		t.Errorf("期望導向 /settings，得到 %q", redirect)
+	}
+
+	if len(f.history.histories) != 1 {
+		t.Fatalf("期望新增 1 筆密碼歷史，得到 %d", len(f.history.histories))
+	}
+	h := f.history.histories[0]
+	if h.MemberID.Value() != 1 || !h.ValidatePassword("correct horse battery", h.Salt, h.Hash) {
+		t.Errorf("密碼歷史未以 argon2 保存新密碼")
+	}
+	if len(f.auth.invalidated) != 1 || f.auth.invalidated[0] != 1 {
+		t.Errorf("期望撤銷會員 1 的所有會話，得到 %v", f.auth.invalidated)
+	}
+
+	if _, err := f.service.ResetPassword(token, "another password"); !errors.Is(err, auth_entities.ErrResetTokenInvalid) {
+		t.Errorf("連結只能使用一次，得到 %v", err)
+	}
+}
+
+func TestPasswordResetService_ResetPassword_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepare  func(f *resetFixture, token string)
+		token    func(token string) string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "密碼太短",
+			password: "short",
+			wantErr:  ErrPasswordTooShort,
+		},
+		{
+			name:     "未知的 token",
+			token:    func(string) string { return "not-a-real-token" },
+			password: "long enough password",
+			wantErr:  auth_entities.ErrResetTokenInvalid,
+		},
+		{
+			name: "已過期",
+			prepare: func(f *resetFixture, token string) {
+				stored, _ := f.resetRepo.FindByHash(HashResetToken(token))
+				stored.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			password: "long enough password",
+			wantErr:  auth_entities.ErrResetTokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newResetFixture(t)
+			token := f.requestToken(t, "")
+			if tt.prepare != nil {
+				tt.prepare(f, token)
+			}
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+
+			_, err := f.service.ResetPassword(token, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+			if len(f.history.histories) != 0 || len(f.auth.invalidated) != 0 {
+				t.Errorf("失敗時不應變更密碼或會話")
+			}
+		})
+	}
+}
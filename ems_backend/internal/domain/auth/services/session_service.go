@@ -0,0 +1,155 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	auth_repositories "ems_backend/internal/domain/auth/repositories"
+)
+
+// 會話預設值
+const (
+	DefaultSessionRevocationRetention = 24 * time.Hour
+	sessionIDSize                     = 16          // bytes，JWT 中的 sid
+	sessionSyncOverlap                = time.Minute // 容許多個實例間的時鐘誤差
+)
+
+// SessionConfig 會話撤銷設定
+type SessionConfig struct {
+	// RevocationRetention 撤銷紀錄保留在記憶體與資料庫的時間 (預設 24h)
+	RevocationRetention time.Duration
+
+	// AccessTokenTTL access token 的有效期；保留時間不足以涵蓋時自動延長，
+	// 否則撤銷紀錄被清除後，已登出會話的 access token 在到期前會重新有效
+	AccessTokenTTL time.Duration
+}
+
+// SessionService 會話管理與撤銷檢查
+// 已撤銷的 sid 保存在記憶體中，驗證 token 時不需查詢資料庫；
+// 本實例撤銷的會話立即生效，其他實例 (或直接寫入資料庫的撤銷) 在下一次 Sync 後生效
+type SessionService struct {
+	authRepo auth_repositories.AuthRepository
+	config   SessionConfig
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time // sid -> revoked_at
+	synced  time.Time
+}
+
+// NewSessionService 創建會話服務，需呼叫 Sync 載入既有的撤銷紀錄
+func NewSessionService(authRepo auth_repositories.AuthRepository, config SessionConfig) *SessionService {
+	if config.RevocationRetention <= 0 {
+		config.RevocationRetention = DefaultSessionRevocationRetention
+	}
+	if minimum := config.AccessTokenTTL + sessionSyncOverlap; config.AccessTokenTTL > 0 && config.RevocationRetention < minimum {
+		config.RevocationRetention = minimum
+	}
+	return &SessionService{
+		authRepo: authRepo,
+		config:   config,
+		now:      time.Now,
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// IsRevoked 會話是否已撤銷 (只查詢記憶體)
+func (s *SessionService) IsRevoked(sessionID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[sessionID]
+	return ok
+}
+
+// Sync 從資料庫載入上次同步後的撤銷紀錄並清除超過保留期限的紀錄，返回新增的數量
+func (s *SessionService) Sync() (int, error) {
+	now := s.now()
+	s.mu.RLock()
+	since := s.synced.Add(-sessionSyncOverlap)
+	s.mu.RUnlock()
+	if oldest := now.Add(-s.config.RevocationRetention); since.Before(oldest) {
+		since = oldest
+	}
+
+	revoked, err := s.authRepo.FindRevokedSince(since)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := 0
+	for sessionID, revokedAt := range revoked {
+		if _, ok := s.revoked[sessionID]; !ok {
+			added++
+		}
+		s.revoked[sessionID] = revokedAt
+	}
+	s.pruneLocked(now)
+	s.synced = now
+	return added, nil
+}
+
+// List 列出會員的有效會話
+func (s *SessionService) List(memberID uint) ([]*entities.AuthSession, error) {
+	return s.authRepo.FindActiveSessionsByMemberID(memberID, s.now())
+}
+
+// Revoke 撤銷會員的單一會話
+func (s *SessionService) Revoke(memberID uint, sessionID string) error {
+	session, err := s.authRepo.FindSessionBySessionID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.MemberID.Value() != memberID || !session.IsActive(s.now()) {
+		return entities.ErrSessionNotFound
+	}
+	return s.RevokeSession(session)
+}
+
+// RevokeSession 撤銷指定會話 (登出、refresh token 失效時使用)
+func (s *SessionService) RevokeSession(session *entities.AuthSession) error {
+	if err := s.authRepo.InvalidateSession(session.ID); err != nil {
+		return err
+	}
+	if session.SessionID != "" {
+		s.markRevoked([]string{session.SessionID})
+	}
+	return nil
+}
+
+// RevokeAll 撤銷會員所有會話；exceptSessionID 非空時保留該會話 (例如目前的登入)，返回撤銷數量
+func (s *SessionService) RevokeAll(memberID uint, exceptSessionID string) (int, error) {
+	sessionIDs, err := s.authRepo.RevokeSessionsByMemberID(memberID, exceptSessionID, s.now())
+	if err != nil {
+		return 0, err
+	}
+	s.markRevoked(sessionIDs)
+	return len(sessionIDs), nil
+}
+
+// Purge 刪除撤銷或過期超過保留期限的會話紀錄
+func (s *SessionService) Purge() (int64, error) {
+	return s.authRepo.DeleteSessionsBefore(s.now().Add(-s.config.RevocationRetention))
+}
+
+func (s *SessionService) markRevoked(sessionIDs []string) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sessionID := range sessionIDs {
+		if sessionID != "" {
+			s.revoked[sessionID] = now
+		}
+	}
+}
+
+func (s *SessionService) pruneLocked(now time.Time) {
+	cutoff := now.Add(-s.config.RevocationRetention)
+	for sessionID, revokedAt := range s.revoked {
+		if revokedAt.Before(cutoff) {
+			delete(s.revoked, sessionID)
+		}
+	}
+}
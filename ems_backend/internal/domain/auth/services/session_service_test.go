@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	auth_entities "ems_backend/internal/domain/auth/entities"
+)
+
+type sessionFixture struct {
+	*lockoutFixture
+	auth     *MockAuthRepository
+	sessions *SessionService
+}
+
+func newSessionFixture(t *testing.T) *sessionFixture {
+	t.Helper()
+	f := newLockoutFixture(t, LoginThrottleConfig{FreeAttempts: 100, IPFreeAttempts: 100})
+	auth := f.service.authRepo.(*MockAuthRepository)
+	sessions := NewSessionService(auth, SessionConfig{})
+	f.service.SetSessionService(sessions)
+	return &sessionFixture{lockoutFixture: f, auth: auth, sessions: sessions}
+}
+
+func (f *sessionFixture) login(t *testing.T, userAgent string) *auth_entities.AuthResult {
+	t.Helper()
+	result, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", userAgent)
+	if err != nil {
+		t.Fatalf("登入失敗: %v", err)
+	}
+	return result
+}
+
+func TestAuthService_SessionBoundTokens(t *testing.T) {
+	f := newSessionFixture(t)
+	result := f.login(t, "browser")
+
+	claims, err := f.service.ValidateToken(result.AccessToken)
+	if err != nil || claims.SessionID == "" {
+		t.Fatalf("access token 應帶有 sid，得到 %+v %v", claims, err)
+	}
+	sessions, _ := f.sessions.List(1)
+	if len(sessions) != 1 || sessions[0].SessionID != claims.SessionID || sessions[0].UserAgent != "browser" || sessions[0].IPAddress != "10.0.0.1" {
+		t.Fatalf("會話應記錄 sid、IP 與 User-Agent，得到 %+v", sessions)
+	}
+
+	refreshed, err := f.service.RefreshToken(result.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh 失敗: %v", err)
+	}
+	if refreshedClaims, err := f.service.ValidateToken(refreshed.AccessToken); err != nil || refreshedClaims.SessionID != claims.SessionID {
+		t.Errorf("refresh 後應沿用同一個 sid，得到 %+v %v", refreshedClaims, err)
+	}
+
+	// 登出後 access token 立即失效，refresh token 也無法使用
+	if err := f.service.Logout(result.RefreshToken); err != nil {
+		t.Fatalf("登出失敗: %v", err)
+	}
+	if _, err := f.service.ValidateToken(result.AccessToken); !errors.Is(err, auth_entities.ErrSessionRevoked) {
+		t.Errorf("登出後期望 ErrSessionRevoked，得到 %v", err)
+	}
+	if _, err := f.service.RefreshToken(result.RefreshToken); err == nil {
+		t.Errorf("登出後 refresh token 不可使用")
+	}
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	f := newSessionFixture(t)
+	first := f.login(t, "browser")
+	second := f.login(t, "mobile")
+	firstClaims, _ := f.service.ValidateToken(first.AccessToken)
+	secondClaims, _ := f.service.ValidateToken(second.AccessToken)
+
+	if err := f.sessions.Revoke(2, firstClaims.SessionID); !errors.Is(err, auth_entities.ErrSessionNotFound) {
+		t.Errorf("不可撤銷其他會員的會話，得到 %v", err)
+	}
+
+	revoked, err := f.sessions.RevokeAll(1, secondClaims.SessionID)
+	if err != nil || revoked != 1 {
+		t.Fatalf("保留目前會話時應撤銷 1 個，得到 %d %v", revoked, err)
+	}
+	if _, err := f.service.ValidateToken(first.AccessToken); !errors.Is(err, auth_entities.ErrSessionRevoked) {
+		t.Errorf("被撤銷的會話應立即失效，得到 %v", err)
+	}
+	if _, err := f.service.ValidateToken(second.AccessToken); err != nil {
+		t.Errorf("保留的會話應仍有效，得到 %v", err)
+	}
+
+	if err := f.sessions.Revoke(1, secondClaims.SessionID); err != nil {
+		t.Fatalf("撤銷單一會話失敗: %v", err)
+	}
+	if err := f.sessions.Revoke(1, secondClaims.SessionID); !errors.Is(err, auth_entities.ErrSessionNotFound) {
+		t.Errorf("已撤銷的會話不可重複撤銷，得到 %v", err)
+	}
+	if sessions, _ := f.sessions.List(1); len(sessions) != 0 {
+		t.Errorf("不應列出已撤銷的會話，得到 %d 個", len(sessions))
+	}
+}
+
+func TestSessionService_SyncRevokedElsewhere(t *testing.T) {
+	f := newSessionFixture(t)
+	result := f.login(t, "browser")
+
+	// 模擬其他實例 (或重設密碼) 直接在資料庫撤銷
+	other := NewSessionService(f.auth, SessionConfig{})
+	if _, err := other.RevokeAll(1, ""); err != nil {
+		t.Fatalf("撤銷失敗: %v", err)
+	}
+	if _, err := f.service.ValidateToken(result.AccessToken); err != nil {
+		t.Fatalf("同步前本實例尚不知道撤銷，得到 %v", err)
+	}
+
+	added, err := f.sessions.Sync()
+	if err != nil || added != 1 {
+		t.Fatalf("期望同步 1 筆撤銷紀錄，得到 %d %v", added, err)
+	}
+	if _, err := f.service.ValidateToken(result.AccessToken); !errors.Is(err, auth_entities.ErrSessionRevoked) {
+		t.Errorf("同步後期望 ErrSessionRevoked，得到 %v", err)
+	}
+
+	// 超過保留期限的撤銷紀錄會被清除
+	f.sessions.now = func() time.Time { return time.Now().Add(DefaultSessionRevocationRetention + time.Hour) }
+	if _, err := f.sessions.Sync(); err != nil {
+		t.Fatalf("同步失敗: %v", err)
+	}
+	if len(f.sessions.revoked) != 0 {
+		t.Errorf("超過保留期限的撤銷紀錄應被清除，剩餘 %d 筆", len(f.sessions.revoked))
+	}
+}
+
+func TestNewSessionService_RetentionCoversAccessToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SessionConfig
+		want   time.Duration
+	}{
+		{name: "預設", config: SessionConfig{}, want: DefaultSessionRevocationRetention},
+		{name: "access token 較短", config: SessionConfig{AccessTokenTTL: 5 * time.Minute}, want: DefaultSessionRevocationRetention},
+		{name: "access token 較長時延長", config: SessionConfig{AccessTokenTTL: 48 * time.Hour}, want: 48*time.Hour + sessionSyncOverlap},
+		{name: "指定的保留時間不足", config: SessionConfig{RevocationRetention: time.Hour, AccessTokenTTL: 2 * time.Hour}, want: 2*time.Hour + sessionSyncOverlap},
+		{name: "指定的保留時間足夠", config: SessionConfig{RevocationRetention: 72 * time.Hour, AccessTokenTTL: 2 * time.Hour}, want: 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewSessionService(&MockAuthRepository{}, tt.config)
+			if service.config.RevocationRetention != tt.want {
+				t.Errorf("期望保留 %s，得到 %s", tt.want, service.config.RevocationRetention)
+			}
+		})
+	}
+}
+
+func TestAuthService_Login_DisabledMember(t *testing.T) {
+	f := newSessionFixture(t)
+	f.alice().IsEnable = false
+
+	_, err := f.service.Login("alice@example.com", "correct password", "10.0.0.1", "browser")
+	if !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("停用的帳號不可登入，得到 %v", err)
+	}
+}
@@ -25,8 +25,9 @@ func (t JWTToken) String() string {
 
 // JWTClaims - JWT 聲明
 type JWTClaims struct {
-	MemberID string `json:"member_id"`
-	Username string `json:"username"`
+	MemberID  string `json:"member_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"` // 會話識別碼，撤銷會話後 token 立即失效
 	jwt.RegisteredClaims
 }
 
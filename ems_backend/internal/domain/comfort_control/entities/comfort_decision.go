@@ -0,0 +1,53 @@
+package entities
+
+import "time"
+
+// ComfortDecision - 單一控制目標的啟停決策
+type ComfortDecision struct {
+	CompanyDeviceID uint
+	AreaID          string
+	TargetType      string // ac_unit, compressor (同 device_command)
+	TargetID        string
+	HeatIndex       float64
+	Running         bool   // 決策當下目標運轉狀態
+	Action          string // start, stop, hold
+	Reason          string
+	DryRun          bool
+	DecidedAt       time.Time
+}
+
+// Decision action constants
+const (
+	DecisionStart = "start"
+	DecisionStop  = "stop"
+	DecisionHold  = "hold"
+)
+
+// Decision reason constants
+const (
+	ReasonAboveBand  = "above_comfort_band"
+	ReasonBelowBand  = "below_comfort_band"
+	ReasonInBand     = "within_comfort_band"
+	ReasonMinOnTime  = "min_on_time_not_reached"
+	ReasonMinOffTime = "min_off_time_not_reached"
+	ReasonNoReading  = "no_recent_sensor_reading"
+	ReasonDemandShed = "shed_by_demand_control"
+)
+
+// TargetState - 控制目標目前狀態
+type TargetState struct {
+	Running      bool
+	LastSwitchAt *time.Time // 最近一次啟停時間，未知時為 nil
+}
+
+// IsSwitch - 決策是否需要發送命令
+func (d *ComfortDecision) IsSwitch() bool {
+	return d.Action == DecisionStart || d.Action == DecisionStop
+}
+
+// ControlTarget - 區域對應的可控目標 (VRF 室內機或箱型機壓縮機)
+type ControlTarget struct {
+	TargetType string
+	TargetID   string
+	State      TargetState
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// ComfortSetting - 區域舒適度閉環控制設定
+// 以區域體感溫度 (heat index) 對照舒適區間，自動啟停該區域對應的壓縮機 / VRF 室內機
+type ComfortSetting struct {
+	ID              uint
+	CompanyDeviceID uint
+	AreaID          string
+	Enabled         bool    // 區域開關，關閉時不做任何判斷
+	DryRun          bool    // 僅記錄決策，不發送命令
+	ComfortLow      float64 // 舒適區間下限 (體感 °C)，低於下限停機
+	ComfortHigh     float64 // 舒適區間上限 (體感 °C)，高於上限開機
+	Hysteresis      float64 // 遲滯寬度 (°C)，避免在邊界反覆啟停
+	MinOnSeconds    int     // 開機後最短運轉時間
+	MinOffSeconds   int     // 停機後最短停機時間
+	UpdatedBy       uint
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Default setting values
+const (
+	DefaultComfortLow    = 24.0
+	DefaultComfortHigh   = 27.0
+	DefaultHysteresis    = 0.5
+	DefaultMinOnSeconds  = 300
+	DefaultMinOffSeconds = 300
+)
+
+// NewComfortSetting - 創建預設舒適度設定 (預設停用且為 dry-run)
+func NewComfortSetting(companyDeviceID uint, areaID string) *ComfortSetting {
+	return &ComfortSetting{
+		CompanyDeviceID: companyDeviceID,
+		AreaID:          areaID,
+		Enabled:         false,
+		DryRun:          true,
+		ComfortLow:      DefaultComfortLow,
+		ComfortHigh:     DefaultComfortHigh,
+		Hysteresis:      DefaultHysteresis,
+		MinOnSeconds:    DefaultMinOnSeconds,
+		MinOffSeconds:   DefaultMinOffSeconds,
+	}
+}
+
+// Validate - 驗證設定值
+func (s *ComfortSetting) Validate() error {
+	if s.AreaID == "" {
+		return errors.New("area_id is required")
+	}
+	if s.ComfortLow >= s.ComfortHigh {
+		return errors.New("comfort_low must be lower than comfort_high")
+	}
+	if s.Hysteresis < 0 {
+		return errors.New("hysteresis must not be negative")
+	}
+	if s.MinOnSeconds < 0 || s.MinOffSeconds < 0 {
+		return errors.New("minimum on/off time must not be negative")
+	}
+	return nil
+}
+
+// StartThreshold - 體感溫度達到此值時開機
+func (s *ComfortSetting) StartThreshold() float64 {
+	return s.ComfortHigh + s.Hysteresis
+}
+
+// StopThreshold - 體感溫度降到此值時停機
+func (s *ComfortSetting) StopThreshold() float64 {
+	return s.ComfortLow - s.Hysteresis
+}
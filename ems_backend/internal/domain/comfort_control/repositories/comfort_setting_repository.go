@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/comfort_control/entities"
+)
+
+// ComfortSettingRepository 區域舒適度設定倉儲接口
+type ComfortSettingRepository interface {
+	FindByCompanyDeviceID(companyDeviceID uint) ([]*entities.ComfortSetting, error)
+	// FindByArea 找不到時返回 nil, nil
+	FindByArea(companyDeviceID uint, areaID string) (*entities.ComfortSetting, error)
+	FindEnabled() ([]*entities.ComfortSetting, error)
+	Save(setting *entities.ComfortSetting) error
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/comfort_control/entities"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	commandEntities "ems_backend/internal/domain/device_command/entities"
+)
+
+// ComfortControlService 區域舒適度閉環控制領域服務
+// 依體感溫度、舒適區間、遲滯與最短啟停時間決定控制目標的啟停
+type ComfortControlService struct{}
+
+// NewComfortControlService 創建舒適度控制領域服務
+func NewComfortControlService() *ComfortControlService {
+	return &ComfortControlService{}
+}
+
+// Decide 對單一控制目標做出啟停決策
+//   - 體感 >= 上限 + 遲滯 且未運轉 → start (需滿足最短停機時間)
+//   - 體感 <= 下限 - 遲滯 且運轉中 → stop (需滿足最短運轉時間)
+//   - 其餘情況維持現狀
+func (s *ComfortControlService) Decide(setting *entities.ComfortSetting, heatIndex float64, state entities.TargetState, now time.Time) (string, string) {
+	switch {
+	case heatIndex >= setting.StartThreshold():
+		if state.Running {
+			return entities.DecisionHold, entities.ReasonAboveBand
+		}
+		if !elapsed(state.LastSwitchAt, setting.MinOffSeconds, now) {
+			return entities.DecisionHold, entities.ReasonMinOffTime
+		}
+		return entities.DecisionStart, entities.ReasonAboveBand
+	case heatIndex <= setting.StopThreshold():
+		if !state.Running {
+			return entities.DecisionHold, entities.ReasonBelowBand
+		}
+		if !elapsed(state.LastSwitchAt, setting.MinOnSeconds, now) {
+			return entities.DecisionHold, entities.ReasonMinOnTime
+		}
+		return entities.DecisionStop, entities.ReasonBelowBand
+	default:
+		return entities.DecisionHold, entities.ReasonInBand
+	}
+}
+
+// AreaSensorIDs 取得區域內對應空調的溫度感測器 IDs (與區域總覽的收集規則相同)
+func (s *ComfortControlService) AreaSensorIDs(content *companyDeviceEntities.DeviceContent, area *companyDeviceEntities.Area) []string {
+	seen := make(map[string]bool)
+	var sensorIDs []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			sensorIDs = append(sensorIDs, id)
+		}
+	}
+
+	for _, pkg := range content.Packages {
+		if !hasMapping(area, pkg.ID, true) {
+			continue
+		}
+		add(pkg.TemperatureSensorID)
+		for _, temp := range pkg.Temperatures {
+			if temp.TemperatureSensorID != "" {
+				add(temp.TemperatureSensorID)
+			} else {
+				add(temp.SensorID)
+			}
+		}
+	}
+
+	for i := range content.VRFs {
+		vrf := &content.VRFs[i]
+		mapped := false
+		for _, unit := range vrf.GetUnits() {
+			if hasMapping(area, unit.ID, false) {
+				mapped = true
+				add(unit.TemperatureSensorID)
+			}
+		}
+		if mapped {
+			for _, tempMapping := range vrf.TemperatureMappings {
+				add(tempMapping.TemperatureSensorID)
+			}
+		}
+	}
+
+	return sensorIDs
+}
+
+// AreaTargets 取得區域對應的控制目標：箱型機的所有壓縮機、VRF 對應的室內機
+func (s *ComfortControlService) AreaTargets(content *companyDeviceEntities.DeviceContent, area *companyDeviceEntities.Area) []entities.ControlTarget {
+	var targets []entities.ControlTarget
+
+	for _, pkg := range content.Packages {
+		if !hasMapping(area, pkg.ID, true) {
+			continue
+		}
+		for _, compressor := range pkg.Compressors {
+			targets = append(targets, entities.ControlTarget{
+				TargetType: commandEntities.TargetTypeCompressor,
+				TargetID:   compressor.ID,
+				State:      entities.TargetState{Running: compressor.RunStatus},
+			})
+		}
+	}
+
+	for i := range content.VRFs {
+		for _, unit := range content.VRFs[i].GetUnits() {
+			if !hasMapping(area, unit.ID, false) {
+				continue
+			}
+			targets = append(targets, entities.ControlTarget{
+				TargetType: commandEntities.TargetTypeACUnit,
+				TargetID:   unit.ID,
+				State:      entities.TargetState{Running: unit.IsRunning()},
+			})
+		}
+	}
+
+	return targets
+}
+
+// hasMapping 檢查區域是否對應到指定的箱型機或 VRF 室內機
+func hasMapping(area *companyDeviceEntities.Area, acID string, isPackage bool) bool {
+	for i := range area.ACMappings {
+		mapping := &area.ACMappings[i]
+		if mapping.ACID != acID {
+			continue
+		}
+		if (isPackage && mapping.IsPackage()) || (!isPackage && mapping.IsVRF()) {
+			return true
+		}
+	}
+	return false
+}
+
+// elapsed 檢查距上次啟停是否已超過指定秒數；未知時視為已滿足
+func elapsed(lastSwitchAt *time.Time, seconds int, now time.Time) bool {
+	if lastSwitchAt == nil {
+		return true
+	}
+	return now.Sub(*lastSwitchAt) >= time.Duration(seconds)*time.Second
+}
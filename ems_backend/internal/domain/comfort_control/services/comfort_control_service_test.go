@@ -0,0 +1,149 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/comfort_control/entities"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	commandEntities "ems_backend/internal/domain/device_command/entities"
+)
+
+func TestComfortControlService_Decide(t *testing.T) {
+	now := time.Date(2026, 7, 1, 14, 0, 0, 0, time.UTC)
+	recent := now.Add(-2 * time.Minute)
+	old := now.Add(-10 * time.Minute)
+
+	setting := entities.NewComfortSetting(1, "area-1")
+	setting.Enabled = true
+	// 開機門檻 27.5，停機門檻 23.5，最短啟停 5 分鐘
+
+	tests := []struct {
+		name       string
+		heatIndex  float64
+		state      entities.TargetState
+		wantAction string
+		wantReason string
+	}{
+		{
+			name:       "高於上限且已停機超過最短時間則開機",
+			heatIndex:  28.0,
+			state:      entities.TargetState{Running: false, LastSwitchAt: &old},
+			wantAction: entities.DecisionStart,
+			wantReason: entities.ReasonAboveBand,
+		},
+		{
+			name:       "停機時間未知視為可開機",
+			heatIndex:  28.0,
+			state:      entities.TargetState{Running: false},
+			wantAction: entities.DecisionStart,
+			wantReason: entities.ReasonAboveBand,
+		},
+		{
+			name:       "高於上限但停機未滿最短時間",
+			heatIndex:  28.0,
+			state:      entities.TargetState{Running: false, LastSwitchAt: &recent},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonMinOffTime,
+		},
+		{
+			name:       "高於上限但在遲滯範圍內不開機",
+			heatIndex:  27.2,
+			state:      entities.TargetState{Running: false},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonInBand,
+		},
+		{
+			name:       "高於上限且已運轉則維持",
+			heatIndex:  29.0,
+			state:      entities.TargetState{Running: true},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonAboveBand,
+		},
+		{
+			name:       "低於下限且已運轉超過最短時間則停機",
+			heatIndex:  23.0,
+			state:      entities.TargetState{Running: true, LastSwitchAt: &old},
+			wantAction: entities.DecisionStop,
+			wantReason: entities.ReasonBelowBand,
+		},
+		{
+			name:       "低於下限但運轉未滿最短時間",
+			heatIndex:  23.0,
+			state:      entities.TargetState{Running: true, LastSwitchAt: &recent},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonMinOnTime,
+		},
+		{
+			name:       "低於下限且已停機則維持",
+			heatIndex:  22.0,
+			state:      entities.TargetState{Running: false},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonBelowBand,
+		},
+		{
+			name:       "舒適區間內維持運轉",
+			heatIndex:  25.0,
+			state:      entities.TargetState{Running: true},
+			wantAction: entities.DecisionHold,
+			wantReason: entities.ReasonInBand,
+		},
+	}
+
+	service := NewComfortControlService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, reason := service.Decide(setting, tt.heatIndex, tt.state, now)
+			if action != tt.wantAction || reason != tt.wantReason {
+				t.Errorf("期望 %s (%s)，得到 %s (%s)", tt.wantAction, tt.wantReason, action, reason)
+			}
+		})
+	}
+}
+
+func TestComfortControlService_AreaTargets(t *testing.T) {
+	content := &companyDeviceEntities.DeviceContent{
+		Packages: []companyDeviceEntities.Package{
+			{ID: "pkg-1", TemperatureSensorID: "t-1", Compressors: []companyDeviceEntities.Compressor{
+				{ID: "c-1", RunStatus: true},
+				{ID: "c-2"},
+			}},
+			{ID: "pkg-2", TemperatureSensorID: "t-2", Compressors: []companyDeviceEntities.Compressor{{ID: "c-3"}}},
+		},
+		VRFs: []companyDeviceEntities.VRF{
+			{ID: "vrf-1", ACs: []companyDeviceEntities.ACUnit{
+				{ID: "u-1", Status: float64(1), TemperatureSensorID: "t-3"},
+				{ID: "u-2", TemperatureSensorID: "t-4"},
+			}},
+		},
+	}
+	area := &companyDeviceEntities.Area{
+		ID: "area-1",
+		ACMappings: []companyDeviceEntities.ACMapping{
+			{ACID: "pkg-1", Type: float64(1)},
+			{ACID: "u-1", Type: "vrf"},
+		},
+	}
+
+	service := NewComfortControlService()
+
+	targets := service.AreaTargets(content, area)
+	want := []entities.ControlTarget{
+		{TargetType: commandEntities.TargetTypeCompressor, TargetID: "c-1", State: entities.TargetState{Running: true}},
+		{TargetType: commandEntities.TargetTypeCompressor, TargetID: "c-2"},
+		{TargetType: commandEntities.TargetTypeACUnit, TargetID: "u-1", State: entities.TargetState{Running: true}},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("期望 %d 個控制目標，得到 %d: %+v", len(want), len(targets), targets)
+	}
+	for i := range want {
+		if targets[i].TargetType != want[i].TargetType || targets[i].TargetID != want[i].TargetID || targets[i].State.Running != want[i].State.Running {
+			t.Errorf("目標 %d: 期望 %+v，得到 %+v", i, want[i], targets[i])
+		}
+	}
+
+	sensorIDs := service.AreaSensorIDs(content, area)
+	if len(sensorIDs) != 2 || sensorIDs[0] != "t-1" || sensorIDs[1] != "t-3" {
+		t.Errorf("期望感測器 [t-1 t-3]，得到 %v", sensorIDs)
+	}
+}
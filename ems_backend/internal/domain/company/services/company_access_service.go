@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+
+	"ems_backend/internal/domain/company/entities"
+	"ems_backend/internal/domain/company/repositories"
+	roleEntities "ems_backend/internal/domain/role/entities"
+	roleRepos "ems_backend/internal/domain/role/repositories"
+)
+
+var (
+	// ErrCompanyNotFound 公司不存在
+	ErrCompanyNotFound = errors.New("company not found")
+	// ErrCompanyAccessDenied 目前角色不可存取該公司
+	ErrCompanyAccessDenied = errors.New("access denied")
+)
+
+// maxCompanyDepth 向上追溯母公司的最大層數，避免資料循環時無限迴圈
+const maxCompanyDepth = 64
+
+// CompanyAccessService - 依角色的公司範圍 (role.company_scope) 決定成員可存取的公司
+//
+//	all     所有公司
+//	subtree 成員所屬的公司及其所有子公司
+//	own     成員所屬的公司
+//	none    不可存取 (未設定範圍或已停用的角色)
+type CompanyAccessService struct {
+	roleRepo    roleRepos.RoleRepository
+	companyRepo repositories.CompanyRepository
+}
+
+// NewCompanyAccessService 創建公司存取服務
+func NewCompanyAccessService(roleRepo roleRepos.RoleRepository, companyRepo repositories.CompanyRepository) *CompanyAccessService {
+	return &CompanyAccessService{
+		roleRepo:    roleRepo,
+		companyRepo: companyRepo,
+	}
+}
+
+// Scope 角色的公司範圍，停用的角色視為 none
+func (s *CompanyAccessService) Scope(roleID uint) (roleEntities.CompanyScope, error) {
+	role, err := s.roleRepo.GetByID(roleID)
+	if err != nil {
+		return "", err
+	}
+	if role == nil || !role.IsEnable || !role.CompanyScope.IsValid() {
+		return roleEntities.CompanyScopeNone, nil
+	}
+	return role.CompanyScope, nil
+}
+
+// AccessibleCompanies 成員以指定角色可存取的公司
+func (s *CompanyAccessService) AccessibleCompanies(memberID, roleID uint) ([]*entities.Company, error) {
+	scope, err := s.Scope(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case roleEntities.CompanyScopeAll:
+		return s.companyRepo.FindAll()
+
+	case roleEntities.CompanyScopeSubtree:
+		memberCompanies, err := s.companyRepo.FindByMemberID(memberID)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[uint]bool)
+		result := make([]*entities.Company, 0, len(memberCompanies))
+		add := func(company *entities.Company) {
+			if !seen[company.ID] {
+				seen[company.ID] = true
+				result = append(result, company)
+			}
+		}
+		for _, c := range memberCompanies {
+			add(c)
+			descendants, err := s.companyRepo.FindDescendants(c.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range descendants {
+				add(d)
+			}
+		}
+		return result, nil
+
+	case roleEntities.CompanyScopeOwn:
+		return s.companyRepo.FindByMemberID(memberID)
+
+	default:
+		return []*entities.Company{}, nil
+	}
+}
+
+// CanAccess 成員以指定角色是否可存取公司
+func (s *CompanyAccessService) CanAccess(memberID, roleID uint, company *entities.Company) (bool, error) {
+	scope, err := s.Scope(roleID)
+	if err != nil {
+		return false, err
+	}
+
+	switch scope {
+	case roleEntities.CompanyScopeAll:
+		return true, nil
+
+	case roleEntities.CompanyScopeOwn, roleEntities.CompanyScopeSubtree:
+		memberCompanies, err := s.companyRepo.FindByMemberID(memberID)
+		if err != nil {
+			return false, err
+		}
+		owned := make(map[uint]bool, len(memberCompanies))
+		for _, c := range memberCompanies {
+			owned[c.ID] = true
+		}
+		if owned[company.ID] {
+			return true, nil
+		}
+		if scope == roleEntities.CompanyScopeOwn {
+			return false, nil
+		}
+		return s.hasAncestorIn(company, owned)
+
+	default:
+		return false, nil
+	}
+}
+
+// Authorize 載入公司並檢查存取權，返回 ErrCompanyNotFound 或 ErrCompanyAccessDenied
+func (s *CompanyAccessService) Authorize(memberID, roleID, companyID uint) (*entities.Company, error) {
+	company, err := s.companyRepo.FindByID(companyID)
+	if err != nil {
+		// FindByID 以錯誤表示查無資料，再以 ExistsByID 區分資料庫錯誤
+		if exists, existsErr := s.companyRepo.ExistsByID(companyID); existsErr == nil && !exists {
+			return nil, ErrCompanyNotFound
+		}
+		return nil, err
+	}
+	if company == nil {
+		return nil, ErrCompanyNotFound
+	}
+
+	allowed, err := s.CanAccess(memberID, roleID, company)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrCompanyAccessDenied
+	}
+	return company, nil
+}
+
+// hasAncestorIn 沿著母公司向上查找是否有任一在集合中
+// 與 FindDescendants 一致，中間經過停用的公司時子樹不再延伸
+func (s *CompanyAccessService) hasAncestorIn(company *entities.Company, ids map[uint]bool) (bool, error) {
+	if !company.IsActive {
+		return false, nil
+	}
+	parentID := company.ParentID
+	for depth := 0; parentID != nil && depth < maxCompanyDepth; depth++ {
+		if ids[*parentID] {
+			return true, nil
+		}
+		parent, err := s.companyRepo.FindByID(*parentID)
+		if err != nil {
+			return false, err
+		}
+		if parent == nil || !parent.IsActive {
+			return false, nil
+		}
+		parentID = parent.ParentID
+	}
+	return false, nil
+}
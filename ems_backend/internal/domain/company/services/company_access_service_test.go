@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"ems_backend/internal/domain/company/entities"
+	roleEntities "ems_backend/internal/domain/role/entities"
+)
+
+// MockRoleRepository 模擬角色倉儲
+type MockRoleRepository struct {
+	roles map[uint]*roleEntities.Role
+}
+
+func (m *MockRoleRepository) GetAll() ([]*roleEntities.Role, error) { return nil, nil }
+
+func (m *MockRoleRepository) GetByID(id uint) (*roleEntities.Role, error) {
+	role, ok := m.roles[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return role, nil
+}
+
+func (m *MockRoleRepository) GetByMemberID(memberID uint) ([]*roleEntities.Role, error) {
+	return nil, nil
+}
+func (m *MockRoleRepository) Create(role *roleEntities.Role, memberID uint) error { return nil }
+func (m *MockRoleRepository) Update(role *roleEntities.Role, memberID uint) error { return nil }
+func (m *MockRoleRepository) Delete(id uint) error                                { return nil }
+func (m *MockRoleRepository) AssignPowers(roleID uint, powerIDs []uint, memberID uint) error {
+	return nil
+}
+func (m *MockRoleRepository) RemovePowers(roleID uint, powerIDs []uint) error { return nil }
+func (m *MockRoleRepository) AssignMembers(roleID uint, memberIDs []uint, memberID uint) error {
+	return nil
+}
+func (m *MockRoleRepository) RemoveMembers(roleID uint, memberIDs []uint) error { return nil }
+func (m *MockRoleRepository) GetRoleMembers(roleID uint) ([]uint, error)        { return nil, nil }
+func (m *MockRoleRepository) GetRolePowers(roleID uint) ([]uint, error)         { return nil, nil }
+
+// MockCompanyRepository 模擬公司倉儲
+type MockCompanyRepository struct {
+	companies map[uint]*entities.Company
+	members   map[uint][]uint // memberID -> companyIDs
+}
+
+func (m *MockCompanyRepository) FindByID(id uint) (*entities.Company, error) {
+	company, ok := m.companies[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return company, nil
+}
+
+func (m *MockCompanyRepository) FindByMemberID(memberID uint) ([]*entities.Company, error) {
+	var result []*entities.Company
+	for _, id := range m.members[memberID] {
+		result = append(result, m.companies[id])
+	}
+	return result, nil
+}
+
+func (m *MockCompanyRepository) FindAll() ([]*entities.Company, error) {
+	var result []*entities.Company
+	for _, company := range m.companies {
+		result = append(result, company)
+	}
+	return result, nil
+}
+
+func (m *MockCompanyRepository) FindByParentID(parentID *uint) ([]*entities.Company, error) {
+	return nil, nil
+}
+
+func (m *MockCompanyRepository) FindDescendants(companyID uint) ([]*entities.Company, error) {
+	var result []*entities.Company
+	for _, company := range m.companies {
+		if company.ParentID != nil && *company.ParentID == companyID && company.IsActive {
+			result = append(result, company)
+			children, _ := m.FindDescendants(company.ID)
+			result = append(result, children...)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockCompanyRepository) FindWithDescendants(companyID uint) ([]*entities.Company, error) {
+	return nil, nil
+}
+func (m *MockCompanyRepository) Save(company *entities.Company) error   { return nil }
+func (m *MockCompanyRepository) Update(company *entities.Company) error { return nil }
+func (m *MockCompanyRepository) Delete(id uint) error                   { return nil }
+
+func (m *MockCompanyRepository) ExistsByID(id uint) (bool, error) {
+	company, ok := m.companies[id]
+	return ok && company.IsActive, nil
+}
+
+// newAccessFixture 公司樹: 1 ─ 2 ─ 3，1 ─ 4 (停用) ─ 5，6 獨立
+// 成員 10 屬於公司 1，成員 20 屬於公司 2
+func newAccessFixture() *CompanyAccessService {
+	parent := func(id uint) *uint { return &id }
+	companies := map[uint]*entities.Company{
+		1: {ID: 1, Name: "總公司", IsActive: true},
+		2: {ID: 2, Name: "北區", IsActive: true, ParentID: parent(1)},
+		3: {ID: 3, Name: "台北廠", IsActive: true, ParentID: parent(2)},
+		4: {ID: 4, Name: "停用分公司", IsActive: false, ParentID: parent(1)},
+		5: {ID: 5, Name: "停用分公司下的廠", IsActive: true, ParentID: parent(4)},
+		6: {ID: 6, Name: "其他公司", IsActive: true},
+	}
+	roles := map[uint]*roleEntities.Role{
+		1: {ID: 1, Title: "SystemAdmin", IsEnable: true, CompanyScope: roleEntities.CompanyScopeAll},
+		2: {ID: 2, Title: "company_manager", IsEnable: true, CompanyScope: roleEntities.CompanyScopeSubtree},
+		3: {ID: 3, Title: "company_user", IsEnable: true, CompanyScope: roleEntities.CompanyScopeOwn},
+		4: {ID: 4, Title: "區域主管", IsEnable: true, CompanyScope: roleEntities.CompanyScopeSubtree},
+		5: {ID: 5, Title: "新角色", IsEnable: true},
+		6: {ID: 6, Title: "停用的管理員", IsEnable: false, CompanyScope: roleEntities.CompanyScopeAll},
+	}
+	return NewCompanyAccessService(
+		&MockRoleRepository{roles: roles},
+		&MockCompanyRepository{companies: companies, members: map[uint][]uint{10: {1}, 20: {2}}},
+	)
+}
+
+func companyIDs(companies []*entities.Company) []uint {
+	ids := make([]uint, 0, len(companies))
+	for _, c := range companies {
+		ids = append(ids, c.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestCompanyAccessService_AccessibleCompanies(t *testing.T) {
+	tests := []struct {
+		name     string
+		memberID uint
+		roleID   uint
+		want     []uint
+	}{
+		{"全部範圍可存取所有公司", 10, 1, []uint{1, 2, 3, 4, 5, 6}},
+		{"子樹範圍包含子公司但不經過停用公司", 10, 2, []uint{1, 2, 3}},
+		{"改名的角色依範圍判斷", 20, 4, []uint{2, 3}},
+		{"自身範圍只含所屬公司", 20, 3, []uint{2}},
+		{"未設定範圍的角色看不到公司", 10, 5, []uint{}},
+		{"停用的角色看不到公司", 10, 6, []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newAccessFixture()
+
+			companies, err := service.AccessibleCompanies(tt.memberID, tt.roleID)
+			if err != nil {
+				t.Fatalf("不期望錯誤，但得到: %v", err)
+			}
+			got := companyIDs(companies)
+			if len(got) != len(tt.want) {
+				t.Fatalf("期望公司 %v，得到 %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("期望公司 %v，得到 %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompanyAccessService_Authorize(t *testing.T) {
+	tests := []struct {
+		name      string
+		memberID  uint
+		roleID    uint
+		companyID uint
+		wantErr   error
+	}{
+		{"全部範圍可存取其他公司", 10, 1, 6, nil},
+		{"子樹範圍可存取孫公司", 10, 2, 3, nil},
+		{"子樹範圍不可存取母公司", 20, 4, 1, ErrCompanyAccessDenied},
+		{"子樹範圍不可經過停用公司", 10, 2, 5, ErrCompanyAccessDenied},
+		{"子樹範圍不可存取其他公司", 10, 2, 6, ErrCompanyAccessDenied},
+		{"自身範圍可存取所屬公司", 20, 3, 2, nil},
+		{"自身範圍不可存取子公司", 20, 3, 3, ErrCompanyAccessDenied},
+		{"未設定範圍的角色被拒絕", 10, 5, 1, ErrCompanyAccessDenied},
+		{"公司不存在", 10, 1, 99, ErrCompanyNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newAccessFixture()
+
+			company, err := service.Authorize(tt.memberID, tt.roleID, tt.companyID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (company == nil || company.ID != tt.companyID) {
+				t.Errorf("期望返回公司 %d，得到 %v", tt.companyID, company)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FieldError - 設備內容欄位驗證錯誤
+type FieldError struct {
+	Field   string `json:"field"` // JSON Pointer (RFC 6901)，例如 /packages/0/compressors/1/id
+	Message string `json:"message"`
+}
+
+// ContentValidationError - 設備內容未通過 schema 或關聯驗證
+type ContentValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ContentValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("invalid device content: %s %s", e.Errors[0].Field, e.Errors[0].Message)
+	}
+	return fmt.Sprintf("invalid device content: %d field errors", len(e.Errors))
+}
+
+// ErrContentVersionConflict - 設備內容版本已被其他人更新
+var ErrContentVersionConflict = errors.New("device content version conflict")
+
+// ContentVersion - 取得內容版本 (未解析或空內容為 0)
+func (cd *CompanyDevice) ContentVersion() int64 {
+	content, err := cd.ParseContent()
+	if err != nil {
+		return 0
+	}
+	return content.Version
+}
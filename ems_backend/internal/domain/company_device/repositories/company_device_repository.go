@@ -13,6 +13,8 @@ type CompanyDeviceRepository interface {
 	FindByCompanyAndDevice(companyID, deviceID uint) (*entities.CompanyDevice, error)
 	Save(companyDevice *entities.CompanyDevice) error
 	Update(companyDevice *entities.CompanyDevice) error
+	// UpdateContentIfVersion 僅在資料庫內容版本仍為 expectedVersion 時更新，否則返回 entities.ErrContentVersionConflict
+	UpdateContentIfVersion(companyDevice *entities.CompanyDevice, expectedVersion int64) error
 	Delete(id uint) error
 	DeleteByCompanyAndDevice(companyID, deviceID uint) error
 	ExistsByCompanyAndDevice(companyID, deviceID uint) (bool, error)
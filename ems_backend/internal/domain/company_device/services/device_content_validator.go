@@ -0,0 +1,113 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"ems_backend/internal/domain/company_device/entities"
+)
+
+//go:embed device_content.schema.json
+var deviceContentSchemaJSON []byte
+
+// DeviceContentSchema 返回 DeviceContent 的 JSON Schema 文件
+func DeviceContentSchema() json.RawMessage {
+	return json.RawMessage(deviceContentSchemaJSON)
+}
+
+// DeviceContentValidator 設備內容驗證領域服務
+// 先以 JSON Schema 驗證結構與型別，再檢查 ID 唯一性與 ac_mappings 的參照
+type DeviceContentValidator struct {
+	schema *jsonSchema
+}
+
+// NewDeviceContentValidator 創建設備內容驗證服務
+func NewDeviceContentValidator() *DeviceContentValidator {
+	schema, err := parseJSONSchema(deviceContentSchemaJSON)
+	if err != nil {
+		// schema 為內嵌檔案，解析失敗屬於程式錯誤
+		panic(fmt.Sprintf("invalid embedded device content schema: %v", err))
+	}
+	return &DeviceContentValidator{schema: schema}
+}
+
+// Validate 驗證設備內容；不通過時返回 *entities.ContentValidationError
+func (v *DeviceContentValidator) Validate(raw json.RawMessage) error {
+	document, err := decodeJSON(raw)
+	if err != nil {
+		return &entities.ContentValidationError{Errors: []entities.FieldError{{Field: "/", Message: "is not valid JSON"}}}
+	}
+
+	if errs := v.schema.validate(v.schema, document, ""); len(errs) > 0 {
+		return &entities.ContentValidationError{Errors: errs}
+	}
+
+	var content entities.DeviceContent
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return &entities.ContentValidationError{Errors: []entities.FieldError{{Field: "/", Message: err.Error()}}}
+	}
+	if errs := v.validateReferences(&content); len(errs) > 0 {
+		return &entities.ContentValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateReferences 檢查 ID 重複與 ac_mappings 指向不存在的箱型機 / VRF 室內機
+func (v *DeviceContentValidator) validateReferences(content *entities.DeviceContent) []entities.FieldError {
+	var errs []entities.FieldError
+	duplicate := func(seen map[string]string, id, field string) {
+		if first, ok := seen[id]; ok {
+			errs = append(errs, entities.FieldError{Field: field, Message: fmt.Sprintf("duplicate id %q (already used at %s)", id, first)})
+			return
+		}
+		seen[id] = field
+	}
+
+	areaIDs := make(map[string]string)
+	for i, area := range content.Areas {
+		duplicate(areaIDs, area.ID, fmt.Sprintf("/areas/%d/id", i))
+	}
+
+	packageIDs := make(map[string]string)
+	compressorIDs := make(map[string]string)
+	for i, pkg := range content.Packages {
+		duplicate(packageIDs, pkg.ID, fmt.Sprintf("/packages/%d/id", i))
+		for j, compressor := range pkg.Compressors {
+			duplicate(compressorIDs, compressor.ID, fmt.Sprintf("/packages/%d/compressors/%d/id", i, j))
+		}
+	}
+
+	vrfIDs := make(map[string]string)
+	unitIDs := make(map[string]string)
+	for i := range content.VRFs {
+		vrf := &content.VRFs[i]
+		duplicate(vrfIDs, vrf.ID, fmt.Sprintf("/vrfs/%d/id", i))
+		unitField := "ac_units"
+		if len(vrf.ACs) > 0 {
+			unitField = "acs"
+		}
+		for j, unit := range vrf.GetUnits() {
+			duplicate(unitIDs, unit.ID, fmt.Sprintf("/vrfs/%d/%s/%d/id", i, unitField, j))
+		}
+	}
+
+	for i, area := range content.Areas {
+		for j := range area.ACMappings {
+			mapping := &area.ACMappings[j]
+			field := fmt.Sprintf("/areas/%d/ac_mappings/%d/ac_id", i, j)
+			switch {
+			case mapping.IsPackage():
+				if _, ok := packageIDs[mapping.ACID]; !ok {
+					errs = append(errs, entities.FieldError{Field: field, Message: fmt.Sprintf("references non-existent package %q", mapping.ACID)})
+				}
+			case mapping.IsVRF():
+				if _, ok := unitIDs[mapping.ACID]; !ok {
+					errs = append(errs, entities.FieldError{Field: field, Message: fmt.Sprintf("references non-existent VRF unit %q", mapping.ACID)})
+				}
+			}
+		}
+	}
+
+	return errs
+}
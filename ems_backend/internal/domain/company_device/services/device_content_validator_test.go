@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"ems_backend/internal/domain/company_device/entities"
+)
+
+func TestDeviceContentValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantFields []string
+	}{
+		{
+			name:    "空內容",
+			content: `{}`,
+		},
+		{
+			name: "完整有效內容",
+			content: `{
+				"areas":[{"id":"a1","name":"一樓","ac_mappings":[{"type":1,"ac_id":"p1"},{"type":"vrf","ac_id":"u1"}],
+					"meter_mappings":[{"ID":"m","AreaID":"a1","DeviceMeterID":"meter-1"}]}],
+				"packages":[{"id":"p1","compressors":[{"id":"c1","address":1,"run_status":true,"last_switch_at":null}]}],
+				"vrfs":[{"id":"v1","address":"1","acs":[{"id":"u1","status":{"power_on":true},"name":null}]}],
+				"schedule":{"daily_rules":{"Monday":{"run_period":{"start":"08:00","end":"18:00"},"actions":[{"type":"skip"}]}}},
+				"version":2
+			}`,
+		},
+		{
+			name:       "型別錯誤與缺少必要欄位",
+			content:    `{"packages":[{"compressors":[{"id":"c1","address":"x"}]}],"version":-1}`,
+			wantFields: []string{"/packages/0/id", "/packages/0/compressors/0/address", "/version"},
+		},
+		{
+			name:       "ac_mappings 類型不合法",
+			content:    `{"areas":[{"id":"a1","ac_mappings":[{"type":"split","ac_id":"x"}]}]}`,
+			wantFields: []string{"/areas/0/ac_mappings/0/type"},
+		},
+		{
+			name:       "壓縮機 ID 重複",
+			content:    `{"packages":[{"id":"p1","compressors":[{"id":"c1"}]},{"id":"p2","compressors":[{"id":"c1"}]}]}`,
+			wantFields: []string{"/packages/1/compressors/0/id"},
+		},
+		{
+			name: "ac_mappings 指向不存在的設備",
+			content: `{"areas":[{"id":"a1","ac_mappings":[{"type":0,"ac_id":"u9"},{"type":1,"ac_id":"p9"}]}],
+				"vrfs":[{"id":"v1","ac_units":[{"id":"u1"}]}]}`,
+			wantFields: []string{"/areas/0/ac_mappings/0/ac_id", "/areas/0/ac_mappings/1/ac_id"},
+		},
+		{
+			name:       "排程時間格式錯誤",
+			content:    `{"schedule":{"daily_rules":{"Monday":{"run_period":{"start":"8am","end":"18:00"}}}}}`,
+			wantFields: []string{"/schedule/daily_rules/Monday/run_period/start"},
+		},
+	}
+
+	validator := NewDeviceContentValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(json.RawMessage(tt.content))
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("期望通過驗證，得到 %v", err)
+				}
+				return
+			}
+
+			var validationErr *entities.ContentValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("期望 ContentValidationError，得到 %v", err)
+			}
+			if len(validationErr.Errors) != len(tt.wantFields) {
+				t.Fatalf("期望 %d 個欄位錯誤，得到 %+v", len(tt.wantFields), validationErr.Errors)
+			}
+			for i, field := range tt.wantFields {
+				if validationErr.Errors[i].Field != field {
+					t.Errorf("錯誤 %d: 期望欄位 %s，得到 %s (%s)", i, field, validationErr.Errors[i].Field, validationErr.Errors[i].Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchOperation RFC 6902 JSON Patch 操作
+type JSONPatchOperation struct {
+	Op    string          `json:"op"` // add, remove, replace, move, copy, test
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatchError 指出失敗的操作索引
+type JSONPatchError struct {
+	Index int
+	Op    JSONPatchOperation
+	Err   error
+}
+
+func (e *JSONPatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *JSONPatchError) Unwrap() error {
+	return e.Err
+}
+
+// ErrPatchTestFailed test 操作比對失敗
+var ErrPatchTestFailed = errors.New("test operation failed")
+
+// DecodeJSONPatch 解析 JSON Patch 文件
+func DecodeJSONPatch(data []byte) ([]JSONPatchOperation, error) {
+	var operations []JSONPatchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch document: %w", err)
+	}
+	return operations, nil
+}
+
+// ApplyJSONPatch 依序套用所有操作 (任一失敗則整體失敗，原文件不變)
+func ApplyJSONPatch(document []byte, operations []JSONPatchOperation) ([]byte, error) {
+	doc, err := decodeJSON(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target document: %w", err)
+	}
+
+	for i, operation := range operations {
+		doc, err = applyOperation(doc, operation)
+		if err != nil {
+			return nil, &JSONPatchError{Index: i, Op: operation, Err: err}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func applyOperation(doc any, operation JSONPatchOperation) (any, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := getValue(doc, path); err != nil {
+			return nil, err
+		}
+		doc, _, err = removeValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "move":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if isProperPrefix(from, path) {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		doc, value, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		copied, err := deepCopy(value)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, copied)
+	case "test":
+		value, err := operationValue(operation)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(actual, value) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unsupported op %q", operation.Op)
+	}
+}
+
+// ============================================
+// JSON Pointer (RFC 6901)
+// ============================================
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getValue(doc any, path []string) (any, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			current = child
+		case []any:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %q", token)
+		}
+	}
+	return current, nil
+}
+
+// addValue 在 path 新增 (或覆蓋物件成員) 並返回新的文件
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			index, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("cannot add member %q to a non-container value", token)
+		}
+	})
+}
+
+// removeValue 移除 path 的值並返回新的文件與被移除的值
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the document root")
+	}
+	var removed any
+	doc, err := updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove member %q from a non-container value", token)
+		}
+	})
+	return doc, removed, err
+}
+
+// updateParent 走到 path 的父節點套用 fn，並將 (可能重新配置的) 子節點寫回上層
+func updateParent(node any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	token := path[0]
+	switch container := node.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+		updated, err := updateParent(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = updated
+		return container, nil
+	case []any:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(container[index], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse into %q", token)
+	}
+}
+
+// arrayIndex 解析陣列索引；allowEnd 時允許 "-" 與 len (新增至尾端)
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" {
+		if allowEnd {
+			return length, nil
+		}
+		return 0, errors.New("\"-\" refers to a nonexistent array element")
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+// ============================================
+// Helpers
+// ============================================
+
+func operationValue(operation JSONPatchOperation) (any, error) {
+	if operation.Value == nil {
+		return nil, errors.New("value is required")
+	}
+	return decodeJSON(operation.Value)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func deepCopy(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+// jsonEqual 依 JSON 語意比較 (數字以數值比較，物件不分成員順序)
+func jsonEqual(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
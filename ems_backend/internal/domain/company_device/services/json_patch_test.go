@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	document := `{"areas":[{"id":"a1","name":"一樓"}],"version":3}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "新增陣列元素至尾端",
+			patch: `[{"op":"add","path":"/areas/-","value":{"id":"a2"}}]`,
+			want:  `{"areas":[{"id":"a1","name":"一樓"},{"id":"a2"}],"version":3}`,
+		},
+		{
+			name:  "插入陣列元素",
+			patch: `[{"op":"add","path":"/areas/0","value":{"id":"a0"}}]`,
+			want:  `{"areas":[{"id":"a0"},{"id":"a1","name":"一樓"}],"version":3}`,
+		},
+		{
+			name:  "取代與移除成員",
+			patch: `[{"op":"replace","path":"/areas/0/name","value":"二樓"},{"op":"remove","path":"/version"}]`,
+			want:  `{"areas":[{"id":"a1","name":"二樓"}]}`,
+		},
+		{
+			name:  "複製與搬移",
+			patch: `[{"op":"copy","from":"/areas/0","path":"/areas/1"},{"op":"move","from":"/areas/1/name","path":"/title"}]`,
+			want:  `{"areas":[{"id":"a1","name":"一樓"},{"id":"a1"}],"title":"一樓","version":3}`,
+		},
+		{
+			name:  "test 數值相等",
+			patch: `[{"op":"test","path":"/version","value":3.0}]`,
+			want:  document,
+		},
+		{
+			name:    "test 失敗",
+			patch:   `[{"op":"test","path":"/version","value":4}]`,
+			wantErr: true,
+		},
+		{
+			name:    "取代不存在的成員",
+			patch:   `[{"op":"replace","path":"/packages","value":[]}]`,
+			wantErr: true,
+		},
+		{
+			name:    "陣列索引超出範圍",
+			patch:   `[{"op":"remove","path":"/areas/1"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "不可搬移至自身子節點",
+			patch:   `[{"op":"move","from":"/areas","path":"/areas/0/children"}]`,
+			wantErr: true,
+		},
+		{
+			name:  "跳脫字元",
+			patch: `[{"op":"add","path":"/a~1b~0c","value":1}]`,
+			want:  `{"a/b~c":1,"areas":[{"id":"a1","name":"一樓"}],"version":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations, err := DecodeJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("解析 patch 失敗: %v", err)
+			}
+
+			result, err := ApplyJSONPatch([]byte(document), operations)
+			if tt.wantErr {
+				var patchErr *JSONPatchError
+				if !errors.As(err, &patchErr) {
+					t.Fatalf("期望 JSONPatchError，得到 %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("套用 patch 失敗: %v", err)
+			}
+
+			got, _ := decodeJSON(result)
+			want, _ := decodeJSON([]byte(tt.want))
+			if !jsonEqual(got, want) {
+				t.Errorf("期望 %s，得到 %s", tt.want, result)
+			}
+		})
+	}
+}
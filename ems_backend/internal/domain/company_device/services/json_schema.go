@@ -0,0 +1,242 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ems_backend/internal/domain/company_device/entities"
+)
+
+// jsonSchema 支援 DeviceContent schema 所使用的 JSON Schema 子集：
+// type, properties, required, items, enum, anyOf, minimum, minLength, pattern,
+// additionalProperties (schema) 以及 "#/$defs/..." 形式的 $ref
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	Minimum              *float64               `json:"minimum"`
+	MinLength            *int                   `json:"minLength"`
+	Pattern              string                 `json:"pattern"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes "type" 可為字串或字串陣列
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// parseJSONSchema 解析 schema 並預先編譯 pattern
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (s *jsonSchema) compile() error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	children := []*jsonSchema{s.Items, s.AdditionalProperties}
+	children = append(children, s.AnyOf...)
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+	for _, child := range s.Defs {
+		children = append(children, child)
+	}
+	for _, child := range children {
+		if err := child.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate 驗證以 UseNumber 解碼的 JSON 值，錯誤路徑以 JSON Pointer 表示
+func (s *jsonSchema) validate(root *jsonSchema, value any, path string) []entities.FieldError {
+	if s.Ref != "" {
+		ref, err := root.resolve(s.Ref)
+		if err != nil {
+			return []entities.FieldError{{Field: pointerOrRoot(path), Message: err.Error()}}
+		}
+		return ref.validate(root, value, path)
+	}
+
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, candidate := range s.AnyOf {
+			if len(candidate.validate(root, value, path)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			// 回報最接近 (非 null) 的候選錯誤較具可讀性
+			for _, candidate := range s.AnyOf {
+				if len(candidate.Type) == 1 && candidate.Type[0] == "null" {
+					continue
+				}
+				return candidate.validate(root, value, path)
+			}
+			return []entities.FieldError{{Field: pointerOrRoot(path), Message: "does not match any allowed schema"}}
+		}
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		return []entities.FieldError{{Field: pointerOrRoot(path), Message: fmt.Sprintf("must be %s", strings.Join(s.Type, " or "))}}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		allowed := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			b, _ := json.Marshal(v)
+			allowed[i] = string(b)
+		}
+		return []entities.FieldError{{Field: pointerOrRoot(path), Message: "must be one of " + strings.Join(allowed, ", ")}}
+	}
+
+	var errs []entities.FieldError
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, entities.FieldError{Field: path + "/" + escapePointer(name), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "/" + escapePointer(key)
+			if property, ok := s.Properties[key]; ok {
+				errs = append(errs, property.validate(root, v[key], childPath)...)
+			} else if s.AdditionalProperties != nil {
+				errs = append(errs, s.AdditionalProperties.validate(root, v[key], childPath)...)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(root, item, path+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			errs = append(errs, entities.FieldError{Field: pointerOrRoot(path), Message: fmt.Sprintf("must be at least %d characters", *s.MinLength)})
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			errs = append(errs, entities.FieldError{Field: pointerOrRoot(path), Message: fmt.Sprintf("must match pattern %s", s.Pattern)})
+		}
+	case json.Number:
+		if s.Minimum != nil {
+			if f, err := v.Float64(); err == nil && f < *s.Minimum {
+				errs = append(errs, entities.FieldError{Field: pointerOrRoot(path), Message: fmt.Sprintf("must be >= %v", *s.Minimum)})
+			}
+		}
+	}
+	return errs
+}
+
+// resolve 解析 "#/$defs/name" 形式的參照
+func (s *jsonSchema) resolve(ref string) (*jsonSchema, error) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %s", ref)
+	}
+	def, ok := s.Defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown $ref %s", ref)
+	}
+	return def, nil
+}
+
+func matchesType(types schemaTypes, value any) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(json.Number); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(json.Number); ok {
+				if _, err := n.Int64(); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if jsonEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func pointerOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,51 @@
+package entities
+
+import "time"
+
+// DemandEvent - 卸載 / 復歸事件紀錄
+type DemandEvent struct {
+	ID              uint
+	CompanyID       uint
+	EventType       string // shed, restore
+	CompanyDeviceID uint
+	TargetType      string
+	TargetID        string
+	RollingKW       float64 // 近 15 分鐘平均需量
+	PredictedKW     float64 // 預測本需量時段結束時的平均需量
+	ContractKW      float64
+	DryRun          bool
+	CommandID       string // 實際發送的命令 ID (dry-run 或發送失敗時為空)
+	Message         string
+	CreatedAt       time.Time
+}
+
+// Event type constants
+const (
+	EventTypeShed    = "shed"
+	EventTypeRestore = "restore"
+)
+
+// ActiveShed - 目前處於卸載狀態的負載
+type ActiveShed struct {
+	ID        uint
+	CompanyID uint
+	Load      SheddableLoad
+	DryRun    bool
+	ShedAt    time.Time
+}
+
+// NewDemandEvent - 創建卸載 / 復歸事件
+func NewDemandEvent(companyID uint, eventType string, load SheddableLoad, status *DemandStatus, contractKW float64, dryRun bool) *DemandEvent {
+	return &DemandEvent{
+		CompanyID:       companyID,
+		EventType:       eventType,
+		CompanyDeviceID: load.CompanyDeviceID,
+		TargetType:      load.TargetType,
+		TargetID:        load.TargetID,
+		RollingKW:       status.RollingKW,
+		PredictedKW:     status.PredictedKW,
+		ContractKW:      contractKW,
+		DryRun:          dryRun,
+		CreatedAt:       time.Now(),
+	}
+}
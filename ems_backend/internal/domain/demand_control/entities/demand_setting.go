@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DemandSetting - 公司契約容量需量控制設定
+type DemandSetting struct {
+	CompanyID      uint
+	Enabled        bool
+	DryRun         bool            // 僅記錄卸載 / 復歸事件，不發送命令
+	ContractKW     float64         // 契約容量 (kW)
+	ShedRatio      float64         // 預測需量達契約容量此比例時開始卸載
+	RestoreRatio   float64         // 需量降至契約容量此比例以下時復歸
+	MinShedSeconds int             // 卸載後最短停機時間
+	Loads          []SheddableLoad // 卸載優先順序，排在前面的先卸載
+	ModifyID       uint
+	ModifyTime     time.Time
+}
+
+// SheddableLoad - 可卸載負載 (VRF 室內機或箱型機壓縮機)
+type SheddableLoad struct {
+	CompanyDeviceID uint   `json:"company_device_id"`
+	TargetType      string `json:"target_type"` // ac_unit, compressor
+	TargetID        string `json:"target_id"`
+}
+
+// Default setting values
+const (
+	DefaultShedRatio      = 0.95
+	DefaultRestoreRatio   = 0.85
+	DefaultMinShedSeconds = 300
+)
+
+// NewDemandSetting - 創建預設需量控制設定 (預設停用且為 dry-run)
+func NewDemandSetting(companyID uint) *DemandSetting {
+	return &DemandSetting{
+		CompanyID:      companyID,
+		Enabled:        false,
+		DryRun:         true,
+		ShedRatio:      DefaultShedRatio,
+		RestoreRatio:   DefaultRestoreRatio,
+		MinShedSeconds: DefaultMinShedSeconds,
+		Loads:          []SheddableLoad{},
+	}
+}
+
+// Validate - 驗證設定值
+func (s *DemandSetting) Validate() error {
+	if s.Enabled && s.ContractKW <= 0 {
+		return errors.New("contract_kw must be greater than 0")
+	}
+	if s.ShedRatio <= 0 || s.ShedRatio > 1.2 {
+		return errors.New("shed_ratio must be between 0 and 1.2")
+	}
+	if s.RestoreRatio <= 0 || s.RestoreRatio >= s.ShedRatio {
+		return errors.New("restore_ratio must be greater than 0 and lower than shed_ratio")
+	}
+	if s.MinShedSeconds < 0 {
+		return errors.New("min_shed_seconds must not be negative")
+	}
+
+	seen := make(map[string]bool, len(s.Loads))
+	for _, load := range s.Loads {
+		if load.CompanyDeviceID == 0 || load.TargetID == "" {
+			return errors.New("load company_device_id and target_id are required")
+		}
+		if seen[load.Key()] {
+			return fmt.Errorf("duplicate load %s", load.Key())
+		}
+		seen[load.Key()] = true
+	}
+	return nil
+}
+
+// ShedThresholdKW - 開始卸載的需量門檻
+func (s *DemandSetting) ShedThresholdKW() float64 {
+	return s.ContractKW * s.ShedRatio
+}
+
+// RestoreThresholdKW - 允許復歸的需量門檻
+func (s *DemandSetting) RestoreThresholdKW() float64 {
+	return s.ContractKW * s.RestoreRatio
+}
+
+// Key - 負載唯一識別 "companyDeviceID/targetType/targetID"
+func (l SheddableLoad) Key() string {
+	return fmt.Sprintf("%d/%s/%s", l.CompanyDeviceID, l.TargetType, l.TargetID)
+}
@@ -0,0 +1,29 @@
+package entities
+
+import "time"
+
+// DemandWindow - 需量計費時段長度 (台電以 15 分鐘平均需量計算)
+const DemandWindow = 15 * time.Minute
+
+// DemandStatus - 公司目前需量狀態
+type DemandStatus struct {
+	CompanyID       uint
+	RollingKW       float64   // 近 15 分鐘滾動平均需量
+	WindowStart     time.Time // 目前需量時段起點 (對齊 00/15/30/45 分)
+	WindowEnd       time.Time
+	WindowAverageKW float64 // 本時段至今的平均需量
+	CurrentKW       float64 // 各電表最新瞬時需量加總
+	PredictedKW     float64 // 預測本時段結束時的平均需量
+	MeterCount      int     // 有讀值的電表數
+	CalculatedAt    time.Time
+}
+
+// DemandAction - 需量控制動作
+type DemandAction struct {
+	Type string // shed, restore, hold
+	Load *SheddableLoad
+	Shed *ActiveShed // restore 時對應的卸載紀錄
+}
+
+// ActionHold - 維持現狀
+const ActionHold = "hold"
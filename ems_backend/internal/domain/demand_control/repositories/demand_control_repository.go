@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/demand_control/entities"
+)
+
+// DemandControlRepository 需量控制倉儲接口
+type DemandControlRepository interface {
+	// FindSettingByCompanyID 未設定時返回 nil, nil
+	FindSettingByCompanyID(companyID uint) (*entities.DemandSetting, error)
+	FindEnabledSettings() ([]*entities.DemandSetting, error)
+	SaveSetting(setting *entities.DemandSetting) error
+
+	FindActiveSheds(companyID uint) ([]*entities.ActiveShed, error)
+	SaveActiveShed(shed *entities.ActiveShed) error
+	DeleteActiveShed(id uint) error
+
+	SaveEvent(event *entities.DemandEvent) error
+	FindEventsByCompanyID(companyID uint, limit int) ([]*entities.DemandEvent, error)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/demand_control/entities"
+	meterEntities "ems_backend/internal/domain/meter/entities"
+)
+
+// DemandControlService 契約容量需量控制領域服務
+// 計算滾動 15 分鐘平均需量、預測時段結束需量，並依優先順序決定卸載 / 復歸
+type DemandControlService struct{}
+
+// NewDemandControlService 創建需量控制領域服務
+func NewDemandControlService() *DemandControlService {
+	return &DemandControlService{}
+}
+
+// WindowBounds 取得 now 所在的需量時段 (對齊 00/15/30/45 分)
+func (s *DemandControlService) WindowBounds(now time.Time) (time.Time, time.Time) {
+	start := now.Truncate(entities.DemandWindow)
+	return start, start.Add(entities.DemandWindow)
+}
+
+// QueryStart 計算需量所需電表資料的起始時間
+func (s *DemandControlService) QueryStart(now time.Time) time.Time {
+	windowStart, _ := s.WindowBounds(now)
+	rollingStart := now.Add(-entities.DemandWindow)
+	if windowStart.Before(rollingStart) {
+		return windowStart
+	}
+	return rollingStart
+}
+
+// Calculate 由各電表讀值計算公司需量狀態
+// 各電表分別計算平均後加總；預測值 = 時段內已發生平均 × 已過比例 + 目前需量 × 剩餘比例
+func (s *DemandControlService) Calculate(companyID uint, readings []*meterEntities.Meter, now time.Time) *entities.DemandStatus {
+	windowStart, windowEnd := s.WindowBounds(now)
+	rollingStart := now.Add(-entities.DemandWindow)
+
+	status := &entities.DemandStatus{
+		CompanyID:    companyID,
+		WindowStart:  windowStart,
+		WindowEnd:    windowEnd,
+		CalculatedAt: now,
+	}
+
+	byMeter := make(map[string][]*meterEntities.Meter)
+	for _, reading := range readings {
+		if reading == nil || reading.Timestamp.After(now) {
+			continue
+		}
+		byMeter[reading.MeterID] = append(byMeter[reading.MeterID], reading)
+	}
+
+	elapsed := float64(now.Sub(windowStart)) / float64(entities.DemandWindow)
+	for _, meterReadings := range byMeter {
+		var latest *meterEntities.Meter
+		var rollingSum, windowSum float64
+		rollingCount, windowCount := 0, 0
+
+		for _, reading := range meterReadings {
+			if latest == nil || reading.Timestamp.After(latest.Timestamp) {
+				latest = reading
+			}
+			if !reading.Timestamp.Before(rollingStart) {
+				rollingSum += reading.KW
+				rollingCount++
+			}
+			if !reading.Timestamp.Before(windowStart) {
+				windowSum += reading.KW
+				windowCount++
+			}
+		}
+
+		status.MeterCount++
+		status.CurrentKW += latest.KW
+
+		rollingAvg := latest.KW
+		if rollingCount > 0 {
+			rollingAvg = rollingSum / float64(rollingCount)
+		}
+		status.RollingKW += rollingAvg
+
+		windowAvg := latest.KW
+		if windowCount > 0 {
+			windowAvg = windowSum / float64(windowCount)
+		}
+		status.WindowAverageKW += windowAvg
+		status.PredictedKW += windowAvg*elapsed + latest.KW*(1-elapsed)
+	}
+
+	return status
+}
+
+// Decide 決定本次控制動作，每次最多卸載或復歸一個負載以避免震盪
+//   - 預測需量 >= 卸載門檻：依優先順序卸載下一個運轉中的負載
+//   - 預測與滾動需量皆 <= 復歸門檻：復歸最後卸載且已滿最短停機時間的負載
+func (s *DemandControlService) Decide(
+	setting *entities.DemandSetting,
+	status *entities.DemandStatus,
+	active []*entities.ActiveShed,
+	running map[string]bool,
+	now time.Time,
+) entities.DemandAction {
+	if status.MeterCount == 0 || setting.ContractKW <= 0 {
+		return entities.DemandAction{Type: entities.ActionHold}
+	}
+
+	shed := make(map[string]bool, len(active))
+	for _, a := range active {
+		shed[a.Load.Key()] = true
+	}
+
+	if status.PredictedKW >= setting.ShedThresholdKW() {
+		for i := range setting.Loads {
+			load := setting.Loads[i]
+			if shed[load.Key()] || !running[load.Key()] {
+				continue
+			}
+			return entities.DemandAction{Type: entities.EventTypeShed, Load: &load}
+		}
+		return entities.DemandAction{Type: entities.ActionHold}
+	}
+
+	if status.PredictedKW <= setting.RestoreThresholdKW() && status.RollingKW <= setting.RestoreThresholdKW() {
+		var latest *entities.ActiveShed
+		for _, a := range active {
+			if latest == nil || a.ShedAt.After(latest.ShedAt) {
+				latest = a
+			}
+		}
+		if latest != nil && now.Sub(latest.ShedAt) >= time.Duration(setting.MinShedSeconds)*time.Second {
+			load := latest.Load
+			return entities.DemandAction{Type: entities.EventTypeRestore, Load: &load, Shed: latest}
+		}
+	}
+
+	return entities.DemandAction{Type: entities.ActionHold}
+}
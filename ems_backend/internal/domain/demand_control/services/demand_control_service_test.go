@@ -0,0 +1,143 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/demand_control/entities"
+	meterEntities "ems_backend/internal/domain/meter/entities"
+)
+
+func TestDemandControlService_Calculate(t *testing.T) {
+	// 時段 10:00-10:15，目前 10:05 (已過 1/3)
+	now := time.Date(2026, 7, 1, 10, 5, 0, 0, time.UTC)
+	readings := []*meterEntities.Meter{
+		// m1：上一時段 200kW，本時段平均 100kW，最新 130kW
+		{MeterID: "m1", Timestamp: now.Add(-10 * time.Minute), KW: 200},
+		{MeterID: "m1", Timestamp: now.Add(-4 * time.Minute), KW: 70},
+		{MeterID: "m1", Timestamp: now.Add(-1 * time.Minute), KW: 130},
+		// m2：只有一筆讀值
+		{MeterID: "m2", Timestamp: now.Add(-2 * time.Minute), KW: 50},
+	}
+
+	status := NewDemandControlService().Calculate(1, readings, now)
+
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"滾動平均", status.RollingKW, (200+70+130)/3.0 + 50},
+		{"時段平均", status.WindowAverageKW, 100 + 50},
+		{"目前需量", status.CurrentKW, 130 + 50},
+		{"預測需量", status.PredictedKW, (100*1.0/3 + 130*2.0/3) + 50},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 0.001 {
+			t.Errorf("%s: 期望 %.3f，得到 %.3f", c.name, c.want, c.got)
+		}
+	}
+	if status.MeterCount != 2 {
+		t.Errorf("期望 2 個電表，得到 %d", status.MeterCount)
+	}
+	if !status.WindowStart.Equal(time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("時段起點錯誤: %s", status.WindowStart)
+	}
+}
+
+func TestDemandControlService_Decide(t *testing.T) {
+	now := time.Date(2026, 7, 1, 10, 5, 0, 0, time.UTC)
+	loadA := entities.SheddableLoad{CompanyDeviceID: 1, TargetType: "compressor", TargetID: "c-1"}
+	loadB := entities.SheddableLoad{CompanyDeviceID: 1, TargetType: "ac_unit", TargetID: "u-1"}
+
+	setting := entities.NewDemandSetting(1)
+	setting.Enabled = true
+	setting.ContractKW = 100 // 卸載門檻 95，復歸門檻 85
+	setting.Loads = []entities.SheddableLoad{loadA, loadB}
+
+	allRunning := map[string]bool{loadA.Key(): true, loadB.Key(): true}
+	shedA := &entities.ActiveShed{ID: 1, Load: loadA, ShedAt: now.Add(-10 * time.Minute)}
+	shedBRecent := &entities.ActiveShed{ID: 2, Load: loadB, ShedAt: now.Add(-1 * time.Minute)}
+	shedBOld := &entities.ActiveShed{ID: 2, Load: loadB, ShedAt: now.Add(-6 * time.Minute)}
+
+	tests := []struct {
+		name       string
+		status     entities.DemandStatus
+		active     []*entities.ActiveShed
+		running    map[string]bool
+		wantType   string
+		wantTarget string
+	}{
+		{
+			name:     "無電表讀值不動作",
+			status:   entities.DemandStatus{PredictedKW: 120},
+			running:  allRunning,
+			wantType: entities.ActionHold,
+		},
+		{
+			name:       "超過卸載門檻依優先順序卸載",
+			status:     entities.DemandStatus{MeterCount: 1, PredictedKW: 96, RollingKW: 90},
+			running:    allRunning,
+			wantType:   entities.EventTypeShed,
+			wantTarget: "c-1",
+		},
+		{
+			name:       "已卸載或未運轉的負載跳過",
+			status:     entities.DemandStatus{MeterCount: 1, PredictedKW: 96},
+			active:     []*entities.ActiveShed{shedA},
+			running:    map[string]bool{loadB.Key(): true},
+			wantType:   entities.EventTypeShed,
+			wantTarget: "u-1",
+		},
+		{
+			name:     "無可卸載負載",
+			status:   entities.DemandStatus{MeterCount: 1, PredictedKW: 120},
+			active:   []*entities.ActiveShed{shedA, shedBOld},
+			running:  allRunning,
+			wantType: entities.ActionHold,
+		},
+		{
+			name:     "介於門檻之間維持",
+			status:   entities.DemandStatus{MeterCount: 1, PredictedKW: 90, RollingKW: 80},
+			active:   []*entities.ActiveShed{shedA},
+			running:  allRunning,
+			wantType: entities.ActionHold,
+		},
+		{
+			name:       "低於復歸門檻復歸最後卸載的負載",
+			status:     entities.DemandStatus{MeterCount: 1, PredictedKW: 70, RollingKW: 75},
+			active:     []*entities.ActiveShed{shedA, shedBOld},
+			running:    allRunning,
+			wantType:   entities.EventTypeRestore,
+			wantTarget: "u-1",
+		},
+		{
+			name:     "未滿最短卸載時間不復歸",
+			status:   entities.DemandStatus{MeterCount: 1, PredictedKW: 70, RollingKW: 75},
+			active:   []*entities.ActiveShed{shedA, shedBRecent},
+			running:  allRunning,
+			wantType: entities.ActionHold,
+		},
+		{
+			name:     "滾動平均仍高不復歸",
+			status:   entities.DemandStatus{MeterCount: 1, PredictedKW: 70, RollingKW: 90},
+			active:   []*entities.ActiveShed{shedA},
+			running:  allRunning,
+			wantType: entities.ActionHold,
+		},
+	}
+
+	service := NewDemandControlService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := service.Decide(setting, &tt.status, tt.active, tt.running, now)
+			if action.Type != tt.wantType {
+				t.Fatalf("期望動作 %s，得到 %s", tt.wantType, action.Type)
+			}
+			if tt.wantTarget != "" && (action.Load == nil || action.Load.TargetID != tt.wantTarget) {
+				t.Errorf("期望目標 %s，得到 %+v", tt.wantTarget, action.Load)
+			}
+		})
+	}
+}
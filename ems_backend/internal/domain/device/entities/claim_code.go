@@ -0,0 +1,101 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// 認領碼狀態
+const (
+	ClaimCodeStatusActive   = "active"
+	ClaimCodeStatusRedeemed = "redeemed"
+	ClaimCodeStatusExpired  = "expired"
+	ClaimCodeStatusRevoked  = "revoked"
+)
+
+var (
+	// ErrClaimCodeInvalid 認領碼不存在或已被撤銷
+	ErrClaimCodeInvalid = errors.New("invalid claim code")
+	// ErrClaimCodeExpired 認領碼已過期
+	ErrClaimCodeExpired = errors.New("claim code expired")
+	// ErrClaimCodeRedeemed 認領碼已被使用
+	ErrClaimCodeRedeemed = errors.New("claim code already redeemed")
+)
+
+// ClaimCode 設備一次性認領碼
+// 只保存雜湊值，明文僅在簽發時返回一次
+type ClaimCode struct {
+	ID           uint
+	DeviceID     uint
+	CodeHash     string // SHA-256 (hex)
+	CodeHint     string // 明文末四碼，供辨識用
+	Status       string
+	ExpiresAt    time.Time
+	IssuedBy     uint
+	IssuedRoleID uint // 簽發者當時的角色，過期時以此記錄審計
+	IssuedAt     time.Time
+	RedeemedBy   *uint
+	RedeemedAt   *time.Time
+	CompanyID    *uint // 兌換後綁定的公司
+	ClosedAt     *time.Time
+}
+
+// NewClaimCode 創建認領碼
+func NewClaimCode(deviceID uint, codeHash, codeHint string, ttl time.Duration, issuedBy, issuedRoleID uint) *ClaimCode {
+	now := time.Now()
+	return &ClaimCode{
+		DeviceID:     deviceID,
+		CodeHash:     codeHash,
+		CodeHint:     codeHint,
+		Status:       ClaimCodeStatusActive,
+		ExpiresAt:    now.Add(ttl),
+		IssuedBy:     issuedBy,
+		IssuedRoleID: issuedRoleID,
+		IssuedAt:     now,
+	}
+}
+
+// IsExpired 是否已超過有效期限
+func (c *ClaimCode) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// CheckRedeemable 檢查認領碼是否可兌換
+func (c *ClaimCode) CheckRedeemable(now time.Time) error {
+	switch c.Status {
+	case ClaimCodeStatusRedeemed:
+		return ErrClaimCodeRedeemed
+	case ClaimCodeStatusExpired:
+		return ErrClaimCodeExpired
+	case ClaimCodeStatusRevoked:
+		return ErrClaimCodeInvalid
+	}
+	if c.IsExpired(now) {
+		return ErrClaimCodeExpired
+	}
+	return nil
+}
+
+// Redeem 標記為已兌換
+func (c *ClaimCode) Redeem(companyID, memberID uint) {
+	now := time.Now()
+	c.Status = ClaimCodeStatusRedeemed
+	c.RedeemedBy = &memberID
+	c.RedeemedAt = &now
+	c.CompanyID = &companyID
+	c.ClosedAt = &now
+}
+
+// Expire 標記為已過期
+func (c *ClaimCode) Expire() {
+	now := time.Now()
+	c.Status = ClaimCodeStatusExpired
+	c.ClosedAt = &now
+}
+
+// Revoke 標記為已撤銷 (重新簽發時作廢舊碼)
+func (c *ClaimCode) Revoke() {
+	now := time.Now()
+	c.Status = ClaimCodeStatusRevoked
+	c.ClosedAt = &now
+}
@@ -6,6 +6,8 @@ import "time"
 type Device struct {
 	ID         uint
 	SN         string
+	Model      string // 設備型號
+	Notes      string // 備註
 	CreateID   uint
 	CreateTime time.Time
 	ModifyID   uint
@@ -30,3 +32,9 @@ func (d *Device) Update(sn string, modifyID uint) {
 	d.ModifyID = modifyID
 	d.ModifyTime = time.Now()
 }
+
+// SetDetails 設定型號與備註
+func (d *Device) SetDetails(model, notes string) {
+	d.Model = model
+	d.Notes = notes
+}
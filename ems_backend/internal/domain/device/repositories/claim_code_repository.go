@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/device/entities"
+)
+
+// ClaimCodeRepository 設備認領碼倉儲接口
+type ClaimCodeRepository interface {
+	// Create 創建認領碼
+	Create(code *entities.ClaimCode) error
+
+	// FindByHash 根據雜湊查找認領碼，不存在時返回 nil
+	FindByHash(codeHash string) (*entities.ClaimCode, error)
+
+	// FindByDeviceID 查找設備的所有認領碼 (新到舊)
+	FindByDeviceID(deviceID uint) ([]*entities.ClaimCode, error)
+
+	// FindActiveExpired 查找狀態仍為 active 但已超過期限的認領碼
+	FindActiveExpired(now time.Time) ([]*entities.ClaimCode, error)
+
+	// RevokeActiveByDeviceID 撤銷設備所有仍有效的認領碼，返回撤銷數量
+	RevokeActiveByDeviceID(deviceID uint) (int64, error)
+
+	// CloseIfActive 僅在狀態仍為 active 時更新 (兌換 / 過期)，返回是否更新成功
+	CloseIfActive(code *entities.ClaimCode) (bool, error)
+
+	// Reopen 兌換後綁定失敗時恢復為 active
+	Reopen(id uint) error
+}
@@ -30,4 +30,13 @@ type DeviceRepository interface {
 
 	// FindUnassigned 查找未被綁定到任何公司的設備
 	FindUnassigned() ([]*entities.Device, error)
+
+	// IsAssigned 檢查設備是否已綁定到公司
+	IsAssigned(id uint) (bool, error)
+
+	// FindExistingSNs 返回列表中已存在的 SN
+	FindExistingSNs(sns []string) ([]string, error)
+
+	// CreateBatch 在同一交易內批次創建設備
+	CreateBatch(devices []*entities.Device) error
 }
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// 匯入限制
+const (
+	MaxImportRows  = 5000
+	MaxSNLength    = 128
+	MaxModelLength = 64
+	MaxNotesLength = 500
+)
+
+// 認領碼格式：12 碼 (去除易混淆的 0/O/1/I)，以 XXXX-XXXX-XXXX 顯示
+const (
+	claimCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	claimCodeLength   = 12
+	claimCodeGroup    = 4
+)
+
+// SN 會出現在 MQTT topic (ems_vrv/{sn}/...)，不可包含 / + # 或空白
+var snPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrEmptyImport CSV 沒有任何資料列
+var ErrEmptyImport = errors.New("CSV contains no device rows")
+
+// ImportRow CSV 單列解析與驗證結果
+type ImportRow struct {
+	Line   int      `json:"line"`
+	SN     string   `json:"sn"`
+	Model  string   `json:"model"`
+	Notes  string   `json:"notes"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Valid 該列是否通過驗證
+func (r *ImportRow) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// ProvisioningService 設備批次建檔與認領碼領域服務
+type ProvisioningService struct{}
+
+// NewProvisioningService 創建設備建檔領域服務
+func NewProvisioningService() *ProvisioningService {
+	return &ProvisioningService{}
+}
+
+// ParseCSV 解析設備 CSV (欄位 sn, model, notes)
+// 第一列若為標題列則依標題對應欄位 (順序不限)，否則依 sn, model, notes 位置讀取；# 開頭為註解
+func (s *ProvisioningService) ParseCSV(r io.Reader) ([]*ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	columns := map[string]int{"sn": 0, "model": 1, "notes": 2}
+	var rows []*ImportRow
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			// Excel 匯出的 UTF-8 CSV 會帶 BOM
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if header, ok := parseHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("too many rows: at most %d devices per import", MaxImportRows)
+		}
+		rows = append(rows, &ImportRow{
+			Line:  line,
+			SN:    field(record, columns, "sn"),
+			Model: field(record, columns, "model"),
+			Notes: field(record, columns, "notes"),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+	return rows, nil
+}
+
+// ValidateRows 逐列驗證並記錄錯誤；existingSNs 為資料庫中已存在的 SN
+func (s *ProvisioningService) ValidateRows(rows []*ImportRow, existingSNs map[string]bool) {
+	firstLine := make(map[string]int, len(rows))
+	for _, row := range rows {
+		row.Errors = nil
+		switch {
+		case row.SN == "":
+			row.Errors = append(row.Errors, "sn is required")
+		case utf8.RuneCountInString(row.SN) > MaxSNLength:
+			row.Errors = append(row.Errors, fmt.Sprintf("sn exceeds %d characters", MaxSNLength))
+		case !snPattern.MatchString(row.SN):
+			row.Errors = append(row.Errors, "sn may only contain letters, digits, '.', '_' and '-'")
+		}
+		if utf8.RuneCountInString(row.Model) > MaxModelLength {
+			row.Errors = append(row.Errors, fmt.Sprintf("model exceeds %d characters", MaxModelLength))
+		}
+		if utf8.RuneCountInString(row.Notes) > MaxNotesLength {
+			row.Errors = append(row.Errors, fmt.Sprintf("notes exceed %d characters", MaxNotesLength))
+		}
+		if row.SN == "" {
+			continue
+		}
+
+		if line, ok := firstLine[row.SN]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("duplicate sn in file (first seen on line %d)", line))
+			continue
+		}
+		firstLine[row.SN] = row.Line
+		if existingSNs[row.SN] {
+			row.Errors = append(row.Errors, "sn already exists")
+		}
+	}
+}
+
+// GenerateClaimCode 產生認領碼，返回明文 (XXXX-XXXX-XXXX)、雜湊與末四碼提示
+func (s *ProvisioningService) GenerateClaimCode() (code, hash, hint string, err error) {
+	buf := make([]byte, claimCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+
+	// 256 可被 32 整除，取餘數不會造成偏差
+	raw := make([]byte, claimCodeLength)
+	for i, b := range buf {
+		raw[i] = claimCodeAlphabet[int(b)%len(claimCodeAlphabet)]
+	}
+
+	groups := make([]string, 0, claimCodeLength/claimCodeGroup)
+	for i := 0; i < claimCodeLength; i += claimCodeGroup {
+		groups = append(groups, string(raw[i:i+claimCodeGroup]))
+	}
+	code = strings.Join(groups, "-")
+	return code, s.HashClaimCode(code), string(raw[claimCodeLength-claimCodeGroup:]), nil
+}
+
+// NormalizeClaimCode 去除分隔符與空白並轉大寫；格式不符時返回空字串
+func (s *ProvisioningService) NormalizeClaimCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		if !strings.ContainsRune(claimCodeAlphabet, r) {
+			return ""
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() != claimCodeLength {
+		return ""
+	}
+	return b.String()
+}
+
+// HashClaimCode 計算正規化後認領碼的 SHA-256
+func (s *ProvisioningService) HashClaimCode(code string) string {
+	sum := sha256.Sum256([]byte(s.NormalizeClaimCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+func parseHeader(record []string) (map[string]int, bool) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "sn", "serial", "serial_number":
+			columns["sn"] = i
+		case "model":
+			columns["model"] = i
+		case "notes", "note":
+			columns["notes"] = i
+		}
+	}
+	if _, ok := columns["sn"]; !ok {
+		return nil, false
+	}
+	return columns, true
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProvisioningService_ParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []ImportRow
+		wantErr bool
+	}{
+		{
+			name:  "標題列可調整欄位順序",
+			input: "model,sn,notes\nVRV-X,SN-001,一樓\nVRV-Y,SN-002,\n",
+			want: []ImportRow{
+				{Line: 2, SN: "SN-001", Model: "VRV-X", Notes: "一樓"},
+				{Line: 3, SN: "SN-002", Model: "VRV-Y"},
+			},
+		},
+		{
+			name:  "無標題列依位置讀取並略過空行與註解",
+			input: "SN-001, VRV-X\n\n# 備用機\n,,\nSN-002\n",
+			want: []ImportRow{
+				{Line: 1, SN: "SN-001", Model: "VRV-X"},
+				{Line: 5, SN: "SN-002"},
+			},
+		},
+		{
+			name:  "帶 BOM 的標題列",
+			input: "\ufeffSerial_Number,Notes\nSN-003,\"含,逗號\"\n",
+			want: []ImportRow{
+				{Line: 2, SN: "SN-003", Notes: "含,逗號"},
+			},
+		},
+		{name: "只有標題列", input: "sn,model,notes\n", wantErr: true},
+		{name: "引號未關閉", input: "sn\n\"SN-001\n", wantErr: true},
+	}
+
+	service := NewProvisioningService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := service.ParseCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期望錯誤 %v，得到 %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]ImportRow, len(rows))
+			for i, row := range rows {
+				got[i] = *row
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("期望 %+v，得到 %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProvisioningService_ValidateRows(t *testing.T) {
+	rows := []*ImportRow{
+		{Line: 2, SN: "SN-001", Model: "VRV-X"},
+		{Line: 3, SN: ""},
+		{Line: 4, SN: "SN/002"},
+		{Line: 5, SN: "SN-001"},
+		{Line: 6, SN: "SN-EXIST"},
+		{Line: 7, SN: "SN-004", Model: strings.Repeat("M", MaxModelLength+1)},
+		{Line: 8, SN: strings.Repeat("S", MaxSNLength+1)},
+	}
+
+	NewProvisioningService().ValidateRows(rows, map[string]bool{"SN-EXIST": true})
+
+	wantErrors := map[int]string{
+		3: "sn is required",
+		4: "sn may only contain letters, digits, '.', '_' and '-'",
+		5: "duplicate sn in file (first seen on line 2)",
+		6: "sn already exists",
+		7: "model exceeds 64 characters",
+		8: "sn exceeds 128 characters",
+	}
+	for _, row := range rows {
+		want, hasError := wantErrors[row.Line]
+		if !hasError {
+			if !row.Valid() {
+				t.Errorf("第 %d 列期望通過，得到 %v", row.Line, row.Errors)
+			}
+			continue
+		}
+		if len(row.Errors) != 1 || row.Errors[0] != want {
+			t.Errorf("第 %d 列期望錯誤 %q，得到 %v", row.Line, want, row.Errors)
+		}
+	}
+}
+
+func TestProvisioningService_ClaimCode(t *testing.T) {
+	service := NewProvisioningService()
+
+	code, hash, hint, err := service.GenerateClaimCode()
+	if err != nil {
+		t.Fatalf("產生認領碼失敗: %v", err)
+	}
+	if len(code) != 14 || strings.Count(code, "-") != 2 {
+		t.Fatalf("認領碼格式錯誤: %q", code)
+	}
+	if !strings.HasSuffix(code, hint) {
+		t.Errorf("提示 %q 應為認領碼末四碼 (%q)", hint, code)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantMatch bool
+	}{
+		{"原始格式", code, true},
+		{"小寫且無分隔符", strings.ToLower(strings.ReplaceAll(code, "-", "")), true},
+		{"含空白", strings.ReplaceAll(code, "-", " "), true},
+		{"長度不足", code[:9], false},
+		{"含易混淆字元", "O" + code[1:], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized := service.NormalizeClaimCode(tt.input)
+			matched := normalized != "" && service.HashClaimCode(tt.input) == hash
+			if matched != tt.wantMatch {
+				t.Errorf("期望相符 %v，得到 %v (正規化 %q)", tt.wantMatch, matched, normalized)
+			}
+		})
+	}
+}
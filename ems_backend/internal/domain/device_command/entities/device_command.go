@@ -0,0 +1,140 @@
+package entities
+
+import (
+	"time"
+)
+
+// DeviceCommand - 遠端控制命令實體
+type DeviceCommand struct {
+	ID              uint
+	CommandID       string // UUID，設備回覆時帶回以對應確認
+	CompanyDeviceID uint
+	DeviceSN        string
+	TargetType      string   // ac_unit, compressor
+	TargetID        string   // ACUnit.ID 或 Compressor.ID
+	Action          string   // power_on, power_off, set_mode, set_setpoint
+	Mode            string   // set_mode 時使用
+	Setpoint        *float64 // set_setpoint 時使用 (°C)
+	Status          string   // pending, sent, acknowledged, failed, timeout
+	Message         string   // 發送錯誤或設備回覆訊息
+	RequestedBy     uint
+	RequestedAt     time.Time
+	SentAt          *time.Time
+	AckAt           *time.Time
+}
+
+// Target type constants
+const (
+	TargetTypeACUnit     = "ac_unit"
+	TargetTypeCompressor = "compressor"
+)
+
+// Action constants
+const (
+	ActionPowerOn     = "power_on"
+	ActionPowerOff    = "power_off"
+	ActionSetMode     = "set_mode"
+	ActionSetSetpoint = "set_setpoint"
+)
+
+// Status constants
+const (
+	StatusPending      = "pending"
+	StatusSent         = "sent"
+	StatusAcknowledged = "acknowledged"
+	StatusFailed       = "failed"
+	StatusTimeout      = "timeout"
+)
+
+// AC mode constants (VRF 室內機)
+const (
+	ModeCool = "cool"
+	ModeHeat = "heat"
+	ModeFan  = "fan"
+	ModeDry  = "dry"
+	ModeAuto = "auto"
+)
+
+// Setpoint range (°C)
+const (
+	MinSetpoint = 16.0
+	MaxSetpoint = 30.0
+)
+
+// AckTimeout - 超過此時間未收到設備確認視為逾時
+const AckTimeout = 30 * time.Second
+
+// NewDeviceCommand - 創建遠端控制命令
+func NewDeviceCommand(commandID string, companyDeviceID uint, deviceSN, targetType, targetID, action string, requestedBy uint) *DeviceCommand {
+	return &DeviceCommand{
+		CommandID:       commandID,
+		CompanyDeviceID: companyDeviceID,
+		DeviceSN:        deviceSN,
+		TargetType:      targetType,
+		TargetID:        targetID,
+		Action:          action,
+		Status:          StatusPending,
+		RequestedBy:     requestedBy,
+		RequestedAt:     time.Now(),
+	}
+}
+
+// MarkSent - 標記已發送
+func (c *DeviceCommand) MarkSent() {
+	now := time.Now()
+	c.Status = StatusSent
+	c.SentAt = &now
+}
+
+// MarkFailed - 標記發送或執行失敗
+func (c *DeviceCommand) MarkFailed(message string) {
+	c.Status = StatusFailed
+	c.Message = message
+}
+
+// Acknowledge - 記錄設備回覆
+func (c *DeviceCommand) Acknowledge(success bool, message string) {
+	now := time.Now()
+	c.AckAt = &now
+	c.Message = message
+	if success {
+		c.Status = StatusAcknowledged
+	} else {
+		c.Status = StatusFailed
+	}
+}
+
+// CheckTimeout - 已發送但逾時未確認則標記為 timeout，返回是否有變更
+func (c *DeviceCommand) CheckTimeout(now time.Time) bool {
+	if c.Status != StatusSent || c.SentAt == nil {
+		return false
+	}
+	if now.Sub(*c.SentAt) < AckTimeout {
+		return false
+	}
+	c.Status = StatusTimeout
+	return true
+}
+
+// IsFinal - 是否為最終狀態
+func (c *DeviceCommand) IsFinal() bool {
+	return c.Status == StatusAcknowledged || c.Status == StatusFailed || c.Status == StatusTimeout
+}
+
+// PermissionCode - 各命令所需的權限代碼
+func PermissionCode(action string) string {
+	switch action {
+	case ActionPowerOn, ActionPowerOff:
+		return "device_control:power"
+	case ActionSetMode:
+		return "device_control:mode"
+	case ActionSetSetpoint:
+		return "device_control:setpoint"
+	}
+	return ""
+}
+
+// IsValidMode - 驗證運轉模式
+func IsValidMode(mode string) bool {
+	return mode == ModeCool || mode == ModeHeat || mode == ModeFan || mode == ModeDry || mode == ModeAuto
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import "ems_backend/internal/domain/device_command/entities"
+
+// DeviceCommandRepository 遠端控制命令倉儲接口
+type DeviceCommandRepository interface {
+	// Save 保存命令
+	Save(command *entities.DeviceCommand) error
+
+	// Update 更新命令狀態
+	Update(command *entities.DeviceCommand) error
+
+	// FindByCommandID 根據命令 UUID 查找
+	FindByCommandID(commandID string) (*entities.DeviceCommand, error)
+
+	// FindByCompanyDeviceID 查找設備的命令紀錄 (最新在前)
+	FindByCompanyDeviceID(companyDeviceID uint, limit int) ([]*entities.DeviceCommand, error)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	"ems_backend/internal/domain/device_command/entities"
+	"fmt"
+)
+
+// DeviceCommandService 遠端控制命令領域服務
+// 依據設備 DeviceContent 驗證命令目標與參數
+type DeviceCommandService struct{}
+
+// NewDeviceCommandService 創建遠端控制命令領域服務
+func NewDeviceCommandService() *DeviceCommandService {
+	return &DeviceCommandService{}
+}
+
+// Validate 驗證命令目標存在且該目標支援此命令
+func (s *DeviceCommandService) Validate(content *companyDeviceEntities.DeviceContent, command *entities.DeviceCommand) error {
+	if entities.PermissionCode(command.Action) == "" {
+		return fmt.Errorf("unsupported action: %s", command.Action)
+	}
+	if command.TargetID == "" {
+		return fmt.Errorf("target_id is required")
+	}
+	if content == nil {
+		return fmt.Errorf("device content is empty, query device info first")
+	}
+
+	switch command.TargetType {
+	case entities.TargetTypeACUnit:
+		if !hasACUnit(content, command.TargetID) {
+			return fmt.Errorf("ac unit %s not found on device", command.TargetID)
+		}
+	case entities.TargetTypeCompressor:
+		if !hasCompressor(content, command.TargetID) {
+			return fmt.Errorf("compressor %s not found on device", command.TargetID)
+		}
+		// 箱型機壓縮機僅支援開關機
+		if command.Action != entities.ActionPowerOn && command.Action != entities.ActionPowerOff {
+			return fmt.Errorf("compressor does not support %s", command.Action)
+		}
+	default:
+		return fmt.Errorf("unsupported target type: %s", command.TargetType)
+	}
+
+	switch command.Action {
+	case entities.ActionSetMode:
+		if !entities.IsValidMode(command.Mode) {
+			return fmt.Errorf("invalid mode: %s", command.Mode)
+		}
+	case entities.ActionSetSetpoint:
+		if command.Setpoint == nil {
+			return fmt.Errorf("setpoint is required")
+		}
+		if *command.Setpoint < entities.MinSetpoint || *command.Setpoint > entities.MaxSetpoint {
+			return fmt.Errorf("setpoint must be between %.0f and %.0f", entities.MinSetpoint, entities.MaxSetpoint)
+		}
+	}
+
+	return nil
+}
+
+// hasACUnit 檢查 VRF 室內機是否存在
+func hasACUnit(content *companyDeviceEntities.DeviceContent, unitID string) bool {
+	for i := range content.VRFs {
+		for _, unit := range content.VRFs[i].GetUnits() {
+			if unit.ID == unitID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasCompressor 檢查箱型機壓縮機是否存在
+func hasCompressor(content *companyDeviceEntities.DeviceContent, compressorID string) bool {
+	for _, pkg := range content.Packages {
+		for _, compressor := range pkg.Compressors {
+			if compressor.ID == compressorID {
+				return true
+			}
+		}
+	}
+	return false
+}
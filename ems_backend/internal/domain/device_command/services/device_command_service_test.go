@@ -0,0 +1,64 @@
+package services
+
+import (
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	"ems_backend/internal/domain/device_command/entities"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestDeviceCommandService_Validate(t *testing.T) {
+	content := &companyDeviceEntities.DeviceContent{
+		VRFs: []companyDeviceEntities.VRF{
+			{ID: "vrf-1", ACUnits: []companyDeviceEntities.ACUnit{{ID: "ac-1"}}},
+			{ID: "vrf-2", ACs: []companyDeviceEntities.ACUnit{{ID: "ac-2"}}},
+		},
+		Packages: []companyDeviceEntities.Package{
+			{ID: "pkg-1", Compressors: []companyDeviceEntities.Compressor{{ID: "comp-1"}}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		targetType  string
+		targetID    string
+		action      string
+		mode        string
+		setpoint    *float64
+		expectError bool
+	}{
+		{"室內機關機", entities.TargetTypeACUnit, "ac-1", entities.ActionPowerOff, "", nil, false},
+		{"acs 欄位的室內機", entities.TargetTypeACUnit, "ac-2", entities.ActionPowerOn, "", nil, false},
+		{"壓縮機關機", entities.TargetTypeCompressor, "comp-1", entities.ActionPowerOff, "", nil, false},
+		{"室內機設定模式", entities.TargetTypeACUnit, "ac-1", entities.ActionSetMode, entities.ModeCool, nil, false},
+		{"室內機設定溫度", entities.TargetTypeACUnit, "ac-1", entities.ActionSetSetpoint, "", floatPtr(25), false},
+		{"室內機不存在", entities.TargetTypeACUnit, "ac-9", entities.ActionPowerOff, "", nil, true},
+		{"壓縮機不存在", entities.TargetTypeCompressor, "comp-9", entities.ActionPowerOff, "", nil, true},
+		{"壓縮機不支援設定溫度", entities.TargetTypeCompressor, "comp-1", entities.ActionSetSetpoint, "", floatPtr(25), true},
+		{"無效的模式", entities.TargetTypeACUnit, "ac-1", entities.ActionSetMode, "turbo", nil, true},
+		{"溫度超出範圍", entities.TargetTypeACUnit, "ac-1", entities.ActionSetSetpoint, "", floatPtr(35), true},
+		{"缺少溫度", entities.TargetTypeACUnit, "ac-1", entities.ActionSetSetpoint, "", nil, true},
+		{"未知的目標類型", "meter", "ac-1", entities.ActionPowerOff, "", nil, true},
+		{"未知的命令", entities.TargetTypeACUnit, "ac-1", "reboot", "", nil, true},
+	}
+
+	service := NewDeviceCommandService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := entities.NewDeviceCommand("cmd", 1, "SN001", tt.targetType, tt.targetID, tt.action, 1)
+			command.Mode = tt.mode
+			command.Setpoint = tt.setpoint
+
+			err := service.Validate(content, command)
+			if tt.expectError && err == nil {
+				t.Errorf("期望錯誤，但沒有返回錯誤")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("不期望錯誤，但返回了錯誤: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Target mode constants
+const (
+	TargetModePercentage = "percentage" // 依比例抽樣符合條件的設備
+	TargetModeDevices    = "devices"    // 明確指定設備清單
+)
+
+// Campaign status constants
+const (
+	CampaignStatusRunning   = "running"
+	CampaignStatusPaused    = "paused"
+	CampaignStatusHalted    = "halted" // 失敗率超過門檻自動停止
+	CampaignStatusCompleted = "completed"
+	CampaignStatusCancelled = "cancelled"
+)
+
+// Default campaign values
+const (
+	DefaultBatchSize            = 10
+	DefaultMaxFailureRate       = 0.2
+	DefaultMinSamples           = 5
+	DefaultDeviceTimeoutSeconds = 1800
+)
+
+// Campaign - 韌體分批發布活動
+type Campaign struct {
+	ID                   uint
+	Name                 string
+	FirmwareID           uint
+	TargetMode           string
+	Percentage           int    // TargetModePercentage 時使用 (1-100)
+	DeviceIDs            []uint // TargetModeDevices 時使用
+	BatchSize            int    // 同時進行更新的設備上限
+	MaxFailureRate       float64
+	MinSamples           int // 完成數達此值後才評估失敗率
+	DeviceTimeoutSeconds int // 單一設備未回報完成的逾時秒數
+	Status               string
+	HaltReason           string
+	// 恢復已停止的活動時記錄當下的完成數，之後的失敗率只計算恢復後的結果
+	SucceededOffset int
+	FailedOffset    int
+	CreateID        uint
+	CreateTime      time.Time
+	ModifyID        uint
+	ModifyTime      time.Time
+	FinishedAt      *time.Time
+}
+
+// NewCampaign - 創建發布活動 (建立後即開始執行)
+func NewCampaign(name string, firmwareID uint, createID uint) *Campaign {
+	now := time.Now()
+	return &Campaign{
+		Name:                 name,
+		FirmwareID:           firmwareID,
+		BatchSize:            DefaultBatchSize,
+		MaxFailureRate:       DefaultMaxFailureRate,
+		MinSamples:           DefaultMinSamples,
+		DeviceTimeoutSeconds: DefaultDeviceTimeoutSeconds,
+		Status:               CampaignStatusRunning,
+		CreateID:             createID,
+		CreateTime:           now,
+		ModifyID:             createID,
+		ModifyTime:           now,
+	}
+}
+
+// Validate - 驗證發布活動設定
+func (c *Campaign) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	switch c.TargetMode {
+	case TargetModePercentage:
+		if c.Percentage < 1 || c.Percentage > 100 {
+			return errors.New("percentage must be between 1 and 100")
+		}
+	case TargetModeDevices:
+		if len(c.DeviceIDs) == 0 {
+			return errors.New("device_ids is required")
+		}
+	default:
+		return errors.New("either percentage or device_ids must be specified")
+	}
+	if c.BatchSize < 1 {
+		return errors.New("batch_size must be at least 1")
+	}
+	if c.MaxFailureRate <= 0 || c.MaxFailureRate > 1 {
+		return errors.New("max_failure_rate must be greater than 0 and at most 1")
+	}
+	if c.MinSamples < 1 {
+		return errors.New("min_samples must be at least 1")
+	}
+	if c.DeviceTimeoutSeconds < 60 {
+		return errors.New("device_timeout_seconds must be at least 60")
+	}
+	return nil
+}
+
+// DeviceTimeout - 單一設備更新逾時
+func (c *Campaign) DeviceTimeout() time.Duration {
+	return time.Duration(c.DeviceTimeoutSeconds) * time.Second
+}
+
+// IsFinished - 是否已結束 (完成或取消)
+func (c *Campaign) IsFinished() bool {
+	return c.Status == CampaignStatusCompleted || c.Status == CampaignStatusCancelled
+}
+
+// Pause - 暫停派送新的更新 (進行中的設備繼續追蹤)
+func (c *Campaign) Pause(memberID uint) error {
+	if c.Status != CampaignStatusRunning {
+		return errors.New("only running campaigns can be paused")
+	}
+	c.Status = CampaignStatusPaused
+	c.touch(memberID)
+	return nil
+}
+
+// Resume - 恢復暫停或自動停止的活動
+func (c *Campaign) Resume(progress CampaignProgress, memberID uint) error {
+	if c.Status != CampaignStatusPaused && c.Status != CampaignStatusHalted {
+		return errors.New("only paused or halted campaigns can be resumed")
+	}
+	if c.Status == CampaignStatusHalted {
+		c.SucceededOffset = progress.Succeeded
+		c.FailedOffset = progress.Failed
+	}
+	c.Status = CampaignStatusRunning
+	c.HaltReason = ""
+	c.touch(memberID)
+	return nil
+}
+
+// Cancel - 取消活動，尚未派送的設備不再更新
+func (c *Campaign) Cancel(memberID uint) error {
+	if c.IsFinished() {
+		return errors.New("campaign is already finished")
+	}
+	c.Status = CampaignStatusCancelled
+	c.touch(memberID)
+	c.finish()
+	return nil
+}
+
+// Halt - 失敗率超過門檻時自動停止
+func (c *Campaign) Halt(reason string) {
+	c.Status = CampaignStatusHalted
+	c.HaltReason = reason
+	c.ModifyTime = time.Now()
+}
+
+// Complete - 所有設備皆已結束
+func (c *Campaign) Complete() {
+	c.Status = CampaignStatusCompleted
+	c.ModifyTime = time.Now()
+	c.finish()
+}
+
+func (c *Campaign) touch(memberID uint) {
+	c.ModifyID = memberID
+	c.ModifyTime = time.Now()
+}
+
+func (c *Campaign) finish() {
+	now := time.Now()
+	c.FinishedAt = &now
+}
+
+// CampaignProgress - 發布活動進度統計
+type CampaignProgress struct {
+	Total       int     `json:"total"`
+	Pending     int     `json:"pending"`
+	InFlight    int     `json:"in_flight"`
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"` // 包含 failed 與 timeout
+	Cancelled   int     `json:"cancelled"`
+	FailureRate float64 `json:"failure_rate"` // 恢復後 (或全部) 已完成設備中的失敗比例
+}
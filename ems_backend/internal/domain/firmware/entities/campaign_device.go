@@ -0,0 +1,117 @@
+package entities
+
+import (
+	"time"
+)
+
+// Campaign device status constants
+const (
+	DeviceStatusPending     = "pending"
+	DeviceStatusSent        = "sent"
+	DeviceStatusDownloading = "downloading"
+	DeviceStatusInstalling  = "installing"
+	DeviceStatusSucceeded   = "succeeded"
+	DeviceStatusFailed      = "failed"
+	DeviceStatusTimeout     = "timeout"
+	DeviceStatusCancelled   = "cancelled"
+)
+
+// CampaignDevice - 發布活動中單一設備的更新進度
+type CampaignDevice struct {
+	ID          uint
+	CampaignID  uint
+	DeviceID    uint
+	DeviceSN    string
+	FromVersion string // 派送時設備回報的韌體版本
+	CommandID   string // OTA 命令 UUID，設備回報進度時帶回
+	Status      string
+	Progress    int // 0-100
+	Message     string
+	SentAt      *time.Time
+	UpdatedAt   time.Time
+	FinishedAt  *time.Time
+}
+
+// NewCampaignDevice - 創建待派送的設備
+func NewCampaignDevice(deviceID uint, deviceSN, fromVersion string) *CampaignDevice {
+	return &CampaignDevice{
+		DeviceID:    deviceID,
+		DeviceSN:    deviceSN,
+		FromVersion: fromVersion,
+		Status:      DeviceStatusPending,
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// IsInFlight - 已派送且尚未結束
+func (d *CampaignDevice) IsInFlight() bool {
+	return d.Status == DeviceStatusSent || d.Status == DeviceStatusDownloading || d.Status == DeviceStatusInstalling
+}
+
+// IsFinal - 是否為最終狀態
+func (d *CampaignDevice) IsFinal() bool {
+	return d.Status == DeviceStatusSucceeded || d.Status == DeviceStatusFailed ||
+		d.Status == DeviceStatusTimeout || d.Status == DeviceStatusCancelled
+}
+
+// MarkSent - 標記 OTA 命令已發送
+func (d *CampaignDevice) MarkSent(commandID string, now time.Time) {
+	d.CommandID = commandID
+	d.Status = DeviceStatusSent
+	d.Progress = 0
+	d.SentAt = &now
+	d.UpdatedAt = now
+}
+
+// ReportProgress - 記錄下載 / 安裝進度
+func (d *CampaignDevice) ReportProgress(status string, progress int, message string, now time.Time) {
+	if d.IsFinal() {
+		return
+	}
+	d.Status = status
+	if progress > d.Progress {
+		d.Progress = min(progress, 100)
+	}
+	if message != "" {
+		d.Message = message
+	}
+	d.UpdatedAt = now
+}
+
+// Succeed - 更新成功
+func (d *CampaignDevice) Succeed(message string, now time.Time) {
+	d.Status = DeviceStatusSucceeded
+	d.Progress = 100
+	if message != "" {
+		d.Message = message
+	}
+	d.UpdatedAt = now
+	d.FinishedAt = &now
+}
+
+// Fail - 更新失敗或逾時
+func (d *CampaignDevice) Fail(status, message string, now time.Time) {
+	d.Status = status
+	d.Message = message
+	d.UpdatedAt = now
+	d.FinishedAt = &now
+}
+
+// Cancel - 取消尚未派送的設備
+func (d *CampaignDevice) Cancel(now time.Time) {
+	d.Status = DeviceStatusCancelled
+	d.UpdatedAt = now
+	d.FinishedAt = &now
+}
+
+// CheckTimeout - 超過逾時仍未完成則標記為 timeout，返回是否有變更
+func (d *CampaignDevice) CheckTimeout(now time.Time, timeout time.Duration) bool {
+	if !d.IsInFlight() || d.SentAt == nil {
+		return false
+	}
+	if now.Sub(*d.SentAt) < timeout {
+		return false
+	}
+	d.Fail(DeviceStatusTimeout, "no completion report before timeout", now)
+	return true
+}
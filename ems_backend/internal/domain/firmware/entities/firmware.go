@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Firmware - 韌體檔案 (目錄中的一個可發布版本)
+type Firmware struct {
+	ID              uint
+	Version         string
+	HardwareVersion string // 相容的硬體版本，空字串表示不限
+	URL             string // 閘道器下載位址 (http/https)
+	SHA256          string // 小寫十六進位
+	SizeBytes       int64
+	ReleaseNotes    string
+	CreateID        uint
+	CreateTime      time.Time
+}
+
+// DeviceFirmware - 設備目前回報的韌體 / 硬體版本
+type DeviceFirmware struct {
+	DeviceID        uint
+	FirmwareVersion string
+	HardwareVersion string
+	ReportedAt      time.Time
+}
+
+// NewFirmware - 創建韌體檔案
+func NewFirmware(version, hardwareVersion, downloadURL, sha256 string, sizeBytes int64, releaseNotes string, createID uint) *Firmware {
+	return &Firmware{
+		Version:         strings.TrimSpace(version),
+		HardwareVersion: strings.TrimSpace(hardwareVersion),
+		URL:             strings.TrimSpace(downloadURL),
+		SHA256:          strings.ToLower(strings.TrimSpace(sha256)),
+		SizeBytes:       sizeBytes,
+		ReleaseNotes:    releaseNotes,
+		CreateID:        createID,
+		CreateTime:      time.Now(),
+	}
+}
+
+// Validate - 驗證韌體檔案資訊
+func (f *Firmware) Validate() error {
+	if f.Version == "" {
+		return errors.New("version is required")
+	}
+	parsed, err := url.Parse(f.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if len(f.SHA256) != 64 {
+		return errors.New("sha256 must be 64 hex characters")
+	}
+	if _, err := hex.DecodeString(f.SHA256); err != nil {
+		return errors.New("sha256 must be 64 hex characters")
+	}
+	if f.SizeBytes <= 0 {
+		return errors.New("size_bytes must be positive")
+	}
+	return nil
+}
+
+// SupportsHardware - 是否可安裝於指定硬體版本 (未知硬體版本僅適用不限硬體的韌體)
+func (f *Firmware) SupportsHardware(hardwareVersion string) bool {
+	return f.HardwareVersion == "" || f.HardwareVersion == hardwareVersion
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/firmware/entities"
+)
+
+// FirmwareRepository 韌體目錄與設備版本盤點倉儲接口
+type FirmwareRepository interface {
+	FindAll() ([]*entities.Firmware, error)
+	// FindByID 不存在時返回 nil, nil
+	FindByID(id uint) (*entities.Firmware, error)
+	ExistsByVersion(version, hardwareVersion string) (bool, error)
+	Save(firmware *entities.Firmware) error
+
+	FindInventory() ([]*entities.DeviceFirmware, error)
+	// FindInventoryByDeviceID 尚未回報時返回 nil, nil
+	FindInventoryByDeviceID(deviceID uint) (*entities.DeviceFirmware, error)
+	SaveInventory(inventory *entities.DeviceFirmware) error
+}
+
+// CampaignRepository 韌體發布活動倉儲接口
+type CampaignRepository interface {
+	// Save 新增或更新活動 (ID 為 0 時新增)
+	Save(campaign *entities.Campaign) error
+	// FindByID 不存在時返回 nil, nil
+	FindByID(id uint) (*entities.Campaign, error)
+	FindAll() ([]*entities.Campaign, error)
+	FindByStatus(status string) ([]*entities.Campaign, error)
+
+	SaveDevices(campaignID uint, devices []*entities.CampaignDevice) error
+	UpdateDevice(device *entities.CampaignDevice) error
+	FindDevices(campaignID uint) ([]*entities.CampaignDevice, error)
+	// FindDeviceByCommandID 不存在時返回 nil, nil
+	FindDeviceByCommandID(commandID string) (*entities.CampaignDevice, error)
+	// FindInFlightDevice 設備目前進行中的更新，不存在時返回 nil, nil
+	FindInFlightDevice(deviceID uint) (*entities.CampaignDevice, error)
+	// FindBusyDeviceIDs 尚在未結束活動中待派送或進行中的設備
+	FindBusyDeviceIDs() ([]uint, error)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"ems_backend/internal/domain/firmware/entities"
+)
+
+// FirmwareService 韌體盤點與分批發布領域服務
+// 從 deviceInfo 回覆擷取版本、挑選發布目標、計算進度並判斷是否自動停止
+type FirmwareService struct{}
+
+// NewFirmwareService 創建韌體領域服務
+func NewFirmwareService() *FirmwareService {
+	return &FirmwareService{}
+}
+
+// 版本欄位名稱 (依序嘗試)；ems_vrv 不同版本的 deviceInfo 使用過不同命名
+var (
+	firmwareVersionKeys = []string{"firmware_version", "fw_version", "firmwareVersion"}
+	hardwareVersionKeys = []string{"hardware_version", "hw_version", "hardwareVersion"}
+	versionContainers   = []string{"device_info", "system", "gateway"}
+)
+
+// ExtractVersions 由 deviceInfo 回覆擷取韌體與硬體版本 (頂層或 device_info / system / gateway 物件內)
+func (s *FirmwareService) ExtractVersions(data json.RawMessage) (string, string, bool) {
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", "", false
+	}
+
+	objects := []map[string]any{document}
+	for _, key := range versionContainers {
+		if nested, ok := document[key].(map[string]any); ok {
+			objects = append(objects, nested)
+		}
+	}
+
+	firmware, hardware := "", ""
+	for _, object := range objects {
+		if firmware == "" {
+			firmware = firstString(object, firmwareVersionKeys)
+		}
+		if hardware == "" {
+			hardware = firstString(object, hardwareVersionKeys)
+		}
+	}
+	return firmware, hardware, firmware != ""
+}
+
+// TargetCandidate 可被納入發布活動的設備
+type TargetCandidate struct {
+	DeviceID        uint
+	DeviceSN        string
+	FirmwareVersion string
+	HardwareVersion string
+	Busy            bool // 已在其他未結束的活動中
+}
+
+// SkippedTarget 未納入活動的設備與原因
+type SkippedTarget struct {
+	DeviceID uint   `json:"device_id"`
+	DeviceSN string `json:"device_sn"`
+	Reason   string `json:"reason"`
+}
+
+// SelectTargets 挑選發布目標
+// 排除已是目標版本、硬體不相容或已在其他活動中的設備；
+// 比例模式以活動名稱與 SN 的雜湊排序後取前 ceil(n × percentage / 100) 台，結果可重現且分散
+func (s *FirmwareService) SelectTargets(campaign *entities.Campaign, firmware *entities.Firmware, candidates []*TargetCandidate) ([]*TargetCandidate, []SkippedTarget) {
+	var eligible []*TargetCandidate
+	var skipped []SkippedTarget
+	for _, candidate := range candidates {
+		reason := ""
+		switch {
+		case candidate.Busy:
+			reason = "already in another active campaign"
+		case candidate.FirmwareVersion == firmware.Version:
+			reason = "already on target version"
+		case !firmware.SupportsHardware(candidate.HardwareVersion):
+			reason = fmt.Sprintf("hardware version %q is not supported", candidate.HardwareVersion)
+		}
+		if reason != "" {
+			skipped = append(skipped, SkippedTarget{DeviceID: candidate.DeviceID, DeviceSN: candidate.DeviceSN, Reason: reason})
+			continue
+		}
+		eligible = append(eligible, candidate)
+	}
+
+	if campaign.TargetMode != entities.TargetModePercentage {
+		return eligible, skipped
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return rolloutHash(campaign.Name, eligible[i].DeviceSN) < rolloutHash(campaign.Name, eligible[j].DeviceSN)
+	})
+	count := (len(eligible)*campaign.Percentage + 99) / 100
+	return eligible[:count], skipped
+}
+
+// Summarize 統計活動進度，失敗率只計算恢復 (SucceededOffset / FailedOffset) 之後完成的設備
+func (s *FirmwareService) Summarize(campaign *entities.Campaign, devices []*entities.CampaignDevice) entities.CampaignProgress {
+	progress := entities.CampaignProgress{Total: len(devices)}
+	for _, device := range devices {
+		switch {
+		case device.Status == entities.DeviceStatusPending:
+			progress.Pending++
+		case device.IsInFlight():
+			progress.InFlight++
+		case device.Status == entities.DeviceStatusSucceeded:
+			progress.Succeeded++
+		case device.Status == entities.DeviceStatusFailed, device.Status == entities.DeviceStatusTimeout:
+			progress.Failed++
+		case device.Status == entities.DeviceStatusCancelled:
+			progress.Cancelled++
+		}
+	}
+
+	succeeded, failed := s.sinceResume(campaign, progress)
+	if succeeded+failed > 0 {
+		progress.FailureRate = float64(failed) / float64(succeeded+failed)
+	}
+	return progress
+}
+
+// ShouldHalt 完成數達 MinSamples 且失敗率超過 MaxFailureRate 時返回停止原因
+func (s *FirmwareService) ShouldHalt(campaign *entities.Campaign, progress entities.CampaignProgress) (bool, string) {
+	succeeded, failed := s.sinceResume(campaign, progress)
+	finished := succeeded + failed
+	if finished < campaign.MinSamples || failed == 0 {
+		return false, ""
+	}
+	rate := float64(failed) / float64(finished)
+	if rate <= campaign.MaxFailureRate {
+		return false, ""
+	}
+	return true, fmt.Sprintf("failure rate %.0f%% (%d/%d) exceeded threshold %.0f%%",
+		rate*100, failed, finished, campaign.MaxFailureRate*100)
+}
+
+// NextBatch 取出可派送的待更新設備，使進行中的設備數不超過 BatchSize
+func (s *FirmwareService) NextBatch(campaign *entities.Campaign, devices []*entities.CampaignDevice) []*entities.CampaignDevice {
+	inFlight := 0
+	for _, device := range devices {
+		if device.IsInFlight() {
+			inFlight++
+		}
+	}
+
+	var batch []*entities.CampaignDevice
+	for _, device := range devices {
+		if inFlight+len(batch) >= campaign.BatchSize {
+			break
+		}
+		if device.Status == entities.DeviceStatusPending {
+			batch = append(batch, device)
+		}
+	}
+	return batch
+}
+
+// ParseReportStatus 將設備回報的 OTA 狀態正規化為 CampaignDevice 狀態
+func (s *FirmwareService) ParseReportStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "downloading", "download":
+		return entities.DeviceStatusDownloading
+	case "installing", "install", "flashing", "rebooting":
+		return entities.DeviceStatusInstalling
+	case "succeeded", "success", "done", "installed", "completed":
+		return entities.DeviceStatusSucceeded
+	case "failed", "fail", "error", "checksum_mismatch", "rejected":
+		return entities.DeviceStatusFailed
+	}
+	return ""
+}
+
+func (s *FirmwareService) sinceResume(campaign *entities.Campaign, progress entities.CampaignProgress) (int, int) {
+	return max(progress.Succeeded-campaign.SucceededOffset, 0), max(progress.Failed-campaign.FailedOffset, 0)
+}
+
+func firstString(object map[string]any, keys []string) string {
+	for _, key := range keys {
+		if value, ok := object[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func rolloutHash(seed, sn string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	h.Write([]byte{0})
+	h.Write([]byte(sn))
+	return h.Sum64()
+}
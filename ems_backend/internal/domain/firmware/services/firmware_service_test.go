@@ -0,0 +1,189 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"ems_backend/internal/domain/firmware/entities"
+)
+
+func TestFirmwareService_ExtractVersions(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantFirmware string
+		wantHardware string
+		wantOK       bool
+	}{
+		{"頂層欄位", `{"firmware_version":"2.1.0","hardware_version":"rev-b","areas":[]}`, "2.1.0", "rev-b", true},
+		{"舊版縮寫欄位", `{"fw_version":" 1.9.3 ","hw_version":"rev-a"}`, "1.9.3", "rev-a", true},
+		{"巢狀 device_info", `{"device_info":{"firmware_version":"2.0.0","hardware_version":"rev-c"}}`, "2.0.0", "rev-c", true},
+		{"只有韌體版本", `{"system":{"firmwareVersion":"3.0.0"}}`, "3.0.0", "", true},
+		{"content version 為數字不視為韌體版本", `{"version":12,"areas":[]}`, "", "", false},
+		{"非 JSON 物件", `[1,2,3]`, "", "", false},
+	}
+
+	service := NewFirmwareService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firmware, hardware, ok := service.ExtractVersions(json.RawMessage(tt.data))
+			if firmware != tt.wantFirmware || hardware != tt.wantHardware || ok != tt.wantOK {
+				t.Errorf("期望 (%q, %q, %v)，得到 (%q, %q, %v)", tt.wantFirmware, tt.wantHardware, tt.wantOK, firmware, hardware, ok)
+			}
+		})
+	}
+}
+
+func TestFirmwareService_SelectTargets(t *testing.T) {
+	firmware := &entities.Firmware{Version: "2.0.0", HardwareVersion: "rev-b"}
+	candidates := []*TargetCandidate{
+		{DeviceID: 1, DeviceSN: "SN-1", FirmwareVersion: "1.0.0", HardwareVersion: "rev-b"},
+		{DeviceID: 2, DeviceSN: "SN-2", FirmwareVersion: "2.0.0", HardwareVersion: "rev-b"},
+		{DeviceID: 3, DeviceSN: "SN-3", FirmwareVersion: "1.0.0", HardwareVersion: "rev-a"},
+		{DeviceID: 4, DeviceSN: "SN-4", FirmwareVersion: "1.0.0", HardwareVersion: "rev-b", Busy: true},
+		{DeviceID: 5, DeviceSN: "SN-5", FirmwareVersion: "", HardwareVersion: "rev-b"},
+	}
+
+	campaign := &entities.Campaign{Name: "v2", TargetMode: entities.TargetModeDevices}
+	selected, skipped := NewFirmwareService().SelectTargets(campaign, firmware, candidates)
+	if len(selected) != 2 || selected[0].DeviceID != 1 || selected[1].DeviceID != 5 {
+		t.Fatalf("期望選中設備 1、5，得到 %v", deviceIDs(selected))
+	}
+	wantReasons := map[uint]string{
+		2: "already on target version",
+		3: `hardware version "rev-a" is not supported`,
+		4: "already in another active campaign",
+	}
+	if len(skipped) != len(wantReasons) {
+		t.Fatalf("期望略過 %d 台，得到 %d", len(wantReasons), len(skipped))
+	}
+	for _, s := range skipped {
+		if wantReasons[s.DeviceID] != s.Reason {
+			t.Errorf("設備 %d 略過原因: 期望 %q，得到 %q", s.DeviceID, wantReasons[s.DeviceID], s.Reason)
+		}
+	}
+}
+
+func TestFirmwareService_SelectTargets_Percentage(t *testing.T) {
+	firmware := &entities.Firmware{Version: "2.0.0"}
+	var candidates []*TargetCandidate
+	for i := 1; i <= 40; i++ {
+		candidates = append(candidates, &TargetCandidate{DeviceID: uint(i), DeviceSN: fmt.Sprintf("SN-%03d", i), FirmwareVersion: "1.0.0"})
+	}
+
+	service := NewFirmwareService()
+	tests := []struct {
+		name       string
+		percentage int
+		want       int
+	}{
+		{"10% 取 4 台", 10, 4},
+		{"向上取整", 1, 1},
+		{"全部", 100, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaign := &entities.Campaign{Name: "canary", TargetMode: entities.TargetModePercentage, Percentage: tt.percentage}
+			selected, _ := service.SelectTargets(campaign, firmware, candidates)
+			if len(selected) != tt.want {
+				t.Fatalf("期望 %d 台，得到 %d", tt.want, len(selected))
+			}
+			again, _ := service.SelectTargets(campaign, firmware, candidates)
+			if fmt.Sprint(deviceIDs(selected)) != fmt.Sprint(deviceIDs(again)) {
+				t.Errorf("相同活動名稱應選中相同設備: %v vs %v", deviceIDs(selected), deviceIDs(again))
+			}
+		})
+	}
+}
+
+func TestFirmwareService_ShouldHalt(t *testing.T) {
+	campaign := &entities.Campaign{MaxFailureRate: 0.2, MinSamples: 5}
+
+	tests := []struct {
+		name            string
+		succeeded       int
+		failed          int
+		succeededOffset int
+		failedOffset    int
+		want            bool
+	}{
+		{"樣本不足不停止", 2, 2, 0, 0, false},
+		{"失敗率未超過門檻", 8, 2, 0, 0, false},
+		{"失敗率超過門檻", 6, 4, 0, 0, true},
+		{"恢復後只計算新的結果", 6, 4, 6, 4, false},
+		{"恢復後再次超過門檻", 9, 7, 6, 4, true},
+	}
+
+	service := NewFirmwareService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaign.SucceededOffset = tt.succeededOffset
+			campaign.FailedOffset = tt.failedOffset
+			progress := entities.CampaignProgress{Succeeded: tt.succeeded, Failed: tt.failed}
+			halt, reason := service.ShouldHalt(campaign, progress)
+			if halt != tt.want {
+				t.Errorf("期望 %v，得到 %v (%s)", tt.want, halt, reason)
+			}
+		})
+	}
+}
+
+func TestFirmwareService_SummarizeAndNextBatch(t *testing.T) {
+	now := time.Now()
+	campaign := &entities.Campaign{BatchSize: 3}
+	devices := []*entities.CampaignDevice{
+		{ID: 1, Status: entities.DeviceStatusSucceeded},
+		{ID: 2, Status: entities.DeviceStatusDownloading},
+		{ID: 3, Status: entities.DeviceStatusTimeout},
+		{ID: 4, Status: entities.DeviceStatusPending},
+		{ID: 5, Status: entities.DeviceStatusPending},
+		{ID: 6, Status: entities.DeviceStatusPending},
+	}
+
+	service := NewFirmwareService()
+	progress := service.Summarize(campaign, devices)
+	if progress.Pending != 3 || progress.InFlight != 1 || progress.Succeeded != 1 || progress.Failed != 1 {
+		t.Errorf("統計錯誤: %+v", progress)
+	}
+	if progress.FailureRate != 0.5 {
+		t.Errorf("期望失敗率 0.5，得到 %v", progress.FailureRate)
+	}
+
+	batch := service.NextBatch(campaign, devices)
+	if len(batch) != 2 || batch[0].ID != 4 || batch[1].ID != 5 {
+		t.Fatalf("期望派送設備 4、5，得到 %d 台", len(batch))
+	}
+
+	for _, device := range batch {
+		device.MarkSent("cmd", now)
+	}
+	if next := service.NextBatch(campaign, devices); len(next) != 0 {
+		t.Errorf("進行中已達上限，不應再派送，得到 %d 台", len(next))
+	}
+}
+
+func TestCampaignDevice_CheckTimeout(t *testing.T) {
+	now := time.Now()
+	device := entities.NewCampaignDevice(1, "SN-1", "1.0.0")
+	device.MarkSent("cmd-1", now.Add(-31*time.Minute))
+
+	if !device.CheckTimeout(now, 30*time.Minute) {
+		t.Fatal("超過逾時應標記為 timeout")
+	}
+	if device.Status != entities.DeviceStatusTimeout || device.FinishedAt == nil {
+		t.Errorf("期望 timeout 且有完成時間，得到 %s", device.Status)
+	}
+	if device.CheckTimeout(now, 30*time.Minute) {
+		t.Error("已結束的設備不應再次變更")
+	}
+}
+
+func deviceIDs(candidates []*TargetCandidate) []uint {
+	ids := make([]uint, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.DeviceID
+	}
+	return ids
+}
@@ -15,6 +15,10 @@ type Member struct {
 	IsEnable  bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// 連續登入失敗自動鎖定；LockedUntil 為 nil 表示需由管理員解鎖
+	LockedAt    *time.Time
+	LockedUntil *time.Time
 }
 
 func (m *Member) UpdateName(name value_objects.Name) error {
@@ -46,6 +50,35 @@ func (m *Member) LockAccount() {
 	m.UpdatedAt = time.Now()
 }
 
+// Lock 因連續登入失敗鎖定帳號，duration 為 0 時需由管理員解鎖
+func (m *Member) Lock(now time.Time, duration time.Duration) {
+	m.LockedAt = &now
+	m.LockedUntil = nil
+	if duration > 0 {
+		until := now.Add(duration)
+		m.LockedUntil = &until
+	}
+}
+
+// Unlock 解除登入鎖定
+func (m *Member) Unlock() {
+	m.LockedAt = nil
+	m.LockedUntil = nil
+}
+
+// IsLocked 目前是否處於登入鎖定中
+func (m *Member) IsLocked(now time.Time) bool {
+	if m.LockedAt == nil {
+		return false
+	}
+	return m.LockedUntil == nil || now.Before(*m.LockedUntil)
+}
+
+// LockExpired 鎖定已到期但尚未清除
+func (m *Member) LockExpired(now time.Time) bool {
+	return m.LockedAt != nil && m.LockedUntil != nil && !now.Before(*m.LockedUntil)
+}
+
 // GetPublicInfo - 返回公開信息（用於 API 回應）
 func (m *Member) GetPublicInfo() *MemberPublicInfo {
 	return &MemberPublicInfo{
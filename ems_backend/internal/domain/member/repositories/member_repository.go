@@ -1,6 +1,10 @@
 package repositories
 
-import "ems_backend/internal/domain/member/entities"
+import (
+	"time"
+
+	"ems_backend/internal/domain/member/entities"
+)
 
 type MemberRepository interface {
 	FindByID(id string) (*entities.Member, error)
@@ -9,4 +13,10 @@ type MemberRepository interface {
 	Save(member *entities.Member) error
 	Update(member *entities.Member) error
 	Delete(id string) error
+
+	// UpdateLock 只更新登入鎖定欄位 (locked_at, locked_until)
+	UpdateLock(member *entities.Member) error
+
+	// FindLockExpired 查找鎖定已到期但尚未清除的會員
+	FindLockExpired(now time.Time) ([]*entities.Member, error)
 }
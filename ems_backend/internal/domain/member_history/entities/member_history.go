@@ -1,19 +1,63 @@
 package entities
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
 	"ems_backend/internal/domain/member/value_objects"
 	"encoding/base64"
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/argon2"
 )
 
+// Argon2Params - argon2id 雜湊參數，與雜湊一起保存
+type Argon2Params struct {
+	Time      uint32 // 迭代次數
+	Memory    uint32 // KiB
+	Threads   uint8
+	KeyLength uint32
+}
+
+var (
+	// LegacyArgon2Params 未記錄參數的舊雜湊所使用的參數
+	LegacyArgon2Params = Argon2Params{Time: 1, Memory: 64 * 1024, Threads: 4, KeyLength: 32}
+	// DefaultArgon2Params 新密碼預設使用的參數
+	DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLength: 32}
+)
+
+// String 編碼為 m=<memory>,t=<time>,p=<threads>,l=<key length>
+func (p Argon2Params) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d,l=%d", p.Memory, p.Time, p.Threads, p.KeyLength)
+}
+
+// Validate 檢查參數是否可用
+func (p Argon2Params) Validate() error {
+	if p.Time == 0 || p.Memory < 8*uint32(p.Threads) || p.Threads == 0 || p.KeyLength < 16 {
+		return fmt.Errorf("invalid argon2 parameters %s", p)
+	}
+	return nil
+}
+
+// ParseArgon2Params 解析保存的參數，空字串表示舊雜湊
+func ParseArgon2Params(encoded string) (Argon2Params, error) {
+	if encoded == "" {
+		return LegacyArgon2Params, nil
+	}
+	var p Argon2Params
+	if _, err := fmt.Sscanf(encoded, "m=%d,t=%d,p=%d,l=%d", &p.Memory, &p.Time, &p.Threads, &p.KeyLength); err != nil {
+		return p, fmt.Errorf("invalid argon2 parameters %q: %w", encoded, err)
+	}
+	return p, p.Validate()
+}
+
 // MemberHistory - 會員歷史實體
 type MemberHistory struct {
 	ID         uint
 	MemberID   value_objects.MemberID
 	Salt       string
 	Hash       string
+	HashParams string // argon2 參數，空字串為 LegacyArgon2Params
 	ErrorCount int
 	CreateID   uint
 	CreateTime time.Time
@@ -22,11 +66,41 @@ type MemberHistory struct {
 }
 
 func (m *MemberHistory) ValidatePassword(input, salt, hash string) bool {
-	return m.HashPassword(input, salt) == hash
+	return subtle.ConstantTimeCompare([]byte(m.HashPassword(input, salt)), []byte(hash)) == 1
 }
 
+// HashPassword 以此筆紀錄保存的參數計算雜湊
 func (m *MemberHistory) HashPassword(password, salt string) string {
+	params, err := ParseArgon2Params(m.HashParams)
+	if err != nil {
+		return ""
+	}
+	return hashPassword(password, salt, params)
+}
+
+// SetPassword 以新的鹽值與指定參數設定密碼
+func (m *MemberHistory) SetPassword(password string, params Argon2Params) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	m.Salt = base64.StdEncoding.EncodeToString(salt)
+	m.HashParams = params.String()
+	m.Hash = hashPassword(password, m.Salt, params)
+	return nil
+}
+
+// NeedsRehash 保存的參數與目前設定不同時需重新雜湊
+func (m *MemberHistory) NeedsRehash(params Argon2Params) bool {
+	current, err := ParseArgon2Params(m.HashParams)
+	return err != nil || current != params
+}
+
+func hashPassword(password, salt string, params Argon2Params) string {
 	saltBytes, _ := base64.StdEncoding.DecodeString(salt)
-	hash := argon2.IDKey([]byte(password), saltBytes, 1, 64*1024, 4, 32)
+	hash := argon2.IDKey([]byte(password), saltBytes, params.Time, params.Memory, params.Threads, params.KeyLength)
 	return base64.StdEncoding.EncodeToString(hash)
 }
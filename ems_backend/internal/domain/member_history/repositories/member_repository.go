@@ -6,4 +6,16 @@ type MemberHistoryRepository interface {
 	Save(member *entities.MemberHistory) error
 	Update(member *entities.MemberHistory) error
 	LastMemberHistory(memberID uint) (*entities.MemberHistory, error)
+
+	// RecentMemberHistories 最近 limit 筆密碼紀錄 (新到舊)
+	RecentMemberHistories(memberID uint, limit int) ([]*entities.MemberHistory, error)
+
+	// UpdateHash 以新的鹽值與參數重新保存同一組密碼的雜湊
+	UpdateHash(id uint, salt, hash, params string) error
+
+	// IncrementErrorCount 最新一筆密碼的連續失敗次數加一，返回累計次數
+	IncrementErrorCount(memberID uint) (int, error)
+
+	// ResetErrorCount 清除最新一筆密碼的連續失敗次數
+	ResetErrorCount(memberID uint) error
 }
@@ -4,6 +4,7 @@ import "ems_backend/internal/domain/menu/entities"
 
 type MenuRepository interface {
 	GetAll() ([]*entities.Menu, error)
+	GetByID(id uint) (*entities.Menu, error)
 	Create(menu *entities.Menu, memberID uint) error
 	Update(menu *entities.Menu, memberID uint) error
 	Delete(id uint) error
@@ -17,6 +17,10 @@ func (s *MenuService) GetAll() ([]*entities.Menu, error) {
 	return s.menuRepo.GetAll()
 }
 
+func (s *MenuService) GetByID(id uint) (*entities.Menu, error) {
+	return s.menuRepo.GetByID(id)
+}
+
 func (s *MenuService) Create(menu *entities.Menu, memberID uint) error {
 	return s.menuRepo.Create(menu, memberID)
 }
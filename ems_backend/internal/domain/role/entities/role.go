@@ -1,10 +1,44 @@
 package entities
 
+// CompanyScope - 角色可存取的公司範圍
+type CompanyScope string
+
+const (
+	CompanyScopeNone    CompanyScope = "none"    // 不可存取任何公司
+	CompanyScopeOwn     CompanyScope = "own"     // 只可存取成員所屬的公司
+	CompanyScopeSubtree CompanyScope = "subtree" // 成員所屬的公司及其所有子公司
+	CompanyScopeAll     CompanyScope = "all"     // 所有公司
+)
+
+// IsValid 是否為已定義的範圍
+func (s CompanyScope) IsValid() bool {
+	switch s {
+	case CompanyScopeNone, CompanyScopeOwn, CompanyScopeSubtree, CompanyScopeAll:
+		return true
+	}
+	return false
+}
+
+// scopeRank none < own < subtree < all；未定義的範圍視同 none
+var scopeRank = map[CompanyScope]int{
+	CompanyScopeNone:    0,
+	CompanyScopeOwn:     1,
+	CompanyScopeSubtree: 2,
+	CompanyScopeAll:     3,
+}
+
+// Includes 範圍是否涵蓋 other
+func (s CompanyScope) Includes(other CompanyScope) bool {
+	return scopeRank[s] >= scopeRank[other]
+}
+
 // Role - 角色
 type Role struct {
-	ID          uint   `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Sort        int    `json:"sort"`
-	IsEnable    bool   `json:"is_enable"`
+	ID           uint         `json:"id"`
+	Title        string       `json:"title"`
+	Description  string       `json:"description"`
+	Sort         int          `json:"sort"`
+	IsEnable     bool         `json:"is_enable"`
+	MFARequired  bool         `json:"mfa_required"`  // 此角色的成員必須啟用 MFA
+	CompanyScope CompanyScope `json:"company_scope"` // 公司存取範圍，取代以角色名稱判斷
 }
@@ -41,6 +41,9 @@ func (s *RoleService) Create(role *entities.Role, memberID uint) error {
 	if role.Title == "" {
 		return fmt.Errorf("role title cannot be empty")
 	}
+	if err := normalizeCompanyScope(role); err != nil {
+		return err
+	}
 
 	return s.roleRepo.Create(role, memberID)
 }
@@ -51,6 +54,15 @@ func (s *RoleService) Update(role *entities.Role, memberID uint) error {
 	if role.Title == "" {
 		return fmt.Errorf("role title cannot be empty")
 	}
+	// 舊版前端不會送出 company_scope，沿用原本的範圍
+	if role.CompanyScope == "" {
+		if existing, err := s.roleRepo.GetByID(role.ID); err == nil && existing != nil {
+			role.CompanyScope = existing.CompanyScope
+		}
+	}
+	if err := normalizeCompanyScope(role); err != nil {
+		return err
+	}
 
 	return s.roleRepo.Update(role, memberID)
 }
@@ -136,3 +148,14 @@ func (s *RoleService) GetPowersByIDs(powerIDs []uint) ([]*powerEntities.Power, e
 
 	return powers, nil
 }
+
+// normalizeCompanyScope 未指定公司範圍時視為 none，避免新角色預設看到公司資料
+func normalizeCompanyScope(role *entities.Role) error {
+	if role.CompanyScope == "" {
+		role.CompanyScope = entities.CompanyScopeNone
+	}
+	if !role.CompanyScope.IsValid() {
+		return fmt.Errorf("invalid company scope %q", role.CompanyScope)
+	}
+	return nil
+}
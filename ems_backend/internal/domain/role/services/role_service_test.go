@@ -252,6 +252,17 @@ func TestRoleService_Create(t *testing.T) {
 			wantError: true,
 			errorMsg:  "role title cannot be empty",
 		},
+		{
+			name: "公司範圍無效",
+			role: &entities.Role{
+				Title:        "區域主管",
+				IsEnable:     true,
+				CompanyScope: "region",
+			},
+			memberID:  1,
+			wantError: true,
+			errorMsg:  `invalid company scope "region"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -261,6 +272,9 @@ func TestRoleService_Create(t *testing.T) {
 			service := NewRoleService(roleRepo, powerRepo)
 
 			err := service.Create(tt.role, tt.memberID)
+			if err == nil && tt.role.CompanyScope != entities.CompanyScopeNone {
+				t.Errorf("未指定公司範圍應為 none，得到 %q", tt.role.CompanyScope)
+			}
 
 			if tt.wantError {
 				if err == nil {
@@ -11,7 +11,7 @@ type Schedule struct {
 	ScheduleID      string // UUID for sync
 	Command         string // typically "schedule"
 	Version         int
-	SyncStatus      string // pending, synced, failed
+	SyncStatus      string // pending, synced, failed, drift
 	SyncedAt        *time.Time
 	CreatedBy       uint
 	CreatedAt       time.Time
@@ -76,6 +76,7 @@ const (
 	SyncStatusPending = "pending"
 	SyncStatusSynced  = "synced"
 	SyncStatusFailed  = "failed"
+	SyncStatusDrift   = "drift" // 設備排程與雲端不一致，等待處理
 )
 
 // ActionType constants (matches ems_vrv)
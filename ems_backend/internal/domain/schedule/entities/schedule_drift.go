@@ -0,0 +1,198 @@
+package entities
+
+import (
+	"sort"
+	"time"
+)
+
+// ScheduleDrift - 雲端與設備排程不一致紀錄
+type ScheduleDrift struct {
+	ID              uint
+	CompanyDeviceID uint
+	ScheduleID      uint // schedules.id (0 = 雲端尚無排程)
+	CloudVersion    int
+	Differences     []RuleDifference
+	DeviceSnapshot  *ScheduleSnapshot // 設備回報的完整排程，用於 device_wins 解決
+	Status          string            // open, resolved
+	Resolution      string            // cloud_wins, device_wins, converged
+	ResolvedBy      uint              // 0 = 系統自動解決
+	ResolvedAt      *time.Time
+	DetectedAt      time.Time
+}
+
+// RuleDifference - 單一規則差異
+type RuleDifference struct {
+	Day    string `json:"day,omitempty"` // Monday-Sunday (exceptions 時為空)
+	Field  string `json:"field"`         // rule, run_period, actions, exceptions
+	Kind   string `json:"kind"`          // added, removed, changed
+	Cloud  string `json:"cloud"`
+	Device string `json:"device"`
+}
+
+// ScheduleDriftPolicy - 公司層級的排程漂移處理策略
+type ScheduleDriftPolicy struct {
+	CompanyID  uint
+	Policy     string // cloud_wins, device_wins, manual
+	ModifyID   uint
+	ModifyTime time.Time
+}
+
+// ScheduleSnapshot - 排程規則快照 (不含資料庫 ID，用於比對與保存)
+type ScheduleSnapshot struct {
+	Command    string                   `json:"command"`
+	DailyRules map[string]*RuleSnapshot `json:"daily_rules"`
+	Exceptions []string                 `json:"exceptions"`
+}
+
+// RuleSnapshot - 每日規則快照
+type RuleSnapshot struct {
+	RunPeriod *PeriodSnapshot  `json:"run_period,omitempty"`
+	Actions   []ActionSnapshot `json:"actions"`
+}
+
+// PeriodSnapshot - 時段快照
+type PeriodSnapshot struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ActionSnapshot - 動作快照
+type ActionSnapshot struct {
+	Type string `json:"type"`
+	Time string `json:"time,omitempty"`
+}
+
+// Drift status constants
+const (
+	DriftStatusOpen     = "open"
+	DriftStatusResolved = "resolved"
+)
+
+// Drift policy / resolution constants
+const (
+	DriftPolicyCloudWins  = "cloud_wins"
+	DriftPolicyDeviceWins = "device_wins"
+	DriftPolicyManual     = "manual"
+
+	// DriftResolutionConverged - 設備再次回報時已與雲端一致，未套用任何一方
+	DriftResolutionConverged = "converged"
+)
+
+// Difference field / kind constants
+const (
+	DiffFieldRule       = "rule"
+	DiffFieldRunPeriod  = "run_period"
+	DiffFieldActions    = "actions"
+	DiffFieldExceptions = "exceptions"
+
+	DiffKindAdded   = "added"
+	DiffKindRemoved = "removed"
+	DiffKindChanged = "changed"
+)
+
+// NewScheduleDrift - 創建漂移紀錄
+func NewScheduleDrift(companyDeviceID uint, cloud *Schedule, differences []RuleDifference, deviceSnapshot *ScheduleSnapshot) *ScheduleDrift {
+	drift := &ScheduleDrift{
+		CompanyDeviceID: companyDeviceID,
+		Differences:     differences,
+		DeviceSnapshot:  deviceSnapshot,
+		Status:          DriftStatusOpen,
+		DetectedAt:      time.Now(),
+	}
+	if cloud != nil {
+		drift.ScheduleID = cloud.ID
+		drift.CloudVersion = cloud.Version
+	}
+	return drift
+}
+
+// Resolve - 標記漂移已解決
+func (d *ScheduleDrift) Resolve(resolution string, resolvedBy uint) {
+	now := time.Now()
+	d.Status = DriftStatusResolved
+	d.Resolution = resolution
+	d.ResolvedBy = resolvedBy
+	d.ResolvedAt = &now
+}
+
+// IsOpen - 是否尚未解決
+func (d *ScheduleDrift) IsOpen() bool {
+	return d.Status == DriftStatusOpen
+}
+
+// IsValidDriftPolicy - 驗證漂移策略
+func IsValidDriftPolicy(policy string) bool {
+	return policy == DriftPolicyCloudWins ||
+		policy == DriftPolicyDeviceWins ||
+		policy == DriftPolicyManual
+}
+
+// IsValidDriftResolution - 驗證手動解決方式 (manual 不是解決方式)
+func IsValidDriftResolution(resolution string) bool {
+	return resolution == DriftPolicyCloudWins || resolution == DriftPolicyDeviceWins
+}
+
+// Snapshot - 將完整排程轉換為快照
+func (s *ScheduleWithRules) Snapshot() *ScheduleSnapshot {
+	snapshot := &ScheduleSnapshot{
+		DailyRules: make(map[string]*RuleSnapshot),
+		Exceptions: []string{},
+	}
+	if s == nil {
+		return snapshot
+	}
+	if s.Schedule != nil {
+		snapshot.Command = s.Schedule.Command
+	}
+
+	for dayName, ruleDetails := range s.DailyRules {
+		if ruleDetails == nil {
+			continue
+		}
+		rule := &RuleSnapshot{Actions: []ActionSnapshot{}}
+		if ruleDetails.RunPeriod != nil {
+			rule.RunPeriod = &PeriodSnapshot{
+				Start: ruleDetails.RunPeriod.Start,
+				End:   ruleDetails.RunPeriod.End,
+			}
+		}
+		for _, action := range ruleDetails.Actions {
+			rule.Actions = append(rule.Actions, ActionSnapshot{Type: action.Type, Time: action.Time})
+		}
+		snapshot.DailyRules[dayName] = rule
+	}
+
+	snapshot.Exceptions = append(snapshot.Exceptions, s.Exceptions...)
+	sort.Strings(snapshot.Exceptions)
+	return snapshot
+}
+
+// ToScheduleWithRules - 將快照還原為完整排程 (沿用 base 的排程主檔資訊)
+func (s *ScheduleSnapshot) ToScheduleWithRules(base *Schedule) *ScheduleWithRules {
+	fullSchedule := &ScheduleWithRules{
+		Schedule:   base,
+		DailyRules: make(map[string]*DailyRuleWithDetails),
+		Exceptions: append([]string{}, s.Exceptions...),
+	}
+	if s.Command != "" {
+		base.Command = s.Command
+	}
+
+	for dayName, rule := range s.DailyRules {
+		if rule == nil || !IsValidDayOfWeek(dayName) {
+			continue
+		}
+		ruleDetails := &DailyRuleWithDetails{
+			DailyRule: NewDailyRule(0, dayName),
+		}
+		if rule.RunPeriod != nil {
+			ruleDetails.RunPeriod = NewTimePeriod(0, rule.RunPeriod.Start, rule.RunPeriod.End)
+		}
+		for _, action := range rule.Actions {
+			ruleDetails.Actions = append(ruleDetails.Actions, NewAction(0, action.Type, action.Time))
+		}
+		fullSchedule.DailyRules[dayName] = ruleDetails
+	}
+
+	return fullSchedule
+}
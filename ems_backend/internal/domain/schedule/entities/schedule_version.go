@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"time"
+)
+
+// ScheduleVersion - 排程歷史版本 (不可變，每次保存排程都會新增一筆)
+type ScheduleVersion struct {
+	ID              uint
+	ScheduleID      uint // schedules.id
+	CompanyDeviceID uint
+	Version         int
+	Source          string // create, update, device, rollback, baseline
+	RestoredFrom    int    // rollback 時來源版本號 (0 = 非還原)
+	Rules           *ScheduleSnapshot
+	CreatedBy       uint // 0 = 來自設備
+	CreatedAt       time.Time
+}
+
+// Version source constants
+const (
+	VersionSourceCreate   = "create"
+	VersionSourceUpdate   = "update"
+	VersionSourceDevice   = "device"
+	VersionSourceRollback = "rollback"
+	VersionSourceBaseline = "baseline" // 功能上線前既有的排程
+)
+
+// NewScheduleVersion - 由完整排程建立版本紀錄
+func NewScheduleVersion(fullSchedule *ScheduleWithRules, source string, createdBy uint) *ScheduleVersion {
+	return &ScheduleVersion{
+		ScheduleID:      fullSchedule.Schedule.ID,
+		CompanyDeviceID: fullSchedule.Schedule.CompanyDeviceID,
+		Version:         fullSchedule.Schedule.Version,
+		Source:          source,
+		Rules:           fullSchedule.Snapshot(),
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+)
+
+// ScheduleDriftRepository - 排程漂移倉儲介面
+type ScheduleDriftRepository interface {
+	// Drift records
+	FindByID(id uint) (*entities.ScheduleDrift, error)
+	FindOpenByCompanyDeviceID(companyDeviceID uint) (*entities.ScheduleDrift, error)
+	FindByCompanyDeviceID(companyDeviceID uint, limit int) ([]*entities.ScheduleDrift, error)
+	Save(drift *entities.ScheduleDrift) error
+	Update(drift *entities.ScheduleDrift) error
+
+	// Per-company policy
+	FindPolicyByCompanyID(companyID uint) (*entities.ScheduleDriftPolicy, error)
+	SavePolicy(policy *entities.ScheduleDriftPolicy) error
+}
@@ -11,6 +11,7 @@ type ScheduleRepository interface {
 	FindByScheduleID(scheduleID string) (*entities.Schedule, error)
 	FindByCompanyDeviceID(companyDeviceID uint) (*entities.Schedule, error)
 	FindPending() ([]*entities.Schedule, error)
+	FindAll() ([]*entities.Schedule, error)
 	Save(schedule *entities.Schedule) error
 	Update(schedule *entities.Schedule) error
 	Delete(id uint) error
@@ -46,4 +47,6 @@ type ScheduleRepository interface {
 	// Full schedule with all relations
 	FindFullSchedule(companyDeviceID uint) (*entities.ScheduleWithRules, error)
 	SaveFullSchedule(schedule *entities.ScheduleWithRules) error
+	// SaveFullScheduleWithVersion 在同一交易中保存排程與 newVersion 產生的歷史版本，任一失敗皆回滾
+	SaveFullScheduleWithVersion(schedule *entities.ScheduleWithRules, newVersion func(*entities.ScheduleWithRules) *entities.ScheduleVersion) error
 }
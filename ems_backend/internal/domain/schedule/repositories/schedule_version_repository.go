@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+)
+
+// ScheduleVersionRepository - 排程歷史版本倉儲介面 (只新增不修改)
+type ScheduleVersionRepository interface {
+	Save(version *entities.ScheduleVersion) error
+	FindByScheduleID(scheduleID uint) ([]*entities.ScheduleVersion, error)
+	FindByVersion(scheduleID uint, version int) (*entities.ScheduleVersion, error)
+	ExistsVersion(scheduleID uint, version int) (bool, error)
+}
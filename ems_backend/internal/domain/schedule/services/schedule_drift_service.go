@@ -0,0 +1,148 @@
+package services
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+	"sort"
+	"strings"
+)
+
+// ScheduleDriftService 比對雲端排程與設備回報排程
+type ScheduleDriftService struct{}
+
+func NewScheduleDriftService() *ScheduleDriftService {
+	return &ScheduleDriftService{}
+}
+
+// Compare 逐日比對兩份排程，返回所有差異（無差異時返回空切片）
+func (s *ScheduleDriftService) Compare(cloud, device *entities.ScheduleSnapshot) []entities.RuleDifference {
+	if cloud == nil {
+		cloud = &entities.ScheduleSnapshot{}
+	}
+	if device == nil {
+		device = &entities.ScheduleSnapshot{}
+	}
+
+	differences := make([]entities.RuleDifference, 0)
+
+	for _, day := range entities.ValidDaysOfWeek {
+		cloudRule := normalizeRule(cloud.DailyRules[day])
+		deviceRule := normalizeRule(device.DailyRules[day])
+
+		switch {
+		case cloudRule == nil && deviceRule == nil:
+			continue
+		case cloudRule == nil:
+			differences = append(differences, entities.RuleDifference{
+				Day:    day,
+				Field:  entities.DiffFieldRule,
+				Kind:   entities.DiffKindAdded,
+				Device: formatRule(deviceRule),
+			})
+			continue
+		case deviceRule == nil:
+			differences = append(differences, entities.RuleDifference{
+				Day:   day,
+				Field: entities.DiffFieldRule,
+				Kind:  entities.DiffKindRemoved,
+				Cloud: formatRule(cloudRule),
+			})
+			continue
+		}
+
+		cloudPeriod := formatPeriod(cloudRule.RunPeriod)
+		devicePeriod := formatPeriod(deviceRule.RunPeriod)
+		if cloudPeriod != devicePeriod {
+			differences = append(differences, entities.RuleDifference{
+				Day:    day,
+				Field:  entities.DiffFieldRunPeriod,
+				Kind:   diffKind(cloudPeriod, devicePeriod),
+				Cloud:  cloudPeriod,
+				Device: devicePeriod,
+			})
+		}
+
+		cloudActions := formatActions(cloudRule.Actions)
+		deviceActions := formatActions(deviceRule.Actions)
+		if cloudActions != deviceActions {
+			differences = append(differences, entities.RuleDifference{
+				Day:    day,
+				Field:  entities.DiffFieldActions,
+				Kind:   diffKind(cloudActions, deviceActions),
+				Cloud:  cloudActions,
+				Device: deviceActions,
+			})
+		}
+	}
+
+	cloudExceptions := formatExceptions(cloud.Exceptions)
+	deviceExceptions := formatExceptions(device.Exceptions)
+	if cloudExceptions != deviceExceptions {
+		differences = append(differences, entities.RuleDifference{
+			Field:  entities.DiffFieldExceptions,
+			Kind:   diffKind(cloudExceptions, deviceExceptions),
+			Cloud:  cloudExceptions,
+			Device: deviceExceptions,
+		})
+	}
+
+	return differences
+}
+
+// normalizeRule 將沒有時段也沒有動作的規則視為不存在
+func normalizeRule(rule *entities.RuleSnapshot) *entities.RuleSnapshot {
+	if rule == nil {
+		return nil
+	}
+	if rule.RunPeriod == nil && len(rule.Actions) == 0 {
+		return nil
+	}
+	return rule
+}
+
+func diffKind(cloud, device string) string {
+	if cloud == "" {
+		return entities.DiffKindAdded
+	}
+	if device == "" {
+		return entities.DiffKindRemoved
+	}
+	return entities.DiffKindChanged
+}
+
+func formatRule(rule *entities.RuleSnapshot) string {
+	parts := make([]string, 0, 2)
+	if period := formatPeriod(rule.RunPeriod); period != "" {
+		parts = append(parts, period)
+	}
+	if actions := formatActions(rule.Actions); actions != "" {
+		parts = append(parts, actions)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatPeriod(period *entities.PeriodSnapshot) string {
+	if period == nil {
+		return ""
+	}
+	return period.Start + "-" + period.End
+}
+
+// formatActions 動作順序不影響設備行為，排序後再比較
+func formatActions(actions []entities.ActionSnapshot) string {
+	items := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if action.Time != "" {
+			items = append(items, action.Type+"@"+action.Time)
+		} else {
+			items = append(items, action.Type)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+func formatExceptions(exceptions []string) string {
+	items := append([]string{}, exceptions...)
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+	"testing"
+)
+
+func newSnapshot(rules map[string]*entities.RuleSnapshot, exceptions ...string) *entities.ScheduleSnapshot {
+	return &entities.ScheduleSnapshot{
+		Command:    "schedule",
+		DailyRules: rules,
+		Exceptions: exceptions,
+	}
+}
+
+func TestScheduleDriftService_Compare(t *testing.T) {
+	weekday := &entities.RuleSnapshot{
+		RunPeriod: &entities.PeriodSnapshot{Start: "08:00", End: "18:00"},
+		Actions: []entities.ActionSnapshot{
+			{Type: entities.ActionTypeCloseOnce, Time: "12:00"},
+			{Type: entities.ActionTypeForceCloseAfter, Time: "21:00"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		cloud     *entities.ScheduleSnapshot
+		device    *entities.ScheduleSnapshot
+		wantDiffs []entities.RuleDifference
+	}{
+		{
+			name:      "完全相同",
+			cloud:     newSnapshot(map[string]*entities.RuleSnapshot{"Monday": weekday}, "2026-01-01"),
+			device:    newSnapshot(map[string]*entities.RuleSnapshot{"Monday": weekday}, "2026-01-01"),
+			wantDiffs: nil,
+		},
+		{
+			name:  "動作順序不同視為相同",
+			cloud: newSnapshot(map[string]*entities.RuleSnapshot{"Monday": weekday}),
+			device: newSnapshot(map[string]*entities.RuleSnapshot{"Monday": {
+				RunPeriod: &entities.PeriodSnapshot{Start: "08:00", End: "18:00"},
+				Actions: []entities.ActionSnapshot{
+					{Type: entities.ActionTypeForceCloseAfter, Time: "21:00"},
+					{Type: entities.ActionTypeCloseOnce, Time: "12:00"},
+				},
+			}}),
+			wantDiffs: nil,
+		},
+		{
+			name:  "空規則視為不存在",
+			cloud: newSnapshot(map[string]*entities.RuleSnapshot{}),
+			device: newSnapshot(map[string]*entities.RuleSnapshot{
+				"Sunday": {Actions: []entities.ActionSnapshot{}},
+			}),
+			wantDiffs: nil,
+		},
+		{
+			name:  "設備端時段被修改",
+			cloud: newSnapshot(map[string]*entities.RuleSnapshot{"Tuesday": weekday}),
+			device: newSnapshot(map[string]*entities.RuleSnapshot{"Tuesday": {
+				RunPeriod: &entities.PeriodSnapshot{Start: "09:00", End: "18:00"},
+				Actions:   weekday.Actions,
+			}}),
+			wantDiffs: []entities.RuleDifference{
+				{Day: "Tuesday", Field: entities.DiffFieldRunPeriod, Kind: entities.DiffKindChanged, Cloud: "08:00-18:00", Device: "09:00-18:00"},
+			},
+		},
+		{
+			name:   "設備端缺少整日規則",
+			cloud:  newSnapshot(map[string]*entities.RuleSnapshot{"Tuesday": weekday}),
+			device: newSnapshot(map[string]*entities.RuleSnapshot{}),
+			wantDiffs: []entities.RuleDifference{
+				{Day: "Tuesday", Field: entities.DiffFieldRule, Kind: entities.DiffKindRemoved, Cloud: "08:00-18:00 closeOnce@12:00,forceCloseAfter@21:00"},
+			},
+		},
+		{
+			name:   "設備端新增規則與例外日期",
+			cloud:  newSnapshot(map[string]*entities.RuleSnapshot{}),
+			device: newSnapshot(map[string]*entities.RuleSnapshot{"Saturday": {Actions: []entities.ActionSnapshot{{Type: entities.ActionTypeSkip}}}}, "2026-02-14"),
+			wantDiffs: []entities.RuleDifference{
+				{Day: "Saturday", Field: entities.DiffFieldRule, Kind: entities.DiffKindAdded, Device: "skip"},
+				{Field: entities.DiffFieldExceptions, Kind: entities.DiffKindAdded, Device: "2026-02-14"},
+			},
+		},
+	}
+
+	service := NewScheduleDriftService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := service.Compare(tt.cloud, tt.device)
+
+			if len(diffs) != len(tt.wantDiffs) {
+				t.Fatalf("期望 %d 個差異，得到 %d: %+v", len(tt.wantDiffs), len(diffs), diffs)
+			}
+			for i, want := range tt.wantDiffs {
+				if diffs[i] != want {
+					t.Errorf("差異 %d: 期望 %+v，得到 %+v", i, want, diffs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleSnapshot_RoundTrip(t *testing.T) {
+	snapshot := newSnapshot(map[string]*entities.RuleSnapshot{
+		"Friday": {
+			RunPeriod: &entities.PeriodSnapshot{Start: "07:30", End: "17:30"},
+			Actions:   []entities.ActionSnapshot{{Type: entities.ActionTypeCloseOnce, Time: "12:00"}},
+		},
+	}, "2026-03-01")
+
+	fullSchedule := snapshot.ToScheduleWithRules(entities.NewSchedule(1, "uuid", 1))
+	diffs := NewScheduleDriftService().Compare(snapshot, fullSchedule.Snapshot())
+	if len(diffs) != 0 {
+		t.Errorf("還原後的排程應與快照相同，得到差異: %+v", diffs)
+	}
+}
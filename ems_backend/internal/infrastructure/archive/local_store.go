@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore - 將歸檔檔案寫入本機目錄 (可掛載網路磁碟或由外部工具同步到物件儲存)
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore - 建立本機歸檔儲存，目錄不存在時自動建立
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("archive directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Get - 讀取檔案，不存在時返回的錯誤符合 fs.ErrNotExist
+func (s *LocalStore) Get(name string) (io.ReadCloser, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+// Put - 先寫入暫存檔再改名，讀取端不會看到寫到一半的檔案
+func (s *LocalStore) Put(name string, content io.Reader) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+// validateName - 檔名不可包含路徑，避免寫出或讀取目錄以外的檔案
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid archive name %q", name)
+	}
+	return nil
+}
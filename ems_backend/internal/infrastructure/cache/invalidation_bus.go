@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"time"
+
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+// InvalidationEvent - 快取失效事件
+type InvalidationEvent struct {
+	ID         uint64
+	Kind       string
+	TargetID   uint
+	CreateTime time.Time
+}
+
+// InvalidationBus - 在多個實例之間傳遞快取失效事件
+type InvalidationBus interface {
+	Publish(events []InvalidationEvent) error
+	Since(since time.Time) ([]InvalidationEvent, error)
+	Purge(before time.Time) (int64, error)
+}
+
+// PostgresInvalidationBus - 以 cache_invalidations 表傳遞失效事件，各實例定期輪詢
+type PostgresInvalidationBus struct {
+	db *gorm.DB
+}
+
+// NewPostgresInvalidationBus - 建立資料庫失效事件通道
+func NewPostgresInvalidationBus(db *gorm.DB) *PostgresInvalidationBus {
+	return &PostgresInvalidationBus{db: db}
+}
+
+// Publish - 寫入失效事件
+func (b *PostgresInvalidationBus) Publish(events []InvalidationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]models.CacheInvalidationModel, len(events))
+	for i, event := range events {
+		rows[i] = models.CacheInvalidationModel{
+			Kind:       event.Kind,
+			TargetID:   event.TargetID,
+			CreateTime: event.CreateTime,
+		}
+	}
+	return b.db.Create(&rows).Error
+}
+
+// Since - 讀取指定時間之後的失效事件
+func (b *PostgresInvalidationBus) Since(since time.Time) ([]InvalidationEvent, error) {
+	var rows []models.CacheInvalidationModel
+	if err := b.db.Where("create_time > ?", since).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]InvalidationEvent, len(rows))
+	for i, row := range rows {
+		events[i] = InvalidationEvent{
+			ID:         row.ID,
+			Kind:       row.Kind,
+			TargetID:   row.TargetID,
+			CreateTime: row.CreateTime,
+		}
+	}
+	return events, nil
+}
+
+// Purge - 刪除指定時間之前的失效事件
+func (b *PostgresInvalidationBus) Purge(before time.Time) (int64, error) {
+	result := b.db.Where("create_time < ?", before).Delete(&models.CacheInvalidationModel{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	memberRoleEntities "ems_backend/internal/domain/member_role/entities"
+)
+
+// 權限快取預設值
+const (
+	DefaultPermissionCacheTTL       = 5 * time.Minute
+	permissionCacheSyncOverlap      = time.Minute // 容許多個實例間的時鐘誤差
+	permissionInvalidationRetention = time.Hour   // 失效事件保留時間，需遠大於同步間隔
+)
+
+// 失效事件種類，TargetID 為 0 時表示全部
+const (
+	InvalidateRole   = "role"   // 角色的權限集合
+	InvalidateMember = "member" // 成員的角色列表
+)
+
+type permissionEntry struct {
+	codes     map[string]bool
+	expiresAt time.Time
+}
+
+type memberRolesEntry struct {
+	roles     []*memberRoleEntities.MemberRole
+	expiresAt time.Time
+}
+
+// PermissionCache - 角色權限集合與成員角色的記憶體快取
+// 本實例的異動立即失效並寫入 InvalidationBus，其他實例在下一次 Sync 後失效；
+// 即使錯過事件，項目最多保留 TTL
+type PermissionCache struct {
+	ttl time.Duration
+	bus InvalidationBus // nil 時只清除本實例
+	now func() time.Time
+
+	mu          sync.RWMutex
+	generation  uint64 // 每次失效遞增，避免失效前開始的查詢寫回舊資料
+	rolePowers  map[uint]permissionEntry
+	memberRoles map[uint]memberRolesEntry
+	applied     map[uint64]time.Time // 已套用的事件 ID -> 建立時間
+	synced      time.Time
+}
+
+// NewPermissionCache - 建立權限快取
+func NewPermissionCache(ttl time.Duration, bus InvalidationBus) *PermissionCache {
+	if ttl <= 0 {
+		ttl = DefaultPermissionCacheTTL
+	}
+	return &PermissionCache{
+		ttl:         ttl,
+		bus:         bus,
+		now:         time.Now,
+		rolePowers:  make(map[uint]permissionEntry),
+		memberRoles: make(map[uint]memberRolesEntry),
+		applied:     make(map[uint64]time.Time),
+	}
+}
+
+// permissions - 角色的權限代碼集合，未命中或過期時以 load 載入
+func (c *PermissionCache) permissions(roleID uint, load func() (map[string]bool, error)) (map[string]bool, error) {
+	now := c.now()
+	c.mu.RLock()
+	entry, ok := c.rolePowers[roleID]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.codes, nil
+	}
+
+	codes, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.rolePowers[roleID] = permissionEntry{codes: codes, expiresAt: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return codes, nil
+}
+
+// roles - 成員的角色列表，未命中或過期時以 load 載入
+func (c *PermissionCache) roles(memberID uint, load func() ([]*memberRoleEntities.MemberRole, error)) ([]*memberRoleEntities.MemberRole, error) {
+	now := c.now()
+	c.mu.RLock()
+	entry, ok := c.memberRoles[memberID]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.roles, nil
+	}
+
+	roles, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.memberRoles[memberID] = memberRolesEntry{roles: roles, expiresAt: now.Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return roles, nil
+}
+
+// InvalidateRoles - 清除角色的權限集合
+func (c *PermissionCache) InvalidateRoles(roleIDs ...uint) {
+	c.invalidate(InvalidateRole, roleIDs)
+}
+
+// InvalidateAllRoles - 清除所有角色的權限集合 (權限本身異動時)
+func (c *PermissionCache) InvalidateAllRoles() {
+	c.invalidate(InvalidateRole, []uint{0})
+}
+
+// InvalidateMembers - 清除成員的角色列表
+func (c *PermissionCache) InvalidateMembers(memberIDs ...uint) {
+	c.invalidate(InvalidateMember, memberIDs)
+}
+
+// InvalidateAllMembers - 清除所有成員的角色列表 (角色本身異動時)
+func (c *PermissionCache) InvalidateAllMembers() {
+	c.invalidate(InvalidateMember, []uint{0})
+}
+
+func (c *PermissionCache) invalidate(kind string, targetIDs []uint) {
+	if len(targetIDs) == 0 {
+		return
+	}
+	now := c.now()
+	events := make([]InvalidationEvent, len(targetIDs))
+	for i, id := range targetIDs {
+		events[i] = InvalidationEvent{Kind: kind, TargetID: id, CreateTime: now}
+	}
+
+	c.mu.Lock()
+	for _, event := range events {
+		c.applyLocked(event)
+	}
+	c.mu.Unlock()
+
+	if c.bus != nil {
+		if err := c.bus.Publish(events); err != nil {
+			// 其他實例最晚在 TTL 後取得新資料
+			log.Printf("[PermissionCache] Failed to publish invalidation: %v", err)
+		}
+	}
+}
+
+func (c *PermissionCache) applyLocked(event InvalidationEvent) {
+	c.generation++
+	switch event.Kind {
+	case InvalidateRole:
+		if event.TargetID == 0 {
+			c.rolePowers = make(map[uint]permissionEntry)
+		} else {
+			delete(c.rolePowers, event.TargetID)
+		}
+	case InvalidateMember:
+		if event.TargetID == 0 {
+			c.memberRoles = make(map[uint]memberRolesEntry)
+		} else {
+			delete(c.memberRoles, event.TargetID)
+		}
+	}
+}
+
+// Sync - 套用其他實例發布的失效事件，返回新套用的數量
+func (c *PermissionCache) Sync() (int, error) {
+	if c.bus == nil {
+		return 0, nil
+	}
+	now := c.now()
+	c.mu.RLock()
+	since := c.synced.Add(-permissionCacheSyncOverlap)
+	c.mu.RUnlock()
+	if oldest := now.Add(-permissionInvalidationRetention); since.Before(oldest) {
+		since = oldest
+	}
+
+	events, err := c.bus.Since(since)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	applied := 0
+	for _, event := range events {
+		if _, ok := c.applied[event.ID]; ok {
+			continue
+		}
+		c.applied[event.ID] = event.CreateTime
+		c.applyLocked(event)
+		applied++
+	}
+	for id, createTime := range c.applied {
+		if createTime.Before(since) {
+			delete(c.applied, id)
+		}
+	}
+	c.synced = now
+	return applied, nil
+}
+
+// StartSyncLoop - 定期套用其他實例的失效事件並清除過期事件
+func (c *PermissionCache) StartSyncLoop(ctx context.Context, interval time.Duration) {
+	if c.bus == nil || interval <= 0 {
+		return
+	}
+
+	syncTicker := time.NewTicker(interval)
+	defer syncTicker.Stop()
+	purgeTicker := time.NewTicker(permissionInvalidationRetention)
+	defer purgeTicker.Stop()
+
+	log.Printf("[PermissionCache] Sync loop started (interval: %s, ttl: %s)", interval, c.ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[PermissionCache] Sync loop stopped")
+			return
+		case <-syncTicker.C:
+			if _, err := c.Sync(); err != nil {
+				log.Printf("[PermissionCache] Failed to sync invalidations: %v", err)
+			}
+		case <-purgeTicker.C:
+			if _, err := c.bus.Purge(c.now().Add(-permissionInvalidationRetention)); err != nil {
+				log.Printf("[PermissionCache] Failed to purge invalidations: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	memberRoleEntities "ems_backend/internal/domain/member_role/entities"
+	memberRoleRepos "ems_backend/internal/domain/member_role/repositories"
+	powerEntities "ems_backend/internal/domain/power/entities"
+	powerRepos "ems_backend/internal/domain/power/repositories"
+	roleRepos "ems_backend/internal/domain/role/repositories"
+)
+
+// MockPowerRepository - 只實作快取用到的方法，其餘方法呼叫時 panic
+type MockPowerRepository struct {
+	powerRepos.PowerRepository
+	codes  map[uint][]string // roleID -> 權限代碼
+	loads  int
+	onLoad func() // 查詢結果返回前呼叫，模擬查詢期間發生的異動
+}
+
+func (m *MockPowerRepository) GetByRoleID(roleID uint) ([]*powerEntities.Power, error) {
+	m.loads++
+	var powers []*powerEntities.Power
+	for _, code := range m.codes[roleID] {
+		powers = append(powers, &powerEntities.Power{Code: code})
+	}
+	if m.onLoad != nil {
+		onLoad := m.onLoad
+		m.onLoad = nil
+		onLoad()
+	}
+	return powers, nil
+}
+
+// MockRoleRepository - 寫入操作直接套用到 MockPowerRepository
+type MockRoleRepository struct {
+	roleRepos.RoleRepository
+	powers *MockPowerRepository
+}
+
+func (m *MockRoleRepository) AssignPowers(roleID uint, powerIDs []uint, memberID uint) error {
+	m.powers.codes[roleID] = append(m.powers.codes[roleID], "device:control")
+	return nil
+}
+
+func (m *MockRoleRepository) AssignMembers(roleID uint, memberIDs []uint, memberID uint) error {
+	return nil
+}
+
+// MockMemberRoleRepository - 記憶體中的成員角色
+type MockMemberRoleRepository struct {
+	memberRoleRepos.MemberRoleRepository
+	roles map[uint][]*memberRoleEntities.MemberRole
+	loads int
+}
+
+func (m *MockMemberRoleRepository) GetByMemberID(memberID uint) ([]*memberRoleEntities.MemberRole, error) {
+	m.loads++
+	return m.roles[memberID], nil
+}
+
+// MockInvalidationBus - 多個實例共用的記憶體事件表
+type MockInvalidationBus struct {
+	events     []InvalidationEvent
+	nextID     uint64
+	publishErr error
+	sinceErr   error
+}
+
+func (b *MockInvalidationBus) Publish(events []InvalidationEvent) error {
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+	for _, event := range events {
+		b.nextID++
+		event.ID = b.nextID
+		b.events = append(b.events, event)
+	}
+	return nil
+}
+
+func (b *MockInvalidationBus) Since(since time.Time) ([]InvalidationEvent, error) {
+	if b.sinceErr != nil {
+		return nil, b.sinceErr
+	}
+	var result []InvalidationEvent
+	for _, event := range b.events {
+		if event.CreateTime.After(since) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (b *MockInvalidationBus) Purge(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// cacheInstance - 一個 API 實例的快取與包裝後的倉儲
+type cacheInstance struct {
+	cache   *PermissionCache
+	powers  *CachedPowerRepository
+	roles   *CachedRoleRepository
+	members *CachedMemberRoleRepository
+}
+
+func newCacheInstance(bus InvalidationBus, powerRepo *MockPowerRepository, memberRoleRepo *MockMemberRoleRepository, now *time.Time) *cacheInstance {
+	cache := NewPermissionCache(time.Minute, bus)
+	cache.now = func() time.Time { return *now }
+	return &cacheInstance{
+		cache:   cache,
+		powers:  NewCachedPowerRepository(powerRepo, cache),
+		roles:   NewCachedRoleRepository(&MockRoleRepository{powers: powerRepo}, cache),
+		members: NewCachedMemberRoleRepository(memberRoleRepo, cache),
+	}
+}
+
+func newMockPowerRepository() *MockPowerRepository {
+	return &MockPowerRepository{codes: map[uint][]string{1: {"device:view"}}}
+}
+
+func TestPermissionCache_Hit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	powerRepo := newMockPowerRepository()
+	memberRoleRepo := &MockMemberRoleRepository{roles: map[uint][]*memberRoleEntities.MemberRole{
+		7: {{RoleID: 1, MemberID: 7}},
+	}}
+	instance := newCacheInstance(nil, powerRepo, memberRoleRepo, &now)
+
+	tests := []struct {
+		name      string
+		code      string
+		advance   time.Duration
+		want      bool
+		wantLoads int
+	}{
+		{name: "第一次查詢載入", code: "device:view", want: true, wantLoads: 1},
+		{name: "TTL 內命中快取", code: "device:view", advance: 30 * time.Second, want: true, wantLoads: 1},
+		{name: "沒有的權限同樣命中", code: "device:control", want: false, wantLoads: 1},
+		{name: "TTL 後重新載入", code: "device:view", advance: time.Minute, want: true, wantLoads: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			got, err := instance.powers.CheckPermission(1, tt.code)
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("期望 %v，得到 %v", tt.want, got)
+			}
+			if powerRepo.loads != tt.wantLoads {
+				t.Errorf("期望查詢資料庫 %d 次，得到 %d", tt.wantLoads, powerRepo.loads)
+			}
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		if roles, _ := instance.members.GetByMemberID(7); len(roles) != 1 {
+			t.Fatalf("期望 1 個角色，得到 %d", len(roles))
+		}
+	}
+	if memberRoleRepo.loads != 1 {
+		t.Errorf("成員角色應只查詢一次，得到 %d", memberRoleRepo.loads)
+	}
+}
+
+func TestPermissionCache_InvalidateOnRoleChange(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	powerRepo := newMockPowerRepository()
+	memberRoleRepo := &MockMemberRoleRepository{roles: map[uint][]*memberRoleEntities.MemberRole{}}
+	instance := newCacheInstance(nil, powerRepo, memberRoleRepo, &now)
+
+	if ok, _ := instance.powers.CheckPermission(1, "device:control"); ok {
+		t.Fatal("分配權限前不應擁有 device:control")
+	}
+	if err := instance.roles.AssignPowers(1, []uint{2}, 1); err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if ok, _ := instance.powers.CheckPermission(1, "device:control"); !ok {
+		t.Error("分配權限後應立即生效")
+	}
+
+	// 成員被指派角色後，角色列表立即重新載入
+	_, _ = instance.members.GetByMemberID(7)
+	memberRoleRepo.roles[7] = []*memberRoleEntities.MemberRole{{RoleID: 1, MemberID: 7}}
+	if err := instance.roles.AssignMembers(1, []uint{7}, 1); err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if roles, _ := instance.members.GetByMemberID(7); len(roles) != 1 {
+		t.Errorf("指派角色後應立即生效，得到 %d 個角色", len(roles))
+	}
+}
+
+func TestPermissionCache_GenerationCheck(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	powerRepo := newMockPowerRepository()
+	instance := newCacheInstance(nil, powerRepo, &MockMemberRoleRepository{}, &now)
+
+	// 查詢已讀到舊資料，返回前角色被分配新權限並失效
+	powerRepo.onLoad = func() {
+		if err := instance.roles.AssignPowers(1, []uint{2}, 1); err != nil {
+			t.Fatalf("不期望錯誤: %v", err)
+		}
+	}
+	if ok, _ := instance.powers.CheckPermission(1, "device:control"); ok {
+		t.Fatal("查詢開始時尚未擁有 device:control")
+	}
+
+	// 舊資料不可寫入快取，下一次查詢須重新載入
+	if ok, _ := instance.powers.CheckPermission(1, "device:control"); !ok {
+		t.Error("失效後的查詢應取得新權限，舊資料不應覆蓋失效")
+	}
+	if powerRepo.loads != 2 {
+		t.Errorf("期望查詢資料庫 2 次，得到 %d", powerRepo.loads)
+	}
+}
+
+func TestPermissionCache_Sync(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	bus := &MockInvalidationBus{}
+	powerRepo := newMockPowerRepository()
+	memberRoleRepo := &MockMemberRoleRepository{}
+	a := newCacheInstance(bus, powerRepo, memberRoleRepo, &now)
+	b := newCacheInstance(bus, powerRepo, memberRoleRepo, &now)
+
+	_, _ = b.powers.CheckPermission(1, "device:control")
+	if applied, err := b.cache.Sync(); err != nil || applied != 0 {
+		t.Fatalf("尚無事件，得到 %d %v", applied, err)
+	}
+	now = now.Add(time.Second)
+	if err := a.roles.AssignPowers(1, []uint{2}, 1); err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if ok, _ := b.powers.CheckPermission(1, "device:control"); ok {
+		t.Fatal("Sync 前其他實例仍使用快取")
+	}
+
+	// 一次輪詢失敗 (錯過通知)，下一次輪詢仍會取得該事件
+	bus.sinceErr = errors.New("connection reset")
+	if _, err := b.cache.Sync(); err == nil {
+		t.Fatal("期望輪詢錯誤")
+	}
+	bus.sinceErr = nil
+	now = now.Add(10 * time.Second)
+	applied, err := b.cache.Sync()
+	if err != nil || applied != 1 {
+		t.Fatalf("期望套用 1 個事件，得到 %d %v", applied, err)
+	}
+	if ok, _ := b.powers.CheckPermission(1, "device:control"); !ok {
+		t.Error("Sync 後應取得新權限")
+	}
+
+	// 重疊區間內再次讀到同一事件時不重複套用
+	loads := powerRepo.loads
+	if applied, _ := b.cache.Sync(); applied != 0 {
+		t.Errorf("已套用的事件不應重複套用，得到 %d", applied)
+	}
+	_, _ = b.powers.CheckPermission(1, "device:control")
+	if powerRepo.loads != loads {
+		t.Error("沒有新事件時應繼續使用快取")
+	}
+}
+
+func TestPermissionCache_PublishFailureFallsBackToTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	bus := &MockInvalidationBus{publishErr: errors.New("connection reset")}
+	powerRepo := newMockPowerRepository()
+	a := newCacheInstance(bus, powerRepo, &MockMemberRoleRepository{}, &now)
+	b := newCacheInstance(bus, powerRepo, &MockMemberRoleRepository{}, &now)
+
+	_, _ = b.powers.CheckPermission(1, "device:control")
+	if err := a.roles.AssignPowers(1, []uint{2}, 1); err != nil {
+		t.Fatalf("發布失敗不應影響異動本身: %v", err)
+	}
+	if ok, _ := a.powers.CheckPermission(1, "device:control"); !ok {
+		t.Error("本實例應立即失效")
+	}
+
+	if applied, _ := b.cache.Sync(); applied != 0 {
+		t.Fatalf("事件未發布，不應有可套用的事件，得到 %d", applied)
+	}
+	if ok, _ := b.powers.CheckPermission(1, "device:control"); ok {
+		t.Fatal("TTL 內其他實例仍使用快取")
+	}
+	now = now.Add(time.Minute)
+	if ok, _ := b.powers.CheckPermission(1, "device:control"); !ok {
+		t.Error("錯過通知的實例最晚在 TTL 後取得新權限")
+	}
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	memberRoleEntities "ems_backend/internal/domain/member_role/entities"
+	memberRoleRepos "ems_backend/internal/domain/member_role/repositories"
+	powerEntities "ems_backend/internal/domain/power/entities"
+	powerRepos "ems_backend/internal/domain/power/repositories"
+	roleEntities "ems_backend/internal/domain/role/entities"
+	roleRepos "ems_backend/internal/domain/role/repositories"
+)
+
+// CachedPowerRepository - 權限檢查改查快取中的角色權限集合，權限異動時清除所有角色
+type CachedPowerRepository struct {
+	powerRepos.PowerRepository
+	cache *PermissionCache
+}
+
+// NewCachedPowerRepository - 以快取包裝權限倉儲
+func NewCachedPowerRepository(repo powerRepos.PowerRepository, cache *PermissionCache) *CachedPowerRepository {
+	return &CachedPowerRepository{PowerRepository: repo, cache: cache}
+}
+
+// CheckPermission 檢查角色是否擁有某個權限代碼
+func (r *CachedPowerRepository) CheckPermission(roleID uint, code string) (bool, error) {
+	codes, err := r.rolePermissions(roleID)
+	if err != nil {
+		return false, err
+	}
+	return codes[code], nil
+}
+
+// CheckPermissionByRoleIDs 檢查多個角色是否擁有某個權限代碼
+func (r *CachedPowerRepository) CheckPermissionByRoleIDs(roleIDs []uint, code string) (bool, error) {
+	for _, roleID := range roleIDs {
+		codes, err := r.rolePermissions(roleID)
+		if err != nil {
+			return false, err
+		}
+		if codes[code] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create 創建權限
+func (r *CachedPowerRepository) Create(power *powerEntities.Power, memberID uint) error {
+	if err := r.PowerRepository.Create(power, memberID); err != nil {
+		return err
+	}
+	r.cache.InvalidateAllRoles()
+	return nil
+}
+
+// Update 更新權限 (代碼或啟用狀態可能改變)
+func (r *CachedPowerRepository) Update(power *powerEntities.Power, memberID uint) error {
+	if err := r.PowerRepository.Update(power, memberID); err != nil {
+		return err
+	}
+	r.cache.InvalidateAllRoles()
+	return nil
+}
+
+// Delete 刪除權限
+func (r *CachedPowerRepository) Delete(id uint) error {
+	if err := r.PowerRepository.Delete(id); err != nil {
+		return err
+	}
+	r.cache.InvalidateAllRoles()
+	return nil
+}
+
+func (r *CachedPowerRepository) rolePermissions(roleID uint) (map[string]bool, error) {
+	return r.cache.permissions(roleID, func() (map[string]bool, error) {
+		powers, err := r.PowerRepository.GetByRoleID(roleID)
+		if err != nil {
+			return nil, err
+		}
+		codes := make(map[string]bool, len(powers))
+		for _, power := range powers {
+			codes[power.Code] = true
+		}
+		return codes, nil
+	})
+}
+
+// CachedRoleRepository - 角色的權限或成員異動時清除對應的快取
+type CachedRoleRepository struct {
+	roleRepos.RoleRepository
+	cache *PermissionCache
+}
+
+// NewCachedRoleRepository - 以快取失效包裝角色倉儲
+func NewCachedRoleRepository(repo roleRepos.RoleRepository, cache *PermissionCache) *CachedRoleRepository {
+	return &CachedRoleRepository{RoleRepository: repo, cache: cache}
+}
+
+// Update 更新角色 (名稱會出現在成員角色列表中)
+func (r *CachedRoleRepository) Update(role *roleEntities.Role, memberID uint) error {
+	if err := r.RoleRepository.Update(role, memberID); err != nil {
+		return err
+	}
+	r.cache.InvalidateAllMembers()
+	return nil
+}
+
+// Delete 刪除角色及其權限與成員關聯
+func (r *CachedRoleRepository) Delete(id uint) error {
+	if err := r.RoleRepository.Delete(id); err != nil {
+		return err
+	}
+	r.cache.InvalidateRoles(id)
+	r.cache.InvalidateAllMembers()
+	return nil
+}
+
+// AssignPowers 為角色分配權限
+func (r *CachedRoleRepository) AssignPowers(roleID uint, powerIDs []uint, memberID uint) error {
+	if err := r.RoleRepository.AssignPowers(roleID, powerIDs, memberID); err != nil {
+		return err
+	}
+	r.cache.InvalidateRoles(roleID)
+	return nil
+}
+
+// RemovePowers 移除角色的權限
+func (r *CachedRoleRepository) RemovePowers(roleID uint, powerIDs []uint) error {
+	if err := r.RoleRepository.RemovePowers(roleID, powerIDs); err != nil {
+		return err
+	}
+	r.cache.InvalidateRoles(roleID)
+	return nil
+}
+
+// AssignMembers 將角色分配給成員
+func (r *CachedRoleRepository) AssignMembers(roleID uint, memberIDs []uint, memberID uint) error {
+	if err := r.RoleRepository.AssignMembers(roleID, memberIDs, memberID); err != nil {
+		return err
+	}
+	r.cache.InvalidateMembers(memberIDs...)
+	return nil
+}
+
+// RemoveMembers 從角色中移除成員
+func (r *CachedRoleRepository) RemoveMembers(roleID uint, memberIDs []uint) error {
+	if err := r.RoleRepository.RemoveMembers(roleID, memberIDs); err != nil {
+		return err
+	}
+	r.cache.InvalidateMembers(memberIDs...)
+	return nil
+}
+
+// CachedMemberRoleRepository - 成員角色列表改查快取 (AuthMiddleware 每個請求都會查詢)
+type CachedMemberRoleRepository struct {
+	memberRoleRepos.MemberRoleRepository
+	cache *PermissionCache
+}
+
+// NewCachedMemberRoleRepository - 以快取包裝成員角色倉儲
+func NewCachedMemberRoleRepository(repo memberRoleRepos.MemberRoleRepository, cache *PermissionCache) *CachedMemberRoleRepository {
+	return &CachedMemberRoleRepository{MemberRoleRepository: repo, cache: cache}
+}
+
+// GetByMemberID 獲取成員的角色列表，返回的切片為共用資料，呼叫端不可修改
+func (r *CachedMemberRoleRepository) GetByMemberID(memberID uint) ([]*memberRoleEntities.MemberRole, error) {
+	return r.cache.roles(memberID, func() ([]*memberRoleEntities.MemberRole, error) {
+		return r.MemberRoleRepository.GetByMemberID(memberID)
+	})
+}
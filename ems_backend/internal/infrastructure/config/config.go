@@ -0,0 +1,635 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 執行環境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DefaultPath - 未指定 CONFIG_FILE 時讀取的設定檔 (不存在時只使用預設值與環境變數)
+const DefaultPath = "config.yaml"
+
+// 開發用的 JWT 密鑰，production 環境拒絕啟動
+const (
+	DefaultAccessSecret  = "default-access-secret-change-in-production"
+	DefaultRefreshSecret = "default-refresh-secret-change-in-production"
+)
+
+// production 環境 JWT 密鑰的最短長度
+const minProductionSecretLength = 32
+
+// Config - 服務設定，依序套用預設值、YAML 設定檔、環境變數後驗證
+type Config struct {
+	Env      string         `yaml:"env"` // development 或 production
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	CORS     CORSConfig     `yaml:"cors"`
+	SQS      SQSConfig      `yaml:"sqs"`
+	MQTT     MQTTConfig     `yaml:"mqtt"`
+
+	Login           LoginConfig           `yaml:"login"`
+	Password        PasswordConfig        `yaml:"password"`
+	MFA             MFAConfig             `yaml:"mfa"`
+	Session         SessionConfig         `yaml:"session"`
+	OIDC            OIDCConfig            `yaml:"oidc"`
+	APIKey          APIKeyConfig          `yaml:"api_key"`
+	PermissionCache PermissionCacheConfig `yaml:"permission_cache"`
+	Audit           AuditConfig           `yaml:"audit"`
+	RateLimit       RateLimitConfig       `yaml:"rate_limit"`
+	Mail            MailConfig            `yaml:"mail"`
+	Jobs            JobsConfig            `yaml:"jobs"`
+
+	File string `yaml:"-"` // 實際讀取的設定檔，未讀取時為空
+}
+
+// ServerConfig - HTTP 服務
+type ServerConfig struct {
+	Port           string   `yaml:"port"`
+	GinMode        string   `yaml:"gin_mode"`        // debug、release 或 test
+	TrustedProxies []string `yaml:"trusted_proxies"` // 只信任這些代理帶入的 X-Forwarded-For
+	MetricsToken   string   `yaml:"metrics_token"`   // 設定時 GET /metrics 需 Bearer token
+}
+
+// DatabaseConfig - PostgreSQL 連線
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+
+	AutoMigrate bool `yaml:"auto_migrate"` // 啟動時在 advisory lock 內套用尚未套用的 migration
+}
+
+// JWTConfig - access / refresh token 的簽章密鑰與有效期限
+type JWTConfig struct {
+	AccessSecret  string        `yaml:"access_secret"`
+	RefreshSecret string        `yaml:"refresh_secret"`
+	AccessExpiry  time.Duration `yaml:"access_expiry"`
+	RefreshExpiry time.Duration `yaml:"refresh_expiry"` // 也是會話的有效期限
+}
+
+// CORSConfig - 允許跨來源呼叫 API 的前端網址
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+// SQSConfig - 溫度、電表與狀態佇列監聽
+type SQSConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Region  string `yaml:"region"`
+}
+
+// MQTTConfig - 設備命令通道 (AWS IoT Core 或標準 broker)
+// 設定 BrokerURL 時優先於 Endpoint；AWS IoT Core 需要 CA、用戶端憑證與私鑰
+type MQTTConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	Endpoint           string `yaml:"endpoint"`   // xxxxx.iot.ap-northeast-1.amazonaws.com:8883
+	BrokerURL          string `yaml:"broker_url"` // tcp://、ssl://、ws://、wss://
+	ClientID           string `yaml:"client_id"`  // 未設定時以啟動時間產生
+	Username           string `yaml:"username"`
+	Password           string `yaml:"password"`
+	CACert             string `yaml:"ca_cert"`
+	ClientCert         string `yaml:"client_cert"`
+	PrivateKey         string `yaml:"private_key"`
+	InsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify"` // 僅供開發
+	TopicPrefix        string `yaml:"topic_prefix"`             // 取代 ac/command、ac/return 的 "ac"
+	ProtocolVersion    uint   `yaml:"protocol_version"`         // 3 或 4 (MQTT 3.1.1，預設)
+	MaxQoS             uint8  `yaml:"max_qos"`                  // 1 或 2，AWS IoT Core 最高為 1
+	CleanSession       bool   `yaml:"clean_session"`            // false 時使用持久會話，離線保留期限由 broker 設定
+}
+
+// LoginConfig - 登入節流與帳號鎖定
+// 同一 email / IP 連續失敗超過免等待次數後，等待時間自 BaseDelay 起加倍 (最多 MaxDelay)
+type LoginConfig struct {
+	FreeAttempts         int           `yaml:"free_attempts"`
+	IPFreeAttempts       int           `yaml:"ip_free_attempts"` // 同一 IP 可能有多位使用者，門檻較高
+	BaseDelay            time.Duration `yaml:"base_delay"`
+	MaxDelay             time.Duration `yaml:"max_delay"`
+	FailureWindow        time.Duration `yaml:"failure_window"`         // 最後一次失敗超過此時間後重新計算
+	LockoutThreshold     int           `yaml:"lockout_threshold"`      // 帳號連續失敗達門檻即鎖定，0 表示不鎖定
+	LockoutDuration      time.Duration `yaml:"lockout_duration"`       // 0 表示需管理員解鎖
+	LockoutSweepInterval time.Duration `yaml:"lockout_sweep_interval"` // 到期鎖定的自動解鎖掃描間隔，0 表示停用
+}
+
+// PasswordConfig - 密碼政策、新密碼的 argon2 參數與忘記密碼
+type PasswordConfig struct {
+	MinLength      int           `yaml:"min_length"`
+	RequireUpper   bool          `yaml:"require_upper"`
+	RequireLower   bool          `yaml:"require_lower"`
+	RequireDigit   bool          `yaml:"require_digit"`
+	RequireSymbol  bool          `yaml:"require_symbol"`
+	MaxAge         time.Duration `yaml:"max_age"`          // 0 表示不限制，過期後登入結果帶 password_expired
+	HistoryCount   int           `yaml:"history_count"`    // 不可與最近幾組密碼相同
+	CommonListFile string        `yaml:"common_list_file"` // 額外的常見 / 外洩密碼清單 (每行一組)
+	Argon2Time     uint32        `yaml:"argon2_time"`      // 調高後舊雜湊會在下次登入成功時自動升級
+	Argon2MemoryKB uint32        `yaml:"argon2_memory_kb"`
+	Argon2Threads  uint8         `yaml:"argon2_threads"`
+	ResetURL       string        `yaml:"reset_url"` // 重設連結指向的前端頁面 (token 以 ?token= 附加)
+	ResetTTL       time.Duration `yaml:"reset_ttl"`
+}
+
+// MFAConfig - 多因素驗證
+type MFAConfig struct {
+	Issuer        string        `yaml:"issuer"`         // 顯示於驗證器 App
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl"`  // 密碼正確後需在此時間內完成驗證
+	EncryptionKey string        `yaml:"encryption_key"` // 以 AES-GCM 加密保存 TOTP 密鑰，設定後不可更換
+}
+
+// SessionConfig - 會話撤銷：本實例立即生效，其他實例每 RevocationSyncInterval 同步一次
+type SessionConfig struct {
+	RevocationSyncInterval time.Duration `yaml:"revocation_sync_interval"`
+	PurgeInterval          time.Duration `yaml:"purge_interval"`
+}
+
+// OIDCConfig - 單一登入
+type OIDCConfig struct {
+	ProvidersFile string        `yaml:"providers_file"` // 身分提供者設定 (JSON 陣列，格式見 oidc.ProviderSettings)，未設定時停用
+	StateTTL      time.Duration `yaml:"state_ttl"`      // 授權碼流程須在此時間內完成
+}
+
+// APIKeyConfig - 服務帳號 API Key
+type APIKeyConfig struct {
+	MaxTTL time.Duration `yaml:"max_ttl"` // 最長有效期限 (未指定 expires_at 時以此為到期時間)，0 表示允許永不過期
+}
+
+// PermissionCacheConfig - 角色權限與成員角色快取
+type PermissionCacheConfig struct {
+	TTL          time.Duration `yaml:"ttl"`           // 最長保留時間
+	SyncInterval time.Duration `yaml:"sync_interval"` // 同步其他實例異動的間隔
+}
+
+// AuditConfig - 審計日誌保留、歸檔與雜湊鏈
+type AuditConfig struct {
+	Retention         time.Duration `yaml:"retention"`          // 0 為永久保留
+	RetentionInterval time.Duration `yaml:"retention_interval"` // 過期記錄歸檔後刪除的間隔
+	ArchiveDriver     string        `yaml:"archive_driver"`     // local (寫入 ArchiveDir)
+	ArchiveDir        string        `yaml:"archive_dir"`
+	ChainKey          string        `yaml:"chain_key"`           // 雜湊鏈的 HMAC key，不可存放在資料庫中且設定後不可更換
+	ChainHeadInterval time.Duration `yaml:"chain_head_interval"` // 鏈尾記錄到歸檔儲存的間隔
+}
+
+// RateLimitConfig - 請求限流
+type RateLimitConfig struct {
+	Policies      string        `yaml:"policies"` // 格式見 ratelimit.ParsePolicies，none 表示停用
+	Store         string        `yaml:"store"`    // memory (單機) 或 postgres (多個實例共用 rate_limit_buckets)
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// MailConfig - 郵件發送
+type MailConfig struct {
+	Driver string     `yaml:"driver"` // log (僅寫入日誌) 或 smtp
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig - SMTP 伺服器
+type SMTPConfig struct {
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	From        string `yaml:"from"`
+	ImplicitTLS bool   `yaml:"implicit_tls"` // true 時直接以 TLS 連線 (465)，否則在伺服器支援時使用 STARTTLS
+}
+
+// JobsConfig - 背景工作的執行間隔，0 表示停用
+type JobsConfig struct {
+	ScheduleDriftPollInterval time.Duration `yaml:"schedule_drift_poll_interval"` // 定期向設備發送 getSchedule 比對排程
+	ComfortControlInterval    time.Duration `yaml:"comfort_control_interval"`     // 區域舒適度閉環控制
+	ComfortControlDryRun      bool          `yaml:"comfort_control_dry_run"`      // true 時所有區域僅記錄決策
+	DemandControlInterval     time.Duration `yaml:"demand_control_interval"`      // 契約容量需量控制
+	FirmwareCampaignInterval  time.Duration `yaml:"firmware_campaign_interval"`   // 韌體發布活動推進 (派送下一批、標記逾時)
+	ClaimCodeExpiryInterval   time.Duration `yaml:"claim_code_expiry_interval"`   // 認領碼過期掃描
+}
+
+// Default - 開發環境的預設設定
+func Default() *Config {
+	return &Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:    "8080",
+			GinMode: "release",
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "ems_user",
+			Name:    "ems",
+			SSLMode: "require", // RDS 需要 SSL
+		},
+		JWT: JWTConfig{
+			AccessSecret:  DefaultAccessSecret,
+			RefreshSecret: DefaultRefreshSecret,
+			AccessExpiry:  5 * time.Minute,
+			RefreshExpiry: 24 * time.Hour,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:5173", "http://localhost:3000", "http://127.0.0.1:5173", "http://127.0.0.1:3000", "https://kaiems.com"},
+		},
+		SQS: SQSConfig{
+			Enabled: true,
+			Region:  "ap-southeast-2",
+		},
+		MQTT: MQTTConfig{
+			TopicPrefix:  "ac",
+			CleanSession: true,
+		},
+		Login: LoginConfig{
+			FreeAttempts:         3,
+			IPFreeAttempts:       20,
+			BaseDelay:            time.Second,
+			MaxDelay:             5 * time.Minute,
+			FailureWindow:        15 * time.Minute,
+			LockoutThreshold:     10,
+			LockoutDuration:      30 * time.Minute,
+			LockoutSweepInterval: time.Minute,
+		},
+		Password: PasswordConfig{
+			MinLength:      8,
+			RequireUpper:   true,
+			RequireLower:   true,
+			RequireDigit:   true,
+			HistoryCount:   5,
+			Argon2Time:     3,
+			Argon2MemoryKB: 64 * 1024,
+			Argon2Threads:  4,
+			ResetURL:       "http://localhost:3000/reset-password",
+			ResetTTL:       30 * time.Minute,
+		},
+		MFA: MFAConfig{
+			Issuer:       "EMS",
+			ChallengeTTL: 5 * time.Minute,
+		},
+		Session: SessionConfig{
+			RevocationSyncInterval: 5 * time.Second,
+			PurgeInterval:          time.Hour,
+		},
+		OIDC: OIDCConfig{
+			StateTTL: 10 * time.Minute,
+		},
+		APIKey: APIKeyConfig{
+			MaxTTL: 365 * 24 * time.Hour,
+		},
+		PermissionCache: PermissionCacheConfig{
+			TTL:          5 * time.Minute,
+			SyncInterval: 5 * time.Second,
+		},
+		Audit: AuditConfig{
+			Retention:         365 * 24 * time.Hour,
+			RetentionInterval: 24 * time.Hour,
+			ArchiveDriver:     "local",
+			ArchiveDir:        "archive/audit_log",
+			ChainHeadInterval: 5 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			// default:ip 在驗證身分前以來源 IP 限流，需容納同一 IP (NAT) 後的多個會員
+			Policies:      "auth:ip=20/m,burst=10;dashboard_areas=20/m,burst=5;default:ip=3000/m,burst=500;default=600/m,burst=100",
+			Store:         "memory",
+			PurgeInterval: 10 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			SMTP:   SMTPConfig{Port: 587},
+		},
+		Jobs: JobsConfig{
+			ScheduleDriftPollInterval: 30 * time.Minute,
+			ComfortControlInterval:    time.Minute,
+			DemandControlInterval:     time.Minute,
+			FirmwareCampaignInterval:  30 * time.Second,
+			ClaimCodeExpiryInterval:   5 * time.Minute,
+		},
+	}
+}
+
+// Load - 讀取設定：預設值 → YAML 設定檔 → 環境變數，最後驗證
+// path 為空時讀取 DefaultPath (不存在則略過)；明確指定的檔案不存在時返回錯誤
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	required := path != ""
+	if path == "" {
+		path = DefaultPath
+	}
+	if err := cfg.loadFile(path); err != nil {
+		if required || !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	} else {
+		cfg.File = path
+	}
+
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 以 YAML 設定檔覆蓋目前的值，不認得的欄位視為錯誤
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsProduction 是否為 production 環境
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validate 檢查設定，返回所有問題；production 環境拒絕預設或過短的密鑰與不安全的選項
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		add("env must be %s or %s, got %q", EnvDevelopment, EnvProduction, c.Env)
+	}
+
+	// Server
+	if port, err := parsePort(c.Server.Port); err != nil || port == 0 {
+		add("server.port must be 1-65535, got %q", c.Server.Port)
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		add("server.gin_mode must be debug, release or test, got %q", c.Server.GinMode)
+	}
+
+	// Database
+	if c.Database.Host == "" {
+		add("database.host is required")
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		add("database.port must be 1-65535, got %d", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		add("database.user is required")
+	}
+	if c.Database.Name == "" {
+		add("database.name is required")
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		add("database.sslmode %q is not a valid libpq sslmode", c.Database.SSLMode)
+	}
+
+	// JWT
+	if c.JWT.AccessSecret == "" || c.JWT.RefreshSecret == "" {
+		add("jwt.access_secret and jwt.refresh_secret are required")
+	}
+	if c.JWT.AccessExpiry <= 0 {
+		add("jwt.access_expiry must be positive, got %s", c.JWT.AccessExpiry)
+	}
+	if c.JWT.RefreshExpiry < c.JWT.AccessExpiry {
+		add("jwt.refresh_expiry (%s) must not be shorter than jwt.access_expiry (%s)", c.JWT.RefreshExpiry, c.JWT.AccessExpiry)
+	}
+
+	// CORS (允許攜帶憑證，不能允許所有來源)
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			add("cors.allow_origins must list explicit origins, \"*\" is not allowed with credentials")
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			add("cors.allow_origins entry %q is not an origin (scheme://host[:port])", origin)
+		}
+	}
+
+	// SQS
+	if c.SQS.Enabled && c.SQS.Region == "" {
+		add("sqs.region is required when SQS is enabled")
+	}
+
+	// MQTT
+	if c.MQTT.Enabled {
+		if c.MQTT.Endpoint == "" && c.MQTT.BrokerURL == "" {
+			add("mqtt.endpoint (AWS IoT Core) or mqtt.broker_url is required when MQTT is enabled")
+		}
+		if c.MQTT.BrokerURL == "" && (c.MQTT.CACert == "" || c.MQTT.ClientCert == "" || c.MQTT.PrivateKey == "") {
+			add("mqtt.ca_cert, mqtt.client_cert and mqtt.private_key are required for AWS IoT Core")
+		}
+	}
+	if v := c.MQTT.ProtocolVersion; v != 0 && v != 3 && v != 4 {
+		add("mqtt.protocol_version must be 3 or 4, got %d", v)
+	}
+	if c.MQTT.MaxQoS > 2 {
+		add("mqtt.max_qos must be 0-2, got %d", c.MQTT.MaxQoS)
+	}
+
+	// 時間長度：間隔與期限為 0 時停用或不限制，不可為負
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"login.base_delay", c.Login.BaseDelay},
+		{"login.max_delay", c.Login.MaxDelay},
+		{"login.failure_window", c.Login.FailureWindow},
+		{"login.lockout_duration", c.Login.LockoutDuration},
+		{"login.lockout_sweep_interval", c.Login.LockoutSweepInterval},
+		{"password.max_age", c.Password.MaxAge},
+		{"session.revocation_sync_interval", c.Session.RevocationSyncInterval},
+		{"session.purge_interval", c.Session.PurgeInterval},
+		{"api_key.max_ttl", c.APIKey.MaxTTL},
+		{"permission_cache.sync_interval", c.PermissionCache.SyncInterval},
+		{"audit.retention", c.Audit.Retention},
+		{"audit.retention_interval", c.Audit.RetentionInterval},
+		{"audit.chain_head_interval", c.Audit.ChainHeadInterval},
+		{"rate_limit.purge_interval", c.RateLimit.PurgeInterval},
+		{"jobs.schedule_drift_poll_interval", c.Jobs.ScheduleDriftPollInterval},
+		{"jobs.comfort_control_interval", c.Jobs.ComfortControlInterval},
+		{"jobs.demand_control_interval", c.Jobs.DemandControlInterval},
+		{"jobs.firmware_campaign_interval", c.Jobs.FirmwareCampaignInterval},
+		{"jobs.claim_code_expiry_interval", c.Jobs.ClaimCodeExpiryInterval},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			add("%s must not be negative, got %s", d.name, d.value)
+		}
+	}
+	ttls := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"password.reset_ttl", c.Password.ResetTTL},
+		{"mfa.challenge_ttl", c.MFA.ChallengeTTL},
+		{"oidc.state_ttl", c.OIDC.StateTTL},
+		{"permission_cache.ttl", c.PermissionCache.TTL},
+	}
+	for _, d := range ttls {
+		if d.value <= 0 {
+			add("%s must be positive, got %s", d.name, d.value)
+		}
+	}
+
+	// Login
+	if c.Login.FreeAttempts < 0 || c.Login.IPFreeAttempts < 0 || c.Login.LockoutThreshold < 0 {
+		add("login.free_attempts, login.ip_free_attempts and login.lockout_threshold must not be negative")
+	}
+
+	// Password
+	if c.Password.MinLength < 0 || c.Password.HistoryCount < 0 {
+		add("password.min_length and password.history_count must not be negative")
+	}
+	if c.Password.Argon2Time == 0 || c.Password.Argon2MemoryKB == 0 || c.Password.Argon2Threads == 0 {
+		add("password.argon2_time, password.argon2_memory_kb and password.argon2_threads must be positive")
+	}
+	if u, err := url.Parse(c.Password.ResetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("password.reset_url must be an absolute http(s) URL, got %q", c.Password.ResetURL)
+	}
+
+	// Audit
+	if c.Audit.ArchiveDriver != "local" {
+		add("audit.archive_driver must be local, got %q", c.Audit.ArchiveDriver)
+	}
+	if c.Audit.ArchiveDriver == "local" && c.Audit.ArchiveDir == "" {
+		add("audit.archive_dir is required for the local archive driver")
+	}
+
+	// Rate limit
+	if c.RateLimit.Policies == "" {
+		add("rate_limit.policies is required, use none to disable rate limiting")
+	}
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "postgres" {
+		add("rate_limit.store must be memory or postgres, got %q", c.RateLimit.Store)
+	}
+
+	// Mail
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.SMTP.Host == "" || c.Mail.SMTP.From == "" {
+			add("mail.smtp.host and mail.smtp.from are required for the smtp mail driver")
+		}
+		if c.Mail.SMTP.Port <= 0 || c.Mail.SMTP.Port > 65535 {
+			add("mail.smtp.port must be 1-65535, got %d", c.Mail.SMTP.Port)
+		}
+	default:
+		add("mail.driver must be log or smtp, got %q", c.Mail.Driver)
+	}
+
+	if c.IsProduction() {
+		errs = append(errs, c.validateProduction()...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateProduction production 環境額外的安全檢查
+func (c *Config) validateProduction() []error {
+	var errs []error
+	checkSecret := func(name, value, defaultValue string) {
+		switch {
+		case value == defaultValue:
+			errs = append(errs, fmt.Errorf("%s must be changed from the default in production", name))
+		case len(value) < minProductionSecretLength:
+			errs = append(errs, fmt.Errorf("%s must be at least %d characters in production", name, minProductionSecretLength))
+		}
+	}
+	checkSecret("jwt.access_secret", c.JWT.AccessSecret, DefaultAccessSecret)
+	checkSecret("jwt.refresh_secret", c.JWT.RefreshSecret, DefaultRefreshSecret)
+	if c.JWT.AccessSecret != "" && c.JWT.AccessSecret == c.JWT.RefreshSecret {
+		errs = append(errs, errors.New("jwt.access_secret and jwt.refresh_secret must differ in production"))
+	}
+
+	if c.Database.Password == "" {
+		errs = append(errs, errors.New("database.password is required in production"))
+	}
+	if c.Database.SSLMode == "disable" {
+		errs = append(errs, errors.New("database.sslmode must not be disable in production"))
+	}
+	if c.Server.GinMode != "release" {
+		errs = append(errs, fmt.Errorf("server.gin_mode must be release in production, got %q", c.Server.GinMode))
+	}
+	if c.MQTT.InsecureSkipVerify {
+		errs = append(errs, errors.New("mqtt.tls_insecure_skip_verify is not allowed in production"))
+	}
+	if u, err := url.Parse(c.Password.ResetURL); err == nil && isLoopbackHost(u.Hostname()) {
+		errs = append(errs, fmt.Errorf("password.reset_url must point to the deployed frontend in production, got %q", c.Password.ResetURL))
+	}
+	if c.MFA.EncryptionKey == "" {
+		errs = append(errs, errors.New("mfa.encryption_key is required in production"))
+	}
+	if c.Audit.ChainKey == "" {
+		errs = append(errs, errors.New("audit.chain_key is required in production"))
+	}
+	if c.Mail.Driver == "log" {
+		errs = append(errs, errors.New("mail.driver must not be log in production, password reset mails would only be logged"))
+	}
+	return errs
+}
+
+// isLoopbackHost localhost 或回送位址
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Warnings 不阻止啟動但應提醒的設定 (開發環境使用預設密鑰等)
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.JWT.AccessSecret == DefaultAccessSecret || c.JWT.RefreshSecret == DefaultRefreshSecret {
+		warnings = append(warnings, "using default JWT secrets, set JWT_ACCESS_SECRET and JWT_REFRESH_SECRET (refused when env is production)")
+	}
+	if c.Database.Password == "" {
+		warnings = append(warnings, "database password is empty")
+	}
+	if c.MQTT.Enabled && c.MQTT.InsecureSkipVerify {
+		warnings = append(warnings, "MQTT broker certificate verification is disabled")
+	}
+	if !c.IsProduction() && c.MFA.EncryptionKey == "" {
+		warnings = append(warnings, "TOTP secrets are stored unencrypted, set MFA_ENCRYPTION_KEY (required when env is production)")
+	}
+	if !c.IsProduction() && c.Audit.ChainKey == "" {
+		warnings = append(warnings, "audit chain hashes are plain SHA-256, set AUDIT_CHAIN_KEY (required when env is production)")
+	}
+	if c.IsProduction() && c.Server.MetricsToken == "" {
+		warnings = append(warnings, "GET /metrics is not protected, set METRICS_TOKEN")
+	}
+	return warnings
+}
+
+// DSN PostgreSQL 連線字串
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=UTC",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), d.SSLMode)
+}
+
+// quoteDSN 值含空白、引號或反斜線時以單引號包住 (libpq 連線字串格式)
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile 在暫存目錄寫入設定檔並返回路徑
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("寫入設定檔失敗: %v", err)
+	}
+	return path
+}
+
+// validProduction 通過 production 檢查的設定
+func validProduction() *Config {
+	cfg := Default()
+	cfg.Env = EnvProduction
+	cfg.Database.Password = "db-password"
+	cfg.JWT.AccessSecret = strings.Repeat("a", minProductionSecretLength)
+	cfg.JWT.RefreshSecret = strings.Repeat("r", minProductionSecretLength)
+	cfg.Password.ResetURL = "https://ems.example.com/reset-password"
+	cfg.MFA.EncryptionKey = "mfa-encryption-key"
+	cfg.Audit.ChainKey = "audit-chain-key"
+	cfg.Mail.Driver = "smtp"
+	cfg.Mail.SMTP.Host = "smtp.example.com"
+	cfg.Mail.SMTP.From = "noreply@example.com"
+	return cfg
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Chdir(t.TempDir()) // 目前目錄沒有 config.yaml
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if cfg.File != "" {
+		t.Errorf("沒有設定檔時 File 應為空，得到 %q", cfg.File)
+	}
+	if cfg.Env != EnvDevelopment || cfg.Server.Port != "8080" || cfg.Audit.Retention != 365*24*time.Hour {
+		t.Errorf("期望預設值，得到 env=%s port=%s retention=%s", cfg.Env, cfg.Server.Port, cfg.Audit.Retention)
+	}
+	if cfg.Mail.Driver != "log" || cfg.RateLimit.Store != "memory" || cfg.Jobs.ClaimCodeExpiryInterval != 5*time.Minute {
+		t.Errorf("期望預設值，得到 mail=%s store=%s claim=%s", cfg.Mail.Driver, cfg.RateLimit.Store, cfg.Jobs.ClaimCodeExpiryInterval)
+	}
+}
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: "9090"
+password:
+  min_length: 12
+  reset_ttl: 1h
+audit:
+  retention: 0s
+jobs:
+  comfort_control_dry_run: true
+`)
+	t.Setenv("PORT", "7070")
+	t.Setenv("AUDIT_CHAIN_KEY", "from-env")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if cfg.File != path {
+		t.Errorf("期望 File 為 %s，得到 %q", path, cfg.File)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"環境變數優先於設定檔", cfg.Server.Port, "7070"},
+		{"設定檔覆蓋預設值", cfg.Password.MinLength, 12},
+		{"設定檔的時間長度", cfg.Password.ResetTTL, time.Hour},
+		{"設定檔可設為 0", cfg.Audit.Retention, time.Duration(0)},
+		{"設定檔的布林值", cfg.Jobs.ComfortControlDryRun, true},
+		{"只有環境變數", cfg.Audit.ChainKey, "from-env"},
+		{"未設定的欄位保留預設值", cfg.Password.HistoryCount, 5},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: 期望 %v，得到 %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    func(t *testing.T) string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "指定的設定檔不存在",
+			path:    func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.yaml") },
+			wantErr: "no such file",
+		},
+		{
+			name:    "拼錯的欄位",
+			path:    func(t *testing.T) string { return writeConfigFile(t, "mfa:\n  isuer: EMS\n") },
+			wantErr: "field isuer not found",
+		},
+		{
+			name:    "無效的環境變數",
+			path:    func(t *testing.T) string { return writeConfigFile(t, "") },
+			env:     map[string]string{"LOGIN_BASE_DELAY": "soon"},
+			wantErr: "invalid LOGIN_BASE_DELAY",
+		},
+		{
+			name:    "驗證失敗",
+			path:    func(t *testing.T) string { return writeConfigFile(t, "mail:\n  driver: sendmail\n") },
+			wantErr: "mail.driver must be log or smtp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := Load(tt.path(t))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("期望包含 %q 的錯誤，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoad_Example(t *testing.T) {
+	cfg, err := Load(filepath.Join("..", "..", "..", "config.example.yaml"))
+	if err != nil {
+		t.Fatalf("config.example.yaml 應可直接使用: %v", err)
+	}
+	defaults := Default()
+	if cfg.Login != defaults.Login || cfg.Password != defaults.Password || cfg.Audit != defaults.Audit ||
+		cfg.RateLimit != defaults.RateLimit || cfg.Mail != defaults.Mail || cfg.Jobs != defaults.Jobs {
+		t.Error("config.example.yaml 的值應與預設值相同")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"LOGIN_FREE_ATTEMPTS":        "5",
+		"LOGIN_LOCKOUT_DURATION":     "0",
+		"PASSWORD_REQUIRE_SYMBOL":    "true",
+		"PASSWORD_ARGON2_MEMORY_KB":  "131072",
+		"PASSWORD_RESET_URL":         "https://ems.example.com/reset",
+		"MFA_CHALLENGE_TTL":          "2m",
+		"OIDC_PROVIDERS_FILE":        "/etc/ems/oidc.json",
+		"API_KEY_MAX_TTL":            "720h",
+		"PERMISSION_CACHE_TTL":       "1m",
+		"AUDIT_ARCHIVE_DIR":          "/var/lib/ems/audit",
+		"RATE_LIMIT_POLICIES":        "none",
+		"SMTP_PORT":                  "465",
+		"SMTP_IMPLICIT_TLS":          "true",
+		"FIRMWARE_CAMPAIGN_INTERVAL": "0s",
+	}
+	cfg := Default()
+	if err := cfg.applyEnv(func(key string) string { return env[key] }); err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+
+	tests := []struct {
+		key       string
+		got, want interface{}
+	}{
+		{"LOGIN_FREE_ATTEMPTS", cfg.Login.FreeAttempts, 5},
+		{"LOGIN_LOCKOUT_DURATION", cfg.Login.LockoutDuration, time.Duration(0)},
+		{"PASSWORD_REQUIRE_SYMBOL", cfg.Password.RequireSymbol, true},
+		{"PASSWORD_ARGON2_MEMORY_KB", cfg.Password.Argon2MemoryKB, uint32(131072)},
+		{"PASSWORD_RESET_URL", cfg.Password.ResetURL, "https://ems.example.com/reset"},
+		{"MFA_CHALLENGE_TTL", cfg.MFA.ChallengeTTL, 2 * time.Minute},
+		{"OIDC_PROVIDERS_FILE", cfg.OIDC.ProvidersFile, "/etc/ems/oidc.json"},
+		{"API_KEY_MAX_TTL", cfg.APIKey.MaxTTL, 720 * time.Hour},
+		{"PERMISSION_CACHE_TTL", cfg.PermissionCache.TTL, time.Minute},
+		{"AUDIT_ARCHIVE_DIR", cfg.Audit.ArchiveDir, "/var/lib/ems/audit"},
+		{"RATE_LIMIT_POLICIES", cfg.RateLimit.Policies, "none"},
+		{"SMTP_PORT", cfg.Mail.SMTP.Port, 465},
+		{"SMTP_IMPLICIT_TLS", cfg.Mail.SMTP.ImplicitTLS, true},
+		{"FIRMWARE_CAMPAIGN_INTERVAL", cfg.Jobs.FirmwareCampaignInterval, time.Duration(0)},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: 期望 %v，得到 %v", tt.key, tt.want, tt.got)
+		}
+	}
+
+	// 所有無效的值一次列出
+	invalid := map[string]string{
+		"LOGIN_FREE_ATTEMPTS":     "-1",
+		"PASSWORD_ARGON2_THREADS": "300",
+		"SMTP_IMPLICIT_TLS":       "maybe",
+		"AUDIT_RETENTION":         "1y",
+	}
+	err := Default().applyEnv(func(key string) string { return invalid[key] })
+	if err == nil {
+		t.Fatal("期望錯誤")
+	}
+	for key := range invalid {
+		if !strings.Contains(err.Error(), "invalid "+key) {
+			t.Errorf("期望錯誤包含 %s，得到 %v", key, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("預設設定應通過驗證: %v", err)
+	}
+	if err := validProduction().Validate(); err != nil {
+		t.Fatalf("production 設定應通過驗證: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		production bool
+		mutate     func(c *Config)
+		wantErr    string
+	}{
+		// 所有環境
+		{name: "未知的環境", mutate: func(c *Config) { c.Env = "staging" }, wantErr: "env must be"},
+		{name: "埠號無效", mutate: func(c *Config) { c.Server.Port = "http" }, wantErr: "server.port"},
+		{name: "gin mode 無效", mutate: func(c *Config) { c.Server.GinMode = "verbose" }, wantErr: "server.gin_mode"},
+		{name: "缺少資料庫主機", mutate: func(c *Config) { c.Database.Host = "" }, wantErr: "database.host is required"},
+		{name: "sslmode 無效", mutate: func(c *Config) { c.Database.SSLMode = "on" }, wantErr: "database.sslmode"},
+		{name: "refresh 短於 access", mutate: func(c *Config) { c.JWT.RefreshExpiry = time.Minute }, wantErr: "jwt.refresh_expiry"},
+		{name: "CORS 允許所有來源", mutate: func(c *Config) { c.CORS.AllowOrigins = []string{"*"} }, wantErr: "cors.allow_origins"},
+		{name: "SQS 缺少區域", mutate: func(c *Config) { c.SQS.Region = "" }, wantErr: "sqs.region"},
+		{name: "MQTT 缺少 broker", mutate: func(c *Config) { c.MQTT.Enabled = true }, wantErr: "mqtt.endpoint"},
+		{name: "MQTT QoS 無效", mutate: func(c *Config) { c.MQTT.MaxQoS = 3 }, wantErr: "mqtt.max_qos"},
+		{name: "間隔為負", mutate: func(c *Config) { c.Jobs.DemandControlInterval = -time.Second }, wantErr: "jobs.demand_control_interval must not be negative"},
+		{name: "期限為 0", mutate: func(c *Config) { c.MFA.ChallengeTTL = 0 }, wantErr: "mfa.challenge_ttl must be positive"},
+		{name: "登入次數為負", mutate: func(c *Config) { c.Login.LockoutThreshold = -1 }, wantErr: "login.free_attempts"},
+		{name: "argon2 參數為 0", mutate: func(c *Config) { c.Password.Argon2Threads = 0 }, wantErr: "password.argon2_time"},
+		{name: "重設網址不是絕對網址", mutate: func(c *Config) { c.Password.ResetURL = "/reset-password" }, wantErr: "password.reset_url must be an absolute"},
+		{name: "未知的歸檔方式", mutate: func(c *Config) { c.Audit.ArchiveDriver = "s3" }, wantErr: "audit.archive_driver"},
+		{name: "缺少歸檔目錄", mutate: func(c *Config) { c.Audit.ArchiveDir = "" }, wantErr: "audit.archive_dir"},
+		{name: "缺少限流政策", mutate: func(c *Config) { c.RateLimit.Policies = "" }, wantErr: "rate_limit.policies"},
+		{name: "未知的限流儲存", mutate: func(c *Config) { c.RateLimit.Store = "redis" }, wantErr: "rate_limit.store"},
+		{name: "未知的郵件驅動", mutate: func(c *Config) { c.Mail.Driver = "sendmail" }, wantErr: "mail.driver must be log or smtp"},
+		{name: "SMTP 缺少主機", mutate: func(c *Config) { c.Mail.Driver = "smtp"; c.Mail.SMTP.From = "a@b.c" }, wantErr: "mail.smtp.host"},
+		{name: "SMTP 埠號無效", mutate: func(c *Config) {
+			c.Mail.Driver = "smtp"
+			c.Mail.SMTP = SMTPConfig{Host: "smtp", From: "a@b.c", Port: 70000}
+		}, wantErr: "mail.smtp.port"},
+
+		// production
+		{name: "預設 JWT 密鑰", production: true, mutate: func(c *Config) { c.JWT.AccessSecret = DefaultAccessSecret }, wantErr: "jwt.access_secret must be changed"},
+		{name: "JWT 密鑰過短", production: true, mutate: func(c *Config) { c.JWT.RefreshSecret = "short" }, wantErr: "jwt.refresh_secret must be at least"},
+		{name: "JWT 密鑰相同", production: true, mutate: func(c *Config) { c.JWT.RefreshSecret = c.JWT.AccessSecret }, wantErr: "must differ"},
+		{name: "資料庫密碼為空", production: true, mutate: func(c *Config) { c.Database.Password = "" }, wantErr: "database.password is required"},
+		{name: "停用 SSL", production: true, mutate: func(c *Config) { c.Database.SSLMode = "disable" }, wantErr: "database.sslmode must not be disable"},
+		{name: "非 release 模式", production: true, mutate: func(c *Config) { c.Server.GinMode = "debug" }, wantErr: "server.gin_mode must be release"},
+		{name: "略過 MQTT 憑證驗證", production: true, mutate: func(c *Config) { c.MQTT.InsecureSkipVerify = true }, wantErr: "mqtt.tls_insecure_skip_verify"},
+		{name: "重設網址指向 localhost", production: true, mutate: func(c *Config) { c.Password.ResetURL = "http://localhost:3000/reset-password" }, wantErr: "password.reset_url must point to the deployed frontend"},
+		{name: "重設網址指向回送位址", production: true, mutate: func(c *Config) { c.Password.ResetURL = "https://127.0.0.1/reset-password" }, wantErr: "password.reset_url must point to the deployed frontend"},
+		{name: "缺少 MFA 加密金鑰", production: true, mutate: func(c *Config) { c.MFA.EncryptionKey = "" }, wantErr: "mfa.encryption_key is required"},
+		{name: "缺少雜湊鏈 key", production: true, mutate: func(c *Config) { c.Audit.ChainKey = "" }, wantErr: "audit.chain_key is required"},
+		{name: "log 郵件驅動", production: true, mutate: func(c *Config) { c.Mail.Driver = "log" }, wantErr: "mail.driver must not be log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			if tt.production {
+				cfg = validProduction()
+			}
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("期望包含 %q 的錯誤，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// 開發環境不套用 production 檢查
+	cfg := validProduction()
+	cfg.Env = EnvDevelopment
+	cfg.Password.ResetURL = "http://localhost:3000/reset-password"
+	cfg.MFA.EncryptionKey = ""
+	cfg.Mail.Driver = "log"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("開發環境不應拒絕: %v", err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *Config
+		want   string
+		wantOK bool
+	}{
+		{name: "開發環境未加密 TOTP", cfg: Default(), want: "MFA_ENCRYPTION_KEY", wantOK: true},
+		{name: "開發環境雜湊鏈未使用 key", cfg: Default(), want: "AUDIT_CHAIN_KEY", wantOK: true},
+		{name: "production 不重複提醒已拒絕的設定", cfg: validProduction(), want: "MFA_ENCRYPTION_KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := strings.Contains(strings.Join(tt.cfg.Warnings(), "\n"), tt.want)
+			if found != tt.wantOK {
+				t.Errorf("期望提醒 %s=%v，得到 %v", tt.want, tt.wantOK, tt.cfg.Warnings())
+			}
+		})
+	}
+}
+
+func TestDatabaseConfig_DSN(t *testing.T) {
+	d := DatabaseConfig{Host: "db", Port: 5432, User: "ems", Password: `p'a ss\`, Name: "ems", SSLMode: "require"}
+	want := `host=db port=5432 user=ems password='p\'a ss\\' dbname=ems sslmode=require TimeZone=UTC`
+	if got := d.DSN(); got != want {
+		t.Errorf("期望 %s，得到 %s", want, got)
+	}
+}
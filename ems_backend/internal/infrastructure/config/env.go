@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv 以環境變數覆蓋設定 (未設定或空字串時保留原值)，沿用既有的變數名稱
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var errs []error
+	str := func(key string, target *string) {
+		if v := getenv(key); v != "" {
+			*target = v
+		}
+	}
+	list := func(key string, target *[]string) {
+		if v := getenv(key); v != "" {
+			*target = splitList(v)
+		}
+	}
+	boolean := func(key string, target *bool) {
+		if v := getenv(key); v != "" {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, v))
+				return
+			}
+			*target = parsed
+		}
+	}
+	integer := func(key string, bits int, set func(uint64)) {
+		if v := getenv(key); v != "" {
+			parsed, err := strconv.ParseUint(v, 10, bits)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, v))
+				return
+			}
+			set(parsed)
+		}
+	}
+	count := func(key string, target *int) {
+		integer(key, 31, func(v uint64) { *target = int(v) })
+	}
+	duration := func(key string, target *time.Duration) {
+		if v := getenv(key); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, v))
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	str("APP_ENV", &c.Env)
+
+	str("PORT", &c.Server.Port)
+	str("GIN_MODE", &c.Server.GinMode)
+	list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+	str("METRICS_TOKEN", &c.Server.MetricsToken)
+
+	str("DB_HOST", &c.Database.Host)
+	integer("DB_PORT", 16, func(v uint64) { c.Database.Port = int(v) })
+	str("DB_USER", &c.Database.User)
+	str("DB_CODE", &c.Database.Password)
+	str("DB_NAME", &c.Database.Name)
+	str("DB_SSLMODE", &c.Database.SSLMode)
+	boolean("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	str("JWT_ACCESS_SECRET", &c.JWT.AccessSecret)
+	str("JWT_REFRESH_SECRET", &c.JWT.RefreshSecret)
+	duration("JWT_ACCESS_EXPIRY", &c.JWT.AccessExpiry)
+	duration("JWT_REFRESH_EXPIRY", &c.JWT.RefreshExpiry)
+
+	list("CORS_ALLOW_ORIGINS", &c.CORS.AllowOrigins)
+
+	boolean("ENABLE_SQS", &c.SQS.Enabled)
+	str("AWS_REGION", &c.SQS.Region)
+
+	boolean("ENABLE_MQTT", &c.MQTT.Enabled)
+	str("MQTT_ENDPOINT", &c.MQTT.Endpoint)
+	str("MQTT_BROKER_URL", &c.MQTT.BrokerURL)
+	str("MQTT_CLIENT_ID", &c.MQTT.ClientID)
+	str("MQTT_USERNAME", &c.MQTT.Username)
+	str("MQTT_PASSWORD", &c.MQTT.Password)
+	str("MQTT_CA_CERT", &c.MQTT.CACert)
+	str("MQTT_CLIENT_CERT", &c.MQTT.ClientCert)
+	str("MQTT_PRIVATE_KEY", &c.MQTT.PrivateKey)
+	boolean("MQTT_TLS_INSECURE_SKIP_VERIFY", &c.MQTT.InsecureSkipVerify)
+	str("MQTT_TOPIC_PREFIX", &c.MQTT.TopicPrefix)
+	integer("MQTT_PROTOCOL_VERSION", 8, func(v uint64) { c.MQTT.ProtocolVersion = uint(v) })
+	integer("MQTT_MAX_QOS", 8, func(v uint64) { c.MQTT.MaxQoS = uint8(v) })
+	boolean("MQTT_CLEAN_SESSION", &c.MQTT.CleanSession)
+
+	count("LOGIN_FREE_ATTEMPTS", &c.Login.FreeAttempts)
+	count("LOGIN_IP_FREE_ATTEMPTS", &c.Login.IPFreeAttempts)
+	duration("LOGIN_BASE_DELAY", &c.Login.BaseDelay)
+	duration("LOGIN_MAX_DELAY", &c.Login.MaxDelay)
+	duration("LOGIN_FAILURE_WINDOW", &c.Login.FailureWindow)
+	count("LOGIN_LOCKOUT_THRESHOLD", &c.Login.LockoutThreshold)
+	duration("LOGIN_LOCKOUT_DURATION", &c.Login.LockoutDuration)
+	duration("LOGIN_LOCKOUT_SWEEP_INTERVAL", &c.Login.LockoutSweepInterval)
+
+	count("PASSWORD_MIN_LENGTH", &c.Password.MinLength)
+	boolean("PASSWORD_REQUIRE_UPPER", &c.Password.RequireUpper)
+	boolean("PASSWORD_REQUIRE_LOWER", &c.Password.RequireLower)
+	boolean("PASSWORD_REQUIRE_DIGIT", &c.Password.RequireDigit)
+	boolean("PASSWORD_REQUIRE_SYMBOL", &c.Password.RequireSymbol)
+	duration("PASSWORD_MAX_AGE", &c.Password.MaxAge)
+	count("PASSWORD_HISTORY_COUNT", &c.Password.HistoryCount)
+	str("PASSWORD_COMMON_LIST_FILE", &c.Password.CommonListFile)
+	integer("PASSWORD_ARGON2_TIME", 32, func(v uint64) { c.Password.Argon2Time = uint32(v) })
+	integer("PASSWORD_ARGON2_MEMORY_KB", 32, func(v uint64) { c.Password.Argon2MemoryKB = uint32(v) })
+	integer("PASSWORD_ARGON2_THREADS", 8, func(v uint64) { c.Password.Argon2Threads = uint8(v) })
+	str("PASSWORD_RESET_URL", &c.Password.ResetURL)
+	duration("PASSWORD_RESET_TTL", &c.Password.ResetTTL)
+
+	str("MFA_ISSUER", &c.MFA.Issuer)
+	duration("MFA_CHALLENGE_TTL", &c.MFA.ChallengeTTL)
+	str("MFA_ENCRYPTION_KEY", &c.MFA.EncryptionKey)
+
+	duration("SESSION_REVOCATION_SYNC_INTERVAL", &c.Session.RevocationSyncInterval)
+	duration("SESSION_PURGE_INTERVAL", &c.Session.PurgeInterval)
+
+	str("OIDC_PROVIDERS_FILE", &c.OIDC.ProvidersFile)
+	duration("OIDC_STATE_TTL", &c.OIDC.StateTTL)
+
+	duration("API_KEY_MAX_TTL", &c.APIKey.MaxTTL)
+
+	duration("PERMISSION_CACHE_TTL", &c.PermissionCache.TTL)
+	duration("PERMISSION_CACHE_SYNC_INTERVAL", &c.PermissionCache.SyncInterval)
+
+	duration("AUDIT_RETENTION", &c.Audit.Retention)
+	duration("AUDIT_RETENTION_INTERVAL", &c.Audit.RetentionInterval)
+	str("AUDIT_ARCHIVE_DRIVER", &c.Audit.ArchiveDriver)
+	str("AUDIT_ARCHIVE_DIR", &c.Audit.ArchiveDir)
+	str("AUDIT_CHAIN_KEY", &c.Audit.ChainKey)
+	duration("AUDIT_CHAIN_HEAD_INTERVAL", &c.Audit.ChainHeadInterval)
+
+	str("RATE_LIMIT_POLICIES", &c.RateLimit.Policies)
+	str("RATE_LIMIT_STORE", &c.RateLimit.Store)
+	duration("RATE_LIMIT_PURGE_INTERVAL", &c.RateLimit.PurgeInterval)
+
+	str("MAIL_DRIVER", &c.Mail.Driver)
+	str("SMTP_HOST", &c.Mail.SMTP.Host)
+	integer("SMTP_PORT", 16, func(v uint64) { c.Mail.SMTP.Port = int(v) })
+	str("SMTP_USERNAME", &c.Mail.SMTP.Username)
+	str("SMTP_PASSWORD", &c.Mail.SMTP.Password)
+	str("SMTP_FROM", &c.Mail.SMTP.From)
+	boolean("SMTP_IMPLICIT_TLS", &c.Mail.SMTP.ImplicitTLS)
+
+	duration("SCHEDULE_DRIFT_POLL_INTERVAL", &c.Jobs.ScheduleDriftPollInterval)
+	duration("COMFORT_CONTROL_INTERVAL", &c.Jobs.ComfortControlInterval)
+	boolean("COMFORT_CONTROL_DRY_RUN", &c.Jobs.ComfortControlDryRun)
+	duration("DEMAND_CONTROL_INTERVAL", &c.Jobs.DemandControlInterval)
+	duration("FIRMWARE_CAMPAIGN_INTERVAL", &c.Jobs.FirmwareCampaignInterval)
+	duration("CLAIM_CODE_EXPIRY_INTERVAL", &c.Jobs.ClaimCodeExpiryInterval)
+
+	return errors.Join(errs...)
+}
+
+// splitList 拆開逗號分隔的清單並去除空白與空項目
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePort 解析 TCP 埠號
+func parsePort(value string) (uint64, error) {
+	return strconv.ParseUint(value, 10, 16)
+}
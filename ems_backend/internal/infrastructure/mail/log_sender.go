@@ -0,0 +1,19 @@
+package mail
+
+import (
+	"log"
+)
+
+// LogSender - 開發用郵件發送器，只把郵件內容寫入日誌
+type LogSender struct{}
+
+// NewLogSender - 建立日誌郵件發送器
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send - 將郵件輸出到日誌
+func (s *LogSender) Send(to, subject, body string) error {
+	log.Printf("[Mail] To: %s\nSubject: %s\n\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig - SMTP 連線設定
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // 寄件者，例如 "EMS <noreply@example.com>"
+	// ImplicitTLS 為 true 時直接以 TLS 連線 (465)，否則在伺服器支援時使用 STARTTLS (587 / 25)
+	ImplicitTLS bool
+}
+
+// SMTPSender - 透過 SMTP 發送郵件
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender - 建立 SMTP 郵件發送器
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("SMTP host and from address are required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &SMTPSender{config: config}, nil
+}
+
+// Send - 發送純文字郵件 (UTF-8)
+func (s *SMTPSender) Send(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	addr := net.JoinHostPort(s.config.Host, fmt.Sprintf("%d", s.config.Port))
+	client, err := s.dial(addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if !s.config.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	from, err := envelopeAddress(s.config.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.buildMessage(to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *SMTPSender) dial(addr string) (*smtp.Client, error) {
+	if s.config.ImplicitTLS {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, &tls.Config{ServerName: s.config.Host})
+		if err != nil {
+			return nil, err
+		}
+		return smtp.NewClient(conn, s.config.Host)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return smtp.NewClient(conn, s.config.Host)
+}
+
+func (s *SMTPSender) buildMessage(to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.config.From + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
+
+// envelopeAddress 從 "名稱 <地址>" 取出地址
+func envelopeAddress(from string) (string, error) {
+	addr, err := netmail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+	return addr.Address, nil
+}
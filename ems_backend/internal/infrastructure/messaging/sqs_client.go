@@ -60,6 +60,15 @@ func (c *SQSClient) ReceiveMessages(ctx context.Context, queueURL string, maxMes
 	return messages, nil
 }
 
+// SendMessage 发送消息 (供设备模拟器直接写入队列)
+func (c *SQSClient) SendMessage(ctx context.Context, queueURL, body string) error {
+	_, err := c.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    &queueURL,
+		MessageBody: &body,
+	})
+	return err
+}
+
 // DeleteMessage 删除消息
 func (c *SQSClient) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
 	_, err := c.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry - 計數器集合，以 Prometheus 文字格式輸出 (GET /metrics)
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]*CounterVec
+}
+
+// Default - 全域計數器集合
+var Default = NewRegistry()
+
+// NewRegistry - 建立計數器集合
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]*CounterVec)}
+}
+
+// CounterVec - 依標籤區分的計數器
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]uint64 // 以 \xff 串接的標籤值 -> 計數
+}
+
+// Counter - 取得或註冊計數器，同名時返回已註冊的計數器
+func (r *Registry) Counter(name, help string, labelNames ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if counter, ok := r.counters[name]; ok {
+		return counter
+	}
+	counter := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]uint64),
+	}
+	r.counters[name] = counter
+	return counter
+}
+
+// Inc - 計數加一，標籤值順序與註冊時的標籤名稱相同
+func (c *CounterVec) Inc(labelValues ...string) {
+	if len(labelValues) != len(c.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	c.values[key]++
+	c.mu.Unlock()
+}
+
+// WriteTo - 以 Prometheus 文字格式輸出所有計數器
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.counters))
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		r.mu.RLock()
+		counter := r.counters[name]
+		r.mu.RUnlock()
+		counter.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteString(c.name)
+		if len(c.labelNames) > 0 {
+			values := strings.Split(key, "\xff")
+			pairs := make([]string, len(c.labelNames))
+			for i, label := range c.labelNames {
+				pairs[i] = fmt.Sprintf("%s=%q", label, values[i])
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		fmt.Fprintf(b, " %d\n", c.values[key])
+	}
+	c.mu.Unlock()
+}
+
+// Handler - GET /metrics；token 不為空時需以 Authorization: Bearer <token> 存取
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := r.WriteTo(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"sync"
 	"time"
@@ -12,15 +13,29 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
-// Config holds MQTT connection configuration for AWS IoT Core
+// Config holds MQTT connection configuration.
+//
+// By default (BrokerURL empty) the client connects to AWS IoT Core at
+// ssl://{Endpoint} with mutual TLS. Setting BrokerURL selects a standard
+// broker such as Mosquitto or EMQX over tcp://, ssl:// (tls://, mqtts://),
+// ws:// or wss://, with optional username/password and TLS.
 type Config struct {
 	// AWS IoT Core endpoint (e.g., "xxxxx.iot.ap-northeast-1.amazonaws.com:8883")
 	Endpoint string
 
+	// Standard broker URL (e.g., "tcp://localhost:1883", "wss://emqx.example.com:8084/mqtt").
+	// Takes precedence over Endpoint when set.
+	BrokerURL string
+
 	// Client ID (must be unique per connection, typically use device SN or UUID)
 	ClientID string
 
-	// Path to CA certificate file (AmazonRootCA1.pem)
+	// Username / password authentication (standard brokers)
+	Username string
+	Password string
+
+	// Path to CA certificate file (AmazonRootCA1.pem, or a private CA for
+	// standard brokers; the system pool is used when empty)
 	CACertPath string
 
 	// Path to client certificate file (xxx-certificate.pem.crt)
@@ -28,12 +43,50 @@ type Config struct {
 
 	// Path to private key file (xxx-private.pem.key)
 	PrivateKeyPath string
+
+	// Skip server certificate verification (development only)
+	InsecureSkipVerify bool
+
+	// Topic prefix for {prefix}/command/{sn} and {prefix}/return/{sn} (default "ac")
+	TopicPrefix string
+
+	// MQTT protocol version: 3 (3.1) or 4 (3.1.1, default). MQTT 5 is not
+	// supported; brokers must accept 3.1.1 connections.
+	ProtocolVersion uint
+
+	// Highest QoS used for publish/subscribe: 1 (default when zero) or 2.
+	// AWS IoT Core supports at most 1; standard brokers may use 2.
+	MaxQoS byte
+
+	// Session options
+	Session SessionOptions
+
+	// Keep-alive interval (default 60s)
+	KeepAlive time.Duration
+}
+
+// SessionOptions controls broker-side session state (MQTT 3.1.1).
+//
+// With CleanSession false the broker keeps subscriptions and queued QoS 1
+// messages while the backend is offline, and the client resumes them on
+// reconnect. MQTT 3.1.1 has no session expiry: how long an offline session is
+// kept is decided by broker configuration (e.g. Mosquitto
+// persistent_client_expiration).
+type SessionOptions struct {
+	CleanSession bool
+}
+
+// persistent reports whether a persistent (non-clean) session is requested
+func (o SessionOptions) persistent() bool {
+	return !o.CleanSession
 }
 
-// Client wraps the Paho MQTT client for AWS IoT Core
+// Client wraps the Paho MQTT client
 type Client struct {
 	client  mqtt.Client
 	config  Config
+	topics  Topics
+	broker  string
 	mu      sync.RWMutex
 	isReady bool
 
@@ -47,42 +100,62 @@ type subscriptionInfo struct {
 	handler mqtt.MessageHandler
 }
 
-// NewClient creates a new MQTT client configured for AWS IoT Core
+// NewClient creates a new MQTT client for AWS IoT Core or a standard broker
 func NewClient(cfg Config) (*Client, error) {
 	// Validate required fields
-	if cfg.Endpoint == "" {
-		return nil, fmt.Errorf("MQTT endpoint is required")
-	}
 	if cfg.ClientID == "" {
 		return nil, fmt.Errorf("MQTT client ID is required")
 	}
-	if cfg.CACertPath == "" || cfg.ClientCertPath == "" || cfg.PrivateKeyPath == "" {
-		return nil, fmt.Errorf("certificate paths are required for AWS IoT Core")
-	}
 
-	// Load TLS config for AWS IoT Core
-	tlsConfig, err := newTLSConfig(cfg.CACertPath, cfg.ClientCertPath, cfg.PrivateKeyPath)
+	brokerURL, tlsConfig, err := resolveBroker(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load TLS config: %w", err)
+		return nil, err
+	}
+
+	protocolVersion := cfg.ProtocolVersion
+	switch protocolVersion {
+	case 0:
+		protocolVersion = 4
+	case 3, 4:
+	case 5:
+		return nil, fmt.Errorf("MQTT protocol version 5 is not supported; the backend speaks MQTT 3.1.1 (protocol version 4)")
+	default:
+		return nil, fmt.Errorf("unsupported MQTT protocol version %d", protocolVersion)
 	}
 
-	// Build broker URL with ssl:// scheme
-	brokerURL := fmt.Sprintf("ssl://%s", cfg.Endpoint)
+	if cfg.MaxQoS == 0 {
+		cfg.MaxQoS = 1
+	}
+	if cfg.MaxQoS > 2 {
+		return nil, fmt.Errorf("MQTT max QoS must be 1 or 2")
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 60 * time.Second
+	}
 
 	opts := mqtt.NewClientOptions().
 		AddBroker(brokerURL).
 		SetClientID(cfg.ClientID).
-		SetTLSConfig(tlsConfig).
 		SetAutoReconnect(true).
 		SetConnectRetry(true).
 		SetConnectRetryInterval(5 * time.Second).
-		SetKeepAlive(60 * time.Second).
+		SetKeepAlive(cfg.KeepAlive).
 		SetPingTimeout(10 * time.Second).
-		SetCleanSession(true).
-		SetProtocolVersion(4) // MQTT 3.1.1
+		SetCleanSession(!cfg.Session.persistent()).
+		SetResumeSubs(cfg.Session.persistent()).
+		SetProtocolVersion(protocolVersion)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if cfg.Username != "" {
+		opts.SetUsername(cfg.Username)
+		opts.SetPassword(cfg.Password)
+	}
 
 	c := &Client{
 		config:        cfg,
+		topics:        NewTopics(cfg.TopicPrefix),
+		broker:        brokerURL,
 		subscriptions: make(map[string]subscriptionInfo),
 	}
 
@@ -91,7 +164,7 @@ func NewClient(cfg Config) (*Client, error) {
 		c.mu.Lock()
 		c.isReady = true
 		c.mu.Unlock()
-		log.Printf("[MQTT] Connected to AWS IoT Core: %s (Client ID: %s)", cfg.Endpoint, cfg.ClientID)
+		log.Printf("[MQTT] Connected to broker: %s (Client ID: %s)", brokerURL, cfg.ClientID)
 
 		// Restore subscriptions on reconnect
 		c.subMu.RLock()
@@ -125,7 +198,7 @@ func NewClient(cfg Config) (*Client, error) {
 
 	// Reconnecting handler
 	opts.SetReconnectingHandler(func(client mqtt.Client, opts *mqtt.ClientOptions) {
-		log.Printf("[MQTT] Attempting to reconnect to AWS IoT Core...")
+		log.Printf("[MQTT] Attempting to reconnect to %s...", brokerURL)
 	})
 
 	// Default message handler for debugging - catches messages on topics without specific handlers
@@ -138,38 +211,103 @@ func NewClient(cfg Config) (*Client, error) {
 	return c, nil
 }
 
-// newTLSConfig creates a TLS configuration for AWS IoT Core mutual TLS authentication
+// resolveBroker returns the broker URL and TLS configuration (nil for plain tcp:// and ws://)
+func resolveBroker(cfg Config) (string, *tls.Config, error) {
+	// AWS IoT Core (default): ssl:// with mutual TLS
+	if cfg.BrokerURL == "" {
+		if cfg.Endpoint == "" {
+			return "", nil, fmt.Errorf("MQTT endpoint or broker URL is required")
+		}
+		if cfg.CACertPath == "" || cfg.ClientCertPath == "" || cfg.PrivateKeyPath == "" {
+			return "", nil, fmt.Errorf("certificate paths are required for AWS IoT Core")
+		}
+		tlsConfig, err := newTLSConfig(cfg.CACertPath, cfg.ClientCertPath, cfg.PrivateKeyPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to load TLS config: %w", err)
+		}
+		return fmt.Sprintf("ssl://%s", cfg.Endpoint), tlsConfig, nil
+	}
+
+	u, err := url.Parse(cfg.BrokerURL)
+	if err != nil || u.Host == "" {
+		return "", nil, fmt.Errorf("invalid MQTT broker URL %q", cfg.BrokerURL)
+	}
+	if u.User != nil {
+		return "", nil, fmt.Errorf("MQTT broker URL must not contain credentials; use username/password settings")
+	}
+	if (cfg.ClientCertPath == "") != (cfg.PrivateKeyPath == "") {
+		return "", nil, fmt.Errorf("client certificate and private key must be provided together")
+	}
+
+	switch u.Scheme {
+	case "tcp", "mqtt", "ws":
+		if cfg.CACertPath != "" || cfg.ClientCertPath != "" {
+			log.Printf("[MQTT] Warning: TLS settings ignored for %s:// broker", u.Scheme)
+		}
+		if u.Scheme == "mqtt" {
+			u.Scheme = "tcp"
+		}
+		return u.String(), nil, nil
+	case "ssl", "tls", "mqtts", "tcps", "wss":
+		tlsConfig, err := newTLSConfig(cfg.CACertPath, cfg.ClientCertPath, cfg.PrivateKeyPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to load TLS config: %w", err)
+		}
+		tlsConfig.InsecureSkipVerify = cfg.InsecureSkipVerify
+		if u.Scheme == "tls" || u.Scheme == "tcps" {
+			u.Scheme = "ssl"
+		}
+		return u.String(), tlsConfig, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported MQTT broker scheme %q (use tcp, ssl, ws or wss)", u.Scheme)
+	}
+}
+
+// newTLSConfig creates a TLS configuration. The CA and the client key pair are
+// optional: without a CA the system pool is used, without a key pair the
+// connection is server-authenticated only. AWS IoT Core requires both.
 func newTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
-	// Load CA certificate
-	caCert, err := os.ReadFile(caPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
 	}
 
-	caCertPool := x509.NewCertPool()
-	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return nil, fmt.Errorf("failed to parse CA certificate")
+	// Load CA certificate
+	if caPath != "" {
+		caCert, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
 	}
 
 	// Load client certificate and private key
-	clientCert, err := tls.LoadX509KeyPair(certPath, keyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	if certPath != "" && keyPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
 	}
 
-	return &tls.Config{
-		RootCAs:      caCertPool,
-		Certificates: []tls.Certificate{clientCert},
-		MinVersion:   tls.VersionTLS12,
-	}, nil
+	return tlsConfig, nil
+}
+
+// Topics returns the device topic layout for this connection
+func (c *Client) Topics() Topics {
+	return c.topics
 }
 
-// Connect establishes connection to AWS IoT Core
+// Connect establishes connection to the broker
 func (c *Client) Connect() error {
-	log.Printf("[MQTT] Attempting to connect to AWS IoT Core...")
+	log.Printf("[MQTT] Attempting to connect to %s...", c.broker)
 	token := c.client.Connect()
 	if token.Wait() && token.Error() != nil {
-		return fmt.Errorf("failed to connect to AWS IoT Core: %w", token.Error())
+		return fmt.Errorf("failed to connect to %s: %w", c.broker, token.Error())
 	}
 
 	// Wait a bit for connection to stabilize
@@ -211,13 +349,13 @@ func (c *Client) monitorConnection() {
 	}
 }
 
-// Disconnect cleanly disconnects from AWS IoT Core
+// Disconnect cleanly disconnects from the broker
 func (c *Client) Disconnect() {
 	c.mu.Lock()
 	c.isReady = false
 	c.mu.Unlock()
 	c.client.Disconnect(250)
-	log.Println("[MQTT] Disconnected from AWS IoT Core")
+	log.Printf("[MQTT] Disconnected from %s", c.broker)
 }
 
 // IsConnected returns true if the client is connected
@@ -228,15 +366,14 @@ func (c *Client) IsConnected() bool {
 }
 
 // Publish sends a message to the specified topic
-// AWS IoT Core requires QoS 0 or 1 (QoS 2 is not supported)
+// QoS is capped at Config.MaxQoS (AWS IoT Core does not support QoS 2)
 func (c *Client) Publish(topic string, qos byte, retained bool, payload []byte) error {
 	if !c.IsConnected() {
 		return fmt.Errorf("MQTT client is not connected")
 	}
 
-	// AWS IoT Core doesn't support QoS 2
-	if qos > 1 {
-		qos = 1
+	if qos > c.config.MaxQoS {
+		qos = c.config.MaxQoS
 	}
 
 	token := c.client.Publish(topic, qos, retained, payload)
@@ -256,10 +393,9 @@ func (c *Client) Subscribe(topic string, qos byte, handler mqtt.MessageHandler)
 		return fmt.Errorf("MQTT client is not connected")
 	}
 
-	// AWS IoT Core doesn't support QoS 2
-	if qos > 1 {
-		qos = 1
-		log.Printf("[MQTT] QoS downgraded to 1 (AWS IoT Core limit)")
+	if qos > c.config.MaxQoS {
+		qos = c.config.MaxQoS
+		log.Printf("[MQTT] QoS downgraded to %d (broker limit)", qos)
 	}
 
 	// Wrap handler with logging
@@ -0,0 +1,73 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CommandPublisher publishes arbitrary commands to a device's command topic
+type CommandPublisher struct {
+	client *Client
+}
+
+// NewCommandPublisher creates a new CommandPublisher
+func NewCommandPublisher(client *Client) *CommandPublisher {
+	return &CommandPublisher{
+		client: client,
+	}
+}
+
+// ControlCommand represents a remote control command sent to ems_vrv.
+// The gateway echoes command_id in its reply on {prefix}/return/{sn}.
+type ControlCommand struct {
+	Command    string   `json:"command"` // "control"
+	CommandID  string   `json:"command_id"`
+	TargetType string   `json:"target_type"` // ac_unit, compressor
+	TargetID   string   `json:"target_id"`
+	Action     string   `json:"action"` // power_on, power_off, set_mode, set_setpoint
+	Mode       string   `json:"mode,omitempty"`
+	Setpoint   *float64 `json:"setpoint,omitempty"`
+}
+
+// Publish marshals cmd and sends it to the device command topic (QoS 1)
+func (p *CommandPublisher) Publish(deviceSN string, cmd interface{}) error {
+	if p.client == nil {
+		return fmt.Errorf("MQTT client is not initialized")
+	}
+
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	topic := p.client.Topics().Command(deviceSN)
+	return p.client.Publish(topic, 1, false, payload)
+}
+
+// PublishControl sends a remote control command to a device
+func (p *CommandPublisher) PublishControl(deviceSN string, cmd *ControlCommand) error {
+	if cmd.Command == "" {
+		cmd.Command = "control"
+	}
+	return p.Publish(deviceSN, cmd)
+}
+
+// OTACommand asks a gateway to download and install a firmware image.
+// The gateway reports progress on {prefix}/return/{sn} with the same command_id and
+// data {"status": "downloading|installing|succeeded|failed", "progress": 0-100}.
+type OTACommand struct {
+	Command   string `json:"command"` // "ota"
+	CommandID string `json:"command_id"`
+	Version   string `json:"version"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Size      int64  `json:"size"`
+}
+
+// PublishOTA sends a firmware update command to a device
+func (p *CommandPublisher) PublishOTA(deviceSN string, cmd *OTACommand) error {
+	if cmd.Command == "" {
+		cmd.Command = "ota"
+	}
+	return p.Publish(deviceSN, cmd)
+}
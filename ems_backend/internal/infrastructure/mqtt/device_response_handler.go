@@ -3,7 +3,6 @@ package mqtt
 import (
 	"encoding/json"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
@@ -31,12 +30,42 @@ type SSEClient struct {
 	CompanyID uint // Filter events by company (0 = all)
 }
 
+// ScheduleReconciler compares a device-reported schedule with the stored one
+// and applies the company drift policy instead of overwriting blindly
+type ScheduleReconciler interface {
+	ReconcileDeviceSchedule(companyDeviceID uint, deviceSchedule *scheduleEntities.ScheduleWithRules) error
+}
+
+// CommandAckHandler receives acknowledgements for remote control commands
+type CommandAckHandler interface {
+	HandleCommandAck(deviceSN, commandID string, success bool, message string)
+}
+
+// FirmwareReportHandler records firmware versions reported in device replies
+// and progress of OTA update commands
+type FirmwareReportHandler interface {
+	HandleDeviceInfo(deviceSN string, data json.RawMessage)
+	// HandleOTAProgress returns true when commandID belongs to an OTA update
+	HandleOTAProgress(deviceSN, commandID string, success bool, message string, data json.RawMessage) bool
+}
+
+// DeviceResponse is the envelope of every payload on {prefix}/return/{sn}
+type DeviceResponse struct {
+	Success   bool            `json:"success"`
+	CommandID string          `json:"command_id"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+}
+
 // DeviceResponseHandler handles incoming device responses via MQTT
 type DeviceResponseHandler struct {
-	client            *Client
-	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
-	deviceRepo        deviceRepos.DeviceRepository
-	scheduleRepo      scheduleRepos.ScheduleRepository
+	client             *Client
+	companyDeviceRepo  companyDeviceRepos.CompanyDeviceRepository
+	deviceRepo         deviceRepos.DeviceRepository
+	scheduleRepo       scheduleRepos.ScheduleRepository
+	scheduleReconciler ScheduleReconciler
+	commandAckHandler  CommandAckHandler
+	firmwareHandler    FirmwareReportHandler
 
 	// SSE clients management
 	sseClients map[string]*SSEClient
@@ -72,6 +101,22 @@ func (h *DeviceResponseHandler) SetScheduleRepository(scheduleRepo scheduleRepos
 	h.scheduleRepo = scheduleRepo
 }
 
+// SetScheduleReconciler sets the reconciler used for getSchedule responses.
+// When set, device schedules are diffed against the cloud copy instead of overwriting it.
+func (h *DeviceResponseHandler) SetScheduleReconciler(reconciler ScheduleReconciler) {
+	h.scheduleReconciler = reconciler
+}
+
+// SetCommandAckHandler sets the handler for replies carrying a command_id
+func (h *DeviceResponseHandler) SetCommandAckHandler(handler CommandAckHandler) {
+	h.commandAckHandler = handler
+}
+
+// SetFirmwareReportHandler sets the handler for firmware versions and OTA progress
+func (h *DeviceResponseHandler) SetFirmwareReportHandler(handler FirmwareReportHandler) {
+	h.firmwareHandler = handler
+}
+
 // Start subscribes to device response topics and begins processing
 func (h *DeviceResponseHandler) Start() error {
 	if h.client == nil {
@@ -79,7 +124,7 @@ func (h *DeviceResponseHandler) Start() error {
 		return nil
 	}
 
-	topic := GetReturnTopicWildcard() // ac/return/+
+	topic := h.client.Topics().ReturnWildcard() // {prefix}/return/+
 	log.Printf("[MQTT] DeviceResponseHandler subscribing to: %s", topic)
 
 	err := h.client.Subscribe(topic, 1, h.handleMessage)
@@ -141,30 +186,42 @@ func (h *DeviceResponseHandler) handleMessage(client mqtt.Client, msg mqtt.Messa
 	log.Printf("[MQTT] Payload: %s", string(payload))
 	log.Printf("[MQTT] ============================================")
 
-	// Extract device SN from topic: ac/return/{device_sn}
-	parts := strings.Split(topic, "/")
-	if len(parts) < 3 {
+	// Extract device SN from topic: {prefix}/return/{device_sn}
+	deviceSN, ok := h.client.Topics().DeviceSNFromReturn(topic)
+	if !ok {
 		log.Printf("[MQTT] Invalid topic format: %s", topic)
 		return
 	}
-	deviceSN := parts[2]
 
 	// Parse the response to extract 'data' field
-	var response struct {
-		Success bool            `json:"success"`
-		Data    json.RawMessage `json:"data"`
-	}
+	var response DeviceResponse
 	if err := json.Unmarshal(payload, &response); err != nil {
 		log.Printf("[MQTT] Failed to parse device response: %v", err)
 		return
 	}
 
+	// OTA progress reports are not device content; stop once they are recorded
+	if response.CommandID != "" && h.firmwareHandler != nil &&
+		h.firmwareHandler.HandleOTAProgress(deviceSN, response.CommandID, response.Success, response.Message, response.Data) {
+		return
+	}
+
+	// Acknowledge remote control commands (reply carries the command_id we sent)
+	if response.CommandID != "" && h.commandAckHandler != nil {
+		h.commandAckHandler.HandleCommandAck(deviceSN, response.CommandID, response.Success, response.Message)
+	}
+
 	// Only process if success and data exists
 	if !response.Success || len(response.Data) == 0 {
 		log.Printf("[MQTT] Response not successful or no data for device %s", deviceSN)
 		return
 	}
 
+	// Record firmware / hardware versions from deviceInfo replies
+	if h.firmwareHandler != nil {
+		h.firmwareHandler.HandleDeviceInfo(deviceSN, response.Data)
+	}
+
 	// Check if this is a getSchedule response (has schedule_id and daily_rules)
 	var scheduleData struct {
 		ScheduleID string                 `json:"schedule_id"`
@@ -181,7 +238,7 @@ func (h *DeviceResponseHandler) handleMessage(client mqtt.Client, msg mqtt.Messa
 	if scheduleData.ScheduleID != "" && scheduleData.DailyRules != nil {
 		// This is a getSchedule response, save to schedules table
 		log.Printf("[MQTT] Detected getSchedule response for device %s", deviceSN)
-		if h.scheduleRepo == nil {
+		if h.scheduleRepo == nil && h.scheduleReconciler == nil {
 			log.Printf("[MQTT] Warning: scheduleRepo is nil, cannot save schedule")
 		} else if err := h.saveScheduleFromDevice(deviceSN, response.Data); err != nil {
 			log.Printf("[MQTT] Failed to save schedule for %s: %v", deviceSN, err)
@@ -296,7 +353,7 @@ type DeviceAction struct {
 
 // saveScheduleFromDevice saves the schedule from device to database
 func (h *DeviceResponseHandler) saveScheduleFromDevice(deviceSN string, data []byte) error {
-	if h.scheduleRepo == nil {
+	if h.scheduleRepo == nil && h.scheduleReconciler == nil {
 		return nil // Schedule repo not configured, skip
 	}
 
@@ -335,15 +392,6 @@ func (h *DeviceResponseHandler) saveScheduleFromDevice(deviceSN string, data []b
 		Exceptions: scheduleData.Exceptions,
 	}
 
-	// Check if schedule already exists
-	existing, _ := h.scheduleRepo.FindByCompanyDeviceID(companyDevice.ID)
-	if existing != nil {
-		fullSchedule.Schedule.ID = existing.ID
-		fullSchedule.Schedule.Version = existing.Version + 1
-		fullSchedule.Schedule.CreatedBy = existing.CreatedBy
-		fullSchedule.Schedule.CreatedAt = existing.CreatedAt
-	}
-
 	// Convert daily rules
 	for dayName, rule := range scheduleData.DailyRules {
 		if rule == nil {
@@ -367,6 +415,20 @@ func (h *DeviceResponseHandler) saveScheduleFromDevice(deviceSN string, data []b
 		fullSchedule.DailyRules[dayName] = ruleDetails
 	}
 
+	// Let the reconciler decide whether the device or the cloud copy wins
+	if h.scheduleReconciler != nil {
+		return h.scheduleReconciler.ReconcileDeviceSchedule(companyDevice.ID, fullSchedule)
+	}
+
+	// Check if schedule already exists
+	existing, _ := h.scheduleRepo.FindByCompanyDeviceID(companyDevice.ID)
+	if existing != nil {
+		fullSchedule.Schedule.ID = existing.ID
+		fullSchedule.Schedule.Version = existing.Version + 1
+		fullSchedule.Schedule.CreatedBy = existing.CreatedBy
+		fullSchedule.Schedule.CreatedAt = existing.CreatedAt
+	}
+
 	// Save to database
 	return h.scheduleRepo.SaveFullSchedule(fullSchedule)
 }
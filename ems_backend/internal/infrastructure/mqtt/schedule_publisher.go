@@ -53,21 +53,6 @@ type GetScheduleCommand struct {
 	Command string `json:"command"` // "getSchedule"
 }
 
-// GetCommandTopic returns the MQTT topic for sending commands to a device
-func GetCommandTopic(deviceSN string) string {
-	return fmt.Sprintf("ac/command/%s", deviceSN)
-}
-
-// GetReturnTopic returns the MQTT topic for receiving responses from a device
-func GetReturnTopic(deviceSN string) string {
-	return fmt.Sprintf("ac/return/%s", deviceSN)
-}
-
-// GetReturnTopicWildcard returns the wildcard topic for subscribing to all device responses
-func GetReturnTopicWildcard() string {
-	return "ac/return/+"
-}
-
 // PublishSchedule sends a schedule command to a device
 func (p *SchedulePublisher) PublishSchedule(deviceSN string, cmd *ScheduleCommand) error {
 	if p.client == nil {
@@ -84,7 +69,7 @@ func (p *SchedulePublisher) PublishSchedule(deviceSN string, cmd *ScheduleComman
 		return fmt.Errorf("failed to marshal schedule command: %w", err)
 	}
 
-	topic := GetCommandTopic(deviceSN)
+	topic := p.client.Topics().Command(deviceSN)
 	return p.client.Publish(topic, 1, false, payload) // QoS 1 for at-least-once delivery
 }
 
@@ -103,7 +88,7 @@ func (p *SchedulePublisher) PublishDeviceInfoRequest(deviceSN string) error {
 		return fmt.Errorf("failed to marshal deviceInfo command: %w", err)
 	}
 
-	topic := GetCommandTopic(deviceSN)
+	topic := p.client.Topics().Command(deviceSN)
 	return p.client.Publish(topic, 1, false, payload)
 }
 
@@ -122,7 +107,7 @@ func (p *SchedulePublisher) PublishGetScheduleRequest(deviceSN string) error {
 		return fmt.Errorf("failed to marshal getSchedule command: %w", err)
 	}
 
-	topic := GetCommandTopic(deviceSN)
+	topic := p.client.Topics().Command(deviceSN)
 	return p.client.Publish(topic, 1, false, payload)
 }
 
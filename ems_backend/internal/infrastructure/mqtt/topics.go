@@ -0,0 +1,69 @@
+package mqtt
+
+import (
+	"strings"
+)
+
+// DefaultTopicPrefix is the topic root used by ems_vrv gateways out of the box
+// (ac/command/{sn} and ac/return/{sn}).
+const DefaultTopicPrefix = "ac"
+
+// Topics builds and parses device topics under a configurable prefix:
+//
+//	{prefix}/command/{sn}  backend -> gateway
+//	{prefix}/return/{sn}   gateway -> backend
+//
+// The prefix may contain several levels (e.g. "site-a/ems/ac") so that several
+// environments can share one broker.
+type Topics struct {
+	Prefix string
+}
+
+// NewTopics returns Topics for prefix, falling back to DefaultTopicPrefix.
+// Leading and trailing slashes are ignored.
+func NewTopics(prefix string) Topics {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultTopicPrefix
+	}
+	return Topics{Prefix: prefix}
+}
+
+// Command returns the topic for sending commands to a device
+func (t Topics) Command(deviceSN string) string {
+	return t.Prefix + "/command/" + deviceSN
+}
+
+// Return returns the topic a device publishes its responses on
+func (t Topics) Return(deviceSN string) string {
+	return t.Prefix + "/return/" + deviceSN
+}
+
+// ReturnWildcard returns the wildcard topic for subscribing to all device responses
+func (t Topics) ReturnWildcard() string {
+	return t.Prefix + "/return/+"
+}
+
+// DeviceSNFromReturn extracts the device SN from a return topic
+func (t Topics) DeviceSNFromReturn(topic string) (string, bool) {
+	deviceSN, ok := strings.CutPrefix(topic, t.Prefix+"/return/")
+	if !ok || deviceSN == "" || strings.Contains(deviceSN, "/") {
+		return "", false
+	}
+	return deviceSN, true
+}
+
+// CommandWildcard returns the wildcard topic covering every device's command topic
+// (used by the gateway simulator when it shares one connection)
+func (t Topics) CommandWildcard() string {
+	return t.Prefix + "/command/+"
+}
+
+// DeviceSNFromCommand extracts the device SN from a command topic
+func (t Topics) DeviceSNFromCommand(topic string) (string, bool) {
+	deviceSN, ok := strings.CutPrefix(topic, t.Prefix+"/command/")
+	if !ok || deviceSN == "" || strings.Contains(deviceSN, "/") {
+		return "", false
+	}
+	return deviceSN, true
+}
@@ -0,0 +1,70 @@
+package mqtt
+
+import (
+	"testing"
+)
+
+func TestTopics(t *testing.T) {
+	tests := []struct {
+		name           string
+		prefix         string
+		wantCommand    string
+		wantReturn     string
+		wantReturnAll  string
+		wantCommandAll string
+	}{
+		{name: "預設前綴", prefix: "", wantCommand: "ac/command/SN1", wantReturn: "ac/return/SN1", wantReturnAll: "ac/return/+", wantCommandAll: "ac/command/+"},
+		{name: "自訂前綴", prefix: "ems", wantCommand: "ems/command/SN1", wantReturn: "ems/return/SN1", wantReturnAll: "ems/return/+", wantCommandAll: "ems/command/+"},
+		{name: "多層前綴", prefix: "site-a/ems/ac", wantCommand: "site-a/ems/ac/command/SN1", wantReturn: "site-a/ems/ac/return/SN1", wantReturnAll: "site-a/ems/ac/return/+", wantCommandAll: "site-a/ems/ac/command/+"},
+		{name: "去除前後斜線", prefix: "/site-a/", wantCommand: "site-a/command/SN1", wantReturn: "site-a/return/SN1", wantReturnAll: "site-a/return/+", wantCommandAll: "site-a/command/+"},
+		{name: "只有斜線時使用預設", prefix: "//", wantCommand: "ac/command/SN1", wantReturn: "ac/return/SN1", wantReturnAll: "ac/return/+", wantCommandAll: "ac/command/+"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topics := NewTopics(tt.prefix)
+			if got := topics.Command("SN1"); got != tt.wantCommand {
+				t.Errorf("Command: 期望 %q，得到 %q", tt.wantCommand, got)
+			}
+			if got := topics.Return("SN1"); got != tt.wantReturn {
+				t.Errorf("Return: 期望 %q，得到 %q", tt.wantReturn, got)
+			}
+			if got := topics.ReturnWildcard(); got != tt.wantReturnAll {
+				t.Errorf("ReturnWildcard: 期望 %q，得到 %q", tt.wantReturnAll, got)
+			}
+			if got := topics.CommandWildcard(); got != tt.wantCommandAll {
+				t.Errorf("CommandWildcard: 期望 %q，得到 %q", tt.wantCommandAll, got)
+			}
+		})
+	}
+}
+
+func TestTopics_DeviceSN(t *testing.T) {
+	topics := NewTopics("site-a/ac")
+
+	tests := []struct {
+		name   string
+		parse  func(string) (string, bool)
+		topic  string
+		wantSN string
+		wantOK bool
+	}{
+		{name: "return 主題", parse: topics.DeviceSNFromReturn, topic: "site-a/ac/return/SN1", wantSN: "SN1", wantOK: true},
+		{name: "return 前綴不符", parse: topics.DeviceSNFromReturn, topic: "ac/return/SN1"},
+		{name: "return 缺少 SN", parse: topics.DeviceSNFromReturn, topic: "site-a/ac/return/"},
+		{name: "return SN 含多層", parse: topics.DeviceSNFromReturn, topic: "site-a/ac/return/SN1/extra"},
+		{name: "command 主題不是 return", parse: topics.DeviceSNFromReturn, topic: "site-a/ac/command/SN1"},
+		{name: "command 主題", parse: topics.DeviceSNFromCommand, topic: "site-a/ac/command/SN2", wantSN: "SN2", wantOK: true},
+		{name: "command 前綴不符", parse: topics.DeviceSNFromCommand, topic: "site-b/ac/command/SN2"},
+		{name: "command SN 含多層", parse: topics.DeviceSNFromCommand, topic: "site-a/ac/command/SN2/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sn, ok := tt.parse(tt.topic)
+			if sn != tt.wantSN || ok != tt.wantOK {
+				t.Errorf("期望 (%q, %v)，得到 (%q, %v)", tt.wantSN, tt.wantOK, sn, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksRefreshInterval 遇到未知的 kid 時最多每分鐘重新取得一次 JWKS (金鑰輪替)
+	jwksRefreshInterval = time.Minute
+	// clockSkew ID token 時間宣告容許的時鐘誤差
+	clockSkew = time.Minute
+	// maxResponseSize 身分提供者回應的大小上限
+	maxResponseSize = 1 << 20
+)
+
+// signingMethods 接受的 ID token 簽章演算法 (不接受 none 與 HMAC)
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// ClientConfig - 身分提供者連線設定
+type ClientConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // 預設 openid email profile
+	HTTPClient   *http.Client
+}
+
+// discoveryDocument - /.well-known/openid-configuration
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// Client - OpenID Connect 授權碼流程用戶端
+// 第一次使用時才讀取 discovery 文件，身分提供者暫時無法連線不影響服務啟動
+type Client struct {
+	config     ClientConfig
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	discovery   *discoveryDocument
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// NewClient - 建立 OpenID Connect 用戶端
+func NewClient(config ClientConfig) (*Client, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("issuer, client_id and redirect_url are required")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{config: config, httpClient: httpClient}, nil
+}
+
+// AuthorizationURL - 產生授權端點網址 (response_type=code、PKCE S256)
+func (c *Client) AuthorizationURL(state, nonce, codeChallenge string) (string, error) {
+	discovery, err := c.discover(context.Background())
+	if err != nil {
+		return "", err
+	}
+	scopes := c.config.Scopes
+	if !contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange - 以授權碼換取 token 並驗證 ID token 的簽章、iss、aud、exp 與 nonce
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*entities.OIDCClaims, error) {
+	discovery, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// 預設 client_secret_basic；提供者只支援 client_secret_post 時改放在表單
+	basicAuth := c.config.ClientSecret != "" && (len(discovery.TokenEndpointAuthMethodsSupported) == 0 ||
+		contains(discovery.TokenEndpointAuthMethodsSupported, "client_secret_basic"))
+	if !basicAuth {
+		form.Set("client_id", c.config.ClientID)
+		if c.config.ClientSecret != "" {
+			form.Set("client_secret", c.config.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.doJSON(req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if status != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d %s %s", status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+	return c.verifyIDToken(ctx, discovery, token.IDToken, nonce)
+}
+
+func (c *Client) verifyIDToken(ctx context.Context, discovery *discoveryDocument, idToken, nonce string) (*entities.OIDCClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.key(ctx, discovery, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+	// 多個 aud 時 azp 必須是本用戶端
+	if audiences, _ := claims.GetAudience(); len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != c.config.ClientID {
+			return nil, fmt.Errorf("id_token azp mismatch")
+		}
+	}
+
+	result := &entities.OIDCClaims{Raw: claims}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	result.PreferredUsername, _ = claims["preferred_username"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = strings.EqualFold(verified, "true")
+	}
+	return result, nil
+}
+
+// discover - 讀取並快取 discovery 文件；issuer 必須與設定完全相同
+func (c *Client) discover(ctx context.Context) (*discoveryDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	endpoint := strings.TrimSuffix(c.config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var document discoveryDocument
+	status, err := c.doJSON(req, &document)
+	if err != nil {
+		return nil, fmt.Errorf("openid discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("openid discovery returned %d", status)
+	}
+	if document.Issuer != c.config.Issuer {
+		return nil, fmt.Errorf("openid discovery issuer %q does not match %q", document.Issuer, c.config.Issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, fmt.Errorf("openid discovery document is incomplete")
+	}
+	c.discovery = &document
+	return c.discovery, nil
+}
+
+// key - 依 kid 取得驗證金鑰；未知的 kid 觸發重新取得 JWKS
+func (c *Client) key(ctx context.Context, discovery *discoveryDocument, kid string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key := c.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(c.keysFetched) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	keys, err := c.fetchKeys(ctx, discovery.JWKSURI)
+	c.keysFetched = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	if key := c.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey - 沒有 kid 時只在 JWKS 只有一把金鑰時使用它
+func (c *Client) lookupKey(kid string) interface{} {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key
+		}
+	}
+	return c.keys[kid]
+}
+
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := c.doJSON(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("jwks request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", status)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // 不支援的金鑰類型不影響其他金鑰
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (c *Client) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// jsonWebKey - JWKS 中的公開金鑰 (RSA 或 EC)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+var providerIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ClaimMapping - 宣告值對應到角色或公司
+type ClaimMapping struct {
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+	ID    uint   `json:"id"`
+}
+
+// ProviderSettings - OIDC_PROVIDERS_FILE 中的單一身分提供者
+type ProviderSettings struct {
+	ID           string   `json:"id"`   // 路徑參數，例如 /auth/oidc/azure/authorize
+	Name         string   `json:"name"` // 登入頁顯示名稱
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"` // 可使用 ${ENV} 引用環境變數
+	RedirectURL  string   `json:"redirect_url"`  // 前端回呼頁，再以 code 與 state 呼叫 callback API
+	Scopes       []string `json:"scopes"`
+
+	AutoProvision   bool           `json:"auto_provision"`
+	LinkByEmail     bool           `json:"link_by_email"`
+	AllowedDomains  []string       `json:"allowed_domains"`
+	DefaultRoleIDs  []uint         `json:"default_role_ids"`
+	RoleMappings    []ClaimMapping `json:"role_mappings"`
+	CompanyMappings []ClaimMapping `json:"company_mappings"`
+	SyncRoles       bool           `json:"sync_roles"`
+	SyncCompanies   bool           `json:"sync_companies"`
+}
+
+// ClientConfig - 轉換為用戶端設定
+func (p ProviderSettings) ClientConfig() ClientConfig {
+	return ClientConfig{
+		Issuer:       p.Issuer,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       p.Scopes,
+	}
+}
+
+// LoadProviders - 讀取 JSON 陣列格式的身分提供者設定
+func LoadProviders(path string) ([]ProviderSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var providers []ProviderSettings
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, fmt.Errorf("invalid identity provider file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(providers))
+	for i := range providers {
+		p := &providers[i]
+		if !providerIDPattern.MatchString(p.ID) {
+			return nil, fmt.Errorf("identity provider %d: invalid id %q", i, p.ID)
+		}
+		if seen[p.ID] {
+			return nil, fmt.Errorf("duplicate identity provider %q", p.ID)
+		}
+		seen[p.ID] = true
+		if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			return nil, fmt.Errorf("identity provider %q: issuer, client_id and redirect_url are required", p.ID)
+		}
+		p.ClientSecret = os.ExpandEnv(p.ClientSecret)
+		if !p.AutoProvision && !p.LinkByEmail {
+			// 只允許已連結的身分時，需要先以 email 連結，否則無人能登入
+			return nil, fmt.Errorf("identity provider %q: enable auto_provision or link_by_email", p.ID)
+		}
+	}
+	return providers, nil
+}
@@ -0,0 +1,257 @@
+-- 基礎資料表與視圖 (原 sql/database.sql)
+-- 資料庫與帳號的建立見 sql/database.sql，需在執行 migration 前由 DBA 完成
+
+-- Drop table
+-- DROP TABLE public.member;
+
+CREATE TABLE public.member (
+    id bigserial NOT NULL,
+    name varchar(128) NOT NULL,
+    email varchar(128) NOT NULL,
+    image varchar(256) NULL,
+    phone varchar(16) NULL,
+    is_enable bool NOT NULL,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_member PRIMARY KEY (id)
+);
+
+-- Drop table
+-- DROP TABLE public.menu;
+
+CREATE TABLE public.menu (
+    id bigserial NOT NULL,
+    title varchar(32) NOT NULL,
+    icon varchar(32) NULL,
+    url varchar(128) NOT NULL,
+    parent int8 NULL,
+    description varchar(128) NULL,
+    sort int8 NULL,
+    is_enable bool NOT NULL,
+    is_show bool NOT NULL,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_menu PRIMARY KEY (id)
+);
+
+-- Drop table
+-- DROP TABLE public.role;
+
+CREATE TABLE public.role (
+    id bigserial NOT NULL,
+    title varchar(32) NOT NULL,
+    description varchar(128) NULL,
+    sort int8 NULL,
+    is_enable bool NOT NULL,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_role PRIMARY KEY (id)
+);
+
+-- Drop table
+-- DROP TABLE public.forgot_temp;
+
+CREATE TABLE public.forgot_temp (
+    id bigserial NOT NULL,
+    member_id int8 NOT NULL,
+    expire_time timestamp NOT NULL,
+    code varchar(128) NOT NULL,
+    redirect_path varchar(512) NULL,
+    CONSTRAINT pk_forgot_temp PRIMARY KEY (id),
+    CONSTRAINT fk_forgot_temp_member_id FOREIGN KEY (member_id) REFERENCES public.member(id)
+);
+
+-- Drop table
+-- DROP TABLE public.member_history;
+
+CREATE TABLE public.member_history (
+    id bigserial NOT NULL,
+    member_id int8 NOT NULL,
+    salt      text not null,
+    hash      text not null,
+    error_count int2 NOT NULL DEFAULT 0,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_member_history PRIMARY KEY (id),
+    CONSTRAINT fk_member_history_member_id FOREIGN KEY (member_id) REFERENCES public.member(id)
+);
+
+-- Drop table
+-- DROP TABLE public.member_role;
+
+CREATE TABLE public.member_role (
+    id bigserial NOT NULL,
+    role_id int8 NOT NULL,
+    member_id int8 NOT NULL,
+    CONSTRAINT pk_member_role PRIMARY KEY (id),
+    CONSTRAINT fk_member_role_member_id FOREIGN KEY (member_id) REFERENCES public.member(id),
+    CONSTRAINT fk_member_role_role_id FOREIGN KEY (role_id) REFERENCES public.role(id)
+);
+
+-- Drop table
+-- DROP TABLE public.power;
+
+CREATE TABLE public.power (
+    id bigserial NOT NULL,
+    menu_id int8 NOT NULL,
+    title varchar(32) NOT NULL,
+    code varchar(32) NOT NULL,
+    description varchar(128) NULL,
+    sort int8 NULL,
+    is_enable bool NOT NULL,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_power PRIMARY KEY (id),
+    CONSTRAINT fk_power_menu_id FOREIGN KEY (menu_id) REFERENCES public.menu(id)
+);
+
+-- Drop table
+-- DROP TABLE public.role_power;
+
+CREATE TABLE public.role_power (
+    id bigserial NOT NULL,
+    role_id int8 NOT NULL,
+    menu_id int8 NOT NULL,
+    power_id int8 NULL,
+    create_id int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    modify_id int8 NOT NULL,
+    modify_time timestamp NOT NULL,
+    CONSTRAINT pk_role_power PRIMARY KEY (id),
+    CONSTRAINT fk_role_power_menu_id FOREIGN KEY (menu_id) REFERENCES public.menu(id),
+    CONSTRAINT fk_role_power_power_id FOREIGN KEY (power_id) REFERENCES public.power(id),
+    CONSTRAINT fk_role_power_role_id FOREIGN KEY (role_id) REFERENCES public.role(id)
+);
+
+CREATE TABLE public.system_log (
+    id serial4 NOT NULL,
+    level varchar(128) NOT NULL,
+    message json NOT NULL,
+    timestamp timestamp NOT NULL,
+    CONSTRAINT system_log_pkey PRIMARY KEY (id)
+);
+
+-- Views renaming
+CREATE OR REPLACE VIEW public.v_member_role
+AS SELECT member_role.id,
+    member_role.member_id,
+    member."name" as member_name,
+    member_role.role_id,
+    role.title AS role_title
+   FROM member_role
+     JOIN role ON role.id = member_role.role_id
+	 JOIN member ON member.id = member_role.member_id;
+
+CREATE OR REPLACE VIEW public.v_power
+AS SELECT power.id,
+    power.menu_id,
+    menu.title AS menu_name,
+    menu.sort AS menu_sort,
+    power.title,
+    power.code,
+    power.description,
+    power.sort,
+    power.is_enable
+   FROM power
+     JOIN menu ON menu.id = power.menu_id
+  ORDER BY menu.sort, power.sort;
+
+CREATE OR REPLACE VIEW public.v_role_power
+AS SELECT role_power.id,
+    role_power.role_id,
+    role.title AS role_title,
+    role_power.menu_id,
+    menu.title AS menu_title,
+    role_power.power_id,
+    power.title AS power_title,
+    power.code AS power_code
+   FROM role_power
+     JOIN role ON role.id = role_power.role_id
+     JOIN menu ON menu.id = role_power.menu_id
+     LEFT JOIN power ON power.id = role_power.power_id;
+
+-- express 需要
+create table public.access_token(
+    id bigserial PRIMARY KEY NOT NULL,
+    "member_id" bigint references public.member(id) not null,
+    "access_token" varchar(256) not null,
+    "refresh_token" varchar(256) unique not null,
+    "create_id" bigint   NOT NULL,
+    "create_time" timestamp   NOT NULL,
+    "modify_id" bigint   NOT NULL,
+    "modify_time" timestamp   NOT NULL
+);
+
+create table public.device(
+    id bigserial PRIMARY KEY NOT NULL,
+    sn varchar(256) not null,
+    "create_id" bigint   NOT NULL,
+    "create_time" timestamp   NOT NULL,
+    "modify_id" bigint   NOT NULL,
+    "modify_time" timestamp   NOT NULL
+);
+
+-- 業務角色註冊帳號，指派公司資料
+create table public.company(
+    id bigserial PRIMARY KEY NOT NULL,
+    "name" varchar(256) not null,
+    "address" varchar(512), -- 分公司地址
+    "contact_person" varchar(128), -- 聯絡人
+    "contact_phone" varchar(32), -- 聯絡電話
+    "is_active" boolean DEFAULT true, -- 是否啟用
+    "parent_id" bigint references public.company(id), -- 父公司
+    "create_id" bigint   NOT NULL,
+    "create_time" timestamp   NOT NULL,
+    "modify_id" bigint   NOT NULL,
+    "modify_time" timestamp   NOT NULL
+);
+
+-- 公司成員
+create table public.company_member(
+    id bigserial PRIMARY KEY NOT NULL,
+    "company_id" bigint references public.company(id) not null,
+    "member_id"  bigint references public.member(id) not null,
+    "create_id"  bigint   NOT NULL,
+    "create_time"timestamp   NOT NULL,
+    "modify_id"  bigint   NOT NULL,
+    "modify_time"timestamp   NOT NULL,
+    unique("company_id", "member_id")
+);
+
+-- 公司擁有硬體
+create table public.company_device(
+    id bigserial PRIMARY KEY NOT NULL,
+    "company_id" bigint references public.company(id) not null,
+    "device_id" bigint references public.device(id) not null,
+    "content" jsonb not null, -- 硬體關聯資料
+    "create_id" bigint   NOT NULL,
+    "create_time" timestamp   NOT NULL,
+    "modify_id" bigint   NOT NULL,
+    "modify_time" timestamp   NOT NULL
+);
+
+create table public.temperatures(
+    id bigserial PRIMARY KEY NOT NULL,
+    "timestamp"  timestamp   NOT NULL,
+    "temperature_id" text not null,
+    "temperature" double precision,
+    "humidity"   double precision
+);
+
+create table public.meters(
+    id bigserial PRIMARY KEY NOT NULL,
+    "timestamp"  timestamp   NOT NULL,
+    "meter_id" text not null,
+    "k_wh" double precision,
+    "kw"   double precision
+);
@@ -0,0 +1,91 @@
+-- 初始資料 (原 sql/database.sql)：SystemAdmin 帳號、角色、設定選單與使用者權限，以及 company_manager 角色
+-- 只在空資料庫 (尚無成員) 時寫入，SystemAdmin 的預設密碼請於首次登入後更換
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM "member") THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO "member"
+    ( "name",  "email", "is_enable", "create_id", "create_time", "modify_id", "modify_time")
+    VALUES( 'SystemAdmin','system@ems.com',true, 1, '2020-11-29 03:53:46.988', 1, '2020-11-29 07:41:35.292');
+
+    INSERT INTO member_history
+    ("member_id", "hash", "salt", "error_count",  "create_id", "create_time", "modify_id", "modify_time")
+    VALUES(1, 'U9n6GNmnWrZon0KvO8nKTMKsMbqwT83Axf0AaqXjBPs=','bj4aCXeSj9QzrrEl', 0, 1, '2020-06-04 11:53:46.988', 1, '2023-02-17 09:40:09.000');
+
+    INSERT INTO "role"
+    (title, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES('SystemAdmin', '', 0, true, 1, '2023-12-04', 1, '2023-12-04');
+
+    INSERT INTO member_role
+    (role_id, member_id)
+    VALUES(1, 1);
+
+    INSERT INTO menu
+    (title, icon, url, parent, description, sort, is_enable, is_show, create_id, create_time, modify_id, modify_time)
+    VALUES('setting', 'SettingsIcon', '/setting', 0, '系統設定', 2, true, true, 1, '2023-12-22 05:31:14.126', 1, '2023-12-22 05:31:14.126');
+    INSERT INTO menu
+    (title, icon, url, parent, description, sort, is_enable, is_show, create_id, create_time, modify_id, modify_time)
+    VALUES('menu', 'ListIcon', '/menu', 1, '選單管理', 1, true, true, 1, '2023-12-22 05:31:39.477', 1, '2023-12-22 05:31:39.477');
+    INSERT INTO menu
+    (title, icon, url, parent, description, sort, is_enable, is_show, create_id, create_time, modify_id, modify_time)
+    VALUES('power', 'PolicyIcon', '/power', 1, '權限管理', 2, true, true, 1, '2023-12-22 05:31:47.418', 1, '2023-12-22 05:31:47.418');
+    INSERT INTO menu
+    (title, icon, url, parent, description, sort, is_enable, is_show, create_id, create_time, modify_id, modify_time)
+    VALUES('role', 'PersonIcon', '/role', 1, '角色管理', 3, true, true, 1, '2023-12-25 06:16:13.451', 1, '2023-12-25 06:16:13.451');
+    INSERT INTO menu
+    (title, icon, url, parent, description, sort, is_enable, is_show, create_id, create_time, modify_id, modify_time)
+    VALUES('user', 'PeopleIcon', '/user', 1, '用戶管理', 4, true, true, 1, '2023-12-25 06:15:41.929', 1, '2023-12-25 06:15:41.929');
+
+    INSERT INTO power
+    (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES(5, 'query', 'user:query', '', NULL, true, 1, '2024-01-04 18:24:28.986', 1, '2024-01-04 18:24:28.986');
+    INSERT INTO power
+    (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES(5, 'queryById', 'user:queryById', '', NULL, true, 1, '2024-01-04 18:25:05.395', 1, '2024-01-04 18:25:05.395');
+    INSERT INTO power
+    (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES(5, 'create', 'user:create', '', NULL, true, 1, '2024-01-04 18:24:28.986', 1, '2024-01-04 18:24:28.986');
+    INSERT INTO power
+    (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES(5, 'update', 'user:update', '', NULL, true, 1, '2024-01-04 18:25:05.395', 1, '2024-01-04 18:25:05.395');
+    INSERT INTO power
+    (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES(5, 'delete', 'user:delete', '', NULL, true, 1, '2024-01-04 18:25:05.395', 1, '2024-01-04 18:25:05.395');
+
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 1, NULL, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 2, NULL, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 3, NULL, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 4, NULL, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, NULL, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, 1, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, 2, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, 3, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, 4, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+    INSERT INTO role_power
+    (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+    VALUES(1, 5, 5, 1, '2024-01-04 18:27:35.126', 1, '2024-01-04 18:27:35.126');
+
+    INSERT INTO "role"
+    (title, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+    VALUES('company_manager', '', 0, true, 1, '2025-10-18', 1, '2025-10-18');
+END $$;
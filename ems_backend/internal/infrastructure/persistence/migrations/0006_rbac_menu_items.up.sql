@@ -4,7 +4,7 @@
 -- ============================================
 
 -- 注意：這個腳本假設 setting 菜單（ID=1）已經存在
--- 如果不存在，請先執行 database.sql
+-- 由 0002_base_seed 建立
 
 -- ============================================
 -- 1. 添加權限管理菜單項
@@ -0,0 +1,10 @@
+-- ============================================
+-- Revert Schedule Drift Tables
+-- ============================================
+
+-- 漂移狀態的排程改回待同步，之後由輪詢重新比對
+UPDATE schedules SET sync_status = 'pending' WHERE sync_status = 'drift';
+COMMENT ON COLUMN schedules.sync_status IS 'pending, synced, failed';
+
+DROP TABLE IF EXISTS schedule_drift_policies;
+DROP TABLE IF EXISTS schedule_drifts;
@@ -0,0 +1,44 @@
+-- ============================================
+-- Schedule Drift Tables
+-- 紀錄設備回報排程與雲端排程的差異及公司處理策略
+-- ============================================
+
+-- 1. Drift records (one row per detected divergence)
+CREATE TABLE IF NOT EXISTS schedule_drifts (
+    id SERIAL PRIMARY KEY,
+    company_device_id INTEGER NOT NULL REFERENCES company_device(id) ON DELETE CASCADE,
+    schedule_id INTEGER NOT NULL DEFAULT 0, -- schedules.id (0 = 雲端尚無排程)
+    cloud_version INTEGER NOT NULL DEFAULT 0,
+    differences JSONB NOT NULL DEFAULT '[]',
+    device_snapshot JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolution VARCHAR(16),
+    resolved_by INTEGER DEFAULT 0,
+    resolved_at TIMESTAMP,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_drifts_company_device ON schedule_drifts(company_device_id);
+CREATE INDEX IF NOT EXISTS idx_schedule_drifts_status ON schedule_drifts(status);
+
+-- 2. Per-company drift policy
+CREATE TABLE IF NOT EXISTS schedule_drift_policies (
+    company_id INTEGER PRIMARY KEY REFERENCES company(id) ON DELETE CASCADE,
+    policy VARCHAR(16) NOT NULL DEFAULT 'manual',
+    modify_id INTEGER NOT NULL,
+    modify_time TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- 3. Add comments
+COMMENT ON TABLE schedule_drifts IS 'Detected differences between device-reported and stored schedules';
+COMMENT ON TABLE schedule_drift_policies IS 'How schedule drift is resolved per company';
+
+COMMENT ON COLUMN schedule_drifts.differences IS 'Rule-by-rule differences: [{day, field, kind, cloud, device}]';
+COMMENT ON COLUMN schedule_drifts.device_snapshot IS 'Full schedule reported by the device';
+COMMENT ON COLUMN schedule_drifts.status IS 'open, resolved';
+COMMENT ON COLUMN schedule_drifts.resolution IS 'cloud_wins, device_wins';
+COMMENT ON COLUMN schedule_drift_policies.policy IS 'cloud_wins, device_wins, manual';
+COMMENT ON COLUMN schedules.sync_status IS 'pending, synced, failed, drift';
+
+-- 4. Verification
+SELECT 'Schedule drift tables created successfully' as status;
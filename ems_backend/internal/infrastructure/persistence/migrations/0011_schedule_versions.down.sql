@@ -0,0 +1,7 @@
+-- ============================================
+-- Revert Schedule Version History
+-- 排程歷史版本會一併刪除
+-- ============================================
+
+DROP TABLE IF EXISTS schedule_versions;
+DROP FUNCTION IF EXISTS schedule_versions_immutable();
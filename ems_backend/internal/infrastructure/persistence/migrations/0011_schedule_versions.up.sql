@@ -0,0 +1,37 @@
+-- ============================================
+-- Schedule Version History
+-- 每次保存排程都新增一筆不可變的版本紀錄 (作者、時間、完整規則)
+-- ============================================
+
+CREATE TABLE IF NOT EXISTS schedule_versions (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER NOT NULL, -- schedules.id (刪除排程後仍保留歷史)
+    company_device_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    source VARCHAR(16) NOT NULL, -- create, update, device, rollback, baseline
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    rules JSONB NOT NULL,
+    created_by INTEGER NOT NULL, -- 0 = 來自設備
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(schedule_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_versions_company_device ON schedule_versions(company_device_id);
+
+-- 禁止修改或刪除歷史版本
+CREATE OR REPLACE FUNCTION schedule_versions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'schedule_versions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_schedule_versions_immutable ON schedule_versions;
+CREATE TRIGGER trg_schedule_versions_immutable
+    BEFORE UPDATE OR DELETE ON schedule_versions
+    FOR EACH ROW EXECUTE FUNCTION schedule_versions_immutable();
+
+COMMENT ON TABLE schedule_versions IS 'Immutable history of every saved schedule version';
+COMMENT ON COLUMN schedule_versions.rules IS 'Full rules snapshot: {command, daily_rules, exceptions}';
+COMMENT ON COLUMN schedule_versions.restored_from IS 'Source version number when created by rollback';
+
+SELECT 'Schedule versions table created successfully' as status;
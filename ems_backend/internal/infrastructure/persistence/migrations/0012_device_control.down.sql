@@ -0,0 +1,10 @@
+-- ============================================
+-- Revert Device Remote Control
+-- ============================================
+
+-- 1. Permissions (role_power 沒有 ON DELETE CASCADE，先移除角色的權限)
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code LIKE 'device_control:%');
+DELETE FROM power WHERE code LIKE 'device_control:%';
+
+-- 2. Command log table
+DROP TABLE IF EXISTS device_commands;
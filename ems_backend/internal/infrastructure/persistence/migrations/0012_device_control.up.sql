@@ -0,0 +1,88 @@
+-- ============================================
+-- Device Remote Control (AC units / compressors)
+-- ============================================
+--
+-- 權限說明:
+-- device_control:power    - 遠端開關 VRF 室內機 / 箱型機壓縮機
+-- device_control:mode     - 遠端設定室內機運轉模式
+-- device_control:setpoint - 遠端設定室內機溫度
+--
+-- 命令紀錄查詢 (GET /companies/:id/devices/:deviceId/commands) 使用 company:view_devices 權限
+--
+
+-- 1. Command log table
+CREATE TABLE IF NOT EXISTS device_commands (
+    id SERIAL PRIMARY KEY,
+    command_id VARCHAR(64) NOT NULL UNIQUE,
+    company_device_id INTEGER NOT NULL REFERENCES company_device(id) ON DELETE CASCADE,
+    device_sn VARCHAR(64) NOT NULL,
+    target_type VARCHAR(16) NOT NULL, -- ac_unit, compressor
+    target_id VARCHAR(64) NOT NULL,
+    action VARCHAR(32) NOT NULL, -- power_on, power_off, set_mode, set_setpoint
+    mode VARCHAR(16),
+    setpoint NUMERIC(4,1),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, sent, acknowledged, failed, timeout
+    message TEXT,
+    requested_by INTEGER NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP,
+    ack_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_commands_company_device ON device_commands(company_device_id, requested_at DESC);
+
+COMMENT ON TABLE device_commands IS 'Remote control commands sent to gateways and their acknowledgement status';
+
+-- 2. Permissions (under 公司管理 menu)
+DO $$
+DECLARE
+    company_menu_id INT;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+
+    IF company_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '遠端開關機', 'device_control:power', '遠端開關室內機或壓縮機', 20, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '遠端設定模式', 'device_control:mode', '遠端設定室內機運轉模式', 21, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '遠端設定溫度', 'device_control:setpoint', '遠端設定室內機溫度', 22, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Device control permissions created';
+    ELSE
+        RAISE NOTICE 'Company menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 3. Assign to SystemAdmin (role_id=1) and company_manager
+DO $$
+DECLARE
+    company_menu_id INT;
+    manager_role_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+    SELECT id INTO manager_role_id FROM role WHERE title = 'company_manager' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code LIKE 'device_control:%' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        IF manager_role_id IS NOT NULL THEN
+            INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+            VALUES (manager_role_id, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+            ON CONFLICT DO NOTHING;
+        END IF;
+    END LOOP;
+
+    RAISE NOTICE 'Device control permissions assigned';
+END $$;
+
+-- 4. Verification
+SELECT id, menu_id, code, title FROM power WHERE code LIKE 'device_control:%' ORDER BY code;
@@ -0,0 +1,10 @@
+-- ============================================
+-- Revert Area Comfort Control
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'comfort_control:manage');
+DELETE FROM power WHERE code = 'comfort_control:manage';
+
+-- 2. Settings table
+DROP TABLE IF EXISTS comfort_control_settings;
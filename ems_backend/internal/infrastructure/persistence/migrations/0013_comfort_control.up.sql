@@ -0,0 +1,84 @@
+-- ============================================
+-- Area Comfort Control (closed-loop heat index control)
+-- ============================================
+--
+-- 控制迴圈每 COMFORT_CONTROL_INTERVAL 執行一次 (需啟用 MQTT)：
+--   區域體感溫度 >= comfort_high + hysteresis → 開啟對應壓縮機 / VRF 室內機
+--   區域體感溫度 <= comfort_low - hysteresis  → 關閉對應壓縮機 / VRF 室內機
+--   並遵守 min_on_seconds / min_off_seconds
+-- dry_run = true 時僅記錄決策不發送命令 (COMFORT_CONTROL_DRY_RUN=true 時全域 dry-run)
+--
+-- 權限說明:
+-- comfort_control:manage - 設定區域舒適度控制
+--
+-- 設定查詢 (GET /companies/:id/devices/:deviceId/comfort-control) 使用 company:view_devices 權限
+--
+
+-- 1. Settings table
+CREATE TABLE IF NOT EXISTS comfort_control_settings (
+    id SERIAL PRIMARY KEY,
+    company_device_id INTEGER NOT NULL REFERENCES company_device(id) ON DELETE CASCADE,
+    area_id VARCHAR(64) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    dry_run BOOLEAN NOT NULL DEFAULT TRUE,
+    comfort_low NUMERIC(4,1) NOT NULL DEFAULT 24.0,
+    comfort_high NUMERIC(4,1) NOT NULL DEFAULT 27.0,
+    hysteresis NUMERIC(3,1) NOT NULL DEFAULT 0.5,
+    min_on_seconds INTEGER NOT NULL DEFAULT 300,
+    min_off_seconds INTEGER NOT NULL DEFAULT 300,
+    updated_by INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_comfort_settings_area UNIQUE (company_device_id, area_id),
+    CONSTRAINT chk_comfort_band CHECK (comfort_low < comfort_high)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comfort_control_settings_enabled ON comfort_control_settings(enabled);
+
+COMMENT ON TABLE comfort_control_settings IS 'Per-area comfort band used by the closed-loop heat index controller';
+
+-- 2. Permissions (under 公司管理 menu)
+DO $$
+DECLARE
+    company_menu_id INT;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+
+    IF company_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '舒適度控制設定', 'comfort_control:manage', '設定區域體感溫度自動啟停空調', 23, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Comfort control permission created';
+    ELSE
+        RAISE NOTICE 'Company menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 3. Assign to SystemAdmin (role_id=1) and company_manager
+DO $$
+DECLARE
+    company_menu_id INT;
+    manager_role_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+    SELECT id INTO manager_role_id FROM role WHERE title = 'company_manager' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code = 'comfort_control:manage' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        IF manager_role_id IS NOT NULL THEN
+            INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+            VALUES (manager_role_id, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+            ON CONFLICT DO NOTHING;
+        END IF;
+    END LOOP;
+
+    RAISE NOTICE 'Comfort control permission assigned';
+END $$;
+
+-- 4. Verification
+SELECT id, menu_id, code, title FROM power WHERE code = 'comfort_control:manage';
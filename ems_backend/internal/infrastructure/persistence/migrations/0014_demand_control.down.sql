@@ -0,0 +1,13 @@
+-- ============================================
+-- Revert Contract-Capacity Demand Limiting
+-- 回復前請先停用需量控制並復歸已卸載的負載 (demand_active_sheds)，否則該負載會維持關閉
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'demand_control:manage');
+DELETE FROM power WHERE code = 'demand_control:manage';
+
+-- 2. Tables
+DROP TABLE IF EXISTS demand_events;
+DROP TABLE IF EXISTS demand_active_sheds;
+DROP TABLE IF EXISTS demand_settings;
@@ -0,0 +1,115 @@
+-- ============================================
+-- Contract-Capacity Demand Limiting (load shedding)
+-- ============================================
+--
+-- 控制迴圈每 DEMAND_CONTROL_INTERVAL 執行一次 (需啟用 MQTT)：
+--   以公司所有區域電表計算 15 分鐘滾動平均需量，並預測本需量時段 (00/15/30/45 分) 結束時的平均需量
+--   預測需量 >= contract_kw * shed_ratio    → 依 loads 順序卸載下一個運轉中的負載
+--   預測與滾動需量 <= contract_kw * restore_ratio → 復歸最後卸載且已滿 min_shed_seconds 的負載
+-- dry_run = true 時僅記錄事件，不發送命令
+--
+-- 權限說明:
+-- demand_control:manage - 設定契約容量與卸載優先順序
+--
+-- 設定與事件查詢使用 company:view_devices 權限，Dashboard (GET /dashboard/demand) 僅需可訪問該公司
+--
+
+-- 1. Settings (one row per company)
+CREATE TABLE IF NOT EXISTS demand_settings (
+    company_id INTEGER PRIMARY KEY REFERENCES company(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    dry_run BOOLEAN NOT NULL DEFAULT TRUE,
+    contract_kw NUMERIC(10,2) NOT NULL DEFAULT 0,
+    shed_ratio NUMERIC(4,3) NOT NULL DEFAULT 0.95,
+    restore_ratio NUMERIC(4,3) NOT NULL DEFAULT 0.85,
+    min_shed_seconds INTEGER NOT NULL DEFAULT 300,
+    loads JSONB NOT NULL DEFAULT '[]', -- [{company_device_id, target_type, target_id}] in shedding order
+    modify_id INTEGER NOT NULL,
+    modify_time TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_demand_settings_enabled ON demand_settings(enabled);
+
+COMMENT ON TABLE demand_settings IS 'Per-company contract capacity and prioritized load shedding order';
+
+-- 2. Loads currently shed
+CREATE TABLE IF NOT EXISTS demand_active_sheds (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES company(id) ON DELETE CASCADE,
+    company_device_id INTEGER NOT NULL REFERENCES company_device(id) ON DELETE CASCADE,
+    target_type VARCHAR(16) NOT NULL, -- ac_unit, compressor
+    target_id VARCHAR(64) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    shed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_demand_active_sheds_company ON demand_active_sheds(company_id);
+
+COMMENT ON TABLE demand_active_sheds IS 'Loads stopped by the demand limiter and awaiting restore';
+
+-- 3. Shed / restore event log
+CREATE TABLE IF NOT EXISTS demand_events (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES company(id) ON DELETE CASCADE,
+    event_type VARCHAR(16) NOT NULL, -- shed, restore
+    company_device_id INTEGER NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    rolling_kw NUMERIC(10,2) NOT NULL,
+    predicted_kw NUMERIC(10,2) NOT NULL,
+    contract_kw NUMERIC(10,2) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    command_id VARCHAR(64),
+    message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_demand_events_company ON demand_events(company_id, created_at DESC);
+
+COMMENT ON TABLE demand_events IS 'Every load shed and restore performed by the demand limiter';
+
+-- 4. Permissions (under 公司管理 menu)
+DO $$
+DECLARE
+    company_menu_id INT;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+
+    IF company_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '需量控制設定', 'demand_control:manage', '設定契約容量與卸載優先順序', 24, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Demand control permission created';
+    ELSE
+        RAISE NOTICE 'Company menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 5. Assign to SystemAdmin (role_id=1) and company_manager
+DO $$
+DECLARE
+    company_menu_id INT;
+    manager_role_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+    SELECT id INTO manager_role_id FROM role WHERE title = 'company_manager' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code = 'demand_control:manage' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        IF manager_role_id IS NOT NULL THEN
+            INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+            VALUES (manager_role_id, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+            ON CONFLICT DO NOTHING;
+        END IF;
+    END LOOP;
+
+    RAISE NOTICE 'Demand control permission assigned';
+END $$;
+
+-- 6. Verification
+SELECT id, menu_id, code, title FROM power WHERE code = 'demand_control:manage';
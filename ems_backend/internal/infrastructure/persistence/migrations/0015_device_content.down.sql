@@ -0,0 +1,7 @@
+-- ============================================
+-- Revert Typed Device Content Editing
+-- company_device.content 保留目前內容
+-- ============================================
+
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'device_content:update');
+DELETE FROM power WHERE code = 'device_content:update';
@@ -0,0 +1,58 @@
+-- ============================================
+-- Typed Device Content Editing (JSON Patch)
+-- ============================================
+--
+-- company_device.content 寫入時以 JSON Schema 驗證 (GET /companies/device-content-schema)
+-- PATCH /companies/:id/devices/:deviceId/content 接受 RFC 6902 JSON Patch：
+--   If-Match 必須等於 content.version，否則返回 409 與 current_version
+--   驗證失敗返回 422 與欄位錯誤 (JSON Pointer)，例如壓縮機 ID 重複、ac_mappings 指向不存在的機組
+--
+-- 權限說明:
+-- device_content:update - 編輯設備內容設定
+--
+
+-- 1. Permissions (under 公司管理 menu)
+DO $$
+DECLARE
+    company_menu_id INT;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+
+    IF company_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '編輯設備內容', 'device_content:update', '以 JSON Patch 編輯設備區域、箱型機與 VRF 設定', 25, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Device content permission created';
+    ELSE
+        RAISE NOTICE 'Company menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 2. Assign to SystemAdmin (role_id=1) and company_manager
+DO $$
+DECLARE
+    company_menu_id INT;
+    manager_role_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+    SELECT id INTO manager_role_id FROM role WHERE title = 'company_manager' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code = 'device_content:update' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        IF manager_role_id IS NOT NULL THEN
+            INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+            VALUES (manager_role_id, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+            ON CONFLICT DO NOTHING;
+        END IF;
+    END LOOP;
+
+    RAISE NOTICE 'Device content permission assigned';
+END $$;
+
+-- 3. Verification
+SELECT id, menu_id, code, title FROM power WHERE code = 'device_content:update';
@@ -0,0 +1,13 @@
+-- ============================================
+-- Revert Gateway Firmware Inventory & Staged OTA Rollout
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code IN ('firmware:read', 'firmware:manage'));
+DELETE FROM power WHERE code IN ('firmware:read', 'firmware:manage');
+
+-- 2. Tables (依外鍵反向刪除)
+DROP TABLE IF EXISTS firmware_campaign_devices;
+DROP TABLE IF EXISTS firmware_campaigns;
+DROP TABLE IF EXISTS device_firmware;
+DROP TABLE IF EXISTS firmware_artifacts;
@@ -0,0 +1,134 @@
+-- ============================================
+-- Gateway Firmware Inventory & Staged OTA Rollout
+-- ============================================
+--
+-- device_firmware: 每台設備最近一次 deviceInfo 回報的 firmware_version / hardware_version
+-- firmware_artifacts: 可發布的韌體檔案 (下載位址與 SHA-256)
+-- firmware_campaigns: 發布活動，依 percentage 或 device_ids 選取設備，
+--   每 FIRMWARE_CAMPAIGN_INTERVAL 派送下一批 (同時進行中的設備不超過 batch_size)，
+--   完成數達 min_samples 且失敗率超過 max_failure_rate 時自動停止 (status = halted)
+-- firmware_campaign_devices: 各設備 OTA 命令與回報進度
+--
+-- 權限說明:
+-- firmware:read   - 查看韌體目錄、版本盤點與發布進度
+-- firmware:manage - 上傳韌體資訊、建立 / 暫停 / 恢復 / 取消發布活動
+--
+
+-- 1. Firmware catalog
+CREATE TABLE IF NOT EXISTS firmware_artifacts (
+    id SERIAL PRIMARY KEY,
+    version VARCHAR(64) NOT NULL,
+    hardware_version VARCHAR(64) NOT NULL DEFAULT '', -- '' = any hardware
+    url TEXT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    release_notes TEXT,
+    create_id INTEGER NOT NULL,
+    create_time TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_firmware_artifacts_version UNIQUE (version, hardware_version)
+);
+
+COMMENT ON TABLE firmware_artifacts IS 'Firmware images available for OTA rollout';
+
+-- 2. Per-device inventory
+CREATE TABLE IF NOT EXISTS device_firmware (
+    device_id INTEGER PRIMARY KEY REFERENCES device(id) ON DELETE CASCADE,
+    firmware_version VARCHAR(64) NOT NULL,
+    hardware_version VARCHAR(64) NOT NULL DEFAULT '',
+    reported_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_firmware_version ON device_firmware(firmware_version);
+
+COMMENT ON TABLE device_firmware IS 'Firmware and hardware version last reported by each gateway';
+
+-- 3. Rollout campaigns
+CREATE TABLE IF NOT EXISTS firmware_campaigns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    firmware_id INTEGER NOT NULL REFERENCES firmware_artifacts(id),
+    target_mode VARCHAR(16) NOT NULL, -- percentage, devices
+    percentage INTEGER NOT NULL DEFAULT 0,
+    device_ids JSONB NOT NULL DEFAULT '[]',
+    batch_size INTEGER NOT NULL DEFAULT 10,
+    max_failure_rate NUMERIC(4,3) NOT NULL DEFAULT 0.2,
+    min_samples INTEGER NOT NULL DEFAULT 5,
+    device_timeout_seconds INTEGER NOT NULL DEFAULT 1800,
+    status VARCHAR(16) NOT NULL, -- running, paused, halted, completed, cancelled
+    halt_reason TEXT,
+    succeeded_offset INTEGER NOT NULL DEFAULT 0, -- counts at resume after a halt
+    failed_offset INTEGER NOT NULL DEFAULT 0,
+    create_id INTEGER NOT NULL,
+    create_time TIMESTAMP NOT NULL DEFAULT NOW(),
+    modify_id INTEGER NOT NULL,
+    modify_time TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_firmware_campaigns_status ON firmware_campaigns(status);
+
+COMMENT ON TABLE firmware_campaigns IS 'Staged OTA rollouts with automatic halt on high failure rate';
+
+-- 4. Per-device rollout progress
+CREATE TABLE IF NOT EXISTS firmware_campaign_devices (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES firmware_campaigns(id) ON DELETE CASCADE,
+    device_id INTEGER NOT NULL REFERENCES device(id) ON DELETE CASCADE,
+    device_sn VARCHAR(64) NOT NULL,
+    from_version VARCHAR(64),
+    command_id VARCHAR(64),
+    status VARCHAR(16) NOT NULL, -- pending, sent, downloading, installing, succeeded, failed, timeout, cancelled
+    progress INTEGER NOT NULL DEFAULT 0,
+    message TEXT,
+    sent_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_firmware_campaign_devices_campaign ON firmware_campaign_devices(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_firmware_campaign_devices_device ON firmware_campaign_devices(device_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_firmware_campaign_devices_command ON firmware_campaign_devices(command_id) WHERE command_id IS NOT NULL AND command_id <> '';
+
+COMMENT ON TABLE firmware_campaign_devices IS 'OTA command and reported progress for each device in a campaign';
+
+-- 5. Permissions (under 設備管理 menu, SystemAdmin only)
+DO $$
+DECLARE
+    device_menu_id INT;
+BEGIN
+    SELECT id INTO device_menu_id FROM menu WHERE url = '/setting/device' LIMIT 1;
+
+    IF device_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (device_menu_id, '查看韌體', 'firmware:read', '查看韌體目錄、設備版本盤點與發布進度', 10, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (device_menu_id, '管理韌體發布', 'firmware:manage', '新增韌體檔案並建立、暫停、恢復或取消 OTA 發布', 11, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Firmware permissions created';
+    ELSE
+        RAISE NOTICE 'Device menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 6. Assign to SystemAdmin (role_id=1)
+DO $$
+DECLARE
+    device_menu_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO device_menu_id FROM menu WHERE url = '/setting/device' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code IN ('firmware:read', 'firmware:manage') LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, device_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    RAISE NOTICE 'Firmware permissions assigned';
+END $$;
+
+-- 7. Verification
+SELECT id, menu_id, code, title FROM power WHERE code LIKE 'firmware:%';
@@ -0,0 +1,15 @@
+-- ============================================
+-- Revert Bulk Device Provisioning & One-Time Claim Codes
+-- 尚未兌換的認領碼會一併刪除
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code IN ('device:provision', 'company:claim_devices'));
+DELETE FROM power WHERE code IN ('device:provision', 'company:claim_devices');
+
+-- 2. Claim codes
+DROP TABLE IF EXISTS device_claim_codes;
+
+-- 3. Device details
+ALTER TABLE device DROP COLUMN IF EXISTS notes;
+ALTER TABLE device DROP COLUMN IF EXISTS model;
@@ -0,0 +1,108 @@
+-- ============================================
+-- Bulk Device Provisioning & One-Time Claim Codes
+-- ============================================
+--
+-- device.model / device.notes: CSV 匯入 (sn, model, notes) 時一併保存
+-- POST /devices/import 接受 multipart 欄位 file 或 text/csv 主體，返回逐列驗證報告：
+--   ?dry_run=true 只驗證；?issue_claim_codes=true 為新建設備簽發認領碼
+-- device_claim_codes: 一次性認領碼，只保存 SHA-256 與末四碼提示，明文僅在簽發時返回一次
+--   重新簽發會撤銷同一設備仍有效的認領碼；逾期由 CLAIM_CODE_EXPIRY_INTERVAL 掃描標記為 expired
+-- POST /companies/:id/devices/claim 由公司管理者兌換，將設備綁定到自己可存取的公司
+-- 簽發 (ISSUE_CLAIM_CODE / IMPORT)、兌換 (REDEEM_CLAIM_CODE) 與過期 (EXPIRE_CLAIM_CODE) 皆寫入審計日誌
+--
+-- 權限說明:
+-- device:provision      - CSV 批次匯入設備、簽發與查看認領碼
+-- company:claim_devices - 以認領碼將設備綁定到公司
+--
+
+-- 1. Device details
+ALTER TABLE device ADD COLUMN IF NOT EXISTS model VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE device ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+
+-- 2. Claim codes
+CREATE TABLE IF NOT EXISTS device_claim_codes (
+    id SERIAL PRIMARY KEY,
+    device_id INTEGER NOT NULL REFERENCES device(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    code_hint VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL, -- active, redeemed, expired, revoked
+    expires_at TIMESTAMP NOT NULL,
+    issued_by INTEGER NOT NULL,
+    issued_role_id INTEGER NOT NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    redeemed_by INTEGER,
+    redeemed_at TIMESTAMP,
+    company_id INTEGER REFERENCES company(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_claim_codes_hash ON device_claim_codes(code_hash);
+CREATE INDEX IF NOT EXISTS idx_device_claim_codes_device ON device_claim_codes(device_id);
+CREATE INDEX IF NOT EXISTS idx_device_claim_codes_active ON device_claim_codes(expires_at) WHERE status = 'active';
+
+COMMENT ON TABLE device_claim_codes IS 'One-time codes that let a company manager attach an unassigned device to their company';
+COMMENT ON COLUMN device_claim_codes.code_hash IS 'SHA-256 of the normalized code; plaintext is never stored';
+
+-- 3. Permissions
+DO $$
+DECLARE
+    device_menu_id INT;
+    company_menu_id INT;
+BEGIN
+    SELECT id INTO device_menu_id FROM menu WHERE url = '/setting/device' LIMIT 1;
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+
+    IF device_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (device_menu_id, '設備建檔', 'device:provision', 'CSV 批次匯入設備並簽發一次性認領碼', 12, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    ELSE
+        RAISE NOTICE 'Device menu not found, skipping device:provision';
+    END IF;
+
+    IF company_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (company_menu_id, '認領設備', 'company:claim_devices', '以認領碼將設備綁定到公司', 26, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    ELSE
+        RAISE NOTICE 'Company menu not found, skipping company:claim_devices';
+    END IF;
+
+    RAISE NOTICE 'Device provisioning permissions created';
+END $$;
+
+-- 4. Assign: device:provision to SystemAdmin (role_id=1); company:claim_devices to SystemAdmin and company_manager
+DO $$
+DECLARE
+    device_menu_id INT;
+    company_menu_id INT;
+    manager_role_id INT;
+    power_rec RECORD;
+BEGIN
+    SELECT id INTO device_menu_id FROM menu WHERE url = '/setting/device' LIMIT 1;
+    SELECT id INTO company_menu_id FROM menu WHERE title = '公司管理' LIMIT 1;
+    SELECT id INTO manager_role_id FROM role WHERE title = 'company_manager' LIMIT 1;
+
+    FOR power_rec IN SELECT id FROM power WHERE code = 'device:provision' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, device_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    FOR power_rec IN SELECT id FROM power WHERE code = 'company:claim_devices' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        IF manager_role_id IS NOT NULL THEN
+            INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+            VALUES (manager_role_id, company_menu_id, power_rec.id, 1, NOW(), 1, NOW())
+            ON CONFLICT DO NOTHING;
+        END IF;
+    END LOOP;
+
+    RAISE NOTICE 'Device provisioning permissions assigned';
+END $$;
+
+-- 5. Verification
+SELECT id, menu_id, code, title FROM power WHERE code IN ('device:provision', 'company:claim_devices');
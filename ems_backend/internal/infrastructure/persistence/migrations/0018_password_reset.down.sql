@@ -0,0 +1,11 @@
+-- ============================================
+-- Revert Self-Service Password Reset
+-- forgot_temp 由 0001_base_schema 建立，此處只移除索引與說明；已清除的明文 code 無法復原
+-- ============================================
+
+DROP INDEX IF EXISTS idx_forgot_temp_expire;
+DROP INDEX IF EXISTS idx_forgot_temp_member;
+DROP INDEX IF EXISTS idx_forgot_temp_code;
+
+COMMENT ON COLUMN forgot_temp.code IS NULL;
+COMMENT ON COLUMN forgot_temp.redirect_path IS NULL;
@@ -0,0 +1,38 @@
+-- ============================================
+-- Self-Service Password Reset
+-- ============================================
+--
+-- POST /auth/forgot {email, redirect_path?}: 簽發一次性重設連結並以 MAIL_DRIVER 寄出
+--   不論帳號是否存在都回應相同訊息；同一會員重新申請會作廢先前的連結
+-- POST /auth/reset {token, password}: 驗證連結後寫入新的 member_history (argon2)，
+--   並撤銷該會員所有 access_token 會話，返回 redirect_path
+-- forgot_temp.code 只保存 token 的 SHA-256 (hex)，明文僅出現在郵件連結中；使用後即刪除
+-- 有效期限由 PASSWORD_RESET_TTL 設定 (預設 30m)
+--
+-- 權限說明:
+-- 兩個端點皆為公開端點，不需要權限
+--
+
+-- 1. forgot_temp (0001_base_schema 已建立，此處確保存在並補上索引)
+CREATE TABLE IF NOT EXISTS public.forgot_temp (
+    id bigserial NOT NULL,
+    member_id int8 NOT NULL,
+    expire_time timestamp NOT NULL,
+    code varchar(128) NOT NULL,
+    redirect_path varchar(512) NULL,
+    CONSTRAINT pk_forgot_temp PRIMARY KEY (id),
+    CONSTRAINT fk_forgot_temp_member_id FOREIGN KEY (member_id) REFERENCES public.member(id)
+);
+
+-- 舊資料的 code 可能為明文，直接清除
+DELETE FROM forgot_temp WHERE length(code) <> 64;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_forgot_temp_code ON forgot_temp(code);
+CREATE INDEX IF NOT EXISTS idx_forgot_temp_member ON forgot_temp(member_id);
+CREATE INDEX IF NOT EXISTS idx_forgot_temp_expire ON forgot_temp(expire_time);
+
+COMMENT ON COLUMN forgot_temp.code IS '重設 token 的 SHA-256 (hex)';
+COMMENT ON COLUMN forgot_temp.redirect_path IS '重設完成後前端導向的站內路徑';
+
+-- 2. Verification
+SELECT COUNT(*) AS pending_reset_tokens FROM forgot_temp WHERE expire_time > NOW();
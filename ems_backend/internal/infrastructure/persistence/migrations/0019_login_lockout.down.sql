@@ -0,0 +1,16 @@
+-- ============================================
+-- Revert Login Throttling & Account Lockout
+-- 目前被鎖定的帳號會在移除欄位後恢復可登入
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'member:unlock');
+DELETE FROM power WHERE code = 'member:unlock';
+
+-- 2. login_attempts
+DROP TABLE IF EXISTS login_attempts;
+
+-- 3. member 鎖定欄位
+DROP INDEX IF EXISTS idx_member_locked_until;
+ALTER TABLE member DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE member DROP COLUMN IF EXISTS locked_at;
@@ -0,0 +1,81 @@
+-- ============================================
+-- Login Throttling & Account Lockout
+-- ============================================
+--
+-- POST /auth/login 依 email 與來源 IP 統計連續失敗 (login_attempts)：
+--   超過免等待次數 (LOGIN_FREE_ATTEMPTS / LOGIN_IP_FREE_ATTEMPTS) 後，
+--   等待時間自 LOGIN_BASE_DELAY 起每次加倍，最多 LOGIN_MAX_DELAY，期間回應 429 + Retry-After
+--   最後一次失敗超過 LOGIN_FAILURE_WINDOW 後重新計算
+-- 帳號連續密碼錯誤 (member_history.error_count) 達 LOGIN_LOCKOUT_THRESHOLD 次即鎖定，回應 423
+--   locked_until 為 NULL 表示需管理員解鎖；到期後由背景掃描自動解鎖 (LOGIN_LOCKOUT_SWEEP_INTERVAL)
+-- POST /api/members/:id/unlock: 管理員解除鎖定並清除失敗次數
+-- 登入失敗 (LOGIN)、鎖定 (LOCK_ACCOUNT)、解鎖 (UNLOCK_ACCOUNT) 皆寫入 audit_log
+--
+-- 權限說明:
+-- member:unlock - 解除用戶登入鎖定
+--
+
+-- 1. member 鎖定欄位
+ALTER TABLE member ADD COLUMN IF NOT EXISTS locked_at timestamp NULL;
+ALTER TABLE member ADD COLUMN IF NOT EXISTS locked_until timestamp NULL;
+
+CREATE INDEX IF NOT EXISTS idx_member_locked_until ON member(locked_until) WHERE locked_at IS NOT NULL;
+
+COMMENT ON COLUMN member.locked_at IS '因連續登入失敗被鎖定的時間，NULL 表示未鎖定';
+COMMENT ON COLUMN member.locked_until IS '自動解鎖時間，NULL 表示需管理員解鎖';
+
+-- 2. login_attempts
+CREATE TABLE IF NOT EXISTS public.login_attempts (
+    scope varchar(16) NOT NULL,
+    attempt_key varchar(255) NOT NULL,
+    failures int4 NOT NULL DEFAULT 0,
+    last_failure_at timestamp NOT NULL,
+    CONSTRAINT pk_login_attempts PRIMARY KEY (scope, attempt_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts(last_failure_at);
+
+COMMENT ON TABLE login_attempts IS '登入失敗統計 (節流用)';
+COMMENT ON COLUMN login_attempts.scope IS 'email 或 ip';
+COMMENT ON COLUMN login_attempts.attempt_key IS '小寫 email 或來源 IP';
+
+-- 3. Permissions
+DO $$
+DECLARE
+    member_menu_id INT;
+BEGIN
+    SELECT menu_id INTO member_menu_id FROM power WHERE code = 'member:update_status' LIMIT 1;
+    IF member_menu_id IS NULL THEN
+        SELECT id INTO member_menu_id FROM menu
+        WHERE url LIKE '%user%' OR title LIKE '%用戶%' OR title LIKE '%成員%'
+        ORDER BY id LIMIT 1;
+    END IF;
+
+    IF member_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (member_menu_id, '解除鎖定', 'member:unlock', '解除用戶登入鎖定', 5, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    ELSE
+        RAISE NOTICE 'Member menu not found, skipping member:unlock';
+    END IF;
+
+    RAISE NOTICE 'Login lockout permissions created';
+END $$;
+
+-- 4. Assign member:unlock to SystemAdmin (role_id=1)
+DO $$
+DECLARE
+    power_rec RECORD;
+BEGIN
+    FOR power_rec IN SELECT id, menu_id FROM power WHERE code = 'member:unlock' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, power_rec.menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    RAISE NOTICE 'Login lockout permissions assigned';
+END $$;
+
+-- 5. Verification
+SELECT id, menu_id, code, title FROM power WHERE code = 'member:unlock';
+SELECT COUNT(*) AS locked_members FROM member WHERE locked_at IS NOT NULL;
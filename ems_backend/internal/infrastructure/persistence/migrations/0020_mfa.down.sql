@@ -0,0 +1,16 @@
+-- ============================================
+-- Revert TOTP Multi-Factor Authentication
+-- 已設定的 TOTP 密鑰與復原碼會一併刪除，回復後成員只需密碼即可登入
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'member:reset_mfa');
+DELETE FROM power WHERE code = 'member:reset_mfa';
+
+-- 2. Tables
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS member_mfa;
+
+-- 3. 角色 MFA 政策
+ALTER TABLE role DROP COLUMN IF EXISTS mfa_required;
@@ -0,0 +1,116 @@
+-- ============================================
+-- TOTP Multi-Factor Authentication
+-- ============================================
+--
+-- 兩階段登入:
+--   POST /auth/login 密碼正確且 (已啟用 MFA 或任一角色 mfa_required) 時不簽發 token，
+--     改返回 mfa_challenge_token (MFA_CHALLENGE_TTL，預設 5m，最多輸入錯誤 5 次)
+--   POST /auth/mfa/verify {mfa_challenge_token, code}: code 為 6 位數 TOTP 或復原碼，成功後簽發 token
+--   POST /auth/mfa/enroll {mfa_challenge_token}: 角色要求但尚未設定時取得密鑰，
+--     接著以 /auth/mfa/verify 確認，回應中附帶復原碼
+-- 自助設定 (需登入):
+--   GET /mfa、POST /mfa/enroll (secret + otpauth:// URI，由前端轉成 QR Code)、
+--   POST /mfa/activate {code}、POST /mfa/recovery-codes {code}、DELETE /mfa {code}
+-- 角色政策: role.mfa_required = true 時該角色成員必須使用 MFA，且不可自行停用
+-- 密鑰在設定 MFA_ENCRYPTION_KEY 時以 AES-GCM 加密保存；復原碼與挑戰憑證只保存 SHA-256
+-- MFA 驗證碼錯誤與密碼錯誤一同累計到帳號鎖定門檻 (migration 0019_login_lockout)
+--
+-- 權限說明:
+-- member:reset_mfa - 清除成員的 MFA 設定 (DELETE /members/:id/mfa，遺失驗證器時使用)
+-- 角色的 mfa_required 透過 PUT /roles/:id 設定 (role:update)
+--
+
+-- 1. 角色 MFA 政策
+ALTER TABLE role ADD COLUMN IF NOT EXISTS mfa_required boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN role.mfa_required IS '此角色的成員必須啟用 TOTP MFA';
+
+-- 2. member_mfa
+CREATE TABLE IF NOT EXISTS public.member_mfa (
+    member_id int8 NOT NULL,
+    secret varchar(255) NOT NULL,
+    enabled boolean NOT NULL DEFAULT false,
+    enabled_at timestamp NULL,
+    last_used_step int8 NOT NULL DEFAULT 0,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_member_mfa PRIMARY KEY (member_id),
+    CONSTRAINT fk_member_mfa_member_id FOREIGN KEY (member_id) REFERENCES public.member(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE member_mfa IS '會員 TOTP 設定 (enabled = false 表示尚未以驗證碼確認)';
+COMMENT ON COLUMN member_mfa.secret IS 'base32 密鑰；設定 MFA_ENCRYPTION_KEY 時為 enc: 開頭的密文';
+COMMENT ON COLUMN member_mfa.last_used_step IS '最後一次通過驗證的 30 秒時間步，防止驗證碼重放';
+
+-- 3. mfa_recovery_codes
+CREATE TABLE IF NOT EXISTS public.mfa_recovery_codes (
+    id bigserial NOT NULL,
+    member_id int8 NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    used_at timestamp NULL,
+    CONSTRAINT pk_mfa_recovery_codes PRIMARY KEY (id),
+    CONSTRAINT fk_mfa_recovery_codes_member_id FOREIGN KEY (member_id) REFERENCES public.member(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_member ON mfa_recovery_codes(member_id);
+
+COMMENT ON COLUMN mfa_recovery_codes.code_hash IS '復原碼 (去除連字號、小寫) 的 SHA-256 (hex)';
+
+-- 4. mfa_challenges
+CREATE TABLE IF NOT EXISTS public.mfa_challenges (
+    id bigserial NOT NULL,
+    member_id int8 NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    attempts int4 NOT NULL DEFAULT 0,
+    expires_at timestamp NOT NULL,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_mfa_challenges PRIMARY KEY (id),
+    CONSTRAINT fk_mfa_challenges_member_id FOREIGN KEY (member_id) REFERENCES public.member(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mfa_challenges_token ON mfa_challenges(token_hash);
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_member ON mfa_challenges(member_id);
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_expires ON mfa_challenges(expires_at);
+
+-- 5. Permissions
+DO $$
+DECLARE
+    member_menu_id INT;
+BEGIN
+    SELECT menu_id INTO member_menu_id FROM power WHERE code = 'member:update_status' LIMIT 1;
+    IF member_menu_id IS NULL THEN
+        SELECT id INTO member_menu_id FROM menu
+        WHERE url LIKE '%user%' OR title LIKE '%用戶%' OR title LIKE '%成員%'
+        ORDER BY id LIMIT 1;
+    END IF;
+
+    IF member_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (member_menu_id, '重設 MFA', 'member:reset_mfa', '清除用戶的多因素驗證設定', 6, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    ELSE
+        RAISE NOTICE 'Member menu not found, skipping member:reset_mfa';
+    END IF;
+
+    RAISE NOTICE 'MFA permissions created';
+END $$;
+
+-- 6. Assign member:reset_mfa to SystemAdmin (role_id=1)
+DO $$
+DECLARE
+    power_rec RECORD;
+BEGIN
+    FOR power_rec IN SELECT id, menu_id FROM power WHERE code = 'member:reset_mfa' LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, power_rec.menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    RAISE NOTICE 'MFA permissions assigned';
+END $$;
+
+-- 要求管理角色使用 MFA (成員下次登入時會被要求設定):
+-- UPDATE role SET mfa_required = true WHERE id = 1;
+
+-- 7. Verification
+SELECT id, menu_id, code, title FROM power WHERE code = 'member:reset_mfa';
+SELECT id, title, mfa_required FROM role ORDER BY id;
@@ -0,0 +1,12 @@
+-- ============================================
+-- Revert Service Accounts & API Keys
+-- 所有 API Key 立即失效；服務帳號的 member 與 company_member 紀錄保留 (無密碼，無法登入)
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code IN ('service_account:read', 'service_account:manage'));
+DELETE FROM power WHERE code IN ('service_account:read', 'service_account:manage');
+
+-- 2. Tables
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS service_accounts;
@@ -0,0 +1,115 @@
+-- ============================================
+-- Service Accounts & API Keys
+-- ============================================
+--
+-- 服務帳號供 BI 與外部整合使用，取代從瀏覽器複製的個人 JWT:
+--   服務帳號是一筆 member (無密碼，無法登入)，另在 service_accounts 記錄說明與綁定公司
+--   API Key 綁定一個角色，呼叫時以 header 傳入:  X-API-Key: ems_<prefix>_<secret>
+--   AuthMiddleware 以 Key 的角色作為 current_role_id (忽略 X-Role-ID)，
+--     權限檢查與公司存取範圍與一般會員相同:
+--       company_scope = subtree → 綁定公司及其子公司
+--       company_scope = own     → 僅綁定公司
+--     綁定公司的服務帳號不可使用 company_scope = all 的角色 (migration 0025_company_access_scope)
+--   Key 只保存 SHA-256，明文僅在建立時返回一次；prefix 可公開顯示用以辨識
+--   未指定 expires_at 時以 API_KEY_MAX_TTL (預設 8760h) 為到期時間，也不可超過此期限
+--   last_used_at / last_used_ip 最多每分鐘更新一次
+--   以 API Key 呼叫的操作會在審計日誌 details 中記錄 api_key_id
+--
+-- API:
+--   GET    /service-accounts                      列出服務帳號
+--   POST   /service-accounts                      {name, description, company_id}
+--   DELETE /service-accounts/:id                  停用服務帳號並撤銷所有 Key
+--   GET    /service-accounts/:id/keys             列出 Key (不含明文)
+--   POST   /service-accounts/:id/keys             {name, role_id, expires_at}
+--   DELETE /service-accounts/:id/keys/:keyId      撤銷 Key
+--
+-- 權限說明:
+-- service_account:read   - 查看服務帳號與 API Key
+-- service_account:manage - 建立/停用服務帳號，建立/撤銷 API Key
+--
+
+-- 1. service_accounts
+CREATE TABLE IF NOT EXISTS public.service_accounts (
+    member_id int8 NOT NULL,
+    description varchar(255) NULL,
+    company_id int8 NULL,
+    created_by int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_service_accounts PRIMARY KEY (member_id),
+    CONSTRAINT fk_service_accounts_member_id FOREIGN KEY (member_id) REFERENCES public.member(id) ON DELETE CASCADE,
+    CONSTRAINT fk_service_accounts_company_id FOREIGN KEY (company_id) REFERENCES public.company(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_accounts_company ON service_accounts(company_id);
+
+COMMENT ON TABLE service_accounts IS '服務帳號 (名稱、email 與啟用狀態保存在 member)';
+COMMENT ON COLUMN service_accounts.company_id IS '綁定的公司 (同時寫入 company_member)；存取範圍依 API Key 的角色';
+
+-- 2. api_keys
+CREATE TABLE IF NOT EXISTS public.api_keys (
+    id bigserial NOT NULL,
+    service_account_id int8 NOT NULL,
+    role_id int8 NOT NULL,
+    name varchar(128) NOT NULL,
+    prefix varchar(16) NOT NULL,
+    key_hash varchar(64) NOT NULL,
+    expires_at timestamp NULL,
+    last_used_at timestamp NULL,
+    last_used_ip varchar(64) NULL,
+    revoked_at timestamp NULL,
+    created_by int8 NOT NULL,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_api_keys PRIMARY KEY (id),
+    CONSTRAINT fk_api_keys_service_account_id FOREIGN KEY (service_account_id) REFERENCES public.service_accounts(member_id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_keys_role_id FOREIGN KEY (role_id) REFERENCES public.role(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_service_account ON api_keys(service_account_id);
+
+COMMENT ON TABLE api_keys IS '服務帳號 API Key (只保存雜湊)';
+COMMENT ON COLUMN api_keys.prefix IS 'Key 明文中的查找前綴 (8 位 hex)';
+COMMENT ON COLUMN api_keys.key_hash IS '完整 Key 明文的 SHA-256 (hex)';
+COMMENT ON COLUMN api_keys.role_id IS '使用此 Key 時的角色 (current_role_id)';
+
+-- 3. Permissions
+DO $$
+DECLARE
+    member_menu_id INT;
+BEGIN
+    SELECT menu_id INTO member_menu_id FROM power WHERE code = 'member:update_status' LIMIT 1;
+    IF member_menu_id IS NULL THEN
+        SELECT id INTO member_menu_id FROM menu
+        WHERE url LIKE '%user%' OR title LIKE '%用戶%' OR title LIKE '%成員%'
+        ORDER BY id LIMIT 1;
+    END IF;
+
+    IF member_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES
+            (member_menu_id, '查看服務帳號', 'service_account:read', '查看服務帳號與 API Key', 7, true, 1, NOW(), 1, NOW()),
+            (member_menu_id, '管理服務帳號', 'service_account:manage', '建立/停用服務帳號，建立/撤銷 API Key', 8, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    ELSE
+        RAISE NOTICE 'Member menu not found, skipping service account permissions';
+    END IF;
+
+    RAISE NOTICE 'Service account permissions created';
+END $$;
+
+-- 4. Assign service account permissions to SystemAdmin (role_id=1)
+DO $$
+DECLARE
+    power_rec RECORD;
+BEGIN
+    FOR power_rec IN SELECT id, menu_id FROM power WHERE code IN ('service_account:read', 'service_account:manage') LOOP
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        VALUES (1, power_rec.menu_id, power_rec.id, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    RAISE NOTICE 'Service account permissions assigned';
+END $$;
+
+-- 5. Verification
+SELECT id, menu_id, code, title FROM power WHERE code LIKE 'service_account:%';
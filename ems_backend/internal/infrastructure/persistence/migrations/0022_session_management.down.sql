@@ -0,0 +1,20 @@
+-- ============================================
+-- Revert Server-side Sessions & Token Revocation
+-- 帶有 sid 的 token 可能超過 256 字元，先刪除這些會話 (相關會員需重新登入)
+-- ============================================
+
+-- 1. 會話索引與欄位
+DROP INDEX IF EXISTS idx_access_token_revoked_at;
+DROP INDEX IF EXISTS idx_access_token_member_id;
+DROP INDEX IF EXISTS idx_access_token_session_id;
+
+ALTER TABLE access_token DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE access_token DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE access_token DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE access_token DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE access_token DROP COLUMN IF EXISTS session_id;
+
+-- 2. token 長度
+DELETE FROM access_token WHERE length(access_token) > 256 OR length(refresh_token) > 256;
+ALTER TABLE access_token ALTER COLUMN access_token TYPE varchar(256);
+ALTER TABLE access_token ALTER COLUMN refresh_token TYPE varchar(256);
@@ -0,0 +1,44 @@
+-- ============================================
+-- Server-side Sessions & Token Revocation
+-- ============================================
+--
+-- 每次登入建立一筆 access_token 會話，session_id 以 sid claim 寫入 access / refresh token:
+--   ValidateToken 拒絕不帶 sid 或 sid 已撤銷的 access token (401)，不需等到 token 過期
+--   已撤銷的 sid 保存在記憶體，每 SESSION_REVOCATION_SYNC_INTERVAL (預設 5s) 從資料庫同步一次，
+--     本實例的撤銷立即生效，其他實例 (或重設密碼) 的撤銷在下一次同步後生效
+--   撤銷只設定 revoked_at，保留的紀錄每 SESSION_PURGE_INTERVAL (預設 1h) 清除超過 24 小時者
+--   登出、撤銷會話、停用會員、重設密碼都會撤銷會話；停用的會員不可登入 (403)
+--   升級前簽發的 token 不帶 sid，需重新登入
+--
+-- API (登入者本人):
+--   GET    /auth/sessions                         列出有效會話 (IP、User-Agent、建立時間、current)
+--   DELETE /auth/sessions/:sessionId              撤銷單一會話
+--   DELETE /auth/sessions?keep_current=true       撤銷所有會話 (可保留目前會話)
+--
+
+-- 1. access_token 會話欄位
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS session_id varchar(64) NULL;
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS ip_address varchar(64) NULL;
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS user_agent varchar(512) NULL;
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS expires_at timestamp NULL;
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS revoked_at timestamp NULL;
+
+-- token 帶有 sid 後長度可能超過 256
+ALTER TABLE access_token ALTER COLUMN access_token TYPE varchar(1024);
+ALTER TABLE access_token ALTER COLUMN refresh_token TYPE varchar(1024);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_token_session_id ON access_token(session_id) WHERE session_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_access_token_member_id ON access_token(member_id);
+CREATE INDEX IF NOT EXISTS idx_access_token_revoked_at ON access_token(revoked_at) WHERE revoked_at IS NOT NULL;
+
+COMMENT ON COLUMN access_token.session_id IS '會話 ID (JWT sid claim)';
+COMMENT ON COLUMN access_token.ip_address IS '登入時的來源 IP';
+COMMENT ON COLUMN access_token.user_agent IS '登入時的 User-Agent';
+COMMENT ON COLUMN access_token.expires_at IS 'refresh token 到期時間';
+COMMENT ON COLUMN access_token.revoked_at IS '撤銷時間，NULL 表示有效';
+
+-- 2. Verification
+SELECT column_name, data_type, character_maximum_length
+FROM information_schema.columns
+WHERE table_name = 'access_token'
+ORDER BY ordinal_position;
@@ -0,0 +1,12 @@
+-- ============================================
+-- Revert Password Policy & Argon2 Parameter Upgrade
+-- 舊版只以固定參數 (m=65536,t=1,p=4,l=32) 驗證；hash_params 不是空字串也不是這組參數的會員
+-- 回復後需以忘記密碼重新設定
+-- ============================================
+
+SELECT DISTINCT member_id AS members_need_reset
+FROM member_history
+WHERE hash_params NOT IN ('', 'm=65536,t=1,p=4,l=32');
+
+DROP INDEX IF EXISTS idx_member_history_member_create_time;
+ALTER TABLE member_history DROP COLUMN IF EXISTS hash_params;
@@ -0,0 +1,30 @@
+-- ============================================
+-- Password Policy & Argon2 Parameter Upgrade
+-- ============================================
+--
+-- 建立成員、公司管理員 / 用戶、重設密碼與變更密碼都套用同一組密碼政策:
+--   PASSWORD_MIN_LENGTH (預設 8)、PASSWORD_REQUIRE_UPPER / LOWER / DIGIT (預設 true) / SYMBOL (預設 false)
+--   內建常見密碼清單，可再以 PASSWORD_COMMON_LIST_FILE 指定外洩密碼清單 (每行一組，不分大小寫)
+--   不可包含帳號名稱或 email 帳號
+--   不可與最近 PASSWORD_HISTORY_COUNT (預設 5) 組密碼相同，以既有的 member_history 紀錄比對
+--   PASSWORD_MAX_AGE (預設 0 不限制) 過期後仍可登入，登入回應帶 password_expired: true
+--
+-- member_history.hash_params 記錄每組雜湊的 argon2id 參數 (m=<KiB>,t=<次數>,p=<執行緒>,l=<長度>)
+--   空字串為升級前的舊雜湊 (m=65536,t=1,p=4,l=32)
+--   PASSWORD_ARGON2_TIME / PASSWORD_ARGON2_MEMORY_KB / PASSWORD_ARGON2_THREADS 調整後，
+--   舊參數的雜湊會在下次登入成功時以新參數重新計算
+--
+-- API:
+--   GET /auth/password-policy      目前的密碼政策 (不需登入)
+--   PUT /auth/password             {current_password, new_password} 變更自己的密碼，撤銷其他會話
+--
+
+-- 1. member_history 雜湊參數
+ALTER TABLE member_history ADD COLUMN IF NOT EXISTS hash_params varchar(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_member_history_member_create_time ON member_history(member_id, create_time DESC);
+
+COMMENT ON COLUMN member_history.hash_params IS 'argon2id 參數 (m=,t=,p=,l=)，空字串為舊雜湊';
+
+-- 2. Verification
+SELECT hash_params, COUNT(*) AS hashes FROM member_history GROUP BY hash_params;
@@ -0,0 +1,7 @@
+-- ============================================
+-- Revert OpenID Connect Single Sign-On
+-- 外部身分連結會一併刪除；自動建立的會員保留 (沒有本地密碼，可經由忘記密碼設定)
+-- ============================================
+
+DROP TABLE IF EXISTS member_identities;
+DROP TABLE IF EXISTS oidc_login_states;
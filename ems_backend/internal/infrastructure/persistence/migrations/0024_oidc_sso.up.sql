@@ -0,0 +1,67 @@
+-- ============================================
+-- OpenID Connect Single Sign-On
+-- ============================================
+--
+-- 授權碼流程 (PKCE S256):
+--   GET  /auth/oidc/providers                             可用的身分提供者 [{id, name}]
+--   GET  /auth/oidc/:provider/authorize?redirect_path=/x  返回 authorization_url，前端整頁導向
+--   身分提供者導回 redirect_url (前端頁面) 並附上 code 與 state
+--   POST /auth/oidc/:provider/callback {code, state}      驗證 ID token (簽章、iss、aud、exp、nonce) 後簽發 token，
+--     回應與 /auth/login 相同並附帶 redirect_path
+-- state、nonce 與 code_verifier 保存在 oidc_login_states (state 只存 SHA-256)，OIDC_STATE_TTL (預設 10m) 內只能使用一次
+-- 多因素驗證由身分提供者負責，單一登入不再要求本地 TOTP；停用或鎖定的帳號仍無法登入
+--
+-- 身分提供者設定 OIDC_PROVIDERS_FILE (JSON 陣列):
+--   id, name, issuer, client_id, client_secret (可用 ${ENV})、redirect_url, scopes
+--   auto_provision    找不到連結時自動建立會員 (沒有本地密碼，可經由忘記密碼設定)
+--   link_by_email     以 email_verified 的 email 連結既有會員
+--   allowed_domains   email 網域白名單
+--   default_role_ids / role_mappings / company_mappings [{claim, value, id}]:
+--     宣告 (字串或陣列，例如 groups) 等於 value 時給予角色 / 加入公司
+--   sync_roles / sync_companies  每次登入移除不再對應的角色 / 公司；宣告不對應任何角色時拒絕登入
+-- 本機測試可使用 go run ./cmd/mockidp (預設使用者 alice / bob)
+--
+
+-- 1. oidc_login_states
+CREATE TABLE IF NOT EXISTS public.oidc_login_states (
+    id bigserial NOT NULL,
+    state_hash varchar(64) NOT NULL,
+    provider varchar(64) NOT NULL,
+    nonce varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    redirect_path varchar(512) NOT NULL DEFAULT '',
+    expires_at timestamp NOT NULL,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_oidc_login_states PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_login_states_state ON oidc_login_states(state_hash);
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires ON oidc_login_states(expires_at);
+
+COMMENT ON TABLE oidc_login_states IS '單一登入進行中的授權碼流程，回呼時刪除';
+COMMENT ON COLUMN oidc_login_states.state_hash IS 'state 的 SHA-256 (hex)';
+
+-- 2. member_identities
+CREATE TABLE IF NOT EXISTS public.member_identities (
+    id bigserial NOT NULL,
+    provider varchar(64) NOT NULL,
+    subject varchar(255) NOT NULL,
+    member_id int8 NOT NULL,
+    email varchar(255) NOT NULL DEFAULT '',
+    create_time timestamp NOT NULL,
+    last_login_at timestamp NOT NULL,
+    CONSTRAINT pk_member_identities PRIMARY KEY (id),
+    CONSTRAINT fk_member_identities_member_id FOREIGN KEY (member_id) REFERENCES public.member(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_member_identities_provider_subject ON member_identities(provider, subject);
+CREATE INDEX IF NOT EXISTS idx_member_identities_member ON member_identities(member_id);
+
+COMMENT ON TABLE member_identities IS '外部身分 (身分提供者 + sub) 與會員的連結';
+COMMENT ON COLUMN member_identities.email IS '最後一次登入時身分提供者返回的 email';
+
+-- 3. Verification
+SELECT table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_name IN ('oidc_login_states', 'member_identities')
+ORDER BY table_name, ordinal_position;
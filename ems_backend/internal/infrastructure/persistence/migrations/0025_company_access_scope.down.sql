@@ -0,0 +1,8 @@
+-- ============================================
+-- Revert Role-based Company Access Scope
+-- 舊版依角色名稱 (SystemAdmin / company_manager / company_user) 判斷公司可見範圍，
+-- 其他角色自訂的 company_scope 會遺失
+-- ============================================
+
+ALTER TABLE role DROP CONSTRAINT IF EXISTS ck_role_company_scope;
+ALTER TABLE role DROP COLUMN IF EXISTS company_scope;
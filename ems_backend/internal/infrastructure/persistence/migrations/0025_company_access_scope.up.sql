@@ -0,0 +1,38 @@
+-- ============================================
+-- Role-based Company Access Scope
+-- ============================================
+--
+-- 公司可見範圍改由角色屬性 role.company_scope 決定，不再比對角色名稱:
+--   all      所有公司
+--   subtree  成員所屬的公司及其所有子公司 (經過停用的公司時不再往下延伸)
+--   own      只有成員所屬的公司 (company_member)
+--   none     看不到任何公司 (新角色的預設值；停用的角色一律視為 none)
+-- 公司列表、儀表板與所有 /companies/:id/... 路由共用同一套判斷:
+--   RequireCompanyAccess 中間件先載入 :id 公司，不存在返回 404，不在範圍內返回 403
+-- 角色的 company_scope 透過 POST /roles、PUT /roles/:id 設定 (role:create / role:update)，
+--   PUT 未帶 company_scope 時保留原值
+-- 綁定公司的服務帳號不可使用 company_scope = all 的角色
+--
+
+-- 1. role.company_scope
+ALTER TABLE role ADD COLUMN IF NOT EXISTS company_scope varchar(16) NOT NULL DEFAULT 'none';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'ck_role_company_scope') THEN
+        ALTER TABLE role ADD CONSTRAINT ck_role_company_scope
+            CHECK (company_scope IN ('none', 'own', 'subtree', 'all'));
+    END IF;
+END $$;
+
+COMMENT ON COLUMN role.company_scope IS '公司存取範圍: none / own / subtree / all';
+
+-- 2. 既有角色沿用原本以名稱判斷的範圍 (只更新仍為預設值的角色)
+UPDATE role SET company_scope = 'all'     WHERE title = 'SystemAdmin'     AND company_scope = 'none';
+UPDATE role SET company_scope = 'subtree' WHERE title = 'company_manager' AND company_scope = 'none';
+UPDATE role SET company_scope = 'own'     WHERE title = 'company_user'    AND company_scope = 'none';
+
+-- 3. Verification
+SELECT id, title, is_enable, company_scope
+FROM role
+ORDER BY sort NULLS LAST, id;
@@ -0,0 +1,5 @@
+-- ============================================
+-- Revert Permission Cache Invalidation
+-- ============================================
+
+DROP TABLE IF EXISTS cache_invalidations;
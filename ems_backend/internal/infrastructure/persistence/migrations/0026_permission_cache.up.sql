@@ -0,0 +1,38 @@
+-- ============================================
+-- Permission Cache Invalidation
+-- ============================================
+--
+-- PermissionMiddleware 的權限檢查與 AuthMiddleware 的成員角色改查記憶體快取:
+--   角色權限集合 (role_id -> 權限代碼) 與成員角色列表 (member_id -> 角色) 最多保留 PERMISSION_CACHE_TTL (預設 5m)
+-- 下列異動在本實例立即失效，並寫入 cache_invalidations 供其他實例輪詢
+-- (PERMISSION_CACHE_SYNC_INTERVAL，預設 5s):
+--   POST/DELETE /roles/:id/powers            → 該角色的權限集合 (kind = role)
+--   POST/DELETE /roles/:id/members、成員角色變更、單一登入角色同步、服務帳號 API Key
+--                                            → 相關成員的角色列表 (kind = member)
+--   權限新增 / 更新 / 刪除                   → 所有角色的權限集合 (kind = role, target_id = 0)
+--   角色更新 / 刪除                          → 所有成員的角色列表 (kind = member, target_id = 0)
+-- 直接修改資料庫的權限或角色時，最晚在 PERMISSION_CACHE_TTL 後生效，或手動寫入失效事件:
+--   INSERT INTO cache_invalidations (kind, target_id, create_time) VALUES ('role', 0, now());
+-- 事件保留一小時後由各實例清除
+--
+
+-- 1. cache_invalidations
+CREATE TABLE IF NOT EXISTS public.cache_invalidations (
+    id bigserial NOT NULL,
+    kind varchar(16) NOT NULL,
+    target_id int8 NOT NULL DEFAULT 0,
+    create_time timestamp NOT NULL,
+    CONSTRAINT pk_cache_invalidations PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cache_invalidations_create_time ON cache_invalidations(create_time);
+
+COMMENT ON TABLE cache_invalidations IS '權限快取失效事件，供多個後端實例同步';
+COMMENT ON COLUMN cache_invalidations.kind IS 'role: 角色權限集合 / member: 成員角色列表';
+COMMENT ON COLUMN cache_invalidations.target_id IS '角色或成員 ID，0 表示全部';
+
+-- 2. Verification
+SELECT table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_name = 'cache_invalidations'
+ORDER BY ordinal_position;
@@ -0,0 +1,6 @@
+-- ============================================
+-- Revert Request Rate Limiting
+-- 回復前請將 RATE_LIMIT_STORE 改為 memory (或 RATE_LIMIT_POLICIES=none)
+-- ============================================
+
+DROP TABLE IF EXISTS rate_limit_buckets;
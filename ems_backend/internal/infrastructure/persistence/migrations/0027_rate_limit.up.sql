@@ -0,0 +1,46 @@
+-- ============================================
+-- Request Rate Limiting
+-- ============================================
+--
+-- 每個路由群組以 token bucket 限流，呼叫者依序以 API Key、會員、來源 IP 區分
+-- RATE_LIMIT_POLICIES: <group>[:<principal>]=<limit>/<period>[,burst=<n>]，以分號分隔，none 為停用
+--   principal 為 member、api_key、ip，省略時適用所有呼叫者
+--   依序套用 group:principal → group → default:principal → default
+--   預設: auth:ip=20/m,burst=10;dashboard_areas=20/m,burst=5;default=600/m,burst=100
+-- 群組名稱:
+--   auth (登入、重設密碼、單一登入，以 IP 計算)、sessions、mfa、menu、dashboard、
+--   dashboard_areas (GET /dashboard/areas，另計於 dashboard 之外)、roles、powers、audit_logs、
+--   members、service_accounts、devices、companies、schedules、firmware、sse
+-- 回應標頭:
+--   RateLimit-Policy: <limit>;w=<秒>;burst=<n>
+--   RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset (補滿所需秒數)
+--   超過時返回 429 與 Retry-After (秒)
+-- RATE_LIMIT_STORE:
+--   memory   單一實例，bucket 存在記憶體
+--   postgres 多個實例共用下列 rate_limit_buckets，以資料庫時間計算補充量
+-- 閒置且已補滿的 bucket 每 RATE_LIMIT_PURGE_INTERVAL (預設 10m) 清除
+-- 結果計入 GET /metrics 的 ems_rate_limit_requests_total{policy,principal,result}
+-- 經反向代理部署時需設定 TRUSTED_PROXIES，否則所有未登入請求會以代理的 IP 計算
+--
+
+-- 1. rate_limit_buckets
+CREATE TABLE IF NOT EXISTS public.rate_limit_buckets (
+    bucket_key varchar(255) NOT NULL,
+    tokens float8 NOT NULL,
+    allowed boolean NOT NULL,
+    updated_at timestamp NOT NULL,
+    CONSTRAINT pk_rate_limit_buckets PRIMARY KEY (bucket_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+COMMENT ON TABLE rate_limit_buckets IS '請求限流 token bucket (RATE_LIMIT_STORE=postgres)';
+COMMENT ON COLUMN rate_limit_buckets.bucket_key IS '<group>:<principal>:<id>';
+COMMENT ON COLUMN rate_limit_buckets.tokens IS '上次取用後剩餘的 token';
+COMMENT ON COLUMN rate_limit_buckets.allowed IS '上次取用是否成功';
+
+-- 2. Verification
+SELECT table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_name = 'rate_limit_buckets'
+ORDER BY ordinal_position;
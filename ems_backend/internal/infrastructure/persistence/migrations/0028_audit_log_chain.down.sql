@@ -0,0 +1,14 @@
+-- ============================================
+-- Revert Tamper-Evident Audit Log
+-- 雜湊鏈欄位會被移除，之後無法再驗證既有記錄；記錄本身保留
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'audit_log:verify');
+DELETE FROM power WHERE code = 'audit_log:verify';
+
+-- 2. Chain columns
+DROP INDEX IF EXISTS idx_audit_log_sequence;
+ALTER TABLE public.audit_log DROP COLUMN IF EXISTS hash;
+ALTER TABLE public.audit_log DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE public.audit_log DROP COLUMN IF EXISTS sequence;
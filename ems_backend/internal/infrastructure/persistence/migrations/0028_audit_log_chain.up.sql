@@ -0,0 +1,61 @@
+-- ============================================
+-- Tamper-Evident Audit Log
+-- ============================================
+--
+-- 每筆審計日誌寫入時取得 advisory lock，依序號串成雜湊鏈:
+--   hash = SHA-256(sequence、prev_hash、member_id、role_id、action、resource_type、resource_id、
+--                  details、ip_address、user_agent、status、error_message、create_time)
+--   prev_hash 為前一筆的 hash，第一筆為空字串
+-- 修改任何一筆記錄會使其 hash 不符，重新計算 hash 則會使下一筆的 prev_hash 不符，
+-- 刪除記錄會使 sequence 出現缺口
+--
+-- API:
+--   GET /audit-logs/verify[?from=<sequence>]  驗證雜湊鏈 (audit_log:verify)
+--     返回 valid、checked、first_sequence、last_sequence 與 issues (gap / broken_link / hash_invalid)
+--
+-- 更新與刪除 ROLE、POWER、MENU、MEMBER、COMPANY、DEVICE、SCHEDULE 時，
+-- details 記錄 before、after 快照與 changes (欄位 -> {before, after})，
+-- 名稱含 password、secret、token、hash 的欄位以 [REDACTED] 取代
+--
+-- 本腳本執行前的記錄 sequence 為 NULL，不在鏈上也不會被驗證
+--
+
+-- 1. Chain columns
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS sequence int8 NULL;
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS prev_hash varchar(64) NULL;
+ALTER TABLE public.audit_log ADD COLUMN IF NOT EXISTS hash varchar(64) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_log_sequence ON public.audit_log(sequence);
+
+COMMENT ON COLUMN audit_log.sequence IS '雜湊鏈序號，連續遞增；NULL 為啟用雜湊鏈前的記錄';
+COMMENT ON COLUMN audit_log.prev_hash IS '前一筆記錄的 hash';
+COMMENT ON COLUMN audit_log.hash IS '本筆內容與 prev_hash 的 SHA-256 (hex)';
+
+-- 2. Permissions (under 權限管理 menu, SystemAdmin only)
+DO $$
+DECLARE
+    power_menu_id INT;
+BEGIN
+    SELECT id INTO power_menu_id FROM menu WHERE url = '/power' LIMIT 1;
+
+    IF power_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (power_menu_id, '驗證審計日誌', 'audit_log:verify', '驗證審計日誌雜湊鏈是否遭到修改或刪除', 10, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        SELECT 1, power_menu_id, id, 1, NOW(), 1, NOW() FROM power WHERE code = 'audit_log:verify'
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Audit log permissions created';
+    ELSE
+        RAISE NOTICE 'Power menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 3. Verification
+SELECT column_name, data_type
+FROM information_schema.columns
+WHERE table_name = 'audit_log' AND column_name IN ('sequence', 'prev_hash', 'hash');
+
+SELECT id, menu_id, code, title FROM power WHERE code = 'audit_log:verify';
@@ -0,0 +1,16 @@
+-- ============================================
+-- Revert Audit Log Retention, Archival and Export
+-- 已歸檔並刪除的記錄不會寫回資料表，歸檔檔案保留在 AUDIT_ARCHIVE_DIR
+-- ============================================
+
+-- 1. Permissions
+DELETE FROM role_power WHERE power_id IN (SELECT id FROM power WHERE code = 'audit_log:export');
+DELETE FROM power WHERE code = 'audit_log:export';
+
+-- 2. 回收刪除權限
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'ems_readwrite') THEN
+        REVOKE DELETE ON public.audit_log FROM ems_readwrite;
+    END IF;
+END $$;
@@ -0,0 +1,53 @@
+-- ============================================
+-- Audit Log Retention, Archival and Export
+-- ============================================
+--
+-- 保留期限:
+--   AUDIT_RETENTION           保留期限 (預設 8760h；0 為永久保留)
+--   AUDIT_RETENTION_INTERVAL  執行間隔 (預設 24h，啟動時也會執行一次)
+--   AUDIT_ARCHIVE_DRIVER      歸檔儲存 (local)
+--   AUDIT_ARCHIVE_DIR         local 的歸檔目錄 (預設 archive/audit_log)
+-- 超過期限的記錄依 id 順序每 5000 筆寫成一個 gzip 壓縮的 JSON Lines 檔:
+--   audit-log-<首筆日期 yyyymmdd>-<首筆 id>-<末筆 id>.jsonl.gz
+-- 檔案寫入成功後才刪除該批記錄；歸檔失敗時記錄保留在表中，下次執行時重試
+-- 歸檔保留 sequence、prev_hash、hash，表中第一筆的 prev_hash 即為最後一個歸檔末筆的 hash，
+-- 因此 GET /audit-logs/verify 返回的 first_sequence 會隨歸檔前進
+--
+-- 匯出:
+--   GET /audit-logs/export?format=csv|jsonl  (audit_log:export)
+--   過濾參數與 GET /audit-logs 相同 (member_id、role_id、action、resource_type、status、start_time、end_time)，
+--   不分頁，依 id 順序串流輸出；匯出本身記錄為 EXPORT AUDIT_LOG
+--
+
+-- 1. 保留期限處理需要刪除權限
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'ems_readwrite') THEN
+        GRANT DELETE ON public.audit_log TO ems_readwrite;
+    END IF;
+END $$;
+
+-- 2. Permissions (under 權限管理 menu, SystemAdmin only)
+DO $$
+DECLARE
+    power_menu_id INT;
+BEGIN
+    SELECT id INTO power_menu_id FROM menu WHERE url = '/power' LIMIT 1;
+
+    IF power_menu_id IS NOT NULL THEN
+        INSERT INTO power (menu_id, title, code, description, sort, is_enable, create_id, create_time, modify_id, modify_time)
+        VALUES (power_menu_id, '匯出審計日誌', 'audit_log:export', '以 CSV 或 JSON Lines 匯出審計日誌', 11, true, 1, NOW(), 1, NOW())
+        ON CONFLICT DO NOTHING;
+
+        INSERT INTO role_power (role_id, menu_id, power_id, create_id, create_time, modify_id, modify_time)
+        SELECT 1, power_menu_id, id, 1, NOW(), 1, NOW() FROM power WHERE code = 'audit_log:export'
+        ON CONFLICT DO NOTHING;
+
+        RAISE NOTICE 'Audit log export permission created';
+    ELSE
+        RAISE NOTICE 'Power menu not found, skipping permission creation';
+    END IF;
+END $$;
+
+-- 3. Verification
+SELECT id, menu_id, code, title FROM power WHERE code LIKE 'audit_log:%';
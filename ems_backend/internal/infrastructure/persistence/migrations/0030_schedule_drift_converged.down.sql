@@ -0,0 +1,7 @@
+-- ============================================
+-- Revert Schedule Drift: converged resolution
+-- 已以 converged 關閉的紀錄保留原值
+-- ============================================
+
+COMMENT ON COLUMN schedule_drifts.resolution IS 'cloud_wins, device_wins';
+COMMENT ON COLUMN schedule_drifts.resolved_by IS NULL;
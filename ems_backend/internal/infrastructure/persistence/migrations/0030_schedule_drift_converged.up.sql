@@ -0,0 +1,7 @@
+-- ============================================
+-- Schedule Drift: converged resolution
+-- 設備再次回報時已與雲端一致，未解決的漂移以 converged 自動關閉 (resolved_by = 0)
+-- ============================================
+
+COMMENT ON COLUMN schedule_drifts.resolution IS 'cloud_wins, device_wins, converged';
+COMMENT ON COLUMN schedule_drifts.resolved_by IS 'Member who resolved the drift (0 = resolved automatically)';
@@ -0,0 +1,165 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultDir - migrate create 產生檔案的預設目錄 (相對於 ems_backend)
+const DefaultDir = "internal/infrastructure/persistence/migrations"
+
+// BaselineVersion - 0001 至此版本由舊 sql/ 腳本轉入，沒有 down 檔案，是不可回復的基準版本
+// 之後的版本都必須提供 down migration (見 README.md)
+const BaselineVersion int64 = 9
+
+//go:embed *.sql
+var embedded embed.FS
+
+// 檔名格式：<版本>_<名稱>.up.sql / <版本>_<名稱>.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// 新 migration 名稱只允許小寫英數與底線
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration - 一個版本的 up / down SQL
+// Down 為空或只有註解表示不可回復 (例如由舊 sql/ 腳本轉入的版本)
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Reversible 是否有 down migration (只有註解的 down 檔案視為不可回復)
+func (m Migration) Reversible() bool {
+	return hasStatements(m.Down)
+}
+
+// IsBaseline 是否為不可回復的基準版本 (BaselineVersion 以前且沒有 down migration)
+func (m Migration) IsBaseline() bool {
+	return m.Version <= BaselineVersion && !m.Reversible()
+}
+
+// String - 0001_base_schema
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Embedded - 編譯進執行檔的 migration，依版本排序
+func Embedded() ([]Migration, error) {
+	return Load(embedded)
+}
+
+// Load 讀取 fsys 根目錄下的 migration 檔案並驗證：
+// 檔名須符合格式、同一版本只能有一個名稱、每個版本都要有 up，down 可省略
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (expected NNNN_name.up.sql or NNNN_name.down.sql)", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if !hasStatements(m.Up) {
+			return nil, fmt.Errorf("migration %s has no up SQL", m)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// hasStatements 去除空白與 -- 註解行後是否還有 SQL
+func hasStatements(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// Create 在 dir 產生下一個版本的 up / down 空白檔案，返回建立的檔案路徑
+// 版本為目錄中最大版本 + 1；down 檔案只留註解即為不可回復
+func Create(dir, name string) ([]string, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q (use lowercase letters, digits and underscores)", name)
+	}
+
+	// 只看檔名取最大版本，尚未填寫內容的 migration 也算在內
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var next int64 = 1
+	for _, entry := range entries {
+		if match := fileNamePattern.FindStringSubmatch(entry.Name()); match != nil {
+			if version, err := strconv.ParseInt(match[1], 10, 64); err == nil && version >= next {
+				next = version + 1
+			}
+		}
+	}
+
+	base := fmt.Sprintf("%04d_%s", next, name)
+	files := map[string]string{
+		base + ".up.sql":   fmt.Sprintf("-- %s\n", base),
+		base + ".down.sql": fmt.Sprintf("-- %s 的回復；只有註解表示不可回復\n", base),
+	}
+	paths := []string{filepath.Join(dir, base+".up.sql"), filepath.Join(dir, base+".down.sql")}
+	for _, path := range paths {
+		// O_EXCL：不覆蓋已存在的檔案
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.WriteString(files[filepath.Base(path)])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,541 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name: "依版本排序並合併 up / down",
+			files: fstest.MapFS{
+				"0010_b.up.sql":   {Data: []byte("SELECT 10;")},
+				"0002_a.up.sql":   {Data: []byte("SELECT 2;")},
+				"0002_a.down.sql": {Data: []byte("SELECT -2;")},
+				"README.md":       {Data: []byte("不是 migration")},
+			},
+			wantVersions: []int64{2, 10},
+		},
+		{
+			name:    "檔名格式錯誤",
+			files:   fstest.MapFS{"0001-init.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "名稱含大寫",
+			files:   fstest.MapFS{"0001_Init.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "版本為 0",
+			files:   fstest.MapFS{"0000_init.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: "invalid migration version",
+		},
+		{
+			name: "同一版本兩個名稱",
+			files: fstest.MapFS{
+				"0001_init.up.sql":  {Data: []byte("SELECT 1;")},
+				"0001_other.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "conflicting names",
+		},
+		{
+			name: "up 與 down 名稱不同",
+			files: fstest.MapFS{
+				"0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+				"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "conflicting names",
+		},
+		{
+			name: "只有 down 沒有 up",
+			files: fstest.MapFS{
+				"0001_init.up.sql":     {Data: []byte("SELECT 1;")},
+				"0002_orphan.down.sql": {Data: []byte("SELECT 2;")},
+			},
+			wantErr: "0002_orphan has no up SQL",
+		},
+		{
+			name:    "up 只有註解",
+			files:   fstest.MapFS{"0001_init.up.sql": {Data: []byte("-- TODO\n\n")}},
+			wantErr: "has no up SQL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望錯誤包含 %q，得到 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.wantVersions) {
+				t.Errorf("期望版本 %v，得到 %v", tt.wantVersions, versions)
+			}
+		})
+	}
+}
+
+func TestLoad_Fields(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0001_init.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_init.down.sql": {Data: []byte("DROP TABLE a;")},
+		"0002_seed.up.sql":   {Data: []byte("INSERT INTO a VALUES (1);")},
+		"0002_seed.down.sql": {Data: []byte("-- 不可回復\n")},
+		"0003_index.up.sql":  {Data: []byte("CREATE INDEX i ON a(id);")},
+	})
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("期望 3 個 migration，得到 %d", len(migrations))
+	}
+
+	first := migrations[0]
+	if first.Name != "init" || first.Up != "CREATE TABLE a (id int);" || first.Down != "DROP TABLE a;" {
+		t.Errorf("第一個 migration 內容不符: %+v", first)
+	}
+	if first.String() != "0001_init" {
+		t.Errorf("期望 0001_init，得到 %s", first.String())
+	}
+
+	wantReversible := []bool{true, false, false}
+	for i, m := range migrations {
+		if m.Reversible() != wantReversible[i] {
+			t.Errorf("%s: 期望 Reversible=%v", m, wantReversible[i])
+		}
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatalf("內嵌的 migration 無法載入: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Fatalf("版本不連續: 第 %d 個為 %s", i+1, m)
+		}
+		// 基準版本以前都沒有 down，之後的版本都要能回復
+		if m.Version <= BaselineVersion && !m.IsBaseline() {
+			t.Errorf("%s 應為不可回復的基準版本", m)
+		}
+		if m.Version > BaselineVersion && !m.Reversible() {
+			t.Errorf("%s 缺少 down migration", m)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"0001_init.up.sql", "0002_seed.up.sql", "0002_seed.down.sql", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Create(dir, "add_devices")
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	want := []string{filepath.Join(dir, "0003_add_devices.up.sql"), filepath.Join(dir, "0003_add_devices.down.sql")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("期望 %v，得到 %v", want, paths)
+	}
+
+	// 新檔案可以被 Load 讀取，down 只有註解為不可回復
+	if err := os.WriteFile(want[0], []byte("CREATE TABLE devices (id int);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	last := migrations[len(migrations)-1]
+	if last.String() != "0003_add_devices" || last.Reversible() {
+		t.Errorf("期望不可回復的 0003_add_devices，得到 %s (reversible=%v)", last, last.Reversible())
+	}
+
+	// 下一個版本接續
+	paths, err = Create(dir, "next")
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if filepath.Base(paths[0]) != "0004_next.up.sql" {
+		t.Errorf("期望 0004_next.up.sql，得到 %s", filepath.Base(paths[0]))
+	}
+}
+
+func TestCreate_InvalidName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"", "AddDevices", "add-devices", "../escape"} {
+		if _, err := Create(dir, name); err == nil || !strings.Contains(err.Error(), "invalid migration name") {
+			t.Errorf("名稱 %q: 期望 invalid migration name，得到 %v", name, err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("名稱錯誤時不應建立檔案，得到 %d 個", len(entries))
+	}
+}
+
+func TestCreate_EmptyDir(t *testing.T) {
+	paths, err := Create(t.TempDir(), "init")
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if filepath.Base(paths[0]) != "0001_init.up.sql" || filepath.Base(paths[1]) != "0001_init.down.sql" {
+		t.Errorf("期望 0001_init，得到 %v", paths)
+	}
+}
+
+// fakeExecutor - 記錄執行的 SQL 與 schema_migrations 內容，transaction 失敗時丟棄該次的變更
+type fakeExecutor struct {
+	rows     map[int64]appliedVersion
+	executed []string // 已提交的 migration SQL (不含 schema_migrations 紀錄)
+	failOn   string   // 包含此字串的 SQL 返回錯誤
+	locks    int
+	now      time.Time
+}
+
+func newFakeExecutor(applied ...int64) *fakeExecutor {
+	f := &fakeExecutor{rows: make(map[int64]appliedVersion), now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, version := range applied {
+		f.rows[version] = appliedVersion{name: "applied", appliedAt: f.now}
+	}
+	return f
+}
+
+func (f *fakeExecutor) withLock(ctx context.Context, fn func(s session) error) error {
+	f.locks++
+	return fn(f)
+}
+
+func (f *fakeExecutor) snapshot(ctx context.Context) (map[int64]appliedVersion, error) {
+	return f.applied(ctx)
+}
+
+func (f *fakeExecutor) applied(ctx context.Context) (map[int64]appliedVersion, error) {
+	result := make(map[int64]appliedVersion, len(f.rows))
+	for version, row := range f.rows {
+		result[version] = row
+	}
+	return result, nil
+}
+
+func (f *fakeExecutor) inTx(ctx context.Context, fn func(tx execer) error) error {
+	tx := &fakeTx{f: f, inserted: make(map[int64]string)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	f.executed = append(f.executed, tx.executed...)
+	for version, name := range tx.inserted {
+		f.rows[version] = appliedVersion{name: name, appliedAt: f.now}
+	}
+	for _, version := range tx.deleted {
+		delete(f.rows, version)
+	}
+	return nil
+}
+
+type fakeTx struct {
+	f        *fakeExecutor
+	executed []string
+	inserted map[int64]string
+	deleted  []int64
+}
+
+func (tx *fakeTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx.f.failOn != "" && strings.Contains(query, tx.f.failOn) {
+		return nil, errors.New("syntax error")
+	}
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO public.schema_migrations"):
+		tx.inserted[args[0].(int64)] = args[1].(string)
+	case strings.HasPrefix(query, "DELETE FROM public.schema_migrations"):
+		tx.deleted = append(tx.deleted, args[0].(int64))
+	default:
+		tx.executed = append(tx.executed, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// testMigrations - 0001 ~ 0004，0002 不可回復
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "seed", Up: "up 2", Down: "-- 不可回復"},
+		{Version: 3, Name: "devices", Up: "up 3", Down: "down 3"},
+		{Version: 4, Name: "index", Up: "up 4", Down: "down 4"},
+	}
+}
+
+func versionsOf(migrations []Migration) []int64 {
+	versions := []int64{}
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	return versions
+}
+
+func appliedVersions(f *fakeExecutor) map[int64]bool {
+	result := make(map[int64]bool, len(f.rows))
+	for version := range f.rows {
+		result[version] = true
+	}
+	return result
+}
+
+func TestMigrator_Up(t *testing.T) {
+	tests := []struct {
+		name         string
+		applied      []int64
+		failOn       string
+		wantApplied  []int64
+		wantExecuted []string
+		wantRows     map[int64]bool
+		wantErr      string
+	}{
+		{
+			name:         "依版本順序套用全部",
+			wantApplied:  []int64{1, 2, 3, 4},
+			wantExecuted: []string{"up 1", "up 2", "up 3", "up 4"},
+			wantRows:     map[int64]bool{1: true, 2: true, 3: true, 4: true},
+		},
+		{
+			name:         "略過已套用的版本",
+			applied:      []int64{1, 3},
+			wantApplied:  []int64{2, 4},
+			wantExecuted: []string{"up 2", "up 4"},
+			wantRows:     map[int64]bool{1: true, 2: true, 3: true, 4: true},
+		},
+		{
+			name:         "全部已套用",
+			applied:      []int64{1, 2, 3, 4},
+			wantApplied:  []int64{},
+			wantExecuted: nil,
+			wantRows:     map[int64]bool{1: true, 2: true, 3: true, 4: true},
+		},
+		{
+			name:         "失敗時停止且不記錄失敗的版本",
+			failOn:       "up 3",
+			wantApplied:  []int64{1, 2},
+			wantExecuted: []string{"up 1", "up 2"},
+			wantRows:     map[int64]bool{1: true, 2: true},
+			wantErr:      "apply 0003_devices: syntax error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeExecutor(tt.applied...)
+			fake.failOn = tt.failOn
+			migrator := &Migrator{exec: fake, migrations: testMigrations()}
+
+			applied, err := migrator.Up(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("期望錯誤 %q，得到 %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if got := versionsOf(applied); !reflect.DeepEqual(got, tt.wantApplied) {
+				t.Errorf("期望套用 %v，得到 %v", tt.wantApplied, got)
+			}
+			if !reflect.DeepEqual(fake.executed, tt.wantExecuted) {
+				t.Errorf("期望執行 %v，得到 %v", tt.wantExecuted, fake.executed)
+			}
+			if got := appliedVersions(fake); !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("期望 schema_migrations %v，得到 %v", tt.wantRows, got)
+			}
+			if fake.locks != 1 {
+				t.Errorf("期望取得 lock 1 次，得到 %d", fake.locks)
+			}
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	tests := []struct {
+		name         string
+		migrations   []Migration
+		applied      []int64
+		steps        int
+		failOn       string
+		wantReverted []int64
+		wantExecuted []string
+		wantRows     map[int64]bool
+		wantErr      string
+	}{
+		{
+			name:         "由最新版本開始回復",
+			applied:      []int64{1, 3, 4},
+			steps:        2,
+			wantReverted: []int64{4, 3},
+			wantExecuted: []string{"down 4", "down 3"},
+			wantRows:     map[int64]bool{1: true},
+		},
+		{
+			name:         "steps 超過已套用數量",
+			applied:      []int64{3, 4},
+			steps:        5,
+			wantReverted: []int64{4, 3},
+			wantExecuted: []string{"down 4", "down 3"},
+			wantRows:     map[int64]bool{},
+		},
+		{
+			name:         "遇到不可回復的版本停止",
+			applied:      []int64{1, 2, 3, 4},
+			steps:        3,
+			wantReverted: []int64{4, 3},
+			wantExecuted: []string{"down 4", "down 3"},
+			wantRows:     map[int64]bool{1: true, 2: true},
+			wantErr:      "revert 0002_seed: migration is irreversible (versions up to 0009 are the baseline)",
+		},
+		{
+			name:         "執行檔中沒有的版本",
+			applied:      []int64{1, 4, 7},
+			steps:        2,
+			wantReverted: []int64{},
+			wantExecuted: nil,
+			wantRows:     map[int64]bool{1: true, 4: true, 7: true},
+			wantErr:      "revert version 7: migration not found in this binary",
+		},
+		{
+			name:         "失敗時保留紀錄",
+			applied:      []int64{1, 3, 4},
+			steps:        2,
+			failOn:       "down 3",
+			wantReverted: []int64{4},
+			wantExecuted: []string{"down 4"},
+			wantRows:     map[int64]bool{1: true, 3: true},
+			wantErr:      "revert 0003_devices: syntax error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeExecutor(tt.applied...)
+			fake.failOn = tt.failOn
+			migrator := &Migrator{exec: fake, migrations: testMigrations()}
+
+			reverted, err := migrator.Down(context.Background(), tt.steps)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("期望錯誤 %q，得到 %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if got := versionsOf(reverted); !reflect.DeepEqual(got, tt.wantReverted) {
+				t.Errorf("期望回復 %v，得到 %v", tt.wantReverted, got)
+			}
+			if !reflect.DeepEqual(fake.executed, tt.wantExecuted) {
+				t.Errorf("期望執行 %v，得到 %v", tt.wantExecuted, fake.executed)
+			}
+			if got := appliedVersions(fake); !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("期望 schema_migrations %v，得到 %v", tt.wantRows, got)
+			}
+		})
+	}
+}
+
+func TestMigrator_Down_Irreversible(t *testing.T) {
+	migrator := &Migrator{exec: newFakeExecutor(1, 2), migrations: testMigrations()}
+	if _, err := migrator.Down(context.Background(), 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("期望 ErrIrreversible，得到 %v", err)
+	}
+	if _, err := migrator.Down(context.Background(), 0); err == nil {
+		t.Error("steps 為 0 應返回錯誤")
+	}
+}
+
+func TestMigrator_Baseline(t *testing.T) {
+	fake := newFakeExecutor(2)
+	migrator := &Migrator{exec: fake, migrations: testMigrations()}
+
+	marked, err := migrator.Baseline(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if got := versionsOf(marked); !reflect.DeepEqual(got, []int64{1, 3}) {
+		t.Errorf("期望標記 [1 3]，得到 %v", got)
+	}
+	if len(fake.executed) != 0 {
+		t.Errorf("Baseline 不應執行 migration，得到 %v", fake.executed)
+	}
+	if got := appliedVersions(fake); !reflect.DeepEqual(got, map[int64]bool{1: true, 2: true, 3: true}) {
+		t.Errorf("期望 schema_migrations [1 2 3]，得到 %v", got)
+	}
+
+	// 之後的 Up 只執行剩下的版本
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if got := versionsOf(applied); !reflect.DeepEqual(got, []int64{4}) {
+		t.Errorf("期望套用 [4]，得到 %v", got)
+	}
+
+	if _, err := migrator.Baseline(context.Background(), 9); err == nil || !strings.Contains(err.Error(), "unknown migration version 9") {
+		t.Errorf("期望 unknown migration version，得到 %v", err)
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	fake := newFakeExecutor(1, 3, 9)
+	migrator := &Migrator{exec: fake, migrations: testMigrations()}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+
+	want := []struct {
+		version    int64
+		applied    bool
+		reversible bool
+		baseline   bool
+		missing    bool
+	}{
+		{version: 1, applied: true, reversible: true},
+		{version: 2, applied: false, reversible: false, baseline: true},
+		{version: 3, applied: true, reversible: true},
+		{version: 4, applied: false, reversible: true},
+		{version: 9, applied: true, missing: true},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("期望 %d 筆狀態，得到 %d", len(want), len(statuses))
+	}
+	for i, w := range want {
+		s := statuses[i]
+		if s.Version != w.version || (s.AppliedAt != nil) != w.applied || s.Reversible != w.reversible || s.Baseline != w.baseline || s.Missing != w.missing {
+			t.Errorf("第 %d 筆期望 %+v，得到 %+v", i, w, s)
+		}
+	}
+	if fake.locks != 0 {
+		t.Errorf("Status 不應取得 lock，得到 %d 次", fake.locks)
+	}
+}
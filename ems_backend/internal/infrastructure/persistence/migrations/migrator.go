@@ -0,0 +1,326 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// advisoryLockKey - 所有實例共用的 pg_advisory_lock key ("ems_migr")
+const advisoryLockKey int64 = 0x656d735f6d696772
+
+// ErrIrreversible - 要回復的 migration 沒有 down SQL
+var ErrIrreversible = errors.New("migration is irreversible")
+
+const createTableSQL = `
+CREATE TABLE IF NOT EXISTS public.schema_migrations (
+    version bigint PRIMARY KEY NOT NULL,
+    name varchar(255) NOT NULL,
+    applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Status - 單一版本的套用狀態
+type Status struct {
+	Version    int64
+	Name       string
+	AppliedAt  *time.Time // nil 表示尚未套用
+	Reversible bool
+	Baseline   bool // 不可回復的基準版本 (0001 至 BaselineVersion)
+	Missing    bool // 資料庫有紀錄但執行檔中沒有對應的 migration
+}
+
+// executor - Migrator 對資料庫的操作 (PostgreSQL，測試時以 fake 取代)
+type executor interface {
+	// withLock 取得 migration lock、確保 schema_migrations 存在後在同一連線上執行 fn
+	withLock(ctx context.Context, fn func(s session) error) error
+
+	// snapshot 不取得 lock 讀取 schema_migrations，資料表不存在時返回空的結果
+	snapshot(ctx context.Context) (map[int64]appliedVersion, error)
+}
+
+// session - 持有 migration lock 的連線
+type session interface {
+	// applied 讀取 schema_migrations 的所有紀錄
+	applied(ctx context.Context) (map[int64]appliedVersion, error)
+
+	// inTx 在 transaction 內執行 fn，fn 返回錯誤時回滾
+	inTx(ctx context.Context, fn func(tx execer) error) error
+}
+
+// execer - transaction 內執行 SQL (*sql.Tx)
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Migrator - 依 schema_migrations 記錄套用或回復 migration
+// 會修改 schema 的操作都在 advisory lock 內執行，多個實例同時啟動時只有一個會實際套用
+type Migrator struct {
+	exec       executor
+	migrations []Migration
+}
+
+// NewMigrator - 建立 Migrator，migrations 須依版本排序 (Embedded / Load 的結果)
+func NewMigrator(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{exec: &postgresExecutor{db: sqlDB}, migrations: migrations}, nil
+}
+
+// Up 依版本順序套用所有尚未套用的 migration，返回本次套用的版本
+// 每個 migration 與其 schema_migrations 紀錄在同一個 transaction 內，失敗時整個版本回滾
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.exec.withLock(ctx, func(conn session) error {
+		done, err := conn.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			log.Printf("[Migrate] Applying %s", migration)
+			err := conn.inTx(ctx, func(tx execer) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("apply %s: %w", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 由最新版本開始回復 steps 個已套用的 migration，返回本次回復的版本
+// 遇到不可回復或找不到檔案的版本時停止，已回復的版本不受影響
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []Migration
+	err := m.exec.withLock(ctx, func(conn session) error {
+		done, err := conn.applied(ctx)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			migration, ok := byVersion[versions[i]]
+			if !ok {
+				return fmt.Errorf("revert version %d: migration not found in this binary", versions[i])
+			}
+			if migration.IsBaseline() {
+				return fmt.Errorf("revert %s: %w (versions up to %04d are the baseline)", migration, ErrIrreversible, BaselineVersion)
+			}
+			if !migration.Reversible() {
+				return fmt.Errorf("revert %s: %w", migration, ErrIrreversible)
+			}
+			log.Printf("[Migrate] Reverting %s", migration)
+			err := conn.inTx(ctx, func(tx execer) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM public.schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("revert %s: %w", migration, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline 將 version (含) 以前尚未記錄的 migration 標記為已套用但不執行
+// 用於先前以 sql/ 腳本手動建立 schema 的資料庫，避免重複執行非冪等的腳本
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	known := false
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var marked []Migration
+	err := m.exec.withLock(ctx, func(conn session) error {
+		done, err := conn.applied(ctx)
+		if err != nil {
+			return err
+		}
+		return conn.inTx(ctx, func(tx execer) error {
+			for _, migration := range m.migrations {
+				if migration.Version > version {
+					break
+				}
+				if _, ok := done[migration.Version]; ok {
+					continue
+				}
+				if _, err := tx.ExecContext(ctx, "INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name); err != nil {
+					return err
+				}
+				marked = append(marked, migration)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+// Status 列出所有版本的套用狀態 (依版本排序)，包含資料庫有紀錄但執行檔中沒有的版本
+// 不取得 lock 也不建立 schema_migrations
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.exec.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{
+			Version:    migration.Version,
+			Name:       migration.Name,
+			Reversible: migration.Reversible(),
+			Baseline:   migration.IsBaseline(),
+		}
+		if row, ok := done[migration.Version]; ok {
+			appliedAt := row.appliedAt
+			status.AppliedAt = &appliedAt
+			delete(done, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range done {
+		appliedAt := row.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: row.name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+type appliedVersion struct {
+	name      string
+	appliedAt time.Time
+}
+
+// postgresExecutor - 以 advisory lock 排隊的 PostgreSQL 實作
+type postgresExecutor struct {
+	db *sql.DB
+}
+
+// postgresSession - 持有 advisory lock 的專用連線
+type postgresSession struct {
+	conn *sql.Conn
+}
+
+// withLock 在專用連線上取得 advisory lock、確保 schema_migrations 存在後執行 fn
+// 其他實例會等待直到 lock 釋放；連線中斷時 PostgreSQL 會自動釋放 lock
+func (e *postgresExecutor) withLock(ctx context.Context, fn func(s session) error) error {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	log.Println("[Migrate] Waiting for migration lock...")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey); err != nil {
+			// 解鎖失敗時丟棄連線，避免 lock 跟著連線留在連線池
+			log.Printf("[Migrate] Failed to release migration lock: %v", err)
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(&postgresSession{conn: conn})
+}
+
+// snapshot 不取得 lock 也不建立 schema_migrations
+func (e *postgresExecutor) snapshot(ctx context.Context) (map[int64]appliedVersion, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('public.schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return map[int64]appliedVersion{}, nil
+	}
+	return (&postgresSession{conn: conn}).applied(ctx)
+}
+
+// applied 讀取 schema_migrations 的所有紀錄
+func (s *postgresSession) applied(ctx context.Context) (map[int64]appliedVersion, error) {
+	rows, err := s.conn.QueryContext(ctx, "SELECT version, name, applied_at FROM public.schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]appliedVersion)
+	for rows.Next() {
+		var version int64
+		var row appliedVersion
+		if err := rows.Scan(&version, &row.name, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = row
+	}
+	return result, rows.Err()
+}
+
+// inTx 在專用連線上執行 transaction，fn 返回錯誤時回滾
+func (s *postgresSession) inTx(ctx context.Context, fn func(tx execer) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
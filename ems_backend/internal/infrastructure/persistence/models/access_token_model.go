@@ -17,6 +17,13 @@ type AccessTokenModel struct {
 	CreateTime   time.Time
 	ModifyID     uint
 	ModifyTime   time.Time
+
+	// 會話管理與撤銷 (migration 0022_session_management)
+	SessionID *string
+	IPAddress string
+	UserAgent string
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
 }
 
 // TableName 返回表名
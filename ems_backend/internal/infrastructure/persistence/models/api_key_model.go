@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// ServiceAccountModel - 服務帳號資料庫模型 (對應的 member 資料列保存名稱、email 與啟用狀態)
+type ServiceAccountModel struct {
+	MemberID    uint      `gorm:"primaryKey"`
+	Description string    `gorm:"type:varchar(255)"`
+	CompanyID   *uint     `gorm:"index"`
+	CreatedBy   uint      `gorm:"not null"`
+	CreateTime  time.Time `gorm:"not null"`
+}
+
+func (ServiceAccountModel) TableName() string {
+	return "service_accounts"
+}
+
+// APIKeyModel - API Key 資料庫模型 (key_hash 為完整明文的 SHA-256)
+type APIKeyModel struct {
+	ID               uint       `gorm:"primaryKey"`
+	ServiceAccountID uint       `gorm:"not null;index"`
+	RoleID           uint       `gorm:"not null"`
+	Name             string     `gorm:"type:varchar(128);not null"`
+	Prefix           string     `gorm:"type:varchar(16);not null;uniqueIndex"`
+	KeyHash          string     `gorm:"type:varchar(64);not null"`
+	ExpiresAt        *time.Time `gorm:""`
+	LastUsedAt       *time.Time `gorm:""`
+	LastUsedIP       string     `gorm:"column:last_used_ip;type:varchar(64)"`
+	RevokedAt        *time.Time `gorm:""`
+	CreatedBy        uint       `gorm:"not null"`
+	CreateTime       time.Time  `gorm:"not null"`
+}
+
+func (APIKeyModel) TableName() string {
+	return "api_keys"
+}
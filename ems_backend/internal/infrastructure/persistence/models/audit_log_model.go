@@ -17,6 +17,9 @@ type AuditLogModel struct {
 	Status       string    `gorm:"not null;size:32"`
 	ErrorMessage string    `gorm:"type:text"`
 	CreateTime   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	Sequence     *uint64   `gorm:"uniqueIndex"` // 雜湊鏈序號，舊資料為 NULL
+	PrevHash     string    `gorm:"size:64"`
+	Hash         string    `gorm:"size:64"`
 }
 
 func (AuditLogModel) TableName() string {
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// CacheInvalidationModel - 權限快取失效事件資料庫模型，供其他實例輪詢
+type CacheInvalidationModel struct {
+	ID         uint64    `gorm:"primaryKey"`
+	Kind       string    `gorm:"type:varchar(16);not null"` // role / member / all
+	TargetID   uint      `gorm:"not null;default:0"`
+	CreateTime time.Time `gorm:"not null;index"`
+}
+
+func (CacheInvalidationModel) TableName() string {
+	return "cache_invalidations"
+}
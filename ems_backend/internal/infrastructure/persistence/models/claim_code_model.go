@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ClaimCodeModel - 設備認領碼資料庫模型
+type ClaimCodeModel struct {
+	ID           uint       `gorm:"primaryKey"`
+	DeviceID     uint       `gorm:"not null;index"`
+	CodeHash     string     `gorm:"type:char(64);not null;uniqueIndex"`
+	CodeHint     string     `gorm:"type:varchar(8);not null"`
+	Status       string     `gorm:"type:varchar(16);not null;index"`
+	ExpiresAt    time.Time  `gorm:"not null"`
+	IssuedBy     uint       `gorm:"not null"`
+	IssuedRoleID uint       `gorm:"not null"`
+	IssuedAt     time.Time  `gorm:"not null"`
+	RedeemedBy   *uint      `gorm:""`
+	RedeemedAt   *time.Time `gorm:""`
+	CompanyID    *uint      `gorm:""`
+	ClosedAt     *time.Time `gorm:""`
+}
+
+func (ClaimCodeModel) TableName() string {
+	return "device_claim_codes"
+}
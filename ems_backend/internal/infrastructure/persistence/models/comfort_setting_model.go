@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ComfortSettingModel - 區域舒適度控制設定資料庫模型
+type ComfortSettingModel struct {
+	ID              uint      `gorm:"primaryKey"`
+	CompanyDeviceID uint      `gorm:"not null;uniqueIndex:idx_comfort_settings_area"`
+	AreaID          string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_comfort_settings_area"`
+	Enabled         bool      `gorm:"not null;default:false;index"`
+	DryRun          bool      `gorm:"not null;default:true"`
+	ComfortLow      float64   `gorm:"type:numeric(4,1);not null"`
+	ComfortHigh     float64   `gorm:"type:numeric(4,1);not null"`
+	Hysteresis      float64   `gorm:"type:numeric(3,1);not null"`
+	MinOnSeconds    int       `gorm:"not null"`
+	MinOffSeconds   int       `gorm:"not null"`
+	UpdatedBy       uint      `gorm:"not null;default:0"`
+	CreatedAt       time.Time `gorm:"not null"`
+	UpdatedAt       time.Time `gorm:"not null"`
+}
+
+func (ComfortSettingModel) TableName() string {
+	return "comfort_control_settings"
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// DemandSettingModel - 公司需量控制設定資料庫模型
+type DemandSettingModel struct {
+	CompanyID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	Enabled        bool      `gorm:"not null;default:false;index"`
+	DryRun         bool      `gorm:"not null;default:true"`
+	ContractKW     float64   `gorm:"column:contract_kw;type:numeric(10,2);not null"`
+	ShedRatio      float64   `gorm:"type:numeric(4,3);not null"`
+	RestoreRatio   float64   `gorm:"type:numeric(4,3);not null"`
+	MinShedSeconds int       `gorm:"not null"`
+	Loads          JSONB     `gorm:"type:jsonb;not null"`
+	ModifyID       uint      `gorm:"not null"`
+	ModifyTime     time.Time `gorm:"not null"`
+}
+
+func (DemandSettingModel) TableName() string {
+	return "demand_settings"
+}
+
+// DemandActiveShedModel - 目前卸載中負載資料庫模型
+type DemandActiveShedModel struct {
+	ID              uint      `gorm:"primaryKey"`
+	CompanyID       uint      `gorm:"not null;index"`
+	CompanyDeviceID uint      `gorm:"not null"`
+	TargetType      string    `gorm:"type:varchar(16);not null"`
+	TargetID        string    `gorm:"type:varchar(64);not null"`
+	DryRun          bool      `gorm:"not null"`
+	ShedAt          time.Time `gorm:"not null"`
+}
+
+func (DemandActiveShedModel) TableName() string {
+	return "demand_active_sheds"
+}
+
+// DemandEventModel - 卸載 / 復歸事件資料庫模型
+type DemandEventModel struct {
+	ID              uint      `gorm:"primaryKey"`
+	CompanyID       uint      `gorm:"not null;index"`
+	EventType       string    `gorm:"type:varchar(16);not null"`
+	CompanyDeviceID uint      `gorm:"not null"`
+	TargetType      string    `gorm:"type:varchar(16);not null"`
+	TargetID        string    `gorm:"type:varchar(64);not null"`
+	RollingKW       float64   `gorm:"column:rolling_kw;type:numeric(10,2);not null"`
+	PredictedKW     float64   `gorm:"column:predicted_kw;type:numeric(10,2);not null"`
+	ContractKW      float64   `gorm:"column:contract_kw;type:numeric(10,2);not null"`
+	DryRun          bool      `gorm:"not null"`
+	CommandID       string    `gorm:"type:varchar(64)"`
+	Message         string    `gorm:"type:text"`
+	CreatedAt       time.Time `gorm:"not null"`
+}
+
+func (DemandEventModel) TableName() string {
+	return "demand_events"
+}
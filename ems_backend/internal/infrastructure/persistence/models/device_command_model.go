@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceCommandModel - 遠端控制命令資料庫模型
+type DeviceCommandModel struct {
+	ID              uint       `gorm:"primaryKey"`
+	CommandID       string     `gorm:"type:varchar(64);not null;uniqueIndex"`
+	CompanyDeviceID uint       `gorm:"not null;index"`
+	DeviceSN        string     `gorm:"type:varchar(64);not null"`
+	TargetType      string     `gorm:"type:varchar(16);not null"`
+	TargetID        string     `gorm:"type:varchar(64);not null"`
+	Action          string     `gorm:"type:varchar(32);not null"`
+	Mode            string     `gorm:"type:varchar(16)"`
+	Setpoint        *float64   `gorm:"type:numeric(4,1)"`
+	Status          string     `gorm:"type:varchar(16);not null"`
+	Message         string     `gorm:"type:text"`
+	RequestedBy     uint       `gorm:"not null"`
+	RequestedAt     time.Time  `gorm:"not null"`
+	SentAt          *time.Time `gorm:"type:timestamp"`
+	AckAt           *time.Time `gorm:"type:timestamp"`
+}
+
+func (DeviceCommandModel) TableName() string {
+	return "device_commands"
+}
@@ -10,6 +10,8 @@ import (
 type DeviceModel struct {
 	ID         uint      `gorm:"column:id;primaryKey;autoIncrement"`
 	SN         string    `gorm:"column:sn;type:varchar(256);not null"`
+	Model      string    `gorm:"column:model;type:varchar(64);not null;default:''"`
+	Notes      string    `gorm:"column:notes;type:text;not null;default:''"`
 	CreateID   uint      `gorm:"column:create_id;not null"`
 	CreateTime time.Time `gorm:"column:create_time;not null"`
 	ModifyID   uint      `gorm:"column:modify_id;not null"`
@@ -26,6 +28,8 @@ func (m *DeviceModel) ToEntity() *entities.Device {
 	return &entities.Device{
 		ID:         m.ID,
 		SN:         m.SN,
+		Model:      m.Model,
+		Notes:      m.Notes,
 		CreateID:   m.CreateID,
 		CreateTime: m.CreateTime,
 		ModifyID:   m.ModifyID,
@@ -38,6 +42,8 @@ func DeviceModelFromEntity(e *entities.Device) *DeviceModel {
 	return &DeviceModel{
 		ID:         e.ID,
 		SN:         e.SN,
+		Model:      e.Model,
+		Notes:      e.Notes,
 		CreateID:   e.CreateID,
 		CreateTime: e.CreateTime,
 		ModifyID:   e.ModifyID,
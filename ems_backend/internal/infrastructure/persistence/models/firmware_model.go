@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+// FirmwareModel - 韌體目錄資料庫模型
+type FirmwareModel struct {
+	ID              uint      `gorm:"primaryKey"`
+	Version         string    `gorm:"type:varchar(64);not null"`
+	HardwareVersion string    `gorm:"type:varchar(64);not null;default:''"`
+	URL             string    `gorm:"column:url;type:text;not null"`
+	SHA256          string    `gorm:"column:sha256;type:char(64);not null"`
+	SizeBytes       int64     `gorm:"not null"`
+	ReleaseNotes    string    `gorm:"type:text"`
+	CreateID        uint      `gorm:"not null"`
+	CreateTime      time.Time `gorm:"not null"`
+}
+
+func (FirmwareModel) TableName() string {
+	return "firmware_artifacts"
+}
+
+// DeviceFirmwareModel - 設備韌體版本盤點資料庫模型
+type DeviceFirmwareModel struct {
+	DeviceID        uint      `gorm:"primaryKey;autoIncrement:false"`
+	FirmwareVersion string    `gorm:"type:varchar(64);not null"`
+	HardwareVersion string    `gorm:"type:varchar(64);not null;default:''"`
+	ReportedAt      time.Time `gorm:"not null"`
+}
+
+func (DeviceFirmwareModel) TableName() string {
+	return "device_firmware"
+}
+
+// FirmwareCampaignModel - 韌體發布活動資料庫模型
+type FirmwareCampaignModel struct {
+	ID                   uint       `gorm:"primaryKey"`
+	Name                 string     `gorm:"type:varchar(128);not null"`
+	FirmwareID           uint       `gorm:"not null"`
+	TargetMode           string     `gorm:"type:varchar(16);not null"`
+	Percentage           int        `gorm:"not null;default:0"`
+	DeviceIDs            JSONB      `gorm:"column:device_ids;type:jsonb;not null"`
+	BatchSize            int        `gorm:"not null"`
+	MaxFailureRate       float64    `gorm:"type:numeric(4,3);not null"`
+	MinSamples           int        `gorm:"not null"`
+	DeviceTimeoutSeconds int        `gorm:"not null"`
+	Status               string     `gorm:"type:varchar(16);not null;index"`
+	HaltReason           string     `gorm:"type:text"`
+	SucceededOffset      int        `gorm:"not null;default:0"`
+	FailedOffset         int        `gorm:"not null;default:0"`
+	CreateID             uint       `gorm:"not null"`
+	CreateTime           time.Time  `gorm:"not null"`
+	ModifyID             uint       `gorm:"not null"`
+	ModifyTime           time.Time  `gorm:"not null"`
+	FinishedAt           *time.Time `gorm:""`
+}
+
+func (FirmwareCampaignModel) TableName() string {
+	return "firmware_campaigns"
+}
+
+// FirmwareCampaignDeviceModel - 發布活動設備進度資料庫模型
+type FirmwareCampaignDeviceModel struct {
+	ID          uint       `gorm:"primaryKey"`
+	CampaignID  uint       `gorm:"not null;index"`
+	DeviceID    uint       `gorm:"not null;index"`
+	DeviceSN    string     `gorm:"column:device_sn;type:varchar(64);not null"`
+	FromVersion string     `gorm:"type:varchar(64)"`
+	CommandID   string     `gorm:"type:varchar(64);index"`
+	Status      string     `gorm:"type:varchar(16);not null"`
+	Progress    int        `gorm:"not null;default:0"`
+	Message     string     `gorm:"type:text"`
+	SentAt      *time.Time `gorm:""`
+	UpdatedAt   time.Time  `gorm:"not null"`
+	FinishedAt  *time.Time `gorm:""`
+}
+
+func (FirmwareCampaignDeviceModel) TableName() string {
+	return "firmware_campaign_devices"
+}
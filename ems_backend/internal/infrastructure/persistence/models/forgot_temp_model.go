@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TableNameForgotTemp = "forgot_temp"
+)
+
+// ForgotTempModel - 忘記密碼重設憑證資料庫模型 (code 保存 SHA-256 雜湊)
+type ForgotTempModel struct {
+	ID           uint      `gorm:"primaryKey"`
+	MemberID     uint      `gorm:"not null"`
+	ExpireTime   time.Time `gorm:"not null"`
+	Code         string    `gorm:"type:varchar(128);not null"`
+	RedirectPath *string   `gorm:"type:varchar(512)"`
+}
+
+func (ForgotTempModel) TableName() string {
+	return TableNameForgotTemp
+}
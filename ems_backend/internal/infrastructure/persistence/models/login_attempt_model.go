@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginAttemptModel - 登入失敗統計資料庫模型 (主鍵 scope + attempt_key)
+type LoginAttemptModel struct {
+	Scope         string    `gorm:"primaryKey;type:varchar(16)"`
+	AttemptKey    string    `gorm:"primaryKey;type:varchar(255)"`
+	Failures      int       `gorm:"not null"`
+	LastFailureAt time.Time `gorm:"not null;index"`
+}
+
+func (LoginAttemptModel) TableName() string {
+	return "login_attempts"
+}
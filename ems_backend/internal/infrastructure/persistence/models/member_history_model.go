@@ -13,6 +13,7 @@ type MemberHistoryModel struct {
 	MemberID   uint      `gorm:"not null"`
 	Salt       string    `gorm:"not null"`
 	Hash       string    `gorm:"not null"`
+	HashParams string    `gorm:"column:hash_params;not null;default:''"`
 	ErrorCount int       `gorm:"not null"`
 	CreateID   uint      `gorm:"not null"`
 	CreateTime time.Time `gorm:"not null"`
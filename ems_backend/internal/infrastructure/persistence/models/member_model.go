@@ -19,6 +19,10 @@ type MemberModel struct {
 	CreateTime time.Time `gorm:"not null"`
 	ModifyID   uint      `gorm:"not null"`
 	ModifyTime time.Time `gorm:"not null"`
+
+	// 連續登入失敗鎖定 (migration 0019_login_lockout)
+	LockedAt    *time.Time
+	LockedUntil *time.Time
 }
 
 // TableName 返回表名
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// MemberMFAModel - 會員 TOTP 設定資料庫模型 (secret 在設定 MFA_ENCRYPTION_KEY 時為密文)
+type MemberMFAModel struct {
+	MemberID     uint       `gorm:"primaryKey"`
+	Secret       string     `gorm:"type:varchar(255);not null"`
+	Enabled      bool       `gorm:"not null;default:false"`
+	EnabledAt    *time.Time `gorm:""`
+	LastUsedStep int64      `gorm:"not null;default:0"`
+	CreateTime   time.Time  `gorm:"not null"`
+}
+
+func (MemberMFAModel) TableName() string {
+	return "member_mfa"
+}
+
+// MFARecoveryCodeModel - MFA 復原碼資料庫模型 (只保存 SHA-256 雜湊)
+type MFARecoveryCodeModel struct {
+	ID       uint       `gorm:"primaryKey"`
+	MemberID uint       `gorm:"not null;index"`
+	CodeHash string     `gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time `gorm:""`
+}
+
+func (MFARecoveryCodeModel) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// MFAChallengeModel - 兩階段登入挑戰資料庫模型 (token_hash 為 SHA-256)
+type MFAChallengeModel struct {
+	ID         uint      `gorm:"primaryKey"`
+	MemberID   uint      `gorm:"not null;index"`
+	TokenHash  string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Attempts   int       `gorm:"not null;default:0"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	CreateTime time.Time `gorm:"not null"`
+}
+
+func (MFAChallengeModel) TableName() string {
+	return "mfa_challenges"
+}
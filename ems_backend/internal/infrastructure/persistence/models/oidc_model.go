@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// OIDCLoginStateModel - 單一登入授權碼流程狀態資料庫模型 (state_hash 為 SHA-256)
+type OIDCLoginStateModel struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Provider     string    `gorm:"type:varchar(64);not null"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	RedirectPath string    `gorm:"type:varchar(512);not null;default:''"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreateTime   time.Time `gorm:"not null"`
+}
+
+func (OIDCLoginStateModel) TableName() string {
+	return "oidc_login_states"
+}
+
+// MemberIdentityModel - 外部身分 (provider + sub) 連結資料庫模型
+type MemberIdentityModel struct {
+	ID          uint      `gorm:"primaryKey"`
+	Provider    string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_member_identities_provider_subject"`
+	Subject     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_member_identities_provider_subject"`
+	MemberID    uint      `gorm:"not null;index"`
+	Email       string    `gorm:"type:varchar(255);not null;default:''"`
+	CreateTime  time.Time `gorm:"not null"`
+	LastLoginAt time.Time `gorm:"not null"`
+}
+
+func (MemberIdentityModel) TableName() string {
+	return "member_identities"
+}
@@ -12,6 +12,12 @@ type RoleModel struct {
 	CreateTime  time.Time `gorm:"not null"`
 	ModifyID    uint      `gorm:"not null"`
 	ModifyTime  time.Time `gorm:"not null"`
+
+	// 角色 MFA 政策 (migration 0020_mfa)
+	MFARequired bool `gorm:"column:mfa_required;not null;default:false"`
+
+	// 公司存取範圍 none / own / subtree / all (migration 0025_company_access_scope)
+	CompanyScope string `gorm:"column:company_scope;not null;default:none"`
 }
 
 func (RoleModel) TableName() string {
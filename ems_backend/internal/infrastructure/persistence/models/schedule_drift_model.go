@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduleDriftModel - 排程漂移資料庫模型
+type ScheduleDriftModel struct {
+	ID              uint       `gorm:"primaryKey"`
+	CompanyDeviceID uint       `gorm:"not null;index"`
+	ScheduleID      uint       `gorm:"not null;default:0"`
+	CloudVersion    int        `gorm:"not null;default:0"`
+	Differences     JSONB      `gorm:"type:jsonb;not null"`
+	DeviceSnapshot  JSONB      `gorm:"type:jsonb"`
+	Status          string     `gorm:"type:varchar(16);not null;index"`
+	Resolution      string     `gorm:"type:varchar(16)"`
+	ResolvedBy      uint       `gorm:"default:0"`
+	ResolvedAt      *time.Time `gorm:"type:timestamp"`
+	DetectedAt      time.Time  `gorm:"not null"`
+}
+
+func (ScheduleDriftModel) TableName() string {
+	return "schedule_drifts"
+}
+
+// ScheduleDriftPolicyModel - 公司排程漂移策略資料庫模型
+type ScheduleDriftPolicyModel struct {
+	CompanyID  uint      `gorm:"primaryKey;autoIncrement:false"`
+	Policy     string    `gorm:"type:varchar(16);not null"`
+	ModifyID   uint      `gorm:"not null"`
+	ModifyTime time.Time `gorm:"not null"`
+}
+
+func (ScheduleDriftPolicyModel) TableName() string {
+	return "schedule_drift_policies"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduleVersionModel - 排程歷史版本資料庫模型
+type ScheduleVersionModel struct {
+	ID              uint      `gorm:"primaryKey"`
+	ScheduleID      uint      `gorm:"not null;uniqueIndex:idx_schedule_version"`
+	CompanyDeviceID uint      `gorm:"not null;index"`
+	Version         int       `gorm:"not null;uniqueIndex:idx_schedule_version"`
+	Source          string    `gorm:"type:varchar(16);not null"`
+	RestoredFrom    int       `gorm:"not null;default:0"`
+	Rules           JSONB     `gorm:"type:jsonb;not null"`
+	CreatedBy       uint      `gorm:"not null"`
+	CreatedAt       time.Time `gorm:"not null"`
+}
+
+func (ScheduleVersionModel) TableName() string {
+	return "schedule_versions"
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type ServiceAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceAccountRepository(db *gorm.DB) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db: db}
+}
+
+// serviceAccountRow service_accounts 與 member 的聯結結果
+type serviceAccountRow struct {
+	MemberID    uint
+	Name        string
+	Email       string
+	Description string
+	CompanyID   *uint
+	IsEnable    bool
+	CreatedBy   uint
+	CreateTime  time.Time
+}
+
+const serviceAccountSelect = `
+	SELECT sa.member_id, m.name, m.email, sa.description, sa.company_id, m.is_enable, sa.created_by, sa.create_time
+	FROM service_accounts sa
+	INNER JOIN member m ON m.id = sa.member_id
+`
+
+// Create 在同一交易中建立 member、service_accounts 與 company_member
+func (r *ServiceAccountRepository) Create(account *entities.ServiceAccount) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		member := &models.MemberModel{
+			Name:       account.Name,
+			Email:      account.Email,
+			IsEnable:   account.IsEnable,
+			CreateID:   account.CreatedBy,
+			CreateTime: account.CreateTime,
+			ModifyID:   account.CreatedBy,
+			ModifyTime: account.CreateTime,
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.ServiceAccountModel{
+			MemberID:    member.ID,
+			Description: account.Description,
+			CompanyID:   account.CompanyID,
+			CreatedBy:   account.CreatedBy,
+			CreateTime:  account.CreateTime,
+		}).Error; err != nil {
+			return err
+		}
+
+		if account.CompanyID != nil {
+			if err := tx.Create(&models.CompanyMemberModel{
+				CompanyID:  *account.CompanyID,
+				MemberID:   member.ID,
+				CreateID:   account.CreatedBy,
+				CreateTime: account.CreateTime,
+				ModifyID:   account.CreatedBy,
+				ModifyTime: account.CreateTime,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		account.MemberID = member.ID
+		return nil
+	})
+}
+
+// FindByMemberID 查找服務帳號 (不存在時返回 nil, nil)
+func (r *ServiceAccountRepository) FindByMemberID(memberID uint) (*entities.ServiceAccount, error) {
+	var rows []serviceAccountRow
+	if err := r.db.Raw(serviceAccountSelect+` WHERE sa.member_id = ?`, memberID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0].toEntity(), nil
+}
+
+func (r *ServiceAccountRepository) FindAll() ([]*entities.ServiceAccount, error) {
+	var rows []serviceAccountRow
+	if err := r.db.Raw(serviceAccountSelect + ` ORDER BY sa.member_id`).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	accounts := make([]*entities.ServiceAccount, len(rows))
+	for i := range rows {
+		accounts[i] = rows[i].toEntity()
+	}
+	return accounts, nil
+}
+
+func (r *ServiceAccountRepository) Disable(memberID uint, modifyID uint, now time.Time) error {
+	return r.db.Model(&models.MemberModel{}).Where("id = ?", memberID).Updates(map[string]interface{}{
+		"is_enable":   false,
+		"modify_id":   modifyID,
+		"modify_time": now,
+	}).Error
+}
+
+func (row *serviceAccountRow) toEntity() *entities.ServiceAccount {
+	return &entities.ServiceAccount{
+		MemberID:    row.MemberID,
+		Name:        row.Name,
+		Email:       row.Email,
+		Description: row.Description,
+		CompanyID:   row.CompanyID,
+		IsEnable:    row.IsEnable,
+		CreatedBy:   row.CreatedBy,
+		CreateTime:  row.CreateTime,
+	}
+}
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(key *entities.APIKey) error {
+	model := &models.APIKeyModel{
+		ServiceAccountID: key.ServiceAccountID,
+		RoleID:           key.RoleID,
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		KeyHash:          key.KeyHash,
+		ExpiresAt:        key.ExpiresAt,
+		CreatedBy:        key.CreatedBy,
+		CreateTime:       key.CreateTime,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	key.ID = model.ID
+	return nil
+}
+
+// FindByID 根據 ID 查找 API Key (不存在時返回 nil, nil)
+func (r *APIKeyRepository) FindByID(id uint) (*entities.APIKey, error) {
+	return r.findOne("id = ?", id)
+}
+
+// FindByPrefix 根據查找前綴取得 API Key (不存在時返回 nil, nil)
+func (r *APIKeyRepository) FindByPrefix(prefix string) (*entities.APIKey, error) {
+	return r.findOne("prefix = ?", prefix)
+}
+
+func (r *APIKeyRepository) FindByServiceAccount(memberID uint) ([]*entities.APIKey, error) {
+	var keyModels []models.APIKeyModel
+	if err := r.db.Where("service_account_id = ?", memberID).Order("id DESC").Find(&keyModels).Error; err != nil {
+		return nil, err
+	}
+	keys := make([]*entities.APIKey, len(keyModels))
+	for i := range keyModels {
+		keys[i] = r.mapToDomain(&keyModels[i])
+	}
+	return keys, nil
+}
+
+// Revoke 條件更新 revoked_at，已撤銷的 Key 不會再次更新
+func (r *APIKeyRepository) Revoke(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.APIKeyModel{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *APIKeyRepository) RevokeByServiceAccount(memberID uint, now time.Time) (int64, error) {
+	result := r.db.Model(&models.APIKeyModel{}).
+		Where("service_account_id = ? AND revoked_at IS NULL", memberID).
+		Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
+
+// TouchLastUsed 只在上次記錄早於 since 時更新，高頻請求不會每次都寫入
+func (r *APIKeyRepository) TouchLastUsed(id uint, ip string, now time.Time, since time.Time) error {
+	return r.db.Model(&models.APIKeyModel{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at <= ?)", id, since).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error
+}
+
+func (r *APIKeyRepository) findOne(query string, arg interface{}) (*entities.APIKey, error) {
+	var model models.APIKeyModel
+	err := r.db.Where(query, arg).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.mapToDomain(&model), nil
+}
+
+func (r *APIKeyRepository) mapToDomain(model *models.APIKeyModel) *entities.APIKey {
+	return &entities.APIKey{
+		ID:               model.ID,
+		ServiceAccountID: model.ServiceAccountID,
+		RoleID:           model.RoleID,
+		Name:             model.Name,
+		Prefix:           model.Prefix,
+		KeyHash:          model.KeyHash,
+		ExpiresAt:        model.ExpiresAt,
+		LastUsedAt:       model.LastUsedAt,
+		LastUsedIP:       model.LastUsedIP,
+		RevokedAt:        model.RevokedAt,
+		CreatedBy:        model.CreatedBy,
+		CreateTime:       model.CreateTime,
+	}
+}
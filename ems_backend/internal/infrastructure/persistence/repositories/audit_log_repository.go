@@ -4,11 +4,15 @@ import (
 	"ems_backend/internal/domain/audit_log/entities"
 	"ems_backend/internal/infrastructure/persistence/models"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// auditChainLockKey - 寫入審計日誌時的 advisory lock，確保多個實例依序延伸雜湊鏈
+const auditChainLockKey = 7_146_012_001
+
 type AuditLogRepository struct {
 	db *gorm.DB
 }
@@ -17,8 +21,56 @@ func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
 	return &AuditLogRepository{db: db}
 }
 
-// Create 創建審計日誌
-func (r *AuditLogRepository) Create(log *entities.AuditLog) error {
+// Append 鎖定雜湊鏈尾後寫入審計日誌
+func (r *AuditLogRepository) Append(log *entities.AuditLog, seal func(last *entities.AuditLog)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last *entities.AuditLog
+		var lastModel models.AuditLogModel
+		err := tx.Where("sequence IS NOT NULL").Order("sequence DESC").First(&lastModel).Error
+		if err == nil {
+			last = r.mapToDomainSingle(&lastModel)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		seal(last)
+		model := r.mapToModel(log)
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		log.ID = model.ID
+		return nil
+	})
+}
+
+// ListChain 依序號遞增返回序號大於 afterSequence 的記錄
+func (r *AuditLogRepository) ListChain(afterSequence uint64, limit int) ([]*entities.AuditLog, error) {
+	var models []*models.AuditLogModel
+	if err := r.db.Where("sequence > ?", afterSequence).Order("sequence").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return r.mapToDomain(models), nil
+}
+
+// LastInChain 返回鏈上序號最大的記錄，鏈為空時返回 nil
+func (r *AuditLogRepository) LastInChain() (*entities.AuditLog, error) {
+	var model models.AuditLogModel
+	err := r.db.Where("sequence IS NOT NULL").Order("sequence DESC").First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.mapToDomainSingle(&model), nil
+}
+
+// mapToModel 將領域實體轉換為模型
+func (r *AuditLogRepository) mapToModel(log *entities.AuditLog) *models.AuditLogModel {
 	// 將 map[string]interface{} 轉換為 JSONB
 	var details models.JSONB
 	if log.Details != nil {
@@ -26,7 +78,12 @@ func (r *AuditLogRepository) Create(log *entities.AuditLog) error {
 		details = models.JSONB(detailsJSON)
 	}
 
-	model := &models.AuditLogModel{
+	var sequence *uint64
+	if log.Sequence > 0 {
+		sequence = &log.Sequence
+	}
+
+	return &models.AuditLogModel{
 		MemberID:     log.MemberID,
 		RoleID:       log.RoleID,
 		Action:       log.Action,
@@ -38,9 +95,10 @@ func (r *AuditLogRepository) Create(log *entities.AuditLog) error {
 		Status:       log.Status,
 		ErrorMessage: log.ErrorMessage,
 		CreateTime:   log.CreateTime,
+		Sequence:     sequence,
+		PrevHash:     log.PrevHash,
+		Hash:         log.Hash,
 	}
-
-	return r.db.Create(model).Error
 }
 
 // GetByID 根據ID獲取審計日誌
@@ -57,27 +115,7 @@ func (r *AuditLogRepository) Query(filter *entities.AuditLogFilter) ([]*entities
 	query := r.db.Model(&models.AuditLogModel{})
 
 	// 應用過濾條件
-	if filter.MemberID != nil {
-		query = query.Where("member_id = ?", *filter.MemberID)
-	}
-	if filter.RoleID != nil {
-		query = query.Where("role_id = ?", *filter.RoleID)
-	}
-	if filter.Action != "" {
-		query = query.Where("action = ?", filter.Action)
-	}
-	if filter.ResourceType != "" {
-		query = query.Where("resource_type = ?", filter.ResourceType)
-	}
-	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
-	}
-	if !filter.StartTime.IsZero() {
-		query = query.Where("create_time >= ?", filter.StartTime)
-	}
-	if !filter.EndTime.IsZero() {
-		query = query.Where("create_time <= ?", filter.EndTime)
-	}
+	query = applyAuditLogFilter(query, filter)
 
 	// 應用分頁
 	if filter.Offset > 0 {
@@ -152,6 +190,43 @@ func (r *AuditLogRepository) Count(filter *entities.AuditLogFilter) (int64, erro
 	query := r.db.Model(&models.AuditLogModel{})
 
 	// 應用過濾條件
+	query = applyAuditLogFilter(query, filter)
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ListAfterID 依 ID 遞增返回符合條件且 ID 大於 afterID 的記錄
+func (r *AuditLogRepository) ListAfterID(filter *entities.AuditLogFilter, afterID uint, limit int) ([]*entities.AuditLog, error) {
+	query := applyAuditLogFilter(r.db.Model(&models.AuditLogModel{}), filter)
+
+	var models []*models.AuditLogModel
+	if err := query.Where("id > ?", afterID).Order("id").Limit(limit).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return r.mapToDomain(models), nil
+}
+
+// DeleteByIDs 刪除指定的審計日誌
+func (r *AuditLogRepository) DeleteByIDs(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.Where("id IN ?", ids).Delete(&models.AuditLogModel{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteOlderThan 刪除早於指定天數的審計日誌
+func (r *AuditLogRepository) DeleteOlderThan(days int) error {
+	cutoffDate := time.Now().AddDate(0, 0, -days)
+	return r.db.Where("create_time < ?", cutoffDate).Delete(&models.AuditLogModel{}).Error
+}
+
+// applyAuditLogFilter 套用查詢條件 (不含分頁)
+func applyAuditLogFilter(query *gorm.DB, filter *entities.AuditLogFilter) *gorm.DB {
 	if filter.MemberID != nil {
 		query = query.Where("member_id = ?", *filter.MemberID)
 	}
@@ -173,18 +248,7 @@ func (r *AuditLogRepository) Count(filter *entities.AuditLogFilter) (int64, erro
 	if !filter.EndTime.IsZero() {
 		query = query.Where("create_time <= ?", filter.EndTime)
 	}
-
-	if err := query.Count(&count).Error; err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
-
-// DeleteOlderThan 刪除早於指定天數的審計日誌
-func (r *AuditLogRepository) DeleteOlderThan(days int) error {
-	cutoffDate := time.Now().AddDate(0, 0, -days)
-	return r.db.Where("create_time < ?", cutoffDate).Delete(&models.AuditLogModel{}).Error
+	return query
 }
 
 // mapToDomain 將多個模型轉換為領域實體
@@ -204,6 +268,11 @@ func (r *AuditLogRepository) mapToDomainSingle(model *models.AuditLogModel) *ent
 		json.Unmarshal([]byte(model.Details), &details)
 	}
 
+	var sequence uint64
+	if model.Sequence != nil {
+		sequence = *model.Sequence
+	}
+
 	return &entities.AuditLog{
 		ID:           model.ID,
 		MemberID:     model.MemberID,
@@ -217,5 +286,8 @@ func (r *AuditLogRepository) mapToDomainSingle(model *models.AuditLogModel) *ent
 		Status:       model.Status,
 		ErrorMessage: model.ErrorMessage,
 		CreateTime:   model.CreateTime,
+		Sequence:     sequence,
+		PrevHash:     model.PrevHash,
+		Hash:         model.Hash,
 	}
 }
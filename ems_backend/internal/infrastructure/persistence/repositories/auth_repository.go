@@ -33,9 +33,21 @@ func (r *AuthRepository) SaveSession(session *entities.AuthSession) error {
 		CreateTime:   session.CreateTime,
 		ModifyID:     session.ModifyID,
 		ModifyTime:   session.ModifyTime,
+		IPAddress:    session.IPAddress,
+		UserAgent:    session.UserAgent,
+	}
+	if session.SessionID != "" {
+		model.SessionID = &session.SessionID
+	}
+	if !session.ExpiresAt.IsZero() {
+		model.ExpiresAt = &session.ExpiresAt
 	}
 
-	return r.db.Save(model).Error
+	if err := r.db.Save(model).Error; err != nil {
+		return err
+	}
+	session.ID = model.ID
+	return nil
 }
 
 func (r *AuthRepository) UpdateAccessToken(id uint, accessToken string) error {
@@ -47,7 +59,7 @@ func (r *AuthRepository) UpdateAccessToken(id uint, accessToken string) error {
 
 func (r *AuthRepository) FindSessionByRefreshToken(refreshToken string) (*entities.AuthSession, error) {
 	var model models.AccessTokenModel
-	if err := r.db.Where("refresh_token = ?", refreshToken).First(&model).Error; err != nil {
+	if err := r.db.Where("refresh_token = ? AND revoked_at IS NULL", refreshToken).First(&model).Error; err != nil {
 		return nil, err
 	}
 
@@ -63,12 +75,84 @@ func (r *AuthRepository) FindSessionByMemberID(memberID uint) (*entities.AuthSes
 	return r.mapToDomain(&model)
 }
 
+// InvalidateSession 標記會話已撤銷；保留紀錄以同步撤銷清單，由 DeleteSessionsBefore 清除
 func (r *AuthRepository) InvalidateSession(sessionID uint) error {
-	return r.db.Model(&models.AccessTokenModel{}).Where("id = ?", sessionID).Delete(&models.AccessTokenModel{}).Error
+	return r.db.Model(&models.AccessTokenModel{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
 }
 
+// InvalidateAllSessionsByMemberID 撤銷會員所有會話
+// 其他實例在下一次同步撤銷清單後拒絕這些會話的 access token
 func (r *AuthRepository) InvalidateAllSessionsByMemberID(memberID uint) error {
-	return r.db.Model(&models.AccessTokenModel{}).Where("member_id = ?", memberID).Update("is_active", false).Error
+	_, err := r.RevokeSessionsByMemberID(memberID, "", time.Now())
+	return err
+}
+
+func (r *AuthRepository) FindActiveSessionsByMemberID(memberID uint, now time.Time) ([]*entities.AuthSession, error) {
+	var sessionModels []models.AccessTokenModel
+	if err := r.db.Where("member_id = ? AND revoked_at IS NULL AND session_id IS NOT NULL AND (expires_at IS NULL OR expires_at > ?)", memberID, now).
+		Order("modify_time DESC").
+		Find(&sessionModels).Error; err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entities.AuthSession, 0, len(sessionModels))
+	for i := range sessionModels {
+		session, err := r.mapToDomain(&sessionModels[i])
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// FindSessionBySessionID 根據 sid 查找會話 (不存在時返回 nil, nil)
+func (r *AuthRepository) FindSessionBySessionID(sessionID string) (*entities.AuthSession, error) {
+	var model models.AccessTokenModel
+	err := r.db.Where("session_id = ?", sessionID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.mapToDomain(&model)
+}
+
+func (r *AuthRepository) RevokeSessionsByMemberID(memberID uint, exceptSessionID string, now time.Time) ([]string, error) {
+	var sessionIDs []string
+	err := r.db.Raw(`
+		UPDATE access_token SET revoked_at = ?
+		WHERE member_id = ? AND revoked_at IS NULL AND (session_id IS NULL OR session_id <> ?)
+		RETURNING COALESCE(session_id, '')
+	`, now, memberID, exceptSessionID).Scan(&sessionIDs).Error
+	return sessionIDs, err
+}
+
+func (r *AuthRepository) FindRevokedSince(since time.Time) (map[string]time.Time, error) {
+	var rows []struct {
+		SessionID string
+		RevokedAt time.Time
+	}
+	if err := r.db.Model(&models.AccessTokenModel{}).
+		Select("session_id, revoked_at").
+		Where("revoked_at > ? AND session_id IS NOT NULL", since).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		revoked[row.SessionID] = row.RevokedAt
+	}
+	return revoked, nil
+}
+
+func (r *AuthRepository) DeleteSessionsBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("revoked_at < ? OR expires_at < ?", cutoff, cutoff).Delete(&models.AccessTokenModel{})
+	return result.RowsAffected, result.Error
 }
 
 func (r *AuthRepository) mapToDomain(model *models.AccessTokenModel) (*entities.AuthSession, error) {
@@ -87,14 +171,24 @@ func (r *AuthRepository) mapToDomain(model *models.AccessTokenModel) (*entities.
 		return nil, err
 	}
 
-	return &entities.AuthSession{
+	session := &entities.AuthSession{
 		ID:           model.ID,
 		MemberID:     memberID,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		IPAddress:    model.IPAddress,
+		UserAgent:    model.UserAgent,
+		RevokedAt:    model.RevokedAt,
 		CreateID:     model.CreateID,
 		CreateTime:   model.CreateTime,
 		ModifyID:     model.ModifyID,
 		ModifyTime:   model.ModifyTime,
-	}, nil
+	}
+	if model.SessionID != nil {
+		session.SessionID = *model.SessionID
+	}
+	if model.ExpiresAt != nil {
+		session.ExpiresAt = *model.ExpiresAt
+	}
+	return session, nil
 }
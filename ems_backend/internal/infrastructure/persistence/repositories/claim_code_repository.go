@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/device/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type ClaimCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewClaimCodeRepository(db *gorm.DB) *ClaimCodeRepository {
+	return &ClaimCodeRepository{db: db}
+}
+
+func (r *ClaimCodeRepository) Create(code *entities.ClaimCode) error {
+	model := r.toModel(code)
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	code.ID = model.ID
+	return nil
+}
+
+// FindByHash 根據雜湊查找認領碼 (不存在時返回 nil, nil)
+func (r *ClaimCodeRepository) FindByHash(codeHash string) (*entities.ClaimCode, error) {
+	var model models.ClaimCodeModel
+	err := r.db.Where("code_hash = ?", codeHash).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+func (r *ClaimCodeRepository) FindByDeviceID(deviceID uint) ([]*entities.ClaimCode, error) {
+	var codeModels []models.ClaimCodeModel
+	if err := r.db.Where("device_id = ?", deviceID).Order("issued_at DESC").Find(&codeModels).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntities(codeModels), nil
+}
+
+func (r *ClaimCodeRepository) FindActiveExpired(now time.Time) ([]*entities.ClaimCode, error) {
+	var codeModels []models.ClaimCodeModel
+	if err := r.db.Where("status = ? AND expires_at <= ?", entities.ClaimCodeStatusActive, now).
+		Order("expires_at").Find(&codeModels).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntities(codeModels), nil
+}
+
+func (r *ClaimCodeRepository) RevokeActiveByDeviceID(deviceID uint) (int64, error) {
+	result := r.db.Model(&models.ClaimCodeModel{}).
+		Where("device_id = ? AND status = ?", deviceID, entities.ClaimCodeStatusActive).
+		Updates(map[string]interface{}{
+			"status":    entities.ClaimCodeStatusRevoked,
+			"closed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// CloseIfActive 條件更新，避免同一認領碼被並行兌換兩次
+func (r *ClaimCodeRepository) CloseIfActive(code *entities.ClaimCode) (bool, error) {
+	result := r.db.Model(&models.ClaimCodeModel{}).
+		Where("id = ? AND status = ?", code.ID, entities.ClaimCodeStatusActive).
+		Updates(map[string]interface{}{
+			"status":      code.Status,
+			"redeemed_by": code.RedeemedBy,
+			"redeemed_at": code.RedeemedAt,
+			"company_id":  code.CompanyID,
+			"closed_at":   code.ClosedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ClaimCodeRepository) Reopen(id uint) error {
+	return r.db.Model(&models.ClaimCodeModel{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      entities.ClaimCodeStatusActive,
+			"redeemed_by": nil,
+			"redeemed_at": nil,
+			"company_id":  nil,
+			"closed_at":   nil,
+		}).Error
+}
+
+func (r *ClaimCodeRepository) toEntities(codeModels []models.ClaimCodeModel) []*entities.ClaimCode {
+	codes := make([]*entities.ClaimCode, len(codeModels))
+	for i := range codeModels {
+		codes[i] = r.toEntity(&codeModels[i])
+	}
+	return codes
+}
+
+func (r *ClaimCodeRepository) toEntity(model *models.ClaimCodeModel) *entities.ClaimCode {
+	return &entities.ClaimCode{
+		ID:           model.ID,
+		DeviceID:     model.DeviceID,
+		CodeHash:     model.CodeHash,
+		CodeHint:     model.CodeHint,
+		Status:       model.Status,
+		ExpiresAt:    model.ExpiresAt,
+		IssuedBy:     model.IssuedBy,
+		IssuedRoleID: model.IssuedRoleID,
+		IssuedAt:     model.IssuedAt,
+		RedeemedBy:   model.RedeemedBy,
+		RedeemedAt:   model.RedeemedAt,
+		CompanyID:    model.CompanyID,
+		ClosedAt:     model.ClosedAt,
+	}
+}
+
+func (r *ClaimCodeRepository) toModel(code *entities.ClaimCode) *models.ClaimCodeModel {
+	return &models.ClaimCodeModel{
+		ID:           code.ID,
+		DeviceID:     code.DeviceID,
+		CodeHash:     code.CodeHash,
+		CodeHint:     code.CodeHint,
+		Status:       code.Status,
+		ExpiresAt:    code.ExpiresAt,
+		IssuedBy:     code.IssuedBy,
+		IssuedRoleID: code.IssuedRoleID,
+		IssuedAt:     code.IssuedAt,
+		RedeemedBy:   code.RedeemedBy,
+		RedeemedAt:   code.RedeemedAt,
+		CompanyID:    code.CompanyID,
+		ClosedAt:     code.ClosedAt,
+	}
+}
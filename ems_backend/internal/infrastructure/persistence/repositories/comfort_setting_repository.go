@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/comfort_control/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ComfortSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewComfortSettingRepository(db *gorm.DB) *ComfortSettingRepository {
+	return &ComfortSettingRepository{db: db}
+}
+
+func (r *ComfortSettingRepository) FindByCompanyDeviceID(companyDeviceID uint) ([]*entities.ComfortSetting, error) {
+	var settingModels []models.ComfortSettingModel
+	if err := r.db.Where("company_device_id = ?", companyDeviceID).Order("area_id").Find(&settingModels).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntities(settingModels), nil
+}
+
+// FindByArea 取得區域設定 (未設定時返回 nil, nil)
+func (r *ComfortSettingRepository) FindByArea(companyDeviceID uint, areaID string) (*entities.ComfortSetting, error) {
+	var model models.ComfortSettingModel
+	err := r.db.Where("company_device_id = ? AND area_id = ?", companyDeviceID, areaID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+func (r *ComfortSettingRepository) FindEnabled() ([]*entities.ComfortSetting, error) {
+	var settingModels []models.ComfortSettingModel
+	if err := r.db.Where("enabled = ?", true).Order("company_device_id, area_id").Find(&settingModels).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntities(settingModels), nil
+}
+
+// Save 依 (company_device_id, area_id) 新增或更新設定
+func (r *ComfortSettingRepository) Save(setting *entities.ComfortSetting) error {
+	model := r.toModel(setting)
+	if model.ID != 0 {
+		return r.db.Save(model).Error
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "company_device_id"}, {Name: "area_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"enabled", "dry_run", "comfort_low", "comfort_high", "hysteresis",
+			"min_on_seconds", "min_off_seconds", "updated_by", "updated_at",
+		}),
+	}).Create(model).Error
+	if err != nil {
+		return err
+	}
+	setting.ID = model.ID
+	return nil
+}
+
+func (r *ComfortSettingRepository) toEntities(settingModels []models.ComfortSettingModel) []*entities.ComfortSetting {
+	settings := make([]*entities.ComfortSetting, len(settingModels))
+	for i := range settingModels {
+		settings[i] = r.toEntity(&settingModels[i])
+	}
+	return settings
+}
+
+func (r *ComfortSettingRepository) toEntity(model *models.ComfortSettingModel) *entities.ComfortSetting {
+	return &entities.ComfortSetting{
+		ID:              model.ID,
+		CompanyDeviceID: model.CompanyDeviceID,
+		AreaID:          model.AreaID,
+		Enabled:         model.Enabled,
+		DryRun:          model.DryRun,
+		ComfortLow:      model.ComfortLow,
+		ComfortHigh:     model.ComfortHigh,
+		Hysteresis:      model.Hysteresis,
+		MinOnSeconds:    model.MinOnSeconds,
+		MinOffSeconds:   model.MinOffSeconds,
+		UpdatedBy:       model.UpdatedBy,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+}
+
+func (r *ComfortSettingRepository) toModel(setting *entities.ComfortSetting) *models.ComfortSettingModel {
+	return &models.ComfortSettingModel{
+		ID:              setting.ID,
+		CompanyDeviceID: setting.CompanyDeviceID,
+		AreaID:          setting.AreaID,
+		Enabled:         setting.Enabled,
+		DryRun:          setting.DryRun,
+		ComfortLow:      setting.ComfortLow,
+		ComfortHigh:     setting.ComfortHigh,
+		Hysteresis:      setting.Hysteresis,
+		MinOnSeconds:    setting.MinOnSeconds,
+		MinOffSeconds:   setting.MinOffSeconds,
+		UpdatedBy:       setting.UpdatedBy,
+		CreatedAt:       setting.CreatedAt,
+		UpdatedAt:       setting.UpdatedAt,
+	}
+}
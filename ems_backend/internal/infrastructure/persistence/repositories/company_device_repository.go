@@ -128,6 +128,24 @@ func (r *CompanyDeviceRepository) Update(companyDevice *entities.CompanyDevice)
 	return nil
 }
 
+func (r *CompanyDeviceRepository) UpdateContentIfVersion(companyDevice *entities.CompanyDevice, expectedVersion int64) error {
+	result := r.db.Model(&models.CompanyDeviceModel{}).
+		Where("id = ? AND COALESCE((content->>'version')::numeric, 0) = ?", companyDevice.ID, expectedVersion).
+		Updates(map[string]interface{}{
+			"content":     models.JSONB(companyDevice.Content),
+			"modify_id":   companyDevice.ModifyID,
+			"modify_time": companyDevice.ModifyTime,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entities.ErrContentVersionConflict
+	}
+	return nil
+}
+
 func (r *CompanyDeviceRepository) Delete(id uint) error {
 	result := r.db.Delete(&models.CompanyDeviceModel{}, id)
 	if result.Error != nil {
@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"encoding/json"
+
+	"ems_backend/internal/domain/demand_control/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DemandControlRepository struct {
+	db *gorm.DB
+}
+
+func NewDemandControlRepository(db *gorm.DB) *DemandControlRepository {
+	return &DemandControlRepository{db: db}
+}
+
+// ============================================
+// Settings
+// ============================================
+
+// FindSettingByCompanyID 取得公司需量設定 (未設定時返回 nil, nil)
+func (r *DemandControlRepository) FindSettingByCompanyID(companyID uint) (*entities.DemandSetting, error) {
+	var model models.DemandSettingModel
+	err := r.db.Where("company_id = ?", companyID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toSettingEntity(&model)
+}
+
+func (r *DemandControlRepository) FindEnabledSettings() ([]*entities.DemandSetting, error) {
+	var settingModels []models.DemandSettingModel
+	if err := r.db.Where("enabled = ?", true).Order("company_id").Find(&settingModels).Error; err != nil {
+		return nil, err
+	}
+
+	settings := make([]*entities.DemandSetting, 0, len(settingModels))
+	for i := range settingModels {
+		setting, err := r.toSettingEntity(&settingModels[i])
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+func (r *DemandControlRepository) SaveSetting(setting *entities.DemandSetting) error {
+	loadsJSON, err := json.Marshal(setting.Loads)
+	if err != nil {
+		return err
+	}
+	model := &models.DemandSettingModel{
+		CompanyID:      setting.CompanyID,
+		Enabled:        setting.Enabled,
+		DryRun:         setting.DryRun,
+		ContractKW:     setting.ContractKW,
+		ShedRatio:      setting.ShedRatio,
+		RestoreRatio:   setting.RestoreRatio,
+		MinShedSeconds: setting.MinShedSeconds,
+		Loads:          models.JSONB(loadsJSON),
+		ModifyID:       setting.ModifyID,
+		ModifyTime:     setting.ModifyTime,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "company_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"enabled", "dry_run", "contract_kw", "shed_ratio", "restore_ratio",
+			"min_shed_seconds", "loads", "modify_id", "modify_time",
+		}),
+	}).Create(model).Error
+}
+
+// ============================================
+// Active sheds
+// ============================================
+
+func (r *DemandControlRepository) FindActiveSheds(companyID uint) ([]*entities.ActiveShed, error) {
+	var shedModels []models.DemandActiveShedModel
+	if err := r.db.Where("company_id = ?", companyID).Order("shed_at").Find(&shedModels).Error; err != nil {
+		return nil, err
+	}
+
+	sheds := make([]*entities.ActiveShed, len(shedModels))
+	for i, model := range shedModels {
+		sheds[i] = &entities.ActiveShed{
+			ID:        model.ID,
+			CompanyID: model.CompanyID,
+			Load: entities.SheddableLoad{
+				CompanyDeviceID: model.CompanyDeviceID,
+				TargetType:      model.TargetType,
+				TargetID:        model.TargetID,
+			},
+			DryRun: model.DryRun,
+			ShedAt: model.ShedAt,
+		}
+	}
+	return sheds, nil
+}
+
+func (r *DemandControlRepository) SaveActiveShed(shed *entities.ActiveShed) error {
+	model := &models.DemandActiveShedModel{
+		CompanyID:       shed.CompanyID,
+		CompanyDeviceID: shed.Load.CompanyDeviceID,
+		TargetType:      shed.Load.TargetType,
+		TargetID:        shed.Load.TargetID,
+		DryRun:          shed.DryRun,
+		ShedAt:          shed.ShedAt,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	shed.ID = model.ID
+	return nil
+}
+
+func (r *DemandControlRepository) DeleteActiveShed(id uint) error {
+	return r.db.Delete(&models.DemandActiveShedModel{}, id).Error
+}
+
+// ============================================
+// Events
+// ============================================
+
+func (r *DemandControlRepository) SaveEvent(event *entities.DemandEvent) error {
+	model := &models.DemandEventModel{
+		CompanyID:       event.CompanyID,
+		EventType:       event.EventType,
+		CompanyDeviceID: event.CompanyDeviceID,
+		TargetType:      event.TargetType,
+		TargetID:        event.TargetID,
+		RollingKW:       event.RollingKW,
+		PredictedKW:     event.PredictedKW,
+		ContractKW:      event.ContractKW,
+		DryRun:          event.DryRun,
+		CommandID:       event.CommandID,
+		Message:         event.Message,
+		CreatedAt:       event.CreatedAt,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	event.ID = model.ID
+	return nil
+}
+
+func (r *DemandControlRepository) FindEventsByCompanyID(companyID uint, limit int) ([]*entities.DemandEvent, error) {
+	var eventModels []models.DemandEventModel
+	query := r.db.Where("company_id = ?", companyID).Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&eventModels).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]*entities.DemandEvent, len(eventModels))
+	for i, model := range eventModels {
+		events[i] = &entities.DemandEvent{
+			ID:              model.ID,
+			CompanyID:       model.CompanyID,
+			EventType:       model.EventType,
+			CompanyDeviceID: model.CompanyDeviceID,
+			TargetType:      model.TargetType,
+			TargetID:        model.TargetID,
+			RollingKW:       model.RollingKW,
+			PredictedKW:     model.PredictedKW,
+			ContractKW:      model.ContractKW,
+			DryRun:          model.DryRun,
+			CommandID:       model.CommandID,
+			Message:         model.Message,
+			CreatedAt:       model.CreatedAt,
+		}
+	}
+	return events, nil
+}
+
+// ============================================
+// Mapping
+// ============================================
+
+func (r *DemandControlRepository) toSettingEntity(model *models.DemandSettingModel) (*entities.DemandSetting, error) {
+	setting := &entities.DemandSetting{
+		CompanyID:      model.CompanyID,
+		Enabled:        model.Enabled,
+		DryRun:         model.DryRun,
+		ContractKW:     model.ContractKW,
+		ShedRatio:      model.ShedRatio,
+		RestoreRatio:   model.RestoreRatio,
+		MinShedSeconds: model.MinShedSeconds,
+		Loads:          []entities.SheddableLoad{},
+		ModifyID:       model.ModifyID,
+		ModifyTime:     model.ModifyTime,
+	}
+	if len(model.Loads) > 0 {
+		if err := json.Unmarshal(model.Loads, &setting.Loads); err != nil {
+			return nil, err
+		}
+	}
+	return setting, nil
+}
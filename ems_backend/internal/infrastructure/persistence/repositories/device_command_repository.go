@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/device_command/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type DeviceCommandRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceCommandRepository(db *gorm.DB) *DeviceCommandRepository {
+	return &DeviceCommandRepository{db: db}
+}
+
+func (r *DeviceCommandRepository) Save(command *entities.DeviceCommand) error {
+	model := r.toModel(command)
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	command.ID = model.ID
+	return nil
+}
+
+func (r *DeviceCommandRepository) Update(command *entities.DeviceCommand) error {
+	return r.db.Save(r.toModel(command)).Error
+}
+
+func (r *DeviceCommandRepository) FindByCommandID(commandID string) (*entities.DeviceCommand, error) {
+	var model models.DeviceCommandModel
+	if err := r.db.Where("command_id = ?", commandID).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+func (r *DeviceCommandRepository) FindByCompanyDeviceID(companyDeviceID uint, limit int) ([]*entities.DeviceCommand, error) {
+	var commandModels []models.DeviceCommandModel
+	query := r.db.Where("company_device_id = ?", companyDeviceID).Order("requested_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&commandModels).Error; err != nil {
+		return nil, err
+	}
+
+	commands := make([]*entities.DeviceCommand, len(commandModels))
+	for i := range commandModels {
+		commands[i] = r.toEntity(&commandModels[i])
+	}
+	return commands, nil
+}
+
+func (r *DeviceCommandRepository) toEntity(model *models.DeviceCommandModel) *entities.DeviceCommand {
+	return &entities.DeviceCommand{
+		ID:              model.ID,
+		CommandID:       model.CommandID,
+		CompanyDeviceID: model.CompanyDeviceID,
+		DeviceSN:        model.DeviceSN,
+		TargetType:      model.TargetType,
+		TargetID:        model.TargetID,
+		Action:          model.Action,
+		Mode:            model.Mode,
+		Setpoint:        model.Setpoint,
+		Status:          model.Status,
+		Message:         model.Message,
+		RequestedBy:     model.RequestedBy,
+		RequestedAt:     model.RequestedAt,
+		SentAt:          model.SentAt,
+		AckAt:           model.AckAt,
+	}
+}
+
+func (r *DeviceCommandRepository) toModel(command *entities.DeviceCommand) *models.DeviceCommandModel {
+	return &models.DeviceCommandModel{
+		ID:              command.ID,
+		CommandID:       command.CommandID,
+		CompanyDeviceID: command.CompanyDeviceID,
+		DeviceSN:        command.DeviceSN,
+		TargetType:      command.TargetType,
+		TargetID:        command.TargetID,
+		Action:          command.Action,
+		Mode:            command.Mode,
+		Setpoint:        command.Setpoint,
+		Status:          command.Status,
+		Message:         command.Message,
+		RequestedBy:     command.RequestedBy,
+		RequestedAt:     command.RequestedAt,
+		SentAt:          command.SentAt,
+		AckAt:           command.AckAt,
+	}
+}
@@ -107,3 +107,41 @@ func (r *deviceRepository) FindUnassigned() ([]*entities.Device, error) {
 	}
 	return result, nil
 }
+
+// IsAssigned 檢查設備是否已綁定到公司
+func (r *deviceRepository) IsAssigned(id uint) (bool, error) {
+	var count int64
+	if err := r.db.Table("company_device").Where("device_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindExistingSNs 返回列表中已存在的 SN
+func (r *deviceRepository) FindExistingSNs(sns []string) ([]string, error) {
+	var existing []string
+	if len(sns) == 0 {
+		return existing, nil
+	}
+	if err := r.db.Model(&models.DeviceModel{}).Where("sn IN ?", sns).Pluck("sn", &existing).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// CreateBatch 在同一交易內批次創建設備
+func (r *deviceRepository) CreateBatch(devices []*entities.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, device := range devices {
+			model := models.DeviceModelFromEntity(device)
+			if err := tx.Create(model).Error; err != nil {
+				return err
+			}
+			device.ID = model.ID
+		}
+		return nil
+	})
+}
@@ -0,0 +1,372 @@
+package repositories
+
+import (
+	"encoding/json"
+
+	"ems_backend/internal/domain/firmware/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ============================================
+// Firmware catalog & inventory
+// ============================================
+
+type FirmwareRepository struct {
+	db *gorm.DB
+}
+
+func NewFirmwareRepository(db *gorm.DB) *FirmwareRepository {
+	return &FirmwareRepository{db: db}
+}
+
+func (r *FirmwareRepository) FindAll() ([]*entities.Firmware, error) {
+	var firmwareModels []models.FirmwareModel
+	if err := r.db.Order("create_time DESC").Find(&firmwareModels).Error; err != nil {
+		return nil, err
+	}
+
+	firmwares := make([]*entities.Firmware, len(firmwareModels))
+	for i := range firmwareModels {
+		firmwares[i] = r.toFirmwareEntity(&firmwareModels[i])
+	}
+	return firmwares, nil
+}
+
+// FindByID 取得韌體檔案 (不存在時返回 nil, nil)
+func (r *FirmwareRepository) FindByID(id uint) (*entities.Firmware, error) {
+	var model models.FirmwareModel
+	err := r.db.Where("id = ?", id).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toFirmwareEntity(&model), nil
+}
+
+func (r *FirmwareRepository) ExistsByVersion(version, hardwareVersion string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.FirmwareModel{}).
+		Where("version = ? AND hardware_version = ?", version, hardwareVersion).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *FirmwareRepository) Save(firmware *entities.Firmware) error {
+	model := &models.FirmwareModel{
+		Version:         firmware.Version,
+		HardwareVersion: firmware.HardwareVersion,
+		URL:             firmware.URL,
+		SHA256:          firmware.SHA256,
+		SizeBytes:       firmware.SizeBytes,
+		ReleaseNotes:    firmware.ReleaseNotes,
+		CreateID:        firmware.CreateID,
+		CreateTime:      firmware.CreateTime,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	firmware.ID = model.ID
+	return nil
+}
+
+func (r *FirmwareRepository) FindInventory() ([]*entities.DeviceFirmware, error) {
+	var inventoryModels []models.DeviceFirmwareModel
+	if err := r.db.Order("device_id").Find(&inventoryModels).Error; err != nil {
+		return nil, err
+	}
+
+	inventory := make([]*entities.DeviceFirmware, len(inventoryModels))
+	for i, model := range inventoryModels {
+		inventory[i] = &entities.DeviceFirmware{
+			DeviceID:        model.DeviceID,
+			FirmwareVersion: model.FirmwareVersion,
+			HardwareVersion: model.HardwareVersion,
+			ReportedAt:      model.ReportedAt,
+		}
+	}
+	return inventory, nil
+}
+
+// FindInventoryByDeviceID 取得設備版本 (尚未回報時返回 nil, nil)
+func (r *FirmwareRepository) FindInventoryByDeviceID(deviceID uint) (*entities.DeviceFirmware, error) {
+	var model models.DeviceFirmwareModel
+	err := r.db.Where("device_id = ?", deviceID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.DeviceFirmware{
+		DeviceID:        model.DeviceID,
+		FirmwareVersion: model.FirmwareVersion,
+		HardwareVersion: model.HardwareVersion,
+		ReportedAt:      model.ReportedAt,
+	}, nil
+}
+
+func (r *FirmwareRepository) SaveInventory(inventory *entities.DeviceFirmware) error {
+	model := &models.DeviceFirmwareModel{
+		DeviceID:        inventory.DeviceID,
+		FirmwareVersion: inventory.FirmwareVersion,
+		HardwareVersion: inventory.HardwareVersion,
+		ReportedAt:      inventory.ReportedAt,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "device_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"firmware_version", "hardware_version", "reported_at"}),
+	}).Create(model).Error
+}
+
+func (r *FirmwareRepository) toFirmwareEntity(model *models.FirmwareModel) *entities.Firmware {
+	return &entities.Firmware{
+		ID:              model.ID,
+		Version:         model.Version,
+		HardwareVersion: model.HardwareVersion,
+		URL:             model.URL,
+		SHA256:          model.SHA256,
+		SizeBytes:       model.SizeBytes,
+		ReleaseNotes:    model.ReleaseNotes,
+		CreateID:        model.CreateID,
+		CreateTime:      model.CreateTime,
+	}
+}
+
+// ============================================
+// Rollout campaigns
+// ============================================
+
+type FirmwareCampaignRepository struct {
+	db *gorm.DB
+}
+
+func NewFirmwareCampaignRepository(db *gorm.DB) *FirmwareCampaignRepository {
+	return &FirmwareCampaignRepository{db: db}
+}
+
+// Save 新增或更新活動 (ID 為 0 時新增)
+func (r *FirmwareCampaignRepository) Save(campaign *entities.Campaign) error {
+	deviceIDs := campaign.DeviceIDs
+	if deviceIDs == nil {
+		deviceIDs = []uint{}
+	}
+	deviceIDsJSON, err := json.Marshal(deviceIDs)
+	if err != nil {
+		return err
+	}
+
+	model := &models.FirmwareCampaignModel{
+		ID:                   campaign.ID,
+		Name:                 campaign.Name,
+		FirmwareID:           campaign.FirmwareID,
+		TargetMode:           campaign.TargetMode,
+		Percentage:           campaign.Percentage,
+		DeviceIDs:            models.JSONB(deviceIDsJSON),
+		BatchSize:            campaign.BatchSize,
+		MaxFailureRate:       campaign.MaxFailureRate,
+		MinSamples:           campaign.MinSamples,
+		DeviceTimeoutSeconds: campaign.DeviceTimeoutSeconds,
+		Status:               campaign.Status,
+		HaltReason:           campaign.HaltReason,
+		SucceededOffset:      campaign.SucceededOffset,
+		FailedOffset:         campaign.FailedOffset,
+		CreateID:             campaign.CreateID,
+		CreateTime:           campaign.CreateTime,
+		ModifyID:             campaign.ModifyID,
+		ModifyTime:           campaign.ModifyTime,
+		FinishedAt:           campaign.FinishedAt,
+	}
+	if err := r.db.Save(model).Error; err != nil {
+		return err
+	}
+	campaign.ID = model.ID
+	return nil
+}
+
+// FindByID 取得活動 (不存在時返回 nil, nil)
+func (r *FirmwareCampaignRepository) FindByID(id uint) (*entities.Campaign, error) {
+	var model models.FirmwareCampaignModel
+	err := r.db.Where("id = ?", id).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toCampaignEntity(&model)
+}
+
+func (r *FirmwareCampaignRepository) FindAll() ([]*entities.Campaign, error) {
+	return r.findCampaigns(r.db.Order("create_time DESC"))
+}
+
+func (r *FirmwareCampaignRepository) FindByStatus(status string) ([]*entities.Campaign, error) {
+	return r.findCampaigns(r.db.Where("status = ?", status).Order("id"))
+}
+
+func (r *FirmwareCampaignRepository) SaveDevices(campaignID uint, devices []*entities.CampaignDevice) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	deviceModels := make([]*models.FirmwareCampaignDeviceModel, len(devices))
+	for i, device := range devices {
+		device.CampaignID = campaignID
+		deviceModels[i] = r.toDeviceModel(device)
+	}
+	if err := r.db.CreateInBatches(deviceModels, 200).Error; err != nil {
+		return err
+	}
+	for i, model := range deviceModels {
+		devices[i].ID = model.ID
+	}
+	return nil
+}
+
+func (r *FirmwareCampaignRepository) UpdateDevice(device *entities.CampaignDevice) error {
+	return r.db.Save(r.toDeviceModel(device)).Error
+}
+
+func (r *FirmwareCampaignRepository) FindDevices(campaignID uint) ([]*entities.CampaignDevice, error) {
+	var deviceModels []models.FirmwareCampaignDeviceModel
+	if err := r.db.Where("campaign_id = ?", campaignID).Order("id").Find(&deviceModels).Error; err != nil {
+		return nil, err
+	}
+
+	devices := make([]*entities.CampaignDevice, len(deviceModels))
+	for i := range deviceModels {
+		devices[i] = r.toDeviceEntity(&deviceModels[i])
+	}
+	return devices, nil
+}
+
+// FindDeviceByCommandID 依 OTA 命令 UUID 查找 (不存在時返回 nil, nil)
+func (r *FirmwareCampaignRepository) FindDeviceByCommandID(commandID string) (*entities.CampaignDevice, error) {
+	var model models.FirmwareCampaignDeviceModel
+	err := r.db.Where("command_id = ?", commandID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toDeviceEntity(&model), nil
+}
+
+// FindInFlightDevice 設備目前進行中的更新 (不存在時返回 nil, nil)
+func (r *FirmwareCampaignRepository) FindInFlightDevice(deviceID uint) (*entities.CampaignDevice, error) {
+	var model models.FirmwareCampaignDeviceModel
+	err := r.db.Where("device_id = ? AND status IN ?", deviceID, inFlightStatuses()).
+		Order("sent_at DESC").
+		First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toDeviceEntity(&model), nil
+}
+
+func (r *FirmwareCampaignRepository) FindBusyDeviceIDs() ([]uint, error) {
+	var deviceIDs []uint
+	err := r.db.Model(&models.FirmwareCampaignDeviceModel{}).
+		Joins("JOIN firmware_campaigns ON firmware_campaigns.id = firmware_campaign_devices.campaign_id").
+		Where("firmware_campaigns.status NOT IN ?", []string{entities.CampaignStatusCompleted, entities.CampaignStatusCancelled}).
+		Where("firmware_campaign_devices.status IN ?", append(inFlightStatuses(), entities.DeviceStatusPending)).
+		Distinct().
+		Pluck("firmware_campaign_devices.device_id", &deviceIDs).Error
+	return deviceIDs, err
+}
+
+func (r *FirmwareCampaignRepository) findCampaigns(query *gorm.DB) ([]*entities.Campaign, error) {
+	var campaignModels []models.FirmwareCampaignModel
+	if err := query.Find(&campaignModels).Error; err != nil {
+		return nil, err
+	}
+
+	campaigns := make([]*entities.Campaign, 0, len(campaignModels))
+	for i := range campaignModels {
+		campaign, err := r.toCampaignEntity(&campaignModels[i])
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	return campaigns, nil
+}
+
+func (r *FirmwareCampaignRepository) toCampaignEntity(model *models.FirmwareCampaignModel) (*entities.Campaign, error) {
+	var deviceIDs []uint
+	if len(model.DeviceIDs) > 0 {
+		if err := json.Unmarshal(model.DeviceIDs, &deviceIDs); err != nil {
+			return nil, err
+		}
+	}
+	return &entities.Campaign{
+		ID:                   model.ID,
+		Name:                 model.Name,
+		FirmwareID:           model.FirmwareID,
+		TargetMode:           model.TargetMode,
+		Percentage:           model.Percentage,
+		DeviceIDs:            deviceIDs,
+		BatchSize:            model.BatchSize,
+		MaxFailureRate:       model.MaxFailureRate,
+		MinSamples:           model.MinSamples,
+		DeviceTimeoutSeconds: model.DeviceTimeoutSeconds,
+		Status:               model.Status,
+		HaltReason:           model.HaltReason,
+		SucceededOffset:      model.SucceededOffset,
+		FailedOffset:         model.FailedOffset,
+		CreateID:             model.CreateID,
+		CreateTime:           model.CreateTime,
+		ModifyID:             model.ModifyID,
+		ModifyTime:           model.ModifyTime,
+		FinishedAt:           model.FinishedAt,
+	}, nil
+}
+
+func (r *FirmwareCampaignRepository) toDeviceModel(device *entities.CampaignDevice) *models.FirmwareCampaignDeviceModel {
+	return &models.FirmwareCampaignDeviceModel{
+		ID:          device.ID,
+		CampaignID:  device.CampaignID,
+		DeviceID:    device.DeviceID,
+		DeviceSN:    device.DeviceSN,
+		FromVersion: device.FromVersion,
+		CommandID:   device.CommandID,
+		Status:      device.Status,
+		Progress:    device.Progress,
+		Message:     device.Message,
+		SentAt:      device.SentAt,
+		UpdatedAt:   device.UpdatedAt,
+		FinishedAt:  device.FinishedAt,
+	}
+}
+
+func (r *FirmwareCampaignRepository) toDeviceEntity(model *models.FirmwareCampaignDeviceModel) *entities.CampaignDevice {
+	return &entities.CampaignDevice{
+		ID:          model.ID,
+		CampaignID:  model.CampaignID,
+		DeviceID:    model.DeviceID,
+		DeviceSN:    model.DeviceSN,
+		FromVersion: model.FromVersion,
+		CommandID:   model.CommandID,
+		Status:      model.Status,
+		Progress:    model.Progress,
+		Message:     model.Message,
+		SentAt:      model.SentAt,
+		UpdatedAt:   model.UpdatedAt,
+		FinishedAt:  model.FinishedAt,
+	}
+}
+
+func inFlightStatuses() []string {
+	return []string{entities.DeviceStatusSent, entities.DeviceStatusDownloading, entities.DeviceStatusInstalling}
+}
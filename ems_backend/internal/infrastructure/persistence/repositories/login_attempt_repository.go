@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Find 查找登入失敗統計 (不存在時返回 nil, nil)
+func (r *LoginAttemptRepository) Find(scope, key string) (*entities.LoginAttempt, error) {
+	var model models.LoginAttemptModel
+	err := r.db.Where("scope = ? AND attempt_key = ?", scope, key).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+// RecordFailure 以 upsert 原子累加，並發失敗不會遺漏
+func (r *LoginAttemptRepository) RecordFailure(scope, key string, now, windowStart time.Time) (*entities.LoginAttempt, error) {
+	var model models.LoginAttemptModel
+	err := r.db.Raw(`INSERT INTO login_attempts (scope, attempt_key, failures, last_failure_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (scope, attempt_key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING scope, attempt_key, failures, last_failure_at`, scope, key, now, windowStart).Scan(&model).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+func (r *LoginAttemptRepository) Reset(scope, key string) error {
+	return r.db.Where("scope = ? AND attempt_key = ?", scope, key).Delete(&models.LoginAttemptModel{}).Error
+}
+
+func (r *LoginAttemptRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("last_failure_at < ?", cutoff).Delete(&models.LoginAttemptModel{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *LoginAttemptRepository) toEntity(model *models.LoginAttemptModel) *entities.LoginAttempt {
+	return &entities.LoginAttempt{
+		Scope:         model.Scope,
+		Key:           model.AttemptKey,
+		Failures:      model.Failures,
+		LastFailureAt: model.LastFailureAt,
+	}
+}
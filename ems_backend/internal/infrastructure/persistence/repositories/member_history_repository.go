@@ -34,6 +34,46 @@ func (r *MemberHistoryRepository) LastMemberHistory(memberID uint) (*entities.Me
 	return r.mapToDomain(&model)
 }
 
+func (r *MemberHistoryRepository) RecentMemberHistories(memberID uint, limit int) ([]*entities.MemberHistory, error) {
+	var records []models.MemberHistoryModel
+	if err := r.db.Where("member_id = ?", memberID).Order("create_time DESC").Limit(limit).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	histories := make([]*entities.MemberHistory, 0, len(records))
+	for i := range records {
+		history, err := r.mapToDomain(&records[i])
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+	return histories, nil
+}
+
+// UpdateHash 只更新雜湊欄位，不覆蓋並發累加的 error_count
+func (r *MemberHistoryRepository) UpdateHash(id uint, salt, hash, params string) error {
+	return r.db.Model(&models.MemberHistoryModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"salt":        salt,
+		"hash":        hash,
+		"hash_params": params,
+	}).Error
+}
+
+// IncrementErrorCount 以單一 UPDATE 累加，避免並發登入失敗互相覆蓋
+func (r *MemberHistoryRepository) IncrementErrorCount(memberID uint) (int, error) {
+	var count int
+	err := r.db.Raw(`UPDATE member_history SET error_count = error_count + 1
+		WHERE id = (SELECT id FROM member_history WHERE member_id = ? ORDER BY create_time DESC LIMIT 1)
+		RETURNING error_count`, memberID).Scan(&count).Error
+	return count, err
+}
+
+func (r *MemberHistoryRepository) ResetErrorCount(memberID uint) error {
+	return r.db.Exec(`UPDATE member_history SET error_count = 0
+		WHERE id = (SELECT id FROM member_history WHERE member_id = ? ORDER BY create_time DESC LIMIT 1)
+		AND error_count <> 0`, memberID).Error
+}
+
 func (r *MemberHistoryRepository) mapToModel(member *entities.MemberHistory) *models.MemberHistoryModel {
 	// 這裡需要處理 ID 的轉換，暫時使用 0 作為新記錄
 	var id uint = 0
@@ -47,6 +87,7 @@ func (r *MemberHistoryRepository) mapToModel(member *entities.MemberHistory) *mo
 		MemberID:   member.MemberID.Value(),
 		Salt:       member.Salt,
 		Hash:       member.Hash,
+		HashParams: member.HashParams,
 		ErrorCount: member.ErrorCount,
 		CreateID:   member.CreateID,
 		CreateTime: member.CreateTime,
@@ -66,6 +107,7 @@ func (r *MemberHistoryRepository) mapToDomain(model *models.MemberHistoryModel)
 		MemberID:   memberID,
 		Salt:       model.Salt,
 		Hash:       model.Hash,
+		HashParams: model.HashParams,
 		ErrorCount: model.ErrorCount,
 		CreateID:   model.CreateID,
 		CreateTime: model.CreateTime,
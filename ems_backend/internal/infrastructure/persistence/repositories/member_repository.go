@@ -5,6 +5,7 @@ import (
 	"ems_backend/internal/domain/member/value_objects"
 	"ems_backend/internal/infrastructure/persistence/models"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -67,6 +68,31 @@ func (r *MemberRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&models.MemberModel{}).Error
 }
 
+func (r *MemberRepository) UpdateLock(member *entities.Member) error {
+	return r.db.Model(&models.MemberModel{}).Where("id = ?", member.ID.Value()).Updates(map[string]interface{}{
+		"locked_at":    member.LockedAt,
+		"locked_until": member.LockedUntil,
+	}).Error
+}
+
+func (r *MemberRepository) FindLockExpired(now time.Time) ([]*entities.Member, error) {
+	var memberModels []models.MemberModel
+	if err := r.db.Where("locked_at IS NOT NULL AND locked_until IS NOT NULL AND locked_until <= ?", now).
+		Find(&memberModels).Error; err != nil {
+		return nil, err
+	}
+
+	members := make([]*entities.Member, 0, len(memberModels))
+	for i := range memberModels {
+		member, err := r.mapToDomain(&memberModels[i])
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
 func (r *MemberRepository) mapToDomain(model *models.MemberModel) (*entities.Member, error) {
 	memberID, err := value_objects.NewMemberID(model.ID)
 	if err != nil {
@@ -90,6 +116,9 @@ func (r *MemberRepository) mapToDomain(model *models.MemberModel) (*entities.Mem
 		IsEnable:  model.IsEnable,
 		CreatedAt: model.CreateTime,
 		UpdatedAt: model.ModifyTime,
+
+		LockedAt:    model.LockedAt,
+		LockedUntil: model.LockedUntil,
 	}, nil
 }
 
@@ -101,5 +130,8 @@ func (r *MemberRepository) mapToModel(member *entities.Member) *models.MemberMod
 		IsEnable:   member.IsEnable,
 		CreateTime: member.CreatedAt,
 		ModifyTime: member.UpdatedAt,
+
+		LockedAt:    member.LockedAt,
+		LockedUntil: member.LockedUntil,
 	}
 }
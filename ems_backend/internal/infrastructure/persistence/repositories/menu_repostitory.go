@@ -24,6 +24,14 @@ func (r *MenuRepository) GetAll() ([]*entities.Menu, error) {
 	return r.mapToDomain(menus), nil
 }
 
+func (r *MenuRepository) GetByID(id uint) (*entities.Menu, error) {
+	var menu models.MenuModel
+	if err := r.db.First(&menu, id).Error; err != nil {
+		return nil, err
+	}
+	return r.mapToDomain([]*models.MenuModel{&menu})[0], nil
+}
+
 func (r *MenuRepository) Create(menu *entities.Menu, memberID uint) error {
 	return r.db.Create(&models.MenuModel{
 		Title:      menu.Title,
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// FindByMemberID 查找會員的 TOTP 設定 (不存在時返回 nil, nil)
+func (r *MFARepository) FindByMemberID(memberID uint) (*entities.MemberMFA, error) {
+	var model models.MemberMFAModel
+	err := r.db.Where("member_id = ?", memberID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.MemberMFA{
+		MemberID:     model.MemberID,
+		Secret:       model.Secret,
+		Enabled:      model.Enabled,
+		EnabledAt:    model.EnabledAt,
+		LastUsedStep: model.LastUsedStep,
+		CreateTime:   model.CreateTime,
+	}, nil
+}
+
+func (r *MFARepository) Save(mfa *entities.MemberMFA) error {
+	model := &models.MemberMFAModel{
+		MemberID:     mfa.MemberID,
+		Secret:       mfa.Secret,
+		Enabled:      mfa.Enabled,
+		EnabledAt:    mfa.EnabledAt,
+		LastUsedStep: mfa.LastUsedStep,
+		CreateTime:   mfa.CreateTime,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "member_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "enabled_at", "last_used_step", "create_time"}),
+	}).Create(model).Error
+}
+
+// AdvanceLastUsedStep 條件更新，同一時間步的驗證碼並發使用時只有一方 RowsAffected 為 1
+func (r *MFARepository) AdvanceLastUsedStep(memberID uint, step int64) (bool, error) {
+	result := r.db.Model(&models.MemberMFAModel{}).
+		Where("member_id = ? AND last_used_step < ?", memberID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *MFARepository) Delete(memberID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("member_id = ?", memberID).Delete(&models.MFAChallengeModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("member_id = ?", memberID).Delete(&models.MFARecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("member_id = ?", memberID).Delete(&models.MemberMFAModel{}).Error
+	})
+}
+
+func (r *MFARepository) ReplaceRecoveryCodes(memberID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("member_id = ?", memberID).Delete(&models.MFARecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		codes := make([]models.MFARecoveryCodeModel, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.MFARecoveryCodeModel{MemberID: memberID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode 條件更新 used_at，已使用的復原碼不會再次成功
+func (r *MFARepository) UseRecoveryCode(memberID uint, codeHash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.MFARecoveryCodeModel{}).
+		Where("member_id = ? AND code_hash = ? AND used_at IS NULL", memberID, codeHash).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *MFARepository) CountRecoveryCodes(memberID uint) (int, error) {
+	var count int64
+	err := r.db.Model(&models.MFARecoveryCodeModel{}).
+		Where("member_id = ? AND used_at IS NULL", memberID).
+		Count(&count).Error
+	return int(count), err
+}
+
+func (r *MFARepository) CreateChallenge(challenge *entities.MFAChallenge) error {
+	model := &models.MFAChallengeModel{
+		MemberID:   challenge.MemberID,
+		TokenHash:  challenge.TokenHash,
+		ExpiresAt:  challenge.ExpiresAt,
+		CreateTime: challenge.CreateTime,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	challenge.ID = model.ID
+	return nil
+}
+
+// FindChallenge 根據 token 雜湊查找挑戰 (不存在時返回 nil, nil)
+func (r *MFARepository) FindChallenge(tokenHash string) (*entities.MFAChallenge, error) {
+	var model models.MFAChallengeModel
+	err := r.db.Where("token_hash = ?", tokenHash).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.MFAChallenge{
+		ID:         model.ID,
+		MemberID:   model.MemberID,
+		TokenHash:  model.TokenHash,
+		Attempts:   model.Attempts,
+		ExpiresAt:  model.ExpiresAt,
+		CreateTime: model.CreateTime,
+	}, nil
+}
+
+func (r *MFARepository) IncrementChallengeAttempts(id uint) (int, error) {
+	var attempts int
+	err := r.db.Raw(`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = ? RETURNING attempts`, id).
+		Scan(&attempts).Error
+	return attempts, err
+}
+
+// DeleteChallenge 刪除挑戰；並發使用同一挑戰時只有一方 RowsAffected 為 1
+func (r *MFARepository) DeleteChallenge(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&models.MFAChallengeModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *MFARepository) DeleteExpiredChallenges(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&models.MFAChallengeModel{})
+	return result.RowsAffected, result.Error
+}
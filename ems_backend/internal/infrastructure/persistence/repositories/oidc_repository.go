@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type OIDCStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository(db *gorm.DB) *OIDCStateRepository {
+	return &OIDCStateRepository{db: db}
+}
+
+func (r *OIDCStateRepository) Create(state *entities.OIDCLoginState) error {
+	model := &models.OIDCLoginStateModel{
+		StateHash:    state.StateHash,
+		Provider:     state.Provider,
+		Nonce:        state.Nonce,
+		CodeVerifier: state.CodeVerifier,
+		RedirectPath: state.RedirectPath,
+		ExpiresAt:    state.ExpiresAt,
+		CreateTime:   state.CreateTime,
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	state.ID = model.ID
+	return nil
+}
+
+// Consume 以 DELETE ... RETURNING 取出狀態，並發的回呼只有一方取得 (不存在時返回 nil, nil)
+func (r *OIDCStateRepository) Consume(stateHash string) (*entities.OIDCLoginState, error) {
+	var rows []models.OIDCLoginStateModel
+	if err := r.db.Raw(
+		"DELETE FROM oidc_login_states WHERE state_hash = ? RETURNING *", stateHash,
+	).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	model := rows[0]
+	return &entities.OIDCLoginState{
+		ID:           model.ID,
+		StateHash:    model.StateHash,
+		Provider:     model.Provider,
+		Nonce:        model.Nonce,
+		CodeVerifier: model.CodeVerifier,
+		RedirectPath: model.RedirectPath,
+		ExpiresAt:    model.ExpiresAt,
+		CreateTime:   model.CreateTime,
+	}, nil
+}
+
+func (r *OIDCStateRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&models.OIDCLoginStateModel{})
+	return result.RowsAffected, result.Error
+}
+
+type OIDCIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCIdentityRepository(db *gorm.DB) *OIDCIdentityRepository {
+	return &OIDCIdentityRepository{db: db}
+}
+
+// FindByProviderSubject 查找外部身分 (不存在時返回 nil, nil)
+func (r *OIDCIdentityRepository) FindByProviderSubject(provider, subject string) (*entities.OIDCIdentity, error) {
+	var model models.MemberIdentityModel
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.OIDCIdentity{
+		ID:          model.ID,
+		Provider:    model.Provider,
+		Subject:     model.Subject,
+		MemberID:    model.MemberID,
+		Email:       model.Email,
+		CreateTime:  model.CreateTime,
+		LastLoginAt: model.LastLoginAt,
+	}, nil
+}
+
+func (r *OIDCIdentityRepository) Create(identity *entities.OIDCIdentity) error {
+	return r.create(r.db, identity)
+}
+
+// CreateWithMember 建立沒有密碼歷史的會員 (只能經由單一登入或重設密碼登入) 與外部身分
+func (r *OIDCIdentityRepository) CreateWithMember(name, email string, identity *entities.OIDCIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		member := &models.MemberModel{
+			Name:       name,
+			Email:      email,
+			IsEnable:   true,
+			CreateTime: identity.CreateTime,
+			ModifyTime: identity.CreateTime,
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+		identity.MemberID = member.ID
+		return r.create(tx, identity)
+	})
+}
+
+// TouchLogin 更新最後登入時間；email 為空白時保留原值
+func (r *OIDCIdentityRepository) TouchLogin(id uint, email string, now time.Time) error {
+	updates := map[string]interface{}{"last_login_at": now}
+	if email != "" {
+		updates["email"] = email
+	}
+	return r.db.Model(&models.MemberIdentityModel{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *OIDCIdentityRepository) create(db *gorm.DB, identity *entities.OIDCIdentity) error {
+	model := &models.MemberIdentityModel{
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		MemberID:    identity.MemberID,
+		Email:       identity.Email,
+		CreateTime:  identity.CreateTime,
+		LastLoginAt: identity.LastLoginAt,
+	}
+	if err := db.Create(model).Error; err != nil {
+		return err
+	}
+	identity.ID = model.ID
+	return nil
+}
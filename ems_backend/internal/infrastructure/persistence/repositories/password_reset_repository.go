@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"time"
+
+	"ems_backend/internal/domain/auth/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Create(token *entities.PasswordResetToken) error {
+	model := &models.ForgotTempModel{
+		MemberID:   token.MemberID,
+		ExpireTime: token.ExpiresAt,
+		Code:       token.CodeHash,
+	}
+	if token.RedirectPath != "" {
+		model.RedirectPath = &token.RedirectPath
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	token.ID = model.ID
+	return nil
+}
+
+// FindByHash 根據雜湊查找重設憑證 (不存在時返回 nil, nil)
+func (r *PasswordResetRepository) FindByHash(codeHash string) (*entities.PasswordResetToken, error) {
+	var model models.ForgotTempModel
+	err := r.db.Where("code = ?", codeHash).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := &entities.PasswordResetToken{
+		ID:        model.ID,
+		MemberID:  model.MemberID,
+		CodeHash:  model.Code,
+		ExpiresAt: model.ExpireTime,
+	}
+	if model.RedirectPath != nil {
+		token.RedirectPath = *model.RedirectPath
+	}
+	return token, nil
+}
+
+// Consume 刪除憑證；並發使用同一憑證時只有一方 RowsAffected 為 1
+func (r *PasswordResetRepository) Consume(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&models.ForgotTempModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *PasswordResetRepository) DeleteByMemberID(memberID uint) error {
+	return r.db.Where("member_id = ?", memberID).Delete(&models.ForgotTempModel{}).Error
+}
+
+func (r *PasswordResetRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expire_time <= ?", now).Delete(&models.ForgotTempModel{})
+	return result.RowsAffected, result.Error
+}
@@ -39,7 +39,7 @@ func (r *RoleRepository) GetByMemberID(memberID uint) ([]*entities.Role, error)
 	var roles []*entities.Role
 
 	sql := `
-	SELECT r.id, r.title, r.description, r.sort, r.is_enable
+	SELECT r.id, r.title, r.description, r.sort, r.is_enable, r.mfa_required, r.company_scope
 	FROM role r
 	INNER JOIN member_role mr ON mr.role_id = r.id
 	WHERE mr.member_id = ? AND r.is_enable = TRUE
@@ -55,26 +55,30 @@ func (r *RoleRepository) GetByMemberID(memberID uint) ([]*entities.Role, error)
 // Create 創建角色
 func (r *RoleRepository) Create(role *entities.Role, memberID uint) error {
 	return r.db.Create(&models.RoleModel{
-		Title:       role.Title,
-		Description: role.Description,
-		Sort:        role.Sort,
-		IsEnable:    role.IsEnable,
-		CreateID:    memberID,
-		CreateTime:  time.Now(),
-		ModifyID:    memberID,
-		ModifyTime:  time.Now(),
+		Title:        role.Title,
+		Description:  role.Description,
+		Sort:         role.Sort,
+		IsEnable:     role.IsEnable,
+		MFARequired:  role.MFARequired,
+		CompanyScope: string(role.CompanyScope),
+		CreateID:     memberID,
+		CreateTime:   time.Now(),
+		ModifyID:     memberID,
+		ModifyTime:   time.Now(),
 	}).Error
 }
 
 // Update 更新角色
 func (r *RoleRepository) Update(role *entities.Role, memberID uint) error {
 	return r.db.Model(&models.RoleModel{}).Where("id = ?", role.ID).Updates(map[string]interface{}{
-		"title":       role.Title,
-		"description": role.Description,
-		"sort":        role.Sort,
-		"is_enable":   role.IsEnable,
-		"modify_id":   memberID,
-		"modify_time": time.Now(),
+		"title":         role.Title,
+		"description":   role.Description,
+		"sort":          role.Sort,
+		"is_enable":     role.IsEnable,
+		"mfa_required":  role.MFARequired,
+		"company_scope": string(role.CompanyScope),
+		"modify_id":     memberID,
+		"modify_time":   time.Now(),
 	}).Error
 }
 
@@ -199,10 +203,12 @@ func (r *RoleRepository) mapToDomain(roles []*models.RoleModel) []*entities.Role
 // mapToDomainSingle 將單個模型轉換為領域實體
 func (r *RoleRepository) mapToDomainSingle(role *models.RoleModel) *entities.Role {
 	return &entities.Role{
-		ID:          role.ID,
-		Title:       role.Title,
-		Description: role.Description,
-		Sort:        role.Sort,
-		IsEnable:    role.IsEnable,
+		ID:           role.ID,
+		Title:        role.Title,
+		Description:  role.Description,
+		Sort:         role.Sort,
+		IsEnable:     role.IsEnable,
+		MFARequired:  role.MFARequired,
+		CompanyScope: entities.CompanyScope(role.CompanyScope),
 	}
 }
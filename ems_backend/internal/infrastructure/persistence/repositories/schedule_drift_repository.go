@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+	"encoding/json"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduleDriftRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewScheduleDriftRepository(db *gorm.DB) *ScheduleDriftRepositoryImpl {
+	return &ScheduleDriftRepositoryImpl{db: db}
+}
+
+func (r *ScheduleDriftRepositoryImpl) FindByID(id uint) (*entities.ScheduleDrift, error) {
+	var model models.ScheduleDriftModel
+	if err := r.db.First(&model, id).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model)
+}
+
+// FindOpenByCompanyDeviceID 取得設備最新一筆未解決的漂移 (無則返回 nil, nil)
+func (r *ScheduleDriftRepositoryImpl) FindOpenByCompanyDeviceID(companyDeviceID uint) (*entities.ScheduleDrift, error) {
+	var model models.ScheduleDriftModel
+	err := r.db.Where("company_device_id = ? AND status = ?", companyDeviceID, entities.DriftStatusOpen).
+		Order("detected_at DESC").
+		First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model)
+}
+
+func (r *ScheduleDriftRepositoryImpl) FindByCompanyDeviceID(companyDeviceID uint, limit int) ([]*entities.ScheduleDrift, error) {
+	var driftModels []models.ScheduleDriftModel
+	query := r.db.Where("company_device_id = ?", companyDeviceID).Order("detected_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&driftModels).Error; err != nil {
+		return nil, err
+	}
+
+	drifts := make([]*entities.ScheduleDrift, 0, len(driftModels))
+	for i := range driftModels {
+		drift, err := r.toEntity(&driftModels[i])
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+func (r *ScheduleDriftRepositoryImpl) Save(drift *entities.ScheduleDrift) error {
+	model, err := r.toModel(drift)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	drift.ID = model.ID
+	return nil
+}
+
+func (r *ScheduleDriftRepositoryImpl) Update(drift *entities.ScheduleDrift) error {
+	model, err := r.toModel(drift)
+	if err != nil {
+		return err
+	}
+	return r.db.Save(model).Error
+}
+
+// FindPolicyByCompanyID 取得公司漂移策略 (未設定時返回 nil, nil)
+func (r *ScheduleDriftRepositoryImpl) FindPolicyByCompanyID(companyID uint) (*entities.ScheduleDriftPolicy, error) {
+	var model models.ScheduleDriftPolicyModel
+	err := r.db.Where("company_id = ?", companyID).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entities.ScheduleDriftPolicy{
+		CompanyID:  model.CompanyID,
+		Policy:     model.Policy,
+		ModifyID:   model.ModifyID,
+		ModifyTime: model.ModifyTime,
+	}, nil
+}
+
+func (r *ScheduleDriftRepositoryImpl) SavePolicy(policy *entities.ScheduleDriftPolicy) error {
+	model := &models.ScheduleDriftPolicyModel{
+		CompanyID:  policy.CompanyID,
+		Policy:     policy.Policy,
+		ModifyID:   policy.ModifyID,
+		ModifyTime: policy.ModifyTime,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"policy", "modify_id", "modify_time"}),
+	}).Create(model).Error
+}
+
+// ============================================
+// Mapping
+// ============================================
+
+func (r *ScheduleDriftRepositoryImpl) toEntity(model *models.ScheduleDriftModel) (*entities.ScheduleDrift, error) {
+	drift := &entities.ScheduleDrift{
+		ID:              model.ID,
+		CompanyDeviceID: model.CompanyDeviceID,
+		ScheduleID:      model.ScheduleID,
+		CloudVersion:    model.CloudVersion,
+		Status:          model.Status,
+		Resolution:      model.Resolution,
+		ResolvedBy:      model.ResolvedBy,
+		ResolvedAt:      model.ResolvedAt,
+		DetectedAt:      model.DetectedAt,
+	}
+	if len(model.Differences) > 0 {
+		if err := json.Unmarshal(model.Differences, &drift.Differences); err != nil {
+			return nil, err
+		}
+	}
+	if len(model.DeviceSnapshot) > 0 {
+		drift.DeviceSnapshot = &entities.ScheduleSnapshot{}
+		if err := json.Unmarshal(model.DeviceSnapshot, drift.DeviceSnapshot); err != nil {
+			return nil, err
+		}
+	}
+	return drift, nil
+}
+
+func (r *ScheduleDriftRepositoryImpl) toModel(drift *entities.ScheduleDrift) (*models.ScheduleDriftModel, error) {
+	differences := drift.Differences
+	if differences == nil {
+		differences = []entities.RuleDifference{}
+	}
+	differencesJSON, err := json.Marshal(differences)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &models.ScheduleDriftModel{
+		ID:              drift.ID,
+		CompanyDeviceID: drift.CompanyDeviceID,
+		ScheduleID:      drift.ScheduleID,
+		CloudVersion:    drift.CloudVersion,
+		Differences:     models.JSONB(differencesJSON),
+		Status:          drift.Status,
+		Resolution:      drift.Resolution,
+		ResolvedBy:      drift.ResolvedBy,
+		ResolvedAt:      drift.ResolvedAt,
+		DetectedAt:      drift.DetectedAt,
+	}
+	if drift.DeviceSnapshot != nil {
+		snapshotJSON, err := json.Marshal(drift.DeviceSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		model.DeviceSnapshot = models.JSONB(snapshotJSON)
+	}
+	return model, nil
+}
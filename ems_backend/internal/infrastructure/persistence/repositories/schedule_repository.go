@@ -52,6 +52,14 @@ func (r *ScheduleRepositoryImpl) FindPending() ([]*entities.Schedule, error) {
 	return r.schedulesToEntities(models), nil
 }
 
+func (r *ScheduleRepositoryImpl) FindAll() ([]*entities.Schedule, error) {
+	var models []models.ScheduleModel
+	if err := r.db.Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return r.schedulesToEntities(models), nil
+}
+
 func (r *ScheduleRepositoryImpl) Save(schedule *entities.Schedule) error {
 	model := r.scheduleToModel(schedule)
 	if err := r.db.Create(model).Error; err != nil {
@@ -272,6 +280,10 @@ func (r *ScheduleRepositoryImpl) FindFullSchedule(companyDeviceID uint) (*entiti
 }
 
 func (r *ScheduleRepositoryImpl) SaveFullSchedule(fullSchedule *entities.ScheduleWithRules) error {
+	return r.SaveFullScheduleWithVersion(fullSchedule, nil)
+}
+
+func (r *ScheduleRepositoryImpl) SaveFullScheduleWithVersion(fullSchedule *entities.ScheduleWithRules, newVersion func(*entities.ScheduleWithRules) *entities.ScheduleVersion) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := &ScheduleRepositoryImpl{db: tx}
 
@@ -326,7 +338,11 @@ func (r *ScheduleRepositoryImpl) SaveFullSchedule(fullSchedule *entities.Schedul
 			}
 		}
 
-		return nil
+		// 歷史版本與排程一起提交
+		if newVersion == nil {
+			return nil
+		}
+		return NewScheduleVersionRepository(tx).Save(newVersion(fullSchedule))
 	})
 }
 
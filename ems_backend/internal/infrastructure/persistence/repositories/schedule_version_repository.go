@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"ems_backend/internal/domain/schedule/entities"
+	"ems_backend/internal/infrastructure/persistence/models"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleVersionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewScheduleVersionRepository(db *gorm.DB) *ScheduleVersionRepositoryImpl {
+	return &ScheduleVersionRepositoryImpl{db: db}
+}
+
+func (r *ScheduleVersionRepositoryImpl) Save(version *entities.ScheduleVersion) error {
+	model, err := r.toModel(version)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Create(model).Error; err != nil {
+		return err
+	}
+	version.ID = model.ID
+	return nil
+}
+
+// FindByScheduleID 取得排程所有版本 (最新在前)
+func (r *ScheduleVersionRepositoryImpl) FindByScheduleID(scheduleID uint) ([]*entities.ScheduleVersion, error) {
+	var versionModels []models.ScheduleVersionModel
+	if err := r.db.Where("schedule_id = ?", scheduleID).Order("version DESC").Find(&versionModels).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make([]*entities.ScheduleVersion, 0, len(versionModels))
+	for i := range versionModels {
+		version, err := r.toEntity(&versionModels[i])
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (r *ScheduleVersionRepositoryImpl) FindByVersion(scheduleID uint, version int) (*entities.ScheduleVersion, error) {
+	var model models.ScheduleVersionModel
+	if err := r.db.Where("schedule_id = ? AND version = ?", scheduleID, version).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model)
+}
+
+func (r *ScheduleVersionRepositoryImpl) ExistsVersion(scheduleID uint, version int) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.ScheduleVersionModel{}).
+		Where("schedule_id = ? AND version = ?", scheduleID, version).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ============================================
+// Mapping
+// ============================================
+
+func (r *ScheduleVersionRepositoryImpl) toEntity(model *models.ScheduleVersionModel) (*entities.ScheduleVersion, error) {
+	version := &entities.ScheduleVersion{
+		ID:              model.ID,
+		ScheduleID:      model.ScheduleID,
+		CompanyDeviceID: model.CompanyDeviceID,
+		Version:         model.Version,
+		Source:          model.Source,
+		RestoredFrom:    model.RestoredFrom,
+		Rules:           &entities.ScheduleSnapshot{},
+		CreatedBy:       model.CreatedBy,
+		CreatedAt:       model.CreatedAt,
+	}
+	if len(model.Rules) > 0 {
+		if err := json.Unmarshal(model.Rules, version.Rules); err != nil {
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
+func (r *ScheduleVersionRepositoryImpl) toModel(version *entities.ScheduleVersion) (*models.ScheduleVersionModel, error) {
+	rules := version.Rules
+	if rules == nil {
+		rules = &entities.ScheduleSnapshot{}
+	}
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ScheduleVersionModel{
+		ID:              version.ID,
+		ScheduleID:      version.ScheduleID,
+		CompanyDeviceID: version.CompanyDeviceID,
+		Version:         version.Version,
+		Source:          version.Source,
+		RestoredFrom:    version.RestoredFrom,
+		Rules:           models.JSONB(rulesJSON),
+		CreatedBy:       version.CreatedBy,
+		CreatedAt:       version.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,264 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"ems_backend/internal/infrastructure/metrics"
+)
+
+// 呼叫者種類
+const (
+	PrincipalMember = "member"  // 已登入的會員
+	PrincipalAPIKey = "api_key" // 服務帳號 API Key
+	PrincipalIP     = "ip"      // 未登入，以來源 IP 計算
+
+	anyPrincipal  = "*"
+	defaultPolicy = "default"
+)
+
+var limitCounter = metrics.Default.Counter(
+	"ems_rate_limit_requests_total",
+	"Requests checked by the rate limiter, by policy, principal kind and result (allowed, limited, error).",
+	"policy", "principal", "result",
+)
+
+// Policy - token bucket 政策: 每 Period 補充 Limit 個 token，最多累積 Burst 個
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// rate 每秒補充的 token 數
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// refillTime 從空到滿需要的時間，閒置超過此時間的 bucket 與新的相同
+func (p Policy) refillTime() time.Duration {
+	return time.Duration(float64(p.Burst) / p.rate() * float64(time.Second))
+}
+
+// Decision - 一次檢查的結果
+type Decision struct {
+	Policy     Policy
+	Allowed    bool
+	Remaining  int           // 取用後剩餘的 token
+	Reset      time.Duration // bucket 補滿所需時間
+	RetryAfter time.Duration // 被限制時，下一個 token 可用前的等待時間
+}
+
+// Store - bucket 儲存，單機使用 MemoryStore，多個實例共用 PostgresStore
+type Store interface {
+	// Take 補充後嘗試取用一個 token，返回剩餘 token 數與是否取用成功
+	Take(key string, policy Policy) (tokens float64, allowed bool, err error)
+	// Purge 刪除閒置超過 idle 的 bucket
+	Purge(idle time.Duration) (int64, error)
+}
+
+// Limiter - 依路由群組與呼叫者種類選擇政策，對每個呼叫者各自計算 bucket
+type Limiter struct {
+	store    Store
+	policies map[string]Policy // "group:principal" 或 "group:*"
+}
+
+// NewLimiter - 建立限流器
+func NewLimiter(store Store, policies []Policy) *Limiter {
+	byName := make(map[string]Policy, len(policies))
+	for _, policy := range policies {
+		byName[policy.Name] = policy
+	}
+	return &Limiter{store: store, policies: byName}
+}
+
+// Resolve - 依序尋找 group:principal、group:*、default:principal、default:*
+func (l *Limiter) Resolve(group, principal string) (Policy, bool) {
+	for _, name := range []string{
+		group + ":" + principal,
+		group + ":" + anyPrincipal,
+		defaultPolicy + ":" + principal,
+		defaultPolicy + ":" + anyPrincipal,
+	} {
+		if policy, ok := l.policies[name]; ok {
+			return policy, true
+		}
+	}
+	return Policy{}, false
+}
+
+// Allow - 為呼叫者取用一個 token；沒有適用的政策時返回 ok = false
+func (l *Limiter) Allow(group, principal, id string) (decision Decision, ok bool, err error) {
+	policy, ok := l.Resolve(group, principal)
+	if !ok {
+		return Decision{}, false, nil
+	}
+	return l.take(group, principal, id, policy)
+}
+
+// AllowIP - 驗證身分前以來源 IP 取用一個 token，只套用 group:ip 或 default:ip
+// 適用所有呼叫者的政策是以單一會員為單位設計的，不套用在同一 IP (NAT) 後的所有請求
+func (l *Limiter) AllowIP(group, ip string) (decision Decision, ok bool, err error) {
+	for _, name := range []string{group + ":" + PrincipalIP, defaultPolicy + ":" + PrincipalIP} {
+		if policy, found := l.policies[name]; found {
+			return l.take(group, PrincipalIP, ip, policy)
+		}
+	}
+	return Decision{}, false, nil
+}
+
+func (l *Limiter) take(group, principal, id string, policy Policy) (decision Decision, ok bool, err error) {
+	// 同一政策可能套用在多個群組 (例如 default)，bucket 以群組區分
+	key := group + ":" + principal + ":" + id
+	tokens, allowed, err := l.store.Take(key, policy)
+	if err != nil {
+		limitCounter.Inc(policy.Name, principal, "error")
+		return Decision{}, true, err
+	}
+
+	decision = Decision{
+		Policy:    policy,
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(policy.Burst) - tokens) / policy.rate() * float64(time.Second)),
+	}
+	if allowed {
+		limitCounter.Inc(policy.Name, principal, "allowed")
+	} else {
+		decision.RetryAfter = time.Duration((1 - tokens) / policy.rate() * float64(time.Second))
+		limitCounter.Inc(policy.Name, principal, "limited")
+	}
+	return decision, true, nil
+}
+
+// Purge - 刪除已補滿的閒置 bucket
+func (l *Limiter) Purge() (int64, error) {
+	var idle time.Duration
+	for _, policy := range l.policies {
+		if refill := policy.refillTime(); refill > idle {
+			idle = refill
+		}
+	}
+	return l.store.Purge(idle)
+}
+
+// StartPurgeLoop - 定期刪除閒置的 bucket
+func (l *Limiter) StartPurgeLoop(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[RateLimit] Purge loop started (interval: %s, policies: %d)", interval, len(l.policies))
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[RateLimit] Purge loop stopped")
+			return
+		case <-ticker.C:
+			if _, err := l.Purge(); err != nil {
+				log.Printf("[RateLimit] Failed to purge buckets: %v", err)
+			}
+		}
+	}
+}
+
+// ParsePolicies - 解析 RATE_LIMIT_POLICIES
+//
+//	<group>[:<principal>]=<limit>/<period>[,burst=<n>]，以分號分隔
+//	principal 為 member、api_key、ip，省略時適用所有呼叫者；period 為 s、m、h 或 Go duration
+//	驗證身分前的 IP 限流 (AllowIP) 只使用 <group>:ip 與 default:ip
+//	例如 auth:ip=20/m,burst=10;dashboard=120/m;default:ip=3000/m;default=600/m
+func ParsePolicies(spec string) ([]Policy, error) {
+	var policies []Policy
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rule, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("rate limit policy %q: expected <group>=<limit>/<period>", entry)
+		}
+		name = strings.TrimSpace(name)
+		group, principal, hasPrincipal := strings.Cut(name, ":")
+		if !hasPrincipal {
+			principal = anyPrincipal
+		}
+		switch principal {
+		case PrincipalMember, PrincipalAPIKey, PrincipalIP, anyPrincipal:
+		default:
+			return nil, fmt.Errorf("rate limit policy %q: unknown principal %q", name, principal)
+		}
+		if group == "" {
+			return nil, fmt.Errorf("rate limit policy %q: group is required", entry)
+		}
+		name = group + ":" + principal
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rate limit policy %q", name)
+		}
+		seen[name] = true
+
+		policy, err := parseRule(name, rule)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parseRule(name, rule string) (Policy, error) {
+	parts := strings.Split(rule, ",")
+	limitText, periodText, found := strings.Cut(strings.TrimSpace(parts[0]), "/")
+	if !found {
+		return Policy{}, fmt.Errorf("rate limit policy %q: expected <limit>/<period>", name)
+	}
+	limit, err := strconv.Atoi(limitText)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit policy %q: invalid limit %q", name, limitText)
+	}
+	period, err := parsePeriod(periodText)
+	if err != nil {
+		return Policy{}, fmt.Errorf("rate limit policy %q: %w", name, err)
+	}
+
+	policy := Policy{Name: name, Limit: limit, Period: period, Burst: limit}
+	for _, option := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		if key != "burst" {
+			return Policy{}, fmt.Errorf("rate limit policy %q: unknown option %q", name, key)
+		}
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst <= 0 {
+			return Policy{}, fmt.Errorf("rate limit policy %q: invalid burst %q", name, value)
+		}
+		policy.Burst = burst
+	}
+	return policy, nil
+}
+
+func parsePeriod(text string) (time.Duration, error) {
+	switch text {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	period, err := time.ParseDuration(text)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid period %q", text)
+	}
+	return period, nil
+}
@@ -0,0 +1,244 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore 建立以 *now 作為時鐘的記憶體儲存
+func newTestStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := newTestStore(&now)
+	// 每秒補充 1 個 token，最多累積 3 個
+	policy := Policy{Name: "test:*", Limit: 60, Period: time.Minute, Burst: 3}
+
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		wantAllowed bool
+		wantTokens  float64
+	}{
+		{name: "新的 bucket 為滿", wantAllowed: true, wantTokens: 2},
+		{name: "突發第 2 個", wantAllowed: true, wantTokens: 1},
+		{name: "突發第 3 個", wantAllowed: true, wantTokens: 0},
+		{name: "突發用完", wantAllowed: false, wantTokens: 0},
+		{name: "半秒只補充半個", advance: 500 * time.Millisecond, wantAllowed: false, wantTokens: 0.5},
+		{name: "滿一個 token 後可取用", advance: 500 * time.Millisecond, wantAllowed: true, wantTokens: 0},
+		{name: "補充不超過 burst", advance: time.Hour, wantAllowed: true, wantTokens: 2},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			now = now.Add(step.advance)
+			tokens, allowed, err := store.Take("k", policy)
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if allowed != step.wantAllowed {
+				t.Errorf("期望 allowed=%v，得到 %v", step.wantAllowed, allowed)
+			}
+			if tokens != step.wantTokens {
+				t.Errorf("期望剩餘 %v 個 token，得到 %v", step.wantTokens, tokens)
+			}
+		})
+	}
+
+	// 不同 key 各自計算
+	if _, allowed, _ := store.Take("other", policy); !allowed {
+		t.Error("其他 key 不受影響")
+	}
+}
+
+func TestLimiter_Decision(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(newTestStore(&now), []Policy{
+		{Name: "default:*", Limit: 60, Period: time.Minute, Burst: 2},
+	})
+
+	tests := []struct {
+		name           string
+		wantAllowed    bool
+		wantRemaining  int
+		wantReset      time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{name: "第一次", wantAllowed: true, wantRemaining: 1, wantReset: time.Second},
+		{name: "用完", wantAllowed: true, wantRemaining: 0, wantReset: 2 * time.Second},
+		{name: "被限制", wantAllowed: false, wantRemaining: 0, wantReset: 2 * time.Second, wantRetryAfter: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, ok, err := limiter.Allow("devices", PrincipalMember, "7")
+			if err != nil || !ok {
+				t.Fatalf("期望套用政策，得到 ok=%v err=%v", ok, err)
+			}
+			if decision.Allowed != tt.wantAllowed || decision.Remaining != tt.wantRemaining ||
+				decision.Reset != tt.wantReset || decision.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("期望 allowed=%v remaining=%d reset=%s retry=%s，得到 %+v",
+					tt.wantAllowed, tt.wantRemaining, tt.wantReset, tt.wantRetryAfter, decision)
+			}
+		})
+	}
+
+	// 同一政策套用在不同群組時 bucket 各自計算
+	if decision, _, _ := limiter.Allow("companies", PrincipalMember, "7"); !decision.Allowed {
+		t.Error("其他群組不受影響")
+	}
+}
+
+func TestLimiter_Resolve(t *testing.T) {
+	policies, err := ParsePolicies("auth:ip=20/m;auth=100/m;dashboard:member=120/m;default:api_key=300/m;default=600/m;default:ip=3000/m")
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	limiter := NewLimiter(NewMemoryStore(), policies)
+
+	tests := []struct {
+		group, principal string
+		want             string
+	}{
+		{"auth", PrincipalIP, "auth:ip"},
+		{"auth", PrincipalMember, "auth:*"},
+		{"dashboard", PrincipalMember, "dashboard:member"},
+		{"dashboard", PrincipalAPIKey, "default:api_key"},
+		{"dashboard", PrincipalIP, "default:ip"},
+		{"devices", PrincipalMember, "default:*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.group+":"+tt.principal, func(t *testing.T) {
+			policy, ok := limiter.Resolve(tt.group, tt.principal)
+			if !ok || policy.Name != tt.want {
+				t.Errorf("期望 %s，得到 %s (ok=%v)", tt.want, policy.Name, ok)
+			}
+		})
+	}
+
+	if _, ok := NewLimiter(NewMemoryStore(), nil).Resolve("auth", PrincipalIP); ok {
+		t.Error("沒有政策時不應限流")
+	}
+}
+
+func TestLimiter_AllowIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		group      string
+		wantPolicy string // 空字串表示不限流
+	}{
+		{name: "群組 IP 政策", spec: "auth:ip=20/m;default:ip=3000/m", group: "auth", wantPolicy: "auth:ip"},
+		{name: "預設 IP 政策", spec: "auth:ip=20/m;default:ip=3000/m", group: "devices", wantPolicy: "default:ip"},
+		{name: "不套用適用所有呼叫者的政策", spec: "devices=100/m;default=600/m", group: "devices"},
+		{name: "不套用其他呼叫者種類的政策", spec: "devices:member=100/m", group: "devices"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := ParsePolicies(tt.spec)
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			decision, ok, err := NewLimiter(NewMemoryStore(), policies).AllowIP(tt.group, "10.0.0.1")
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if tt.wantPolicy == "" {
+				if ok {
+					t.Errorf("不應限流，得到 %s", decision.Policy.Name)
+				}
+				return
+			}
+			if !ok || decision.Policy.Name != tt.wantPolicy {
+				t.Errorf("期望 %s，得到 %s (ok=%v)", tt.wantPolicy, decision.Policy.Name, ok)
+			}
+		})
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Policy
+		wantErr string
+	}{
+		{
+			name: "完整格式",
+			spec: " auth:ip=20/m,burst=10 ; dashboard=120/1m30s;default=5/s ",
+			want: []Policy{
+				{Name: "auth:ip", Limit: 20, Period: time.Minute, Burst: 10},
+				{Name: "dashboard:*", Limit: 120, Period: 90 * time.Second, Burst: 120},
+				{Name: "default:*", Limit: 5, Period: time.Second, Burst: 5},
+			},
+		},
+		{name: "空字串", spec: "", want: nil},
+		{name: "缺少等號", spec: "auth", wantErr: "expected <group>=<limit>/<period>"},
+		{name: "未知呼叫者", spec: "auth:user=20/m", wantErr: "unknown principal"},
+		{name: "缺少群組", spec: ":ip=20/m", wantErr: "group is required"},
+		{name: "重複政策", spec: "auth=20/m;auth:*=30/m", wantErr: "duplicate"},
+		{name: "缺少期間", spec: "auth=20", wantErr: "expected <limit>/<period>"},
+		{name: "數量不是正整數", spec: "auth=0/m", wantErr: "invalid limit"},
+		{name: "期間無效", spec: "auth=20/day", wantErr: "invalid period"},
+		{name: "期間為負", spec: "auth=20/-1m", wantErr: "invalid period"},
+		{name: "未知選項", spec: "auth=20/m,max=5", wantErr: "unknown option"},
+		{name: "burst 無效", spec: "auth=20/m,burst=0", wantErr: "invalid burst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := ParsePolicies(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望包含 %q 的錯誤，得到 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望錯誤: %v", err)
+			}
+			if len(policies) != len(tt.want) {
+				t.Fatalf("期望 %d 個政策，得到 %+v", len(tt.want), policies)
+			}
+			for i := range policies {
+				if policies[i] != tt.want[i] {
+					t.Errorf("政策 %d 期望 %+v，得到 %+v", i, tt.want[i], policies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiter_Purge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := newTestStore(&now)
+	// 補滿時間: fast 為 2 秒，slow 為 10 秒；閒置超過最長補滿時間才刪除
+	limiter := NewLimiter(store, []Policy{
+		{Name: "fast:*", Limit: 60, Period: time.Minute, Burst: 2},
+		{Name: "slow:*", Limit: 6, Period: time.Minute, Burst: 1},
+	})
+
+	_, _, _ = limiter.Allow("fast", PrincipalMember, "1")
+	_, _, _ = limiter.Allow("slow", PrincipalMember, "1")
+	now = now.Add(5 * time.Second)
+	_, _, _ = limiter.Allow("fast", PrincipalMember, "2")
+
+	now = now.Add(6 * time.Second)
+	purged, err := limiter.Purge()
+	if err != nil {
+		t.Fatalf("不期望錯誤: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("期望刪除閒置 11 秒的 2 個 bucket，得到 %d", purged)
+	}
+	if _, ok := store.buckets["fast:member:2"]; !ok {
+		t.Error("閒置 6 秒的 bucket 不應刪除")
+	}
+
+	// 刪除後重新建立的 bucket 為滿
+	if decision, _, _ := limiter.Allow("slow", PrincipalMember, "1"); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("重新建立的 bucket 應為滿，得到 %+v", decision)
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryStore - 單一實例的 bucket 儲存，多個實例時每個實例各自計算
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryStore - 建立記憶體儲存
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take 補充後嘗試取用一個 token
+func (s *MemoryStore) Take(key string, policy Policy) (float64, bool, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	b.tokens = math.Min(float64(policy.Burst), b.tokens+elapsed*policy.rate())
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// Purge 刪除閒置超過 idle 的 bucket
+func (s *MemoryStore) Purge(idle time.Duration) (int64, error) {
+	cutoff := s.now().Add(-idle)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, b := range s.buckets {
+		if b.updatedAt.Before(cutoff) {
+			delete(s.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// takeSQL 以單一 upsert 補充並取用 token，所有實例以資料庫時間計算
+// SET 中的 b.* 為更新前的值，allowed 記錄本次是否取用成功
+const takeSQL = `
+INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@burst AS float8) - 1, TRUE, LOCALTIMESTAMP)
+ON CONFLICT (bucket_key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST(CAST(@burst AS float8), b.tokens + EXTRACT(EPOCH FROM LOCALTIMESTAMP - b.updated_at) * CAST(@rate AS float8)) >= 1
+        THEN LEAST(CAST(@burst AS float8), b.tokens + EXTRACT(EPOCH FROM LOCALTIMESTAMP - b.updated_at) * CAST(@rate AS float8)) - 1
+        ELSE LEAST(CAST(@burst AS float8), b.tokens + EXTRACT(EPOCH FROM LOCALTIMESTAMP - b.updated_at) * CAST(@rate AS float8))
+    END,
+    allowed = LEAST(CAST(@burst AS float8), b.tokens + EXTRACT(EPOCH FROM LOCALTIMESTAMP - b.updated_at) * CAST(@rate AS float8)) >= 1,
+    updated_at = LOCALTIMESTAMP
+RETURNING tokens, allowed`
+
+// PostgresStore - 多個實例共用的 bucket 儲存 (rate_limit_buckets)
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore - 建立資料庫儲存
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take 補充後嘗試取用一個 token
+func (s *PostgresStore) Take(key string, policy Policy) (float64, bool, error) {
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+	err := s.db.Raw(takeSQL, map[string]interface{}{
+		"key":   key,
+		"burst": float64(policy.Burst),
+		"rate":  policy.rate(),
+	}).Scan(&row).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return row.Tokens, row.Allowed, nil
+}
+
+// Purge 刪除閒置超過 idle 的 bucket
+func (s *PostgresStore) Purge(idle time.Duration) (int64, error) {
+	result := s.db.Exec("DELETE FROM rate_limit_buckets WHERE updated_at < LOCALTIMESTAMP - make_interval(secs => ?)", idle.Seconds())
+	return result.RowsAffected, result.Error
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"ems_backend/internal/application/dto"
 	"ems_backend/internal/application/services"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -20,6 +22,63 @@ func NewAuditLogHandler(auditLogAppService *services.AuditLogApplicationService)
 
 // Query 根據過濾條件查詢審計日誌
 func (h *AuditLogHandler) Query(c *gin.Context) {
+	req := parseAuditLogFilter(c)
+
+	// 解析分頁參數
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = limit
+		}
+	}
+	if req.Limit == 0 {
+		req.Limit = 50 // 默認50條
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil {
+			req.Offset = offset
+		}
+	}
+
+	result, err := h.auditLogAppService.Query(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{Success: true, Data: result})
+}
+
+// Export 以 CSV 或 JSON Lines 串流匯出符合條件的審計日誌
+// 查詢參數與 Query 相同 (不分頁)，format 為 csv (預設) 或 jsonl
+func (h *AuditLogHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", services.AuditExportCSV)
+	var contentType string
+	switch format {
+	case services.AuditExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case services.AuditExportJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, dto.APIResponse{Success: false, Error: "format must be csv or jsonl"})
+		return
+	}
+
+	req := parseAuditLogFilter(c)
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 已開始輸出後無法再改變狀態碼，只能記錄錯誤
+	if err := h.auditLogAppService.Export(&req, format, c.Writer); err != nil {
+		log.Printf("[AuditLog] Export failed: %v", err)
+		c.Abort()
+	}
+}
+
+// parseAuditLogFilter 解析審計日誌的過濾條件 (不含分頁)
+func parseAuditLogFilter(c *gin.Context) dto.AuditLogQueryRequest {
 	var req dto.AuditLogQueryRequest
 
 	// 解析查詢參數
@@ -54,29 +113,7 @@ func (h *AuditLogHandler) Query(c *gin.Context) {
 		}
 	}
 
-	// 解析分頁參數
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			req.Limit = limit
-		}
-	}
-	if req.Limit == 0 {
-		req.Limit = 50 // 默認50條
-	}
-
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			req.Offset = offset
-		}
-	}
-
-	result, err := h.auditLogAppService.Query(&req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, dto.APIResponse{Success: true, Data: result})
+	return req
 }
 
 // GetByID 根據ID獲取審計日誌
@@ -97,6 +134,28 @@ func (h *AuditLogHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.APIResponse{Success: true, Data: log})
 }
 
+// VerifyChain 驗證審計日誌雜湊鏈，偵測被修改或刪除的記錄
+// 查詢參數 from 為起始序號 (選填，預設從頭驗證)
+func (h *AuditLogHandler) VerifyChain(c *gin.Context) {
+	var fromSequence uint64
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := strconv.ParseUint(fromStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.APIResponse{Success: false, Error: "Invalid from sequence"})
+			return
+		}
+		fromSequence = from
+	}
+
+	result, err := h.auditLogAppService.VerifyChain(fromSequence)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIResponse{Success: true, Data: result})
+}
+
 // GetByMemberID 獲取指定成員的審計日誌
 func (h *AuditLogHandler) GetByMemberID(c *gin.Context) {
 	memberIDStr := c.Param("memberId")
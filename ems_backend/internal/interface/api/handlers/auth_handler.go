@@ -3,7 +3,11 @@ package handlers
 import (
 	"ems_backend/internal/application/dto"
 	"ems_backend/internal/application/services"
+	auth_services "ems_backend/internal/domain/auth/services"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,7 +33,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authAppService.Login(&req)
+	response, err := h.authAppService.Login(&req, c.ClientIP(), c.Request.UserAgent())
+	if respondLoginError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -57,7 +64,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authAppService.RefreshToken(&req)
+	response, err := h.authAppService.RefreshToken(&req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -85,7 +92,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authAppService.Logout(&req)
+	response, err := h.authAppService.Logout(&req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -101,3 +108,300 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ForgotPassword - 忘記密碼，寄送重設連結
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.ForgotPassword(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error:   "internal server error",
+		})
+		return
+	}
+
+	if !response.Success {
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword - 以重設連結設定新密碼
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.ResetPassword(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error:   "internal server error",
+		})
+		return
+	}
+
+	if !response.Success {
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ChangePassword - 變更自己的密碼，其他會話會被撤銷
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.ChangePassword(memberID, c.GetString("session_id"), &req)
+	if respondLoginError(c, err) {
+		return
+	}
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+// GetPasswordPolicy - 密碼政策 (不需登入，供註冊與重設頁面顯示規則)
+func (h *AuthHandler) GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, h.authAppService.GetPasswordPolicy())
+}
+
+// ListOIDCProviders - 可用的單一登入身分提供者
+func (h *AuthHandler) ListOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.authAppService.ListOIDCProviders())
+}
+
+// AuthorizeOIDC - 產生身分提供者的登入網址 (?redirect_path= 登入後的前端路徑)
+func (h *AuthHandler) AuthorizeOIDC(c *gin.Context) {
+	response, err := h.authAppService.BeginOIDCLogin(c.Param("provider"), c.Query("redirect_path"))
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+// OIDCCallback - 身分提供者導回前端後，以 code 與 state 完成登入並簽發 token
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), &req, c.ClientIP(), c.Request.UserAgent())
+	if respondLoginError(c, err) {
+		return
+	}
+	h.respondResult(c, response, err, http.StatusUnauthorized)
+}
+
+// respondLoginError 節流回應 429、帳號鎖定回應 423、帳號停用回應 403，並附上 Retry-After
+func respondLoginError(c *gin.Context, err error) bool {
+	var loginErr *auth_services.LoginError
+	if !errors.As(err, &loginErr) {
+		return false
+	}
+	status := http.StatusTooManyRequests
+	if errors.Is(loginErr, auth_services.ErrAccountLocked) {
+		status = http.StatusLocked
+	} else if errors.Is(loginErr, auth_services.ErrAccountDisabled) {
+		status = http.StatusForbidden
+	}
+	if loginErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(loginErr.RetryAfter.Seconds()))))
+	}
+	c.JSON(status, dto.APIResponse{
+		Success: false,
+		Error:   loginErr.Error(),
+	})
+	return true
+}
+
+// VerifyMFA - 兩階段登入第二步，驗證 TOTP 或復原碼後簽發 token
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.VerifyMFA(&req, c.ClientIP(), c.Request.UserAgent())
+	if respondLoginError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error:   "internal server error",
+		})
+		return
+	}
+
+	if !response.Success {
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// EnrollMFAChallenge - 角色要求 MFA 但尚未設定時，於登入途中取得驗證器密鑰
+func (h *AuthHandler) EnrollMFAChallenge(c *gin.Context) {
+	var req dto.MFAChallengeEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authAppService.BeginChallengeEnrollment(&req)
+	h.respondResult(c, response, err, http.StatusUnauthorized)
+}
+
+// MFAStatus - 查詢自己的 MFA 狀態
+func (h *AuthHandler) MFAStatus(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	response, err := h.authAppService.MFAStatus(memberID)
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+// EnrollMFA - 產生驗證器密鑰與 otpauth URI
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	response, err := h.authAppService.BeginMFAEnrollment(memberID)
+	h.respondResult(c, response, err, http.StatusConflict)
+}
+
+// ActivateMFA - 以驗證碼確認並啟用 MFA
+func (h *AuthHandler) ActivateMFA(c *gin.Context) {
+	h.withMFACode(c, h.authAppService.ActivateMFA)
+}
+
+// RegenerateRecoveryCodes - 重新產生復原碼
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	h.withMFACode(c, h.authAppService.RegenerateRecoveryCodes)
+}
+
+// DisableMFA - 停用自己的 MFA
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	h.withMFACode(c, h.authAppService.DisableMFA)
+}
+
+// ListSessions - 列出自己登入中的會話
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	response, err := h.authAppService.ListSessions(memberID, c.GetString("session_id"))
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+// RevokeSession - 撤銷自己的單一會話
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	response, err := h.authAppService.RevokeSession(memberID, c.Param("sessionId"))
+	h.respondResult(c, response, err, http.StatusNotFound)
+}
+
+// RevokeAllSessions - 撤銷自己所有會話 (?keep_current=true 保留目前的會話)
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	keepCurrent := c.Query("keep_current") == "true"
+	response, err := h.authAppService.RevokeAllSessions(memberID, c.GetString("session_id"), keepCurrent)
+	if err == nil && response.Success {
+		c.Set("audit_details", response.Data)
+	}
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+func (h *AuthHandler) withMFACode(c *gin.Context, action func(uint, *dto.MFACodeRequest) (*dto.APIResponse, error)) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := action(memberID, &req)
+	h.respondResult(c, response, err, http.StatusBadRequest)
+}
+
+func (h *AuthHandler) respondResult(c *gin.Context, response *dto.APIResponse, err error, failureStatus int) {
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error:   "internal server error",
+		})
+		return
+	}
+	if !response.Success {
+		_ = c.Error(errors.New(response.Error))
+		c.JSON(failureStatus, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// currentMemberID 從上下文獲取登入會員 ID
+func currentMemberID(c *gin.Context) (uint, bool) {
+	memberID, ok := c.Get("member_id")
+	if id, valid := memberID.(uint); ok && valid {
+		return id, true
+	}
+	c.JSON(http.StatusUnauthorized, dto.APIResponse{
+		Success: false,
+		Error:   "unauthorized",
+	})
+	return 0, false
+}
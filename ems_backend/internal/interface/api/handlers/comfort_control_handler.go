@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComfortControlHandler 區域舒適度閉環控制處理器
+type ComfortControlHandler struct {
+	comfortAppService *services.ComfortControlApplicationService
+}
+
+// NewComfortControlHandler 創建舒適度控制處理器
+func NewComfortControlHandler(
+	comfortAppService *services.ComfortControlApplicationService,
+) *ComfortControlHandler {
+	return &ComfortControlHandler{
+		comfortAppService: comfortAppService,
+	}
+}
+
+// GetSettings 取得設備各區域舒適度控制設定
+// @Summary 取得區域舒適度控制設定
+// @Tags comfort-control
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/comfort-control [get]
+func (h *ComfortControlHandler) GetSettings(c *gin.Context) {
+	companyID, deviceID, _, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.comfortAppService.GetSettings(companyID, deviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+// UpdateSetting 更新區域舒適度控制設定
+// @Summary 更新區域舒適度控制設定
+// @Tags comfort-control
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param areaId path string true "區域 ID"
+// @Param setting body dto.ComfortSettingRequest true "舒適度設定"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/comfort-control/{areaId} [put]
+func (h *ComfortControlHandler) UpdateSetting(c *gin.Context) {
+	var req dto.ComfortSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	companyID, deviceID, memberID, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	before, _ := h.comfortAppService.GetSetting(companyID, deviceID, c.Param("areaId"))
+
+	setting, err := h.comfortAppService.UpdateSetting(companyID, deviceID, c.Param("areaId"), &req, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	after, _ := h.comfortAppService.GetSetting(companyID, deviceID, c.Param("areaId"))
+	setAuditChange(c, deviceID, before, after)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setting})
+}
+
+// resolveTarget 取得公司存取中間件已檢查的公司並解析設備 ID
+func (h *ComfortControlHandler) resolveTarget(c *gin.Context) (uint, uint, uint, bool) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	deviceID, err := strconv.ParseUint(c.Param("deviceId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid device ID"})
+		return 0, 0, 0, false
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return 0, 0, 0, false
+	}
+
+	return company.ID, uint(deviceID), memberID, true
+}
@@ -2,11 +2,19 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"ems_backend/internal/application/dto"
 	"ems_backend/internal/application/services"
+	companyEntities "ems_backend/internal/domain/company/entities"
+	companyDeviceEntities "ems_backend/internal/domain/company_device/entities"
+	companyDeviceServices "ems_backend/internal/domain/company_device/services"
+	deviceEntities "ems_backend/internal/domain/device/entities"
+	"ems_backend/internal/interface/api/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -67,36 +75,14 @@ func (h *CompanyHandler) GetAll(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Router /companies/{id} [get]
 func (h *CompanyHandler) GetByID(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "invalid company ID",
-		})
-		return
-	}
-
-	memberID, roleID, err := getMemberAndRoleFromContext(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	company, err := h.companyAppService.GetByID(uint(id), memberID, roleID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
+	company, ok := companyFromContext(c)
+	if !ok {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    company,
+		"data":    dto.NewCompanyResponse(company),
 	})
 }
 
@@ -507,6 +493,8 @@ func (h *CompanyHandler) RemoveMember(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.companyAppService.GetCompanyMember(uint(id), uint(targetMemberID))
+
 	if err := h.companyAppService.RemoveMemberFromCompany(uint(id), uint(targetMemberID), memberID, roleID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -514,6 +502,7 @@ func (h *CompanyHandler) RemoveMember(c *gin.Context) {
 		})
 		return
 	}
+	setAuditChange(c, uint(id), before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -600,6 +589,15 @@ func (h *CompanyHandler) AssignDevice(c *gin.Context) {
 	}
 
 	if err := h.companyAppService.AssignDeviceToCompany(uint(id), &req, memberID); err != nil {
+		var validationErr *companyDeviceEntities.ContentValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error":   err.Error(),
+				"details": validationErr.Errors,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -613,6 +611,78 @@ func (h *CompanyHandler) AssignDevice(c *gin.Context) {
 	})
 }
 
+// ClaimDevice 以一次性認領碼將設備綁定到公司
+// @Summary 兌換設備認領碼
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param request body dto.RedeemClaimCodeRequest true "認領碼"
+// @Success 201 {object} map[string]interface{}
+// @Router /companies/{id}/devices/claim [post]
+func (h *CompanyHandler) ClaimDevice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid company ID",
+		})
+		return
+	}
+
+	var req dto.RedeemClaimCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	memberID, roleID, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	claimCode, err := h.companyAppService.ClaimDevice(uint(id), req.Code, memberID, roleID)
+	if claimCode != nil {
+		c.Set("resource_id", claimCode.DeviceID)
+		c.Set("audit_details", map[string]interface{}{
+			"company_id":    uint(id),
+			"claim_code_id": claimCode.ID,
+			"code_hint":     claimCode.CodeHint,
+			"expires_at":    claimCode.ExpiresAt,
+		})
+	}
+	if err != nil {
+		_ = c.Error(err)
+		status := http.StatusBadRequest
+		switch {
+		case err.Error() == "access denied":
+			status = http.StatusForbidden
+		case errors.Is(err, deviceEntities.ErrClaimCodeInvalid):
+			status = http.StatusNotFound
+		case errors.Is(err, deviceEntities.ErrClaimCodeExpired), errors.Is(err, deviceEntities.ErrClaimCodeRedeemed):
+			status = http.StatusGone
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    claimCode,
+		"message": "設備綁定成功",
+	})
+}
+
 // RemoveDevice 從公司移除設備
 // @Summary 移除設備
 // @Tags companies
@@ -649,6 +719,8 @@ func (h *CompanyHandler) RemoveDevice(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.companyAppService.GetCompanyDevice(uint(id), uint(deviceID))
+
 	if err := h.companyAppService.RemoveDeviceFromCompany(uint(id), uint(deviceID), memberID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -656,6 +728,7 @@ func (h *CompanyHandler) RemoveDevice(c *gin.Context) {
 		})
 		return
 	}
+	setAuditChange(c, uint(id), before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -711,7 +784,9 @@ func (h *CompanyHandler) SyncDeviceSchedule(c *gin.Context) {
 	}
 
 	// Send getSchedule command to device via MQTT to fetch schedule from device
-	if err := h.scheduleAppService.QueryScheduleFromDevice(companyDevice.ID); err != nil {
+	summary, err := h.scheduleAppService.QueryScheduleFromDevice(companyDevice.ID)
+	setDeviceCommandAudit(c, summary, err)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -775,7 +850,9 @@ func (h *CompanyHandler) QueryDeviceInfo(c *gin.Context) {
 	}
 
 	// Send deviceInfo command via MQTT using company_device.ID
-	if err := h.scheduleAppService.QueryDeviceInfo(companyDevice.ID); err != nil {
+	summary, err := h.scheduleAppService.QueryDeviceInfo(companyDevice.ID)
+	setDeviceCommandAudit(c, summary, err)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -791,8 +868,239 @@ func (h *CompanyHandler) QueryDeviceInfo(c *gin.Context) {
 	})
 }
 
+// GetSchedulePolicy 獲取公司排程漂移策略
+// @Summary 獲取排程漂移策略
+// @Tags companies
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/schedule-policy [get]
+func (h *CompanyHandler) GetSchedulePolicy(c *gin.Context) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.scheduleAppService.GetDriftPolicy(company.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdateSchedulePolicy 設定公司排程漂移策略
+// @Summary 設定排程漂移策略 (cloud_wins / device_wins / manual)
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param policy body dto.ScheduleDriftPolicyRequest true "漂移策略"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/schedule-policy [put]
+func (h *CompanyHandler) UpdateSchedulePolicy(c *gin.Context) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ScheduleDriftPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.scheduleAppService.SetDriftPolicy(company.ID, req.Policy, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+		"message": "排程漂移策略已更新",
+	})
+}
+
+// GetDeviceContentSchema 取得設備內容的 JSON Schema
+// @Summary 設備內容 JSON Schema
+// @Tags companies
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/device-content-schema [get]
+func (h *CompanyHandler) GetDeviceContentSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    companyDeviceServices.DeviceContentSchema(),
+	})
+}
+
+// PatchDeviceContent 以 JSON Patch 更新設備內容
+// 需帶 If-Match 標頭指定目前內容版本；版本不符返回 409，驗證失敗返回 422 與欄位錯誤
+// @Summary 部分更新設備內容 (RFC 6902)
+// @Tags companies
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID"
+// @Param If-Match header string true "目前內容版本"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/content [patch]
+func (h *CompanyHandler) PatchDeviceContent(c *gin.Context) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return
+	}
+
+	deviceID, err := strconv.ParseUint(c.Param("deviceId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "invalid device ID",
+		})
+		return
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"success": false,
+			"error":   "If-Match header with the current content version is required",
+		})
+		return
+	}
+	expectedVersion, err := parseContentVersion(ifMatch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	before, _ := h.companyAppService.GetCompanyDevice(company.ID, uint(deviceID))
+
+	device, err := h.companyAppService.PatchDeviceContent(company.ID, uint(deviceID), patch, expectedVersion, memberID)
+	if err != nil {
+		var validationErr *companyDeviceEntities.ContentValidationError
+		var patchErr *companyDeviceServices.JSONPatchError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error":   err.Error(),
+				"details": validationErr.Errors,
+			})
+		case errors.Is(err, companyDeviceEntities.ErrContentVersionConflict):
+			currentVersion, _ := h.companyAppService.GetDeviceContentVersion(company.ID, uint(deviceID))
+			c.JSON(http.StatusConflict, gin.H{
+				"success":         false,
+				"error":           err.Error(),
+				"current_version": currentVersion,
+			})
+		case errors.As(err, &patchErr):
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, companyDeviceServices.ErrPatchTestFailed) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+	setAuditChange(c, company.ID, before, device)
+
+	c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(expectedVersion+1, 10)))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    device,
+		"message": "設備內容已更新",
+	})
+}
+
 // ==================== 輔助函數 ====================
 
+// parseContentVersion 解析 If-Match 中的內容版本 (接受 3、"3"、W/"3")
+func parseContentVersion(ifMatch string) (int64, error) {
+	value := strings.TrimPrefix(strings.TrimSpace(ifMatch), "W/")
+	value = strings.Trim(value, `"`)
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid If-Match version %q", ifMatch)
+	}
+	return version, nil
+}
+
+// companyFromContext 取得公司存取中間件已檢查並放入上下文的公司
+// 路由未套用 RequireCompanyAccess 時返回 500，不在未檢查的情況下繼續處理
+func companyFromContext(c *gin.Context) (*companyEntities.Company, bool) {
+	value, _ := c.Get(middleware.CompanyContextKey)
+	company, ok := value.(*companyEntities.Company)
+	if !ok || company == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "company access not checked",
+		})
+		return nil, false
+	}
+	return company, true
+}
+
+// setAuditChange 設定審計日誌的資源 ID 與異動前後快照 (刪除時 after 為 nil)
+func setAuditChange(c *gin.Context, resourceID uint, before, after interface{}) {
+	c.Set("resource_id", resourceID)
+	c.Set("audit_before", before)
+	c.Set("audit_after", after)
+}
+
 // getMemberAndRoleFromContext 從上下文獲取 member_id 和 role_id
 func getMemberAndRoleFromContext(c *gin.Context) (uint, uint, error) {
 	memberIDVal, exists := c.Get("member_id")
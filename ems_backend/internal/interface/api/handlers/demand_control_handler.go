@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DemandControlHandler 契約容量需量控制處理器
+type DemandControlHandler struct {
+	demandAppService *services.DemandControlApplicationService
+}
+
+// NewDemandControlHandler 創建需量控制處理器
+func NewDemandControlHandler(
+	demandAppService *services.DemandControlApplicationService,
+) *DemandControlHandler {
+	return &DemandControlHandler{
+		demandAppService: demandAppService,
+	}
+}
+
+// GetSetting 取得公司需量控制設定
+// @Summary 取得需量控制設定
+// @Tags demand-control
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/demand-control [get]
+func (h *DemandControlHandler) GetSetting(c *gin.Context) {
+	companyID, _, ok := h.resolveCompany(c)
+	if !ok {
+		return
+	}
+
+	setting, err := h.demandAppService.GetSetting(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setting})
+}
+
+// UpdateSetting 更新公司需量控制設定 (契約容量、門檻與卸載優先順序)
+// @Summary 更新需量控制設定
+// @Tags demand-control
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param setting body dto.DemandSettingRequest true "需量控制設定"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/demand-control [put]
+func (h *DemandControlHandler) UpdateSetting(c *gin.Context) {
+	var req dto.DemandSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	companyID, memberID, ok := h.resolveCompany(c)
+	if !ok {
+		return
+	}
+
+	setting, err := h.demandAppService.UpdateSetting(companyID, &req, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setting})
+}
+
+// GetEvents 取得卸載 / 復歸事件紀錄
+// @Summary 取得需量卸載事件
+// @Tags demand-control
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param limit query int false "筆數上限 (預設 50)"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/demand-control/events [get]
+func (h *DemandControlHandler) GetEvents(c *gin.Context) {
+	companyID, _, ok := h.resolveCompany(c)
+	if !ok {
+		return
+	}
+
+	limit := services.DemandEventLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	events, err := h.demandAppService.GetEvents(companyID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": events})
+}
+
+// GetDashboard Dashboard 需量總覽
+// @Summary 需量總覽 (滾動平均、預測、卸載中負載、最近事件)
+// @Tags dashboard
+// @Produce json
+// @Param company_id query int true "公司 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /dashboard/demand [get]
+func (h *DemandControlHandler) GetDashboard(c *gin.Context) {
+	companyID, _, ok := h.resolveCompany(c)
+	if !ok {
+		return
+	}
+
+	dashboard, err := h.demandAppService.GetDashboard(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": dashboard})
+}
+
+// resolveCompany 取得公司存取中間件已檢查的公司
+func (h *DemandControlHandler) resolveCompany(c *gin.Context) (uint, uint, bool) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return 0, 0, false
+	}
+
+	return company.ID, memberID, true
+}
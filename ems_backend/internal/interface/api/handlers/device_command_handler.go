@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceCommandHandler 遠端控制命令處理器
+type DeviceCommandHandler struct {
+	commandAppService *services.DeviceCommandApplicationService
+}
+
+// NewDeviceCommandHandler 創建遠端控制命令處理器
+func NewDeviceCommandHandler(
+	commandAppService *services.DeviceCommandApplicationService,
+) *DeviceCommandHandler {
+	return &DeviceCommandHandler{
+		commandAppService: commandAppService,
+	}
+}
+
+// Power 開關 VRF 室內機或箱型機壓縮機
+// @Summary 遠端開關機
+// @Tags device-commands
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param wait query int false "等待設備確認秒數 (最多 30)"
+// @Param command body dto.DevicePowerCommandRequest true "開關機命令"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/commands/power [post]
+func (h *DeviceCommandHandler) Power(c *gin.Context) {
+	var req dto.DevicePowerCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	companyID, deviceID, memberID, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.commandAppService.SendPower(companyID, deviceID, &req, memberID, parseWait(c))
+	h.respond(c, result, err)
+}
+
+// Mode 設定 VRF 室內機運轉模式
+// @Summary 遠端設定運轉模式
+// @Tags device-commands
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param wait query int false "等待設備確認秒數 (最多 30)"
+// @Param command body dto.DeviceModeCommandRequest true "運轉模式命令"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/commands/mode [post]
+func (h *DeviceCommandHandler) Mode(c *gin.Context) {
+	var req dto.DeviceModeCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	companyID, deviceID, memberID, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.commandAppService.SendMode(companyID, deviceID, &req, memberID, parseWait(c))
+	h.respond(c, result, err)
+}
+
+// Setpoint 設定 VRF 室內機溫度
+// @Summary 遠端設定溫度
+// @Tags device-commands
+// @Accept json
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param wait query int false "等待設備確認秒數 (最多 30)"
+// @Param command body dto.DeviceSetpointCommandRequest true "設定溫度命令"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/commands/setpoint [post]
+func (h *DeviceCommandHandler) Setpoint(c *gin.Context) {
+	var req dto.DeviceSetpointCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	companyID, deviceID, memberID, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.commandAppService.SendSetpoint(companyID, deviceID, &req, memberID, parseWait(c))
+	h.respond(c, result, err)
+}
+
+// List 查詢設備命令紀錄
+// @Summary 查詢遠端控制命令紀錄
+// @Tags device-commands
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param limit query int false "筆數上限 (預設 50)"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/commands [get]
+func (h *DeviceCommandHandler) List(c *gin.Context) {
+	companyID, deviceID, _, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	commands, err := h.commandAppService.ListCommands(companyID, deviceID, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": commands})
+}
+
+// Get 查詢單一命令確認狀態
+// @Summary 查詢遠端控制命令狀態
+// @Tags device-commands
+// @Produce json
+// @Param id path int true "公司 ID"
+// @Param deviceId path int true "設備 ID (device.id)"
+// @Param commandId path string true "命令 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /companies/{id}/devices/{deviceId}/commands/{commandId} [get]
+func (h *DeviceCommandHandler) Get(c *gin.Context) {
+	companyID, deviceID, _, ok := h.resolveTarget(c)
+	if !ok {
+		return
+	}
+
+	command, err := h.commandAppService.GetCommand(companyID, deviceID, c.Param("commandId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": command})
+}
+
+// resolveTarget 取得公司存取中間件已檢查的公司並解析設備 ID
+func (h *DeviceCommandHandler) resolveTarget(c *gin.Context) (uint, uint, uint, bool) {
+	company, ok := companyFromContext(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	deviceID, err := strconv.ParseUint(c.Param("deviceId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid device ID"})
+		return 0, 0, 0, false
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return 0, 0, 0, false
+	}
+
+	return company.ID, uint(deviceID), memberID, true
+}
+
+// respond 返回命令結果；發送失敗時仍返回命令紀錄以便追蹤
+func (h *DeviceCommandHandler) respond(c *gin.Context, result *dto.DeviceCommandResponse, err error) {
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	status := http.StatusAccepted
+	if result.Status == "acknowledged" {
+		status = http.StatusOK
+	} else if result.Status == "failed" {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, gin.H{
+		"success": result.Status != "failed",
+		"data":    result,
+	})
+}
+
+// parseWait 解析等待設備確認秒數
+func parseWait(c *gin.Context) time.Duration {
+	seconds, err := strconv.Atoi(c.Query("wait"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
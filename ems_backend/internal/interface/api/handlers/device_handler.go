@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"ems_backend/internal/application/dto"
 	"ems_backend/internal/application/services"
@@ -226,3 +229,190 @@ func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
 		"message": "設備刪除成功",
 	})
 }
+
+// ImportDevices 從 CSV 批次建檔設備
+// 接受 multipart 欄位 file 或 text/csv 請求主體；欄位為 sn, model, notes
+// @Summary 批次匯入設備
+// @Tags devices
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file false "CSV 檔案"
+// @Param dry_run query bool false "僅驗證不寫入"
+// @Param issue_claim_codes query bool false "為新建設備簽發認領碼"
+// @Param claim_code_ttl_hours query int false "認領碼有效時數 (預設 168)"
+// @Success 200 {object} map[string]interface{}
+// @Router /devices/import [post]
+func (h *DeviceHandler) ImportDevices(c *gin.Context) {
+	memberID, roleID, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "未授權",
+		})
+		return
+	}
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "請上傳 CSV 檔案 (file)",
+			})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "讀取檔案失敗: " + err.Error(),
+			})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	opts := dto.DeviceImportOptions{
+		DryRun:          c.Query("dry_run") == "true",
+		IssueClaimCodes: c.Query("issue_claim_codes") == "true",
+	}
+	if hours := c.Query("claim_code_ttl_hours"); hours != "" {
+		n, err := strconv.Atoi(hours)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "無效的 claim_code_ttl_hours",
+			})
+			return
+		}
+		opts.ClaimCodeTTL = time.Duration(n) * time.Hour
+	}
+
+	report, err := h.deviceService.ImportDevices(body, opts, memberID, roleID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 審計只記錄摘要與認領碼提示，不記錄明文
+	claimCodes := make([]map[string]interface{}, 0)
+	for _, row := range report.Rows {
+		if row.ClaimCode != nil {
+			claimCodes = append(claimCodes, map[string]interface{}{
+				"device_id":  row.DeviceID,
+				"code_hint":  row.ClaimCode.CodeHint,
+				"expires_at": row.ClaimCode.ExpiresAt,
+			})
+		}
+	}
+	c.Set("audit_details", map[string]interface{}{
+		"dry_run":     report.DryRun,
+		"total":       report.Total,
+		"created":     report.Created,
+		"failed":      report.Failed,
+		"claim_codes": claimCodes,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// IssueClaimCode 為未綁定設備簽發一次性認領碼
+// @Summary 簽發設備認領碼
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param id path int true "設備 ID"
+// @Param request body dto.IssueClaimCodeRequest false "有效期限"
+// @Success 201 {object} map[string]interface{}
+// @Router /devices/{id}/claim-codes [post]
+func (h *DeviceHandler) IssueClaimCode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的設備 ID",
+		})
+		return
+	}
+
+	var req dto.IssueClaimCodeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "請求參數錯誤: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	memberID, roleID, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "未授權",
+		})
+		return
+	}
+
+	claimCode, err := h.deviceService.IssueClaimCode(uint(id), time.Duration(req.TTLHours)*time.Hour, memberID, roleID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Set("audit_details", map[string]interface{}{
+		"claim_code_id": claimCode.ID,
+		"code_hint":     claimCode.CodeHint,
+		"expires_at":    claimCode.ExpiresAt,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    claimCode,
+		"message": "認領碼僅顯示一次，請妥善保存",
+	})
+}
+
+// GetClaimCodes 取得設備的認領碼紀錄 (不含明文)
+// @Summary 設備認領碼紀錄
+// @Tags devices
+// @Produce json
+// @Param id path int true "設備 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /devices/{id}/claim-codes [get]
+func (h *DeviceHandler) GetClaimCodes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的設備 ID",
+		})
+		return
+	}
+
+	codes, err := h.deviceService.GetClaimCodes(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "獲取認領碼紀錄失敗: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    codes,
+	})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FirmwareHandler 閘道器韌體與 OTA 發布處理器
+type FirmwareHandler struct {
+	firmwareAppService *services.FirmwareApplicationService
+}
+
+// NewFirmwareHandler 創建韌體處理器
+func NewFirmwareHandler(firmwareAppService *services.FirmwareApplicationService) *FirmwareHandler {
+	return &FirmwareHandler{
+		firmwareAppService: firmwareAppService,
+	}
+}
+
+// List 取得韌體目錄
+// @Summary 韌體目錄
+// @Tags firmware
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware [get]
+func (h *FirmwareHandler) List(c *gin.Context) {
+	firmwares, err := h.firmwareAppService.ListFirmware()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": firmwares})
+}
+
+// Create 新增韌體檔案
+// @Summary 新增韌體檔案
+// @Tags firmware
+// @Accept json
+// @Produce json
+// @Param firmware body dto.CreateFirmwareRequest true "韌體資訊 (版本、下載位址、SHA-256)"
+// @Success 201 {object} map[string]interface{}
+// @Router /firmware [post]
+func (h *FirmwareHandler) Create(c *gin.Context) {
+	var req dto.CreateFirmwareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	firmware, err := h.firmwareAppService.CreateFirmware(&req, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": firmware})
+}
+
+// GetInventory 取得設備韌體 / 硬體版本盤點
+// @Summary 韌體版本盤點
+// @Tags firmware
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/inventory [get]
+func (h *FirmwareHandler) GetInventory(c *gin.Context) {
+	inventory, err := h.firmwareAppService.GetInventory()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": inventory})
+}
+
+// ListCampaigns 取得發布活動列表
+// @Summary 韌體發布活動列表
+// @Tags firmware
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/campaigns [get]
+func (h *FirmwareHandler) ListCampaigns(c *gin.Context) {
+	campaigns, err := h.firmwareAppService.ListCampaigns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaigns})
+}
+
+// CreateCampaign 建立發布活動 (依比例或指定設備)
+// @Summary 建立韌體發布活動
+// @Tags firmware
+// @Accept json
+// @Produce json
+// @Param campaign body dto.CreateCampaignRequest true "發布活動設定"
+// @Success 201 {object} map[string]interface{}
+// @Router /firmware/campaigns [post]
+func (h *FirmwareHandler) CreateCampaign(c *gin.Context) {
+	var req dto.CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	campaign, err := h.firmwareAppService.CreateCampaign(&req, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": campaign})
+}
+
+// GetCampaign 取得發布活動與各設備進度
+// @Summary 韌體發布活動詳情
+// @Tags firmware
+// @Produce json
+// @Param id path int true "活動 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/campaigns/{id} [get]
+func (h *FirmwareHandler) GetCampaign(c *gin.Context) {
+	id, ok := parseCampaignID(c)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.firmwareAppService.GetCampaign(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaign})
+}
+
+// PauseCampaign 暫停發布活動
+// @Summary 暫停韌體發布
+// @Tags firmware
+// @Produce json
+// @Param id path int true "活動 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/campaigns/{id}/pause [post]
+func (h *FirmwareHandler) PauseCampaign(c *gin.Context) {
+	h.transition(c, h.firmwareAppService.PauseCampaign)
+}
+
+// ResumeCampaign 恢復暫停或自動停止的發布活動
+// @Summary 恢復韌體發布
+// @Tags firmware
+// @Produce json
+// @Param id path int true "活動 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/campaigns/{id}/resume [post]
+func (h *FirmwareHandler) ResumeCampaign(c *gin.Context) {
+	h.transition(c, h.firmwareAppService.ResumeCampaign)
+}
+
+// CancelCampaign 取消發布活動
+// @Summary 取消韌體發布
+// @Tags firmware
+// @Produce json
+// @Param id path int true "活動 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /firmware/campaigns/{id}/cancel [post]
+func (h *FirmwareHandler) CancelCampaign(c *gin.Context) {
+	h.transition(c, h.firmwareAppService.CancelCampaign)
+}
+
+func (h *FirmwareHandler) transition(c *gin.Context, apply func(id, memberID uint) (*dto.CampaignDetailResponse, error)) {
+	id, ok := parseCampaignID(c)
+	if !ok {
+		return
+	}
+
+	memberID, _, err := getMemberAndRoleFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	campaign, err := apply(id, memberID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaign})
+}
+
+func parseCampaignID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid campaign ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
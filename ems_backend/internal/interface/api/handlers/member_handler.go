@@ -5,6 +5,7 @@ import (
 	"ems_backend/internal/application/services"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -96,6 +97,65 @@ func (h *MemberHandler) UpdateStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// Unlock 解除成員登入鎖定
+func (h *MemberHandler) Unlock(c *gin.Context) {
+	id := c.Param("id")
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   "Invalid ID format",
+		})
+		return
+	}
+
+	response, err := h.memberAppService.Unlock(uint(parsedID))
+	if err != nil {
+		_ = c.Error(err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "member not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Set("audit_details", response.Data)
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetMFA 清除成員的 MFA 設定
+func (h *MemberHandler) ResetMFA(c *gin.Context) {
+	id := c.Param("id")
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   "Invalid ID format",
+		})
+		return
+	}
+
+	response, err := h.memberAppService.ResetMFA(uint(parsedID))
+	if err != nil {
+		_ = c.Error(err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "member not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // Create 創建成員
 func (h *MemberHandler) Create(c *gin.Context) {
 	var req dto.MemberCreateRequest
@@ -118,7 +178,11 @@ func (h *MemberHandler) Create(c *gin.Context) {
 
 	response, err := h.memberAppService.Create(&req, memberID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+		status := http.StatusInternalServerError
+		if services.IsPasswordPolicyError(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -160,7 +224,11 @@ func (h *MemberHandler) Update(c *gin.Context) {
 
 	response, err := h.memberAppService.Update(uint(parsedID), &req, memberID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+		status := http.StatusInternalServerError
+		if services.IsPasswordPolicyError(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, dto.APIResponse{
 			Success: false,
 			Error:   err.Error(),
 		})
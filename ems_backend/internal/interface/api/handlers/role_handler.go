@@ -149,11 +149,15 @@ func (h *RoleHandler) AssignPowers(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.roleAppService.GetRolePowerIDs(uint(parsedID))
+
 	response, err := h.roleAppService.AssignPowers(uint(parsedID), &req, memberID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
 		return
 	}
+	after, _ := h.roleAppService.GetRolePowerIDs(uint(parsedID))
+	setAuditChange(c, uint(parsedID), before, after)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -173,11 +177,15 @@ func (h *RoleHandler) RemovePowers(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.roleAppService.GetRolePowerIDs(uint(parsedID))
+
 	response, err := h.roleAppService.RemovePowers(uint(parsedID), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
 		return
 	}
+	after, _ := h.roleAppService.GetRolePowerIDs(uint(parsedID))
+	setAuditChange(c, uint(parsedID), before, after)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -221,11 +229,15 @@ func (h *RoleHandler) AssignMembers(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.roleAppService.GetRoleMembers(uint(parsedID))
+
 	response, err := h.roleAppService.AssignMembers(uint(parsedID), &req, memberID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
 		return
 	}
+	after, _ := h.roleAppService.GetRoleMembers(uint(parsedID))
+	setAuditChange(c, uint(parsedID), before, after)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -245,11 +257,15 @@ func (h *RoleHandler) RemoveMembers(c *gin.Context) {
 		return
 	}
 
+	before, _ := h.roleAppService.GetRoleMembers(uint(parsedID))
+
 	response, err := h.roleAppService.RemoveMembers(uint(parsedID), &req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: err.Error()})
 		return
 	}
+	after, _ := h.roleAppService.GetRoleMembers(uint(parsedID))
+	setAuditChange(c, uint(parsedID), before, after)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -180,7 +180,9 @@ func (h *ScheduleHandler) Sync(c *gin.Context) {
 	}
 
 	// Sync schedule to device via MQTT
-	if err := h.scheduleService.SyncToDevice(uint(companyDeviceID)); err != nil {
+	summary, err := h.scheduleService.SyncToDevice(uint(companyDeviceID))
+	setDeviceCommandAudit(c, summary, err)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -206,7 +208,9 @@ func (h *ScheduleHandler) QueryDeviceInfo(c *gin.Context) {
 	}
 
 	// Send deviceInfo command to device via MQTT
-	if err := h.scheduleService.QueryDeviceInfo(uint(companyDeviceID)); err != nil {
+	summary, err := h.scheduleService.QueryDeviceInfo(uint(companyDeviceID))
+	setDeviceCommandAudit(c, summary, err)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -232,7 +236,9 @@ func (h *ScheduleHandler) QuerySchedule(c *gin.Context) {
 	}
 
 	// Send getSchedule command to device via MQTT
-	if err := h.scheduleService.QueryScheduleFromDevice(uint(companyDeviceID)); err != nil {
+	summary, err := h.scheduleService.QueryScheduleFromDevice(uint(companyDeviceID))
+	setDeviceCommandAudit(c, summary, err)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -242,3 +248,217 @@ func (h *ScheduleHandler) QuerySchedule(c *gin.Context) {
 		"company_device_id": companyDeviceID,
 	})
 }
+
+// GetDrifts - 獲取設備排程漂移紀錄
+// @Summary 獲取設備與雲端排程差異紀錄
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Param limit query int false "筆數上限 (預設 20)"
+// @Success 200 {array} dto.ScheduleDriftResponse
+// @Router /schedules/{id}/drifts [get]
+func (h *ScheduleHandler) GetDrifts(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	drifts, err := h.scheduleService.GetDrifts(uint(companyDeviceID), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, drifts)
+}
+
+// ResolveDrift - 手動解決排程漂移
+// @Summary 解決設備與雲端排程差異
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Param resolution body dto.ResolveScheduleDriftRequest true "cloud_wins 或 device_wins"
+// @Success 200 {object} dto.ScheduleResponse
+// @Router /schedules/{id}/drift/resolve [post]
+func (h *ScheduleHandler) ResolveDrift(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req dto.ResolveScheduleDriftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	memberID, exists := c.Get("member_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	schedule, err := h.scheduleService.ResolveDrift(uint(companyDeviceID), req.Resolution, memberID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// GetVersions - 獲取排程歷史版本列表
+// @Summary 獲取排程歷史版本
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Success 200 {array} dto.ScheduleVersionResponse
+// @Router /schedules/{id}/versions [get]
+func (h *ScheduleHandler) GetVersions(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	versions, err := h.scheduleService.GetVersions(uint(companyDeviceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetVersion - 獲取單一歷史版本
+// @Summary 獲取排程歷史版本詳情 (含完整規則)
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Param version path int true "版本號"
+// @Success 200 {object} dto.ScheduleVersionResponse
+// @Router /schedules/{id}/versions/{version} [get]
+func (h *ScheduleHandler) GetVersion(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+
+	result, err := h.scheduleService.GetVersion(uint(companyDeviceID), version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DiffVersions - 比對兩個歷史版本
+// @Summary 比對排程版本差異
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Param from query int true "起始版本"
+// @Param to query int true "目標版本"
+// @Success 200 {object} dto.ScheduleVersionDiffResponse
+// @Router /schedules/{id}/diff [get]
+func (h *ScheduleHandler) DiffVersions(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from version"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to version"})
+		return
+	}
+
+	diff, err := h.scheduleService.DiffVersions(uint(companyDeviceID), from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreVersion - 還原排程至指定版本
+// @Summary 還原排程版本 (建立新版本)
+// @Tags Schedules
+// @Param id path int true "公司設備 ID"
+// @Param version path int true "要還原的版本號"
+// @Param request body dto.RestoreScheduleVersionRequest false "是否同步到設備"
+// @Success 200 {object} dto.ScheduleResponse
+// @Router /schedules/{id}/versions/{version}/restore [post]
+func (h *ScheduleHandler) RestoreVersion(c *gin.Context) {
+	idStr := c.Param("id")
+	companyDeviceID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+
+	var req dto.RestoreScheduleVersionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	memberID, exists := c.Get("member_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	schedule, err := h.scheduleService.RestoreVersion(uint(companyDeviceID), version, req.Sync, memberID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// setDeviceCommandAudit - 提供 MQTT 命令的目標設備與內容摘要給審計中間件，失敗時附上錯誤訊息
+func setDeviceCommandAudit(c *gin.Context, summary *dto.DeviceCommandSummary, err error) {
+	if summary != nil {
+		c.Set("resource_id", summary.DeviceID)
+		c.Set("audit_details", map[string]interface{}{
+			"company_device_id": summary.CompanyDeviceID,
+			"device_sn":         summary.DeviceSN,
+			"command":           summary.Command,
+			"payload":           summary.Payload,
+		})
+	}
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/application/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountHandler struct {
+	serviceAccountAppService *services.ServiceAccountApplicationService
+}
+
+func NewServiceAccountHandler(serviceAccountAppService *services.ServiceAccountApplicationService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{serviceAccountAppService: serviceAccountAppService}
+}
+
+// GetAll 列出所有服務帳號
+func (h *ServiceAccountHandler) GetAll(c *gin.Context) {
+	response, err := h.serviceAccountAppService.GetAll()
+	h.respond(c, response, err, http.StatusOK)
+}
+
+// Create 建立服務帳號
+func (h *ServiceAccountHandler) Create(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ServiceAccountCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := h.serviceAccountAppService.Create(&req, memberID)
+	h.respond(c, response, err, http.StatusCreated)
+}
+
+// Disable 停用服務帳號並撤銷其所有 API Key
+func (h *ServiceAccountHandler) Disable(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	response, err := h.serviceAccountAppService.Disable(id, memberID)
+	h.respond(c, response, err, http.StatusOK)
+}
+
+// GetKeys 列出服務帳號的 API Key
+func (h *ServiceAccountHandler) GetKeys(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	response, err := h.serviceAccountAppService.GetKeys(id)
+	h.respond(c, response, err, http.StatusOK)
+}
+
+// CreateKey 建立 API Key，明文只會在此回應中出現一次
+func (h *ServiceAccountHandler) CreateKey(c *gin.Context) {
+	memberID, ok := currentMemberID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// 由 RequirePermission 保證存在
+	roleID, ok := c.Get("current_role_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.APIResponse{
+			Success: false,
+			Error:   "no role selected",
+		})
+		return
+	}
+
+	var req dto.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := h.serviceAccountAppService.CreateKey(id, &req, memberID, roleID.(uint))
+	if err == nil && response.Success {
+		key := response.Data.(dto.APIKeyCreatedResponse)
+		c.Set("audit_details", map[string]interface{}{
+			"key_id":  key.ID,
+			"prefix":  key.Prefix,
+			"role_id": key.RoleID,
+		})
+	}
+	h.respond(c, response, err, http.StatusCreated)
+}
+
+// RevokeKey 撤銷 API Key
+func (h *ServiceAccountHandler) RevokeKey(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	keyID, ok := parseUintParam(c, "keyId")
+	if !ok {
+		return
+	}
+
+	response, err := h.serviceAccountAppService.RevokeKey(id, keyID)
+	if err == nil && response.Success {
+		c.Set("audit_details", map[string]interface{}{"key_id": keyID})
+	}
+	h.respond(c, response, err, http.StatusOK)
+}
+
+func (h *ServiceAccountHandler) respond(c *gin.Context, response *dto.APIResponse, err error, successStatus int) {
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !response.Success {
+		_ = c.Error(errors.New(response.Error))
+		status := http.StatusBadRequest
+		if services.IsServiceAccountNotFound(response) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, response)
+		return
+	}
+	c.JSON(successStatus, response)
+}
+
+func parseUintParam(c *gin.Context, name string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{
+			Success: false,
+			Error:   "Invalid ID format",
+		})
+		return 0, false
+	}
+	return uint(value), true
+}
@@ -6,16 +6,46 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// 處理器可自行設定異動前後的資源狀態，未設定時以 RegisterSnapshot 註冊的讀取函數取得
+const (
+	auditBeforeKey = "audit_before"
+	auditAfterKey  = "audit_after"
+)
+
+// SnapshotLoader 讀取資源目前的狀態，找不到時返回 nil 或錯誤
+type SnapshotLoader func(id uint) (interface{}, error)
+
 // AuditMiddleware 審計日誌中間件
 type AuditMiddleware struct {
 	auditLogService *services.AuditLogService
+	snapshots       map[string]SnapshotLoader
 }
 
 // NewAuditMiddleware 創建審計中間件
 func NewAuditMiddleware(auditLogService *services.AuditLogService) *AuditMiddleware {
 	return &AuditMiddleware{
 		auditLogService: auditLogService,
+		snapshots:       make(map[string]SnapshotLoader),
+	}
+}
+
+// RegisterSnapshot 註冊資源類型的讀取函數
+// AuditLogWithResourceID 會在處理前後各讀取一次，將快照與欄位差異記錄在 details 的 before、after、changes
+func (am *AuditMiddleware) RegisterSnapshot(resourceType string, loader SnapshotLoader) {
+	am.snapshots[resourceType] = loader
+}
+
+// loadSnapshot 讀取資源快照，沒有註冊讀取函數或讀取失敗時返回 nil
+func (am *AuditMiddleware) loadSnapshot(resourceType string, id uint) map[string]interface{} {
+	loader, ok := am.snapshots[resourceType]
+	if !ok {
+		return nil
+	}
+	resource, err := loader(id)
+	if err != nil {
+		return nil
 	}
+	return services.Snapshot(resource)
 }
 
 // AuditLog 記錄審計日誌
@@ -76,6 +106,23 @@ func (am *AuditMiddleware) AuditLog(action, resourceType string) gin.HandlerFunc
 		// 添加請求路徑和方法到詳情
 		details["path"] = c.Request.URL.Path
 		details["method"] = c.Request.Method
+		if apiKeyID, exists := c.Get("api_key_id"); exists {
+			details["api_key_id"] = apiKeyID // 服務帳號以 API Key 呼叫
+		}
+
+		// 異動前後的快照與欄位差異 (只記錄成功的異動)
+		if beforeValue, exists := c.Get(auditBeforeKey); exists && status == "SUCCESS" {
+			before := services.Snapshot(beforeValue)
+			var after map[string]interface{}
+			if afterValue, exists := c.Get(auditAfterKey); exists {
+				after = services.Snapshot(afterValue)
+			} else if resourceID != nil {
+				after = am.loadSnapshot(resourceType, *resourceID)
+			}
+			details["before"] = before
+			details["after"] = after
+			details["changes"] = services.DiffSnapshots(before, after)
+		}
 
 		// 記錄審計日誌（異步，不影響響應）
 		go func() {
@@ -118,6 +165,11 @@ func (am *AuditMiddleware) AuditLogWithResourceID(action, resourceType, idParam
 			if _, err := parseUint(id); err == nil {
 				resourceID = uint(parseUintValue(id))
 				c.Set("resource_id", resourceID)
+
+				// 處理前的快照
+				if before := am.loadSnapshot(resourceType, resourceID); before != nil {
+					c.Set(auditBeforeKey, before)
+				}
 			}
 		}
 
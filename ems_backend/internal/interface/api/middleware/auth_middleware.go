@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"ems_backend/internal/application/dto"
+	authEntities "ems_backend/internal/domain/auth/entities"
 	"ems_backend/internal/domain/auth/services"
+	memberRoleEntities "ems_backend/internal/domain/member_role/entities"
 	memberRoleDomainService "ems_backend/internal/domain/member_role/services"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -15,6 +18,12 @@ import (
 
 func AuthMiddleware(authService *services.AuthService, memberRoleDomainService *memberRoleDomainService.MemberRoleService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 服務帳號以 X-API-Key 驗證，優先於 Authorization
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKey, authService, memberRoleDomainService)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -67,6 +76,7 @@ func AuthMiddleware(authService *services.AuthService, memberRoleDomainService *
 
 		// 將會員信息存儲到上下文中（轉換為 uint 以保持一致性）
 		c.Set("member_id", uint(memberIDUint64))
+		c.Set("session_id", claims.SessionID)
 
 		memberRoles, err := memberRoleDomainService.GetByMemberID(uint(memberIDUint64))
 		if err != nil {
@@ -127,6 +137,46 @@ func AuthMiddleware(authService *services.AuthService, memberRoleDomainService *
 	}
 }
 
+// authenticateAPIKey 以 API Key 綁定的角色作為 current_role_id，並只暴露該角色
+// 使 PermissionMiddleware 與公司存取檢查以一般會員的方式運作
+func authenticateAPIKey(c *gin.Context, apiKey string, authService *services.AuthService, memberRoleDomainService *memberRoleDomainService.MemberRoleService) {
+	key, err := authService.AuthenticateAPIKey(apiKey, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, authEntities.ErrAPIKeyInvalid) {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "invalid API key"})
+		} else {
+			c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: "failed to validate API key"})
+		}
+		c.Abort()
+		return
+	}
+
+	memberRoles, err := memberRoleDomainService.GetByMemberID(key.ServiceAccountID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "failed to get member roles"})
+		c.Abort()
+		return
+	}
+	keyRoles := make([]*memberRoleEntities.MemberRole, 0, 1)
+	for _, memberRole := range memberRoles {
+		if memberRole.RoleID == key.RoleID {
+			keyRoles = append(keyRoles, memberRole)
+		}
+	}
+	if len(keyRoles) == 0 {
+		c.JSON(http.StatusForbidden, dto.APIResponse{Success: false, Error: "role not authorized"})
+		c.Abort()
+		return
+	}
+
+	c.Set("member_id", key.ServiceAccountID)
+	c.Set("current_role_id", key.RoleID)
+	c.Set("member_roles", keyRoles)
+	c.Set("role_ids", []uint{key.RoleID})
+	c.Set("api_key_id", key.ID)
+	c.Next()
+}
+
 // SSEAuthMiddleware - SSE/WebSocket 專用驗證中間件
 // 因為 EventSource 和 WebSocket 不支援自定義 header，所以從 query param 取得 token
 func SSEAuthMiddleware(authService *services.AuthService, memberRoleDomainService *memberRoleDomainService.MemberRoleService) gin.HandlerFunc {
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/domain/company/services"
+	companyDeviceRepos "ems_backend/internal/domain/company_device/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompanyContextKey 通過檢查的公司 (*entities.Company) 在上下文中的鍵，處理器可直接取用
+const CompanyContextKey = "company"
+
+// CompanyAccessMiddleware 公司存取檢查中間件
+type CompanyAccessMiddleware struct {
+	accessService     *services.CompanyAccessService
+	companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository
+}
+
+// NewCompanyAccessMiddleware 創建公司存取中間件
+func NewCompanyAccessMiddleware(accessService *services.CompanyAccessService, companyDeviceRepo companyDeviceRepos.CompanyDeviceRepository) *CompanyAccessMiddleware {
+	return &CompanyAccessMiddleware{
+		accessService:     accessService,
+		companyDeviceRepo: companyDeviceRepo,
+	}
+}
+
+// RequireCompanyAccess 載入路徑參數指定的公司，並依目前角色的公司範圍檢查存取權
+// 使用示例: companyGroup.Group("/:id", companyAccessMw.RequireCompanyAccess("id"))
+func (cm *CompanyAccessMiddleware) RequireCompanyAccess(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memberID, exists := c.Get("member_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "unauthorized"})
+			c.Abort()
+			return
+		}
+		currentRoleID, exists := c.Get("current_role_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "no role selected"})
+			c.Abort()
+			return
+		}
+
+		companyID, err := strconv.ParseUint(c.Param(param), 10, 32)
+		if err != nil || companyID == 0 {
+			c.JSON(http.StatusBadRequest, dto.APIResponse{Success: false, Error: "invalid company ID"})
+			c.Abort()
+			return
+		}
+
+		if !cm.authorize(c, memberID.(uint), currentRoleID.(uint), uint(companyID)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCompanyQueryAccess 載入查詢參數指定的公司 (必填)，並依目前角色的公司範圍檢查存取權
+// 使用示例: dashboardGroup.GET("/demand", companyAccessMw.RequireCompanyQueryAccess("company_id"), ...)
+func (cm *CompanyAccessMiddleware) RequireCompanyQueryAccess(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memberID, exists := c.Get("member_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "unauthorized"})
+			c.Abort()
+			return
+		}
+		currentRoleID, exists := c.Get("current_role_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "no role selected"})
+			c.Abort()
+			return
+		}
+
+		companyID, err := strconv.ParseUint(c.Query(key), 10, 32)
+		if err != nil || companyID == 0 {
+			c.JSON(http.StatusBadRequest, dto.APIResponse{Success: false, Error: "invalid company ID"})
+			c.Abort()
+			return
+		}
+
+		if !cm.authorize(c, memberID.(uint), currentRoleID.(uint), uint(companyID)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCompanyDeviceAccess 依路徑參數指定的公司設備 (company_device.id) 找出所屬公司，並檢查存取權
+// 使用示例: scheduleGroup.GET("/:id/versions", companyAccessMw.RequireCompanyDeviceAccess("id"), ...)
+func (cm *CompanyAccessMiddleware) RequireCompanyDeviceAccess(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memberID, exists := c.Get("member_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "unauthorized"})
+			c.Abort()
+			return
+		}
+		currentRoleID, exists := c.Get("current_role_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "no role selected"})
+			c.Abort()
+			return
+		}
+
+		if !cm.authorizeCompanyDevice(c, memberID.(uint), currentRoleID.(uint), c.Param(param)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCompanyDeviceQueryAccess 查詢參數帶有公司設備 ID 時檢查其所屬公司的存取權，未帶參數時直接放行
+// 使用示例: scheduleGroup.GET("", companyAccessMw.RequireCompanyDeviceQueryAccess("company_device_id"), ...)
+func (cm *CompanyAccessMiddleware) RequireCompanyDeviceQueryAccess(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := c.GetQuery(key)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		memberID, exists := c.Get("member_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "unauthorized"})
+			c.Abort()
+			return
+		}
+		currentRoleID, exists := c.Get("current_role_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.APIResponse{Success: false, Error: "no role selected"})
+			c.Abort()
+			return
+		}
+
+		if !cm.authorizeCompanyDevice(c, memberID.(uint), currentRoleID.(uint), raw) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeCompanyDevice 載入公司設備並檢查所屬公司的存取權，失敗時已寫入回應並中止
+func (cm *CompanyAccessMiddleware) authorizeCompanyDevice(c *gin.Context, memberID, roleID uint, raw string) bool {
+	companyDeviceID, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || companyDeviceID == 0 {
+		c.JSON(http.StatusBadRequest, dto.APIResponse{Success: false, Error: "invalid company device ID"})
+		c.Abort()
+		return false
+	}
+
+	// FindByID 以錯誤表示查無資料；不區分不存在與無權限，避免透露其他公司的設備
+	companyDevice, err := cm.companyDeviceRepo.FindByID(uint(companyDeviceID))
+	if err != nil || companyDevice == nil {
+		c.JSON(http.StatusNotFound, dto.APIResponse{Success: false, Error: "company device not found"})
+		c.Abort()
+		return false
+	}
+
+	return cm.authorize(c, memberID, roleID, companyDevice.CompanyID)
+}
+
+// authorize 檢查公司存取權並將公司放入上下文，失敗時已寫入回應並中止
+func (cm *CompanyAccessMiddleware) authorize(c *gin.Context, memberID, roleID, companyID uint) bool {
+	company, err := cm.accessService.Authorize(memberID, roleID, companyID)
+	switch {
+	case errors.Is(err, services.ErrCompanyNotFound):
+		c.JSON(http.StatusNotFound, dto.APIResponse{Success: false, Error: err.Error()})
+		c.Abort()
+		return false
+	case errors.Is(err, services.ErrCompanyAccessDenied):
+		c.JSON(http.StatusForbidden, dto.APIResponse{Success: false, Error: err.Error()})
+		c.Abort()
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, dto.APIResponse{Success: false, Error: "failed to check company access"})
+		c.Abort()
+		return false
+	}
+
+	c.Set(CompanyContextKey, company)
+	return true
+}
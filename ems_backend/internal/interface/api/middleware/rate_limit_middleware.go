@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ems_backend/internal/application/dto"
+	"ems_backend/internal/infrastructure/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware 請求限流中間件
+type RateLimitMiddleware struct {
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimitMiddleware 創建限流中間件，limiter 為 nil 時不限流
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter: limiter,
+	}
+}
+
+// Limit 以路由群組的政策限制每個呼叫者 (API Key、會員或來源 IP)
+// 需放在 AuthMiddleware 之後才能區分會員與 API Key；驗證前的 IP 限流見 LimitIP
+// 使用示例: router.Group("/dashboard", middleware.AuthMiddleware(...), rateLimitMw.Limit("dashboard"))
+func (rm *RateLimitMiddleware) Limit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rm.limiter == nil {
+			c.Next()
+			return
+		}
+
+		principal, id := rateLimitPrincipal(c)
+		decision, ok, err := rm.limiter.Allow(group, principal, id)
+		rm.enforce(c, group, principal, id, decision, ok, err)
+	}
+}
+
+// LimitIP 以來源 IP 限制尚未驗證身分的請求，只套用 <group>:ip 或 default:ip 政策
+// 需放在 AuthMiddleware 之前，無效 token 的大量請求才不會先消耗驗證 (JWT、資料庫查詢) 的成本
+// 使用示例: router.Group("/dashboard", rateLimitMw.LimitIP("dashboard"), middleware.AuthMiddleware(...), rateLimitMw.Limit("dashboard"))
+func (rm *RateLimitMiddleware) LimitIP(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rm.limiter == nil {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		decision, ok, err := rm.limiter.AllowIP(group, ip)
+		rm.enforce(c, group, ratelimit.PrincipalIP, ip, decision, ok, err)
+	}
+}
+
+// enforce 寫入 RateLimit 標頭，超過限制時返回 429
+func (rm *RateLimitMiddleware) enforce(c *gin.Context, group, principal, id string, decision ratelimit.Decision, ok bool, err error) {
+	if err != nil {
+		// 儲存失敗時不阻擋請求
+		log.Printf("[RateLimit] Failed to check %s limit for %s %s: %v", group, principal, id, err)
+		c.Next()
+		return
+	}
+	if !ok {
+		c.Next()
+		return
+	}
+
+	policy := decision.Policy
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, int(policy.Period.Seconds()), policy.Burst))
+	c.Header("RateLimit-Limit", strconv.Itoa(policy.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+
+	if !decision.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, dto.APIResponse{
+			Success: false,
+			Error:   "rate limit exceeded",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// rateLimitPrincipal 依序以 API Key、會員、來源 IP 識別呼叫者
+func rateLimitPrincipal(c *gin.Context) (string, string) {
+	if keyID, exists := c.Get("api_key_id"); exists {
+		return ratelimit.PrincipalAPIKey, fmt.Sprint(keyID)
+	}
+	if memberID, exists := c.Get("member_id"); exists {
+		return ratelimit.PrincipalMember, fmt.Sprint(memberID)
+	}
+	return ratelimit.PrincipalIP, c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
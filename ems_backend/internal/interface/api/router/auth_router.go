@@ -21,20 +21,58 @@ func SetupRoutes(
 	deviceHandler *handlers.DeviceHandler,
 	companyHandler *handlers.CompanyHandler,
 	scheduleHandler *handlers.ScheduleHandler,
+	deviceCommandHandler *handlers.DeviceCommandHandler,
+	comfortControlHandler *handlers.ComfortControlHandler,
+	demandControlHandler *handlers.DemandControlHandler,
+	firmwareHandler *handlers.FirmwareHandler,
 	sseHandler *handlers.SSEHandler,
 	wsHandler *handlers.WebSocketHandler,
+	serviceAccountHandler *handlers.ServiceAccountHandler,
 	authService *services.AuthService,
 	memberRoleDomainService *memberRoleDomainService.MemberRoleService,
 	permissionMw *middleware.PermissionMiddleware,
 	auditMw *middleware.AuditMiddleware,
+	companyAccessMw *middleware.CompanyAccessMiddleware,
+	rateLimitMw *middleware.RateLimitMiddleware,
 ) {
-	authGroup := router.Group("/auth")
+	authGroup := router.Group("/auth", rateLimitMw.Limit("auth"))
 	{
 		authGroup.POST("/login", authHandler.Login)
 		authGroup.POST("/refresh", authHandler.RefreshToken)
 		authGroup.POST("/logout", authHandler.Logout)
+		authGroup.POST("/forgot", authHandler.ForgotPassword)
+		authGroup.POST("/reset", authHandler.ResetPassword)
+		authGroup.GET("/password-policy", authHandler.GetPasswordPolicy) // 密碼政策
+		authGroup.POST("/mfa/verify", authHandler.VerifyMFA)          // 兩階段登入第二步
+		authGroup.POST("/mfa/enroll", authHandler.EnrollMFAChallenge) // 角色要求 MFA 時於登入途中設定
+
+		// OpenID Connect 單一登入 (授權碼 + PKCE)
+		authGroup.GET("/oidc/providers", authHandler.ListOIDCProviders)          // 可用的身分提供者
+		authGroup.GET("/oidc/:provider/authorize", authHandler.AuthorizeOIDC)    // 產生登入網址
+		authGroup.POST("/oidc/:provider/callback", authHandler.OIDCCallback)     // 以授權碼完成登入
+	}
+
+	// 變更自己的密碼 (需目前密碼，成功後撤銷其他會話)
+	router.PUT("/auth/password", rateLimitMw.LimitIP("auth"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("auth"), auditMw.AuditLog("CHANGE_PASSWORD", "MEMBER"), authHandler.ChangePassword)
+
+	// Session API - 登入中的會話 (撤銷後 token 立即失效)
+	sessionGroup := router.Group("/auth/sessions", rateLimitMw.LimitIP("sessions"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("sessions"))
+	{
+		sessionGroup.GET("", authHandler.ListSessions)                                                                                  // 列出會話
+		sessionGroup.DELETE("", auditMw.AuditLog("REVOKE_ALL_SESSIONS", "MEMBER"), authHandler.RevokeAllSessions)                      // 撤銷所有會話
+		sessionGroup.DELETE("/:sessionId", auditMw.AuditLog("REVOKE_SESSION", "MEMBER"), authHandler.RevokeSession)                     // 撤銷單一會話
 	}
-	menuGroup := router.Group("/menu", middleware.AuthMiddleware(authService, memberRoleDomainService))
+
+	// MFA API - 自助設定 TOTP
+	mfaGroup := router.Group("/mfa", rateLimitMw.LimitIP("mfa"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("mfa"))
+	{
+		mfaGroup.GET("", authHandler.MFAStatus)                                                                   // MFA 狀態
+		mfaGroup.POST("/enroll", authHandler.EnrollMFA)                                                           // 產生密鑰與 otpauth URI
+		mfaGroup.POST("/activate", auditMw.AuditLog("ENABLE_MFA", "MEMBER"), authHandler.ActivateMFA)             // 確認並啟用
+		mfaGroup.POST("/recovery-codes", auditMw.AuditLog("REGENERATE_RECOVERY_CODES", "MEMBER"), authHandler.RegenerateRecoveryCodes) // 重新產生復原碼
+		mfaGroup.DELETE("", auditMw.AuditLog("DISABLE_MFA", "MEMBER"), authHandler.DisableMFA)                    // 停用
+	}
+	menuGroup := router.Group("/menu", rateLimitMw.LimitIP("menu"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("menu"))
 	{
 		menuGroup.GET("", menuHandler.GetAll)  // 匹配 /menu
 		menuGroup.GET("/", menuHandler.GetAll) // 匹配 /menu/
@@ -45,18 +83,19 @@ func SetupRoutes(
 	}
 
 	// Dashboard API - 需要認證
-	dashboardGroup := router.Group("/dashboard", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	dashboardGroup := router.Group("/dashboard", rateLimitMw.LimitIP("dashboard"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("dashboard"))
 	{
 		dashboardGroup.GET("/companies", dashboardHandler.GetCompanyList)        // 獲取公司列表（用於下拉選單）
 		dashboardGroup.GET("/company/areas", dashboardHandler.GetAreaList)       // 獲取指定公司的區域列表（用於下拉選單）
 		dashboardGroup.GET("/summary", dashboardHandler.GetDashboardSummary)     // 獲取總覽
 		dashboardGroup.GET("/meters", dashboardHandler.GetMeterData)             // 獲取電表數據
 		dashboardGroup.GET("/temperatures", dashboardHandler.GetTemperatureData) // 獲取溫度數據
-		dashboardGroup.GET("/areas", dashboardHandler.GetAreaOverview)           // 獲取區域完整數據
+		dashboardGroup.GET("/areas", rateLimitMw.Limit("dashboard_areas"), dashboardHandler.GetAreaOverview) // 獲取區域完整數據 (彙總成本高，另有較嚴格的限流)
+		dashboardGroup.GET("/demand", companyAccessMw.RequireCompanyQueryAccess("company_id"), demandControlHandler.GetDashboard)         // 需量總覽與卸載事件
 	}
 
 	// Role API - 角色管理
-	roleGroup := router.Group("/roles", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	roleGroup := router.Group("/roles", rateLimitMw.LimitIP("roles"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("roles"))
 	{
 		roleGroup.GET("", roleHandler.GetAll)                     // 獲取所有角色
 		roleGroup.GET("/:id", roleHandler.GetByID)                // 獲取單個角色
@@ -76,7 +115,7 @@ func SetupRoutes(
 	}
 
 	// Power API - 權限管理
-	powerGroup := router.Group("/powers", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	powerGroup := router.Group("/powers", rateLimitMw.LimitIP("powers"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("powers"))
 	{
 		powerGroup.GET("", powerHandler.GetAll)                                           // 獲取所有權限
 		powerGroup.GET("/:id", powerHandler.GetByID)                                      // 獲取單個權限
@@ -88,26 +127,41 @@ func SetupRoutes(
 	}
 
 	// Audit Log API - 審計日誌查詢
-	auditLogGroup := router.Group("/audit-logs", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	auditLogGroup := router.Group("/audit-logs", rateLimitMw.LimitIP("audit_logs"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("audit_logs"))
 	{
 		auditLogGroup.GET("", auditLogHandler.Query)                              // 查詢審計日誌
+		auditLogGroup.GET("/export", permissionMw.RequirePermission("audit_log:export"), auditMw.AuditLog("EXPORT", "AUDIT_LOG"), auditLogHandler.Export) // 匯出 CSV / JSONL
+		auditLogGroup.GET("/verify", permissionMw.RequirePermission("audit_log:verify"), auditLogHandler.VerifyChain) // 驗證雜湊鏈
 		auditLogGroup.GET("/:id", auditLogHandler.GetByID)                        // 獲取單個日誌
 		auditLogGroup.GET("/member/:memberId", auditLogHandler.GetByMemberID)     // 根據成員ID獲取日誌
 		auditLogGroup.GET("/resource/:resourceType", auditLogHandler.GetByResourceType) // 根據資源類型獲取日誌
 	}
 
 	// Member API - 成員管理
-	memberGroup := router.Group("/members", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	memberGroup := router.Group("/members", rateLimitMw.LimitIP("members"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("members"))
 	{
 		memberGroup.GET("", memberHandler.GetAll)                                                                                                                                   // 獲取所有成員
 		memberGroup.GET("/:id", memberHandler.GetByID)                                                                                                                              // 獲取單個成員
 		memberGroup.POST("", permissionMw.RequirePermission("member:create"), auditMw.AuditLog("CREATE", "MEMBER"), memberHandler.Create)                                           // 創建成員
 		memberGroup.PUT("/:id", permissionMw.RequirePermission("member:update"), auditMw.AuditLogWithResourceID("UPDATE", "MEMBER", "id"), memberHandler.Update)                    // 更新成員
 		memberGroup.PUT("/:id/status", permissionMw.RequirePermission("member:update_status"), auditMw.AuditLogWithResourceID("UPDATE_STATUS", "MEMBER", "id"), memberHandler.UpdateStatus) // 更新成員狀態
+		memberGroup.POST("/:id/unlock", permissionMw.RequirePermission("member:unlock"), auditMw.AuditLogWithResourceID("UNLOCK_ACCOUNT", "MEMBER", "id"), memberHandler.Unlock) // 解除登入鎖定
+		memberGroup.DELETE("/:id/mfa", permissionMw.RequirePermission("member:reset_mfa"), auditMw.AuditLogWithResourceID("RESET_MFA", "MEMBER", "id"), memberHandler.ResetMFA) // 重設 MFA
+	}
+
+	// Service Account API - 服務帳號與 API Key (以 X-API-Key header 呼叫其他 API)
+	serviceAccountGroup := router.Group("/service-accounts", rateLimitMw.LimitIP("service_accounts"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("service_accounts"))
+	{
+		serviceAccountGroup.GET("", permissionMw.RequirePermission("service_account:read"), serviceAccountHandler.GetAll)                                                                                 // 列出服務帳號
+		serviceAccountGroup.POST("", permissionMw.RequirePermission("service_account:manage"), auditMw.AuditLog("CREATE", "SERVICE_ACCOUNT"), serviceAccountHandler.Create)                              // 建立服務帳號
+		serviceAccountGroup.DELETE("/:id", permissionMw.RequirePermission("service_account:manage"), auditMw.AuditLogWithResourceID("DISABLE", "SERVICE_ACCOUNT", "id"), serviceAccountHandler.Disable) // 停用並撤銷所有 Key
+		serviceAccountGroup.GET("/:id/keys", permissionMw.RequirePermission("service_account:read"), serviceAccountHandler.GetKeys)                                                                      // 列出 API Key
+		serviceAccountGroup.POST("/:id/keys", permissionMw.RequirePermission("service_account:manage"), auditMw.AuditLogWithResourceID("CREATE_API_KEY", "SERVICE_ACCOUNT", "id"), serviceAccountHandler.CreateKey)      // 建立 API Key
+		serviceAccountGroup.DELETE("/:id/keys/:keyId", permissionMw.RequirePermission("service_account:manage"), auditMw.AuditLogWithResourceID("REVOKE_API_KEY", "SERVICE_ACCOUNT", "id"), serviceAccountHandler.RevokeKey) // 撤銷 API Key
 	}
 
 	// Device API - 設備管理 (僅限 system 角色)
-	deviceGroup := router.Group("/devices", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	deviceGroup := router.Group("/devices", rateLimitMw.LimitIP("devices"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("devices"))
 	{
 		deviceGroup.GET("", permissionMw.RequirePermission("device:read"), deviceHandler.GetAllDevices)                                                                  // 獲取所有設備
 		deviceGroup.GET("/unassigned", permissionMw.RequirePermission("device:read"), deviceHandler.GetUnassignedDevices)                                                // 獲取未綁定設備
@@ -115,52 +169,101 @@ func SetupRoutes(
 		deviceGroup.POST("", permissionMw.RequirePermission("device:create"), auditMw.AuditLog("CREATE", "DEVICE"), deviceHandler.CreateDevice)                          // 創建設備
 		deviceGroup.PUT("/:id", permissionMw.RequirePermission("device:update"), auditMw.AuditLogWithResourceID("UPDATE", "DEVICE", "id"), deviceHandler.UpdateDevice)   // 更新設備
 		deviceGroup.DELETE("/:id", permissionMw.RequirePermission("device:delete"), auditMw.AuditLogWithResourceID("DELETE", "DEVICE", "id"), deviceHandler.DeleteDevice) // 刪除設備
+		deviceGroup.POST("/import", permissionMw.RequirePermission("device:provision"), auditMw.AuditLog("IMPORT", "DEVICE"), deviceHandler.ImportDevices)                                     // CSV 批次匯入
+		deviceGroup.GET("/:id/claim-codes", permissionMw.RequirePermission("device:provision"), deviceHandler.GetClaimCodes)                                                               // 認領碼紀錄
+		deviceGroup.POST("/:id/claim-codes", permissionMw.RequirePermission("device:provision"), auditMw.AuditLogWithResourceID("ISSUE_CLAIM_CODE", "DEVICE", "id"), deviceHandler.IssueClaimCode) // 簽發認領碼
 	}
 
 	// Company API - 公司管理
-	companyGroup := router.Group("/companies", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	companyGroup := router.Group("/companies", rateLimitMw.LimitIP("companies"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("companies"))
 	{
 		companyGroup.GET("", companyHandler.GetAll)                                                                                                                        // 獲取公司列表（根據角色過濾）
-		companyGroup.GET("/:id", companyHandler.GetByID)                                                                                                                   // 獲取公司詳情
+		companyGroup.GET("/device-content-schema", companyHandler.GetDeviceContentSchema)                                                                                  // 設備內容 JSON Schema
 		companyGroup.POST("", permissionMw.RequirePermission("company:create"), auditMw.AuditLog("CREATE", "COMPANY"), companyHandler.Create)                              // 創建公司（SystemAdmin）
-		companyGroup.PUT("/:id", permissionMw.RequirePermission("company:update"), auditMw.AuditLogWithResourceID("UPDATE", "COMPANY", "id"), companyHandler.Update)       // 更新公司
-		companyGroup.DELETE("/:id", permissionMw.RequirePermission("company:delete"), auditMw.AuditLogWithResourceID("DELETE", "COMPANY", "id"), companyHandler.Delete)    // 刪除公司（SystemAdmin）
-
-		// 公司樹結構
-		companyGroup.GET("/:id/tree", companyHandler.GetTree) // 獲取公司樹結構
-
-		// 創建公司管理員/用戶
-		companyGroup.POST("/:id/manager", permissionMw.RequirePermission("company:create_manager"), auditMw.AuditLog("CREATE_MANAGER", "COMPANY"), companyHandler.CreateManager) // 創建管理員（SystemAdmin）
-		companyGroup.POST("/:id/user", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("CREATE_USER", "COMPANY"), companyHandler.CreateUser)          // 創建用戶
-
-		// 公司成員管理
-		companyGroup.GET("/:id/members", companyHandler.GetMembers)                                                                                                                                       // 獲取公司成員
-		companyGroup.POST("/:id/members", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("ADD_MEMBER", "COMPANY"), companyHandler.AddMember)                                  // 添加成員
-		companyGroup.DELETE("/:id/members/:memberId", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("REMOVE_MEMBER", "COMPANY"), companyHandler.RemoveMember)                // 移除成員
-
-		// 公司設備管理
-		companyGroup.GET("/:id/devices", permissionMw.RequirePermission("company:view_devices"), companyHandler.GetDevices)                                                                               // 獲取公司設備
-		companyGroup.POST("/:id/devices", permissionMw.RequirePermission("company:assign_devices"), auditMw.AuditLog("ASSIGN_DEVICE", "COMPANY"), companyHandler.AssignDevice)                            // 分配設備（SystemAdmin）
-		companyGroup.DELETE("/:id/devices/:deviceId", permissionMw.RequirePermission("company:assign_devices"), auditMw.AuditLog("REMOVE_DEVICE", "COMPANY"), companyHandler.RemoveDevice)                // 移除設備（SystemAdmin）
-		companyGroup.POST("/:id/devices/:deviceId/sync", permissionMw.RequirePermission("schedule:sync"), companyHandler.SyncDeviceSchedule)                                                              // 同步設備排程 (MQTT)
-		companyGroup.POST("/:id/devices/:deviceId/info", permissionMw.RequirePermission("company:view_devices"), companyHandler.QueryDeviceInfo)                                                          // 查詢設備資訊 (MQTT)
+
+		// 以 :id 指定公司的路由，統一依目前角色的公司範圍檢查存取權
+		companyScoped := companyGroup.Group("/:id", companyAccessMw.RequireCompanyAccess("id"))
+		{
+			companyScoped.GET("", companyHandler.GetByID)                                                                                                                   // 獲取公司詳情
+			companyScoped.PUT("", permissionMw.RequirePermission("company:update"), auditMw.AuditLogWithResourceID("UPDATE", "COMPANY", "id"), companyHandler.Update)       // 更新公司
+			companyScoped.DELETE("", permissionMw.RequirePermission("company:delete"), auditMw.AuditLogWithResourceID("DELETE", "COMPANY", "id"), companyHandler.Delete)    // 刪除公司（SystemAdmin）
+
+			// 公司樹結構
+			companyScoped.GET("/tree", companyHandler.GetTree) // 獲取公司樹結構
+
+			// 創建公司管理員/用戶
+			companyScoped.POST("/manager", permissionMw.RequirePermission("company:create_manager"), auditMw.AuditLog("CREATE_MANAGER", "COMPANY"), companyHandler.CreateManager) // 創建管理員（SystemAdmin）
+			companyScoped.POST("/user", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("CREATE_USER", "COMPANY"), companyHandler.CreateUser)          // 創建用戶
+
+			// 公司成員管理
+			companyScoped.GET("/members", companyHandler.GetMembers)                                                                                                                                       // 獲取公司成員
+			companyScoped.POST("/members", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("ADD_MEMBER", "COMPANY"), companyHandler.AddMember)                                  // 添加成員
+			companyScoped.DELETE("/members/:memberId", permissionMw.RequirePermission("company:manage_members"), auditMw.AuditLog("REMOVE_MEMBER", "COMPANY"), companyHandler.RemoveMember)                // 移除成員
+
+			// 公司設備管理
+			companyScoped.GET("/devices", permissionMw.RequirePermission("company:view_devices"), companyHandler.GetDevices)                                                                               // 獲取公司設備
+			companyScoped.POST("/devices", permissionMw.RequirePermission("company:assign_devices"), auditMw.AuditLog("ASSIGN_DEVICE", "COMPANY"), companyHandler.AssignDevice)                            // 分配設備（SystemAdmin）
+			companyScoped.POST("/devices/claim", permissionMw.RequirePermission("company:claim_devices"), auditMw.AuditLog("REDEEM_CLAIM_CODE", "DEVICE"), companyHandler.ClaimDevice)                   // 以認領碼綁定設備
+			companyScoped.DELETE("/devices/:deviceId", permissionMw.RequirePermission("company:assign_devices"), auditMw.AuditLog("REMOVE_DEVICE", "COMPANY"), companyHandler.RemoveDevice)                // 移除設備（SystemAdmin）
+			companyScoped.POST("/devices/:deviceId/sync", permissionMw.RequirePermission("schedule:sync"), auditMw.AuditLog("QUERY_SCHEDULE", "DEVICE"), companyHandler.SyncDeviceSchedule)                                                              // 同步設備排程 (MQTT)
+			companyScoped.POST("/devices/:deviceId/info", permissionMw.RequirePermission("company:view_devices"), auditMw.AuditLog("QUERY_DEVICE_INFO", "DEVICE"), companyHandler.QueryDeviceInfo)                                                          // 查詢設備資訊 (MQTT)
+			companyScoped.PATCH("/devices/:deviceId/content", permissionMw.RequirePermission("device_content:update"), auditMw.AuditLog("UPDATE_DEVICE_CONTENT", "COMPANY"), companyHandler.PatchDeviceContent) // 部分更新設備內容 (JSON Patch + If-Match)
+
+			// 遠端控制命令 (MQTT)
+			companyScoped.GET("/devices/:deviceId/commands", permissionMw.RequirePermission("company:view_devices"), deviceCommandHandler.List)                                                           // 命令紀錄
+			companyScoped.GET("/devices/:deviceId/commands/:commandId", permissionMw.RequirePermission("company:view_devices"), deviceCommandHandler.Get)                                                 // 命令確認狀態
+			companyScoped.POST("/devices/:deviceId/commands/power", permissionMw.RequirePermission("device_control:power"), auditMw.AuditLog("DEVICE_POWER", "DEVICE_COMMAND"), deviceCommandHandler.Power)          // 開關機
+			companyScoped.POST("/devices/:deviceId/commands/mode", permissionMw.RequirePermission("device_control:mode"), auditMw.AuditLog("DEVICE_MODE", "DEVICE_COMMAND"), deviceCommandHandler.Mode)              // 運轉模式
+			companyScoped.POST("/devices/:deviceId/commands/setpoint", permissionMw.RequirePermission("device_control:setpoint"), auditMw.AuditLog("DEVICE_SETPOINT", "DEVICE_COMMAND"), deviceCommandHandler.Setpoint) // 設定溫度
+			companyScoped.GET("/devices/:deviceId/comfort-control", permissionMw.RequirePermission("company:view_devices"), comfortControlHandler.GetSettings)                                                                                // 區域舒適度控制設定
+			companyScoped.PUT("/devices/:deviceId/comfort-control/:areaId", permissionMw.RequirePermission("comfort_control:manage"), auditMw.AuditLog("UPDATE_COMFORT_CONTROL", "COMFORT_CONTROL"), comfortControlHandler.UpdateSetting) // 更新區域舒適度控制
+
+			// 契約容量需量控制
+			companyScoped.GET("/demand-control", permissionMw.RequirePermission("company:view_devices"), demandControlHandler.GetSetting)                                                                    // 獲取需量控制設定
+			companyScoped.PUT("/demand-control", permissionMw.RequirePermission("demand_control:manage"), auditMw.AuditLogWithResourceID("UPDATE_DEMAND_CONTROL", "COMPANY", "id"), demandControlHandler.UpdateSetting) // 設定需量控制
+			companyScoped.GET("/demand-control/events", permissionMw.RequirePermission("company:view_devices"), demandControlHandler.GetEvents)                                                              // 卸載 / 復歸事件
+
+			// 排程漂移策略
+			companyScoped.GET("/schedule-policy", permissionMw.RequirePermission("schedule:read"), companyHandler.GetSchedulePolicy)                                                                      // 獲取排程漂移策略
+			companyScoped.PUT("/schedule-policy", permissionMw.RequirePermission("schedule:sync"), auditMw.AuditLogWithResourceID("UPDATE_SCHEDULE_POLICY", "COMPANY", "id"), companyHandler.UpdateSchedulePolicy) // 設定排程漂移策略
+		}
 	}
 
 	// Schedule API - 排程管理
-	scheduleGroup := router.Group("/schedules", middleware.AuthMiddleware(authService, memberRoleDomainService))
+	scheduleGroup := router.Group("/schedules", rateLimitMw.LimitIP("schedules"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("schedules"))
 	{
-		scheduleGroup.GET("", permissionMw.RequirePermission("schedule:read"), scheduleHandler.GetAll)                                                                                    // 獲取排程列表
-		scheduleGroup.GET("/:id", permissionMw.RequirePermission("schedule:read"), scheduleHandler.GetByID)                                                                               // 獲取單個排程
+		scheduleGroup.GET("", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceQueryAccess("company_device_id"), scheduleHandler.GetAll)                                                                                    // 獲取排程列表
+		scheduleGroup.GET("/:id", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), scheduleHandler.GetByID)                                                                               // 獲取單個排程
 		scheduleGroup.POST("", permissionMw.RequirePermission("schedule:create"), auditMw.AuditLog("CREATE", "SCHEDULE"), scheduleHandler.Create)                                         // 創建排程
-		scheduleGroup.PUT("/:id", permissionMw.RequirePermission("schedule:update"), auditMw.AuditLogWithResourceID("UPDATE", "SCHEDULE", "id"), scheduleHandler.Update)                  // 更新排程
-		scheduleGroup.DELETE("/:id", permissionMw.RequirePermission("schedule:delete"), auditMw.AuditLogWithResourceID("DELETE", "SCHEDULE", "id"), scheduleHandler.Delete)               // 刪除排程
-		scheduleGroup.POST("/:id/sync", permissionMw.RequirePermission("schedule:sync"), scheduleHandler.Sync)                                                                            // 同步排程到設備 (MQTT)
-		scheduleGroup.POST("/:id/query", permissionMw.RequirePermission("schedule:read"), scheduleHandler.QuerySchedule)                                                                  // 從設備獲取排程 (MQTT getSchedule)
+		scheduleGroup.PUT("/:id", permissionMw.RequirePermission("schedule:update"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLogWithResourceID("UPDATE", "SCHEDULE", "id"), scheduleHandler.Update)                  // 更新排程
+		scheduleGroup.DELETE("/:id", permissionMw.RequirePermission("schedule:delete"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLogWithResourceID("DELETE", "SCHEDULE", "id"), scheduleHandler.Delete)               // 刪除排程
+		scheduleGroup.POST("/:id/sync", permissionMw.RequirePermission("schedule:sync"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLog("SYNC_SCHEDULE", "DEVICE"), scheduleHandler.Sync)                                                                            // 同步排程到設備 (MQTT)
+		scheduleGroup.POST("/:id/query", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLog("QUERY_SCHEDULE", "DEVICE"), scheduleHandler.QuerySchedule)                                                                  // 從設備獲取排程 (MQTT getSchedule)
+		scheduleGroup.GET("/:id/drifts", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), scheduleHandler.GetDrifts)                                                                      // 排程漂移紀錄
+		scheduleGroup.POST("/:id/drift/resolve", permissionMw.RequirePermission("schedule:sync"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLogWithResourceID("RESOLVE_DRIFT", "SCHEDULE", "id"), scheduleHandler.ResolveDrift) // 解決排程漂移
+		scheduleGroup.GET("/:id/versions", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), scheduleHandler.GetVersions)                                                                  // 排程歷史版本
+		scheduleGroup.GET("/:id/versions/:version", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), scheduleHandler.GetVersion)                                                          // 排程版本詳情
+		scheduleGroup.GET("/:id/diff", permissionMw.RequirePermission("schedule:read"), companyAccessMw.RequireCompanyDeviceAccess("id"), scheduleHandler.DiffVersions)                                                                     // 比對版本差異 (?from=&to=)
+		scheduleGroup.POST("/:id/versions/:version/restore", permissionMw.RequirePermission("schedule:update"), companyAccessMw.RequireCompanyDeviceAccess("id"), auditMw.AuditLogWithResourceID("RESTORE_VERSION", "SCHEDULE", "id"), scheduleHandler.RestoreVersion) // 還原排程版本
+	}
+
+	// Firmware API - 閘道器韌體盤點與 OTA 分批發布
+	firmwareGroup := router.Group("/firmware", rateLimitMw.LimitIP("firmware"), middleware.AuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("firmware"))
+	{
+		firmwareGroup.GET("", permissionMw.RequirePermission("firmware:read"), firmwareHandler.List)                                                                                          // 韌體目錄
+		firmwareGroup.POST("", permissionMw.RequirePermission("firmware:manage"), auditMw.AuditLog("CREATE", "FIRMWARE"), firmwareHandler.Create)                                            // 新增韌體檔案
+		firmwareGroup.GET("/inventory", permissionMw.RequirePermission("firmware:read"), firmwareHandler.GetInventory)                                                                       // 設備版本盤點
+		firmwareGroup.GET("/campaigns", permissionMw.RequirePermission("firmware:read"), firmwareHandler.ListCampaigns)                                                                      // 發布活動列表
+		firmwareGroup.POST("/campaigns", permissionMw.RequirePermission("firmware:manage"), auditMw.AuditLog("CREATE", "FIRMWARE_CAMPAIGN"), firmwareHandler.CreateCampaign)                 // 建立發布活動
+		firmwareGroup.GET("/campaigns/:id", permissionMw.RequirePermission("firmware:read"), firmwareHandler.GetCampaign)                                                                    // 發布進度
+		firmwareGroup.POST("/campaigns/:id/pause", permissionMw.RequirePermission("firmware:manage"), auditMw.AuditLogWithResourceID("PAUSE", "FIRMWARE_CAMPAIGN", "id"), firmwareHandler.PauseCampaign)    // 暫停
+		firmwareGroup.POST("/campaigns/:id/resume", permissionMw.RequirePermission("firmware:manage"), auditMw.AuditLogWithResourceID("RESUME", "FIRMWARE_CAMPAIGN", "id"), firmwareHandler.ResumeCampaign) // 恢復
+		firmwareGroup.POST("/campaigns/:id/cancel", permissionMw.RequirePermission("firmware:manage"), auditMw.AuditLogWithResourceID("CANCEL", "FIRMWARE_CAMPAIGN", "id"), firmwareHandler.CancelCampaign) // 取消
 	}
 
 	// SSE API - Server-Sent Events for real-time updates
 	// SSE uses token in query param since EventSource doesn't support headers
-	sseGroup := router.Group("/sse", middleware.SSEAuthMiddleware(authService, memberRoleDomainService))
+	sseGroup := router.Group("/sse", rateLimitMw.LimitIP("sse"), middleware.SSEAuthMiddleware(authService, memberRoleDomainService), rateLimitMw.Limit("sse"))
 	{
 		sseGroup.GET("/devices", sseHandler.DeviceUpdates)   // 設備更新即時通知 (MQTT)
 		sseGroup.GET("/dashboard", sseHandler.Dashboard)     // Dashboard 即時更新 (AC 狀態、溫度、電表)